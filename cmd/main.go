@@ -1,18 +1,38 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
+	"time"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/middleware"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/policy"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/boot_resources"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/dns"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/fabrics"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/interfaces"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/ip_ranges"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/pools"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/spaces"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/storage"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/subnets"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/tags"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/vlans"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools/zones"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -34,26 +54,139 @@ func init() {
 	zap.ReplaceGlobals(logger)
 }
 
-func registerTools(mcpServer *server.MCPServer) {
+func registerTools(mcpServer registry.ToolServer, regions *maas_client.Regions, jobManager *jobs.Manager) {
 	registries := []registry.Registry{
-		tools.VMHosts{},
-		tools.Machines{},
-		tools.Events{},
-		tools.Power{},
 		tools.Templates{},
-		tags.Tags{},
-		tags.Tag{},
-		subnets.Subnets{},
-		subnets.Subnet{},
-		fabrics.Fabrics{},
-		fabrics.Fabric{},
-		vlans.Vlans{},
-		vlans.Vlan{},
+		tools.Regions{Regions: regions},
+	}
+	if jobManager != nil {
+		registries = append(registries, tools.Jobs{Manager: jobManager})
+	}
+
+	// The tools that talk to MAAS take a region argument.
+	regionalRegistries := []registry.Registry{
+		tools.VMHosts{Client: regions},
+		tools.Machines{Client: regions, Jobs: jobManager},
+		tools.Events{Client: regions},
+		tools.Power{Client: regions},
+		tools.Provisioning{Client: regions, Jobs: jobManager},
+		tags.Tags{Client: regions},
+		tags.Tag{Client: regions},
+		interfaces.Interfaces{Client: regions},
+		interfaces.Interface{Client: regions},
+		storage.Storage{Client: regions},
+		storage.Partitions{Client: regions},
+		storage.RAIDs{Client: regions},
+		storage.VolumeGroups{Client: regions},
+		subnets.Subnets{Client: regions},
+		subnets.Subnet{Client: regions},
+		ip_ranges.IPRanges{Client: regions},
+		ip_ranges.IPRange{Client: regions},
+		fabrics.Fabrics{Client: regions},
+		fabrics.Fabric{Client: regions},
+		vlans.Vlans{Client: regions},
+		vlans.Vlan{Client: regions},
+		spaces.Spaces{Client: regions},
+		spaces.Space{Client: regions},
+		dns.Domains{Client: regions},
+		dns.Domain{Client: regions},
+		dns.Records{Client: regions},
+		boot_resources.BootResources{Client: regions},
+		zones.Zones{Client: regions},
+		zones.Zone{Client: regions},
+		pools.Pools{Client: regions},
+		pools.Pool{Client: regions},
 	}
 
 	for _, reg := range registries {
 		reg.Register(mcpServer)
 	}
+
+	regionalServer := registry.WithRegions(mcpServer, regions)
+	for _, reg := range regionalRegistries {
+		reg.Register(regionalServer)
+	}
+}
+
+// openJobStore opens the job database at path, or in the per-user state
+// directory when path is empty, creating its directory.
+func openJobStore(path string) (*jobs.Store, error) {
+	if path == "" {
+		stateDir := os.Getenv("XDG_STATE_HOME")
+		if stateDir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			stateDir = filepath.Join(home, ".local", "state")
+		}
+		path = filepath.Join(stateDir, "ztp-mcp", "jobs.db")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return jobs.OpenStore(path)
+}
+
+// loadRegions reads the MAAS regions from regionsFile, or configures a single
+// region from MAAS_BASE_URL and MAAS_API_KEY when it is empty.
+func loadRegions(regionsFile string) (*maas_client.Regions, error) {
+	if regionsFile != "" {
+		return maas_client.LoadRegions(regionsFile)
+	}
+
+	client, err := maas_client.NewMAASClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return maas_client.NewRegions(maas_client.DefaultRegionName, map[string]*maas_client.MAASClient{
+		maas_client.DefaultRegionName: client,
+	})
+}
+
+// listenAndServe serves handler on address, over TLS when a certificate is
+// given. Client certificates signed by clientCAFile are requested and verified
+// when it is set.
+func listenAndServe(address string, handler http.Handler, certFile, keyFile, clientCAFile string) error {
+	if certFile == "" {
+		return http.ListenAndServe(address, handler)
+	}
+
+	httpServer := &http.Server{
+		Addr:      address,
+		Handler:   handler,
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	if clientCAFile != "" {
+		content, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file %s: %w", clientCAFile, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return fmt.Errorf("client CA file %s does not contain any PEM certificate", clientCAFile)
+		}
+
+		httpServer.TLSConfig.ClientCAs = pool
+		httpServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return httpServer.ListenAndServeTLS(certFile, keyFile)
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func main() {
@@ -66,32 +199,165 @@ func main() {
 		version = info.Main.Version
 	}
 
+	if err := godotenv.Load(".env"); err != nil {
+		zap.L().Warn("Failed to load environment variables from .env. Using the envs in environ...")
+	}
+
 	mcpTransportRaw := flag.String("mcp-transport", "stdio", "MCP transport to use when strating the server.")
 	mcpAddressRaw := flag.String("mcp-address", "localhost:8080", "MCP address in the form of <host>:<port> for SSE and HTTP transport modes.")
+	templatesDirRaw := flag.String("templates-dir", os.Getenv("ZTP_TEMPLATES_DIR"), "Directory where templates are persisted, one folder per template. Templates are kept in memory only when empty.")
+	templatesReloadIntervalRaw := flag.Duration("templates-reload-interval", 5*time.Second, "How often the templates directory is checked for changes made on disk. 0 turns the checks off.")
+	authAPIKeysRaw := flag.String("auth-api-keys", os.Getenv("ZTP_AUTH_API_KEYS"), "Comma separated list of accepted bearer tokens, optionally named as <name>:<key>. Escape colons in names as \\:.")
+	authTokenHashesRaw := flag.String("auth-token-hashes", os.Getenv("ZTP_AUTH_TOKEN_HASHES"), "Comma separated list of hex encoded SHA-256 digests of accepted bearer tokens, optionally named as <name>:<hash>. Escape colons in names as \\:.")
+	authJWKSFileRaw := flag.String("auth-jwks-file", os.Getenv("ZTP_AUTH_JWKS_FILE"), "Path to a JWKS file used to validate JWT bearer tokens.")
+	authJWTIssuerRaw := flag.String("auth-jwt-issuer", os.Getenv("ZTP_AUTH_JWT_ISSUER"), "Expected iss claim of JWT bearer tokens.")
+	authJWTAudienceRaw := flag.String("auth-jwt-audience", os.Getenv("ZTP_AUTH_JWT_AUDIENCE"), "Expected aud claim of JWT bearer tokens.")
+	authDisabledRaw := flag.Bool("auth-disabled", os.Getenv("ZTP_AUTH_DISABLED") == "true", "Serve the SSE and HTTP transports without authentication.")
+	logRequestBodiesRaw := flag.Bool("log-request-bodies", false, "Log the body of every HTTP request. Bodies can contain template secrets and BMC credentials.")
+	tlsCertRaw := flag.String("tls-cert", os.Getenv("ZTP_TLS_CERT"), "Path to the PEM certificate used to serve the SSE and HTTP transports over TLS.")
+	tlsKeyRaw := flag.String("tls-key", os.Getenv("ZTP_TLS_KEY"), "Path to the PEM private key of -tls-cert.")
+	tlsClientCARaw := flag.String("tls-client-ca", os.Getenv("ZTP_TLS_CLIENT_CA"), "Path to the PEM CA bundle used to verify client certificates. Verified clients are identified as cert:<common name>.")
+	policyFileRaw := flag.String("policy-file", os.Getenv("ZTP_POLICY_FILE"), "Path to the YAML policy deciding which tools each caller may use. Every caller may use every tool when empty.")
+	readOnlyRaw := flag.Bool("read-only", os.Getenv("ZTP_READ_ONLY") == "true", "Only register the tools annotated as read-only.")
+	maasRegionsFileRaw := flag.String("maas-regions-file", os.Getenv("ZTP_MAAS_REGIONS_FILE"), "Path to the YAML file defining the MAAS regions. A single region is configured from MAAS_BASE_URL and MAAS_API_KEY when empty.")
+	asyncJobsRaw := flag.Bool("async-jobs", os.Getenv("ZTP_ASYNC_JOBS") == "true", "Run the long-running tools as background jobs, stored in -jobs-db and resumed at startup.")
+	jobsDBRaw := flag.String("jobs-db", os.Getenv("ZTP_JOBS_DB"), "Path to the database where the asynchronous jobs are stored. Enables -async-jobs when set. Default: ztp-mcp/jobs.db in $XDG_STATE_HOME or ~/.local/state.")
+	dryRunRaw := flag.Bool("dry-run", os.Getenv("ZTP_DRY_RUN") == "true", "Return the requests that would change MAAS instead of sending them.")
 	flag.Parse()
 
 	mcpTransport := *mcpTransportRaw
 	mcpAddress := *mcpAddressRaw
+	templatesDir := *templatesDirRaw
+	templatesReloadInterval := *templatesReloadIntervalRaw
+	authDisabled := *authDisabledRaw
+	tlsCert := *tlsCertRaw
+	tlsKey := *tlsKeyRaw
+	tlsClientCA := *tlsClientCARaw
+	policyFile := *policyFileRaw
+	readOnly := *readOnlyRaw
+	dryRun := *dryRunRaw
+	maasRegionsFile := *maasRegionsFileRaw
+	asyncJobs := *asyncJobsRaw || *jobsDBRaw != ""
+	jobsDB := *jobsDBRaw
 
-	mcpServer := server.NewMCPServer(
-		"Zero-Touch Provisioning MPC Server",
-		version,
+	if templatesReloadInterval < 0 {
+		zap.L().Fatal(fmt.Sprintf("-templates-reload-interval must not be negative, got %s.", templatesReloadInterval))
+	}
+
+	if tlsClientCA != "" && tlsCert == "" {
+		zap.L().Fatal("-tls-client-ca requires -tls-cert and -tls-key.")
+	}
+
+	authenticator, err := middleware.NewAuthenticator(middleware.AuthConfig{
+		APIKeys:            splitList(*authAPIKeysRaw),
+		TokenHashes:        splitList(*authTokenHashesRaw),
+		JWKSFile:           *authJWKSFileRaw,
+		JWTIssuer:          *authJWTIssuerRaw,
+		JWTAudience:        *authJWTAudienceRaw,
+		ClientCertificates: tlsClientCA != "",
+		LogBodies:          *logRequestBodiesRaw,
+	})
+	if err != nil {
+		zap.L().Fatal(fmt.Sprintf("Failed to configure authentication: %v", err))
+	}
+
+	if mcpTransport != "stdio" && mcpTransport != "STDIO" && !authenticator.Enabled() && !authDisabled {
+		zap.L().Fatal("No credentials configured for the SSE/HTTP transport. Set -auth-api-keys, -auth-token-hashes, -auth-jwks-file or -tls-client-ca, or pass -auth-disabled to run without authentication.")
+	}
+
+	regions, err := loadRegions(maasRegionsFile)
+	if err != nil {
+		zap.L().Fatal(fmt.Sprintf("Failed to configure the MAAS regions: %v", err))
+	}
+
+	serverOptions := []server.ServerOption{
 		server.WithInstructions("This server is used to communicate with the ZTP agent in order to deploy, interact and retrieve the status of machines inside an Ubuntu MAAS instance."),
 		server.WithToolCapabilities(true),
 		server.WithResourceCapabilities(true, true),
+	}
+
+	if dryRun {
+		zap.L().Info("Dry-run mode enabled, requests changing MAAS will not be sent.")
+		serverOptions = append(serverOptions, server.WithToolHandlerMiddleware(middleware.DryRun))
+	}
+
+	var toolPolicy *policy.Policy
+	if policyFile != "" {
+		toolPolicy, err = policy.Load(policyFile)
+		if err != nil {
+			zap.L().Fatal(fmt.Sprintf("Failed to load the policy: %v", err))
+		}
+
+		serverOptions = append(serverOptions, server.WithToolFilter(toolPolicy.Filter))
+	}
+
+	mcpServer := server.NewMCPServer(
+		"Zero-Touch Provisioning MPC Server",
+		version,
+		serverOptions...,
 	)
 
-	if err := godotenv.Load(".env"); err != nil {
-		zap.L().Warn("Failed to load environment variables from .env. Using the envs in environ...")
+	if templatesDir != "" {
+		templateStore, err := templates.InitTemplateStore(templatesDir)
+		if err != nil {
+			zap.L().Fatal(fmt.Sprintf("Failed to load the templates from %s: %v", templatesDir, err))
+		}
+
+		if templatesReloadInterval > 0 {
+			go templateStore.Watch(context.Background(), templatesReloadInterval)
+		} else {
+			zap.L().Info(fmt.Sprintf("Template reloading disabled, changes made on disk to %s are not picked up until a restart.", templatesDir))
+		}
 	}
 
-	registerTools(mcpServer)
+	var toolServer registry.ToolServer = mcpServer
+	if toolPolicy != nil {
+		toolServer = toolPolicy.Guard(toolServer)
+	}
+	if readOnly {
+		zap.L().Info("Read-only mode enabled, only read-only tools are registered.")
+		toolServer = registry.ReadOnly(toolServer)
+	}
+
+	var jobManager *jobs.Manager
+	if asyncJobs {
+		jobStore, err := openJobStore(jobsDB)
+		if err != nil {
+			zap.L().Fatal(fmt.Sprintf("Failed to open the job database: %v", err))
+		}
+		defer jobStore.Close()
+		jobManager = jobs.NewManager(jobStore)
+	}
+
+	registerTools(toolServer, regions, jobManager)
+
+	if jobManager != nil {
+		// Jobs run without a caller, so the restrictions their tool call went
+		// through are checked again before they are resumed.
+		resumable := func(job jobs.Job) error {
+			switch {
+			case readOnly:
+				return errors.New("the server runs in read-only mode")
+			case dryRun:
+				return errors.New("the server runs in dry-run mode")
+			case toolPolicy != nil && !toolPolicy.AllowedName(job.Caller, job.Kind):
+				return fmt.Errorf("caller %s is not allowed to use the tool %s", job.Caller, job.Kind)
+			}
+			return nil
+		}
+
+		if err := jobManager.Resume(resumable); err != nil {
+			zap.L().Error(fmt.Sprintf("Failed to resume the unfinished jobs: %v", err))
+		}
+	}
 
 	switch mcpTransport {
 	case "SSE", "sse":
 		zap.L().Info("Starting MCP server in SSE mode...")
 		sseServer := server.NewSSEServer(mcpServer)
-		if err := sseServer.Start(mcpAddress); err != nil {
+		handler := middleware.Logging(middleware.Auth(authenticator, sseServer))
+
+		if err := listenAndServe(mcpAddress, handler, tlsCert, tlsKey, tlsClientCA); err != nil {
 			zap.L().Fatal(err.Error())
 		}
 	case "HTTP", "http":
@@ -100,9 +366,9 @@ func main() {
 		mux := http.NewServeMux()
 
 		mux.Handle("/mcp", server.NewStreamableHTTPServer(mcpServer))
-		handler := middleware.Logging(middleware.Auth(mux))
+		handler := middleware.Logging(middleware.Auth(authenticator, mux))
 
-		if err := http.ListenAndServe(mcpAddress, handler); err != nil {
+		if err := listenAndServe(mcpAddress, handler, tlsCert, tlsKey, tlsClientCA); err != nil {
 			zap.L().Fatal(err.Error())
 		}
 	case "STDIO", "stdio":
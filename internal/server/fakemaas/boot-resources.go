@@ -0,0 +1,316 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// BootResource is an image imported in the fake, like ubuntu/noble.
+type BootResource struct {
+	ID           int
+	Type         string
+	Name         string
+	Architecture string
+	Subarches    string
+	// Version is the version of the only set of the image, and Progress the
+	// percentage of it that was downloaded.
+	Version  string
+	Progress float64
+}
+
+// BootSource is a mirror images are imported from.
+type BootSource struct {
+	ID  int
+	URL string
+}
+
+// BootSourceSelection selects a release to import from a boot source.
+type BootSourceSelection struct {
+	ID           int
+	BootSourceID int
+	OS           string
+	Release      string
+	Arches       []string
+	Subarches    []string
+	Labels       []string
+}
+
+// AddBootResource adds an image and returns it. The type defaults to Synced,
+// the architecture to amd64/generic, and the image is fully downloaded.
+func (s *Server) AddBootResource(resource BootResource) BootResource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resource.Progress == 0 {
+		resource.Progress = 100
+	}
+	return *s.addBootResource(resource)
+}
+
+func (s *Server) addBootResource(resource BootResource) *BootResource {
+	if resource.ID == 0 {
+		resource.ID = s.newID()
+	}
+	resource.Type = valueOr(resource.Type, "Synced")
+	resource.Architecture = valueOr(resource.Architecture, "amd64/generic")
+	resource.Version = valueOr(resource.Version, "20240101")
+
+	stored := resource
+	s.bootResources = append(s.bootResources, &stored)
+	return &stored
+}
+
+// BootResources returns a copy of the images.
+func (s *Server) BootResources() []BootResource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resources := make([]BootResource, 0, len(s.bootResources))
+	for _, resource := range s.bootResources {
+		resources = append(resources, *resource)
+	}
+	return resources
+}
+
+// AddBootSource adds a boot source and returns it.
+func (s *Server) AddBootSource(source BootSource) BootSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if source.ID == 0 {
+		source.ID = s.newID()
+	}
+	source.URL = valueOr(source.URL, "http://images.maas.io/ephemeral-v3/stable/")
+
+	stored := source
+	s.bootSources = append(s.bootSources, &stored)
+	return stored
+}
+
+// AddBootSourceSelection adds a selection to a boot source and returns it.
+func (s *Server) AddBootSourceSelection(selection BootSourceSelection) BootSourceSelection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.addBootSourceSelection(selection)
+}
+
+func (s *Server) addBootSourceSelection(selection BootSourceSelection) *BootSourceSelection {
+	if selection.ID == 0 {
+		selection.ID = s.newID()
+	}
+	if len(selection.Arches) == 0 {
+		selection.Arches = []string{"amd64"}
+	}
+	if len(selection.Subarches) == 0 {
+		selection.Subarches = []string{"*"}
+	}
+	if len(selection.Labels) == 0 {
+		selection.Labels = []string{"*"}
+	}
+
+	stored := selection
+	s.bootSourceSelections = append(s.bootSourceSelections, &stored)
+	return &stored
+}
+
+// BootSourceSelections returns a copy of the selections.
+func (s *Server) BootSourceSelections() []BootSourceSelection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	selections := make([]BootSourceSelection, 0, len(s.bootSourceSelections))
+	for _, selection := range s.bootSourceSelections {
+		selections = append(selections, *selection)
+	}
+	return selections
+}
+
+func renderBootResource(resource *BootResource, withSets bool) map[string]any {
+	rendered := map[string]any{
+		"id":           resource.ID,
+		"type":         resource.Type,
+		"name":         resource.Name,
+		"architecture": resource.Architecture,
+		"subarches":    resource.Subarches,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/boot-resources/%d/", resource.ID),
+	}
+	if withSets {
+		rendered["sets"] = map[string]any{
+			resource.Version: map[string]any{
+				"version":  resource.Version,
+				"label":    "stable",
+				"size":     300 * 1000 * 1000,
+				"complete": resource.Progress >= 100,
+				"progress": resource.Progress,
+			},
+		}
+	}
+	return rendered
+}
+
+func renderBootSourceSelection(selection *BootSourceSelection) map[string]any {
+	return map[string]any{
+		"id":             selection.ID,
+		"boot_source_id": selection.BootSourceID,
+		"os":             selection.OS,
+		"release":        selection.Release,
+		"arches":         selection.Arches,
+		"subarches":      selection.Subarches,
+		"labels":         selection.Labels,
+		"resource_uri":   fmt.Sprintf("/MAAS/api/2.0/boot-sources/%d/selections/%d/", selection.BootSourceID, selection.ID),
+	}
+}
+
+func (s *Server) handleBootResources(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch {
+		case req.method == http.MethodGet && req.op == "":
+			resources := []map[string]any{}
+			for _, resource := range s.bootResources {
+				resources = append(resources, renderBootResource(resource, false))
+			}
+			return resources, nil
+		case req.method == http.MethodGet && req.op == "is_importing":
+			s.readImport()
+			return s.importing, nil
+		case req.method == http.MethodPost && req.op == "import":
+			s.startImport()
+			return rawResponse{contentType: "text/plain", body: []byte("Import of boot resources started")}, nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+	if req.method != http.MethodGet {
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+	for _, resource := range s.bootResources {
+		if resource.ID == id {
+			s.readImport()
+			return renderBootResource(resource, true), nil
+		}
+	}
+	return nil, notFound()
+}
+
+// startImport adds the images selected on the boot sources that are missing,
+// not downloaded yet. They are downloaded after TransitionReads reads of the
+// import status or of the images.
+func (s *Server) startImport() {
+	for _, selection := range s.bootSourceSelections {
+		name := selection.OS + "/" + selection.Release
+		for _, arch := range selection.Arches {
+			exists := slices.ContainsFunc(s.bootResources, func(resource *BootResource) bool {
+				return resource.Name == name && strings.HasPrefix(resource.Architecture, arch+"/")
+			})
+			if !exists {
+				s.addBootResource(BootResource{Name: name, Architecture: arch + "/generic", Subarches: "generic"})
+			}
+		}
+	}
+
+	s.importing = true
+	s.importReads = s.TransitionReads
+	if s.importReads <= 0 {
+		s.finishImport()
+	}
+}
+
+func (s *Server) readImport() {
+	if !s.importing {
+		return
+	}
+
+	s.importReads--
+	if s.importReads < 0 {
+		s.finishImport()
+	}
+}
+
+func (s *Server) finishImport() {
+	for _, resource := range s.bootResources {
+		resource.Progress = 100
+	}
+	s.importing = false
+}
+
+func (s *Server) handleBootSources(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		if req.method != http.MethodGet {
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+		sources := []map[string]any{}
+		for _, source := range s.bootSources {
+			sources = append(sources, renderBootSource(source))
+		}
+		return sources, nil
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, notFound()
+	}
+	source := s.findBootSource(id)
+	if source == nil || len(rest) > 2 || (len(rest) == 2 && rest[1] != "selections") {
+		return nil, notFound()
+	}
+
+	switch {
+	case len(rest) == 1 && req.method == http.MethodGet:
+		return renderBootSource(source), nil
+	case len(rest) == 2 && req.method == http.MethodGet:
+		selections := []map[string]any{}
+		for _, selection := range s.bootSourceSelections {
+			if selection.BootSourceID == source.ID {
+				selections = append(selections, renderBootSourceSelection(selection))
+			}
+		}
+		return selections, nil
+	case len(rest) == 2 && req.method == http.MethodPost:
+		osystem, release := req.form.Get("os"), req.form.Get("release")
+		if osystem == "" || release == "" {
+			return nil, badRequest(`{"os": ["This field is required."], "release": ["This field is required."]}`)
+		}
+		for _, selection := range s.bootSourceSelections {
+			if selection.BootSourceID == source.ID && selection.OS == osystem && selection.Release == release {
+				return nil, badRequest(`{"__all__": ["Boot source selection with this Boot source, Os and Release already exists."]}`)
+			}
+		}
+		selection := s.addBootSourceSelection(BootSourceSelection{
+			BootSourceID: source.ID,
+			OS:           osystem,
+			Release:      release,
+			Arches:       req.form["arches"],
+			Subarches:    req.form["subarches"],
+			Labels:       req.form["labels"],
+		})
+		return renderBootSourceSelection(selection), nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) findBootSource(id int) *BootSource {
+	for _, source := range s.bootSources {
+		if source.ID == id {
+			return source
+		}
+	}
+	return nil
+}
+
+func renderBootSource(source *BootSource) map[string]any {
+	return map[string]any{
+		"id":               source.ID,
+		"url":              source.URL,
+		"keyring_filename": "/usr/share/keyrings/ubuntu-cloudimage-keyring.gpg",
+		"created":          timestamp(),
+		"updated":          timestamp(),
+		"resource_uri":     fmt.Sprintf("/MAAS/api/2.0/boot-sources/%d/", source.ID),
+	}
+}
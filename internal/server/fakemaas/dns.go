@@ -0,0 +1,425 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Domain is a DNS domain known to the fake.
+type Domain struct {
+	ID            int
+	Name          string
+	TTL           int
+	Authoritative bool
+}
+
+// DNSResource is a name in a domain with its addresses and records.
+type DNSResource struct {
+	ID          int
+	Name        string
+	Domain      string
+	AddressTTL  int
+	IPAddresses []string
+	Records     []DNSRecord
+}
+
+// DNSRecord is a resource record other than A and AAAA.
+type DNSRecord struct {
+	ID     int
+	RRType string
+	RRData string
+	TTL    int
+}
+
+// AddDomain adds a domain and returns it.
+func (s *Server) AddDomain(domain Domain) Domain {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if domain.ID == 0 {
+		domain.ID = s.newID()
+	}
+	stored := domain
+	s.domains = append(s.domains, &stored)
+	return stored
+}
+
+// AddDNSResource adds a DNS resource and returns it.
+func (s *Server) AddDNSResource(resource DNSResource) DNSResource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resource.ID == 0 {
+		resource.ID = s.newID()
+	}
+	resource.Records = slices.Clone(resource.Records)
+	for i := range resource.Records {
+		if resource.Records[i].ID == 0 {
+			resource.Records[i].ID = s.newID()
+		}
+	}
+	stored := resource
+	s.dnsResources = append(s.dnsResources, &stored)
+	return stored
+}
+
+// Domains returns a copy of the domains.
+func (s *Server) Domains() []Domain {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domains := make([]Domain, 0, len(s.domains))
+	for _, domain := range s.domains {
+		domains = append(domains, *domain)
+	}
+	return domains
+}
+
+// DNSResources returns a copy of the DNS resources.
+func (s *Server) DNSResources() []DNSResource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resources := make([]DNSResource, 0, len(s.dnsResources))
+	for _, resource := range s.dnsResources {
+		stored := *resource
+		stored.IPAddresses = slices.Clone(resource.IPAddresses)
+		stored.Records = slices.Clone(resource.Records)
+		resources = append(resources, stored)
+	}
+	return resources
+}
+
+func (s *Server) findDomain(name string) *Domain {
+	for _, domain := range s.domains {
+		if domain.Name == name {
+			return domain
+		}
+	}
+	return nil
+}
+
+func (s *Server) findDNSResource(name, domain string) *DNSResource {
+	for _, resource := range s.dnsResources {
+		if resource.Name == name && resource.Domain == domain {
+			return resource
+		}
+	}
+	return nil
+}
+
+// splitFQDN returns the name and the domain of the fqdn form value, or of
+// the name and domain values.
+func (s *Server) splitFQDN(req request) (string, string, error) {
+	name, domain := req.form.Get("name"), req.form.Get("domain")
+	if fqdn := strings.TrimSuffix(req.form.Get("fqdn"), "."); fqdn != "" {
+		// The name may hold dots itself, like _http._tcp, so the domain is
+		// the longest known suffix.
+		name, domain = fqdn, ""
+		for _, known := range s.domains {
+			if prefix, ok := strings.CutSuffix(fqdn, "."+known.Name); ok && len(known.Name) > len(domain) {
+				name, domain = prefix, known.Name
+			}
+		}
+	}
+	if name == "" {
+		return "", "", badRequest(`{"fqdn": ["This field is required."]}`)
+	}
+	if s.findDomain(domain) == nil {
+		return "", "", badRequest(`{"domain": ["Select a valid choice."]}`)
+	}
+	return name, domain, nil
+}
+
+func (s *Server) renderDomain(domain *Domain) map[string]any {
+	count := 0
+	for _, resource := range s.dnsResources {
+		if resource.Domain == domain.Name {
+			count += len(resource.IPAddresses) + len(resource.Records)
+		}
+	}
+
+	var ttl any
+	if domain.TTL != 0 {
+		ttl = domain.TTL
+	}
+
+	return map[string]any{
+		"id":                    domain.ID,
+		"name":                  domain.Name,
+		"ttl":                   ttl,
+		"authoritative":         domain.Authoritative,
+		"resource_record_count": count,
+		"is_default":            domain == s.domains[0],
+		"resource_uri":          fmt.Sprintf("/MAAS/api/2.0/domains/%d/", domain.ID),
+	}
+}
+
+func renderDNSRecord(resource *DNSResource, record DNSRecord) map[string]any {
+	var ttl any
+	if record.TTL != 0 {
+		ttl = record.TTL
+	}
+
+	return map[string]any{
+		"id":           record.ID,
+		"fqdn":         resource.Name + "." + resource.Domain,
+		"ttl":          ttl,
+		"rrtype":       record.RRType,
+		"rrdata":       record.RRData,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/dnsresourcerecords/%d/", record.ID),
+	}
+}
+
+func renderDNSResource(resource *DNSResource) map[string]any {
+	addresses := []map[string]any{}
+	for _, ip := range resource.IPAddresses {
+		addresses = append(addresses, map[string]any{"ip": ip})
+	}
+	records := []map[string]any{}
+	for _, record := range resource.Records {
+		records = append(records, renderDNSRecord(resource, record))
+	}
+
+	var ttl any
+	if resource.AddressTTL != 0 {
+		ttl = resource.AddressTTL
+	}
+
+	return map[string]any{
+		"id":               resource.ID,
+		"fqdn":             resource.Name + "." + resource.Domain,
+		"address_ttl":      ttl,
+		"ip_addresses":     addresses,
+		"resource_records": records,
+		"resource_uri":     fmt.Sprintf("/MAAS/api/2.0/dnsresources/%d/", resource.ID),
+	}
+}
+
+// applyDNSResourceForm sets the addresses and the TTL of a DNS resource.
+func applyDNSResourceForm(resource *DNSResource, req request) error {
+	if req.form.Has("ip_addresses") {
+		addresses := strings.Fields(req.form.Get("ip_addresses"))
+		for _, address := range addresses {
+			if _, err := netip.ParseAddr(address); err != nil {
+				return badRequest(`{"ip_addresses": ["%s is not a valid IP address."]}`, address)
+			}
+		}
+		resource.IPAddresses = addresses
+	}
+	if ttl, ok, err := formInt(req.form, "address_ttl"); err != nil {
+		return err
+	} else if ok {
+		resource.AddressTTL = ttl
+	}
+	return nil
+}
+
+func (s *Server) handleDomains(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			domains := []map[string]any{}
+			for _, domain := range s.domains {
+				domains = append(domains, s.renderDomain(domain))
+			}
+			return domains, nil
+		case http.MethodPost:
+			name := req.form.Get("name")
+			if name == "" {
+				return nil, badRequest(`{"name": ["This field is required."]}`)
+			}
+			if s.findDomain(name) != nil {
+				return nil, badRequest(`{"name": ["Domain with this Name already exists."]}`)
+			}
+			domain := &Domain{ID: s.newID(), Name: name, Authoritative: true}
+			if authoritative, ok := formBool(req.form, "authoritative"); ok {
+				domain.Authoritative = authoritative
+			}
+			if ttl, ok, err := formInt(req.form, "ttl"); err != nil {
+				return nil, err
+			} else if ok {
+				domain.TTL = ttl
+			}
+			s.domains = append(s.domains, domain)
+			return s.renderDomain(domain), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(s.domains, func(domain *Domain) bool { return domain.ID == id })
+	if index < 0 || len(rest) > 1 {
+		return nil, notFound()
+	}
+	domain := s.domains[index]
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderDomain(domain), nil
+	case http.MethodPut:
+		if name := req.form.Get("name"); name != "" && name != domain.Name {
+			if s.findDomain(name) != nil {
+				return nil, badRequest(`{"name": ["Domain with this Name already exists."]}`)
+			}
+			for _, resource := range s.dnsResources {
+				if resource.Domain == domain.Name {
+					resource.Domain = name
+				}
+			}
+			domain.Name = name
+		}
+		if authoritative, ok := formBool(req.form, "authoritative"); ok {
+			domain.Authoritative = authoritative
+		}
+		if ttl, ok, err := formInt(req.form, "ttl"); err != nil {
+			return nil, err
+		} else if ok {
+			domain.TTL = ttl
+		}
+		return s.renderDomain(domain), nil
+	case http.MethodDelete:
+		if index == 0 {
+			return nil, badRequest("This domain is the default domain, it cannot be deleted.")
+		}
+		if slices.ContainsFunc(s.dnsResources, func(resource *DNSResource) bool { return resource.Domain == domain.Name }) {
+			return nil, badRequest("Cannot delete domain %s: it contains DNS resources.", domain.Name)
+		}
+		s.domains = slices.Delete(s.domains, index, index+1)
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) handleDNSResources(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			resources := []map[string]any{}
+			for _, resource := range s.dnsResources {
+				if domain := req.query.Get("domain"); domain == "" || domain == resource.Domain {
+					resources = append(resources, renderDNSResource(resource))
+				}
+			}
+			return resources, nil
+		case http.MethodPost:
+			name, domain, err := s.splitFQDN(req)
+			if err != nil {
+				return nil, err
+			}
+			if s.findDNSResource(name, domain) != nil {
+				return nil, badRequest(`{"__all__": ["Labels are already in use in this domain."]}`)
+			}
+			resource := &DNSResource{Name: name, Domain: domain}
+			if err := applyDNSResourceForm(resource, req); err != nil {
+				return nil, err
+			}
+			resource.ID = s.newID()
+			s.dnsResources = append(s.dnsResources, resource)
+			return renderDNSResource(resource), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(s.dnsResources, func(resource *DNSResource) bool { return resource.ID == id })
+	if index < 0 || len(rest) > 1 {
+		return nil, notFound()
+	}
+	resource := s.dnsResources[index]
+
+	switch req.method {
+	case http.MethodGet:
+		return renderDNSResource(resource), nil
+	case http.MethodPut:
+		if err := applyDNSResourceForm(resource, req); err != nil {
+			return nil, err
+		}
+		return renderDNSResource(resource), nil
+	case http.MethodDelete:
+		s.dnsResources = slices.Delete(s.dnsResources, index, index+1)
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) handleDNSResourceRecords(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			records := []map[string]any{}
+			for _, resource := range s.dnsResources {
+				for _, record := range resource.Records {
+					records = append(records, renderDNSRecord(resource, record))
+				}
+			}
+			return records, nil
+		case http.MethodPost:
+			name, domain, err := s.splitFQDN(req)
+			if err != nil {
+				return nil, err
+			}
+			record := DNSRecord{RRType: strings.ToUpper(req.form.Get("rrtype")), RRData: req.form.Get("rrdata")}
+			if !slices.Contains([]string{"CNAME", "TXT", "SRV", "MX", "NS", "SSHFP"}, record.RRType) {
+				return nil, badRequest(`{"rrtype": ["%s is not a valid resource record type."]}`, record.RRType)
+			}
+			if record.RRData == "" {
+				return nil, badRequest(`{"rrdata": ["This field is required."]}`)
+			}
+			if ttl, ok, err := formInt(req.form, "ttl"); err != nil {
+				return nil, err
+			} else if ok {
+				record.TTL = ttl
+			}
+
+			resource := s.findDNSResource(name, domain)
+			if resource == nil {
+				resource = &DNSResource{ID: s.newID(), Name: name, Domain: domain}
+				s.dnsResources = append(s.dnsResources, resource)
+			}
+			if record.RRType == "CNAME" && (len(resource.IPAddresses) > 0 || len(resource.Records) > 0) {
+				return nil, badRequest(`{"__all__": ["CNAME records for a name cannot coexist with other records."]}`)
+			}
+			record.ID = s.newID()
+			resource.Records = append(resource.Records, record)
+			return renderDNSRecord(resource, record), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 1 {
+		return nil, notFound()
+	}
+	for index, resource := range s.dnsResources {
+		recordIndex := slices.IndexFunc(resource.Records, func(record DNSRecord) bool { return record.ID == id })
+		if recordIndex < 0 {
+			continue
+		}
+
+		switch req.method {
+		case http.MethodGet:
+			return renderDNSRecord(resource, resource.Records[recordIndex]), nil
+		case http.MethodDelete:
+			resource.Records = slices.Delete(resource.Records, recordIndex, recordIndex+1)
+			if len(resource.Records) == 0 && len(resource.IPAddresses) == 0 {
+				s.dnsResources = slices.Delete(s.dnsResources, index, index+1)
+			}
+			return rawResponse{contentType: "text/plain"}, nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+	return nil, notFound()
+}
@@ -0,0 +1,115 @@
+package fakemaas
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// Event levels, from the least to the most severe.
+var eventLevels = []string{"AUDIT", "DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"}
+
+// Event is an entry of the MAAS event log.
+type Event struct {
+	ID          int
+	Level       string
+	Type        string
+	Description string
+	Hostname    string
+	Node        string
+	Created     string
+}
+
+// Events returns a copy of the events, oldest first.
+func (s *Server) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]Event, 0, len(s.events))
+	for _, event := range s.events {
+		events = append(events, *event)
+	}
+	return events
+}
+
+func (s *Server) addEvent(level string, m *Machine, eventType, description string) {
+	s.events = append(s.events, &Event{
+		ID:          s.newID(),
+		Level:       level,
+		Type:        eventType,
+		Description: description,
+		Hostname:    m.Hostname,
+		Node:        m.SystemID,
+		Created:     timestamp(),
+	})
+}
+
+func (s *Server) handleEvents(req request, rest []string) (any, error) {
+	if len(rest) != 0 || req.op != "" {
+		return nil, notFound()
+	}
+	if req.method != http.MethodGet {
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	limit, _, err := formInt(req.query, "limit")
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	before, _, err := formInt(req.query, "before")
+	if err != nil {
+		return nil, err
+	}
+	after, _, err := formInt(req.query, "after")
+	if err != nil {
+		return nil, err
+	}
+
+	minLevel := 0
+	if level := req.query.Get("level"); level != "" {
+		minLevel = slices.Index(eventLevels, level)
+		if minLevel < 0 {
+			return nil, badRequest("Unrecognised log level: %s", level)
+		}
+	}
+
+	// MAAS returns the newest events first.
+	events := []map[string]any{}
+	for i := len(s.events) - 1; i >= 0 && len(events) < limit; i-- {
+		event := s.events[i]
+		if slices.Index(eventLevels, event.Level) < minLevel {
+			continue
+		}
+		if (before != 0 && event.ID >= before) || event.ID <= after {
+			continue
+		}
+		if ids := req.query["id"]; len(ids) > 0 && !slices.Contains(ids, event.Node) {
+			continue
+		}
+		events = append(events, map[string]any{
+			"id":          event.ID,
+			"level":       event.Level,
+			"type":        event.Type,
+			"description": event.Description,
+			"hostname":    event.Hostname,
+			"node":        event.Node,
+			"created":     event.Created,
+		})
+	}
+
+	var prevURI, nextURI any
+	if len(events) > 0 {
+		prevURI = "/MAAS/api/2.0/events/?op=query&after=" + strconv.Itoa(events[0]["id"].(int))
+		nextURI = "/MAAS/api/2.0/events/?op=query&before=" + strconv.Itoa(events[len(events)-1]["id"].(int))
+	}
+
+	return map[string]any{
+		"count":    len(events),
+		"events":   events,
+		"prev_uri": prevURI,
+		"next_uri": nextURI,
+	}, nil
+}
@@ -0,0 +1,403 @@
+// Package fakemaas provides an in-process fake of the MAAS 2.0 API for tests.
+//
+// The fake keeps machines, power state, tags, fabrics, VLANs, subnets,
+// spaces, VM hosts, events and scripts in memory and serves them over an
+// httptest.Server. Every request must carry a valid OAuth 1.0 PLAINTEXT
+// Authorization header signed with the key returned by APIKey.
+package fakemaas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+)
+
+const (
+	apiPrefix   = "/MAAS/api/2.0/"
+	consumerKey = "fake-consumer"
+	tokenKey    = "fake-token"
+	tokenSecret = "fake-secret"
+
+	timeLayout = "Mon, 02 Jan. 2006 15:04:05"
+)
+
+// Server is a fake MAAS API server. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// TransitionReads is the number of times a machine in a transitional
+	// status (e.g. Deploying) is read before it reaches its final status.
+	TransitionReads int
+
+	mu       sync.Mutex
+	nextID   int
+	nonces   map[string]bool
+	requests []maas_client.Request
+	failures []failure
+
+	machines []*Machine
+	fabrics  []*Fabric
+	vlans    []*VLAN
+	subnets  []*Subnet
+	spaces   []*Space
+	tags     []*Tag
+	vmHosts  []*VMHost
+	events   []*Event
+	scripts  []*Script
+	zones    []*Zone
+	pools    []*ResourcePool
+
+	domains      []*Domain
+	dnsResources []*DNSResource
+
+	bootResources        []*BootResource
+	bootSources          []*BootSource
+	bootSourceSelections []*BootSourceSelection
+	importing            bool
+	importReads          int
+}
+
+type failure struct {
+	method string
+	path   string
+	status int
+}
+
+// New starts a fake MAAS without any data. Call Close when done.
+func New() *Server {
+	s := &Server{
+		TransitionReads: 1,
+		nextID:          1,
+		nonces:          make(map[string]bool),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Start starts a fake MAAS that is closed when the test finishes.
+func Start(t testing.TB) *Server {
+	t.Helper()
+
+	s := New()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// APIKey returns the MAAS API key accepted by the fake.
+func (s *Server) APIKey() string {
+	return strings.Join([]string{consumerKey, tokenKey, tokenSecret}, ":")
+}
+
+// Client returns a MAAS client pointed at the fake.
+func (s *Server) Client() *maas_client.MAASClient {
+	client, err := maas_client.NewMAASClient(maas_client.Config{
+		BaseURL:        s.URL,
+		APIKey:         s.APIKey(),
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  10 * time.Millisecond,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []maas_client.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]maas_client.Request(nil), s.requests...)
+}
+
+// LastRequest returns the last request received with the given method.
+func (s *Server) LastRequest(method string) (maas_client.Request, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		if s.requests[i].Method == method {
+			return s.requests[i], true
+		}
+	}
+	return maas_client.Request{}, false
+}
+
+// Fail makes every request with the given method and path (without the
+// query string) fail with status until ClearFailures is called.
+func (s *Server) Fail(method, path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure{method: method, path: path, status: status})
+}
+
+// ClearFailures removes the failures registered with Fail.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = nil
+}
+
+// apiError is returned by the handlers to produce a non-2xx response.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func notFound() *apiError {
+	return &apiError{status: http.StatusNotFound, message: "Not Found"}
+}
+
+func badRequest(format string, args ...any) *apiError {
+	return &apiError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func conflict(format string, args ...any) *apiError {
+	return &apiError{status: http.StatusConflict, message: fmt.Sprintf(format, args...)}
+}
+
+// request is the parsed form of an API call passed to the resource handlers.
+type request struct {
+	method   string
+	segments []string
+	op       string
+	query    url.Values
+	form     url.Values
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	form, _ := url.ParseQuery(string(body))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recorded := maas_client.Request{Method: r.Method, Path: r.URL.RequestURI(), Body: string(body)}
+	if len(form) > 0 {
+		recorded.Form = form
+	}
+	s.requests = append(s.requests, recorded)
+
+	if err := s.checkAuthorization(r.Header.Get("Authorization")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	for _, f := range s.failures {
+		if f.method == r.Method && f.path == r.URL.Path {
+			http.Error(w, http.StatusText(f.status), f.status)
+			return
+		}
+	}
+
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	req := request{
+		method: r.Method,
+		query:  r.URL.Query(),
+		form:   form,
+	}
+
+	// Operations are addressed as a trailing "op-<name>" segment.
+	for _, segment := range strings.Split(strings.TrimPrefix(r.URL.Path, apiPrefix), "/") {
+		if segment == "" {
+			continue
+		}
+		if op, found := strings.CutPrefix(segment, "op-"); found {
+			req.op = op
+			continue
+		}
+		req.segments = append(req.segments, segment)
+	}
+
+	result, err := s.route(req)
+	if err != nil {
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			apiErr = &apiError{status: http.StatusInternalServerError, message: err.Error()}
+		}
+		http.Error(w, apiErr.message, apiErr.status)
+		return
+	}
+
+	if raw, ok := result.(rawResponse); ok {
+		w.Header().Set("Content-Type", raw.contentType)
+		_, _ = w.Write(raw.body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// rawResponse is returned by handlers that do not answer with JSON.
+type rawResponse struct {
+	contentType string
+	body        []byte
+}
+
+func (s *Server) route(req request) (any, error) {
+	if len(req.segments) == 0 {
+		return nil, notFound()
+	}
+
+	resource, rest := req.segments[0], req.segments[1:]
+	switch resource {
+	case "machines":
+		return s.handleMachines(req, rest)
+	case "nodes":
+		return s.handleNodes(req, rest)
+	case "installation-results":
+		return s.handleInstallationResults(req, rest)
+	case "fabrics":
+		return s.handleFabrics(req, rest)
+	case "subnets":
+		return s.handleSubnets(req, rest)
+	case "spaces":
+		return s.handleSpaces(req, rest)
+	case "ipranges":
+		return s.handleIPRanges(req, rest)
+	case "domains":
+		return s.handleDomains(req, rest)
+	case "dnsresources":
+		return s.handleDNSResources(req, rest)
+	case "dnsresourcerecords":
+		return s.handleDNSResourceRecords(req, rest)
+	case "tags":
+		return s.handleTags(req, rest)
+	case "vm-hosts", "pods":
+		return s.handleVMHosts(req, rest)
+	case "events":
+		return s.handleEvents(req, rest)
+	case "scripts":
+		return s.handleScripts(req, rest)
+	case "boot-resources":
+		return s.handleBootResources(req, rest)
+	case "boot-sources":
+		return s.handleBootSources(req, rest)
+	case "zones":
+		return s.handleZones(req, rest)
+	case "resourcepools":
+		return s.handleResourcePools(req, rest)
+	case "resourcepool":
+		return s.handleResourcePool(req, rest)
+	default:
+		return nil, notFound()
+	}
+}
+
+// checkAuthorization validates an OAuth 1.0 PLAINTEXT Authorization header
+// in the format sent by maas_client.
+func (s *Server) checkAuthorization(header string) error {
+	params, found := strings.CutPrefix(header, "OAuth ")
+	if !found {
+		return fmt.Errorf("missing OAuth authorization header")
+	}
+
+	values := make(map[string]string)
+	for _, param := range strings.Split(params, ", ") {
+		key, quoted, found := strings.Cut(param, "=")
+		if !found || len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+			return fmt.Errorf("malformed OAuth parameter %q", param)
+		}
+
+		value, err := url.QueryUnescape(quoted[1 : len(quoted)-1])
+		if err != nil {
+			return fmt.Errorf("malformed OAuth parameter %q", param)
+		}
+		values[key] = value
+	}
+
+	expected := map[string]string{
+		"oauth_version":          "1.0",
+		"oauth_signature_method": "PLAINTEXT",
+		"oauth_consumer_key":     consumerKey,
+		"oauth_token":            tokenKey,
+		"oauth_signature":        "&" + tokenSecret,
+	}
+	for key, value := range expected {
+		if values[key] != value {
+			return fmt.Errorf("invalid %s", key)
+		}
+	}
+
+	nonce := values["oauth_nonce"]
+	if nonce == "" {
+		return fmt.Errorf("missing oauth_nonce")
+	}
+	if s.nonces[nonce] {
+		return fmt.Errorf("oauth_nonce %s was already used", nonce)
+	}
+	s.nonces[nonce] = true
+
+	if _, err := strconv.ParseInt(values["oauth_timestamp"], 10, 64); err != nil {
+		return fmt.Errorf("invalid oauth_timestamp")
+	}
+
+	return nil
+}
+
+func (s *Server) newID() int {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+func formBool(form url.Values, key string) (bool, bool) {
+	if !form.Has(key) {
+		return false, false
+	}
+	switch strings.ToLower(form.Get(key)) {
+	case "1", "true", "on", "yes":
+		return true, true
+	default:
+		return false, true
+	}
+}
+
+func formInt(form url.Values, key string) (int, bool, error) {
+	if !form.Has(key) {
+		return 0, false, nil
+	}
+	value, err := strconv.Atoi(form.Get(key))
+	if err != nil {
+		return 0, true, badRequest(`{"%s": ["Enter a whole number."]}`, key)
+	}
+	return value, true, nil
+}
+
+func pathID(segment string) (int, error) {
+	id, err := strconv.Atoi(segment)
+	if err != nil {
+		return 0, notFound()
+	}
+	return id, nil
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format(timeLayout)
+}
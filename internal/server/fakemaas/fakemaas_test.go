@@ -0,0 +1,165 @@
+package fakemaas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+)
+
+func TestServer_Authorization(t *testing.T) {
+	fake := New()
+	t.Cleanup(fake.Close)
+
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{"missing header", ""},
+		{"wrong signature method", `OAuth oauth_version="1.0", oauth_signature_method="HMAC-SHA1", oauth_consumer_key="fake-consumer", oauth_token="fake-token", oauth_signature="%26fake-secret", oauth_nonce="a", oauth_timestamp="1"`},
+		{"wrong secret", `OAuth oauth_version="1.0", oauth_signature_method="PLAINTEXT", oauth_consumer_key="fake-consumer", oauth_token="fake-token", oauth_signature="%26other", oauth_nonce="b", oauth_timestamp="1"`},
+		{"unquoted value", `OAuth oauth_version=1.0`},
+		{"missing nonce", `OAuth oauth_version="1.0", oauth_signature_method="PLAINTEXT", oauth_consumer_key="fake-consumer", oauth_token="fake-token", oauth_signature="%26fake-secret", oauth_timestamp="1"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			req, _ := http.NewRequest(http.MethodGet, fake.URL+"/MAAS/api/2.0/machines/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			// Act
+			resp, err := http.DefaultClient.Do(req)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("expected status 401, got %d", resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("accepts the client header", func(t *testing.T) {
+		// Act
+		_, err := fake.Client().Do(context.Background(), maas_client.RequestTypeGet, "/MAAS/api/2.0/machines/", nil)
+
+		// Assert
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestServer_MachineTransitions(t *testing.T) {
+	t.Run("deploying becomes deployed after the configured reads", func(t *testing.T) {
+		// Arrange
+		fake := New()
+		t.Cleanup(fake.Close)
+		fake.TransitionReads = 2
+		m := fake.AddMachine(Machine{})
+		client := fake.Client()
+
+		// Act
+		_, err := client.Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+m.SystemID+"/op-deploy", nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var seen []string
+		for range 4 {
+			body, err := client.Do(context.Background(), maas_client.RequestTypeGet, "/MAAS/api/2.0/machines/"+m.SystemID+"/", nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			var machine map[string]any
+			_ = json.Unmarshal([]byte(body), &machine)
+			seen = append(seen, machine["status_name"].(string))
+		}
+
+		// Assert
+		expected := []string{StatusDeploying, StatusDeploying, StatusDeployed, StatusDeployed}
+		if strings.Join(seen, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected statuses %v, got %v", expected, seen)
+		}
+	})
+
+	t.Run("rejects operations that are invalid for the status", func(t *testing.T) {
+		// Arrange
+		fake := New()
+		t.Cleanup(fake.Close)
+		m := fake.AddMachine(Machine{Status: StatusNew})
+
+		// Act
+		_, err := fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+m.SystemID+"/op-deploy", nil)
+
+		// Assert
+		if err == nil || !strings.Contains(err.Error(), "409") {
+			t.Errorf("expected a 409 error, got %v", err)
+		}
+	})
+
+	t.Run("failed transitions end in the failed status", func(t *testing.T) {
+		// Arrange
+		fake := New()
+		t.Cleanup(fake.Close)
+		m := fake.AddMachine(Machine{})
+		_, _ = fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+m.SystemID+"/op-commission", nil)
+
+		// Act
+		fake.FailTransition(m.SystemID)
+
+		// Assert
+		if got, _ := fake.Machine(m.SystemID); got.Status != StatusFailedCommissioning {
+			t.Errorf("expected status %s, got %s", StatusFailedCommissioning, got.Status)
+		}
+	})
+}
+
+func TestServer_SubnetStatistics(t *testing.T) {
+	// Arrange
+	fake := New()
+	t.Cleanup(fake.Close)
+	subnet := fake.AddSubnet(Subnet{
+		CIDR:      "10.0.0.0/24",
+		GatewayIP: "10.0.0.1",
+		IPRanges:  []IPRange{{Type: "dynamic", StartIP: "10.0.0.100", EndIP: "10.0.0.199"}},
+	})
+	fake.AddMachine(Machine{Interfaces: []Interface{{Name: "eth0", Links: []Link{{IPAddress: "10.0.0.5", SubnetID: subnet.ID}}}}})
+
+	// Act
+	body, err := fake.Client().Do(context.Background(), maas_client.RequestTypeGet, "/MAAS/api/2.0/subnets/"+strconv.Itoa(subnet.ID)+"/op-statistics", nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var statistics map[string]any
+	_ = json.Unmarshal([]byte(body), &statistics)
+	if statistics["total_addresses"] != 254.0 || statistics["num_unavailable"] != 102.0 || statistics["largest_available"] != 94.0 {
+		t.Errorf("unexpected statistics %v", statistics)
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	// Arrange
+	fake := New()
+	t.Cleanup(fake.Close)
+	fake.Fail(http.MethodGet, "/MAAS/api/2.0/tags/", http.StatusServiceUnavailable)
+
+	// Act
+	_, err := fake.Client().Do(context.Background(), maas_client.RequestTypeGet, "/MAAS/api/2.0/tags/", nil)
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected a 503 error, got %v", err)
+	}
+}
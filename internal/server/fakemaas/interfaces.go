@@ -0,0 +1,379 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func (s *Server) handleNodes(req request, rest []string) (any, error) {
+	if len(rest) < 2 {
+		return nil, notFound()
+	}
+
+	m := s.findMachine(rest[0])
+	if m == nil {
+		return nil, notFound()
+	}
+
+	switch rest[1] {
+	case "interfaces":
+		return s.handleInterfaces(m, req, rest[2:])
+	case "blockdevices":
+		return s.handleBlockDevices(m, req, rest[2:])
+	case "raids", "raid":
+		return s.handleRAIDs(m, req, rest[1], rest[2:])
+	case "volume-groups", "volume-group":
+		return s.handleVolumeGroups(m, req, rest[1], rest[2:])
+	case "bcaches":
+		return s.handleBcaches(m, req, rest[2:])
+	default:
+		return nil, notFound()
+	}
+}
+
+func (s *Server) handleInterfaces(m *Machine, req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch {
+		case req.method == http.MethodGet && req.op == "":
+			interfaces := make([]map[string]any, 0, len(m.Interfaces))
+			for _, iface := range m.Interfaces {
+				interfaces = append(interfaces, s.renderInterface(m, iface))
+			}
+			return interfaces, nil
+		case req.method == http.MethodPost:
+			if err := checkInterfacesEditable(m, req.op); err != nil {
+				return nil, err
+			}
+			iface, err := s.createInterface(m, req)
+			if err != nil {
+				return nil, err
+			}
+			return s.renderInterface(m, *iface), nil
+		default:
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+	}
+
+	if len(rest) != 1 {
+		return nil, notFound()
+	}
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(m.Interfaces, func(iface Interface) bool { return iface.ID == id })
+	if index < 0 {
+		return nil, notFound()
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderInterface(m, m.Interfaces[index]), nil
+	case http.MethodPost:
+		if err := checkInterfacesEditable(m, req.op); err != nil {
+			return nil, err
+		}
+		iface := &m.Interfaces[index]
+		switch req.op {
+		case "link_subnet":
+			err = s.linkSubnet(m, iface, req)
+		case "unlink_subnet":
+			err = unlinkSubnet(m, iface, req)
+		case "set_default_gateway":
+			err = s.setDefaultGateway(m, iface, req)
+		default:
+			return nil, badRequest("Unrecognised signature: method=POST op=%s", req.op)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.renderInterface(m, *iface), nil
+	case http.MethodDelete:
+		if err := checkInterfacesEditable(m, "delete"); err != nil {
+			return nil, err
+		}
+		if children := interfaceChildren(m, m.Interfaces[index].Name); len(children) > 0 {
+			return nil, badRequest("Cannot delete interface %s, it is the parent of %s.", m.Interfaces[index].Name, strings.Join(children, ", "))
+		}
+		for _, link := range m.Interfaces[index].Links {
+			if link.ID == m.GatewayLinkID {
+				m.GatewayLinkID = 0
+			}
+		}
+		m.Interfaces = slices.Delete(m.Interfaces, index, index+1)
+		return nil, nil
+	default:
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+}
+
+// checkInterfacesEditable refuses interface changes outside the Ready and
+// Allocated states, as MAAS does.
+func checkInterfacesEditable(m *Machine, op string) error {
+	if m.Status != StatusReady && m.Status != StatusAllocated {
+		return conflict("Cannot %s interface because the machine is %s.", strings.ReplaceAll(op, "_", " "), m.Status)
+	}
+	return nil
+}
+
+// interfaceChildren returns the names of the interfaces built on top of the
+// interface with the given name.
+func interfaceChildren(m *Machine, name string) []string {
+	var children []string
+	for _, iface := range m.Interfaces {
+		if slices.Contains(iface.Parents, name) {
+			children = append(children, iface.Name)
+		}
+	}
+	return children
+}
+
+func findInterface(m *Machine, id int) *Interface {
+	for i := range m.Interfaces {
+		if m.Interfaces[i].ID == id {
+			return &m.Interfaces[i]
+		}
+	}
+	return nil
+}
+
+// parentInterface returns the interface with the ID value, given as the
+// form value key. It must not already be the parent of a bond or a bridge.
+func parentInterface(m *Machine, key, value string) (*Interface, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, badRequest(`{"%s": ["This field is required."]}`, key)
+	}
+	parent := findInterface(m, id)
+	if parent == nil {
+		return nil, badRequest(`{"%s": ["Select a valid choice. %d is not one of the available choices."]}`, key, id)
+	}
+	for _, child := range m.Interfaces {
+		if slices.Contains(child.Parents, parent.Name) && child.Type != "vlan" {
+			return nil, badRequest(`{"%s": ["%s is already in use by %s."]}`, key, parent.Name, child.Name)
+		}
+	}
+	return parent, nil
+}
+
+func (s *Server) createInterface(m *Machine, req request) (*Interface, error) {
+	iface := Interface{ID: s.newID(), Name: req.form.Get("name"), MACAddress: req.form.Get("mac_address")}
+
+	switch req.op {
+	case "create_bond", "create_bridge":
+		key := "parent"
+		iface.Type = "bridge"
+		if req.op == "create_bond" {
+			key, iface.Type = "parents", "bond"
+			iface.BondMode = valueOr(req.form.Get("bond_mode"), "balance-rr")
+		}
+		if iface.Name == "" {
+			return nil, badRequest(`{"name": ["This field is required."]}`)
+		}
+		if slices.ContainsFunc(m.Interfaces, func(other Interface) bool { return other.Name == iface.Name }) {
+			return nil, badRequest(`{"name": ["Interface with this name already exists on the node."]}`)
+		}
+		if len(req.form[key]) == 0 {
+			return nil, badRequest(`{"%s": ["This field is required."]}`, key)
+		}
+		var parents []*Interface
+		for _, value := range req.form[key] {
+			parent, err := parentInterface(m, key, value)
+			if err != nil {
+				return nil, err
+			}
+			if parent.Type == "vlan" {
+				return nil, badRequest(`{"%s": ["%s is a VLAN interface."]}`, key, parent.Name)
+			}
+			parents = append(parents, parent)
+		}
+		for _, parent := range parents {
+			iface.Parents = append(iface.Parents, parent.Name)
+			iface.VLANID = parent.VLANID
+			iface.MACAddress = valueOr(iface.MACAddress, parent.MACAddress)
+			// The links of the parents move to the new interface.
+			iface.Links = append(iface.Links, parent.Links...)
+			parent.Links = nil
+		}
+	case "create_vlan":
+		parent, err := parentInterface(m, "parent", req.form.Get("parent"))
+		if err != nil {
+			return nil, err
+		}
+		vlanID, _, err := formInt(req.form, "vlan")
+		if err != nil {
+			return nil, err
+		}
+		vlan := s.findVLAN(vlanID)
+		if vlan == nil {
+			return nil, badRequest(`{"vlan": ["This field is required."]}`)
+		}
+		if vlan.VID == 0 {
+			return nil, badRequest(`{"vlan": ["VLAN interfaces cannot be created on the untagged VLAN."]}`)
+		}
+		iface.Type = "vlan"
+		iface.Name = fmt.Sprintf("%s.%d", parent.Name, vlan.VID)
+		if slices.ContainsFunc(m.Interfaces, func(other Interface) bool { return other.Name == iface.Name }) {
+			return nil, badRequest(`{"vlan": ["A VLAN interface %s already exists on the node."]}`, iface.Name)
+		}
+		iface.Parents = []string{parent.Name}
+		iface.VLANID = vlan.ID
+		iface.MACAddress = parent.MACAddress
+	default:
+		return nil, badRequest("Unrecognised signature: method=POST op=%s", req.op)
+	}
+
+	m.Interfaces = append(m.Interfaces, iface)
+	return &m.Interfaces[len(m.Interfaces)-1], nil
+}
+
+func (s *Server) linkSubnet(m *Machine, iface *Interface, req request) error {
+	mode := strings.ToLower(req.form.Get("mode"))
+	if !slices.Contains([]string{"auto", "dhcp", "static", "link_up"}, mode) {
+		return badRequest(`{"mode": ["Select a valid choice."]}`)
+	}
+
+	link := Link{ID: s.newID(), Mode: mode}
+	subnetID, hasSubnet, err := formInt(req.form, "subnet")
+	if err != nil {
+		return err
+	}
+	subnet := s.findSubnet(subnetID)
+	if hasSubnet {
+		if subnet == nil {
+			return badRequest(`{"subnet": ["Select a valid choice."]}`)
+		}
+		link.SubnetID = subnet.ID
+	} else if mode == "auto" || mode == "static" {
+		return badRequest(`{"subnet": ["This field is required."]}`)
+	}
+
+	if mode == "static" {
+		ip, err := s.staticAddress(link.SubnetID, req.form.Get("ip_address"))
+		if err != nil {
+			return err
+		}
+		link.IPAddress = ip
+	} else if req.form.Get("ip_address") != "" {
+		return badRequest(`{"ip_address": ["IP address can only be set in STATIC mode."]}`)
+	}
+
+	isDefault, _ := formBool(req.form, "default_gateway")
+	if isDefault && mode != "auto" && mode != "static" {
+		return badRequest(`{"default_gateway": ["Cannot use in mode '%s'."]}`, strings.ToUpper(mode))
+	}
+
+	// The interface moves to the VLAN of the subnet it is linked to.
+	if subnet != nil {
+		iface.VLANID = subnet.VLANID
+	}
+	iface.Links = append(iface.Links, link)
+	if isDefault {
+		m.GatewayLinkID = link.ID
+	}
+	return nil
+}
+
+// staticAddress checks a requested static address, or picks the first free
+// address of the subnet when ip is empty.
+func (s *Server) staticAddress(subnetID int, ip string) (string, error) {
+	subnet := s.findSubnet(subnetID)
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil {
+		return "", badRequest("invalid CIDR %s", subnet.CIDR)
+	}
+
+	used := map[string]bool{subnet.GatewayIP: true}
+	for _, other := range s.machines {
+		for _, iface := range other.Interfaces {
+			for _, link := range iface.Links {
+				used[link.IPAddress] = true
+			}
+		}
+	}
+
+	if ip != "" {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || !prefix.Contains(addr) {
+			return "", badRequest(`{"ip_address": ["IP address %s is not within subnet %s."]}`, ip, subnet.CIDR)
+		}
+		if used[ip] {
+			return "", badRequest(`{"ip_address": ["IP address %s is already in use."]}`, ip)
+		}
+		return ip, nil
+	}
+
+	for addr := prefix.Masked().Addr().Next(); prefix.Contains(addr); addr = addr.Next() {
+		if !used[addr.String()] && prefix.Contains(addr.Next()) {
+			return addr.String(), nil
+		}
+	}
+	return "", badRequest("No more IPs available in subnet: %s.", subnet.CIDR)
+}
+
+func unlinkSubnet(m *Machine, iface *Interface, req request) error {
+	linkID, _, err := formInt(req.form, "id")
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(iface.Links, func(link Link) bool { return link.ID == linkID })
+	if index < 0 {
+		return badRequest(`{"id": ["Select a valid choice."]}`)
+	}
+	if m.GatewayLinkID == linkID {
+		m.GatewayLinkID = 0
+	}
+	iface.Links = slices.Delete(iface.Links, index, index+1)
+	return nil
+}
+
+func (s *Server) setDefaultGateway(m *Machine, iface *Interface, req request) error {
+	linkID, hasLink, err := formInt(req.form, "link_id")
+	if err != nil {
+		return err
+	}
+
+	for _, link := range iface.Links {
+		if hasLink && link.ID != linkID {
+			continue
+		}
+		subnet := s.findSubnet(link.SubnetID)
+		if (link.Mode == "auto" || link.Mode == "static") && subnet != nil && subnet.GatewayIP != "" {
+			m.GatewayLinkID = link.ID
+			return nil
+		}
+		if hasLink {
+			return badRequest(`{"link_id": ["This link cannot be a default gateway: it needs an AUTO or STATIC link to a subnet with a gateway."]}`)
+		}
+	}
+	if hasLink {
+		return badRequest(`{"link_id": ["Select a valid choice."]}`)
+	}
+	return badRequest("This interface has no usable gateway.")
+}
+
+// renderDefaultGateways returns the default_gateways of a machine.
+func (s *Server) renderDefaultGateways(m *Machine) map[string]any {
+	gateways := map[string]any{
+		"ipv4": map[string]any{"gateway_ip": nil, "link_id": nil},
+		"ipv6": map[string]any{"gateway_ip": nil, "link_id": nil},
+	}
+	for _, iface := range m.Interfaces {
+		for _, link := range iface.Links {
+			subnet := s.findSubnet(link.SubnetID)
+			if link.ID != m.GatewayLinkID || subnet == nil {
+				continue
+			}
+			family := "ipv4"
+			if prefix, err := netip.ParsePrefix(subnet.CIDR); err == nil && prefix.Addr().Is6() {
+				family = "ipv6"
+			}
+			gateways[family] = map[string]any{"gateway_ip": subnet.GatewayIP, "link_id": link.ID}
+		}
+	}
+	return gateways
+}
@@ -0,0 +1,140 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+)
+
+// IPRanges returns a copy of the IP ranges of every subnet.
+func (s *Server) IPRanges() []IPRange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ranges []IPRange
+	for _, subnet := range s.subnets {
+		ranges = append(ranges, subnet.IPRanges...)
+	}
+	return ranges
+}
+
+// findIPRange returns the subnet of the range with the given ID and the
+// index of the range in it.
+func (s *Server) findIPRange(id int) (*Subnet, int) {
+	for _, subnet := range s.subnets {
+		if index := slices.IndexFunc(subnet.IPRanges, func(r IPRange) bool { return r.ID == id }); index >= 0 {
+			return subnet, index
+		}
+	}
+	return nil, -1
+}
+
+func (s *Server) renderIPRange(subnet *Subnet, r IPRange) map[string]any {
+	return map[string]any{
+		"id":           r.ID,
+		"type":         valueOr(r.Type, "reserved"),
+		"start_ip":     r.StartIP,
+		"end_ip":       r.EndIP,
+		"comment":      r.Comment,
+		"subnet":       s.renderSubnet(subnet),
+		"user":         "admin",
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/ipranges/%d/", r.ID),
+	}
+}
+
+// checkIPRange validates a range like MAAS does: both ends inside the
+// subnet, in order, and not overlapping the other ranges of the subnet.
+func checkIPRange(subnet *Subnet, r IPRange) error {
+	start, errStart := netip.ParseAddr(r.StartIP)
+	end, errEnd := netip.ParseAddr(r.EndIP)
+	if errStart != nil || errEnd != nil {
+		return badRequest(`{"start_ip": ["Enter a valid IPv4 or IPv6 address."]}`)
+	}
+	prefix := mustPrefix(subnet.CIDR)
+	if !prefix.Contains(start) || !prefix.Contains(end) {
+		return badRequest(`{"__all__": ["IP addresses must be within subnet: %s."]}`, subnet.CIDR)
+	}
+	if end.Less(start) {
+		return badRequest(`{"__all__": ["End IP address must not be less than Start IP address."]}`)
+	}
+	for _, other := range subnet.IPRanges {
+		if other.ID == r.ID {
+			continue
+		}
+		otherStart, _ := netip.ParseAddr(other.StartIP)
+		otherEnd, _ := netip.ParseAddr(other.EndIP)
+		if !end.Less(otherStart) && !otherEnd.Less(start) {
+			return badRequest(`{"__all__": ["Requested %s range conflicts with an existing range."]}`, valueOr(r.Type, "reserved"))
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleIPRanges(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			ranges := []map[string]any{}
+			for _, subnet := range s.subnets {
+				for _, r := range subnet.IPRanges {
+					ranges = append(ranges, s.renderIPRange(subnet, r))
+				}
+			}
+			return ranges, nil
+		case http.MethodPost:
+			r := IPRange{Type: req.form.Get("type"), StartIP: req.form.Get("start_ip"), EndIP: req.form.Get("end_ip"), Comment: req.form.Get("comment")}
+			if r.Type != "dynamic" && r.Type != "reserved" {
+				return nil, badRequest(`{"type": ["Select a valid choice. %s is not one of the available choices."]}`, r.Type)
+			}
+			id, err := strconv.Atoi(req.form.Get("subnet"))
+			subnet := s.findSubnet(id)
+			if err != nil || subnet == nil {
+				return nil, badRequest(`{"subnet": ["Select a valid choice."]}`)
+			}
+			if err := checkIPRange(subnet, r); err != nil {
+				return nil, err
+			}
+			r.ID = s.newID()
+			subnet.IPRanges = append(subnet.IPRanges, r)
+			return s.renderIPRange(subnet, r), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	subnet, index := s.findIPRange(id)
+	if subnet == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderIPRange(subnet, subnet.IPRanges[index]), nil
+	case http.MethodPut:
+		r := subnet.IPRanges[index]
+		for key, target := range map[string]*string{
+			"type":     &r.Type,
+			"start_ip": &r.StartIP,
+			"end_ip":   &r.EndIP,
+			"comment":  &r.Comment,
+		} {
+			if req.form.Has(key) {
+				*target = req.form.Get(key)
+			}
+		}
+		if err := checkIPRange(subnet, r); err != nil {
+			return nil, err
+		}
+		subnet.IPRanges[index] = r
+		return s.renderIPRange(subnet, r), nil
+	case http.MethodDelete:
+		subnet.IPRanges = slices.Delete(subnet.IPRanges, index, index+1)
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
@@ -0,0 +1,707 @@
+package fakemaas
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Machine statuses as reported in status_name.
+const (
+	StatusNew                      = "New"
+	StatusCommissioning            = "Commissioning"
+	StatusFailedCommissioning      = "Failed commissioning"
+	StatusReady                    = "Ready"
+	StatusAllocated                = "Allocated"
+	StatusDeploying                = "Deploying"
+	StatusDeployed                 = "Deployed"
+	StatusFailedDeployment         = "Failed deployment"
+	StatusReleasing                = "Releasing"
+	StatusDiskErasing              = "Disk erasing"
+	StatusFailedDiskErasing        = "Failed disk erasing"
+	StatusRescueMode               = "Rescue mode"
+	StatusEnteringRescueMode       = "Entering rescue mode"
+	StatusFailedEnteringRescueMode = "Failed to enter rescue mode"
+	StatusExitingRescueMode        = "Exiting rescue mode"
+	StatusTesting                  = "Testing"
+	StatusFailedTesting            = "Failed testing"
+	StatusBroken                   = "Broken"
+)
+
+var statusCodes = map[string]int{
+	StatusNew:                      0,
+	StatusCommissioning:            1,
+	StatusFailedCommissioning:      2,
+	StatusReady:                    4,
+	StatusDeployed:                 6,
+	StatusBroken:                   8,
+	StatusDeploying:                9,
+	StatusAllocated:                10,
+	StatusFailedDeployment:         11,
+	StatusReleasing:                12,
+	StatusDiskErasing:              14,
+	StatusFailedDiskErasing:        15,
+	StatusRescueMode:               16,
+	StatusEnteringRescueMode:       17,
+	StatusFailedEnteringRescueMode: 18,
+	StatusExitingRescueMode:        19,
+	StatusTesting:                  21,
+	StatusFailedTesting:            22,
+}
+
+// failedStatuses maps transitional statuses to the status a failed transition ends in.
+var failedStatuses = map[string]string{
+	StatusCommissioning:      StatusFailedCommissioning,
+	StatusDeploying:          StatusFailedDeployment,
+	StatusDiskErasing:        StatusFailedDiskErasing,
+	StatusEnteringRescueMode: StatusFailedEnteringRescueMode,
+	StatusTesting:            StatusFailedTesting,
+	StatusReleasing:          StatusBroken,
+	StatusExitingRescueMode:  StatusBroken,
+}
+
+// Machine is a machine known to the fake.
+type Machine struct {
+	SystemID     string
+	Hostname     string
+	Status       string
+	PowerState   string
+	PowerType    string
+	Architecture string
+	CPUCount     int
+	CPUModel     string
+	Memory       int
+	Storage      float64
+	OSystem      string
+	DistroSeries string
+	HWEKernel    string
+	Zone         string
+	Pool         string
+	TagNames     []string
+	Locked       bool
+
+	// PowerParameters are the BMC settings of the machine, as returned by
+	// op-power_parameters. BMCPowerState is the state the BMC reports when
+	// queried, if it differs from the cached PowerState.
+	PowerParameters map[string]string
+	BMCPowerState   string
+
+	// VMHostID is the id of the VM host the machine was composed on, 0 for
+	// bare metal. VirtualMachineID is the id of the VM on that host.
+	VMHostID         int
+	VirtualMachineID int
+
+	Interfaces    []Interface
+	ScriptResults []ScriptResult
+	// GatewayLinkID is the link the machine routes through, 0 for none.
+	GatewayLinkID int
+	Details       string
+
+	// BlockDevices holds the physical disks and the virtual devices built on
+	// them. BootDiskID is the boot disk, the first physical disk when 0.
+	BlockDevices []BlockDevice
+	BootDiskID   int
+	RAIDs        []RAID
+	VolumeGroups []VolumeGroup
+	Bcaches      []Bcache
+
+	// UserData is the decoded user_data of the last deployment.
+	UserData string
+
+	pendingStatus  string
+	pendingReads   int
+	previousStatus string
+}
+
+// Interface is a network interface of a machine.
+type Interface struct {
+	ID         int
+	Name       string
+	Type       string
+	MACAddress string
+	Parents    []string
+	VLANID     int
+	Links      []Link
+	// BondMode is set for bonds only.
+	BondMode string
+}
+
+// Link is an IP address assigned to an interface.
+type Link struct {
+	ID        int
+	Mode      string
+	IPAddress string
+	SubnetID  int
+}
+
+// ScriptResult is a commissioning or installation script result of a machine.
+type ScriptResult struct {
+	ID           int
+	Name         string
+	ScriptResult int
+	ResultType   int
+	Output       string
+}
+
+// AddMachine adds a machine and returns it with defaults filled in. A random
+// system id is generated when empty and the status defaults to Ready.
+func (s *Server) AddMachine(m Machine) Machine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m.SystemID == "" {
+		m.SystemID = s.newSystemID()
+	}
+	if m.Hostname == "" {
+		m.Hostname = "machine-" + m.SystemID
+	}
+	if m.Status == "" {
+		m.Status = StatusReady
+	}
+	if m.PowerState == "" {
+		m.PowerState = "off"
+	}
+	if m.PowerType == "" {
+		m.PowerType = "ipmi"
+	}
+	if m.Architecture == "" {
+		m.Architecture = "amd64/generic"
+	}
+	if m.Zone == "" {
+		m.Zone = "default"
+	}
+	if m.Pool == "" {
+		m.Pool = "default"
+	}
+	for i := range m.Interfaces {
+		if m.Interfaces[i].ID == 0 {
+			m.Interfaces[i].ID = s.newID()
+		}
+		if m.Interfaces[i].Type == "" {
+			m.Interfaces[i].Type = "physical"
+		}
+		for j := range m.Interfaces[i].Links {
+			if m.Interfaces[i].Links[j].ID == 0 {
+				m.Interfaces[i].Links[j].ID = s.newID()
+			}
+		}
+	}
+
+	for i := range m.BlockDevices {
+		device := &m.BlockDevices[i]
+		if device.ID == 0 {
+			device.ID = s.newID()
+		}
+		if device.Type == "" {
+			device.Type = "physical"
+		}
+		for j := range device.Partitions {
+			if device.Partitions[j].ID == 0 {
+				device.Partitions[j].ID = s.newID()
+			}
+		}
+	}
+
+	stored := m
+	s.machines = append(s.machines, &stored)
+	return stored
+}
+
+// Machine returns a copy of the machine with the given system id.
+func (s *Server) Machine(systemID string) (Machine, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.findMachine(systemID)
+	if m == nil {
+		return Machine{}, false
+	}
+	return *m, true
+}
+
+// SetStatus moves a machine to status, cancelling any transition in progress.
+func (s *Server) SetStatus(systemID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m := s.findMachine(systemID); m != nil {
+		m.Status = status
+		m.pendingStatus = ""
+	}
+}
+
+// CompleteTransition makes the transition in progress on a machine finish now.
+func (s *Server) CompleteTransition(systemID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m := s.findMachine(systemID); m != nil && m.pendingStatus != "" {
+		s.finishTransition(m)
+	}
+}
+
+// FailTransition makes the transition in progress on a machine fail, e.g.
+// a Deploying machine ends in Failed deployment.
+func (s *Server) FailTransition(systemID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.findMachine(systemID)
+	if m == nil || m.pendingStatus == "" {
+		return
+	}
+
+	if failed, ok := failedStatuses[m.Status]; ok {
+		m.Status = failed
+	}
+	m.pendingStatus = ""
+	s.addEvent("ERROR", m, "Failed", fmt.Sprintf("Machine moved to %s", m.Status))
+}
+
+func (s *Server) newSystemID() string {
+	const alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	for {
+		id := make([]byte, 6)
+		for i := range id {
+			id[i] = alphabet[rand.IntN(len(alphabet))]
+		}
+		if s.findMachine(string(id)) == nil {
+			return string(id)
+		}
+	}
+}
+
+func (s *Server) findMachine(systemID string) *Machine {
+	for _, m := range s.machines {
+		if m.SystemID == systemID {
+			return m
+		}
+	}
+	return nil
+}
+
+// startTransition moves a machine to a transitional status that turns into
+// final after TransitionReads reads of the machine.
+func (s *Server) startTransition(m *Machine, transitional, final, event string) {
+	m.previousStatus = m.Status
+	m.Status = transitional
+	m.pendingStatus = final
+	m.pendingReads = s.TransitionReads
+	s.addEvent("INFO", m, event, fmt.Sprintf("From '%s' to '%s'", m.previousStatus, transitional))
+
+	if m.pendingReads <= 0 {
+		s.finishTransition(m)
+	}
+}
+
+func (s *Server) finishTransition(m *Machine) {
+	m.Status = m.pendingStatus
+	m.pendingStatus = ""
+
+	switch m.Status {
+	case StatusDeployed, StatusRescueMode:
+		m.PowerState = "on"
+	case StatusReady, StatusNew:
+		m.PowerState = "off"
+	}
+
+	s.addEvent("INFO", m, "Status changed", fmt.Sprintf("Machine moved to %s", m.Status))
+}
+
+// readMachine advances the transition in progress, as if time passed between reads.
+func (s *Server) readMachine(m *Machine) {
+	if m.pendingStatus == "" {
+		return
+	}
+
+	m.pendingReads--
+	if m.pendingReads < 0 {
+		s.finishTransition(m)
+	}
+}
+
+func (s *Server) handleMachines(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		if req.method == http.MethodPost && req.op == "allocate" {
+			return s.allocateMachine(req)
+		}
+		if req.method == http.MethodPost && req.op == "" {
+			return s.createMachine(req)
+		}
+		if req.method != http.MethodGet {
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+
+		machines := []map[string]any{}
+		for _, m := range s.machines {
+			if status := req.query.Get("status"); status != "" && !statusMatches(m.Status, status) {
+				continue
+			}
+			if hostnames := req.query["hostname"]; len(hostnames) > 0 && !slices.Contains(hostnames, m.Hostname) {
+				continue
+			}
+			if ids := req.query["id"]; len(ids) > 0 && !slices.Contains(ids, m.SystemID) {
+				continue
+			}
+			if tags := req.query["tags"]; len(tags) > 0 && !containsAll(m.TagNames, tags) {
+				continue
+			}
+			if zone := req.query.Get("zone"); zone != "" && zone != m.Zone {
+				continue
+			}
+			if pool := req.query.Get("pool"); pool != "" && pool != m.Pool {
+				continue
+			}
+			s.readMachine(m)
+			machines = append(machines, s.renderMachine(m))
+		}
+		return machines, nil
+	}
+
+	m := s.findMachine(rest[0])
+	if m == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch {
+	case req.method == http.MethodGet && req.op == "":
+		s.readMachine(m)
+		return s.renderMachine(m), nil
+	case req.method == http.MethodGet && req.op == "query_power_state":
+		// Like MAAS, the queried state replaces the cached one.
+		if m.BMCPowerState != "" {
+			m.PowerState, m.BMCPowerState = m.BMCPowerState, ""
+		}
+		return map[string]any{"state": m.PowerState}, nil
+	case req.method == http.MethodGet && req.op == "details":
+		return rawResponse{contentType: "application/bson", body: []byte(m.Details)}, nil
+	case req.method == http.MethodGet && req.op == "power_parameters":
+		return s.renderPowerParameters(m), nil
+	case req.method == http.MethodPut && req.op == "":
+		if err := s.updateMachine(m, req); err != nil {
+			return nil, err
+		}
+		return s.renderMachine(m), nil
+	case req.method == http.MethodDelete && req.op == "":
+		return s.deleteMachine(m)
+	case req.method == http.MethodPost:
+		if err := s.machineOperation(m, req); err != nil {
+			return nil, err
+		}
+		return s.renderMachine(m), nil
+	default:
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+}
+
+// allocateMachine allocates the first ready machine matching the constraints
+// of the form. Interface constraints are not modelled.
+func (s *Server) allocateMachine(req request) (any, error) {
+	cpuCount, _, err := formInt(req.form, "cpu_count")
+	if err != nil {
+		return nil, err
+	}
+	memory, _, err := formInt(req.form, "mem")
+	if err != nil {
+		return nil, err
+	}
+	storage, err := storageSize(req.form.Get("storage"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range s.machines {
+		switch {
+		case m.Status != StatusReady || m.Locked,
+			req.form.Has("name") && req.form.Get("name") != m.Hostname && req.form.Get("name") != m.Hostname+".maas",
+			req.form.Has("system_id") && req.form.Get("system_id") != m.SystemID,
+			req.form.Has("arch") && !strings.HasPrefix(m.Architecture, req.form.Get("arch")),
+			m.CPUCount < cpuCount || m.Memory < memory || m.Storage < storage*1000,
+			!containsAll(m.TagNames, req.form["tags"]),
+			slices.ContainsFunc(req.form["not_tags"], func(tag string) bool { return slices.Contains(m.TagNames, tag) }),
+			req.form.Has("zone") && req.form.Get("zone") != m.Zone,
+			req.form.Has("pool") && req.form.Get("pool") != m.Pool:
+			continue
+		}
+
+		m.Status = StatusAllocated
+		s.addEvent("INFO", m, "Allocated", valueOr(req.form.Get("comment"), "Machine allocated"))
+		return s.renderMachine(m), nil
+	}
+
+	return nil, conflict("No available machine matches constraints: %s", req.form.Encode())
+}
+
+// storageSize sums the sizes, in GB, of a storage constraint such as
+// "root:100(ssd),data:500".
+func storageSize(constraint string) (float64, error) {
+	var total float64
+	for _, disk := range strings.Split(constraint, ",") {
+		if disk == "" {
+			continue
+		}
+		if _, size, found := strings.Cut(disk, ":"); found {
+			disk = size
+		}
+		disk, _, _ = strings.Cut(disk, "(")
+		size, err := strconv.ParseFloat(disk, 64)
+		if err != nil {
+			return 0, badRequest(`{"storage": ["Malformed storage constraint, '%s'."]}`, constraint)
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// updateMachine applies the fields of the form to the machine.
+func (s *Server) updateMachine(m *Machine, req request) error {
+	if zone := req.form.Get("zone"); zone != "" {
+		if s.findZone(zone) == nil {
+			return badRequest(`{"zone": ["Select a valid choice. That choice is not one of the available choices."]}`)
+		}
+		m.Zone = zone
+	}
+	if pool := req.form.Get("pool"); pool != "" {
+		if s.findResourcePool(pool) == nil {
+			return badRequest(`{"pool": ["Select a valid choice. That choice is not one of the available choices."]}`)
+		}
+		m.Pool = pool
+	}
+	for key := range req.form {
+		if key == "power_type" || strings.HasPrefix(key, "power_parameters_") {
+			return s.updatePowerParameters(m, req)
+		}
+	}
+	return nil
+}
+
+func (s *Server) machineOperation(m *Machine, req request) error {
+	if m.Locked && req.op != "unlock" {
+		return conflict("Cannot %s node because the machine is locked.", req.op)
+	}
+
+	switch req.op {
+	case "commission":
+		if !slices.Contains([]string{StatusNew, StatusReady, StatusBroken, StatusFailedCommissioning, StatusFailedTesting}, m.Status) {
+			return conflict("Machine cannot be commissioned, it is %s.", m.Status)
+		}
+		s.startTransition(m, StatusCommissioning, StatusReady, "Commissioning")
+	case "deploy":
+		if m.Status != StatusReady && m.Status != StatusAllocated {
+			return conflict("Machine cannot be deployed, it is %s.", m.Status)
+		}
+		if userData := req.form.Get("user_data"); userData != "" {
+			decoded, err := base64.StdEncoding.DecodeString(userData)
+			if err != nil {
+				decoded = []byte(userData)
+			}
+			m.UserData = string(decoded)
+		}
+		m.OSystem = valueOr(req.form.Get("osystem"), "ubuntu")
+		m.DistroSeries = valueOr(req.form.Get("distro_series"), "noble")
+		m.HWEKernel = req.form.Get("hwe_kernel")
+		m.PowerState = "on"
+		s.startTransition(m, StatusDeploying, StatusDeployed, "Deploying")
+	case "release":
+		if !slices.Contains([]string{StatusDeployed, StatusAllocated, StatusFailedDeployment, StatusBroken, StatusFailedDiskErasing}, m.Status) {
+			return conflict("Machine cannot be released, it is %s.", m.Status)
+		}
+		m.OSystem, m.DistroSeries, m.HWEKernel, m.UserData = "", "", "", ""
+		if erase, _ := formBool(req.form, "erase"); erase {
+			s.startTransition(m, StatusDiskErasing, StatusReady, "Erasing disks")
+		} else {
+			s.startTransition(m, StatusReleasing, StatusReady, "Releasing")
+		}
+	case "abort":
+		previous := map[string]string{
+			StatusCommissioning: StatusNew,
+			StatusDeploying:     StatusAllocated,
+			StatusDiskErasing:   StatusFailedDiskErasing,
+			StatusTesting:       StatusReady,
+		}
+		status, ok := previous[m.Status]
+		if !ok {
+			return conflict("No action to abort, machine is %s.", m.Status)
+		}
+		if m.Status == StatusCommissioning && m.previousStatus != "" {
+			status = m.previousStatus
+		}
+		m.Status = status
+		m.pendingStatus = ""
+		s.addEvent("INFO", m, "Aborted", fmt.Sprintf("Machine moved to %s", status))
+	case "set_storage_layout":
+		return s.setStorageLayout(m, req)
+	case "rescue_mode":
+		if !slices.Contains([]string{StatusDeployed, StatusReady, StatusBroken, StatusFailedDeployment, StatusFailedCommissioning, StatusFailedTesting}, m.Status) {
+			return conflict("Machine cannot enter rescue mode, it is %s.", m.Status)
+		}
+		s.startTransition(m, StatusEnteringRescueMode, StatusRescueMode, "Entering rescue mode")
+	case "exit_rescue_mode":
+		if m.Status != StatusRescueMode {
+			return conflict("Machine is not in rescue mode, it is %s.", m.Status)
+		}
+		previous := m.previousStatus
+		s.startTransition(m, StatusExitingRescueMode, valueOr(previous, StatusReady), "Exiting rescue mode")
+	case "power_on":
+		m.PowerState, m.BMCPowerState = "on", ""
+		s.addEvent("INFO", m, "Powering on", "")
+	case "power_off":
+		m.PowerState, m.BMCPowerState = "off", ""
+		s.addEvent("INFO", m, "Powering off", "")
+	case "lock":
+		m.Locked = true
+	case "unlock":
+		m.Locked = false
+	default:
+		return badRequest("Unrecognised signature: method=POST op=%s", req.op)
+	}
+
+	return nil
+}
+
+func (s *Server) renderMachine(m *Machine) map[string]any {
+	interfaces := make([]map[string]any, 0, len(m.Interfaces))
+	ipAddresses := []string{}
+	for _, iface := range m.Interfaces {
+		interfaces = append(interfaces, s.renderInterface(m, iface))
+		for _, link := range iface.Links {
+			if link.IPAddress != "" {
+				ipAddresses = append(ipAddresses, link.IPAddress)
+			}
+		}
+	}
+
+	tagNames := append([]string{}, m.TagNames...)
+
+	machine := map[string]any{
+		"system_id":        m.SystemID,
+		"hostname":         m.Hostname,
+		"fqdn":             m.Hostname + ".maas",
+		"status":           statusCodes[m.Status],
+		"status_name":      m.Status,
+		"power_state":      m.PowerState,
+		"power_type":       m.PowerType,
+		"architecture":     m.Architecture,
+		"cpu_count":        m.CPUCount,
+		"memory":           m.Memory,
+		"storage":          m.Storage,
+		"osystem":          m.OSystem,
+		"distro_series":    m.DistroSeries,
+		"hwe_kernel":       m.HWEKernel,
+		"locked":           m.Locked,
+		"tag_names":        tagNames,
+		"ip_addresses":     ipAddresses,
+		"interface_set":    interfaces,
+		"default_gateways": s.renderDefaultGateways(m),
+		"hardware_info":    map[string]any{"cpu_model": m.CPUModel},
+		"zone":             map[string]any{"name": m.Zone},
+		"pool":             map[string]any{"name": m.Pool},
+		"node_type_name":   "Machine",
+		"resource_uri":     fmt.Sprintf("/MAAS/api/2.0/machines/%s/", m.SystemID),
+	}
+
+	if len(interfaces) > 0 {
+		machine["boot_interface"] = interfaces[0]
+	}
+
+	devices := make([]map[string]any, 0, len(m.BlockDevices))
+	for i := range m.BlockDevices {
+		devices = append(devices, s.renderBlockDevice(m, &m.BlockDevices[i]))
+	}
+	machine["blockdevice_set"] = devices
+	if disk := bootDisk(m); disk != nil {
+		machine["boot_disk"] = s.renderBlockDevice(m, disk)
+	}
+
+	if m.VMHostID != 0 {
+		machine["virtualmachine_id"] = m.VirtualMachineID
+		machine["pod"] = map[string]any{"id": m.VMHostID, "name": s.vmHostName(m.VMHostID)}
+	}
+
+	return machine
+}
+
+func (s *Server) renderInterface(m *Machine, iface Interface) map[string]any {
+	links := make([]map[string]any, 0, len(iface.Links))
+	for _, link := range iface.Links {
+		rendered := map[string]any{
+			"id":   link.ID,
+			"mode": valueOr(link.Mode, "auto"),
+		}
+		if link.IPAddress != "" {
+			rendered["ip_address"] = link.IPAddress
+		}
+		if subnet := s.findSubnet(link.SubnetID); subnet != nil {
+			rendered["subnet"] = s.renderSubnet(subnet)
+		}
+		links = append(links, rendered)
+	}
+
+	rendered := map[string]any{
+		"id":           iface.ID,
+		"name":         iface.Name,
+		"type":         iface.Type,
+		"mac_address":  iface.MACAddress,
+		"parents":      append([]string{}, iface.Parents...),
+		"children":     append([]string{}, interfaceChildren(m, iface.Name)...),
+		"links":        links,
+		"system_id":    m.SystemID,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/nodes/%s/interfaces/%d/", m.SystemID, iface.ID),
+	}
+	if vlan := s.findVLAN(iface.VLANID); vlan != nil {
+		rendered["vlan"] = s.renderVLAN(vlan)
+	}
+	return rendered
+}
+
+func (s *Server) handleInstallationResults(req request, rest []string) (any, error) {
+	if len(rest) != 0 || req.method != http.MethodGet {
+		return nil, notFound()
+	}
+
+	results := []map[string]any{}
+	for _, m := range s.machines {
+		if systemIDs := req.query["system_id"]; len(systemIDs) > 0 && !slices.Contains(systemIDs, m.SystemID) {
+			continue
+		}
+		for _, result := range m.ScriptResults {
+			results = append(results, map[string]any{
+				"id":            result.ID,
+				"name":          result.Name,
+				"script_result": result.ScriptResult,
+				"result_type":   result.ResultType,
+				"node":          map[string]any{"system_id": m.SystemID},
+				"data":          base64.StdEncoding.EncodeToString([]byte(result.Output)),
+				"created":       timestamp(),
+				"updated":       timestamp(),
+			})
+		}
+	}
+	return results, nil
+}
+
+// statusMatches compares a status name with a status filter such as "failed_deployment".
+func statusMatches(status, filter string) bool {
+	return strings.EqualFold(strings.ReplaceAll(status, " ", "_"), filter)
+}
+
+func containsAll(values, required []string) bool {
+	for _, r := range required {
+		if !slices.Contains(values, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
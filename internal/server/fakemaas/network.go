@@ -0,0 +1,982 @@
+package fakemaas
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Fabric is a fabric known to the fake.
+type Fabric struct {
+	ID          int
+	Name        string
+	Description string
+	ClassType   string
+}
+
+// VLAN is a VLAN of a fabric.
+type VLAN struct {
+	ID            int
+	FabricID      int
+	VID           int
+	Name          string
+	Description   string
+	MTU           int
+	Space         string
+	DHCPOn        bool
+	PrimaryRack   string
+	SecondaryRack string
+	RelayVLAN     int
+}
+
+// Subnet is a subnet known to the fake.
+type Subnet struct {
+	ID                        int
+	Name                      string
+	CIDR                      string
+	Description               string
+	VLANID                    int
+	Space                     string
+	GatewayIP                 string
+	DNSServers                []string
+	Managed                   bool
+	AllowDNS                  bool
+	AllowProxy                bool
+	RDNSMode                  int
+	DisabledBootArchitectures []string
+	IPRanges                  []IPRange
+}
+
+// Space is a space known to the fake. VLANs and subnets reference spaces by
+// name.
+type Space struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+// IPRange is a reserved or dynamic range of a subnet.
+type IPRange struct {
+	ID      int
+	Type    string
+	StartIP string
+	EndIP   string
+	Comment string
+}
+
+// AddFabric adds a fabric together with its untagged VLAN and returns it.
+func (s *Server) AddFabric(f Fabric) Fabric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.createFabric(f)
+}
+
+// AddVLAN adds a VLAN to an existing fabric and returns it.
+func (s *Server) AddVLAN(v VLAN) VLAN {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.createVLAN(v)
+}
+
+// AddSubnet adds a subnet and returns it. It is placed on the untagged VLAN
+// of the first fabric when VLANID is not set.
+func (s *Server) AddSubnet(subnet Subnet) Subnet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subnet.VLANID == 0 {
+		subnet.VLANID = s.defaultVLAN().ID
+	}
+	return *s.createSubnet(subnet)
+}
+
+// AddSpace adds a space and returns it.
+func (s *Server) AddSpace(space Space) Space {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if space.ID == 0 {
+		space.ID = s.newID()
+	}
+	stored := space
+	s.spaces = append(s.spaces, &stored)
+	return stored
+}
+
+// Fabrics returns a copy of the fabrics.
+func (s *Server) Fabrics() []Fabric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fabrics := make([]Fabric, 0, len(s.fabrics))
+	for _, f := range s.fabrics {
+		fabrics = append(fabrics, *f)
+	}
+	return fabrics
+}
+
+// VLANs returns a copy of the VLANs.
+func (s *Server) VLANs() []VLAN {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vlans := make([]VLAN, 0, len(s.vlans))
+	for _, v := range s.vlans {
+		vlans = append(vlans, *v)
+	}
+	return vlans
+}
+
+// Subnets returns a copy of the subnets.
+func (s *Server) Subnets() []Subnet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subnets := make([]Subnet, 0, len(s.subnets))
+	for _, subnet := range s.subnets {
+		subnets = append(subnets, *subnet)
+	}
+	return subnets
+}
+
+// Spaces returns a copy of the spaces.
+func (s *Server) Spaces() []Space {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spaces := make([]Space, 0, len(s.spaces))
+	for _, space := range s.spaces {
+		spaces = append(spaces, *space)
+	}
+	return spaces
+}
+
+func (s *Server) createFabric(f Fabric) *Fabric {
+	if f.ID == 0 {
+		f.ID = s.newID()
+	}
+	if f.Name == "" {
+		f.Name = fmt.Sprintf("fabric-%d", f.ID)
+	}
+
+	stored := f
+	s.fabrics = append(s.fabrics, &stored)
+	s.createVLAN(VLAN{FabricID: f.ID, VID: 0, Name: "untagged"})
+	return &stored
+}
+
+func (s *Server) createVLAN(v VLAN) *VLAN {
+	if v.ID == 0 {
+		v.ID = s.newID()
+	}
+	if v.Name == "" {
+		v.Name = strconv.Itoa(v.VID)
+	}
+	if v.MTU == 0 {
+		v.MTU = 1500
+	}
+
+	stored := v
+	s.vlans = append(s.vlans, &stored)
+	return &stored
+}
+
+func (s *Server) createSubnet(subnet Subnet) *Subnet {
+	if subnet.ID == 0 {
+		subnet.ID = s.newID()
+	}
+	if subnet.Name == "" {
+		subnet.Name = subnet.CIDR
+	}
+	subnet.IPRanges = slices.Clone(subnet.IPRanges)
+	for i := range subnet.IPRanges {
+		if subnet.IPRanges[i].ID == 0 {
+			subnet.IPRanges[i].ID = s.newID()
+		}
+	}
+
+	stored := subnet
+	s.subnets = append(s.subnets, &stored)
+	return &stored
+}
+
+func (s *Server) defaultVLAN() *VLAN {
+	if len(s.fabrics) == 0 {
+		s.createFabric(Fabric{})
+	}
+	return s.findFabricVLAN(s.fabrics[0].ID, 0)
+}
+
+func (s *Server) findFabric(id int) *Fabric {
+	for _, f := range s.fabrics {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *Server) findVLAN(id int) *VLAN {
+	for _, v := range s.vlans {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *Server) findFabricVLAN(fabricID, vid int) *VLAN {
+	for _, v := range s.vlans {
+		if v.FabricID == fabricID && v.VID == vid {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *Server) findSubnet(id int) *Subnet {
+	for _, subnet := range s.subnets {
+		if subnet.ID == id {
+			return subnet
+		}
+	}
+	return nil
+}
+
+func (s *Server) findSpace(name string) *Space {
+	for _, space := range s.spaces {
+		if space.Name == name {
+			return space
+		}
+	}
+	return nil
+}
+
+// subnetSpace returns the space of a subnet, which is the space of its VLAN
+// unless the subnet names one itself.
+func (s *Server) subnetSpace(subnet *Subnet) string {
+	if subnet.Space != "" {
+		return subnet.Space
+	}
+	if v := s.findVLAN(subnet.VLANID); v != nil {
+		return v.Space
+	}
+	return ""
+}
+
+func (s *Server) renderFabric(f *Fabric) map[string]any {
+	vlans := []map[string]any{}
+	for _, v := range s.vlans {
+		if v.FabricID == f.ID {
+			vlans = append(vlans, s.renderVLAN(v))
+		}
+	}
+
+	return map[string]any{
+		"id":           f.ID,
+		"name":         f.Name,
+		"description":  f.Description,
+		"class_type":   nullable(f.ClassType),
+		"vlans":        vlans,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/fabrics/%d/", f.ID),
+	}
+}
+
+func (s *Server) renderVLAN(v *VLAN) map[string]any {
+	fabricName := ""
+	if f := s.findFabric(v.FabricID); f != nil {
+		fabricName = f.Name
+	}
+
+	var relayVLAN any
+	if v.RelayVLAN != 0 {
+		relayVLAN = v.RelayVLAN
+	}
+
+	return map[string]any{
+		"id":             v.ID,
+		"vid":            v.VID,
+		"name":           v.Name,
+		"description":    v.Description,
+		"fabric":         fabricName,
+		"fabric_id":      v.FabricID,
+		"mtu":            v.MTU,
+		"space":          valueOr(v.Space, "undefined"),
+		"dhcp_on":        v.DHCPOn,
+		"primary_rack":   nullable(v.PrimaryRack),
+		"secondary_rack": nullable(v.SecondaryRack),
+		"relay_vlan":     relayVLAN,
+		"external_dhcp":  nil,
+		"resource_uri":   fmt.Sprintf("/MAAS/api/2.0/vlans/%d/", v.ID),
+	}
+}
+
+func (s *Server) renderSubnet(subnet *Subnet) map[string]any {
+	var vlan any
+	if v := s.findVLAN(subnet.VLANID); v != nil {
+		vlan = s.renderVLAN(v)
+	}
+
+	return map[string]any{
+		"id":                          subnet.ID,
+		"name":                        subnet.Name,
+		"cidr":                        subnet.CIDR,
+		"description":                 subnet.Description,
+		"vlan":                        vlan,
+		"space":                       valueOr(s.subnetSpace(subnet), "undefined"),
+		"gateway_ip":                  nullable(subnet.GatewayIP),
+		"dns_servers":                 append([]string{}, subnet.DNSServers...),
+		"managed":                     subnet.Managed,
+		"allow_dns":                   subnet.AllowDNS,
+		"allow_proxy":                 subnet.AllowProxy,
+		"rdns_mode":                   subnet.RDNSMode,
+		"active_discovery":            false,
+		"disabled_boot_architectures": append([]string{}, subnet.DisabledBootArchitectures...),
+		"resource_uri":                fmt.Sprintf("/MAAS/api/2.0/subnets/%d/", subnet.ID),
+	}
+}
+
+func (s *Server) renderSpace(space *Space) map[string]any {
+	vlans := []map[string]any{}
+	for _, v := range s.vlans {
+		if v.Space == space.Name {
+			vlans = append(vlans, s.renderVLAN(v))
+		}
+	}
+	subnets := []map[string]any{}
+	for _, subnet := range s.subnets {
+		if s.subnetSpace(subnet) == space.Name {
+			subnets = append(subnets, s.renderSubnet(subnet))
+		}
+	}
+
+	return map[string]any{
+		"id":           space.ID,
+		"name":         space.Name,
+		"description":  space.Description,
+		"vlans":        vlans,
+		"subnets":      subnets,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/spaces/%d/", space.ID),
+	}
+}
+
+// renameSpace moves the VLANs and subnets of a space to another space, or
+// to the undefined space when to is empty.
+func (s *Server) renameSpace(from, to string) {
+	for _, v := range s.vlans {
+		if v.Space == from {
+			v.Space = to
+		}
+	}
+	for _, subnet := range s.subnets {
+		if subnet.Space == from {
+			subnet.Space = to
+		}
+	}
+}
+
+func (s *Server) handleSpaces(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			spaces := []map[string]any{}
+			for _, space := range s.spaces {
+				spaces = append(spaces, s.renderSpace(space))
+			}
+			return spaces, nil
+		case http.MethodPost:
+			name := req.form.Get("name")
+			if !tagNamePattern.MatchString(name) || name == "undefined" {
+				return nil, badRequest(`{"name": ["Enter a valid name."]}`)
+			}
+			if s.findSpace(name) != nil {
+				return nil, badRequest(`{"name": ["Space with this Name already exists."]}`)
+			}
+			space := &Space{ID: s.newID(), Name: name, Description: req.form.Get("description")}
+			s.spaces = append(s.spaces, space)
+			return s.renderSpace(space), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(s.spaces, func(space *Space) bool { return space.ID == id })
+	if index < 0 || len(rest) > 1 {
+		return nil, notFound()
+	}
+	space := s.spaces[index]
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderSpace(space), nil
+	case http.MethodPut:
+		if name := req.form.Get("name"); name != "" && name != space.Name {
+			if !tagNamePattern.MatchString(name) || name == "undefined" {
+				return nil, badRequest(`{"name": ["Enter a valid name."]}`)
+			}
+			if s.findSpace(name) != nil {
+				return nil, badRequest(`{"name": ["Space with this Name already exists."]}`)
+			}
+			s.renameSpace(space.Name, name)
+			space.Name = name
+		}
+		if req.form.Has("description") {
+			space.Description = req.form.Get("description")
+		}
+		return s.renderSpace(space), nil
+	case http.MethodDelete:
+		s.renameSpace(space.Name, "")
+		s.spaces = slices.Delete(s.spaces, index, index+1)
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) handleFabrics(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			fabrics := []map[string]any{}
+			for _, f := range s.fabrics {
+				fabrics = append(fabrics, s.renderFabric(f))
+			}
+			return fabrics, nil
+		case http.MethodPost:
+			f := s.createFabric(Fabric{
+				Name:        req.form.Get("name"),
+				Description: req.form.Get("description"),
+				ClassType:   req.form.Get("class_type"),
+			})
+			return s.renderFabric(f), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	f := s.findFabric(id)
+	if f == nil {
+		return nil, notFound()
+	}
+
+	if len(rest) > 1 {
+		if rest[1] != "vlans" {
+			return nil, notFound()
+		}
+		return s.handleVLANs(req, f, rest[2:])
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderFabric(f), nil
+	case http.MethodPut:
+		if req.form.Has("name") {
+			f.Name = req.form.Get("name")
+		}
+		if req.form.Has("description") {
+			f.Description = req.form.Get("description")
+		}
+		if req.form.Has("class_type") {
+			f.ClassType = req.form.Get("class_type")
+		}
+		return s.renderFabric(f), nil
+	case http.MethodDelete:
+		vlanIDs := []int{}
+		for _, v := range s.vlans {
+			if v.FabricID == f.ID {
+				vlanIDs = append(vlanIDs, v.ID)
+			}
+		}
+		s.subnets = slices.DeleteFunc(s.subnets, func(subnet *Subnet) bool { return slices.Contains(vlanIDs, subnet.VLANID) })
+		s.vlans = slices.DeleteFunc(s.vlans, func(v *VLAN) bool { return v.FabricID == f.ID })
+		s.fabrics = slices.DeleteFunc(s.fabrics, func(other *Fabric) bool { return other.ID == f.ID })
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) handleVLANs(req request, f *Fabric, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			vlans := []map[string]any{}
+			for _, v := range s.vlans {
+				if v.FabricID == f.ID {
+					vlans = append(vlans, s.renderVLAN(v))
+				}
+			}
+			return vlans, nil
+		case http.MethodPost:
+			vid, _, err := formInt(req.form, "vid")
+			if err != nil {
+				return nil, err
+			}
+			if vid < 1 || vid > 4094 {
+				return nil, badRequest(`{"vid": ["VID must be between 1 and 4094."]}`)
+			}
+			if s.findFabricVLAN(f.ID, vid) != nil {
+				return nil, badRequest(`{"__all__": ["VLAN with this Vid and Fabric already exists."]}`)
+			}
+
+			v := VLAN{FabricID: f.ID, VID: vid, Name: req.form.Get("name"), Description: req.form.Get("description"), Space: req.form.Get("space")}
+			if mtu, ok, err := formInt(req.form, "mtu"); err != nil {
+				return nil, err
+			} else if ok {
+				v.MTU = mtu
+			}
+			return s.renderVLAN(s.createVLAN(v)), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	vid, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	v := s.findFabricVLAN(f.ID, vid)
+	if v == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderVLAN(v), nil
+	case http.MethodPut:
+		for key, target := range map[string]*string{
+			"name":           &v.Name,
+			"description":    &v.Description,
+			"space":          &v.Space,
+			"primary_rack":   &v.PrimaryRack,
+			"secondary_rack": &v.SecondaryRack,
+		} {
+			if req.form.Has(key) {
+				*target = req.form.Get(key)
+			}
+		}
+		if mtu, ok, err := formInt(req.form, "mtu"); err != nil {
+			return nil, err
+		} else if ok {
+			v.MTU = mtu
+		}
+		if relay, ok, err := formInt(req.form, "relay_vlan"); err != nil {
+			return nil, err
+		} else if ok {
+			v.RelayVLAN = relay
+		}
+		if dhcpOn, ok := formBool(req.form, "dhcp_on"); ok {
+			if dhcpOn && v.PrimaryRack == "" {
+				return nil, badRequest(`{"dhcp_on": ["dhcp can only be turned on when a primary rack controller is set."]}`)
+			}
+			v.DHCPOn = dhcpOn
+		}
+		return s.renderVLAN(v), nil
+	case http.MethodDelete:
+		if v.VID == 0 {
+			return nil, badRequest("The default VLAN of a fabric cannot be deleted.")
+		}
+		s.subnets = slices.DeleteFunc(s.subnets, func(subnet *Subnet) bool { return subnet.VLANID == v.ID })
+		s.vlans = slices.DeleteFunc(s.vlans, func(other *VLAN) bool { return other.ID == v.ID })
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) handleSubnets(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			subnets := []map[string]any{}
+			for _, subnet := range s.subnets {
+				subnets = append(subnets, s.renderSubnet(subnet))
+			}
+			return subnets, nil
+		case http.MethodPost:
+			if !req.form.Has("cidr") {
+				return nil, badRequest(`{"cidr": ["This field is required."]}`)
+			}
+			subnet := &Subnet{Managed: true}
+			if err := s.applySubnetForm(subnet, req); err != nil {
+				return nil, err
+			}
+			if subnet.VLANID == 0 {
+				subnet.VLANID = s.defaultVLAN().ID
+			}
+			return s.renderSubnet(s.createSubnet(*subnet)), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	subnet := s.findSubnet(id)
+	if subnet == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch {
+	case req.method == http.MethodGet && req.op == "":
+		return s.renderSubnet(subnet), nil
+	case req.method == http.MethodGet && req.op == "ip_addresses":
+		return s.subnetIPAddresses(subnet, req), nil
+	case req.method == http.MethodGet && req.op == "reserved_ip_ranges":
+		return s.reservedRanges(subnet), nil
+	case req.method == http.MethodGet && req.op == "unreserved_ip_ranges":
+		return s.unreservedRanges(subnet), nil
+	case req.method == http.MethodGet && req.op == "statistics":
+		return s.subnetStatistics(subnet, req), nil
+	case req.method == http.MethodPut:
+		if err := s.applySubnetForm(subnet, req); err != nil {
+			return nil, err
+		}
+		return s.renderSubnet(subnet), nil
+	case req.method == http.MethodDelete:
+		s.subnets = slices.DeleteFunc(s.subnets, func(other *Subnet) bool { return other.ID == subnet.ID })
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) applySubnetForm(subnet *Subnet, req request) error {
+	form := req.form
+
+	if form.Has("cidr") {
+		prefix, err := netip.ParsePrefix(form.Get("cidr"))
+		if err != nil || prefix.Masked() != prefix {
+			return badRequest(`{"cidr": ["Required format: <network>/<prefixlen>."]}`)
+		}
+		subnet.CIDR = prefix.String()
+	}
+
+	if form.Has("gateway_ip") {
+		gateway, err := netip.ParseAddr(form.Get("gateway_ip"))
+		if err != nil || !mustPrefix(subnet.CIDR).Contains(gateway) {
+			return badRequest(`{"gateway_ip": ["Gateway IP must be within CIDR range."]}`)
+		}
+		subnet.GatewayIP = gateway.String()
+	}
+
+	switch {
+	case form.Has("vlan"):
+		id, err := strconv.Atoi(form.Get("vlan"))
+		if err != nil || s.findVLAN(id) == nil {
+			return badRequest(`{"vlan": ["Select a valid choice."]}`)
+		}
+		subnet.VLANID = id
+	case form.Has("fabric") || form.Has("vid"):
+		fabricID := s.fabrics[0].ID
+		if form.Has("fabric") {
+			id, err := strconv.Atoi(form.Get("fabric"))
+			if err != nil || s.findFabric(id) == nil {
+				return badRequest(`{"fabric": ["Select a valid choice."]}`)
+			}
+			fabricID = id
+		}
+		vid, _ := strconv.Atoi(form.Get("vid"))
+		vlan := s.findFabricVLAN(fabricID, vid)
+		if vlan == nil {
+			return badRequest(`{"vid": ["No VLAN with vid %d on fabric %d."]}`, vid, fabricID)
+		}
+		subnet.VLANID = vlan.ID
+	}
+
+	if form.Has("name") {
+		subnet.Name = form.Get("name")
+	}
+	if form.Has("description") {
+		subnet.Description = form.Get("description")
+	}
+	if form.Has("space") {
+		subnet.Space = form.Get("space")
+	}
+	if form.Has("dns_servers") {
+		subnet.DNSServers = strings.FieldsFunc(form.Get("dns_servers"), func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	if form.Has("disabled_boot_architectures") {
+		subnet.DisabledBootArchitectures = strings.FieldsFunc(form.Get("disabled_boot_architectures"), func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	if mode, ok, err := formInt(form, "rdns_mode"); err != nil {
+		return err
+	} else if ok {
+		subnet.RDNSMode = mode
+	}
+	for key, target := range map[string]*bool{
+		"managed":     &subnet.Managed,
+		"allow_dns":   &subnet.AllowDNS,
+		"allow_proxy": &subnet.AllowProxy,
+	} {
+		if value, ok := formBool(form, key); ok {
+			*target = value
+		}
+	}
+
+	return nil
+}
+
+// addressRange is an inclusive range of addresses with the reasons it is reserved.
+type addressRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	purpose []string
+}
+
+// usableRange returns the first and last address of a subnet that can be
+// assigned. The network and broadcast addresses of IPv4 subnets are excluded.
+func usableRange(prefix netip.Prefix) (netip.Addr, netip.Addr) {
+	first := prefix.Addr()
+	last := first
+	for i := prefix.Bits(); i < first.BitLen(); i++ {
+		last = setBit(last, first.BitLen()-1-i)
+	}
+
+	if first.Is4() && prefix.Bits() < 31 {
+		first, last = first.Next(), last.Prev()
+	}
+	return first, last
+}
+
+func setBit(addr netip.Addr, bit int) netip.Addr {
+	bytes := addr.AsSlice()
+	bytes[len(bytes)-1-bit/8] |= 1 << (bit % 8)
+	result, _ := netip.AddrFromSlice(bytes)
+	return result
+}
+
+func mustPrefix(cidr string) netip.Prefix {
+	prefix, _ := netip.ParsePrefix(cidr)
+	return prefix
+}
+
+// usedAddresses returns the addresses of machines linked to the subnet.
+func (s *Server) usedAddresses(subnet *Subnet) []struct {
+	addr    netip.Addr
+	machine *Machine
+	mode    string
+} {
+	var used []struct {
+		addr    netip.Addr
+		machine *Machine
+		mode    string
+	}
+	for _, m := range s.machines {
+		for _, iface := range m.Interfaces {
+			for _, link := range iface.Links {
+				addr, err := netip.ParseAddr(link.IPAddress)
+				if link.SubnetID != subnet.ID || err != nil {
+					continue
+				}
+				used = append(used, struct {
+					addr    netip.Addr
+					machine *Machine
+					mode    string
+				}{addr, m, valueOr(link.Mode, "auto")})
+			}
+		}
+	}
+	return used
+}
+
+func (s *Server) reservedAddressRanges(subnet *Subnet) []addressRange {
+	var ranges []addressRange
+
+	if gateway, err := netip.ParseAddr(subnet.GatewayIP); err == nil {
+		ranges = append(ranges, addressRange{start: gateway, end: gateway, purpose: []string{"gateway-ip"}})
+	}
+	for _, r := range subnet.IPRanges {
+		start, errStart := netip.ParseAddr(r.StartIP)
+		end, errEnd := netip.ParseAddr(r.EndIP)
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+		ranges = append(ranges, addressRange{start: start, end: end, purpose: []string{valueOr(r.Type, "reserved")}})
+	}
+	for _, used := range s.usedAddresses(subnet) {
+		ranges = append(ranges, addressRange{start: used.addr, end: used.addr, purpose: []string{"assigned-ip"}})
+	}
+
+	slices.SortFunc(ranges, func(a, b addressRange) int { return a.start.Compare(b.start) })
+
+	// Merge overlapping and adjacent ranges.
+	var merged []addressRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.start.Compare(merged[n-1].end.Next()) <= 0 {
+			if r.end.Compare(merged[n-1].end) > 0 {
+				merged[n-1].end = r.end
+			}
+			for _, purpose := range r.purpose {
+				if !slices.Contains(merged[n-1].purpose, purpose) {
+					merged[n-1].purpose = append(merged[n-1].purpose, purpose)
+				}
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func (s *Server) unreservedAddressRanges(subnet *Subnet) []addressRange {
+	first, last := usableRange(mustPrefix(subnet.CIDR))
+
+	var free []addressRange
+	next := first
+	for _, r := range s.reservedAddressRanges(subnet) {
+		if r.start.Compare(next) > 0 {
+			free = append(free, addressRange{start: next, end: minAddr(r.start.Prev(), last)})
+		}
+		if r.end.Compare(next) >= 0 {
+			next = r.end.Next()
+		}
+		if next.Compare(last) > 0 || !next.IsValid() {
+			return free
+		}
+	}
+	return append(free, addressRange{start: next, end: last})
+}
+
+func minAddr(a, b netip.Addr) netip.Addr {
+	if a.Compare(b) < 0 {
+		return a
+	}
+	return b
+}
+
+// rangeSize returns the number of addresses in an inclusive range.
+func rangeSize(start, end netip.Addr) *big.Int {
+	a := new(big.Int).SetBytes(start.AsSlice())
+	b := new(big.Int).SetBytes(end.AsSlice())
+	return b.Sub(b, a).Add(b, big.NewInt(1))
+}
+
+func renderRanges(ranges []addressRange, withPurpose bool) []map[string]any {
+	rendered := []map[string]any{}
+	for _, r := range ranges {
+		item := map[string]any{
+			"start":         r.start.String(),
+			"end":           r.end.String(),
+			"num_addresses": rangeSize(r.start, r.end).Int64(),
+		}
+		if withPurpose {
+			item["purpose"] = r.purpose
+		}
+		rendered = append(rendered, item)
+	}
+	return rendered
+}
+
+func (s *Server) reservedRanges(subnet *Subnet) []map[string]any {
+	return renderRanges(s.reservedAddressRanges(subnet), true)
+}
+
+func (s *Server) unreservedRanges(subnet *Subnet) []map[string]any {
+	return renderRanges(s.unreservedAddressRanges(subnet), false)
+}
+
+func (s *Server) subnetIPAddresses(subnet *Subnet, req request) []map[string]any {
+	withSummary := req.query.Get("with_summary") != "0"
+	withUsername := req.query.Get("with_username") != "0"
+
+	addresses := []map[string]any{}
+	for _, used := range s.usedAddresses(subnet) {
+		address := map[string]any{
+			"ip":              used.addr.String(),
+			"alloc_type":      1,
+			"alloc_type_name": "Automatic",
+			"created":         timestamp(),
+			"updated":         timestamp(),
+		}
+		if withUsername {
+			address["user"] = "admin"
+		}
+		if withSummary {
+			address["node_summary"] = map[string]any{
+				"system_id": used.machine.SystemID,
+				"hostname":  used.machine.Hostname,
+				"node_type": 0,
+				"via":       "eth0",
+			}
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+func (s *Server) subnetStatistics(subnet *Subnet, req request) map[string]any {
+	prefix := mustPrefix(subnet.CIDR)
+	first, last := usableRange(prefix)
+	total := rangeSize(first, last)
+
+	available := new(big.Int)
+	largest := new(big.Int)
+	for _, r := range s.unreservedAddressRanges(subnet) {
+		size := rangeSize(r.start, r.end)
+		available.Add(available, size)
+		if size.Cmp(largest) > 0 {
+			largest = size
+		}
+	}
+	unavailable := new(big.Int).Sub(total, available)
+
+	usage, _ := new(big.Float).Quo(new(big.Float).SetInt(unavailable), new(big.Float).SetInt(total)).Float64()
+
+	ipVersion := 4
+	if prefix.Addr().Is6() {
+		ipVersion = 6
+	}
+
+	statistics := map[string]any{
+		"num_available":     available.Int64(),
+		"largest_available": largest.Int64(),
+		"num_unavailable":   unavailable.Int64(),
+		"total_addresses":   total.Int64(),
+		"usage":             usage,
+		"usage_string":      fmt.Sprintf("%.0f%%", usage*100),
+		"available_string":  fmt.Sprintf("%.0f%%", (1-usage)*100),
+		"first_address":     first.String(),
+		"last_address":      last.String(),
+		"ip_version":        ipVersion,
+	}
+
+	if req.query.Get("include_ranges") == "1" {
+		ranges := renderRanges(s.reservedAddressRanges(subnet), true)
+		for _, r := range renderRanges(s.unreservedAddressRanges(subnet), false) {
+			r["purpose"] = []string{"unused"}
+			ranges = append(ranges, r)
+		}
+		statistics["ranges"] = ranges
+	}
+
+	if req.query.Get("include_suggestions") == "1" {
+		if subnet.GatewayIP == "" {
+			statistics["suggested_gateway"] = first.String()
+		}
+		if free := s.unreservedAddressRanges(subnet); len(free) > 0 {
+			statistics["suggested_dynamic_range"] = map[string]any{
+				"start": free[len(free)-1].start.String(),
+				"end":   free[len(free)-1].end.String(),
+			}
+		}
+	}
+
+	return statistics
+}
+
+// nullable renders empty strings as JSON null, like MAAS does for unset references.
+func nullable(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,137 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// powerTypes are the power types the fake accepts, with the power parameters
+// MAAS requires for each unless the check is skipped.
+var powerTypes = map[string][]string{
+	"ipmi":    {"power_address"},
+	"redfish": {"power_address"},
+	"virsh":   {"power_address", "power_id"},
+	"lxd":     {"power_address", "instance_name"},
+	"manual":  nil,
+}
+
+// createMachine enlists a machine with a physical interface per MAC address.
+func (s *Server) createMachine(req request) (any, error) {
+	architecture := req.form.Get("architecture")
+	if architecture == "" {
+		return nil, badRequest(`{"architecture": ["This field is required."]}`)
+	}
+
+	macs := req.form["mac_addresses"]
+	if len(macs) == 0 {
+		return nil, badRequest(`{"mac_addresses": ["This field is required."]}`)
+	}
+	for _, mac := range macs {
+		if _, err := net.ParseMAC(mac); err != nil {
+			return nil, badRequest(`{"mac_addresses": ["One or more MAC addresses is invalid. ('%s' is not a valid MAC address.)"]}`, mac)
+		}
+		if s.findMachineByMAC(mac) != nil {
+			return nil, badRequest(`{"mac_addresses": ["One or more MAC addresses is invalid. (MAC address %s already in use.)"]}`, mac)
+		}
+	}
+
+	hostname := req.form.Get("hostname")
+	if hostname != "" && slices.ContainsFunc(s.machines, func(m *Machine) bool { return m.Hostname == hostname }) {
+		return nil, badRequest(`{"hostname": ["Node with this Hostname already exists."]}`)
+	}
+
+	m := &Machine{
+		SystemID:     s.newSystemID(),
+		Hostname:     hostname,
+		Status:       StatusNew,
+		PowerState:   "unknown",
+		PowerType:    valueOr(req.form.Get("power_type"), "manual"),
+		Architecture: architecture,
+		Zone:         "default",
+		Pool:         "default",
+	}
+	if m.Hostname == "" {
+		m.Hostname = "machine-" + m.SystemID
+	}
+	for i, mac := range macs {
+		m.Interfaces = append(m.Interfaces, Interface{ID: s.newID(), Name: fmt.Sprintf("eth%d", i), Type: "physical", MACAddress: mac})
+	}
+
+	if err := s.updateMachine(m, req); err != nil {
+		return nil, err
+	}
+
+	s.machines = append(s.machines, m)
+	s.addEvent("INFO", m, "Enlisted", "Machine created")
+
+	if commission, _ := formBool(req.form, "commission"); commission {
+		s.startTransition(m, StatusCommissioning, StatusReady, "Commissioning")
+	}
+	return s.renderMachine(m), nil
+}
+
+// updatePowerParameters applies the power type and the power_parameters_*
+// fields of the form. Parameters are merged into the current ones unless
+// the power type changes.
+func (s *Server) updatePowerParameters(m *Machine, req request) error {
+	powerType := valueOr(req.form.Get("power_type"), m.PowerType)
+	required, ok := powerTypes[powerType]
+	if !ok {
+		return badRequest(`{"power_type": ["Select a valid choice. %s is not one of the available choices."]}`, powerType)
+	}
+
+	params := map[string]string{}
+	if powerType == m.PowerType {
+		for name, value := range m.PowerParameters {
+			params[name] = value
+		}
+	}
+	for key := range req.form {
+		if name, found := strings.CutPrefix(key, "power_parameters_"); found && name != "skip_check" {
+			params[name] = req.form.Get(key)
+		}
+	}
+
+	if skip, _ := formBool(req.form, "power_parameters_skip_check"); !skip {
+		for _, name := range required {
+			if params[name] == "" {
+				return badRequest(`{"power_parameters": ["%s: This field is required."]}`, name)
+			}
+		}
+	}
+
+	m.PowerType = powerType
+	m.PowerParameters = params
+	return nil
+}
+
+// deleteMachine removes a machine unless it is locked.
+func (s *Server) deleteMachine(m *Machine) (any, error) {
+	if m.Locked {
+		return nil, conflict("Cannot delete node because the machine is locked.")
+	}
+
+	s.machines = slices.DeleteFunc(s.machines, func(other *Machine) bool { return other == m })
+	return rawResponse{contentType: "text/plain"}, nil
+}
+
+func (s *Server) renderPowerParameters(m *Machine) map[string]any {
+	params := map[string]any{}
+	for name, value := range m.PowerParameters {
+		params[name] = value
+	}
+	return params
+}
+
+func (s *Server) findMachineByMAC(mac string) *Machine {
+	for _, m := range s.machines {
+		for _, iface := range m.Interfaces {
+			if strings.EqualFold(iface.MACAddress, mac) {
+				return m
+			}
+		}
+	}
+	return nil
+}
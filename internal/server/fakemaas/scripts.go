@@ -0,0 +1,268 @@
+package fakemaas
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var scriptTypes = map[string]int{"commissioning": 0, "testing": 2, "release": 3}
+
+var hardwareTypes = map[string]int{"node": 0, "cpu": 1, "memory": 2, "storage": 3, "network": 4, "gpu": 5}
+
+// Script is a commissioning, testing or release script.
+type Script struct {
+	ID           int
+	Name         string
+	Title        string
+	Description  string
+	Tags         []string
+	Type         string
+	HardwareType string
+	Parallel     int
+	Timeout      time.Duration
+	Destructive  bool
+	MayReboot    bool
+	Recommission bool
+	ForHardware  []string
+	Default      bool
+	// Script is the current content. Earlier revisions are kept in history.
+	Script string
+
+	history []string
+}
+
+// AddScript adds a script and returns it.
+func (s *Server) AddScript(script Script) Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.createScript(script)
+}
+
+// Script returns a copy of the script with the given name.
+func (s *Server) Script(name string) (Script, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	script := s.findScript(name)
+	if script == nil {
+		return Script{}, false
+	}
+	return *script, true
+}
+
+func (s *Server) createScript(script Script) *Script {
+	if script.ID == 0 {
+		script.ID = s.newID()
+	}
+	script.Type = valueOr(script.Type, "testing")
+	script.HardwareType = valueOr(script.HardwareType, "node")
+	script.history = []string{script.Script}
+
+	stored := script
+	s.scripts = append(s.scripts, &stored)
+	return &stored
+}
+
+func (s *Server) findScript(name string) *Script {
+	for _, script := range s.scripts {
+		if script.Name == name {
+			return script
+		}
+	}
+	return nil
+}
+
+func renderScript(script *Script, includeScript bool) map[string]any {
+	history := make([]map[string]any, 0, len(script.history))
+	for i := len(script.history) - 1; i >= 0; i-- {
+		revision := map[string]any{"id": i + 1, "comment": ""}
+		if includeScript {
+			revision["data"] = base64.StdEncoding.EncodeToString([]byte(script.history[i]))
+		}
+		history = append(history, revision)
+	}
+
+	return map[string]any{
+		"id":                 script.ID,
+		"name":               script.Name,
+		"title":              script.Title,
+		"description":        script.Description,
+		"tags":               append([]string{}, script.Tags...),
+		"script_type":        scriptTypes[script.Type],
+		"script_type_name":   script.Type,
+		"hardware_type":      hardwareTypes[script.HardwareType],
+		"hardware_type_name": script.HardwareType,
+		"parallel":           script.Parallel,
+		"timeout":            formatTimeout(script.Timeout),
+		"destructive":        script.Destructive,
+		"may_reboot":         script.MayReboot,
+		"recommission":       script.Recommission,
+		"for_hardware":       append([]string{}, script.ForHardware...),
+		"default":            script.Default,
+		"history":            history,
+		"resource_uri":       fmt.Sprintf("/MAAS/api/2.0/scripts/%s", script.Name),
+	}
+}
+
+func formatTimeout(timeout time.Duration) string {
+	seconds := int(timeout.Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+func (s *Server) handleScripts(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			return s.listScripts(req)
+		case http.MethodPost:
+			name := req.form.Get("name")
+			if name == "" {
+				return nil, badRequest(`{"name": ["This field is required."]}`)
+			}
+			if s.findScript(name) != nil {
+				return nil, badRequest(`{"name": ["Script with this Name already exists."]}`)
+			}
+			if req.form.Get("script") == "" {
+				return nil, badRequest(`{"script": ["This field is required."]}`)
+			}
+
+			script := &Script{Name: name}
+			if err := applyScriptForm(script, req); err != nil {
+				return nil, err
+			}
+			return renderScript(s.createScript(*script), false), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	script := s.findScript(rest[0])
+	if script == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch {
+	case req.method == http.MethodGet && req.op == "":
+		return renderScript(script, req.query.Has("include_script")), nil
+	case req.method == http.MethodGet && req.op == "download":
+		revision := len(script.history)
+		if req.query.Has("revision") {
+			var err error
+			revision, err = strconv.Atoi(req.query.Get("revision"))
+			if err != nil || revision < 1 || revision > len(script.history) {
+				return nil, notFound()
+			}
+		}
+		return rawResponse{contentType: "text/plain", body: []byte(script.history[revision-1])}, nil
+	case req.method == http.MethodPost && req.op == "add_tag":
+		if tag := req.form.Get("tag"); tag != "" && !slices.Contains(script.Tags, tag) {
+			script.Tags = append(script.Tags, tag)
+		}
+		return renderScript(script, false), nil
+	case req.method == http.MethodPost && req.op == "remove_tag":
+		script.Tags = slices.DeleteFunc(script.Tags, func(tag string) bool { return tag == req.form.Get("tag") })
+		return renderScript(script, false), nil
+	case req.method == http.MethodPut:
+		if err := applyScriptForm(script, req); err != nil {
+			return nil, err
+		}
+		return renderScript(script, false), nil
+	case req.method == http.MethodDelete:
+		if script.Default {
+			return nil, badRequest("Unable to delete default script")
+		}
+		s.scripts = slices.DeleteFunc(s.scripts, func(other *Script) bool { return other == script })
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) listScripts(req request) (any, error) {
+	var filters []string
+	if value := req.query.Get("filters"); value != "" {
+		filters = strings.Split(value, ",")
+	}
+
+	scripts := []map[string]any{}
+	for _, script := range s.scripts {
+		if scriptType := req.query.Get("type"); scriptType != "" && script.Type != scriptType {
+			continue
+		}
+		if hardwareType := req.query.Get("hardware_type"); hardwareType != "" && script.HardwareType != hardwareType {
+			continue
+		}
+		if len(filters) > 0 && !slices.ContainsFunc(filters, func(filter string) bool {
+			return filter == script.Name || slices.Contains(script.Tags, filter)
+		}) {
+			continue
+		}
+		scripts = append(scripts, renderScript(script, req.query.Has("include_script")))
+	}
+	return scripts, nil
+}
+
+func applyScriptForm(script *Script, req request) error {
+	form := req.form
+
+	if form.Has("script") && form.Get("script") != script.Script {
+		script.Script = form.Get("script")
+		if script.history != nil {
+			script.history = append(script.history, script.Script)
+		}
+	}
+	if form.Has("type") {
+		if _, ok := scriptTypes[form.Get("type")]; !ok {
+			return badRequest(`{"script_type": ["Invalid script type."]}`)
+		}
+		script.Type = form.Get("type")
+	}
+	if form.Has("hardware_type") {
+		if _, ok := hardwareTypes[form.Get("hardware_type")]; !ok {
+			return badRequest(`{"hardware_type": ["Invalid hardware type."]}`)
+		}
+		script.HardwareType = form.Get("hardware_type")
+	}
+	if form.Has("title") {
+		script.Title = form.Get("title")
+	}
+	if form.Has("description") {
+		script.Description = form.Get("description")
+	}
+	if form.Has("tags") {
+		script.Tags = strings.Split(form.Get("tags"), ",")
+	}
+	if form.Has("for_hardware") {
+		script.ForHardware = strings.Split(form.Get("for_hardware"), ",")
+		for _, hardware := range script.ForHardware {
+			if !strings.HasPrefix(hardware, "modalias:") && !strings.HasPrefix(hardware, "pci:") && !strings.HasPrefix(hardware, "usb:") {
+				return badRequest(`{"for_hardware": ["Hardware identifier '%s' must start with modalias:, pci: or usb:."]}`, hardware)
+			}
+		}
+	}
+	if timeout, ok, err := formInt(form, "timeout"); err != nil {
+		return err
+	} else if ok {
+		script.Timeout = time.Duration(timeout) * time.Second
+	}
+	if parallel, ok, err := formInt(form, "parallel"); err != nil {
+		return err
+	} else if ok {
+		script.Parallel = parallel
+	}
+	for key, target := range map[string]*bool{
+		"destructive":  &script.Destructive,
+		"may_reboot":   &script.MayReboot,
+		"recommission": &script.Recommission,
+	} {
+		if value, ok := formBool(form, key); ok {
+			*target = value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,1055 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EFIPartitionSize is the size of the EFI system partition the storage
+// layouts create on the boot disk.
+const EFIPartitionSize int64 = 512 << 20
+
+// minimumRAIDMembers are the RAID levels MAAS accepts with the smallest
+// number of active members of each.
+var minimumRAIDMembers = map[string]int{
+	"raid-0":  2,
+	"raid-1":  2,
+	"raid-5":  3,
+	"raid-6":  4,
+	"raid-10": 3,
+}
+
+var filesystemTypes = []string{"ext2", "ext4", "xfs", "btrfs", "fat32", "vfat", "swap"}
+
+// BlockDevice is a disk of a machine. Physical disks are given with the
+// machine; virtual ones are created for RAID sets, logical volumes and
+// bcaches.
+type BlockDevice struct {
+	ID         int
+	Name       string
+	Type       string
+	Size       int64
+	Model      string
+	Serial     string
+	Tags       []string
+	Partitions []Partition
+	Filesystem *Filesystem
+}
+
+// Partition is a partition of a block device. It is named after the device
+// and its position, like sda-part2.
+type Partition struct {
+	ID         int
+	Size       int64
+	Bootable   bool
+	Filesystem *Filesystem
+}
+
+// Filesystem is the filesystem of a block device or a partition.
+type Filesystem struct {
+	FSType       string
+	Label        string
+	MountPoint   string
+	MountOptions string
+}
+
+// RAID is a software RAID set. Members and Spares hold the IDs of block
+// devices and partitions, DeviceID the ID of the virtual block device.
+type RAID struct {
+	ID       int
+	Name     string
+	Level    string
+	Members  []int
+	Spares   []int
+	DeviceID int
+}
+
+// VolumeGroup is an LVM volume group. Members hold the IDs of block devices
+// and partitions, Volumes the IDs of the virtual block devices of its
+// logical volumes.
+type VolumeGroup struct {
+	ID      int
+	Name    string
+	Members []int
+	Volumes []int
+}
+
+// Bcache is a bcache device. BackingID and CacheID hold the IDs of a block
+// device or a partition, DeviceID the ID of the virtual block device.
+type Bcache struct {
+	ID         int
+	Name       string
+	CacheMode  string
+	BackingID  int
+	CacheID    int
+	CacheSetID int
+	DeviceID   int
+}
+
+func (s *Server) handleBlockDevices(m *Machine, req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		if req.method != http.MethodGet {
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+		devices := make([]map[string]any, 0, len(m.BlockDevices))
+		for i := range m.BlockDevices {
+			devices = append(devices, s.renderBlockDevice(m, &m.BlockDevices[i]))
+		}
+		return devices, nil
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	device := findBlockDevice(m, id)
+	if device == nil {
+		return nil, notFound()
+	}
+
+	switch {
+	case len(rest) == 1:
+		return s.blockDeviceOperation(m, device, req)
+	case len(rest) == 2 && rest[1] == "partitions":
+		switch req.method {
+		case http.MethodGet:
+			partitions := make([]map[string]any, 0, len(device.Partitions))
+			for i := range device.Partitions {
+				partitions = append(partitions, s.renderPartition(m, device, i))
+			}
+			return partitions, nil
+		case http.MethodPost:
+			if err := checkStorageEditable(m, "create partition"); err != nil {
+				return nil, err
+			}
+			if err := s.createPartition(m, device, req); err != nil {
+				return nil, err
+			}
+			return s.renderPartition(m, device, len(device.Partitions)-1), nil
+		default:
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+	case len(rest) == 3 && rest[1] == "partition":
+		id, err := pathID(rest[2])
+		if err != nil {
+			return nil, err
+		}
+		index := slices.IndexFunc(device.Partitions, func(p Partition) bool { return p.ID == id })
+		if index < 0 {
+			return nil, notFound()
+		}
+		return s.partitionOperation(m, device, index, req)
+	default:
+		return nil, notFound()
+	}
+}
+
+func (s *Server) blockDeviceOperation(m *Machine, device *BlockDevice, req request) (any, error) {
+	if req.method == http.MethodGet {
+		return s.renderBlockDevice(m, device), nil
+	}
+	if req.method != http.MethodPost {
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+	if err := checkStorageEditable(m, req.op); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch req.op {
+	case "format":
+		if len(device.Partitions) > 0 {
+			return nil, badRequest("Cannot format block device with partitions.")
+		}
+		err = s.formatStorage(m, device.ID, &device.Filesystem, req)
+	case "mount":
+		err = mountStorage(device.Filesystem, req)
+	case "unmount":
+		err = unmountStorage(device.Filesystem)
+	case "set_boot_disk":
+		if device.Type != "physical" {
+			return nil, badRequest("Cannot set a %s block device as the boot disk.", device.Type)
+		}
+		m.BootDiskID = device.ID
+	default:
+		return nil, badRequest("Unrecognised signature: method=POST op=%s", req.op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.renderBlockDevice(m, device), nil
+}
+
+func (s *Server) partitionOperation(m *Machine, device *BlockDevice, index int, req request) (any, error) {
+	partition := &device.Partitions[index]
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderPartition(m, device, index), nil
+	case http.MethodDelete:
+		if err := checkStorageEditable(m, "delete partition"); err != nil {
+			return nil, err
+		}
+		if user := s.storageUser(m, partition.ID); user != "" {
+			return nil, badRequest("Cannot delete partition %s, it is used as %s.", partitionName(device, index), user)
+		}
+		device.Partitions = slices.Delete(device.Partitions, index, index+1)
+		return nil, nil
+	case http.MethodPost:
+		if err := checkStorageEditable(m, req.op); err != nil {
+			return nil, err
+		}
+		var err error
+		switch req.op {
+		case "format":
+			err = s.formatStorage(m, partition.ID, &partition.Filesystem, req)
+		case "mount":
+			err = mountStorage(partition.Filesystem, req)
+		case "unmount":
+			err = unmountStorage(partition.Filesystem)
+		default:
+			return nil, badRequest("Unrecognised signature: method=POST op=%s", req.op)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.renderPartition(m, device, index), nil
+	default:
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+}
+
+func (s *Server) handleRAIDs(m *Machine, req request, resource string, rest []string) (any, error) {
+	if resource == "raids" {
+		if len(rest) != 0 {
+			return nil, notFound()
+		}
+		switch req.method {
+		case http.MethodGet:
+			raids := make([]map[string]any, 0, len(m.RAIDs))
+			for i := range m.RAIDs {
+				raids = append(raids, s.renderRAID(m, &m.RAIDs[i]))
+			}
+			return raids, nil
+		case http.MethodPost:
+			if err := checkStorageEditable(m, "create RAID"); err != nil {
+				return nil, err
+			}
+			raid, err := s.createRAID(m, req)
+			if err != nil {
+				return nil, err
+			}
+			return s.renderRAID(m, raid), nil
+		default:
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+	}
+
+	if len(rest) != 1 {
+		return nil, notFound()
+	}
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(m.RAIDs, func(r RAID) bool { return r.ID == id })
+	if index < 0 {
+		return nil, notFound()
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderRAID(m, &m.RAIDs[index]), nil
+	case http.MethodDelete:
+		if err := checkStorageEditable(m, "delete RAID"); err != nil {
+			return nil, err
+		}
+		raid := m.RAIDs[index]
+		if user := s.storageUser(m, raid.DeviceID); user != "" {
+			return nil, badRequest("Cannot delete RAID %s, it is used as %s.", raid.Name, user)
+		}
+		m.RAIDs = slices.Delete(m.RAIDs, index, index+1)
+		deleteBlockDevice(m, raid.DeviceID)
+		return nil, nil
+	default:
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+}
+
+func (s *Server) handleVolumeGroups(m *Machine, req request, resource string, rest []string) (any, error) {
+	if resource == "volume-groups" {
+		if len(rest) != 0 {
+			return nil, notFound()
+		}
+		switch req.method {
+		case http.MethodGet:
+			groups := make([]map[string]any, 0, len(m.VolumeGroups))
+			for i := range m.VolumeGroups {
+				groups = append(groups, s.renderVolumeGroup(m, &m.VolumeGroups[i]))
+			}
+			return groups, nil
+		case http.MethodPost:
+			if err := checkStorageEditable(m, "create volume group"); err != nil {
+				return nil, err
+			}
+			group, err := s.createVolumeGroup(m, req)
+			if err != nil {
+				return nil, err
+			}
+			return s.renderVolumeGroup(m, group), nil
+		default:
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+	}
+
+	if len(rest) != 1 {
+		return nil, notFound()
+	}
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(m.VolumeGroups, func(g VolumeGroup) bool { return g.ID == id })
+	if index < 0 {
+		return nil, notFound()
+	}
+	group := &m.VolumeGroups[index]
+
+	switch req.method {
+	case http.MethodGet:
+		return s.renderVolumeGroup(m, group), nil
+	case http.MethodDelete:
+		if err := checkStorageEditable(m, "delete volume group"); err != nil {
+			return nil, err
+		}
+		for _, volume := range group.Volumes {
+			if user := s.storageUser(m, volume); user != "" {
+				return nil, badRequest("Cannot delete volume group %s, a logical volume is used as %s.", group.Name, user)
+			}
+		}
+		for _, volume := range group.Volumes {
+			deleteBlockDevice(m, volume)
+		}
+		m.VolumeGroups = slices.Delete(m.VolumeGroups, index, index+1)
+		return nil, nil
+	case http.MethodPost:
+		if err := checkStorageEditable(m, req.op); err != nil {
+			return nil, err
+		}
+		switch req.op {
+		case "create_logical_volume":
+			volume, err := s.createLogicalVolume(m, group, req)
+			if err != nil {
+				return nil, err
+			}
+			return s.renderBlockDevice(m, volume), nil
+		case "delete_logical_volume":
+			id, _, err := formInt(req.form, "id")
+			if err != nil {
+				return nil, err
+			}
+			volume := slices.Index(group.Volumes, id)
+			if volume < 0 {
+				return nil, badRequest(`{"id": ["Logical volume %d is not part of volume group %s."]}`, id, group.Name)
+			}
+			if user := s.storageUser(m, id); user != "" {
+				return nil, badRequest("Cannot delete logical volume, it is used as %s.", user)
+			}
+			group.Volumes = slices.Delete(group.Volumes, volume, volume+1)
+			deleteBlockDevice(m, id)
+			return nil, nil
+		default:
+			return nil, badRequest("Unrecognised signature: method=POST op=%s", req.op)
+		}
+	default:
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+}
+
+func (s *Server) handleBcaches(m *Machine, req request, rest []string) (any, error) {
+	if len(rest) != 0 {
+		return nil, notFound()
+	}
+	if req.method != http.MethodGet {
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	bcaches := make([]map[string]any, 0, len(m.Bcaches))
+	for i := range m.Bcaches {
+		bcaches = append(bcaches, s.renderBcache(m, &m.Bcaches[i]))
+	}
+	return bcaches, nil
+}
+
+// checkStorageEditable refuses storage changes outside the Ready and
+// Allocated states, as MAAS does.
+func checkStorageEditable(m *Machine, action string) error {
+	if m.Status != StatusReady && m.Status != StatusAllocated {
+		return conflict("Cannot %s because the machine is %s.", strings.ReplaceAll(action, "_", " "), m.Status)
+	}
+	return nil
+}
+
+// setStorageLayout replaces the storage of the machine with a layout built on
+// the boot disk, or the root_device of the form, like MAAS does. The bcache
+// layout falls back to flat when there is no cache device.
+func (s *Server) setStorageLayout(m *Machine, req request) error {
+	if m.Status != StatusReady && m.Status != StatusAllocated {
+		return conflict("Cannot change the storage layout because the machine is %s.", m.Status)
+	}
+
+	layout := valueOr(req.form.Get("storage_layout"), "flat")
+	switch layout {
+	case "flat", "lvm", "bcache", "blank":
+	case "custom":
+		return badRequest("No custom storage layout configuration found for this machine.")
+	default:
+		return badRequest(`{"storage_layout": ["Select a valid choice. %s is not one of the available choices."]}`, layout)
+	}
+
+	root := bootDisk(m)
+	if id, ok, err := formInt(req.form, "root_device"); err != nil {
+		return err
+	} else if ok {
+		root = findBlockDevice(m, id)
+		if root == nil || root.Type != "physical" {
+			return badRequest(`{"root_device": ["Select a valid choice. %d is not one of the available choices."]}`, id)
+		}
+	}
+	if root == nil {
+		return badRequest("Machine doesn't have any storage devices to configure.")
+	}
+
+	bootSize, _, err := formSize(req.form, "boot_size")
+	if err != nil {
+		return err
+	}
+	available := root.Size - EFIPartitionSize - bootSize
+	if available <= 0 {
+		return badRequest(`{"boot_size": ["Size is too large. Maximum size is %d."]}`, root.Size-EFIPartitionSize)
+	}
+	rootSize, ok, err := formSize(req.form, "root_size")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		rootSize = available
+	} else if rootSize > available {
+		return badRequest(`{"root_size": ["Size is too large. Maximum size is %d."]}`, available)
+	}
+
+	lvSize, hasLVSize, err := formSize(req.form, "lv_size")
+	if err != nil {
+		return err
+	}
+	if layout == "lvm" && hasLVSize && lvSize > rootSize {
+		return badRequest(`{"lv_size": ["Size is too large. Maximum size is %d."]}`, rootSize)
+	}
+
+	var cache *BlockDevice
+	var cacheSize int64
+	if layout == "bcache" {
+		if cache, err = cacheDevice(m, root, req); err != nil {
+			return err
+		}
+		var hasCacheSize bool
+		if cacheSize, hasCacheSize, err = formSize(req.form, "cache_size"); err != nil {
+			return err
+		}
+		if cache == nil {
+			layout = "flat"
+		} else if hasCacheSize && cacheSize > cache.Size {
+			return badRequest(`{"cache_size": ["Size is too large. Maximum size is %d."]}`, cache.Size)
+		}
+	}
+
+	// Clearing the storage moves the block devices, so they are looked up
+	// again by ID afterwards.
+	rootID, cacheID := root.ID, 0
+	if cache != nil {
+		cacheID = cache.ID
+	}
+	m.RAIDs, m.VolumeGroups, m.Bcaches = nil, nil, nil
+	m.BlockDevices = slices.DeleteFunc(m.BlockDevices, func(d BlockDevice) bool { return d.Type != "physical" })
+	for i := range m.BlockDevices {
+		m.BlockDevices[i].Partitions = nil
+		m.BlockDevices[i].Filesystem = nil
+	}
+	if layout == "blank" {
+		return nil
+	}
+
+	root = findBlockDevice(m, rootID)
+	root.Partitions = append(root.Partitions, Partition{
+		ID:         s.newID(),
+		Size:       EFIPartitionSize,
+		Bootable:   true,
+		Filesystem: &Filesystem{FSType: "fat32", MountPoint: "/boot/efi"},
+	})
+	if bootSize > 0 {
+		root.Partitions = append(root.Partitions, Partition{
+			ID:         s.newID(),
+			Size:       bootSize,
+			Filesystem: &Filesystem{FSType: "ext4", MountPoint: "/boot"},
+		})
+	}
+	rootPartition := Partition{ID: s.newID(), Size: rootSize}
+	rootFilesystem := &Filesystem{FSType: "ext4", MountPoint: "/"}
+	if layout == "flat" {
+		rootPartition.Filesystem = rootFilesystem
+	}
+	root.Partitions = append(root.Partitions, rootPartition)
+
+	switch layout {
+	case "lvm":
+		if !hasLVSize {
+			lvSize = rootSize
+		}
+		volume := s.addVirtualDevice(m, valueOr(req.form.Get("vg_name"), "vgroot")+"-"+valueOr(req.form.Get("lv_name"), "lvroot"), lvSize)
+		volume.Filesystem = rootFilesystem
+		m.VolumeGroups = append(m.VolumeGroups, VolumeGroup{
+			ID:      s.newID(),
+			Name:    valueOr(req.form.Get("vg_name"), "vgroot"),
+			Members: []int{rootPartition.ID},
+			Volumes: []int{volume.ID},
+		})
+	case "bcache":
+		if cacheSize > 0 {
+			cache = findBlockDevice(m, cacheID)
+			partition := Partition{ID: s.newID(), Size: cacheSize}
+			cache.Partitions = append(cache.Partitions, partition)
+			cacheID = partition.ID
+		}
+		device := s.addVirtualDevice(m, "bcache0", rootSize)
+		device.Filesystem = rootFilesystem
+		m.Bcaches = append(m.Bcaches, Bcache{
+			ID:         s.newID(),
+			Name:       "bcache0",
+			CacheMode:  valueOr(req.form.Get("cache_mode"), "writethrough"),
+			BackingID:  rootPartition.ID,
+			CacheID:    cacheID,
+			CacheSetID: s.newID(),
+			DeviceID:   device.ID,
+		})
+	}
+	return nil
+}
+
+// cacheDevice returns the cache device of the bcache layout: the
+// cache_device of the form, or else the smallest disk tagged ssd other than
+// the root device. It returns nil when there is none.
+func cacheDevice(m *Machine, root *BlockDevice, req request) (*BlockDevice, error) {
+	id, ok, err := formInt(req.form, "cache_device")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		device := findBlockDevice(m, id)
+		if device == nil || device.Type != "physical" || device.ID == root.ID {
+			return nil, badRequest(`{"cache_device": ["Select a valid choice. %d is not one of the available choices."]}`, id)
+		}
+		return device, nil
+	}
+
+	var cache *BlockDevice
+	for i := range m.BlockDevices {
+		device := &m.BlockDevices[i]
+		if device.Type != "physical" || device.ID == root.ID || !slices.Contains(device.Tags, "ssd") {
+			continue
+		}
+		if cache == nil || device.Size < cache.Size {
+			cache = device
+		}
+	}
+	return cache, nil
+}
+
+// bootDisk returns the boot disk of the machine, the first physical disk
+// unless another one was chosen.
+func bootDisk(m *Machine) *BlockDevice {
+	var first *BlockDevice
+	for i := range m.BlockDevices {
+		device := &m.BlockDevices[i]
+		if device.Type != "physical" {
+			continue
+		}
+		if device.ID == m.BootDiskID {
+			return device
+		}
+		if first == nil {
+			first = device
+		}
+	}
+	return first
+}
+
+func (s *Server) addVirtualDevice(m *Machine, name string, size int64) *BlockDevice {
+	m.BlockDevices = append(m.BlockDevices, BlockDevice{ID: s.newID(), Name: name, Type: "virtual", Size: size})
+	return &m.BlockDevices[len(m.BlockDevices)-1]
+}
+
+func deleteBlockDevice(m *Machine, id int) {
+	m.BlockDevices = slices.DeleteFunc(m.BlockDevices, func(d BlockDevice) bool { return d.ID == id })
+}
+
+func findBlockDevice(m *Machine, id int) *BlockDevice {
+	for i := range m.BlockDevices {
+		if m.BlockDevices[i].ID == id {
+			return &m.BlockDevices[i]
+		}
+	}
+	return nil
+}
+
+// findPartition returns the device holding the partition with the given ID
+// and the index of the partition.
+func findPartition(m *Machine, id int) (*BlockDevice, int) {
+	for i := range m.BlockDevices {
+		for j, partition := range m.BlockDevices[i].Partitions {
+			if partition.ID == id {
+				return &m.BlockDevices[i], j
+			}
+		}
+	}
+	return nil, -1
+}
+
+func partitionName(device *BlockDevice, index int) string {
+	return fmt.Sprintf("%s-part%d", device.Name, index+1)
+}
+
+// storageUser describes the RAID set, volume group or bcache the block device
+// or partition with the given ID belongs to, or returns "" when it belongs to
+// none.
+func (s *Server) storageUser(m *Machine, id int) string {
+	for _, raid := range m.RAIDs {
+		if slices.Contains(raid.Members, id) {
+			return fmt.Sprintf("Active %s device for %s", raid.Level, raid.Name)
+		}
+		if slices.Contains(raid.Spares, id) {
+			return fmt.Sprintf("Spare %s device for %s", raid.Level, raid.Name)
+		}
+	}
+	for _, group := range m.VolumeGroups {
+		if slices.Contains(group.Members, id) {
+			return "LVM volume for " + group.Name
+		}
+	}
+	for _, bcache := range m.Bcaches {
+		if bcache.BackingID == id {
+			return "Backing device for " + bcache.Name
+		}
+		if bcache.CacheID == id {
+			return fmt.Sprintf("Cache device for cache%d", bcache.CacheSetID)
+		}
+	}
+	return ""
+}
+
+// usedFor describes what uses a block device or partition, as in the
+// used_for field of MAAS.
+func (s *Server) usedFor(m *Machine, id int, filesystem *Filesystem, partitions int) string {
+	switch {
+	case filesystem != nil && filesystem.MountPoint != "":
+		return fmt.Sprintf("%s formatted filesystem mounted at %s", filesystem.FSType, filesystem.MountPoint)
+	case filesystem != nil:
+		return fmt.Sprintf("Unmounted %s formatted filesystem", filesystem.FSType)
+	case partitions == 1:
+		return "GPT partitioned with 1 partition"
+	case partitions > 1:
+		return fmt.Sprintf("GPT partitioned with %d partitions", partitions)
+	}
+	if user := s.storageUser(m, id); user != "" {
+		return user
+	}
+	return "Unused"
+}
+
+// availableSize returns the space of a block device left for partitions.
+func (s *Server) availableSize(m *Machine, device *BlockDevice) int64 {
+	if device.Filesystem != nil || s.storageUser(m, device.ID) != "" {
+		return 0
+	}
+	available := device.Size
+	for _, partition := range device.Partitions {
+		available -= partition.Size
+	}
+	return available
+}
+
+func (s *Server) formatStorage(m *Machine, id int, filesystem **Filesystem, req request) error {
+	fsType := req.form.Get("fstype")
+	if !slices.Contains(filesystemTypes, fsType) {
+		return badRequest(`{"fstype": ["Select a valid choice. %s is not one of the available choices."]}`, fsType)
+	}
+	if user := s.storageUser(m, id); user != "" {
+		return badRequest("Cannot format, the device is used as %s.", user)
+	}
+
+	*filesystem = &Filesystem{FSType: fsType, Label: req.form.Get("label")}
+	return nil
+}
+
+func mountStorage(filesystem *Filesystem, req request) error {
+	if filesystem == nil {
+		return badRequest("Cannot mount an unformatted device.")
+	}
+	mountPoint := req.form.Get("mount_point")
+	if filesystem.FSType == "swap" {
+		mountPoint = "none"
+	} else if !strings.HasPrefix(mountPoint, "/") {
+		return badRequest(`{"mount_point": ["This field must be an absolute path."]}`)
+	}
+	filesystem.MountPoint = mountPoint
+	filesystem.MountOptions = req.form.Get("mount_options")
+	return nil
+}
+
+func unmountStorage(filesystem *Filesystem) error {
+	if filesystem == nil || filesystem.MountPoint == "" {
+		return badRequest("Filesystem is already unmounted.")
+	}
+	filesystem.MountPoint = ""
+	filesystem.MountOptions = ""
+	return nil
+}
+
+func (s *Server) createPartition(m *Machine, device *BlockDevice, req request) error {
+	if device.Filesystem != nil || s.storageUser(m, device.ID) != "" {
+		return badRequest("Cannot create a partition on %s, it is in use.", device.Name)
+	}
+	available := s.availableSize(m, device)
+	size, ok, err := formSize(req.form, "size")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		size = available
+	}
+	if size <= 0 || size > available {
+		return badRequest(`{"size": ["Partition size must be between 1 and %d bytes."]}`, available)
+	}
+
+	device.Partitions = append(device.Partitions, Partition{ID: s.newID(), Size: size})
+	return nil
+}
+
+// members resolves the IDs of the block devices and partitions of the form
+// keys to their sizes. They must exist and be unused.
+func (s *Server) members(m *Machine, req request, deviceKey, partitionKey string) ([]int, []int64, error) {
+	var ids []int
+	var sizes []int64
+	for _, key := range []string{deviceKey, partitionKey} {
+		for _, value := range req.form[key] {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, nil, badRequest(`{"%s": ["Enter a whole number."]}`, key)
+			}
+
+			var size int64
+			var inUse bool
+			if key == deviceKey {
+				device := findBlockDevice(m, id)
+				if device == nil {
+					return nil, nil, badRequest(`{"%s": ["Select a valid choice. %d is not one of the available choices."]}`, key, id)
+				}
+				size = device.Size
+				inUse = device.Filesystem != nil || len(device.Partitions) > 0
+			} else {
+				device, index := findPartition(m, id)
+				if device == nil {
+					return nil, nil, badRequest(`{"%s": ["Select a valid choice. %d is not one of the available choices."]}`, key, id)
+				}
+				size = device.Partitions[index].Size
+				inUse = device.Partitions[index].Filesystem != nil
+			}
+			if inUse || s.storageUser(m, id) != "" || slices.Contains(ids, id) {
+				return nil, nil, badRequest(`{"%s": ["Device %d is already in use."]}`, key, id)
+			}
+			ids = append(ids, id)
+			sizes = append(sizes, size)
+		}
+	}
+	return ids, sizes, nil
+}
+
+func (s *Server) createRAID(m *Machine, req request) (*RAID, error) {
+	level := req.form.Get("level")
+	minimum, ok := minimumRAIDMembers[level]
+	if !ok {
+		return nil, badRequest(`{"level": ["Select a valid choice. %s is not one of the available choices."]}`, level)
+	}
+
+	members, sizes, err := s.members(m, req, "block_devices", "partitions")
+	if err != nil {
+		return nil, err
+	}
+	if len(members) < minimum {
+		return nil, badRequest("RAID level %s must have at least %d raid devices.", strings.TrimPrefix(level, "raid-"), minimum)
+	}
+	spares, _, err := s.members(m, req, "spare_devices", "spare_partitions")
+	if err != nil {
+		return nil, err
+	}
+	if len(spares) > 0 && level == "raid-0" {
+		return nil, badRequest("RAID level 0 must have 0 spare raid devices.")
+	}
+	for _, spare := range spares {
+		if slices.Contains(members, spare) {
+			return nil, badRequest("Device %d cannot be both active and spare.", spare)
+		}
+	}
+
+	name := req.form.Get("name")
+	if name == "" {
+		name = fmt.Sprintf("md%d", len(m.RAIDs))
+	}
+	if slices.ContainsFunc(m.BlockDevices, func(d BlockDevice) bool { return d.Name == name }) {
+		return nil, badRequest(`{"name": ["A block device named %s already exists."]}`, name)
+	}
+
+	device := s.addVirtualDevice(m, name, raidSize(level, sizes))
+	m.RAIDs = append(m.RAIDs, RAID{
+		ID:       s.newID(),
+		Name:     name,
+		Level:    level,
+		Members:  members,
+		Spares:   spares,
+		DeviceID: device.ID,
+	})
+	return &m.RAIDs[len(m.RAIDs)-1], nil
+}
+
+// raidSize returns the usable size of a RAID set of the given level over
+// members of the given sizes.
+func raidSize(level string, sizes []int64) int64 {
+	smallest := slices.Min(sizes)
+	count := int64(len(sizes))
+	switch level {
+	case "raid-0":
+		return smallest * count
+	case "raid-1":
+		return smallest
+	case "raid-5":
+		return smallest * (count - 1)
+	case "raid-6":
+		return smallest * (count - 2)
+	default:
+		return smallest * count / 2
+	}
+}
+
+func (s *Server) createVolumeGroup(m *Machine, req request) (*VolumeGroup, error) {
+	name := req.form.Get("name")
+	if name == "" {
+		return nil, badRequest(`{"name": ["This field is required."]}`)
+	}
+	if slices.ContainsFunc(m.VolumeGroups, func(g VolumeGroup) bool { return g.Name == name }) {
+		return nil, badRequest(`{"name": ["A volume group named %s already exists."]}`, name)
+	}
+
+	members, _, err := s.members(m, req, "block_devices", "partitions")
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, badRequest("At least one valid block device or partition is required.")
+	}
+
+	m.VolumeGroups = append(m.VolumeGroups, VolumeGroup{ID: s.newID(), Name: name, Members: members})
+	return &m.VolumeGroups[len(m.VolumeGroups)-1], nil
+}
+
+func (s *Server) createLogicalVolume(m *Machine, group *VolumeGroup, req request) (*BlockDevice, error) {
+	name := req.form.Get("name")
+	if name == "" {
+		return nil, badRequest(`{"name": ["This field is required."]}`)
+	}
+	name = group.Name + "-" + name
+	if slices.ContainsFunc(m.BlockDevices, func(d BlockDevice) bool { return d.Name == name }) {
+		return nil, badRequest(`{"name": ["A logical volume named %s already exists."]}`, name)
+	}
+
+	available := s.volumeGroupAvailable(m, group)
+	size, ok, err := formSize(req.form, "size")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		size = available
+	}
+	if size <= 0 || size > available {
+		return nil, badRequest(`{"size": ["Logical volume size must be between 1 and %d bytes."]}`, available)
+	}
+
+	volume := s.addVirtualDevice(m, name, size)
+	group.Volumes = append(group.Volumes, volume.ID)
+	return volume, nil
+}
+
+func (s *Server) volumeGroupSize(m *Machine, group *VolumeGroup) int64 {
+	var size int64
+	for _, id := range group.Members {
+		size += memberSize(m, id)
+	}
+	return size
+}
+
+func (s *Server) volumeGroupAvailable(m *Machine, group *VolumeGroup) int64 {
+	available := s.volumeGroupSize(m, group)
+	for _, id := range group.Volumes {
+		available -= memberSize(m, id)
+	}
+	return available
+}
+
+func memberSize(m *Machine, id int) int64 {
+	if device := findBlockDevice(m, id); device != nil {
+		return device.Size
+	}
+	if device, index := findPartition(m, id); device != nil {
+		return device.Partitions[index].Size
+	}
+	return 0
+}
+
+// formSize returns the size in bytes of the form value key.
+func formSize(form map[string][]string, key string) (int64, bool, error) {
+	values, ok := form[key]
+	if !ok || len(values) == 0 {
+		return 0, false, nil
+	}
+	size, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil || size < 0 {
+		return 0, true, badRequest(`{"%s": ["Enter a whole number."]}`, key)
+	}
+	return size, true, nil
+}
+
+func (s *Server) renderBlockDevice(m *Machine, device *BlockDevice) map[string]any {
+	partitions := make([]map[string]any, 0, len(device.Partitions))
+	for i := range device.Partitions {
+		partitions = append(partitions, s.renderPartition(m, device, i))
+	}
+
+	return map[string]any{
+		"id":             device.ID,
+		"name":           device.Name,
+		"type":           device.Type,
+		"path":           "/dev/disk/by-dname/" + device.Name,
+		"size":           device.Size,
+		"model":          device.Model,
+		"serial":         device.Serial,
+		"tags":           append([]string{}, device.Tags...),
+		"partitions":     partitions,
+		"filesystem":     renderFilesystem(device.Filesystem),
+		"used_for":       s.usedFor(m, device.ID, device.Filesystem, len(device.Partitions)),
+		"available_size": s.availableSize(m, device),
+		"system_id":      m.SystemID,
+		"resource_uri":   fmt.Sprintf("/MAAS/api/2.0/nodes/%s/blockdevices/%d/", m.SystemID, device.ID),
+	}
+}
+
+func (s *Server) renderPartition(m *Machine, device *BlockDevice, index int) map[string]any {
+	partition := device.Partitions[index]
+	return map[string]any{
+		"id":           partition.ID,
+		"type":         "partition",
+		"name":         partitionName(device, index),
+		"path":         "/dev/disk/by-dname/" + partitionName(device, index),
+		"size":         partition.Size,
+		"bootable":     partition.Bootable,
+		"filesystem":   renderFilesystem(partition.Filesystem),
+		"used_for":     s.usedFor(m, partition.ID, partition.Filesystem, 0),
+		"device_id":    device.ID,
+		"system_id":    m.SystemID,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/nodes/%s/blockdevices/%d/partition/%d", m.SystemID, device.ID, partition.ID),
+	}
+}
+
+// renderMember renders the block device or partition with the given ID.
+func (s *Server) renderMember(m *Machine, id int) map[string]any {
+	if device := findBlockDevice(m, id); device != nil {
+		return s.renderBlockDevice(m, device)
+	}
+	if device, index := findPartition(m, id); device != nil {
+		return s.renderPartition(m, device, index)
+	}
+	return nil
+}
+
+func (s *Server) renderMembers(m *Machine, ids []int) []map[string]any {
+	members := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		if member := s.renderMember(m, id); member != nil {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+func renderFilesystem(filesystem *Filesystem) any {
+	if filesystem == nil {
+		return nil
+	}
+	return map[string]any{
+		"fstype":        filesystem.FSType,
+		"label":         filesystem.Label,
+		"mount_point":   filesystem.MountPoint,
+		"mount_options": filesystem.MountOptions,
+	}
+}
+
+func (s *Server) renderRAID(m *Machine, raid *RAID) map[string]any {
+	rendered := map[string]any{
+		"id":            raid.ID,
+		"name":          raid.Name,
+		"level":         raid.Level,
+		"devices":       s.renderMembers(m, raid.Members),
+		"spare_devices": s.renderMembers(m, raid.Spares),
+		"system_id":     m.SystemID,
+		"resource_uri":  fmt.Sprintf("/MAAS/api/2.0/nodes/%s/raid/%d/", m.SystemID, raid.ID),
+	}
+	if device := findBlockDevice(m, raid.DeviceID); device != nil {
+		rendered["size"] = device.Size
+		rendered["virtual_device"] = s.renderBlockDevice(m, device)
+	}
+	return rendered
+}
+
+func (s *Server) renderVolumeGroup(m *Machine, group *VolumeGroup) map[string]any {
+	return map[string]any{
+		"id":              group.ID,
+		"name":            group.Name,
+		"size":            s.volumeGroupSize(m, group),
+		"available_size":  s.volumeGroupAvailable(m, group),
+		"devices":         s.renderMembers(m, group.Members),
+		"logical_volumes": s.renderMembers(m, group.Volumes),
+		"system_id":       m.SystemID,
+		"resource_uri":    fmt.Sprintf("/MAAS/api/2.0/nodes/%s/volume-group/%d/", m.SystemID, group.ID),
+	}
+}
+
+func (s *Server) renderBcache(m *Machine, bcache *Bcache) map[string]any {
+	rendered := map[string]any{
+		"id":             bcache.ID,
+		"name":           bcache.Name,
+		"cache_mode":     bcache.CacheMode,
+		"backing_device": s.renderMember(m, bcache.BackingID),
+		"cache_set": map[string]any{
+			"id":           bcache.CacheSetID,
+			"name":         fmt.Sprintf("cache%d", bcache.CacheSetID),
+			"cache_device": s.renderMember(m, bcache.CacheID),
+		},
+		"system_id":    m.SystemID,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/nodes/%s/bcache/%d/", m.SystemID, bcache.ID),
+	}
+	if device := findBlockDevice(m, bcache.DeviceID); device != nil {
+		rendered["size"] = device.Size
+		rendered["virtual_device"] = s.renderBlockDevice(m, device)
+	}
+	return rendered
+}
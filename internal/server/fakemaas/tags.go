@@ -0,0 +1,176 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+)
+
+// Tag is a tag known to the fake. Machines reference tags through TagNames.
+type Tag struct {
+	Name       string
+	Comment    string
+	Definition string
+	KernelOpts string
+}
+
+var tagNamePattern = regexp.MustCompile(`^[\w-]+$`)
+
+// AddTag adds a tag and returns it.
+func (s *Server) AddTag(tag Tag) Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := tag
+	s.tags = append(s.tags, &stored)
+	return stored
+}
+
+// Tags returns a copy of the tags.
+func (s *Server) Tags() []Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := make([]Tag, 0, len(s.tags))
+	for _, tag := range s.tags {
+		tags = append(tags, *tag)
+	}
+	return tags
+}
+
+func (s *Server) findTag(name string) *Tag {
+	for _, tag := range s.tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+func renderTag(tag *Tag) map[string]any {
+	return map[string]any{
+		"name":         tag.Name,
+		"comment":      tag.Comment,
+		"definition":   tag.Definition,
+		"kernel_opts":  tag.KernelOpts,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/tags/%s/", tag.Name),
+	}
+}
+
+func (s *Server) handleTags(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			tags := []map[string]any{}
+			for _, tag := range s.tags {
+				tags = append(tags, renderTag(tag))
+			}
+			return tags, nil
+		case http.MethodPost:
+			name := req.form.Get("name")
+			if !tagNamePattern.MatchString(name) {
+				return nil, badRequest(`{"name": ["Invalid character in the tag name."]}`)
+			}
+			if s.findTag(name) != nil {
+				return nil, badRequest(`{"name": ["Tag with this Name already exists."]}`)
+			}
+
+			tag := &Tag{
+				Name:       name,
+				Comment:    req.form.Get("comment"),
+				Definition: req.form.Get("definition"),
+				KernelOpts: req.form.Get("kernel_opts"),
+			}
+			s.tags = append(s.tags, tag)
+			return renderTag(tag), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	tag := s.findTag(rest[0])
+	if tag == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch {
+	case req.method == http.MethodGet && req.op == "":
+		return renderTag(tag), nil
+	case req.method == http.MethodGet && (req.op == "nodes" || req.op == "machines"):
+		machines := []map[string]any{}
+		for _, m := range s.machines {
+			if slices.Contains(m.TagNames, tag.Name) {
+				machines = append(machines, s.renderMachine(m))
+			}
+		}
+		return machines, nil
+	case req.method == http.MethodGet && (req.op == "devices" || req.op == "rack_controllers" || req.op == "region_controllers"):
+		// The fake only models machines.
+		return []map[string]any{}, nil
+	case req.method == http.MethodPost && req.op == "update_nodes":
+		added, removed := 0, 0
+		for _, systemID := range req.form["add"] {
+			m := s.findMachine(systemID)
+			if m == nil {
+				return nil, badRequest(`{"add": ["Unknown node %s."]}`, systemID)
+			}
+			if !slices.Contains(m.TagNames, tag.Name) {
+				m.TagNames = append(m.TagNames, tag.Name)
+				added++
+			}
+		}
+		for _, systemID := range req.form["remove"] {
+			m := s.findMachine(systemID)
+			if m == nil {
+				return nil, badRequest(`{"remove": ["Unknown node %s."]}`, systemID)
+			}
+			if index := slices.Index(m.TagNames, tag.Name); index >= 0 {
+				m.TagNames = slices.Delete(m.TagNames, index, index+1)
+				removed++
+			}
+		}
+		return map[string]any{"added": added, "removed": removed}, nil
+	case req.method == http.MethodPut:
+		if req.form.Has("name") && req.form.Get("name") != tag.Name {
+			name := req.form.Get("name")
+			if !tagNamePattern.MatchString(name) {
+				return nil, badRequest(`{"name": ["Invalid character in the tag name."]}`)
+			}
+			if s.findTag(name) != nil {
+				return nil, badRequest(`{"name": ["Tag with this Name already exists."]}`)
+			}
+			s.renameTag(tag.Name, name)
+			tag.Name = name
+		}
+		if req.form.Has("comment") {
+			tag.Comment = req.form.Get("comment")
+		}
+		if req.form.Has("definition") {
+			tag.Definition = req.form.Get("definition")
+		}
+		if req.form.Has("kernel_opts") {
+			tag.KernelOpts = req.form.Get("kernel_opts")
+		}
+		return renderTag(tag), nil
+	case req.method == http.MethodDelete:
+		s.renameTag(tag.Name, "")
+		s.tags = slices.DeleteFunc(s.tags, func(other *Tag) bool { return other == tag })
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+// renameTag renames a tag on every machine, removing it when name is empty.
+func (s *Server) renameTag(oldName, name string) {
+	for _, m := range s.machines {
+		index := slices.Index(m.TagNames, oldName)
+		if index < 0 {
+			continue
+		}
+		if name == "" {
+			m.TagNames = slices.Delete(m.TagNames, index, index+1)
+		} else {
+			m.TagNames[index] = name
+		}
+	}
+}
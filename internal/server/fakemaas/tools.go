@@ -0,0 +1,114 @@
+package fakemaas
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolRecorder collects the handlers added by registries.
+type toolRecorder map[string]server.ToolHandlerFunc
+
+func (r toolRecorder) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	r[tool.Name] = handler
+}
+
+// Handlers registers the registries and returns their handlers by tool name.
+func Handlers(registries ...registry.Registry) map[string]server.ToolHandlerFunc {
+	recorder := toolRecorder{}
+	for _, r := range registries {
+		r.Register(recorder)
+	}
+	return recorder
+}
+
+// CallTool invokes a tool handler with arguments, failing the test when the
+// handler returns a protocol level error.
+func CallTool(t testing.TB, handler server.ToolHandlerFunc, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = arguments
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return result
+}
+
+// ResultText returns the text of the first content of a tool result.
+func ResultText(t testing.TB, result *mcp.CallToolResult) string {
+	t.Helper()
+
+	if len(result.Content) == 0 {
+		t.Fatal("expected the result to have content")
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("expected text content, got %T", result.Content[0])
+	}
+	return text.Text
+}
+
+// progressSession is an initialized MCP session collecting the notifications
+// sent to it.
+type progressSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *progressSession) Initialize()       {}
+func (s *progressSession) Initialized() bool { return true }
+func (s *progressSession) SessionID() string { return "progress" }
+
+func (s *progressSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// CallToolWithProgress calls a tool through an MCP server with a progress
+// token, as a client asking for progress notifications would. It returns the
+// result together with the progress notifications sent during the call.
+func CallToolWithProgress(t testing.TB, tool mcp.Tool, handler server.ToolHandlerFunc, arguments map[string]any) (*mcp.CallToolResult, []mcp.JSONRPCNotification) {
+	t.Helper()
+
+	mcpServer := server.NewMCPServer("fakemaas", "test", server.WithToolCapabilities(false))
+	mcpServer.AddTool(tool, handler)
+
+	session := &progressSession{notifications: make(chan mcp.JSONRPCNotification, 1000)}
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  string(mcp.MethodToolsCall),
+		"params": map[string]any{
+			"name":      tool.Name,
+			"arguments": arguments,
+			"_meta":     map[string]any{"progressToken": "progress"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode the request: %v", err)
+	}
+
+	response := mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), session), message)
+	rpcResponse, ok := response.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a response, got %#v", response)
+	}
+	result, ok := rpcResponse.Result.(mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("expected a tool result, got %T", rpcResponse.Result)
+	}
+
+	close(session.notifications)
+	var notifications []mcp.JSONRPCNotification
+	for notification := range session.notifications {
+		if notification.Method == "notifications/progress" {
+			notifications = append(notifications, notification)
+		}
+	}
+	return &result, notifications
+}
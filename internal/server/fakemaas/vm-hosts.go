@@ -0,0 +1,208 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const gigabyte = 1000 * 1000 * 1000
+
+// VMHost is a VM host (pod) that machines can be composed on.
+type VMHost struct {
+	ID   int
+	Name string
+	Type string
+	// Cores, Memory (MiB) and Storage (bytes) are the total resources of the host.
+	Cores   int
+	Memory  int
+	Storage int64
+	Zone    string
+	Pool    string
+}
+
+// AddVMHost adds a VM host and returns it.
+func (s *Server) AddVMHost(host VMHost) VMHost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if host.ID == 0 {
+		host.ID = s.newID()
+	}
+	if host.Name == "" {
+		host.Name = fmt.Sprintf("vm-host-%d", host.ID)
+	}
+	if host.Type == "" {
+		host.Type = "lxd"
+	}
+	host.Zone = valueOr(host.Zone, "default")
+	host.Pool = valueOr(host.Pool, "default")
+
+	stored := host
+	s.vmHosts = append(s.vmHosts, &stored)
+	return stored
+}
+
+func (s *Server) findVMHost(id int) *VMHost {
+	for _, host := range s.vmHosts {
+		if host.ID == id {
+			return host
+		}
+	}
+	return nil
+}
+
+func (s *Server) vmHostName(id int) string {
+	if host := s.findVMHost(id); host != nil {
+		return host.Name
+	}
+	return ""
+}
+
+// vmHostUsage returns the cores, memory and storage used by the machines
+// composed on a host.
+func (s *Server) vmHostUsage(host *VMHost) (cores, memory int, storage int64) {
+	for _, m := range s.machines {
+		if m.VMHostID == host.ID {
+			cores += m.CPUCount
+			memory += m.Memory
+			storage += int64(m.Storage * 1000 * 1000)
+		}
+	}
+	return cores, memory, storage
+}
+
+func (s *Server) renderVMHost(host *VMHost) map[string]any {
+	cores, memory, storage := s.vmHostUsage(host)
+
+	return map[string]any{
+		"id":   host.ID,
+		"name": host.Name,
+		"type": host.Type,
+		"total": map[string]any{
+			"cores":         host.Cores,
+			"memory":        host.Memory,
+			"local_storage": host.Storage,
+		},
+		"used": map[string]any{
+			"cores":         cores,
+			"memory":        memory,
+			"local_storage": storage,
+		},
+		"available": map[string]any{
+			"cores":         host.Cores - cores,
+			"memory":        host.Memory - memory,
+			"local_storage": host.Storage - storage,
+		},
+		"zone":         map[string]any{"name": host.Zone},
+		"pool":         map[string]any{"name": host.Pool},
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/vm-hosts/%d/", host.ID),
+	}
+}
+
+func (s *Server) handleVMHosts(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		if req.method != http.MethodGet {
+			return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+		}
+
+		hosts := []map[string]any{}
+		for _, host := range s.vmHosts {
+			hosts = append(hosts, s.renderVMHost(host))
+		}
+		return hosts, nil
+	}
+
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	host := s.findVMHost(id)
+	if host == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch {
+	case req.method == http.MethodGet && req.op == "":
+		return s.renderVMHost(host), nil
+	case req.method == http.MethodPost && req.op == "compose":
+		return s.compose(host, req)
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+// compose creates a machine on a VM host. The machine is commissioned
+// right away, like MAAS does for composed machines.
+func (s *Server) compose(host *VMHost, req request) (any, error) {
+	cores, _, err := formInt(req.form, "cores")
+	if err != nil {
+		return nil, err
+	}
+	memory, _, err := formInt(req.form, "memory")
+	if err != nil {
+		return nil, err
+	}
+	storage, err := parseStorage(req.form.Get("storage"))
+	if err != nil {
+		return nil, err
+	}
+
+	cores, memory, storage = max(cores, 1), max(memory, 2048), max(storage, 8)
+
+	usedCores, usedMemory, usedStorage := s.vmHostUsage(host)
+	switch {
+	case usedCores+cores > host.Cores:
+		return nil, badRequest("Unable to compose KVM instance in '%s'. CPU overcommit ratio is %d and there are %d available resources; %d requested.", host.Name, 1, host.Cores-usedCores, cores)
+	case usedMemory+memory > host.Memory:
+		return nil, badRequest("Unable to compose KVM instance in '%s'. Memory overcommit ratio is %d and there are %d available resources; %d requested.", host.Name, 1, host.Memory-usedMemory, memory)
+	case usedStorage+int64(storage)*gigabyte > host.Storage:
+		return nil, badRequest("Unable to compose KVM instance in '%s'. Not enough storage space.", host.Name)
+	}
+
+	m := &Machine{
+		SystemID:         s.newSystemID(),
+		Hostname:         req.form.Get("hostname"),
+		Status:           StatusNew,
+		PowerState:       "off",
+		PowerType:        host.Type,
+		Architecture:     "amd64/generic",
+		CPUCount:         cores,
+		Memory:           memory,
+		Storage:          float64(storage) * 1000,
+		Zone:             host.Zone,
+		Pool:             host.Pool,
+		VMHostID:         host.ID,
+		VirtualMachineID: s.newID(),
+	}
+	if m.Hostname == "" {
+		m.Hostname = "machine-" + m.SystemID
+	}
+	s.machines = append(s.machines, m)
+	s.startTransition(m, StatusCommissioning, StatusReady, "Commissioning")
+
+	return map[string]any{
+		"system_id":    m.SystemID,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/machines/%s/", m.SystemID),
+	}, nil
+}
+
+// parseStorage parses the size in GB of the first disk of a compose storage
+// constraint such as "20", "root:20" or "root:20(ssd)".
+func parseStorage(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	disk, _, _ := strings.Cut(value, ",")
+	if _, size, found := strings.Cut(disk, ":"); found {
+		disk = size
+	}
+	disk, _, _ = strings.Cut(disk, "(")
+
+	size, err := strconv.Atoi(disk)
+	if err != nil {
+		return 0, badRequest(`{"storage": ["Malformed storage constraint, '%s'."]}`, value)
+	}
+	return size, nil
+}
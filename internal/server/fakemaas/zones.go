@@ -0,0 +1,253 @@
+package fakemaas
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Zone is an availability zone. Machines reference zones by name.
+type Zone struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+// ResourcePool is a resource pool. Machines reference pools by name.
+type ResourcePool struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+// AddZone adds a zone and returns it.
+func (s *Server) AddZone(zone Zone) Zone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if zone.ID == 0 {
+		zone.ID = s.newID()
+	}
+	stored := zone
+	s.zones = append(s.zones, &stored)
+	return stored
+}
+
+// AddResourcePool adds a resource pool and returns it.
+func (s *Server) AddResourcePool(pool ResourcePool) ResourcePool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pool.ID == 0 {
+		pool.ID = s.newID()
+	}
+	stored := pool
+	s.pools = append(s.pools, &stored)
+	return stored
+}
+
+// Zones returns a copy of the zones.
+func (s *Server) Zones() []Zone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zones := make([]Zone, 0, len(s.zones))
+	for _, zone := range s.zones {
+		zones = append(zones, *zone)
+	}
+	return zones
+}
+
+// ResourcePools returns a copy of the resource pools.
+func (s *Server) ResourcePools() []ResourcePool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pools := make([]ResourcePool, 0, len(s.pools))
+	for _, pool := range s.pools {
+		pools = append(pools, *pool)
+	}
+	return pools
+}
+
+func (s *Server) findZone(name string) *Zone {
+	for _, zone := range s.zones {
+		if zone.Name == name {
+			return zone
+		}
+	}
+	return nil
+}
+
+func (s *Server) findResourcePool(name string) *ResourcePool {
+	for _, pool := range s.pools {
+		if pool.Name == name {
+			return pool
+		}
+	}
+	return nil
+}
+
+func renderZone(zone *Zone) map[string]any {
+	return map[string]any{
+		"id":           zone.ID,
+		"name":         zone.Name,
+		"description":  zone.Description,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/zones/%s/", zone.Name),
+	}
+}
+
+func renderResourcePool(pool *ResourcePool) map[string]any {
+	return map[string]any{
+		"id":           pool.ID,
+		"name":         pool.Name,
+		"description":  pool.Description,
+		"resource_uri": fmt.Sprintf("/MAAS/api/2.0/resourcepool/%d/", pool.ID),
+	}
+}
+
+func (s *Server) handleZones(req request, rest []string) (any, error) {
+	if len(rest) == 0 {
+		switch req.method {
+		case http.MethodGet:
+			zones := []map[string]any{}
+			for _, zone := range s.zones {
+				zones = append(zones, renderZone(zone))
+			}
+			return zones, nil
+		case http.MethodPost:
+			name := req.form.Get("name")
+			if !tagNamePattern.MatchString(name) {
+				return nil, badRequest(`{"name": ["Enter a valid name."]}`)
+			}
+			if s.findZone(name) != nil {
+				return nil, badRequest(`{"name": ["Zone with this Name already exists."]}`)
+			}
+			zone := &Zone{ID: s.newID(), Name: name, Description: req.form.Get("description")}
+			s.zones = append(s.zones, zone)
+			return renderZone(zone), nil
+		}
+		return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+	}
+
+	zone := s.findZone(rest[0])
+	if zone == nil || len(rest) > 1 {
+		return nil, notFound()
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		return renderZone(zone), nil
+	case http.MethodPut:
+		if name := req.form.Get("name"); name != "" && name != zone.Name {
+			if !tagNamePattern.MatchString(name) {
+				return nil, badRequest(`{"name": ["Enter a valid name."]}`)
+			}
+			if s.findZone(name) != nil {
+				return nil, badRequest(`{"name": ["Zone with this Name already exists."]}`)
+			}
+			for _, m := range s.machines {
+				if m.Zone == zone.Name {
+					m.Zone = name
+				}
+			}
+			zone.Name = name
+		}
+		if req.form.Has("description") {
+			zone.Description = req.form.Get("description")
+		}
+		return renderZone(zone), nil
+	case http.MethodDelete:
+		if zone.Name == "default" {
+			return nil, badRequest("This zone is the default zone, it cannot be deleted.")
+		}
+		for _, m := range s.machines {
+			if m.Zone == zone.Name {
+				m.Zone = "default"
+			}
+		}
+		s.zones = slices.DeleteFunc(s.zones, func(other *Zone) bool { return other == zone })
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+func (s *Server) handleResourcePools(req request, rest []string) (any, error) {
+	if len(rest) != 0 {
+		return nil, notFound()
+	}
+
+	switch req.method {
+	case http.MethodGet:
+		pools := []map[string]any{}
+		for _, pool := range s.pools {
+			pools = append(pools, renderResourcePool(pool))
+		}
+		return pools, nil
+	case http.MethodPost:
+		name := req.form.Get("name")
+		if !tagNamePattern.MatchString(name) {
+			return nil, badRequest(`{"name": ["Enter a valid name."]}`)
+		}
+		if s.findResourcePool(name) != nil {
+			return nil, badRequest(`{"name": ["Resource pool with this Name already exists."]}`)
+		}
+		pool := &ResourcePool{ID: s.newID(), Name: name, Description: req.form.Get("description")}
+		s.pools = append(s.pools, pool)
+		return renderResourcePool(pool), nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
+
+// handleResourcePool serves a single pool, under the singular resourcepool
+// endpoint.
+func (s *Server) handleResourcePool(req request, rest []string) (any, error) {
+	if len(rest) != 1 {
+		return nil, notFound()
+	}
+	id, err := pathID(rest[0])
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(s.pools, func(pool *ResourcePool) bool { return pool.ID == id })
+	if index < 0 {
+		return nil, notFound()
+	}
+	pool := s.pools[index]
+
+	switch req.method {
+	case http.MethodGet:
+		return renderResourcePool(pool), nil
+	case http.MethodPut:
+		if name := req.form.Get("name"); name != "" && name != pool.Name {
+			if !tagNamePattern.MatchString(name) {
+				return nil, badRequest(`{"name": ["Enter a valid name."]}`)
+			}
+			if s.findResourcePool(name) != nil {
+				return nil, badRequest(`{"name": ["Resource pool with this Name already exists."]}`)
+			}
+			for _, m := range s.machines {
+				if m.Pool == pool.Name {
+					m.Pool = name
+				}
+			}
+			pool.Name = name
+		}
+		if req.form.Has("description") {
+			pool.Description = req.form.Get("description")
+		}
+		return renderResourcePool(pool), nil
+	case http.MethodDelete:
+		if pool.Name == "default" {
+			return nil, badRequest("This is the default pool, it cannot be deleted.")
+		}
+		for _, m := range s.machines {
+			if m.Pool == pool.Name {
+				m.Pool = "default"
+			}
+		}
+		s.pools = slices.Delete(s.pools, index, index+1)
+		return rawResponse{contentType: "text/plain"}, nil
+	}
+	return nil, &apiError{status: http.StatusMethodNotAllowed, message: "Method Not Allowed"}
+}
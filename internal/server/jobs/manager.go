@@ -0,0 +1,322 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrCancelled is the error of the jobs stopped by Cancel.
+var ErrCancelled = errors.New("job cancelled")
+
+// Runner runs a job of a kind and returns its result. The job fails when it
+// returns an error. Runners must return when ctx is done.
+type Runner func(ctx context.Context, run *Run) (string, error)
+
+// Manager runs jobs in the background and records their progress in a Store.
+// Jobs are not tied to the tool call that submitted them, so they keep running
+// after the client disconnects, and they are resumed after a restart.
+type Manager struct {
+	store   *Store
+	mu      sync.Mutex
+	runners map[string]Runner
+	cancels map[string]context.CancelCauseFunc
+	wg      sync.WaitGroup
+}
+
+// NewManager returns a manager storing its jobs in store.
+func NewManager(store *Store) *Manager {
+	return &Manager{
+		store:   store,
+		runners: make(map[string]Runner),
+		cancels: make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// Handle registers the runner of the jobs of kind.
+func (m *Manager) Handle(kind string, runner Runner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.runners[kind] = runner
+}
+
+// Submit stores a new job of kind and starts running it. The arguments named
+// by secrets are given to the runner but not stored.
+func (m *Manager) Submit(kind, caller string, arguments map[string]any, secrets ...string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runner, ok := m.runners[kind]
+	if !ok {
+		return Job{}, fmt.Errorf("no runner for job kind %s", kind)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job := Job{
+		ID:        id,
+		Kind:      kind,
+		State:     StateQueued,
+		Caller:    caller,
+		Arguments: arguments,
+		Steps:     []Step{},
+		Created:   now,
+		Updated:   now,
+	}
+	for _, key := range secrets {
+		if _, ok := arguments[key]; ok {
+			job.SecretArguments = append(job.SecretArguments, key)
+		}
+	}
+	if err := m.store.Save(job); err != nil {
+		return Job{}, err
+	}
+
+	zap.L().Info(fmt.Sprintf("[Jobs] Starting job %s (%s)", job.ID, job.Kind))
+	m.start(job, runner)
+	return job, nil
+}
+
+// Get returns the job with the given id, or ErrNotFound.
+func (m *Manager) Get(id string) (Job, error) {
+	return m.store.Get(id)
+}
+
+// List returns every job, newest first.
+func (m *Manager) List() ([]Job, error) {
+	return m.store.List()
+}
+
+// Cancel stops the job with the given id. A running job is cancelled once its
+// runner returns, so the job returned may still be running.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.cancels[id]; ok {
+		cancel(ErrCancelled)
+		return m.store.Get(id)
+	}
+
+	job, err := m.store.Get(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.State.Done() {
+		return Job{}, fmt.Errorf("job %s already %s", id, job.State)
+	}
+
+	finish(&job, StateCancelled, ErrCancelled.Error())
+	if err := m.store.Save(job); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Resume starts again the jobs left queued or running when the server
+// stopped. The steps they already completed are not run again. Jobs with
+// secret arguments fail instead, since their arguments were not stored. So do
+// the jobs check returns an error for, e.g. because the server now runs in
+// read-only mode or the caller lost the right to use the tool. A nil check
+// resumes every job. Resume must be called once every runner is
+// registered.
+func (m *Manager) Resume(check func(job Job) error) error {
+	jobs, err := m.store.List()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range jobs {
+		if job.State.Done() {
+			continue
+		}
+
+		for i := range job.Steps {
+			if job.Steps[i].State == StateRunning {
+				finishStep(&job.Steps[i], StateFailed, "", "interrupted by a server restart")
+			}
+		}
+
+		runner, ok := m.runners[job.Kind]
+		if !ok {
+			finish(&job, StateFailed, fmt.Sprintf("no runner for job kind %s", job.Kind))
+			if err := m.store.Save(job); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(job.SecretArguments) > 0 {
+			finish(&job, StateFailed, fmt.Sprintf("not resumed after a server restart: its arguments %s are not stored", strings.Join(job.SecretArguments, ", ")))
+			if err := m.store.Save(job); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if check != nil {
+			if err := check(job); err != nil {
+				zap.L().Warn(fmt.Sprintf("[Jobs] Not resuming job %s (%s): %v", job.ID, job.Kind, err))
+				finish(&job, StateFailed, fmt.Sprintf("not resumed after a server restart: %v", err))
+				if err := m.store.Save(job); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		job.State = StateQueued
+		job.Updated = time.Now().UTC()
+		if err := m.store.Save(job); err != nil {
+			return err
+		}
+
+		zap.L().Info(fmt.Sprintf("[Jobs] Resuming job %s (%s)", job.ID, job.Kind))
+		m.start(job, runner)
+	}
+	return nil
+}
+
+// Wait blocks until every started job returns.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// start runs the job in the background. m.mu must be held.
+func (m *Manager) start(job Job, runner Runner) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	m.cancels[job.ID] = cancel
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel(nil)
+
+		run := &Run{store: m.store, job: job}
+		run.update(func(job *Job) { job.State = StateRunning })
+
+		result, err := runner(ctx, run)
+
+		run.update(func(job *Job) {
+			switch {
+			case errors.Is(context.Cause(ctx), ErrCancelled):
+				finish(job, StateCancelled, ErrCancelled.Error())
+			case err != nil:
+				finish(job, StateFailed, err.Error())
+			default:
+				job.Result = result
+				finish(job, StateSucceeded, "")
+			}
+		})
+
+		m.mu.Lock()
+		delete(m.cancels, job.ID)
+		m.mu.Unlock()
+
+		zap.L().Info(fmt.Sprintf("[Jobs] Job %s (%s) %s", job.ID, job.Kind, run.Job().State))
+	}()
+}
+
+// Run is a job being run, given to its Runner.
+type Run struct {
+	store *Store
+	mu    sync.Mutex
+	job   Job
+}
+
+// Job returns a copy of the job.
+func (r *Run) Job() Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.job
+}
+
+// Arguments returns the arguments the job was submitted with.
+func (r *Run) Arguments() map[string]any {
+	return r.Job().Arguments
+}
+
+// Step runs fn as the step name of the job and records its state, message and
+// error. A step that already succeeded before the server restarted is not run
+// again, its message is returned instead.
+func (r *Run) Step(ctx context.Context, name string, fn func(ctx context.Context) (string, error)) (string, error) {
+	for _, step := range r.Job().Steps {
+		if step.Name == name && step.State == StateSucceeded {
+			return step.Message, nil
+		}
+	}
+
+	var index int
+	r.update(func(job *Job) {
+		job.Steps = append(job.Steps, Step{Name: name, State: StateRunning, Started: time.Now().UTC()})
+		index = len(job.Steps) - 1
+	})
+
+	message, err := fn(ctx)
+
+	r.update(func(job *Job) {
+		switch {
+		case errors.Is(context.Cause(ctx), ErrCancelled):
+			finishStep(&job.Steps[index], StateCancelled, message, ErrCancelled.Error())
+		case err != nil:
+			finishStep(&job.Steps[index], StateFailed, message, err.Error())
+		default:
+			finishStep(&job.Steps[index], StateSucceeded, message, "")
+		}
+	})
+
+	return message, err
+}
+
+// update changes the job with fn and saves it.
+func (r *Run) update(fn func(job *Job)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fn(&r.job)
+	r.job.Updated = time.Now().UTC()
+
+	if err := r.store.Save(r.job); err != nil {
+		zap.L().Warn(fmt.Sprintf("[Jobs] Failed to save job %s err=%v", r.job.ID, err))
+	}
+}
+
+func finish(job *Job, state State, errMsg string) {
+	now := time.Now().UTC()
+	job.State = state
+	job.Error = errMsg
+	job.Updated = now
+	job.Finished = &now
+}
+
+func finishStep(step *Step, state State, message, errMsg string) {
+	now := time.Now().UTC()
+	step.State = state
+	step.Message = message
+	step.Error = errMsg
+	step.Finished = &now
+}
+
+func newID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate a job id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestManager_Submit(t *testing.T) {
+	cases := []struct {
+		name          string
+		waitErr       error
+		expectedState State
+		expectedError string
+	}{
+		{"succeeds", nil, StateSucceeded, ""},
+		{"fails at a step", errors.New("machine broken"), StateFailed, "machine broken"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			manager := NewManager(openTestStore(t, filepath.Join(t.TempDir(), "jobs.db")))
+			manager.Handle("deploy", func(ctx context.Context, run *Run) (string, error) {
+				if _, err := run.Step(ctx, "deploy", func(ctx context.Context) (string, error) { return "Deploying", nil }); err != nil {
+					return "", err
+				}
+				return run.Step(ctx, "wait", func(ctx context.Context) (string, error) { return "Deployed", tc.waitErr })
+			})
+
+			// Act
+			submitted, err := manager.Submit("deploy", "anonymous", map[string]any{"id": "abc123"})
+			manager.wg.Wait()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			job, err := manager.Get(submitted.ID)
+			if err != nil {
+				t.Fatalf("failed to get the job: %v", err)
+			}
+			if job.State != tc.expectedState || job.Error != tc.expectedError || job.Finished == nil {
+				t.Errorf("expected state %s and error %q, got %+v", tc.expectedState, tc.expectedError, job)
+			}
+			if len(job.Steps) != 2 || job.Steps[0].State != StateSucceeded || job.Steps[1].Message != "Deployed" {
+				t.Errorf("expected the two steps to be recorded, got %+v", job.Steps)
+			}
+		})
+	}
+}
+
+func TestManager_SubmitSecretArguments(t *testing.T) {
+	// Arrange
+	store := openTestStore(t, filepath.Join(t.TempDir(), "jobs.db"))
+	manager := NewManager(store)
+	var given any
+	manager.Handle("deploy", func(ctx context.Context, run *Run) (string, error) {
+		given = run.Arguments()["parameters"]
+		return "Deployed", nil
+	})
+
+	// Act
+	submitted, err := manager.Submit("deploy", "anonymous", map[string]any{"id": "abc123", "parameters": "s3cr3t"}, "parameters", "document")
+	manager.wg.Wait()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if given != "s3cr3t" {
+		t.Errorf("expected the runner to get the secret argument, got %v", given)
+	}
+	job, _ := store.Get(submitted.ID)
+	if job.Arguments["parameters"] != Redacted || job.Arguments["id"] != "abc123" || strings.Join(job.SecretArguments, ",") != "parameters" {
+		t.Errorf("expected only the secret argument to be redacted in the store, got %+v", job)
+	}
+
+	t.Run("is not resumed", func(t *testing.T) {
+		// Arrange
+		job.State = StateRunning
+		if err := store.Save(job); err != nil {
+			t.Fatalf("failed to save the job: %v", err)
+		}
+		given = nil
+
+		// Act
+		err := manager.Resume(nil)
+		manager.wg.Wait()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resumed, _ := manager.Get(job.ID)
+		if given != nil || resumed.State != StateFailed || !strings.Contains(resumed.Error, "arguments parameters are not stored") {
+			t.Errorf("expected the job to fail without running, got %+v", resumed)
+		}
+	})
+}
+
+func TestManager_SubmitUnknownKind(t *testing.T) {
+	// Arrange
+	manager := NewManager(openTestStore(t, filepath.Join(t.TempDir(), "jobs.db")))
+
+	// Act
+	_, err := manager.Submit("unknown", "anonymous", nil)
+
+	// Assert
+	if err == nil {
+		t.Error("expected an error for a kind without runner")
+	}
+}
+
+func TestManager_Cancel(t *testing.T) {
+	// Arrange
+	manager := NewManager(openTestStore(t, filepath.Join(t.TempDir(), "jobs.db")))
+	started := make(chan struct{})
+	manager.Handle("wait", func(ctx context.Context, run *Run) (string, error) {
+		return run.Step(ctx, "wait", func(ctx context.Context) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+	})
+	submitted, err := manager.Submit("wait", "anonymous", nil)
+	if err != nil {
+		t.Fatalf("failed to submit the job: %v", err)
+	}
+	<-started
+
+	// Act
+	_, err = manager.Cancel(submitted.ID)
+	manager.wg.Wait()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	job, _ := manager.Get(submitted.ID)
+	if job.State != StateCancelled || len(job.Steps) != 1 || job.Steps[0].State != StateCancelled {
+		t.Errorf("expected the job and its step to be cancelled, got %+v", job)
+	}
+	if _, err := manager.Cancel(submitted.ID); err == nil {
+		t.Error("expected an error cancelling a finished job")
+	}
+}
+
+func TestManager_Resume(t *testing.T) {
+	// Arrange
+	store := openTestStore(t, filepath.Join(t.TempDir(), "jobs.db"))
+	interrupted := Job{
+		ID:    "interrupted",
+		Kind:  "deploy",
+		State: StateRunning,
+		Steps: []Step{
+			{Name: "deploy", State: StateSucceeded, Message: "Deploying"},
+			{Name: "wait", State: StateRunning},
+		},
+	}
+	orphan := Job{ID: "orphan", Kind: "removed", State: StateQueued}
+	for _, job := range []Job{interrupted, orphan} {
+		if err := store.Save(job); err != nil {
+			t.Fatalf("failed to save job %s: %v", job.ID, err)
+		}
+	}
+
+	var ran []string
+	manager := NewManager(store)
+	manager.Handle("deploy", func(ctx context.Context, run *Run) (string, error) {
+		for _, name := range []string{"deploy", "wait"} {
+			if _, err := run.Step(ctx, name, func(ctx context.Context) (string, error) {
+				ran = append(ran, name)
+				return name + " done", nil
+			}); err != nil {
+				return "", err
+			}
+		}
+		return "Deployed", nil
+	})
+
+	// Act
+	err := manager.Resume(nil)
+	manager.wg.Wait()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "wait" {
+		t.Errorf("expected only the wait step to run again, got %v", ran)
+	}
+	job, _ := manager.Get("interrupted")
+	if job.State != StateSucceeded || len(job.Steps) != 3 || job.Steps[1].State != StateFailed {
+		t.Errorf("expected the interrupted step to fail and the job to succeed, got %+v", job)
+	}
+	job, _ = manager.Get("orphan")
+	if job.State != StateFailed {
+		t.Errorf("expected the job without runner to fail, got %+v", job)
+	}
+}
+
+func TestManager_ResumeRefused(t *testing.T) {
+	// Arrange
+	store := openTestStore(t, filepath.Join(t.TempDir(), "jobs.db"))
+	for _, job := range []Job{
+		{ID: "allowed", Kind: "deploy", State: StateQueued, Caller: "token:ops"},
+		{ID: "refused", Kind: "deploy", State: StateRunning, Caller: "token:ci-bot"},
+	} {
+		if err := store.Save(job); err != nil {
+			t.Fatalf("failed to save job %s: %v", job.ID, err)
+		}
+	}
+
+	var ran []string
+	var mu sync.Mutex
+	manager := NewManager(store)
+	manager.Handle("deploy", func(ctx context.Context, run *Run) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, run.Job().ID)
+		return "Deployed", nil
+	})
+
+	// Act
+	err := manager.Resume(func(job Job) error {
+		if job.Caller != "token:ops" {
+			return fmt.Errorf("caller %s may not use %s", job.Caller, job.Kind)
+		}
+		return nil
+	})
+	manager.wg.Wait()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "allowed" {
+		t.Errorf("expected only the allowed job to run, got %v", ran)
+	}
+	job, _ := manager.Get("refused")
+	if job.State != StateFailed || !strings.Contains(job.Error, "may not use deploy") {
+		t.Errorf("expected the refused job to fail with the check error, got %+v", job)
+	}
+}
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var jobsBucket = []byte("jobs")
+
+// Redacted replaces the secret arguments of the jobs in the database.
+const Redacted = "REDACTED"
+
+// ErrNotFound is returned for jobs missing from the store.
+var ErrNotFound = errors.New("job not found")
+
+// State is the state of a job or of one of its steps.
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+// Done reports whether the state is final.
+func (s State) Done() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCancelled
+}
+
+// Step is a step of a job, like starting a deployment or waiting for it.
+type Step struct {
+	Name     string     `json:"name"`
+	State    State      `json:"state"`
+	Message  string     `json:"message,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// Job is a long-running operation run in the background.
+type Job struct {
+	ID        string         `json:"id"`
+	Kind      string         `json:"kind"`
+	State     State          `json:"state"`
+	Caller    string         `json:"caller,omitempty"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	// SecretArguments name the arguments kept in memory only. The database
+	// stores them as Redacted, so a job with secret arguments cannot be
+	// resumed after a restart.
+	SecretArguments []string   `json:"secret_arguments,omitempty"`
+	Steps           []Step     `json:"steps"`
+	Result          string     `json:"result,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	Created         time.Time  `json:"created"`
+	Updated         time.Time  `json:"updated"`
+	Finished        *time.Time `json:"finished,omitempty"`
+}
+
+// Store persists jobs in an embedded bbolt database.
+type Store struct {
+	db *bbolt.DB
+}
+
+// OpenStore opens the job database at path, creating it if missing. It fails
+// when another process holds the database for more than a second.
+func OpenStore(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job database %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize job database %s: %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save creates or replaces the job, with its secret arguments redacted.
+func (s *Store) Save(job Job) error {
+	if len(job.SecretArguments) > 0 {
+		job.Arguments = maps.Clone(job.Arguments)
+		for _, key := range job.SecretArguments {
+			if _, ok := job.Arguments[key]; ok {
+				job.Arguments[key] = Redacted
+			}
+		}
+	}
+
+	content, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(job.ID), content)
+	})
+}
+
+// Get returns the job with the given id, or ErrNotFound.
+func (s *Store) Get(id string) (Job, error) {
+	var job Job
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		content := tx.Bucket(jobsBucket).Get([]byte(id))
+		if content == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(content, &job)
+	})
+	return job, err
+}
+
+// List returns every job, newest first.
+func (s *Store) List() ([]Job, error) {
+	jobs := []Job{}
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(key, content []byte) error {
+			var job Job
+			if err := json.Unmarshal(content, &job); err != nil {
+				return fmt.Errorf("failed to decode job %s: %w", key, err)
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].Created.After(jobs[j].Created) })
+	return jobs, nil
+}
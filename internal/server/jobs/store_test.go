@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_SaveAndReopen(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	store := openTestStore(t, path)
+	created := time.Now().UTC()
+	older := Job{ID: "older", Kind: "deploy-machine", State: StateSucceeded, Created: created.Add(-time.Minute)}
+	newer := Job{ID: "newer", Kind: "deploy-machine", State: StateRunning, Arguments: map[string]any{"machineId": "abc123"}, Created: created}
+
+	// Act
+	for _, job := range []Job{older, newer} {
+		if err := store.Save(job); err != nil {
+			t.Fatalf("failed to save job %s: %v", job.ID, err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close the store: %v", err)
+	}
+	reopened := openTestStore(t, path)
+	jobs, err := reopened.List()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "newer" || jobs[1].ID != "older" {
+		t.Fatalf("expected the jobs newest first, got %+v", jobs)
+	}
+	if jobs[0].Arguments["machineId"] != "abc123" || jobs[0].State != StateRunning {
+		t.Errorf("expected the job to survive the reopening, got %+v", jobs[0])
+	}
+}
+
+func TestStore_GetMissing(t *testing.T) {
+	// Arrange
+	store := openTestStore(t, filepath.Join(t.TempDir(), "jobs.db"))
+
+	// Act
+	_, err := store.Get("missing")
+
+	// Assert
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package maas_api is a typed view of the MAAS 2.0 REST API built on
+// maas_client.Client. Responses are decoded into Go structs, so a field whose
+// type changes in MAAS fails the call instead of silently reading as zero.
+package maas_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+)
+
+const basePath = "/MAAS/api/2.0"
+
+// API sends typed requests to MAAS through a maas_client.Client.
+type API struct {
+	client maas_client.Client
+}
+
+// New returns an API sending its requests through client.
+func New(client maas_client.Client) *API {
+	return &API{client: client}
+}
+
+// IsNotFound reports whether err is a 404 answer from MAAS.
+func IsNotFound(err error) bool {
+	return HasStatus(err, http.StatusNotFound)
+}
+
+// HasStatus reports whether err is an answer from MAAS with the given status.
+func HasStatus(err error, status int) bool {
+	var statusErr *maas_client.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == status
+}
+
+func (a *API) get(ctx context.Context, path string, query url.Values, out any) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return a.do(ctx, maas_client.RequestTypeGet, path, nil, out)
+}
+
+// getRaw returns the undecoded response, for the endpoints that do not answer with JSON.
+func (a *API) getRaw(ctx context.Context, path string, query url.Values) (string, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return a.client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+}
+
+func (a *API) post(ctx context.Context, path string, form url.Values, out any) error {
+	return a.do(ctx, maas_client.RequestTypePost, path, form, out)
+}
+
+func (a *API) put(ctx context.Context, path string, form url.Values, out any) error {
+	return a.do(ctx, maas_client.RequestTypePut, path, form, out)
+}
+
+func (a *API) delete(ctx context.Context, path string) error {
+	return a.do(ctx, maas_client.RequestTypeDelete, path, nil, nil)
+}
+
+// do sends the request and decodes the response into out, unless out is nil.
+func (a *API) do(ctx context.Context, requestType maas_client.RequestType, path string, form url.Values, out any) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	response, err := a.client.Do(ctx, requestType, path, body)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(response), out); err != nil {
+		return fmt.Errorf("failed to decode the response of %s %s: %w", requestType, path, err)
+	}
+	return nil
+}
+
+// setString adds value to form unless it is empty.
+func setString(form url.Values, key, value string) {
+	if value != "" {
+		form.Set(key, value)
+	}
+}
+
+// setInt adds value to form unless it is nil.
+func setInt(form url.Values, key string, value *int) {
+	if value != nil {
+		form.Set(key, strconv.Itoa(*value))
+	}
+}
+
+// setInt64 adds value to form unless it is nil.
+func setInt64(form url.Values, key string, value *int64) {
+	if value != nil {
+		form.Set(key, strconv.FormatInt(*value, 10))
+	}
+}
+
+// setBool adds value to form as 1 or 0 unless it is nil.
+func setBool(form url.Values, key string, value *bool) {
+	if value == nil {
+		return
+	}
+	if *value {
+		form.Set(key, "1")
+	} else {
+		form.Set(key, "0")
+	}
+}
@@ -0,0 +1,184 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// BootResource is an image imported in MAAS, like ubuntu/noble for amd64.
+type BootResource struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"` // Synced, Uploaded or Generated
+	// Name is the operating system and the series, like ubuntu/noble.
+	Name string `json:"name"`
+	// Architecture is the architecture and the subarchitecture, like
+	// amd64/ga-24.04.
+	Architecture string `json:"architecture"`
+	// Subarches are the comma separated subarchitectures the image boots.
+	Subarches   string `json:"subarches,omitempty"`
+	Title       string `json:"title,omitempty"`
+	ResourceURI string `json:"resource_uri"`
+
+	// Sets are the versions of the image, by version. They are only returned
+	// by GetBootResource.
+	Sets map[string]BootResourceSet `json:"sets,omitempty"`
+}
+
+// BootResourceSet is a version of an image.
+type BootResourceSet struct {
+	Version  string  `json:"version"`
+	Label    string  `json:"label"`
+	Size     int64   `json:"size"` // in bytes
+	Complete bool    `json:"complete"`
+	Progress float64 `json:"progress"` // in percent
+}
+
+// LatestSet returns the newest version of the image.
+func (r BootResource) LatestSet() (BootResourceSet, bool) {
+	if len(r.Sets) == 0 {
+		return BootResourceSet{}, false
+	}
+	versions := slices.Sorted(maps.Keys(r.Sets))
+	return r.Sets[versions[len(versions)-1]], true
+}
+
+// OSystem returns the operating system of the image, like ubuntu.
+func (r BootResource) OSystem() string {
+	osystem, _, _ := strings.Cut(r.Name, "/")
+	return osystem
+}
+
+// Series returns the series of the image, like noble.
+func (r BootResource) Series() string {
+	_, series, _ := strings.Cut(r.Name, "/")
+	return series
+}
+
+// Arch returns the architecture of the image without its subarchitecture.
+func (r BootResource) Arch() string {
+	arch, _, _ := strings.Cut(r.Architecture, "/")
+	return arch
+}
+
+// Kernels returns the subarchitectures of the image, which are the kernels
+// a machine can be deployed with, like ga-24.04 or hwe-24.04.
+func (r BootResource) Kernels() []string {
+	var kernels []string
+	if _, subarch, found := strings.Cut(r.Architecture, "/"); found {
+		kernels = append(kernels, subarch)
+	}
+	for _, subarch := range strings.Split(r.Subarches, ",") {
+		if subarch = strings.TrimSpace(subarch); subarch != "" {
+			kernels = append(kernels, subarch)
+		}
+	}
+	return kernels
+}
+
+// ListBootResources returns the images imported in MAAS.
+func (a *API) ListBootResources(ctx context.Context) ([]BootResource, error) {
+	var resources []BootResource
+	if err := a.get(ctx, basePath+"/boot-resources/", nil, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetBootResource returns the image with the given id, with its versions.
+func (a *API) GetBootResource(ctx context.Context, id int) (BootResource, error) {
+	var resource BootResource
+	err := a.get(ctx, fmt.Sprintf("%s/boot-resources/%d/", basePath, id), nil, &resource)
+	return resource, err
+}
+
+// ImportBootResources starts importing the images selected on the boot
+// sources.
+func (a *API) ImportBootResources(ctx context.Context) error {
+	return a.post(ctx, basePath+"/boot-resources/op-import", nil, nil)
+}
+
+// IsImportingBootResources reports whether MAAS is importing images.
+func (a *API) IsImportingBootResources(ctx context.Context) (bool, error) {
+	var importing bool
+	err := a.get(ctx, basePath+"/boot-resources/op-is_importing", nil, &importing)
+	return importing, err
+}
+
+// BootSource is a simplestreams mirror MAAS imports images from.
+type BootSource struct {
+	ID              int    `json:"id"`
+	URL             string `json:"url"`
+	KeyringFilename string `json:"keyring_filename,omitempty"`
+	Created         string `json:"created,omitempty"`
+	Updated         string `json:"updated,omitempty"`
+	ResourceURI     string `json:"resource_uri"`
+}
+
+// BootSourceSelection selects the images of a release imported from a boot
+// source.
+type BootSourceSelection struct {
+	ID           int      `json:"id"`
+	BootSourceID int      `json:"boot_source_id"`
+	OS           string   `json:"os"`
+	Release      string   `json:"release"`
+	Arches       []string `json:"arches"`
+	Subarches    []string `json:"subarches"`
+	Labels       []string `json:"labels"`
+	ResourceURI  string   `json:"resource_uri"`
+}
+
+func bootSourcePath(id int) string {
+	return fmt.Sprintf("%s/boot-sources/%d/", basePath, id)
+}
+
+// ListBootSources returns the boot sources.
+func (a *API) ListBootSources(ctx context.Context) ([]BootSource, error) {
+	var sources []BootSource
+	if err := a.get(ctx, basePath+"/boot-sources/", nil, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// ListBootSourceSelections returns the selections of a boot source.
+func (a *API) ListBootSourceSelections(ctx context.Context, sourceID int) ([]BootSourceSelection, error) {
+	var selections []BootSourceSelection
+	if err := a.get(ctx, bootSourcePath(sourceID)+"selections/", nil, &selections); err != nil {
+		return nil, err
+	}
+	return selections, nil
+}
+
+// BootSourceSelectionParams are the fields of CreateBootSourceSelection.
+// Empty lists are left to the MAAS default, every subarchitecture and label.
+type BootSourceSelectionParams struct {
+	OS        string
+	Release   string
+	Arches    []string
+	Subarches []string
+	Labels    []string
+}
+
+// CreateBootSourceSelection selects a release to import from a boot source.
+func (a *API) CreateBootSourceSelection(ctx context.Context, sourceID int, params BootSourceSelectionParams) (BootSourceSelection, error) {
+	form := url.Values{}
+	setString(form, "os", params.OS)
+	setString(form, "release", params.Release)
+	for _, arch := range params.Arches {
+		form.Add("arches", arch)
+	}
+	for _, subarch := range params.Subarches {
+		form.Add("subarches", subarch)
+	}
+	for _, label := range params.Labels {
+		form.Add("labels", label)
+	}
+
+	var selection BootSourceSelection
+	err := a.post(ctx, bootSourcePath(sourceID)+"selections/", form, &selection)
+	return selection, err
+}
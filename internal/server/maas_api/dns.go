@@ -0,0 +1,195 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Domain is a DNS domain managed by MAAS.
+type Domain struct {
+	ID                  int    `json:"id"`
+	Name                string `json:"name"`
+	TTL                 *int   `json:"ttl"`
+	Authoritative       bool   `json:"authoritative"`
+	ResourceRecordCount int    `json:"resource_record_count"`
+	IsDefault           bool   `json:"is_default"`
+	ResourceURI         string `json:"resource_uri"`
+}
+
+// DNSResource is a name in a domain with its addresses and its other
+// resource records. MAAS serves A and AAAA records from the addresses.
+type DNSResource struct {
+	ID              int                 `json:"id"`
+	FQDN            string              `json:"fqdn"`
+	AddressTTL      *int                `json:"address_ttl"`
+	IPAddresses     []DNSAddress        `json:"ip_addresses"`
+	ResourceRecords []DNSResourceRecord `json:"resource_records"`
+	ResourceURI     string              `json:"resource_uri"`
+}
+
+// DNSAddress is an address of a DNS resource.
+type DNSAddress struct {
+	IP string `json:"ip"`
+}
+
+// DNSResourceRecord is a record other than A and AAAA, such as CNAME, TXT
+// or SRV.
+type DNSResourceRecord struct {
+	ID          int    `json:"id"`
+	FQDN        string `json:"fqdn"`
+	TTL         *int   `json:"ttl"`
+	RRType      string `json:"rrtype"`
+	RRData      string `json:"rrdata"`
+	ResourceURI string `json:"resource_uri"`
+}
+
+// DomainParams are the fields of a domain to create or update. Empty and nil
+// fields are left unchanged.
+type DomainParams struct {
+	Name          string
+	Authoritative *bool
+	TTL           *int
+}
+
+func (p DomainParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setBool(form, "authoritative", p.Authoritative)
+	setInt(form, "ttl", p.TTL)
+	return form
+}
+
+// DNSResourceParams are the fields of a DNS resource to create or update.
+// Empty and nil fields are left unchanged.
+type DNSResourceParams struct {
+	FQDN string
+	// IPAddresses is a space separated list of addresses.
+	IPAddresses string
+	AddressTTL  *int
+}
+
+func (p DNSResourceParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "fqdn", p.FQDN)
+	setString(form, "ip_addresses", p.IPAddresses)
+	setInt(form, "address_ttl", p.AddressTTL)
+	return form
+}
+
+// DNSResourceRecordParams are the fields of a resource record to create.
+type DNSResourceRecordParams struct {
+	FQDN   string
+	RRType string
+	RRData string
+	TTL    *int
+}
+
+func (p DNSResourceRecordParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "fqdn", p.FQDN)
+	setString(form, "rrtype", p.RRType)
+	setString(form, "rrdata", p.RRData)
+	setInt(form, "ttl", p.TTL)
+	return form
+}
+
+func domainPath(id int) string {
+	return fmt.Sprintf("%s/domains/%d/", basePath, id)
+}
+
+func dnsResourcePath(id int) string {
+	return fmt.Sprintf("%s/dnsresources/%d/", basePath, id)
+}
+
+func dnsResourceRecordPath(id int) string {
+	return fmt.Sprintf("%s/dnsresourcerecords/%d/", basePath, id)
+}
+
+// ListDomains returns all the domains.
+func (a *API) ListDomains(ctx context.Context) ([]Domain, error) {
+	var domains []Domain
+	if err := a.get(ctx, basePath+"/domains/", nil, &domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// GetDomain returns the domain with the given ID.
+func (a *API) GetDomain(ctx context.Context, id int) (Domain, error) {
+	var domain Domain
+	err := a.get(ctx, domainPath(id), nil, &domain)
+	return domain, err
+}
+
+// CreateDomain creates a domain. The name of params is required.
+func (a *API) CreateDomain(ctx context.Context, params DomainParams) (Domain, error) {
+	var domain Domain
+	err := a.post(ctx, basePath+"/domains/", params.form(), &domain)
+	return domain, err
+}
+
+// UpdateDomain updates the domain with the given ID.
+func (a *API) UpdateDomain(ctx context.Context, id int, params DomainParams) (Domain, error) {
+	var domain Domain
+	err := a.put(ctx, domainPath(id), params.form(), &domain)
+	return domain, err
+}
+
+// DeleteDomain deletes the domain with the given ID.
+func (a *API) DeleteDomain(ctx context.Context, id int) error {
+	return a.delete(ctx, domainPath(id))
+}
+
+// ListDNSResources returns the DNS resources defined by users, those of the
+// given domain when it is not empty.
+func (a *API) ListDNSResources(ctx context.Context, domain string) ([]DNSResource, error) {
+	query := url.Values{}
+	setString(query, "domain", domain)
+
+	var resources []DNSResource
+	if err := a.get(ctx, basePath+"/dnsresources/", query, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetDNSResource returns the DNS resource with the given ID.
+func (a *API) GetDNSResource(ctx context.Context, id int) (DNSResource, error) {
+	var resource DNSResource
+	err := a.get(ctx, dnsResourcePath(id), nil, &resource)
+	return resource, err
+}
+
+// CreateDNSResource creates a DNS resource.
+func (a *API) CreateDNSResource(ctx context.Context, params DNSResourceParams) (DNSResource, error) {
+	var resource DNSResource
+	err := a.post(ctx, basePath+"/dnsresources/", params.form(), &resource)
+	return resource, err
+}
+
+// UpdateDNSResource updates the DNS resource with the given ID.
+func (a *API) UpdateDNSResource(ctx context.Context, id int, params DNSResourceParams) (DNSResource, error) {
+	var resource DNSResource
+	err := a.put(ctx, dnsResourcePath(id), params.form(), &resource)
+	return resource, err
+}
+
+// DeleteDNSResource deletes the DNS resource with the given ID together with
+// its resource records.
+func (a *API) DeleteDNSResource(ctx context.Context, id int) error {
+	return a.delete(ctx, dnsResourcePath(id))
+}
+
+// CreateDNSResourceRecord creates a resource record. The DNS resource of
+// its FQDN is created when it does not exist.
+func (a *API) CreateDNSResourceRecord(ctx context.Context, params DNSResourceRecordParams) (DNSResourceRecord, error) {
+	var record DNSResourceRecord
+	err := a.post(ctx, basePath+"/dnsresourcerecords/", params.form(), &record)
+	return record, err
+}
+
+// DeleteDNSResourceRecord deletes the resource record with the given ID.
+func (a *API) DeleteDNSResourceRecord(ctx context.Context, id int) error {
+	return a.delete(ctx, dnsResourceRecordPath(id))
+}
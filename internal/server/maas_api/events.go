@@ -0,0 +1,73 @@
+package maas_api
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Event is an entry of the MAAS event log.
+type Event struct {
+	ID          int    `json:"id"`
+	Level       string `json:"level"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Hostname    string `json:"hostname"`
+	// Node is the system ID of the node the event is about.
+	Node    string `json:"node"`
+	Created string `json:"created"`
+}
+
+// EventsPage is a page of the event log, newest events first.
+type EventsPage struct {
+	Count   int     `json:"count"`
+	Events  []Event `json:"events"`
+	PrevURI string  `json:"prev_uri"`
+	NextURI string  `json:"next_uri"`
+}
+
+// EventFilter selects events. Zero fields do not filter.
+type EventFilter struct {
+	// Level is the minimum level of the events, such as "INFO" or "ERROR".
+	Level string
+	Limit int
+	// Before and After are event IDs bounding the page.
+	Before int
+	After  int
+	// SystemIDs selects the events of the given nodes.
+	SystemIDs []string
+}
+
+func (f EventFilter) query() url.Values {
+	query := url.Values{}
+	setString(query, "level", f.Level)
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Before > 0 {
+		query.Set("before", strconv.Itoa(f.Before))
+	}
+	if f.After > 0 {
+		query.Set("after", strconv.Itoa(f.After))
+	}
+	for _, id := range f.SystemIDs {
+		query.Add("id", id)
+	}
+	return query
+}
+
+// ListEvents returns a page of the events matching filter.
+func (a *API) ListEvents(ctx context.Context, filter EventFilter) (EventsPage, error) {
+	var page EventsPage
+	err := a.get(ctx, basePath+"/events/", filter.query(), &page)
+	return page, err
+}
+
+// LatestEvent returns the newest event of the node, or false when it has none.
+func (a *API) LatestEvent(ctx context.Context, systemID string) (Event, bool, error) {
+	page, err := a.ListEvents(ctx, EventFilter{Limit: 1, SystemIDs: []string{systemID}})
+	if err != nil || len(page.Events) == 0 {
+		return Event{}, false, err
+	}
+	return page.Events[0], true, nil
+}
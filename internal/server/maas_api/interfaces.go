@@ -0,0 +1,166 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Link modes accepted by LinkSubnet.
+const (
+	LinkModeAuto   = "AUTO"
+	LinkModeDHCP   = "DHCP"
+	LinkModeStatic = "STATIC"
+	LinkModeLinkUp = "LINK_UP"
+)
+
+// BondParams are the fields of a bond to create.
+type BondParams struct {
+	Name       string
+	Parents    []int
+	MACAddress string
+	BondMode   string
+	MTU        *int
+}
+
+func (p BondParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	for _, parent := range p.Parents {
+		form.Add("parents", strconv.Itoa(parent))
+	}
+	setString(form, "mac_address", p.MACAddress)
+	setString(form, "bond_mode", p.BondMode)
+	setInt(form, "mtu", p.MTU)
+	return form
+}
+
+// BridgeParams are the fields of a bridge to create.
+type BridgeParams struct {
+	Name       string
+	Parent     int
+	MACAddress string
+	BridgeSTP  *bool
+	BridgeFD   *int
+	MTU        *int
+}
+
+func (p BridgeParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	form.Set("parent", strconv.Itoa(p.Parent))
+	setString(form, "mac_address", p.MACAddress)
+	setBool(form, "bridge_stp", p.BridgeSTP)
+	setInt(form, "bridge_fd", p.BridgeFD)
+	setInt(form, "mtu", p.MTU)
+	return form
+}
+
+// VLANInterfaceParams are the fields of a VLAN sub-interface to create. VLAN
+// is the ID of the VLAN, not its VID.
+type VLANInterfaceParams struct {
+	VLAN   int
+	Parent int
+	MTU    *int
+}
+
+func (p VLANInterfaceParams) form() url.Values {
+	form := url.Values{}
+	form.Set("vlan", strconv.Itoa(p.VLAN))
+	form.Set("parent", strconv.Itoa(p.Parent))
+	setInt(form, "mtu", p.MTU)
+	return form
+}
+
+// LinkSubnetParams are the fields of a link between an interface and a
+// subnet. Subnet is left out when 0 and IPAddress when empty; MAAS then picks
+// a free address for STATIC links.
+type LinkSubnetParams struct {
+	Mode           string
+	Subnet         int
+	IPAddress      string
+	DefaultGateway bool
+}
+
+func (p LinkSubnetParams) form() url.Values {
+	form := url.Values{}
+	form.Set("mode", p.Mode)
+	if p.Subnet != 0 {
+		form.Set("subnet", strconv.Itoa(p.Subnet))
+	}
+	setString(form, "ip_address", p.IPAddress)
+	if p.DefaultGateway {
+		form.Set("default_gateway", "1")
+	}
+	return form
+}
+
+func interfacesPath(systemID string) string {
+	return nodePath(systemID) + "interfaces/"
+}
+
+func interfacePath(systemID string, id int) string {
+	return fmt.Sprintf("%s%d/", interfacesPath(systemID), id)
+}
+
+// GetInterface returns the interface with the given ID of a machine.
+func (a *API) GetInterface(ctx context.Context, systemID string, id int) (Interface, error) {
+	var iface Interface
+	err := a.get(ctx, interfacePath(systemID, id), nil, &iface)
+	return iface, err
+}
+
+// CreateBond creates a bond of the parent interfaces of a machine.
+func (a *API) CreateBond(ctx context.Context, systemID string, params BondParams) (Interface, error) {
+	var iface Interface
+	err := a.post(ctx, interfacesPath(systemID)+"op-create_bond", params.form(), &iface)
+	return iface, err
+}
+
+// CreateBridge creates a bridge on top of an interface of a machine.
+func (a *API) CreateBridge(ctx context.Context, systemID string, params BridgeParams) (Interface, error) {
+	var iface Interface
+	err := a.post(ctx, interfacesPath(systemID)+"op-create_bridge", params.form(), &iface)
+	return iface, err
+}
+
+// CreateVLANInterface creates a tagged VLAN sub-interface on top of an
+// interface of a machine.
+func (a *API) CreateVLANInterface(ctx context.Context, systemID string, params VLANInterfaceParams) (Interface, error) {
+	var iface Interface
+	err := a.post(ctx, interfacesPath(systemID)+"op-create_vlan", params.form(), &iface)
+	return iface, err
+}
+
+// DeleteInterface deletes the interface with the given ID of a machine.
+func (a *API) DeleteInterface(ctx context.Context, systemID string, id int) error {
+	return a.delete(ctx, interfacePath(systemID, id))
+}
+
+// LinkSubnet links an interface of a machine to a subnet and returns the
+// updated interface.
+func (a *API) LinkSubnet(ctx context.Context, systemID string, id int, params LinkSubnetParams) (Interface, error) {
+	var iface Interface
+	err := a.post(ctx, interfacePath(systemID, id)+"op-link_subnet", params.form(), &iface)
+	return iface, err
+}
+
+// UnlinkSubnet removes the link with the given ID from an interface of a
+// machine and returns the updated interface.
+func (a *API) UnlinkSubnet(ctx context.Context, systemID string, id, linkID int) (Interface, error) {
+	var iface Interface
+	err := a.post(ctx, interfacePath(systemID, id)+"op-unlink_subnet", url.Values{"id": {strconv.Itoa(linkID)}}, &iface)
+	return iface, err
+}
+
+// SetDefaultGateway routes the machine through the gateway of a link of the
+// interface. MAAS picks the link when linkID is nil.
+func (a *API) SetDefaultGateway(ctx context.Context, systemID string, id int, linkID *int) (Interface, error) {
+	form := url.Values{}
+	setInt(form, "link_id", linkID)
+
+	var iface Interface
+	err := a.post(ctx, interfacePath(systemID, id)+"op-set_default_gateway", form, &iface)
+	return iface, err
+}
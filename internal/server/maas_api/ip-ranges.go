@@ -0,0 +1,81 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// SubnetRange is a dynamic or reserved range of addresses of a subnet, as
+// managed on the ipranges endpoint.
+type SubnetRange struct {
+	ID          int     `json:"id"`
+	Type        string  `json:"type"`
+	StartIP     string  `json:"start_ip"`
+	EndIP       string  `json:"end_ip"`
+	Comment     string  `json:"comment"`
+	Subnet      *Subnet `json:"subnet"`
+	User        string  `json:"user"`
+	ResourceURI string  `json:"resource_uri"`
+}
+
+// IPRangeParams are the fields of an IP range to create or update. Empty
+// fields are left unchanged.
+type IPRangeParams struct {
+	// Type is "dynamic" or "reserved".
+	Type    string
+	StartIP string
+	EndIP   string
+	// Subnet is the ID of the subnet of the range.
+	Subnet  string
+	Comment string
+}
+
+func (p IPRangeParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "type", p.Type)
+	setString(form, "start_ip", p.StartIP)
+	setString(form, "end_ip", p.EndIP)
+	setString(form, "subnet", p.Subnet)
+	setString(form, "comment", p.Comment)
+	return form
+}
+
+func ipRangePath(id int) string {
+	return fmt.Sprintf("%s/ipranges/%d/", basePath, id)
+}
+
+// ListIPRanges returns the IP ranges of every subnet.
+func (a *API) ListIPRanges(ctx context.Context) ([]SubnetRange, error) {
+	var ranges []SubnetRange
+	if err := a.get(ctx, basePath+"/ipranges/", nil, &ranges); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// GetIPRange returns the IP range with the given ID.
+func (a *API) GetIPRange(ctx context.Context, id int) (SubnetRange, error) {
+	var ipRange SubnetRange
+	err := a.get(ctx, ipRangePath(id), nil, &ipRange)
+	return ipRange, err
+}
+
+// CreateIPRange creates an IP range.
+func (a *API) CreateIPRange(ctx context.Context, params IPRangeParams) (SubnetRange, error) {
+	var ipRange SubnetRange
+	err := a.post(ctx, basePath+"/ipranges/", params.form(), &ipRange)
+	return ipRange, err
+}
+
+// UpdateIPRange updates the IP range with the given ID.
+func (a *API) UpdateIPRange(ctx context.Context, id int, params IPRangeParams) (SubnetRange, error) {
+	var ipRange SubnetRange
+	err := a.put(ctx, ipRangePath(id), params.form(), &ipRange)
+	return ipRange, err
+}
+
+// DeleteIPRange deletes the IP range with the given ID.
+func (a *API) DeleteIPRange(ctx context.Context, id int) error {
+	return a.delete(ctx, ipRangePath(id))
+}
@@ -0,0 +1,517 @@
+package maas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// ProtectedTag marks the machines the tools must not touch.
+const ProtectedTag = "protected"
+
+// Machine is a MAAS machine as returned by the machines endpoints.
+type Machine struct {
+	SystemID      string `json:"system_id"`
+	Hostname      string `json:"hostname"`
+	FQDN          string `json:"fqdn"`
+	Description   string `json:"description,omitempty"`
+	Status        int    `json:"status"`
+	StatusName    string `json:"status_name"`
+	StatusMessage string `json:"status_message,omitempty"`
+	PowerState    string `json:"power_state"`
+	PowerType     string `json:"power_type"`
+	Locked        bool   `json:"locked"`
+	Owner         string `json:"owner,omitempty"`
+
+	Architecture string            `json:"architecture"`
+	CPUCount     int               `json:"cpu_count"`
+	CPUSpeed     int               `json:"cpu_speed,omitempty"`
+	Memory       int               `json:"memory"`  // in MiB
+	Storage      float64           `json:"storage"` // in MB
+	HardwareInfo map[string]string `json:"hardware_info,omitempty"`
+
+	OSystem      string `json:"osystem"`
+	DistroSeries string `json:"distro_series"`
+	HWEKernel    string `json:"hwe_kernel,omitempty"`
+
+	IPAddresses     []string         `json:"ip_addresses"`
+	DefaultGateways *DefaultGateways `json:"default_gateways,omitempty"`
+	BootInterface   *Interface       `json:"boot_interface,omitempty"`
+	Interfaces      []Interface      `json:"interface_set"`
+	BootDisk        *BlockDevice     `json:"boot_disk,omitempty"`
+	BlockDevices    []BlockDevice    `json:"blockdevice_set,omitempty"`
+
+	Zone     *Zone         `json:"zone,omitempty"`
+	Pool     *ResourcePool `json:"pool,omitempty"`
+	TagNames []string      `json:"tag_names"`
+
+	// Pod and VirtualMachineID are set for the machines composed on a VM host.
+	Pod              *PodReference `json:"pod,omitempty"`
+	VirtualMachineID *int          `json:"virtualmachine_id,omitempty"`
+
+	ResourceURI string `json:"resource_uri"`
+}
+
+// HasTag reports whether the machine has the tag.
+func (m Machine) HasTag(tag string) bool {
+	return slices.Contains(m.TagNames, tag)
+}
+
+// Protected reports whether the machine has the protected tag.
+func (m Machine) Protected() bool {
+	return m.HasTag(ProtectedTag)
+}
+
+// Zone is a MAAS availability zone.
+type Zone struct {
+	ID          int    `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ResourcePool is a MAAS resource pool.
+type ResourcePool struct {
+	ID          int    `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PodReference names the VM host a machine was composed on.
+type PodReference struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// DefaultGateways are the gateways a machine routes through.
+type DefaultGateways struct {
+	IPv4 Gateway `json:"ipv4"`
+	IPv6 Gateway `json:"ipv6"`
+}
+
+// Gateway is a default gateway of a machine.
+type Gateway struct {
+	GatewayIP string `json:"gateway_ip"`
+	LinkID    *int   `json:"link_id"`
+}
+
+// Interface is a network interface of a machine.
+type Interface struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	MACAddress string   `json:"mac_address"`
+	Enabled    bool     `json:"enabled,omitempty"`
+	Parents    []string `json:"parents"`
+	Children   []string `json:"children,omitempty"`
+	VLAN       *VLAN    `json:"vlan"`
+	Links      []Link   `json:"links"`
+	SystemID   string   `json:"system_id,omitempty"`
+}
+
+// Link is an address configuration of an interface.
+type Link struct {
+	ID        int     `json:"id"`
+	Mode      string  `json:"mode"`
+	IPAddress string  `json:"ip_address,omitempty"`
+	Subnet    *Subnet `json:"subnet,omitempty"`
+}
+
+// BlockDevice is a disk of a machine.
+type BlockDevice struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Type       string      `json:"type,omitempty"`
+	Path       string      `json:"path,omitempty"`
+	Size       int64       `json:"size"` // in bytes
+	Model      string      `json:"model,omitempty"`
+	Serial     string      `json:"serial,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Partitions []Partition `json:"partitions,omitempty"`
+	Filesystem *Filesystem `json:"filesystem,omitempty"`
+	// UsedFor describes what uses the device, like "GPT partitioned with 2
+	// partitions". AvailableSize is the space left for new partitions.
+	UsedFor       string `json:"used_for,omitempty"`
+	AvailableSize int64  `json:"available_size,omitempty"`
+}
+
+// Partition is a partition of a block device.
+type Partition struct {
+	ID         int         `json:"id"`
+	Path       string      `json:"path"`
+	Size       int64       `json:"size"` // in bytes
+	Bootable   bool        `json:"bootable,omitempty"`
+	Filesystem *Filesystem `json:"filesystem,omitempty"`
+	UsedFor    string      `json:"used_for,omitempty"`
+}
+
+// Filesystem is the filesystem of a block device or a partition.
+type Filesystem struct {
+	FSType       string `json:"fstype"`
+	Label        string `json:"label,omitempty"`
+	MountPoint   string `json:"mount_point,omitempty"`
+	MountOptions string `json:"mount_options,omitempty"`
+}
+
+// ScriptResult is the output of a commissioning or installation script run on a machine.
+type ScriptResult struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	ScriptResult int    `json:"script_result"`
+	ResultType   int    `json:"result_type"`
+	Node         struct {
+		SystemID string `json:"system_id"`
+	} `json:"node"`
+	// Data is the base64 encoded output of the script.
+	Data    string `json:"data"`
+	Created string `json:"created"`
+	Updated string `json:"updated"`
+}
+
+// MachineFilter selects machines. Empty fields do not filter.
+type MachineFilter struct {
+	// Status is a status such as "deployed" or "failed_commissioning".
+	Status    string
+	Hostnames []string
+	SystemIDs []string
+	// Tags selects the machines that have all of the tags.
+	Tags []string
+	Zone string
+	Pool string
+}
+
+func (f MachineFilter) query() url.Values {
+	query := url.Values{}
+	setString(query, "status", f.Status)
+	for _, hostname := range f.Hostnames {
+		query.Add("hostname", hostname)
+	}
+	for _, id := range f.SystemIDs {
+		query.Add("id", id)
+	}
+	for _, tag := range f.Tags {
+		query.Add("tags", tag)
+	}
+	setString(query, "zone", f.Zone)
+	setString(query, "pool", f.Pool)
+	return query
+}
+
+func machinePath(systemID string) string {
+	return fmt.Sprintf("%s/machines/%s/", basePath, url.PathEscape(systemID))
+}
+
+// ListMachines returns the machines matching filter.
+func (a *API) ListMachines(ctx context.Context, filter MachineFilter) ([]Machine, error) {
+	var machines []Machine
+	if err := a.get(ctx, basePath+"/machines/", filter.query(), &machines); err != nil {
+		return nil, err
+	}
+	return machines, nil
+}
+
+// ListMachinesRaw returns the machines matching filter as MAAS encoded them,
+// with the fields Machine does not model.
+func (a *API) ListMachinesRaw(ctx context.Context, filter MachineFilter) ([]json.RawMessage, error) {
+	var machines []json.RawMessage
+	if err := a.get(ctx, basePath+"/machines/", filter.query(), &machines); err != nil {
+		return nil, err
+	}
+	return machines, nil
+}
+
+// GetMachine returns the machine with the given system ID.
+func (a *API) GetMachine(ctx context.Context, systemID string) (Machine, error) {
+	var machine Machine
+	err := a.get(ctx, machinePath(systemID), nil, &machine)
+	return machine, err
+}
+
+// GetMachineRaw returns the machine with the given system ID as MAAS encoded
+// it, with the fields Machine does not model.
+func (a *API) GetMachineRaw(ctx context.Context, systemID string) (json.RawMessage, error) {
+	var machine json.RawMessage
+	err := a.get(ctx, machinePath(systemID), nil, &machine)
+	return machine, err
+}
+
+// GetMachineDetails returns the lshw and lldp details of the machine, as
+// encoded by MAAS.
+func (a *API) GetMachineDetails(ctx context.Context, systemID string) (string, error) {
+	return a.getRaw(ctx, machinePath(systemID)+"op-details", nil)
+}
+
+// ListInterfaces returns the network interfaces of a machine.
+func (a *API) ListInterfaces(ctx context.Context, systemID string) ([]Interface, error) {
+	var interfaces []Interface
+	if err := a.get(ctx, interfacesPath(systemID), nil, &interfaces); err != nil {
+		return nil, err
+	}
+	return interfaces, nil
+}
+
+// ListScriptResults returns the installation and commissioning script results of a machine.
+func (a *API) ListScriptResults(ctx context.Context, systemID string) ([]ScriptResult, error) {
+	var results []ScriptResult
+	if err := a.get(ctx, basePath+"/installation-results/", url.Values{"system_id": {systemID}}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// MachineParams are the fields of a machine to update. Empty fields are
+// left unchanged.
+type MachineParams struct {
+	Zone string
+	Pool string
+
+	// PowerParameters are merged into the power parameters of the machine.
+	// SkipPowerCheck makes MAAS store them without validating them against
+	// the power type.
+	PowerType       string
+	PowerParameters map[string]string
+	SkipPowerCheck  bool
+}
+
+func (p MachineParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "zone", p.Zone)
+	setString(form, "pool", p.Pool)
+	setString(form, "power_type", p.PowerType)
+	setPowerParameters(form, p.PowerParameters)
+	if p.SkipPowerCheck {
+		form.Set("power_parameters_skip_check", "1")
+	}
+	return form
+}
+
+// CreateMachineParams are the fields of a machine to enlist. Empty fields
+// are left to the MAAS defaults.
+type CreateMachineParams struct {
+	Hostname     string
+	Architecture string
+	MACAddresses []string
+	Domain       string
+	Zone         string
+	Pool         string
+	Description  string
+
+	PowerType       string
+	PowerParameters map[string]string
+	// Commission starts commissioning the machine once it is created.
+	Commission *bool
+}
+
+func (p CreateMachineParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "hostname", p.Hostname)
+	setString(form, "architecture", p.Architecture)
+	for _, mac := range p.MACAddresses {
+		form.Add("mac_addresses", mac)
+	}
+	setString(form, "domain", p.Domain)
+	setString(form, "zone", p.Zone)
+	setString(form, "pool", p.Pool)
+	setString(form, "description", p.Description)
+	setString(form, "power_type", p.PowerType)
+	setPowerParameters(form, p.PowerParameters)
+	setBool(form, "commission", p.Commission)
+	return form
+}
+
+// CreateMachine enlists a new machine.
+func (a *API) CreateMachine(ctx context.Context, params CreateMachineParams) (Machine, error) {
+	var machine Machine
+	err := a.post(ctx, basePath+"/machines/", params.form(), &machine)
+	return machine, err
+}
+
+// DeleteMachine removes the machine from MAAS.
+func (a *API) DeleteMachine(ctx context.Context, systemID string) error {
+	return a.delete(ctx, machinePath(systemID))
+}
+
+// UpdateMachine updates the machine with the given system ID.
+func (a *API) UpdateMachine(ctx context.Context, systemID string, params MachineParams) (Machine, error) {
+	var machine Machine
+	err := a.put(ctx, machinePath(systemID), params.form(), &machine)
+	return machine, err
+}
+
+// CommissionParams are the options of CommissionMachine.
+type CommissionParams struct {
+	EnableSSH bool
+}
+
+// CommissionMachine starts commissioning the machine.
+func (a *API) CommissionMachine(ctx context.Context, systemID string, params CommissionParams) (Machine, error) {
+	form := url.Values{}
+	setBool(form, "enable_ssh", &params.EnableSSH)
+
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-commission", form, &machine)
+	return machine, err
+}
+
+// AllocateParams are the constraints of AllocateMachine. Empty fields do not
+// constrain the allocation.
+type AllocateParams struct {
+	// Name and SystemID ask for a particular machine.
+	Name         string
+	SystemID     string
+	Architecture string
+	// CPUCount and Memory, in MiB, are minimums.
+	CPUCount int
+	Memory   int
+	// Tags must all be on the machine, NotTags none of them.
+	Tags    []string
+	NotTags []string
+	Zone    string
+	Pool    string
+	// Storage and Interfaces use the MAAS label syntax, like
+	// "root:100(ssd),data:500" and "eth0:space=storage".
+	Storage    string
+	Interfaces string
+	Comment    string
+}
+
+// AllocateMachine allocates a ready machine matching the constraints to the
+// caller. MAAS answers with a conflict when no machine matches.
+func (a *API) AllocateMachine(ctx context.Context, params AllocateParams) (Machine, error) {
+	form := url.Values{}
+	setString(form, "name", params.Name)
+	setString(form, "system_id", params.SystemID)
+	setString(form, "arch", params.Architecture)
+	if params.CPUCount > 0 {
+		setInt(form, "cpu_count", &params.CPUCount)
+	}
+	if params.Memory > 0 {
+		setInt(form, "mem", &params.Memory)
+	}
+	for _, tag := range params.Tags {
+		form.Add("tags", tag)
+	}
+	for _, tag := range params.NotTags {
+		form.Add("not_tags", tag)
+	}
+	setString(form, "zone", params.Zone)
+	setString(form, "pool", params.Pool)
+	setString(form, "storage", params.Storage)
+	setString(form, "interfaces", params.Interfaces)
+	setString(form, "comment", params.Comment)
+
+	var machine Machine
+	err := a.post(ctx, basePath+"/machines/op-allocate", form, &machine)
+	return machine, err
+}
+
+// DeployParams are the options of DeployMachine. Empty fields are left to
+// the MAAS defaults.
+type DeployParams struct {
+	UserData     string
+	OSystem      string
+	DistroSeries string
+	HWEKernel    string
+
+	InstallKVM      *bool
+	RegisterVMHost  *bool
+	EnableHWSync    *bool
+	EphemeralDeploy *bool
+	InstallRackd    *bool
+}
+
+// DeployMachine starts deploying the machine.
+func (a *API) DeployMachine(ctx context.Context, systemID string, params DeployParams) (Machine, error) {
+	form := url.Values{}
+	setString(form, "user_data", params.UserData)
+	setString(form, "osystem", params.OSystem)
+	setString(form, "distro_series", params.DistroSeries)
+	setString(form, "hwe_kernel", params.HWEKernel)
+	setBool(form, "install_kvm", params.InstallKVM)
+	setBool(form, "register_vmhost", params.RegisterVMHost)
+	setBool(form, "enable_hw_sync", params.EnableHWSync)
+	setBool(form, "ephemeral_deploy", params.EphemeralDeploy)
+	setBool(form, "install_rackd", params.InstallRackd)
+
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-deploy", form, &machine)
+	return machine, err
+}
+
+// ReleaseParams are the options of ReleaseMachine.
+type ReleaseParams struct {
+	Comment     string
+	Erase       bool
+	SecureErase bool
+	QuickErase  bool
+}
+
+// ReleaseMachine releases the machine, optionally erasing its disks.
+func (a *API) ReleaseMachine(ctx context.Context, systemID string, params ReleaseParams) (Machine, error) {
+	form := url.Values{}
+	setString(form, "comment", params.Comment)
+	if params.Erase {
+		form.Set("erase", "1")
+		if params.SecureErase {
+			form.Set("secure_erase", "1")
+		}
+		if params.QuickErase {
+			form.Set("quick_erase", "1")
+		}
+	}
+
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-release", form, &machine)
+	return machine, err
+}
+
+// AbortMachineOperation aborts the current operation of the machine.
+func (a *API) AbortMachineOperation(ctx context.Context, systemID, comment string) (Machine, error) {
+	form := url.Values{}
+	setString(form, "comment", comment)
+
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-abort", form, &machine)
+	return machine, err
+}
+
+// EnterRescueMode boots the machine into rescue mode.
+func (a *API) EnterRescueMode(ctx context.Context, systemID string) (Machine, error) {
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-rescue_mode", nil, &machine)
+	return machine, err
+}
+
+// ExitRescueMode takes the machine out of rescue mode.
+func (a *API) ExitRescueMode(ctx context.Context, systemID string) (Machine, error) {
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-exit_rescue_mode", nil, &machine)
+	return machine, err
+}
+
+// PowerState is the answer of QueryPowerState.
+type PowerState struct {
+	State string `json:"state"`
+}
+
+// QueryPowerState asks the BMC of the machine for its power state.
+func (a *API) QueryPowerState(ctx context.Context, systemID string) (PowerState, error) {
+	var state PowerState
+	err := a.get(ctx, machinePath(systemID)+"op-query_power_state", nil, &state)
+	return state, err
+}
+
+// PowerOn powers the machine on.
+func (a *API) PowerOn(ctx context.Context, systemID string) (Machine, error) {
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-power_on", nil, &machine)
+	return machine, err
+}
+
+// PowerOff powers the machine off.
+func (a *API) PowerOff(ctx context.Context, systemID string) (Machine, error) {
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-power_off", nil, &machine)
+	return machine, err
+}
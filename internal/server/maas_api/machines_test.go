@@ -0,0 +1,144 @@
+package maas_api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+)
+
+// staticClient answers every request with the same body.
+type staticClient string
+
+func (c staticClient) Do(context.Context, maas_client.RequestType, string, io.Reader) (string, error) {
+	return string(c), nil
+}
+
+func TestAPI_ListMachines(t *testing.T) {
+	cases := []struct {
+		name     string
+		filter   MachineFilter
+		expected []string
+	}{
+		{"no filter", MachineFilter{}, []string{"alpha", "beta", "gamma"}},
+		{"status", MachineFilter{Status: "deployed"}, []string{"beta"}},
+		{"hostnames", MachineFilter{Hostnames: []string{"alpha", "gamma"}}, []string{"alpha", "gamma"}},
+		{"tags", MachineFilter{Tags: []string{"gpu", "ssd"}}, []string{"gamma"}},
+		{"zone", MachineFilter{Zone: "edge"}, []string{"alpha"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddMachine(fakemaas.Machine{Hostname: "alpha", Status: fakemaas.StatusReady, Zone: "edge", TagNames: []string{"gpu"}})
+			fake.AddMachine(fakemaas.Machine{Hostname: "beta", Status: fakemaas.StatusDeployed})
+			fake.AddMachine(fakemaas.Machine{Hostname: "gamma", Status: fakemaas.StatusReady, TagNames: []string{"gpu", "ssd"}})
+
+			// Act
+			machines, err := New(fake.Client()).ListMachines(context.Background(), tc.filter)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			hostnames := []string{}
+			for _, machine := range machines {
+				hostnames = append(hostnames, machine.Hostname)
+			}
+			slices.Sort(hostnames)
+			if !slices.Equal(hostnames, tc.expected) {
+				t.Errorf("expected machines %v, got %v", tc.expected, hostnames)
+			}
+		})
+	}
+}
+
+func TestAPI_GetMachine(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{Hostname: "alpha", CPUCount: 8, TagNames: []string{ProtectedTag}})
+
+	// Act
+	machine, err := New(fake.Client()).GetMachine(context.Background(), m.SystemID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if machine.SystemID != m.SystemID || machine.Hostname != "alpha" || machine.CPUCount != 8 {
+		t.Errorf("unexpected machine %+v", machine)
+	}
+	if !machine.Protected() {
+		t.Errorf("expected the machine to be protected")
+	}
+}
+
+func TestAPI_GetMachine_NotFound(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+
+	// Act
+	_, err := New(fake.Client()).GetMachine(context.Background(), "missing")
+
+	// Assert
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if HasStatus(err, http.StatusConflict) {
+		t.Errorf("expected the status to be 404, got %v", err)
+	}
+}
+
+func TestAPI_DecodeError(t *testing.T) {
+	// Arrange
+	api := New(staticClient(`{"system_id": "abc123", "cpu_count": "eight"}`))
+
+	// Act
+	_, err := api.GetMachine(context.Background(), "abc123")
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "failed to decode the response of GET /MAAS/api/2.0/machines/abc123/") {
+		t.Errorf("expected a decode error, got %v", err)
+	}
+}
+
+func TestAPI_ReleaseMachine(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   ReleaseParams
+		expected map[string]string
+	}{
+		{"plain", ReleaseParams{}, map[string]string{}},
+		{"comment", ReleaseParams{Comment: "done"}, map[string]string{"comment": "done"}},
+		{"erase", ReleaseParams{Erase: true, QuickErase: true}, map[string]string{"erase": "1", "quick_erase": "1"}},
+		{"erase options need erase", ReleaseParams{SecureErase: true}, map[string]string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusDeployed})
+
+			// Act
+			_, err := New(fake.Client()).ReleaseMachine(context.Background(), m.SystemID, tc.params)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			request, _ := fake.LastRequest(http.MethodPost)
+			for _, key := range []string{"comment", "erase", "secure_erase", "quick_erase"} {
+				if got := request.Form.Get(key); got != tc.expected[key] {
+					t.Errorf("expected %s=%q, got %q", key, tc.expected[key], got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package maas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Fabric is a MAAS fabric with its VLANs.
+type Fabric struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ClassType   string `json:"class_type"`
+	VLANs       []VLAN `json:"vlans"`
+	ResourceURI string `json:"resource_uri"`
+}
+
+// VLAN is a VLAN of a fabric.
+type VLAN struct {
+	ID            int    `json:"id"`
+	VID           int    `json:"vid"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Fabric        string `json:"fabric"`
+	FabricID      int    `json:"fabric_id"`
+	MTU           int    `json:"mtu"`
+	Space         string `json:"space"`
+	DHCPOn        bool   `json:"dhcp_on"`
+	PrimaryRack   string `json:"primary_rack"`
+	SecondaryRack string `json:"secondary_rack"`
+	ExternalDHCP  string `json:"external_dhcp"`
+	// RelayVLAN is kept undecoded: depending on the MAAS version it is the ID
+	// of the relay VLAN or the VLAN itself.
+	RelayVLAN   json.RawMessage `json:"relay_vlan"`
+	ResourceURI string          `json:"resource_uri"`
+}
+
+// Subnet is a MAAS subnet.
+type Subnet struct {
+	ID                        int      `json:"id"`
+	Name                      string   `json:"name"`
+	CIDR                      string   `json:"cidr"`
+	Description               string   `json:"description"`
+	VLAN                      *VLAN    `json:"vlan"`
+	Space                     string   `json:"space"`
+	GatewayIP                 string   `json:"gateway_ip"`
+	DNSServers                []string `json:"dns_servers"`
+	Managed                   bool     `json:"managed"`
+	AllowDNS                  bool     `json:"allow_dns"`
+	AllowProxy                bool     `json:"allow_proxy"`
+	RDNSMode                  int      `json:"rdns_mode"`
+	ActiveDiscovery           bool     `json:"active_discovery"`
+	DisabledBootArchitectures []string `json:"disabled_boot_architectures"`
+	ResourceURI               string   `json:"resource_uri"`
+}
+
+// Space is a MAAS space with the VLANs and subnets placed in it.
+type Space struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	VLANs       []VLAN   `json:"vlans"`
+	Subnets     []Subnet `json:"subnets"`
+	ResourceURI string   `json:"resource_uri"`
+}
+
+// IPRange is an inclusive range of addresses of a subnet.
+type IPRange struct {
+	Start        string      `json:"start"`
+	End          string      `json:"end"`
+	NumAddresses json.Number `json:"num_addresses"` // overflows int64 in IPv6 subnets
+	Purpose      []string    `json:"purpose,omitempty"`
+}
+
+// SubnetIPAddress is an address in use in a subnet.
+type SubnetIPAddress struct {
+	IP            string       `json:"ip"`
+	AllocType     int          `json:"alloc_type"`
+	AllocTypeName string       `json:"alloc_type_name"`
+	Created       string       `json:"created"`
+	Updated       string       `json:"updated"`
+	User          string       `json:"user,omitempty"`
+	NodeSummary   *NodeSummary `json:"node_summary,omitempty"`
+}
+
+// NodeSummary names the node an address is assigned to.
+type NodeSummary struct {
+	SystemID string `json:"system_id"`
+	Hostname string `json:"hostname"`
+	NodeType int    `json:"node_type"`
+	Via      string `json:"via"`
+}
+
+// SubnetStatistics describes the usage of the addresses of a subnet.
+type SubnetStatistics struct {
+	// The address counts overflow int64 in IPv6 subnets, so they are kept as
+	// they were encoded.
+	NumAvailable          json.Number `json:"num_available"`
+	LargestAvailable      json.Number `json:"largest_available"`
+	NumUnavailable        json.Number `json:"num_unavailable"`
+	TotalAddresses        json.Number `json:"total_addresses"`
+	Usage                 float64     `json:"usage"`
+	UsageString           string      `json:"usage_string"`
+	AvailableString       string      `json:"available_string"`
+	FirstAddress          string      `json:"first_address"`
+	LastAddress           string      `json:"last_address"`
+	IPVersion             int         `json:"ip_version"`
+	Ranges                []IPRange   `json:"ranges,omitempty"`
+	SuggestedGateway      string      `json:"suggested_gateway,omitempty"`
+	SuggestedDynamicRange *IPRange    `json:"suggested_dynamic_range,omitempty"`
+}
+
+// FabricParams are the fields of a fabric to create or update. Empty fields are left unchanged.
+type FabricParams struct {
+	Name        string
+	Description string
+	ClassType   string
+}
+
+func (p FabricParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "description", p.Description)
+	setString(form, "class_type", p.ClassType)
+	return form
+}
+
+// SpaceParams are the fields of a space to create or update. Empty fields are left unchanged.
+type SpaceParams struct {
+	Name        string
+	Description string
+}
+
+func (p SpaceParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "description", p.Description)
+	return form
+}
+
+// VLANParams are the fields of a VLAN to create or update. Empty and nil
+// fields are left unchanged.
+type VLANParams struct {
+	Name          string
+	Description   string
+	MTU           *int
+	Space         string
+	DHCPOn        *bool
+	PrimaryRack   string
+	SecondaryRack string
+	RelayVLAN     *int
+}
+
+func (p VLANParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "description", p.Description)
+	setInt(form, "mtu", p.MTU)
+	setString(form, "space", p.Space)
+	setBool(form, "dhcp_on", p.DHCPOn)
+	setString(form, "primary_rack", p.PrimaryRack)
+	setString(form, "secondary_rack", p.SecondaryRack)
+	setInt(form, "relay_vlan", p.RelayVLAN)
+	return form
+}
+
+// SubnetParams are the fields of a subnet to create or update. Empty and nil
+// fields are left unchanged.
+type SubnetParams struct {
+	CIDR        string
+	Name        string
+	Description string
+	// VLAN is the ID of the VLAN of the subnet. Fabric and VID select the VLAN
+	// instead when it is not given.
+	VLAN      string
+	Fabric    string
+	VID       string
+	Space     string
+	GatewayIP string
+	// DNSServers and DisabledBootArchitectures are comma separated lists.
+	DNSServers                string
+	DisabledBootArchitectures string
+	RDNSMode                  *int
+	Managed                   *bool
+	AllowDNS                  *bool
+	AllowProxy                *bool
+}
+
+func (p SubnetParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "cidr", p.CIDR)
+	setString(form, "name", p.Name)
+	setString(form, "description", p.Description)
+	setString(form, "vlan", p.VLAN)
+	setString(form, "fabric", p.Fabric)
+	setString(form, "vid", p.VID)
+	setString(form, "space", p.Space)
+	setString(form, "gateway_ip", p.GatewayIP)
+	setString(form, "dns_servers", p.DNSServers)
+	setString(form, "disabled_boot_architectures", p.DisabledBootArchitectures)
+	setInt(form, "rdns_mode", p.RDNSMode)
+	setBool(form, "managed", p.Managed)
+	setBool(form, "allow_dns", p.AllowDNS)
+	setBool(form, "allow_proxy", p.AllowProxy)
+	return form
+}
+
+func fabricPath(id int) string {
+	return fmt.Sprintf("%s/fabrics/%d/", basePath, id)
+}
+
+func vlanPath(fabricID, vid int) string {
+	return fmt.Sprintf("%s/fabrics/%d/vlans/%d/", basePath, fabricID, vid)
+}
+
+func subnetPath(id int) string {
+	return fmt.Sprintf("%s/subnets/%d/", basePath, id)
+}
+
+func spacePath(id int) string {
+	return fmt.Sprintf("%s/spaces/%d/", basePath, id)
+}
+
+// ListFabrics returns all the fabrics.
+func (a *API) ListFabrics(ctx context.Context) ([]Fabric, error) {
+	var fabrics []Fabric
+	if err := a.get(ctx, basePath+"/fabrics/", nil, &fabrics); err != nil {
+		return nil, err
+	}
+	return fabrics, nil
+}
+
+// GetFabric returns the fabric with the given ID.
+func (a *API) GetFabric(ctx context.Context, id int) (Fabric, error) {
+	var fabric Fabric
+	err := a.get(ctx, fabricPath(id), nil, &fabric)
+	return fabric, err
+}
+
+// CreateFabric creates a fabric.
+func (a *API) CreateFabric(ctx context.Context, params FabricParams) (Fabric, error) {
+	var fabric Fabric
+	err := a.post(ctx, basePath+"/fabrics/", params.form(), &fabric)
+	return fabric, err
+}
+
+// UpdateFabric updates the fabric with the given ID.
+func (a *API) UpdateFabric(ctx context.Context, id int, params FabricParams) (Fabric, error) {
+	var fabric Fabric
+	err := a.put(ctx, fabricPath(id), params.form(), &fabric)
+	return fabric, err
+}
+
+// DeleteFabric deletes the fabric with the given ID.
+func (a *API) DeleteFabric(ctx context.Context, id int) error {
+	return a.delete(ctx, fabricPath(id))
+}
+
+// ListVLANs returns the VLANs of a fabric.
+func (a *API) ListVLANs(ctx context.Context, fabricID int) ([]VLAN, error) {
+	var vlans []VLAN
+	if err := a.get(ctx, fabricPath(fabricID)+"vlans/", nil, &vlans); err != nil {
+		return nil, err
+	}
+	return vlans, nil
+}
+
+// GetVLAN returns the VLAN of a fabric with the given VID.
+func (a *API) GetVLAN(ctx context.Context, fabricID, vid int) (VLAN, error) {
+	var vlan VLAN
+	err := a.get(ctx, vlanPath(fabricID, vid), nil, &vlan)
+	return vlan, err
+}
+
+// CreateVLAN creates a VLAN with the given VID on a fabric.
+func (a *API) CreateVLAN(ctx context.Context, fabricID, vid int, params VLANParams) (VLAN, error) {
+	form := params.form()
+	form.Set("vid", strconv.Itoa(vid))
+
+	var vlan VLAN
+	err := a.post(ctx, fabricPath(fabricID)+"vlans/", form, &vlan)
+	return vlan, err
+}
+
+// UpdateVLAN updates the VLAN of a fabric with the given VID.
+func (a *API) UpdateVLAN(ctx context.Context, fabricID, vid int, params VLANParams) (VLAN, error) {
+	var vlan VLAN
+	err := a.put(ctx, vlanPath(fabricID, vid), params.form(), &vlan)
+	return vlan, err
+}
+
+// DeleteVLAN deletes the VLAN of a fabric with the given VID.
+func (a *API) DeleteVLAN(ctx context.Context, fabricID, vid int) error {
+	return a.delete(ctx, vlanPath(fabricID, vid))
+}
+
+// ListSpaces returns all the spaces.
+func (a *API) ListSpaces(ctx context.Context) ([]Space, error) {
+	var spaces []Space
+	if err := a.get(ctx, basePath+"/spaces/", nil, &spaces); err != nil {
+		return nil, err
+	}
+	return spaces, nil
+}
+
+// GetSpace returns the space with the given ID.
+func (a *API) GetSpace(ctx context.Context, id int) (Space, error) {
+	var space Space
+	err := a.get(ctx, spacePath(id), nil, &space)
+	return space, err
+}
+
+// CreateSpace creates a space. The name of params is required.
+func (a *API) CreateSpace(ctx context.Context, params SpaceParams) (Space, error) {
+	var space Space
+	err := a.post(ctx, basePath+"/spaces/", params.form(), &space)
+	return space, err
+}
+
+// UpdateSpace updates the space with the given ID.
+func (a *API) UpdateSpace(ctx context.Context, id int, params SpaceParams) (Space, error) {
+	var space Space
+	err := a.put(ctx, spacePath(id), params.form(), &space)
+	return space, err
+}
+
+// DeleteSpace deletes the space with the given ID. Its VLANs and subnets are
+// moved to the undefined space.
+func (a *API) DeleteSpace(ctx context.Context, id int) error {
+	return a.delete(ctx, spacePath(id))
+}
+
+// ListSubnets returns all the subnets.
+func (a *API) ListSubnets(ctx context.Context) ([]Subnet, error) {
+	var subnets []Subnet
+	if err := a.get(ctx, basePath+"/subnets/", nil, &subnets); err != nil {
+		return nil, err
+	}
+	return subnets, nil
+}
+
+// GetSubnet returns the subnet with the given ID.
+func (a *API) GetSubnet(ctx context.Context, id int) (Subnet, error) {
+	var subnet Subnet
+	err := a.get(ctx, subnetPath(id), nil, &subnet)
+	return subnet, err
+}
+
+// CreateSubnet creates a subnet. The CIDR of params is required.
+func (a *API) CreateSubnet(ctx context.Context, params SubnetParams) (Subnet, error) {
+	var subnet Subnet
+	err := a.post(ctx, basePath+"/subnets/", params.form(), &subnet)
+	return subnet, err
+}
+
+// UpdateSubnet updates the subnet with the given ID.
+func (a *API) UpdateSubnet(ctx context.Context, id int, params SubnetParams) (Subnet, error) {
+	var subnet Subnet
+	err := a.put(ctx, subnetPath(id), params.form(), &subnet)
+	return subnet, err
+}
+
+// DeleteSubnet deletes the subnet with the given ID.
+func (a *API) DeleteSubnet(ctx context.Context, id int) error {
+	return a.delete(ctx, subnetPath(id))
+}
+
+// GetSubnetIPAddresses returns the addresses in use in a subnet.
+func (a *API) GetSubnetIPAddresses(ctx context.Context, id int, withUsername, withSummary bool) ([]SubnetIPAddress, error) {
+	query := url.Values{}
+	setBool(query, "with_username", &withUsername)
+	setBool(query, "with_summary", &withSummary)
+
+	var addresses []SubnetIPAddress
+	if err := a.get(ctx, subnetPath(id)+"op-ip_addresses", query, &addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// GetSubnetReservedIPRanges returns the ranges of a subnet that are reserved or in use.
+func (a *API) GetSubnetReservedIPRanges(ctx context.Context, id int) ([]IPRange, error) {
+	var ranges []IPRange
+	if err := a.get(ctx, subnetPath(id)+"op-reserved_ip_ranges", nil, &ranges); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// GetSubnetUnreservedIPRanges returns the ranges of a subnet that are free.
+func (a *API) GetSubnetUnreservedIPRanges(ctx context.Context, id int) ([]IPRange, error) {
+	var ranges []IPRange
+	if err := a.get(ctx, subnetPath(id)+"op-unreserved_ip_ranges", nil, &ranges); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// GetSubnetStatistics returns the usage statistics of a subnet.
+func (a *API) GetSubnetStatistics(ctx context.Context, id int, includeRanges, includeSuggestions bool) (SubnetStatistics, error) {
+	query := url.Values{}
+	setBool(query, "include_ranges", &includeRanges)
+	setBool(query, "include_suggestions", &includeSuggestions)
+
+	var statistics SubnetStatistics
+	err := a.get(ctx, subnetPath(id)+"op-statistics", query, &statistics)
+	return statistics, err
+}
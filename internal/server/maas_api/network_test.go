@@ -0,0 +1,108 @@
+package maas_api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestAPI_UpdateVLAN(t *testing.T) {
+	mtu := 9000
+	dhcpOff := false
+
+	cases := []struct {
+		name     string
+		params   VLANParams
+		expected map[string]string
+	}{
+		{"name only", VLANParams{Name: "storage"}, map[string]string{"name": "storage"}},
+		{"mtu", VLANParams{MTU: &mtu}, map[string]string{"mtu": "9000"}},
+		{"dhcp off", VLANParams{DHCPOn: &dhcpOff}, map[string]string{"dhcp_on": "0"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fabric := fake.AddFabric(fakemaas.Fabric{})
+			fake.AddVLAN(fakemaas.VLAN{FabricID: fabric.ID, VID: 10, MTU: 1500})
+
+			// Act
+			vlan, err := New(fake.Client()).UpdateVLAN(context.Background(), fabric.ID, 10, tc.params)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if vlan.VID != 10 || vlan.FabricID != fabric.ID {
+				t.Errorf("unexpected VLAN %+v", vlan)
+			}
+			request, _ := fake.LastRequest(http.MethodPut)
+			if len(request.Form) != len(tc.expected) {
+				t.Errorf("expected form %v, got %v", tc.expected, request.Form)
+			}
+			for key, value := range tc.expected {
+				if got := request.Form.Get(key); got != value {
+					t.Errorf("expected %s=%q, got %q", key, value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAPI_GetSubnetStatistics(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	subnet := fake.AddSubnet(fakemaas.Subnet{
+		CIDR:     "10.0.0.0/24",
+		IPRanges: []fakemaas.IPRange{{Type: "dynamic", StartIP: "10.0.0.100", EndIP: "10.0.0.199"}},
+	})
+
+	// Act
+	statistics, err := New(fake.Client()).GetSubnetStatistics(context.Background(), subnet.ID, true, false)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if statistics.TotalAddresses != "254" || statistics.NumUnavailable != "100" {
+		t.Errorf("unexpected statistics %+v", statistics)
+	}
+	if len(statistics.Ranges) == 0 {
+		t.Errorf("expected the ranges to be included")
+	}
+}
+
+func TestAPI_GetSubnetStatistics_IPv6(t *testing.T) {
+	// Arrange
+	api := New(staticClient(`{"num_available": 18446744073709551614, "total_addresses": 18446744073709551614, "ip_version": 6}`))
+
+	// Act
+	statistics, err := api.GetSubnetStatistics(context.Background(), 1, false, false)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if statistics.TotalAddresses != "18446744073709551614" {
+		t.Errorf("expected the total to be kept, got %s", statistics.TotalAddresses)
+	}
+}
+
+func TestAPI_ListTaggedNodes_UnknownType(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+
+	// Act
+	_, err := New(fake.Client()).ListTaggedNodes(context.Background(), "gpu", "switches")
+
+	// Assert
+	if err == nil {
+		t.Errorf("expected an error for an unknown node type")
+	}
+	if _, ok := fake.LastRequest(http.MethodGet); ok {
+		t.Errorf("expected no request to be sent")
+	}
+}
@@ -0,0 +1,75 @@
+package maas_api
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces the value of a secret in output and logs.
+const Redacted = "********"
+
+// powerParametersPrefix prefixes the power parameters in the forms MAAS takes.
+const powerParametersPrefix = "power_parameters_"
+
+// secretPowerParameterParts are the parts of the names of the power
+// parameters holding BMC credentials. They match the parameters of every
+// power type MAAS knows, like power_token_secret of proxmox or power_token of
+// webhook, not only those of the power types the tools enlist with.
+var secretPowerParameterParts = []string{"pass", "secret", "token", "key"}
+
+// IsSecretPowerParameter reports whether the power parameter name holds a
+// credential that must not be shown.
+func IsSecretPowerParameter(name string) bool {
+	name = strings.ToLower(name)
+	return name == "k_g" || slices.ContainsFunc(secretPowerParameterParts, func(part string) bool {
+		return strings.Contains(name, part)
+	})
+}
+
+// RedactPowerParameters returns a copy of params with the values of the
+// secrets replaced by Redacted. Empty secrets are kept, so that a missing
+// password still shows.
+func RedactPowerParameters(params map[string]any) map[string]any {
+	redacted := make(map[string]any, len(params))
+	for name, value := range params {
+		if IsSecretPowerParameter(name) && value != nil && value != "" {
+			value = Redacted
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// RedactForm returns a copy of form with the values of the secret power
+// parameters replaced by Redacted.
+func RedactForm(form url.Values) url.Values {
+	redacted := make(url.Values, len(form))
+	for key, values := range form {
+		name, found := strings.CutPrefix(key, powerParametersPrefix)
+		if found && IsSecretPowerParameter(name) {
+			values = slices.Repeat([]string{Redacted}, len(values))
+		}
+		redacted[key] = slices.Clone(values)
+	}
+	return redacted
+}
+
+// setPowerParameters adds the power parameters to form.
+func setPowerParameters(form url.Values, params map[string]string) {
+	for name, value := range params {
+		form.Set(powerParametersPrefix+name, value)
+	}
+}
+
+// GetPowerParameters returns the power parameters of the machine, including
+// its BMC credentials. Redact them with RedactPowerParameters before showing
+// them.
+func (a *API) GetPowerParameters(ctx context.Context, systemID string) (map[string]any, error) {
+	var params map[string]any
+	if err := a.get(ctx, machinePath(systemID)+"op-power_parameters", nil, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,167 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Script is a commissioning, testing or release script stored in MAAS.
+type Script struct {
+	ID                        int              `json:"id"`
+	Name                      string           `json:"name"`
+	Title                     string           `json:"title"`
+	Description               string           `json:"description"`
+	Tags                      []string         `json:"tags"`
+	ScriptType                int              `json:"script_type"`
+	ScriptTypeName            string           `json:"script_type_name"`
+	HardwareType              int              `json:"hardware_type"`
+	HardwareTypeName          string           `json:"hardware_type_name"`
+	Parallel                  int              `json:"parallel"`
+	Timeout                   string           `json:"timeout"` // as H:MM:SS
+	Destructive               bool             `json:"destructive"`
+	MayReboot                 bool             `json:"may_reboot"`
+	Recommission              bool             `json:"recommission"`
+	ApplyConfiguredNetworking bool             `json:"apply_configured_networking,omitempty"`
+	ForHardware               []string         `json:"for_hardware"`
+	Default                   bool             `json:"default"`
+	History                   []ScriptRevision `json:"history"`
+	ResourceURI               string           `json:"resource_uri"`
+}
+
+// ScriptRevision is a revision of a script, newest first in Script.History.
+type ScriptRevision struct {
+	ID      int    `json:"id"`
+	Comment string `json:"comment"`
+	Created string `json:"created,omitempty"`
+	// Data is the base64 encoded script, only set when it was requested.
+	Data string `json:"data,omitempty"`
+}
+
+// ScriptFilter selects scripts. Empty fields do not filter.
+type ScriptFilter struct {
+	// Type is "commissioning", "testing" or "release".
+	Type         string
+	HardwareType string
+	// Filters is a comma separated list of script names and tags.
+	Filters       string
+	IncludeScript bool
+}
+
+// ScriptParams are the fields of a script to create or update. Empty and nil
+// fields are left unchanged.
+type ScriptParams struct {
+	Name         string
+	Script       string
+	Type         string
+	HardwareType string
+	Title        string
+	Description  string
+	// Tags and ForHardware are comma separated lists.
+	Tags        string
+	ForHardware string
+	Comment     string
+	// Timeout is in seconds.
+	Timeout                   *int
+	Parallel                  *int
+	Destructive               *bool
+	MayReboot                 *bool
+	Recommission              *bool
+	ApplyConfiguredNetworking *bool
+}
+
+func (p ScriptParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "script", p.Script)
+	setString(form, "type", p.Type)
+	setString(form, "hardware_type", p.HardwareType)
+	setString(form, "title", p.Title)
+	setString(form, "description", p.Description)
+	setString(form, "tags", p.Tags)
+	setString(form, "for_hardware", p.ForHardware)
+	setString(form, "comment", p.Comment)
+	setInt(form, "timeout", p.Timeout)
+	setInt(form, "parallel", p.Parallel)
+	setBool(form, "destructive", p.Destructive)
+	setBool(form, "may_reboot", p.MayReboot)
+	setBool(form, "recommission", p.Recommission)
+	setBool(form, "apply_configured_networking", p.ApplyConfiguredNetworking)
+	return form
+}
+
+func scriptPath(name string) string {
+	return fmt.Sprintf("%s/scripts/%s", basePath, url.PathEscape(name))
+}
+
+// ListScripts returns the scripts matching filter.
+func (a *API) ListScripts(ctx context.Context, filter ScriptFilter) ([]Script, error) {
+	query := url.Values{}
+	setString(query, "type", filter.Type)
+	setString(query, "hardware_type", filter.HardwareType)
+	setString(query, "filters", filter.Filters)
+	if filter.IncludeScript {
+		query.Set("include_script", "1")
+	}
+
+	var scripts []Script
+	if err := a.get(ctx, basePath+"/scripts/", query, &scripts); err != nil {
+		return nil, err
+	}
+	return scripts, nil
+}
+
+// GetScript returns the script with the given name.
+func (a *API) GetScript(ctx context.Context, name string, includeScript bool) (Script, error) {
+	query := url.Values{}
+	if includeScript {
+		query.Set("include_script", "1")
+	}
+
+	var script Script
+	err := a.get(ctx, scriptPath(name), query, &script)
+	return script, err
+}
+
+// CreateScript creates a script. The name and the script of params are required.
+func (a *API) CreateScript(ctx context.Context, params ScriptParams) (Script, error) {
+	var script Script
+	err := a.post(ctx, basePath+"/scripts/", params.form(), &script)
+	return script, err
+}
+
+// UpdateScript updates the script with the given name.
+func (a *API) UpdateScript(ctx context.Context, name string, params ScriptParams) (Script, error) {
+	var script Script
+	err := a.put(ctx, scriptPath(name), params.form(), &script)
+	return script, err
+}
+
+// DeleteScript deletes the script with the given name.
+func (a *API) DeleteScript(ctx context.Context, name string) error {
+	return a.delete(ctx, scriptPath(name))
+}
+
+// DownloadScript returns the content of a script. A zero revision selects the latest one.
+func (a *API) DownloadScript(ctx context.Context, name string, revision int) (string, error) {
+	query := url.Values{}
+	if revision > 0 {
+		query.Set("revision", strconv.Itoa(revision))
+	}
+	return a.getRaw(ctx, scriptPath(name)+"/op-download", query)
+}
+
+// AddScriptTag adds a tag to the script with the given name.
+func (a *API) AddScriptTag(ctx context.Context, name, tag string) (Script, error) {
+	var script Script
+	err := a.post(ctx, scriptPath(name)+"/op-add_tag", url.Values{"tag": {tag}}, &script)
+	return script, err
+}
+
+// RemoveScriptTag removes a tag from the script with the given name.
+func (a *API) RemoveScriptTag(ctx context.Context, name, tag string) (Script, error) {
+	var script Script
+	err := a.post(ctx, scriptPath(name)+"/op-remove_tag", url.Values{"tag": {tag}}, &script)
+	return script, err
+}
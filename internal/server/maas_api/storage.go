@@ -0,0 +1,314 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Storage layouts accepted by SetStorageLayout.
+const (
+	StorageLayoutFlat   = "flat"
+	StorageLayoutLVM    = "lvm"
+	StorageLayoutBcache = "bcache"
+	StorageLayoutBlank  = "blank"
+	StorageLayoutCustom = "custom"
+)
+
+// RAID is a software RAID set of a machine. Devices holds block devices and
+// partitions; partitions have the type "partition".
+type RAID struct {
+	ID            int           `json:"id"`
+	Name          string        `json:"name"`
+	Level         string        `json:"level"`
+	UUID          string        `json:"uuid,omitempty"`
+	Size          int64         `json:"size"` // in bytes
+	Devices       []BlockDevice `json:"devices"`
+	SpareDevices  []BlockDevice `json:"spare_devices"`
+	VirtualDevice *BlockDevice  `json:"virtual_device,omitempty"`
+}
+
+// VolumeGroup is an LVM volume group of a machine. Devices holds block
+// devices and partitions; partitions have the type "partition".
+type VolumeGroup struct {
+	ID             int           `json:"id"`
+	Name           string        `json:"name"`
+	UUID           string        `json:"uuid,omitempty"`
+	Size           int64         `json:"size"`           // in bytes
+	AvailableSize  int64         `json:"available_size"` // in bytes
+	Devices        []BlockDevice `json:"devices"`
+	LogicalVolumes []BlockDevice `json:"logical_volumes"`
+}
+
+// Bcache is a block device cached by a cache set.
+type Bcache struct {
+	ID            int          `json:"id"`
+	Name          string       `json:"name"`
+	UUID          string       `json:"uuid,omitempty"`
+	CacheMode     string       `json:"cache_mode"`
+	Size          int64        `json:"size"` // in bytes
+	BackingDevice *BlockDevice `json:"backing_device,omitempty"`
+	CacheSet      *CacheSet    `json:"cache_set,omitempty"`
+	VirtualDevice *BlockDevice `json:"virtual_device,omitempty"`
+}
+
+// CacheSet is the cache device of a bcache.
+type CacheSet struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	CacheDevice *BlockDevice `json:"cache_device,omitempty"`
+}
+
+// StorageLayoutParams are the fields of a storage layout. Sizes are in
+// bytes; nil and empty fields are left to MAAS.
+type StorageLayoutParams struct {
+	Layout      string
+	BootSize    *int64
+	RootSize    *int64
+	RootDevice  *int
+	VGName      string
+	LVName      string
+	LVSize      *int64
+	CacheDevice *int
+	CacheMode   string
+	CacheSize   *int64
+}
+
+func (p StorageLayoutParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "storage_layout", p.Layout)
+	setInt64(form, "boot_size", p.BootSize)
+	setInt64(form, "root_size", p.RootSize)
+	setInt(form, "root_device", p.RootDevice)
+	setString(form, "vg_name", p.VGName)
+	setString(form, "lv_name", p.LVName)
+	setInt64(form, "lv_size", p.LVSize)
+	setInt(form, "cache_device", p.CacheDevice)
+	setString(form, "cache_mode", p.CacheMode)
+	setInt64(form, "cache_size", p.CacheSize)
+	return form
+}
+
+// FormatParams are the fields of a filesystem to create.
+type FormatParams struct {
+	FSType string
+	Label  string
+}
+
+func (p FormatParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "fstype", p.FSType)
+	setString(form, "label", p.Label)
+	return form
+}
+
+// MountParams are the fields of a mount.
+type MountParams struct {
+	MountPoint   string
+	MountOptions string
+}
+
+func (p MountParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "mount_point", p.MountPoint)
+	setString(form, "mount_options", p.MountOptions)
+	return form
+}
+
+// RAIDParams are the fields of a RAID set to create. The members are given
+// by the IDs of block devices and partitions.
+type RAIDParams struct {
+	Name            string
+	Level           string
+	BlockDevices    []int
+	Partitions      []int
+	SpareDevices    []int
+	SparePartitions []int
+}
+
+func (p RAIDParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "level", p.Level)
+	addInts(form, "block_devices", p.BlockDevices)
+	addInts(form, "partitions", p.Partitions)
+	addInts(form, "spare_devices", p.SpareDevices)
+	addInts(form, "spare_partitions", p.SparePartitions)
+	return form
+}
+
+// VolumeGroupParams are the fields of a volume group to create. The physical
+// volumes are given by the IDs of block devices and partitions.
+type VolumeGroupParams struct {
+	Name         string
+	BlockDevices []int
+	Partitions   []int
+}
+
+func (p VolumeGroupParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	addInts(form, "block_devices", p.BlockDevices)
+	addInts(form, "partitions", p.Partitions)
+	return form
+}
+
+func addInts(form url.Values, key string, values []int) {
+	for _, value := range values {
+		form.Add(key, strconv.Itoa(value))
+	}
+}
+
+func nodePath(systemID string) string {
+	return fmt.Sprintf("%s/nodes/%s/", basePath, url.PathEscape(systemID))
+}
+
+func blockDevicePath(systemID string, id int) string {
+	return fmt.Sprintf("%sblockdevices/%d/", nodePath(systemID), id)
+}
+
+func partitionPath(systemID string, deviceID, id int) string {
+	return fmt.Sprintf("%spartition/%d/", blockDevicePath(systemID, deviceID), id)
+}
+
+func volumeGroupPath(systemID string, id int) string {
+	return fmt.Sprintf("%svolume-group/%d/", nodePath(systemID), id)
+}
+
+// SetStorageLayout replaces the storage configuration of a machine with a
+// layout built on its boot disk. The other disks are left blank.
+func (a *API) SetStorageLayout(ctx context.Context, systemID string, params StorageLayoutParams) (Machine, error) {
+	var machine Machine
+	err := a.post(ctx, machinePath(systemID)+"op-set_storage_layout", params.form(), &machine)
+	return machine, err
+}
+
+// ListBlockDevices returns the physical and virtual block devices of a
+// machine with their partitions.
+func (a *API) ListBlockDevices(ctx context.Context, systemID string) ([]BlockDevice, error) {
+	var devices []BlockDevice
+	if err := a.get(ctx, nodePath(systemID)+"blockdevices/", nil, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// FormatBlockDevice creates a filesystem on a whole block device.
+func (a *API) FormatBlockDevice(ctx context.Context, systemID string, id int, params FormatParams) (BlockDevice, error) {
+	var device BlockDevice
+	err := a.post(ctx, blockDevicePath(systemID, id)+"op-format", params.form(), &device)
+	return device, err
+}
+
+// MountBlockDevice mounts the filesystem of a block device.
+func (a *API) MountBlockDevice(ctx context.Context, systemID string, id int, params MountParams) (BlockDevice, error) {
+	var device BlockDevice
+	err := a.post(ctx, blockDevicePath(systemID, id)+"op-mount", params.form(), &device)
+	return device, err
+}
+
+// SetBootDisk makes a physical block device the boot disk of a machine.
+func (a *API) SetBootDisk(ctx context.Context, systemID string, id int) error {
+	return a.post(ctx, blockDevicePath(systemID, id)+"op-set_boot_disk", nil, nil)
+}
+
+// CreatePartition creates a partition on a block device. It takes the
+// remaining space of the device when size is nil.
+func (a *API) CreatePartition(ctx context.Context, systemID string, deviceID int, size *int64) (Partition, error) {
+	form := url.Values{}
+	setInt64(form, "size", size)
+
+	var partition Partition
+	err := a.post(ctx, blockDevicePath(systemID, deviceID)+"partitions/", form, &partition)
+	return partition, err
+}
+
+// DeletePartition deletes a partition of a block device.
+func (a *API) DeletePartition(ctx context.Context, systemID string, deviceID, id int) error {
+	return a.delete(ctx, partitionPath(systemID, deviceID, id))
+}
+
+// FormatPartition creates a filesystem on a partition.
+func (a *API) FormatPartition(ctx context.Context, systemID string, deviceID, id int, params FormatParams) (Partition, error) {
+	var partition Partition
+	err := a.post(ctx, partitionPath(systemID, deviceID, id)+"op-format", params.form(), &partition)
+	return partition, err
+}
+
+// MountPartition mounts the filesystem of a partition.
+func (a *API) MountPartition(ctx context.Context, systemID string, deviceID, id int, params MountParams) (Partition, error) {
+	var partition Partition
+	err := a.post(ctx, partitionPath(systemID, deviceID, id)+"op-mount", params.form(), &partition)
+	return partition, err
+}
+
+// ListRAIDs returns the RAID sets of a machine.
+func (a *API) ListRAIDs(ctx context.Context, systemID string) ([]RAID, error) {
+	var raids []RAID
+	if err := a.get(ctx, nodePath(systemID)+"raids/", nil, &raids); err != nil {
+		return nil, err
+	}
+	return raids, nil
+}
+
+// CreateRAID creates a RAID set on a machine.
+func (a *API) CreateRAID(ctx context.Context, systemID string, params RAIDParams) (RAID, error) {
+	var raid RAID
+	err := a.post(ctx, nodePath(systemID)+"raids/", params.form(), &raid)
+	return raid, err
+}
+
+// DeleteRAID deletes a RAID set of a machine. Its members are freed.
+func (a *API) DeleteRAID(ctx context.Context, systemID string, id int) error {
+	return a.delete(ctx, fmt.Sprintf("%sraid/%d/", nodePath(systemID), id))
+}
+
+// ListVolumeGroups returns the LVM volume groups of a machine with their
+// logical volumes.
+func (a *API) ListVolumeGroups(ctx context.Context, systemID string) ([]VolumeGroup, error) {
+	var groups []VolumeGroup
+	if err := a.get(ctx, nodePath(systemID)+"volume-groups/", nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// CreateVolumeGroup creates an LVM volume group on a machine.
+func (a *API) CreateVolumeGroup(ctx context.Context, systemID string, params VolumeGroupParams) (VolumeGroup, error) {
+	var group VolumeGroup
+	err := a.post(ctx, nodePath(systemID)+"volume-groups/", params.form(), &group)
+	return group, err
+}
+
+// DeleteVolumeGroup deletes an LVM volume group of a machine with its
+// logical volumes.
+func (a *API) DeleteVolumeGroup(ctx context.Context, systemID string, id int) error {
+	return a.delete(ctx, volumeGroupPath(systemID, id))
+}
+
+// CreateLogicalVolume creates a logical volume in a volume group. It takes
+// the remaining space of the group when size is nil.
+func (a *API) CreateLogicalVolume(ctx context.Context, systemID string, groupID int, name string, size *int64) (BlockDevice, error) {
+	form := url.Values{"name": {name}}
+	setInt64(form, "size", size)
+
+	var volume BlockDevice
+	err := a.post(ctx, volumeGroupPath(systemID, groupID)+"op-create_logical_volume", form, &volume)
+	return volume, err
+}
+
+// DeleteLogicalVolume deletes a logical volume of a volume group.
+func (a *API) DeleteLogicalVolume(ctx context.Context, systemID string, groupID, id int) error {
+	form := url.Values{"id": {strconv.Itoa(id)}}
+	return a.post(ctx, volumeGroupPath(systemID, groupID)+"op-delete_logical_volume", form, nil)
+}
+
+// ListBcaches returns the bcache devices of a machine.
+func (a *API) ListBcaches(ctx context.Context, systemID string) ([]Bcache, error) {
+	var bcaches []Bcache
+	if err := a.get(ctx, nodePath(systemID)+"bcaches/", nil, &bcaches); err != nil {
+		return nil, err
+	}
+	return bcaches, nil
+}
@@ -0,0 +1,132 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Tag is a MAAS tag.
+type Tag struct {
+	Name        string `json:"name"`
+	Comment     string `json:"comment"`
+	Definition  string `json:"definition"`
+	KernelOpts  string `json:"kernel_opts"`
+	ResourceURI string `json:"resource_uri"`
+}
+
+// TagParams are the fields of a tag to create or update. Empty fields are left unchanged.
+type TagParams struct {
+	Name       string
+	Comment    string
+	Definition string
+	KernelOpts string
+}
+
+func (p TagParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "comment", p.Comment)
+	setString(form, "definition", p.Definition)
+	setString(form, "kernel_opts", p.KernelOpts)
+	return form
+}
+
+// Node is the part common to the machines, devices and controllers of MAAS.
+type Node struct {
+	SystemID     string   `json:"system_id"`
+	Hostname     string   `json:"hostname"`
+	FQDN         string   `json:"fqdn"`
+	NodeTypeName string   `json:"node_type_name,omitempty"`
+	StatusName   string   `json:"status_name,omitempty"`
+	IPAddresses  []string `json:"ip_addresses"`
+	TagNames     []string `json:"tag_names"`
+	Zone         *Zone    `json:"zone,omitempty"`
+	ResourceURI  string   `json:"resource_uri"`
+}
+
+// Tagged node types accepted by ListTaggedNodes.
+const (
+	NodeTypeNodes             = "nodes"
+	NodeTypeMachines          = "machines"
+	NodeTypeDevices           = "devices"
+	NodeTypeRackControllers   = "rack_controllers"
+	NodeTypeRegionControllers = "region_controllers"
+)
+
+func tagPath(name string) string {
+	return fmt.Sprintf("%s/tags/%s/", basePath, url.PathEscape(name))
+}
+
+// ListTags returns all the tags.
+func (a *API) ListTags(ctx context.Context) ([]Tag, error) {
+	var tags []Tag
+	if err := a.get(ctx, basePath+"/tags/", nil, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetTag returns the tag with the given name.
+func (a *API) GetTag(ctx context.Context, name string) (Tag, error) {
+	var tag Tag
+	err := a.get(ctx, tagPath(name), nil, &tag)
+	return tag, err
+}
+
+// CreateTag creates a tag. The name of params is required.
+func (a *API) CreateTag(ctx context.Context, params TagParams) (Tag, error) {
+	var tag Tag
+	err := a.post(ctx, basePath+"/tags/", params.form(), &tag)
+	return tag, err
+}
+
+// UpdateTag updates the tag with the given name. Setting the name of params renames it.
+func (a *API) UpdateTag(ctx context.Context, name string, params TagParams) (Tag, error) {
+	var tag Tag
+	err := a.put(ctx, tagPath(name), params.form(), &tag)
+	return tag, err
+}
+
+// DeleteTag deletes the tag with the given name.
+func (a *API) DeleteTag(ctx context.Context, name string) error {
+	return a.delete(ctx, tagPath(name))
+}
+
+// ListTaggedNodes returns the nodes of the given type, one of the NodeType
+// constants, that have the tag.
+func (a *API) ListTaggedNodes(ctx context.Context, name, nodeType string) ([]Node, error) {
+	switch nodeType {
+	case NodeTypeNodes, NodeTypeMachines, NodeTypeDevices, NodeTypeRackControllers, NodeTypeRegionControllers:
+	default:
+		return nil, fmt.Errorf("unknown node type %q", nodeType)
+	}
+
+	var nodes []Node
+	if err := a.get(ctx, tagPath(name)+"op-"+nodeType, nil, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// TagNodesUpdate counts the nodes changed by UpdateTagNodes.
+type TagNodesUpdate struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// UpdateTagNodes adds the tag to the nodes in add and removes it from the
+// nodes in remove, both given by system ID.
+func (a *API) UpdateTagNodes(ctx context.Context, name string, add, remove []string) (TagNodesUpdate, error) {
+	form := url.Values{}
+	for _, systemID := range add {
+		form.Add("add", systemID)
+	}
+	for _, systemID := range remove {
+		form.Add("remove", systemID)
+	}
+
+	var update TagNodesUpdate
+	err := a.post(ctx, tagPath(name)+"op-update_nodes", form, &update)
+	return update, err
+}
@@ -0,0 +1,80 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// VMHost is a MAAS VM host, also known as a pod.
+type VMHost struct {
+	ID                    int             `json:"id"`
+	Name                  string          `json:"name"`
+	Type                  string          `json:"type"`
+	Total                 VMHostResources `json:"total"`
+	Used                  VMHostResources `json:"used"`
+	Available             VMHostResources `json:"available"`
+	CPUOverCommitRatio    float64         `json:"cpu_over_commit_ratio,omitempty"`
+	MemoryOverCommitRatio float64         `json:"memory_over_commit_ratio,omitempty"`
+	Tags                  []string        `json:"tags,omitempty"`
+	Zone                  *Zone           `json:"zone,omitempty"`
+	Pool                  *ResourcePool   `json:"pool,omitempty"`
+	ResourceURI           string          `json:"resource_uri"`
+}
+
+// VMHostResources are the resources of a VM host.
+type VMHostResources struct {
+	Cores        int   `json:"cores"`
+	Memory       int   `json:"memory"`        // in MiB
+	LocalStorage int64 `json:"local_storage"` // in bytes
+}
+
+// ComposeParams describe the VM to compose on a VM host.
+type ComposeParams struct {
+	Cores int
+	// Memory is in MiB.
+	Memory int
+	// Storage is a storage constraint, such as "20" for a 20 GB disk.
+	Storage  string
+	Hostname string
+}
+
+// ComposedMachine is the machine MAAS created for a composed VM.
+type ComposedMachine struct {
+	SystemID    string `json:"system_id"`
+	ResourceURI string `json:"resource_uri"`
+}
+
+func vmHostPath(id int) string {
+	return fmt.Sprintf("%s/vm-hosts/%d/", basePath, id)
+}
+
+// ListVMHosts returns all the VM hosts.
+func (a *API) ListVMHosts(ctx context.Context) ([]VMHost, error) {
+	var hosts []VMHost
+	if err := a.get(ctx, basePath+"/vm-hosts/", nil, &hosts); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// GetVMHost returns the VM host with the given ID.
+func (a *API) GetVMHost(ctx context.Context, id int) (VMHost, error) {
+	var host VMHost
+	err := a.get(ctx, vmHostPath(id), nil, &host)
+	return host, err
+}
+
+// ComposeVM composes a VM on the VM host with the given ID.
+func (a *API) ComposeVM(ctx context.Context, id int, params ComposeParams) (ComposedMachine, error) {
+	form := url.Values{}
+	form.Set("cores", strconv.Itoa(params.Cores))
+	form.Set("memory", strconv.Itoa(params.Memory))
+	setString(form, "storage", params.Storage)
+	setString(form, "hostname", params.Hostname)
+
+	var machine ComposedMachine
+	err := a.post(ctx, vmHostPath(id)+"op-compose", form, &machine)
+	return machine, err
+}
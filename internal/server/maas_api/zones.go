@@ -0,0 +1,103 @@
+package maas_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// ZoneParams are the fields of a zone or a resource pool to create or
+// update. Empty fields are left unchanged.
+type ZoneParams struct {
+	Name        string
+	Description string
+}
+
+func (p ZoneParams) form() url.Values {
+	form := url.Values{}
+	setString(form, "name", p.Name)
+	setString(form, "description", p.Description)
+	return form
+}
+
+func zonePath(name string) string {
+	return fmt.Sprintf("%s/zones/%s/", basePath, url.PathEscape(name))
+}
+
+// resourcePoolPath is the path of a resource pool, under the singular
+// resourcepool endpoint.
+func resourcePoolPath(id int) string {
+	return fmt.Sprintf("%s/resourcepool/%d/", basePath, id)
+}
+
+// ListZones returns all the availability zones.
+func (a *API) ListZones(ctx context.Context) ([]Zone, error) {
+	var zones []Zone
+	if err := a.get(ctx, basePath+"/zones/", nil, &zones); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// GetZone returns the zone with the given name.
+func (a *API) GetZone(ctx context.Context, name string) (Zone, error) {
+	var zone Zone
+	err := a.get(ctx, zonePath(name), nil, &zone)
+	return zone, err
+}
+
+// CreateZone creates a zone.
+func (a *API) CreateZone(ctx context.Context, params ZoneParams) (Zone, error) {
+	var zone Zone
+	err := a.post(ctx, basePath+"/zones/", params.form(), &zone)
+	return zone, err
+}
+
+// UpdateZone updates the zone with the given name.
+func (a *API) UpdateZone(ctx context.Context, name string, params ZoneParams) (Zone, error) {
+	var zone Zone
+	err := a.put(ctx, zonePath(name), params.form(), &zone)
+	return zone, err
+}
+
+// DeleteZone deletes the zone with the given name. Its machines are moved to
+// the default zone.
+func (a *API) DeleteZone(ctx context.Context, name string) error {
+	return a.delete(ctx, zonePath(name))
+}
+
+// ListResourcePools returns all the resource pools.
+func (a *API) ListResourcePools(ctx context.Context) ([]ResourcePool, error) {
+	var pools []ResourcePool
+	if err := a.get(ctx, basePath+"/resourcepools/", nil, &pools); err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
+// GetResourcePool returns the resource pool with the given ID.
+func (a *API) GetResourcePool(ctx context.Context, id int) (ResourcePool, error) {
+	var pool ResourcePool
+	err := a.get(ctx, resourcePoolPath(id), nil, &pool)
+	return pool, err
+}
+
+// CreateResourcePool creates a resource pool.
+func (a *API) CreateResourcePool(ctx context.Context, params ZoneParams) (ResourcePool, error) {
+	var pool ResourcePool
+	err := a.post(ctx, basePath+"/resourcepools/", params.form(), &pool)
+	return pool, err
+}
+
+// UpdateResourcePool updates the resource pool with the given ID.
+func (a *API) UpdateResourcePool(ctx context.Context, id int, params ZoneParams) (ResourcePool, error) {
+	var pool ResourcePool
+	err := a.put(ctx, resourcePoolPath(id), params.form(), &pool)
+	return pool, err
+}
+
+// DeleteResourcePool deletes the resource pool with the given ID. Its
+// machines are moved to the default pool.
+func (a *API) DeleteResourcePool(ctx context.Context, id int) error {
+	return a.delete(ctx, resourcePoolPath(id))
+}
@@ -0,0 +1,78 @@
+package maas_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"sync"
+)
+
+// ErrDryRun is returned by Do for mutating requests that were recorded instead of sent.
+var ErrDryRun = errors.New("request not sent to MAAS in dry-run mode")
+
+// Request describes a MAAS API request recorded in dry-run mode.
+type Request struct {
+	Method string     `json:"method"`
+	Path   string     `json:"path"`
+	Body   string     `json:"body,omitempty"`
+	Form   url.Values `json:"form,omitempty"`
+}
+
+// DryRunRecorder collects the mutating requests that Do did not send.
+type DryRunRecorder struct {
+	mu       sync.Mutex
+	requests []Request
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns a copy of ctx in which Do records POST, PUT and DELETE
+// requests on the returned recorder and fails them with ErrDryRun instead of
+// sending them. GET requests are still sent.
+func WithDryRun(ctx context.Context) (context.Context, *DryRunRecorder) {
+	recorder := &DryRunRecorder{}
+	return context.WithValue(ctx, dryRunKey{}, recorder), recorder
+}
+
+// IsDryRun reports whether ctx is in dry-run mode.
+func IsDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(*DryRunRecorder)
+	return ok
+}
+
+// Requests returns the recorded requests in the order they were made.
+func (r *DryRunRecorder) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Request(nil), r.requests...)
+}
+
+// recordDryRun records the request if ctx is in dry-run mode and reports whether it did.
+func recordDryRun(ctx context.Context, requestType RequestType, path string, body io.Reader) (bool, error) {
+	recorder, ok := ctx.Value(dryRunKey{}).(*DryRunRecorder)
+	if !ok || requestType == RequestTypeGet {
+		return false, nil
+	}
+
+	request := Request{Method: requestType.String(), Path: path}
+
+	if body != nil {
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return false, err
+		}
+
+		request.Body = string(content)
+		if form, err := url.ParseQuery(request.Body); err == nil && len(form) > 0 {
+			request.Form = form
+		}
+	}
+
+	recorder.mu.Lock()
+	recorder.requests = append(recorder.requests, request)
+	recorder.mu.Unlock()
+
+	return true, nil
+}
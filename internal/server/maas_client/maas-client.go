@@ -1,26 +1,33 @@
 package maas_client
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
-)
 
-var (
-	defaultClient *MAASClient
-	once          sync.Once
-	initErr       error
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
+// Client sends requests to the MAAS API. It is implemented by MAASClient and
+// passed to the tools when they are registered.
+type Client interface {
+	Do(ctx context.Context, requestType RequestType, path string, body io.Reader) (string, error)
+}
+
+// defaultTimeout bounds a request when Config.Timeout is not set.
+const defaultTimeout = 60 * time.Second
+
 type RequestType int
 
 const (
@@ -56,22 +63,6 @@ func (rt RequestType) Headers() map[string]string {
 	}
 }
 
-func GetClient() (*MAASClient, error) {
-	once.Do(func() {
-		defaultClient, initErr = NewMAASClientFromEnv()
-	})
-
-	return defaultClient, initErr
-}
-
-func MustClient() *MAASClient {
-	client, err := GetClient()
-	if err != nil {
-		panic(fmt.Errorf("failed to initialize MAAS client: %w", err))
-	}
-	return client
-}
-
 func generateNonce() (string, error) {
 	bytes := make([]byte, 16)
 
@@ -84,40 +75,251 @@ func generateNonce() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-type MAASClient struct {
-	baseURL     string
-	consumerKey string
-	token       string
-	secret      string
+// sharedTransport pools the connections of every client that is not given
+// its own HTTPClient, so concurrent tools reuse connections to MAAS.
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   32,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
 }
 
-func NewMAASClientFromEnv() (*MAASClient, error) {
+var sharedHTTPClient = &http.Client{Transport: sharedTransport}
+
+// Config configures a MAASClient.
+type Config struct {
+	// BaseURL is the address of the MAAS server, e.g. http://maas.example.com:5240.
+	BaseURL string
+	// APIKey is the MAAS API key in the format consumer_key:token:secret.
+	APIKey string
+	// Timeout bounds every attempt of a request. It defaults to 60 seconds and
+	// can be overridden per request with WithTimeout.
+	Timeout time.Duration
+	// HTTPClient sends the requests. A client sharing a tuned transport with
+	// the other MAASClients is used when nil.
+	HTTPClient *http.Client
+	// MaxRetries is how many times a failed request is sent again. It
+	// defaults to 3; a negative value disables retries.
+	MaxRetries int
+	// RetryBaseDelay is the delay before the first retry, doubled on every
+	// following one up to RetryMaxDelay. They default to 500ms and 30s.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// RateLimit is the number of requests per second sent to MAAS, with bursts
+	// of up to RateBurst requests. Requests are not limited when it is zero.
+	RateLimit float64
+	RateBurst int
+}
+
+// ConfigFromEnv reads the client configuration from MAAS_BASE_URL and
+// MAAS_API_KEY, and optionally MAAS_TIMEOUT, MAAS_MAX_RETRIES,
+// MAAS_RATE_LIMIT and MAAS_RATE_BURST.
+func ConfigFromEnv() (Config, error) {
 	baseURL := os.Getenv("MAAS_BASE_URL")
 	apiKey := os.Getenv("MAAS_API_KEY")
 	if baseURL == "" {
-		return nil, fmt.Errorf("MAAS_BASE_URL environment variable not set")
+		return Config{}, fmt.Errorf("MAAS_BASE_URL environment variable not set")
 	}
 	if apiKey == "" {
-		return nil, fmt.Errorf("MAAS_API_KEY environment variable not set")
+		return Config{}, fmt.Errorf("MAAS_API_KEY environment variable not set")
+	}
+
+	config := Config{BaseURL: baseURL, APIKey: apiKey}
+
+	if value := os.Getenv("MAAS_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid MAAS_TIMEOUT %q: %w", value, err)
+		}
+		config.Timeout = timeout
+	}
+	if value := os.Getenv("MAAS_MAX_RETRIES"); value != "" {
+		maxRetries, err := strconv.Atoi(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid MAAS_MAX_RETRIES %q: %w", value, err)
+		}
+		config.MaxRetries = maxRetries
+	}
+	if value := os.Getenv("MAAS_RATE_LIMIT"); value != "" {
+		rateLimit, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid MAAS_RATE_LIMIT %q: %w", value, err)
+		}
+		config.RateLimit = rateLimit
+	}
+	if value := os.Getenv("MAAS_RATE_BURST"); value != "" {
+		rateBurst, err := strconv.Atoi(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid MAAS_RATE_BURST %q: %w", value, err)
+		}
+		config.RateBurst = rateBurst
+	}
+
+	return config, nil
+}
+
+type MAASClient struct {
+	baseURL        string
+	consumerKey    string
+	token          string
+	secret         string
+	timeout        time.Duration
+	httpClient     *http.Client
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	limiter        *rate.Limiter
+}
+
+type timeoutKey struct{}
+
+// WithTimeout returns a copy of ctx in which every attempt of a request made
+// by Do is bounded by timeout instead of Config.Timeout.
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
+// NewMAASClientFromEnv creates a client configured by ConfigFromEnv.
+func NewMAASClientFromEnv() (*MAASClient, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewMAASClient(config)
+}
+
+// NewMAASClient creates a client for the MAAS server described by config.
+func NewMAASClient(config Config) (*MAASClient, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("the MAAS base URL is required")
+	}
+	if _, err := url.ParseRequestURI(config.BaseURL); err != nil {
+		return nil, fmt.Errorf("invalid MAAS base URL %q: %w", config.BaseURL, err)
 	}
-	parts := strings.Split(apiKey, ":")
+
+	parts := strings.Split(config.APIKey, ":")
 	if len(parts) != 3 {
-		return nil, fmt.Errorf("MAAS_API_KEY must be in the format consumer_key:token:secret")
+		return nil, fmt.Errorf("the MAAS API key must be in the format consumer_key:token:secret")
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = sharedHTTPClient
+	}
+
+	maxRetries := config.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	retryBaseDelay := config.RetryBaseDelay
+	if retryBaseDelay <= 0 {
+		retryBaseDelay = defaultRetryBaseDelay
+	}
+
+	retryMaxDelay := config.RetryMaxDelay
+	if retryMaxDelay <= 0 {
+		retryMaxDelay = defaultRetryMaxDelay
+	}
+
+	if config.RateLimit < 0 {
+		return nil, fmt.Errorf("the MAAS rate limit must not be negative")
 	}
+
+	var limiter *rate.Limiter
+	if config.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.RateLimit), max(config.RateBurst, 1))
+	}
+
 	return &MAASClient{
-		baseURL:     baseURL,
-		consumerKey: parts[0],
-		token:       parts[1],
-		secret:      parts[2],
+		baseURL:        strings.TrimSuffix(config.BaseURL, "/"),
+		consumerKey:    parts[0],
+		token:          parts[1],
+		secret:         parts[2],
+		timeout:        timeout,
+		httpClient:     httpClient,
+		maxRetries:     max(maxRetries, 0),
+		retryBaseDelay: retryBaseDelay,
+		retryMaxDelay:  retryMaxDelay,
+		limiter:        limiter,
 	}, nil
 }
 
+// BaseURL returns the address of the MAAS server the client sends requests to.
+func (c *MAASClient) BaseURL() string {
+	return c.baseURL
+}
+
+// Do sends a request to MAAS and returns the response body. Requests that
+// fail in a way that is safe to retry are sent again with an exponential
+// backoff; see retryable.
 func (c *MAASClient) Do(ctx context.Context, requestType RequestType, path string, body io.Reader) (string, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = io.ReadAll(body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the request body: %w", err)
+		}
+	}
+
+	recorded, err := recordDryRun(ctx, requestType, path, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the request body: %w", err)
+	}
+	if recorded {
+		return "", ErrDryRun
+	}
+
+	for retry := 0; ; retry++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return "", fmt.Errorf("MAAS API error: %w", err)
+			}
+		}
+
+		response, err := c.send(ctx, requestType, path, payload)
+		if err == nil || retry >= c.maxRetries || !retryable(requestType, err) || ctx.Err() != nil {
+			return response, err
+		}
+
+		delay := backoff(retry, c.retryBaseDelay, c.retryMaxDelay, err)
+		zap.L().Warn(fmt.Sprintf("[MAASClient] %s %s failed, retrying in %s: %v", requestType, path, delay.Round(time.Millisecond), err))
+		if err := sleep(ctx, delay); err != nil {
+			return "", fmt.Errorf("MAAS API error: %w", err)
+		}
+	}
+}
+
+// send makes a single attempt of a request.
+func (c *MAASClient) send(ctx context.Context, requestType RequestType, path string, payload []byte) (string, error) {
 	fullURL := fmt.Sprintf("%s%s", c.baseURL, path)
 
-	timeoutContext, cancel := context.WithTimeout(ctx, 60*time.Second)
+	timeout := c.timeout
+	if override, ok := ctx.Value(timeoutKey{}).(time.Duration); ok && override > 0 {
+		timeout = override
+	}
+
+	timeoutContext, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
 	req, err := http.NewRequestWithContext(timeoutContext, requestType.String(), fullURL, body)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -149,8 +351,7 @@ func (c *MAASClient) Do(ctx context.Context, requestType RequestType, path strin
 		}
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("MAAS API error: %w", err)
 	}
@@ -162,7 +363,11 @@ func (c *MAASClient) Do(ctx context.Context, requestType RequestType, path strin
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("MAAS API returned status %d: %s", resp.StatusCode, string(responseBody))
+		return "", &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(responseBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	return string(responseBody), nil
@@ -0,0 +1,53 @@
+package maas_client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMAASClient(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  Config
+		isError bool
+	}{
+		{"valid config", Config{BaseURL: "http://maas.example.com:5240/", APIKey: "consumer:token:secret"}, false},
+		{"missing base URL", Config{APIKey: "consumer:token:secret"}, true},
+		{"relative base URL", Config{BaseURL: "maas.example.com", APIKey: "consumer:token:secret"}, true},
+		{"malformed API key", Config{BaseURL: "http://maas.example.com:5240", APIKey: "consumer:token"}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			client, err := NewMAASClient(tc.config)
+
+			// Assert
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error=%v, got %v", tc.isError, err)
+			}
+			if tc.isError {
+				return
+			}
+			if client.baseURL != "http://maas.example.com:5240" {
+				t.Errorf("expected the trailing slash to be trimmed, got %s", client.baseURL)
+			}
+			if client.timeout != defaultTimeout {
+				t.Errorf("expected the default timeout, got %s", client.timeout)
+			}
+		})
+	}
+
+	t.Run("keeps a configured timeout", func(t *testing.T) {
+		// Act
+		client, err := NewMAASClient(Config{BaseURL: "http://maas.example.com:5240", APIKey: "consumer:token:secret", Timeout: time.Second})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if client.timeout != time.Second {
+			t.Errorf("expected a 1s timeout, got %s", client.timeout)
+		}
+	})
+}
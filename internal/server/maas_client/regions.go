@@ -0,0 +1,185 @@
+package maas_client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRegionName names the only region when the client is configured from
+// the environment instead of a regions file.
+const DefaultRegionName = "default"
+
+// RegionConfig is the YAML representation of a MAAS region.
+type RegionConfig struct {
+	BaseURL string `yaml:"base_url"`
+	// APIKey is the MAAS API key in the format consumer_key:token:secret.
+	APIKey string `yaml:"api_key"`
+	// APIKeyEnv names an environment variable holding the API key. It is read
+	// when APIKey is empty, so the key can be kept out of the file.
+	APIKeyEnv  string        `yaml:"api_key_env"`
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxRetries int           `yaml:"max_retries"`
+	RateLimit  float64       `yaml:"rate_limit"`
+	RateBurst  int           `yaml:"rate_burst"`
+}
+
+// RegionsConfig is the YAML representation of the MAAS regions. The default
+// region may be omitted when a single region is defined.
+type RegionsConfig struct {
+	DefaultRegion string                  `yaml:"default_region"`
+	Regions       map[string]RegionConfig `yaml:"regions"`
+}
+
+// Region describes a MAAS region as listed to the tools.
+type Region struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
+	Default bool   `json:"default"`
+}
+
+// Regions is a Client that sends every request to one of several named MAAS
+// regions: the one selected with WithRegion, or the default region.
+type Regions struct {
+	clients       map[string]*MAASClient
+	names         []string
+	defaultRegion string
+}
+
+type regionKey struct{}
+
+// WithRegion returns a copy of ctx in which Regions sends requests to the named region.
+func WithRegion(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, regionKey{}, name)
+}
+
+// RegionFromContext returns the region selected with WithRegion, if any.
+func RegionFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(regionKey{}).(string)
+	return name, ok
+}
+
+// LoadRegions reads the MAAS regions from a YAML file.
+func LoadRegions(filePath string) (*Regions, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read regions file %s: %w", filePath, err)
+	}
+
+	var config RegionsConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse regions file %s: %w", filePath, err)
+	}
+
+	return NewRegionsFromConfig(config)
+}
+
+// NewRegionsFromConfig creates a client for every region of config.
+func NewRegionsFromConfig(config RegionsConfig) (*Regions, error) {
+	clients := make(map[string]*MAASClient, len(config.Regions))
+	for name, region := range config.Regions {
+		apiKey := region.APIKey
+		if apiKey == "" && region.APIKeyEnv != "" {
+			apiKey = os.Getenv(region.APIKeyEnv)
+			if apiKey == "" {
+				return nil, fmt.Errorf("region %q: %s environment variable not set", name, region.APIKeyEnv)
+			}
+		}
+
+		client, err := NewMAASClient(Config{
+			BaseURL:    region.BaseURL,
+			APIKey:     apiKey,
+			Timeout:    region.Timeout,
+			MaxRetries: region.MaxRetries,
+			RateLimit:  region.RateLimit,
+			RateBurst:  region.RateBurst,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("region %q: %w", name, err)
+		}
+		clients[name] = client
+	}
+
+	defaultRegion := config.DefaultRegion
+	if defaultRegion == "" && len(clients) == 1 {
+		for name := range clients {
+			defaultRegion = name
+		}
+	}
+
+	return NewRegions(defaultRegion, clients)
+}
+
+// NewRegions groups clients by region name. defaultRegion receives the
+// requests that do not select a region.
+func NewRegions(defaultRegion string, clients map[string]*MAASClient) (*Regions, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("at least one MAAS region is required")
+	}
+	if defaultRegion == "" {
+		return nil, fmt.Errorf("the default region is required when several regions are defined")
+	}
+	if _, ok := clients[defaultRegion]; !ok {
+		return nil, fmt.Errorf("the default region %q is not defined", defaultRegion)
+	}
+
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return &Regions{clients: clients, names: names, defaultRegion: defaultRegion}, nil
+}
+
+// Names returns the region names in alphabetical order.
+func (r *Regions) Names() []string {
+	return slices.Clone(r.names)
+}
+
+// Default returns the name of the default region.
+func (r *Regions) Default() string {
+	return r.defaultRegion
+}
+
+// Has reports whether the region is defined.
+func (r *Regions) Has(name string) bool {
+	_, ok := r.clients[name]
+	return ok
+}
+
+// List describes the regions in alphabetical order.
+func (r *Regions) List() []Region {
+	regions := make([]Region, 0, len(r.names))
+	for _, name := range r.names {
+		regions = append(regions, Region{
+			Name:    name,
+			BaseURL: r.clients[name].BaseURL(),
+			Default: name == r.defaultRegion,
+		})
+	}
+	return regions
+}
+
+func (r *Regions) Do(ctx context.Context, requestType RequestType, path string, body io.Reader) (string, error) {
+	name, ok := RegionFromContext(ctx)
+	if !ok {
+		name = r.defaultRegion
+	}
+
+	client, ok := r.clients[name]
+	if !ok {
+		return "", fmt.Errorf("unknown MAAS region %q", name)
+	}
+
+	return client.Do(ctx, requestType, path, body)
+}
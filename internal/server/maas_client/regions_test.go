@@ -0,0 +1,163 @@
+package maas_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func startRegion(t *testing.T, name string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLoadRegions(t *testing.T) {
+	t.Setenv("ZTP_TEST_PROD_KEY", "consumer:token:prod")
+
+	cases := []struct {
+		name     string
+		content  string
+		expected string
+		isError  bool
+	}{
+		{
+			name: "several regions",
+			content: `default_region: lab
+regions:
+  lab:
+    base_url: http://lab.example.com:5240
+    api_key: consumer:token:lab
+    timeout: 30s
+  prod:
+    base_url: http://prod.example.com:5240
+    api_key_env: ZTP_TEST_PROD_KEY
+`,
+			expected: "lab",
+		},
+		{
+			name: "single region without a default",
+			content: `regions:
+  lab:
+    base_url: http://lab.example.com:5240
+    api_key: consumer:token:lab
+`,
+			expected: "lab",
+		},
+		{
+			name: "several regions without a default",
+			content: `regions:
+  lab: {base_url: "http://lab.example.com:5240", api_key: "consumer:token:lab"}
+  prod: {base_url: "http://prod.example.com:5240", api_key: "consumer:token:prod"}
+`,
+			isError: true,
+		},
+		{
+			name:    "unknown default region",
+			content: "default_region: prod\nregions:\n  lab: {base_url: \"http://lab.example.com:5240\", api_key: \"consumer:token:lab\"}\n",
+			isError: true,
+		},
+		{
+			name:    "missing API key variable",
+			content: "regions:\n  lab: {base_url: \"http://lab.example.com:5240\", api_key_env: ZTP_TEST_MISSING_KEY}\n",
+			isError: true,
+		},
+		{
+			name:    "invalid API key",
+			content: "regions:\n  lab: {base_url: \"http://lab.example.com:5240\", api_key: \"consumer\"}\n",
+			isError: true,
+		},
+		{
+			name:    "unknown field",
+			content: "regions:\n  lab: {url: \"http://lab.example.com:5240\"}\n",
+			isError: true,
+		},
+		{
+			name:    "no regions",
+			content: "",
+			isError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			filePath := filepath.Join(t.TempDir(), "regions.yaml")
+			if err := os.WriteFile(filePath, []byte(tc.content), 0o600); err != nil {
+				t.Fatalf("failed to write the regions file: %v", err)
+			}
+
+			// Act
+			regions, err := LoadRegions(filePath)
+
+			// Assert
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error=%v, got %v", tc.isError, err)
+			}
+			if !tc.isError && regions.Default() != tc.expected {
+				t.Errorf("expected default region %s, got %s", tc.expected, regions.Default())
+			}
+		})
+	}
+}
+
+func TestRegions_Do(t *testing.T) {
+	clients := map[string]*MAASClient{}
+	for _, name := range []string{"lab", "prod"} {
+		client, err := NewMAASClient(Config{BaseURL: startRegion(t, name), APIKey: "consumer:token:secret"})
+		if err != nil {
+			t.Fatalf("failed to create the client: %v", err)
+		}
+		clients[name] = client
+	}
+	regions, err := NewRegions("lab", clients)
+	if err != nil {
+		t.Fatalf("failed to create the regions: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+		isError  bool
+	}{
+		{"default region", context.Background(), "lab", false},
+		{"selected region", WithRegion(context.Background(), "prod"), "prod", false},
+		{"unknown region", WithRegion(context.Background(), "staging"), "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			body, err := regions.Do(tc.ctx, RequestTypeGet, "/MAAS/api/2.0/machines/", nil)
+
+			// Assert
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error=%v, got %v", tc.isError, err)
+			}
+			if body != tc.expected {
+				t.Errorf("expected the request to reach %q, got %q", tc.expected, body)
+			}
+		})
+	}
+
+	t.Run("lists the regions", func(t *testing.T) {
+		// Act
+		list := regions.List()
+
+		// Assert
+		if len(list) != 2 || list[0].Name != "lab" || !list[0].Default || list[1].Name != "prod" || list[1].Default {
+			t.Errorf("unexpected regions %+v", list)
+		}
+		if !strings.HasPrefix(list[1].BaseURL, "http://127.0.0.1") {
+			t.Errorf("expected the base URL of the region, got %s", list[1].BaseURL)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package maas_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry defaults applied by NewMAASClient when the Config leaves them unset.
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// StatusError is returned by Do when MAAS answers with a status outside of 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("MAAS API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether a request that failed with err may be sent again.
+// Only idempotent GET requests are retried after a transport error or a
+// gateway error, since a mutating request may have reached MAAS. 429 and 503
+// mean that MAAS did not process the request, so they are retried for every
+// request type.
+func retryable(requestType RequestType, err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return requestType == RequestTypeGet && !errors.Is(err, context.Canceled)
+	}
+
+	switch statusErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return requestType == RequestTypeGet
+	default:
+		return false
+	}
+}
+
+// backoff returns how long to wait before the given retry, counted from zero.
+// The delay doubles on every retry up to maxDelay and is then drawn between
+// half and all of it, so clients do not retry in lockstep. A Retry-After
+// requested by MAAS takes precedence, still capped at maxDelay.
+func backoff(retry int, baseDelay, maxDelay time.Duration, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, maxDelay)
+	}
+
+	delay := maxDelay
+	if retry < 32 && baseDelay<<retry > 0 {
+		delay = min(baseDelay<<retry, maxDelay)
+	}
+
+	jitter := time.Duration(rand.Int64N(int64(delay/2) + 1))
+	return delay/2 + jitter
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// sleep waits for delay or until ctx is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
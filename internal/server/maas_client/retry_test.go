@@ -0,0 +1,215 @@
+package maas_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startFlakyServer answers the first failures requests with status and the
+// following ones with 200, counting the requests it receives.
+func startFlakyServer(t *testing.T, failures int, status int) (string, *atomic.Int32) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(count.Add(1)) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &count
+}
+
+func TestMAASClient_Retries(t *testing.T) {
+	cases := []struct {
+		name        string
+		requestType RequestType
+		failures    int
+		status      int
+		maxRetries  int
+		attempts    int32
+		isError     bool
+	}{
+		{"GET retried on 503", RequestTypeGet, 2, http.StatusServiceUnavailable, 0, 3, false},
+		{"GET retried on 502", RequestTypeGet, 1, http.StatusBadGateway, 0, 2, false},
+		{"POST retried on 429", RequestTypePost, 1, http.StatusTooManyRequests, 0, 2, false},
+		{"POST not retried on 502", RequestTypePost, 1, http.StatusBadGateway, 0, 1, true},
+		{"not retried on 409", RequestTypeGet, 1, http.StatusConflict, 0, 1, true},
+		{"not retried on 500", RequestTypeDelete, 1, http.StatusInternalServerError, 0, 1, true},
+		{"gives up after the retries", RequestTypeGet, 5, http.StatusServiceUnavailable, 2, 3, true},
+		{"retries disabled", RequestTypeGet, 1, http.StatusServiceUnavailable, -1, 1, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			baseURL, count := startFlakyServer(t, tc.failures, tc.status)
+			client, err := NewMAASClient(Config{
+				BaseURL:        baseURL,
+				APIKey:         "consumer:token:secret",
+				MaxRetries:     tc.maxRetries,
+				RetryBaseDelay: time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("failed to create the client: %v", err)
+			}
+
+			// Act
+			_, err = client.Do(context.Background(), tc.requestType, "/MAAS/api/2.0/machines/", strings.NewReader("a=b"))
+
+			// Assert
+			if (err != nil) != tc.isError {
+				t.Errorf("expected error=%v, got %v", tc.isError, err)
+			}
+			var statusErr *StatusError
+			if tc.isError && (!errors.As(err, &statusErr) || statusErr.StatusCode != tc.status) {
+				t.Errorf("expected a StatusError with status %d, got %v", tc.status, err)
+			}
+			if count.Load() != tc.attempts {
+				t.Errorf("expected %d attempts, got %d", tc.attempts, count.Load())
+			}
+		})
+	}
+
+	t.Run("resends the body", func(t *testing.T) {
+		// Arrange
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			bodies = append(bodies, r.PostForm.Encode())
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		t.Cleanup(server.Close)
+		client, _ := NewMAASClient(Config{BaseURL: server.URL, APIKey: "consumer:token:secret", RetryBaseDelay: time.Millisecond})
+
+		// Act
+		_, err := client.Do(context.Background(), RequestTypePost, "/MAAS/api/2.0/machines/", strings.NewReader("a=b"))
+
+		// Assert
+		if err != nil || len(bodies) != 2 || bodies[1] != "a=b" {
+			t.Errorf("expected the body to be sent twice, got %v %v", bodies, err)
+		}
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		// Arrange
+		baseURL, count := startFlakyServer(t, 5, http.StatusServiceUnavailable)
+		client, _ := NewMAASClient(Config{BaseURL: baseURL, APIKey: "consumer:token:secret", RetryBaseDelay: time.Hour, RetryMaxDelay: time.Hour})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// Act
+		_, err := client.Do(ctx, RequestTypeGet, "/MAAS/api/2.0/machines/", nil)
+
+		// Assert
+		if !errors.Is(err, context.DeadlineExceeded) || count.Load() != 1 {
+			t.Errorf("expected the retry to be cancelled after 1 attempt, got %d attempts and %v", count.Load(), err)
+		}
+	})
+}
+
+func TestMAASClient_WithTimeout(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, _ := NewMAASClient(Config{BaseURL: server.URL, APIKey: "consumer:token:secret", MaxRetries: -1})
+
+	// Act
+	start := time.Now()
+	_, err := client.Do(WithTimeout(context.Background(), 20*time.Millisecond), RequestTypeGet, "/MAAS/api/2.0/machines/", nil)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected the request to time out after 20ms, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestMAASClient_RateLimit(t *testing.T) {
+	// Arrange
+	baseURL, count := startFlakyServer(t, 0, http.StatusOK)
+	client, _ := NewMAASClient(Config{BaseURL: baseURL, APIKey: "consumer:token:secret", RateLimit: 50, RateBurst: 1})
+
+	// Act
+	start := time.Now()
+	for range 4 {
+		if _, err := client.Do(context.Background(), RequestTypeGet, "/MAAS/api/2.0/machines/", nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// Assert
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || count.Load() != 4 {
+		t.Errorf("expected 4 requests spread over at least 50ms, got %d in %s", count.Load(), elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "7", 7 * time.Second},
+		{"HTTP date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			delay := parseRetryAfter(tc.value, now)
+
+			// Assert
+			if delay != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, delay)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		name     string
+		retry    int
+		err      error
+		min, max time.Duration
+	}{
+		{"first retry", 0, errors.New("reset"), 50 * time.Millisecond, 100 * time.Millisecond},
+		{"third retry", 2, errors.New("reset"), 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 40, errors.New("reset"), 500 * time.Millisecond, time.Second},
+		{"Retry-After", 0, &StatusError{StatusCode: 503, RetryAfter: 700 * time.Millisecond}, 700 * time.Millisecond, 700 * time.Millisecond},
+		{"Retry-After capped", 0, &StatusError{StatusCode: 503, RetryAfter: time.Hour}, time.Second, time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for range 20 {
+				// Act
+				delay := backoff(tc.retry, 100*time.Millisecond, time.Second, tc.err)
+
+				// Assert
+				if delay < tc.min || delay > tc.max {
+					t.Fatalf("expected a delay between %s and %s, got %s", tc.min, tc.max, delay)
+				}
+			}
+		})
+	}
+}
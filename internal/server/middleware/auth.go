@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid bearer token")
+)
+
+type identityKey struct{}
+
+// AuthConfig configures the credentials accepted by the Auth middleware.
+//
+// API keys and token hashes may be prefixed with "<name>:" to give the caller
+// a stable name, e.g. "ci-bot:s3cr3t". The name ends at the first colon not
+// escaped as "\:", so names may contain escaped colons and the key may
+// contain colons; a key containing a colon must be named, e.g.
+// "api-key:a:b:c" for the key "a:b:c". Token hashes are hex encoded SHA-256
+// digests of the accepted bearer tokens. When ClientCertificates is set,
+// requests without a bearer token are identified by the common name of a
+// client certificate verified by the TLS listener.
+type AuthConfig struct {
+	APIKeys            []string
+	TokenHashes        []string
+	JWKSFile           string
+	JWTIssuer          string
+	JWTAudience        string
+	ClientCertificates bool
+	LogBodies          bool
+}
+
+// Identity describes an authenticated caller.
+type Identity struct {
+	Subject string `json:"subject"`
+	Method  string `json:"method"`
+}
+
+// String returns the identity as "<method>:<subject>".
+func (i Identity) String() string {
+	return i.Method + ":" + i.Subject
+}
+
+type credential struct {
+	name   string
+	digest []byte
+}
+
+// Authenticator validates bearer tokens against static credentials and JWTs.
+type Authenticator struct {
+	credentials []credential
+	jwt         *jwtValidator
+	clientCerts bool
+	logBodies   bool
+}
+
+// NewAuthenticator builds an Authenticator from the given configuration.
+func NewAuthenticator(config AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{clientCerts: config.ClientCertificates, logBodies: config.LogBodies}
+
+	for i, entry := range config.APIKeys {
+		name, key := splitNamed(entry, fmt.Sprintf("api-key-%d", i+1))
+		if key == "" {
+			return nil, fmt.Errorf("API key %s is empty", name)
+		}
+
+		digest := sha256.Sum256([]byte(key))
+		a.credentials = append(a.credentials, credential{name: name, digest: digest[:]})
+	}
+
+	for i, entry := range config.TokenHashes {
+		name, hash := splitNamed(entry, fmt.Sprintf("token-%d", i+1))
+
+		digest, err := hex.DecodeString(hash)
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("token hash %s must be a hex encoded SHA-256 digest", name)
+		}
+
+		a.credentials = append(a.credentials, credential{name: name, digest: digest})
+	}
+
+	if config.JWKSFile != "" {
+		validator, err := newJWTValidator(config.JWKSFile, config.JWTIssuer, config.JWTAudience)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = validator
+	}
+
+	return a, nil
+}
+
+// Enabled reports whether any credential source has been configured.
+func (a *Authenticator) Enabled() bool {
+	return len(a.credentials) > 0 || a.jwt != nil || a.clientCerts
+}
+
+// Authenticate validates the bearer token or the client certificate of the
+// request and returns the caller identity.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		if a.clientCerts && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			if name := r.TLS.VerifiedChains[0][0].Subject.CommonName; name != "" {
+				return Identity{Subject: name, Method: "cert"}, nil
+			}
+		}
+		return Identity{}, errMissingToken
+	}
+	token = strings.TrimSpace(token)
+
+	digest := sha256.Sum256([]byte(token))
+	for _, c := range a.credentials {
+		if subtle.ConstantTimeCompare(digest[:], c.digest) == 1 {
+			return Identity{Subject: c.name, Method: "token"}, nil
+		}
+	}
+
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		subject, err := a.jwt.validate(token)
+		if err != nil {
+			return Identity{}, fmt.Errorf("%w: %v", errInvalidToken, err)
+		}
+		return Identity{Subject: subject, Method: "jwt"}, nil
+	}
+
+	return Identity{}, errInvalidToken
+}
+
+// Auth rejects requests without valid credentials with 401 and stores the
+// caller identity in the request context for the next handlers. Requests are
+// passed through untouched when the authenticator has no credentials.
+func Auth(authenticator *Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authenticator.Enabled() {
+			identity, err := authenticator.Authenticate(r)
+			if err != nil {
+				zap.L().Warn(fmt.Sprintf("Rejected unauthenticated request from %s: %v", r.RemoteAddr, err))
+
+				challenge := `Bearer realm="ztp-mcp"`
+				if !errors.Is(err, errMissingToken) {
+					challenge += `, error="invalid_token"`
+				}
+
+				w.Header().Set("WWW-Authenticate", challenge)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			r = r.WithContext(WithIdentity(r.Context(), identity))
+		}
+
+		if authenticator.logBodies && r.Body != nil {
+			bodyBytes, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Failed to read body", http.StatusInternalServerError)
+				return
+			}
+
+			_ = r.Body.Close()
+
+			r.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
+
+			zap.L().Info(string(bodyBytes))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithIdentity returns a copy of ctx carrying the caller identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller identity stored by Auth, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// splitNamed splits a "<name>:<value>" entry at its first colon not escaped
+// as "\:", and unescapes the colons of the name. Entries without name are
+// given defaultName.
+func splitNamed(entry, defaultName string) (string, string) {
+	for i := 0; i < len(entry); i++ {
+		switch entry[i] {
+		case '\\':
+			i++
+		case ':':
+			if i == 0 {
+				return defaultName, entry
+			}
+			return strings.ReplaceAll(entry[:i], `\:`, ":"), entry[i+1:]
+		}
+	}
+	return defaultName, entry
+}
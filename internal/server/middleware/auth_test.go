@@ -0,0 +1,468 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0"}`))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func serveAuth(t *testing.T, config AuthConfig, r *http.Request) (*httptest.ResponseRecorder, Identity) {
+	t.Helper()
+
+	authenticator, err := NewAuthenticator(config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var identity Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	Auth(authenticator, next).ServeHTTP(recorder, r)
+
+	return recorder, identity
+}
+
+func encodeSegment(t *testing.T, value any) string {
+	t.Helper()
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to marshal segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	return signRSA(t, "RS256", crypto.SHA256, key, kid, claims)
+}
+
+// signRSA signs a token with alg in its header and hash as digest.
+func signRSA(t *testing.T, alg string, hash crypto.Hash, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	signed := encodeSegment(t, map[string]string{"alg": alg, "kid": kid}) + "." + encodeSegment(t, claims)
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, hash, hasher.Sum(nil))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	return signECDSA(t, "ES256", crypto.SHA256, key, kid, claims)
+}
+
+// signECDSA signs a token with alg in its header and hash as digest,
+// whatever the curve of key.
+func signECDSA(t *testing.T, alg string, hash crypto.Hash, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	signed := encodeSegment(t, map[string]string{"alg": alg, "kid": kid}) + "." + encodeSegment(t, claims)
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, hasher.Sum(nil))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	size := (key.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	ecdhKey, err := ecKey.PublicKey.ECDH()
+	if err != nil {
+		t.Fatalf("failed to convert EC key: %v", err)
+	}
+	point := ecdhKey.Bytes()
+
+	jwks := map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "RSA",
+				"kid": "rsa-512",
+				"use": "sig",
+				"alg": "RS512",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec-1",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+				"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	content, _ := json.Marshal(jwks)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS file: %v", err)
+	}
+	return path
+}
+
+func TestAuth_StaticCredentials(t *testing.T) {
+	t.Run("accepts a configured API key", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{APIKeys: []string{"s3cr3t"}}
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest("s3cr3t"))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.Subject != "api-key-1" || identity.Method != "token" {
+			t.Errorf("unexpected identity %+v", identity)
+		}
+	})
+
+	t.Run("uses the configured name of a key", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{APIKeys: []string{"other", "ci-bot:s3cr3t=="}}
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest("s3cr3t=="))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.Subject != "ci-bot" {
+			t.Errorf("expected subject 'ci-bot', got %q", identity.Subject)
+		}
+	})
+
+	t.Run("accepts a named key containing colons", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{APIKeys: []string{`ci\:bot:s3:cr:3t`}}
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest("s3:cr:3t"))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.Subject != "ci:bot" {
+			t.Errorf("expected subject 'ci:bot', got %q", identity.Subject)
+		}
+	})
+
+	t.Run("accepts a token matching a configured hash", func(t *testing.T) {
+		// Arrange
+		digest := sha256.Sum256([]byte("hashed-token"))
+		config := AuthConfig{TokenHashes: []string{"ops:" + hex.EncodeToString(digest[:])}}
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest("hashed-token"))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.Subject != "ops" {
+			t.Errorf("expected subject 'ops', got %q", identity.Subject)
+		}
+	})
+
+	t.Run("rejects a request without token", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{APIKeys: []string{"s3cr3t"}}
+
+		// Act
+		recorder, _ := serveAuth(t, config, newTestRequest(""))
+
+		// Assert
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status 401, got %d", recorder.Code)
+		}
+		if challenge := recorder.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="ztp-mcp"` {
+			t.Errorf("unexpected WWW-Authenticate header %q", challenge)
+		}
+	})
+
+	t.Run("rejects an unknown token", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{APIKeys: []string{"s3cr3t"}}
+
+		// Act
+		recorder, _ := serveAuth(t, config, newTestRequest("wrong"))
+
+		// Assert
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status 401, got %d", recorder.Code)
+		}
+		if challenge := recorder.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, `error="invalid_token"`) {
+			t.Errorf("expected invalid_token challenge, got %q", challenge)
+		}
+	})
+
+	t.Run("passes requests through when no credentials are configured", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{}
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest(""))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity != (Identity{}) {
+			t.Errorf("expected no identity, got %+v", identity)
+		}
+	})
+
+	t.Run("identifies callers by their verified client certificate", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{ClientCertificates: true}
+		r := newTestRequest("")
+		r.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "node-01"}}}},
+		}
+
+		// Act
+		recorder, identity := serveAuth(t, config, r)
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.String() != "cert:node-01" {
+			t.Errorf("expected identity 'cert:node-01', got %q", identity.String())
+		}
+	})
+
+	t.Run("rejects unverified TLS connections when certificates are accepted", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{ClientCertificates: true}
+		r := newTestRequest("")
+		r.TLS = &tls.ConnectionState{}
+
+		// Act
+		recorder, _ := serveAuth(t, config, r)
+
+		// Assert
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status 401, got %d", recorder.Code)
+		}
+	})
+
+	t.Run("rejects malformed token hashes", func(t *testing.T) {
+		// Arrange
+		config := AuthConfig{TokenHashes: []string{"not-a-digest"}}
+
+		// Act
+		_, err := NewAuthenticator(config)
+
+		// Assert
+		if err == nil {
+			t.Fatal("expected error for malformed hash")
+		}
+	})
+}
+
+func TestSplitNamed(t *testing.T) {
+	cases := []struct {
+		name, entry, wantName, wantValue string
+	}{
+		{"unnamed entry", "s3cr3t", "api-key-1", "s3cr3t"},
+		{"named entry", "ci-bot:s3cr3t", "ci-bot", "s3cr3t"},
+		{"value with colons", "ci-bot:a:b:c", "ci-bot", "a:b:c"},
+		{"name with an escaped colon", `ci\:bot:a:b`, "ci:bot", "a:b"},
+		{"empty name", ":s3cr3t", "api-key-1", ":s3cr3t"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			name, value := splitNamed(tc.entry, "api-key-1")
+
+			// Assert
+			if name != tc.wantName || value != tc.wantValue {
+				t.Errorf("expected (%q, %q), got (%q, %q)", tc.wantName, tc.wantValue, name, value)
+			}
+		})
+	}
+}
+
+func TestAuth_JWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+
+	config := AuthConfig{
+		JWKSFile:    writeJWKS(t, rsaKey, ecKey),
+		JWTIssuer:   "https://idp.example.com",
+		JWTAudience: "ztp-mcp",
+	}
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub": "alice",
+			"iss": "https://idp.example.com",
+			"aud": []string{"ztp-mcp", "other"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	t.Run("accepts a valid RS256 token", func(t *testing.T) {
+		// Arrange
+		token := signRS256(t, rsaKey, "rsa-1", claims(nil))
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest(token))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.Subject != "alice" || identity.Method != "jwt" {
+			t.Errorf("unexpected identity %+v", identity)
+		}
+	})
+
+	t.Run("accepts a valid ES256 token", func(t *testing.T) {
+		// Arrange
+		token := signES256(t, ecKey, "ec-1", claims(map[string]any{"aud": "ztp-mcp"}))
+
+		// Act
+		recorder, identity := serveAuth(t, config, newTestRequest(token))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+		if identity.Subject != "alice" {
+			t.Errorf("expected subject 'alice', got %q", identity.Subject)
+		}
+	})
+
+	t.Run("accepts a token signed with the algorithm of its key", func(t *testing.T) {
+		// Arrange
+		token := signRSA(t, "RS512", crypto.SHA512, rsaKey, "rsa-512", claims(nil))
+
+		// Act
+		recorder, _ := serveAuth(t, config, newTestRequest(token))
+
+		// Assert
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+	})
+
+	rejected := []struct {
+		name  string
+		token func() string
+	}{
+		{"expired token", func() string {
+			return signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}))
+		}},
+		{"token without expiry", func() string {
+			c := claims(nil)
+			delete(c, "exp")
+			return signRS256(t, rsaKey, "rsa-1", c)
+		}},
+		{"wrong audience", func() string {
+			return signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"aud": "someone-else"}))
+		}},
+		{"wrong issuer", func() string {
+			return signES256(t, ecKey, "ec-1", claims(map[string]any{"iss": "https://evil.example.com"}))
+		}},
+		{"unknown key id", func() string {
+			return signRS256(t, rsaKey, "rsa-2", claims(nil))
+		}},
+		{"key of another type", func() string {
+			return signES256(t, ecKey, "rsa-1", claims(nil))
+		}},
+		{"algorithm of another curve", func() string {
+			return signECDSA(t, "ES384", crypto.SHA384, ecKey, "ec-1", claims(nil))
+		}},
+		{"algorithm other than the one of its key", func() string {
+			return signRS256(t, rsaKey, "rsa-512", claims(nil))
+		}},
+		{"tampered claims", func() string {
+			parts := strings.Split(signRS256(t, rsaKey, "rsa-1", claims(nil)), ".")
+			parts[1] = encodeSegment(t, claims(map[string]any{"sub": "mallory"}))
+			return strings.Join(parts, ".")
+		}},
+	}
+
+	for _, tc := range rejected {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			// Arrange
+			token := tc.token()
+
+			// Act
+			recorder, _ := serveAuth(t, config, newTestRequest(token))
+
+			// Assert
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status 401, got %d", recorder.Code)
+			}
+		})
+	}
+}
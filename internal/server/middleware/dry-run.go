@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// DryRunResult is returned instead of the tool result when a tool tried to
+// change MAAS in dry-run mode.
+type DryRunResult struct {
+	DryRun   bool                  `json:"dry_run"`
+	Requests []maas_client.Request `json:"requests"`
+}
+
+// localStateTools are the tools that change the state of the server itself,
+// the templates on disk or the jobs, instead of MAAS. Their changes cannot be
+// recorded as requests, so they are refused in dry-run mode.
+var localStateTools = map[string]bool{
+	"create-template": true,
+	"delete-template": true,
+	"cancel-job":      true,
+}
+
+// DryRun is a tool handler middleware that keeps tools from sending mutating
+// requests to MAAS. When a tool tries to, the request it would have sent is
+// returned as the result of the call, with the BMC credentials redacted.
+// Tools stop at the first such request. The tools changing the state of the
+// server itself are refused.
+func DryRun(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if localStateTools[request.Params.Name] {
+			errMsg := fmt.Sprintf("%s changes the state of the server and is not run in dry-run mode", request.Params.Name)
+			zap.L().Warn(fmt.Sprintf("[DryRun] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		ctx, recorder := maas_client.WithDryRun(ctx)
+
+		result, err := next(ctx, request)
+
+		requests := recorder.Requests()
+		if len(requests) == 0 {
+			return result, err
+		}
+
+		for i := range requests {
+			requests[i] = redactRequest(requests[i])
+		}
+
+		zap.L().Info(fmt.Sprintf("[DryRun] %s would have sent %s %s", request.Params.Name, requests[0].Method, requests[0].Path))
+
+		jsonData, err := json.Marshal(DryRunResult{DryRun: true, Requests: requests})
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to marshal result: %v", err)
+			zap.L().Error(fmt.Sprintf("[DryRun] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
+
+// redactRequest hides the secret power parameters in the form of a recorded
+// request. MAAS forms are URL encoded, so the body is the encoded form.
+func redactRequest(request maas_client.Request) maas_client.Request {
+	if request.Form != nil {
+		request.Form = maas_api.RedactForm(request.Form)
+		request.Body = request.Form.Encode()
+	}
+	return request
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newTestMAAS(t *testing.T) (*maas_client.MAASClient, *[]string) {
+	t.Helper()
+
+	var received []string
+	maas := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"status_name":"Ready"}`))
+	}))
+	t.Cleanup(maas.Close)
+
+	t.Setenv("MAAS_BASE_URL", maas.URL)
+	t.Setenv("MAAS_API_KEY", "consumer:token:secret")
+
+	client, err := maas_client.NewMAASClientFromEnv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return client, &received
+}
+
+func textOf(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+
+	if len(result.Content) != 1 {
+		t.Fatalf("expected one content item, got %d", len(result.Content))
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("expected text content, got %T", result.Content[0])
+	}
+	return text.Text
+}
+
+func TestDryRun(t *testing.T) {
+	t.Run("returns the mutating request instead of sending it", func(t *testing.T) {
+		// Arrange
+		client, received := newTestMAAS(t)
+		form := url.Values{"distro_series": {"noble"}, "user_data": {"I2Nsb3VkLWNvbmZpZw=="}}
+
+		handler := DryRun(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if _, err := client.Do(ctx, maas_client.RequestTypeGet, "/MAAS/api/2.0/machines/abc123/", nil); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if _, err := client.Do(ctx, maas_client.RequestTypePost, "/MAAS/api/2.0/machines/abc123/op-deploy", strings.NewReader(form.Encode())); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText("deployed"), nil
+		})
+
+		// Act
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.IsError {
+			t.Fatalf("expected a successful result, got %s", textOf(t, result))
+		}
+
+		var dryRun DryRunResult
+		if err := json.Unmarshal([]byte(textOf(t, result)), &dryRun); err != nil {
+			t.Fatalf("failed to unmarshal result: %v", err)
+		}
+		if !dryRun.DryRun || len(dryRun.Requests) != 1 {
+			t.Fatalf("unexpected dry-run result %+v", dryRun)
+		}
+
+		sent := dryRun.Requests[0]
+		if sent.Method != "POST" || sent.Path != "/MAAS/api/2.0/machines/abc123/op-deploy" {
+			t.Errorf("unexpected request %s %s", sent.Method, sent.Path)
+		}
+		if sent.Body != form.Encode() || sent.Form.Get("distro_series") != "noble" {
+			t.Errorf("unexpected request body %q", sent.Body)
+		}
+		if len(*received) != 1 || (*received)[0] != "GET /MAAS/api/2.0/machines/abc123/" {
+			t.Errorf("expected only the GET request to reach MAAS, got %v", *received)
+		}
+	})
+
+	t.Run("redacts the BMC credentials of the request", func(t *testing.T) {
+		// Arrange
+		client, _ := newTestMAAS(t)
+		form := url.Values{"power_type": {"ipmi"}, "power_parameters_power_user": {"admin"}, "power_parameters_power_pass": {"hunter2"}}
+
+		handler := DryRun(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if _, err := client.Do(ctx, maas_client.RequestTypePut, "/MAAS/api/2.0/machines/abc123/", strings.NewReader(form.Encode())); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText("updated"), nil
+		})
+
+		// Act
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		text := textOf(t, result)
+		if strings.Contains(text, "hunter2") {
+			t.Fatalf("expected the password to be redacted, got %s", text)
+		}
+
+		var dryRun DryRunResult
+		if err := json.Unmarshal([]byte(text), &dryRun); err != nil {
+			t.Fatalf("failed to unmarshal result: %v", err)
+		}
+		sent := dryRun.Requests[0]
+		if sent.Form.Get("power_parameters_power_pass") != "********" || sent.Form.Get("power_parameters_power_user") != "admin" {
+			t.Errorf("unexpected request form %v", sent.Form)
+		}
+	})
+
+	t.Run("returns the result of read-only tools untouched", func(t *testing.T) {
+		// Arrange
+		client, _ := newTestMAAS(t)
+
+		handler := DryRun(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			resultData, err := client.Do(ctx, maas_client.RequestTypeGet, "/MAAS/api/2.0/machines/abc123/", nil)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText(resultData), nil
+		})
+
+		// Act
+		result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if text := textOf(t, result); text != `{"status_name":"Ready"}` {
+			t.Errorf("unexpected result %s", text)
+		}
+	})
+}
+
+func TestDryRun_LocalStateTools(t *testing.T) {
+	for _, name := range []string{"create-template", "delete-template", "cancel-job"} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			called := false
+			handler := DryRun(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				called = true
+				return mcp.NewToolResultText("done"), nil
+			})
+			request := mcp.CallToolRequest{}
+			request.Params.Name = name
+
+			// Act
+			result, err := handler(context.Background(), request)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if called {
+				t.Errorf("expected %s not to run in dry-run mode", name)
+			}
+			if !result.IsError {
+				t.Errorf("expected an error result, got %s", textOf(t, result))
+			}
+		})
+	}
+}
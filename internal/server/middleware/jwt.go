@@ -0,0 +1,321 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jwtLeeway is the clock skew tolerated when checking exp and nbf.
+const jwtLeeway = 30 * time.Second
+
+var oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type algorithmIdentifier struct {
+	Algorithm asn1.ObjectIdentifier
+	Curve     asn1.ObjectIdentifier
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// signingKey is a key of the JWKS file with the algorithm it is
+// restricted to, empty when the JWK does not name one.
+type signingKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// jwtValidator validates RS* and ES* signed JWTs against keys loaded from a local JWKS file.
+type jwtValidator struct {
+	keys     map[string]signingKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+func newJWTValidator(jwksFile, issuer, audience string) (*jwtValidator, error) {
+	content, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file %s: %w", jwksFile, err)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file %s: %w", jwksFile, err)
+	}
+
+	keys := make(map[string]signingKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in JWKS file %s: %w", jwk.Kid, jwksFile, err)
+		}
+		keys[jwk.Kid] = signingKey{key: key, alg: jwk.Alg}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s does not contain any signing key", jwksFile)
+	}
+
+	return &jwtValidator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+		now:      time.Now,
+	}, nil
+}
+
+// validate checks the signature and registered claims of the token and returns its subject.
+func (v *jwtValidator) validate(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed JWT")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("malformed JWT header: %w", err)
+	}
+
+	key, ok := v.keys[header.Kid]
+	if !ok {
+		return "", fmt.Errorf("unknown signing key %q", header.Kid)
+	}
+	if key.alg != "" && header.Alg != key.alg {
+		return "", fmt.Errorf("algorithm %q is not allowed for signing key %q", header.Alg, header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed JWT signature: %w", err)
+	}
+
+	if err := verifySignature(header.Alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return "", err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("malformed JWT claims: %w", err)
+	}
+
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return "", fmt.Errorf("token has no expiry")
+	}
+	if now.After(time.Unix(int64(*claims.ExpiresAt), 0).Add(jwtLeeway)) {
+		return "", fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(int64(*claims.NotBefore), 0)) {
+		return "", fmt.Errorf("token not valid yet")
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return "", fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if v.audience != "" && !claims.hasAudience(v.audience) {
+		return "", fmt.Errorf("token is not intended for audience %q", v.audience)
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("token has no subject")
+	}
+
+	return claims.Subject, nil
+}
+
+func (c jwtClaims) hasAudience(audience string) bool {
+	var single string
+	if err := json.Unmarshal(c.Audience, &single); err == nil {
+		return single == audience
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(c.Audience, &multiple); err == nil {
+		return slices.Contains(multiple, audience)
+	}
+
+	return false
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, size, ok := ecCurves(jwk.Crv)
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != size {
+			return nil, fmt.Errorf("invalid x coordinate")
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil || len(y) != size {
+			return nil, fmt.Errorf("invalid y coordinate")
+		}
+
+		// Let x509 validate the point by parsing it as a SubjectPublicKeyInfo.
+		point := append(append([]byte{0x04}, x...), y...)
+		der, err := asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: algorithmIdentifier{Algorithm: oidECPublicKey, Curve: curve},
+			PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid token signature")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %s does not match EC key", alg)
+		}
+
+		// Each ES algorithm is bound to one curve, so that a token cannot
+		// pick a weaker hash than the key is meant for.
+		if curve := k.Curve.Params().Name; curve != esCurves[alg] {
+			return fmt.Errorf("algorithm %s does not match EC key on curve %s", alg, curve)
+		}
+
+		_, size, _ := ecCurves(k.Curve.Params().Name)
+		if size == 0 || len(signature) != 2*size {
+			return fmt.Errorf("invalid token signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("invalid token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+
+	return nil
+}
+
+// esCurves are the curves of the keys of the ES algorithms (RFC 7518, 3.4).
+var esCurves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+// ecCurves returns the ASN.1 identifier and coordinate size of a JWK curve.
+func ecCurves(name string) (asn1.ObjectIdentifier, int, bool) {
+	switch name {
+	case "P-256":
+		return asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}, 32, true
+	case "P-384":
+		return asn1.ObjectIdentifier{1, 3, 132, 0, 34}, 48, true
+	case "P-521":
+		return asn1.ObjectIdentifier{1, 3, 132, 0, 35}, 66, true
+	default:
+		return nil, 0, false
+	}
+}
+
+func decodeSegment(segment string, target any) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, target)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(content), nil
+}
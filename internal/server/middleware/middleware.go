@@ -3,10 +3,8 @@ package middleware
 import (
 	"bufio"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -50,21 +48,3 @@ func Logging(next http.Handler) http.Handler {
 		zap.L().Info(fmt.Sprintf("%d, %s, %s, %s", wrapped.statusCode, r.Method, r.URL.Path, time.Since(start)))
 	})
 }
-
-func Auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read body", http.StatusInternalServerError)
-			return
-		}
-
-		_ = r.Body.Close()
-
-		r.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
-
-		zap.L().Info(string(bodyBytes))
-
-		next.ServeHTTP(w, r)
-	})
-}
@@ -0,0 +1,322 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/middleware"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Selectors that match tools by their annotations instead of by name. They
+// can be used in the allow and deny lists of a role next to tool name globs.
+const (
+	SelectorReadOnly       = "@read-only"
+	SelectorNonDestructive = "@non-destructive"
+)
+
+// AdminRole is the builtin role granting every tool. Its callers also see and
+// cancel the jobs of the other callers.
+const AdminRole = "admin"
+
+// AnonymousSubject is the subject of callers without an identity, e.g. the
+// stdio transport or an HTTP server started with authentication disabled.
+const AnonymousSubject = "anonymous"
+
+// Role lists the tools granted by a role. Allow and Deny hold tool name globs
+// (see path.Match) or selectors; a tool matched by Deny is never granted by
+// the role, even when one of the inherited roles allows it.
+type Role struct {
+	Inherits []string `yaml:"inherits"`
+	Allow    []string `yaml:"allow"`
+	Deny     []string `yaml:"deny"`
+}
+
+// Config is the YAML representation of a policy.
+//
+// Subjects map a caller to its roles. Callers are named as
+// "<method>:<subject>", e.g. "token:ci-bot", "jwt:alice" or "cert:node-01",
+// or "anonymous" for callers without an identity. Callers that are not listed
+// get DefaultRoles.
+type Config struct {
+	Roles        map[string]Role     `yaml:"roles"`
+	Subjects     map[string][]string `yaml:"subjects"`
+	DefaultRoles []string            `yaml:"default_roles"`
+}
+
+// BuiltinRoles returns the roles available in every policy. They name the
+// tools they grant, so that a new tool is only granted once it is added here,
+// and can be redefined by the policy file.
+func BuiltinRoles() map[string]Role {
+	return map[string]Role{
+		"observer": {
+			Allow: []string{
+				"list-*",
+				"read-*",
+				"retrieve-templates",
+				"retrieve-template-by-id",
+				"retrieve-template-content",
+				"get-events",
+				"get-job",
+				"get-machine-details",
+				"get-machine-ip",
+				"get-machine-script-results",
+				"get-machine-status",
+				"get-boot-resources-import-status",
+				"subnet-ip-addresses",
+				"subnet-reserved-ip-ranges",
+				"subnet-unreserved-ip-ranges",
+				"subnet-statistics",
+			},
+		},
+		"operator": {
+			Inherits: []string{"observer"},
+			Allow: []string{
+				"deploy-machine",
+				"change-power-state",
+				"power-cycle",
+				"power-state",
+				"wait-for-machine-status",
+				"wait-for-machines-status",
+				"cancel-job",
+			},
+		},
+		AdminRole: {
+			Allow: []string{"*"},
+		},
+	}
+}
+
+type rule struct {
+	allow []string
+	deny  []string
+}
+
+// Policy decides which tools a caller may list and call.
+type Policy struct {
+	roles        map[string]rule
+	subjects     map[string][]string
+	defaultRoles []string
+
+	mu    sync.RWMutex
+	tools map[string]mcp.Tool
+}
+
+// Load reads a policy from a YAML file.
+func Load(filePath string) (*Policy, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file %s: %w", filePath, err)
+	}
+
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", filePath, err)
+	}
+
+	return New(config)
+}
+
+// New builds a policy from the given configuration, merged over the builtin roles.
+func New(config Config) (*Policy, error) {
+	roles := BuiltinRoles()
+	for name, role := range config.Roles {
+		roles[name] = role
+	}
+
+	p := &Policy{
+		roles:        make(map[string]rule, len(roles)),
+		subjects:     config.Subjects,
+		defaultRoles: config.DefaultRoles,
+		tools:        make(map[string]mcp.Tool),
+	}
+
+	for name, role := range roles {
+		for _, pattern := range slices.Concat(role.Allow, role.Deny) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("role %q has invalid tool pattern %q: %w", name, pattern, err)
+			}
+		}
+
+		resolved, err := resolveRole(roles, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.roles[name] = resolved
+	}
+
+	for subject, subjectRoles := range config.Subjects {
+		for _, role := range subjectRoles {
+			if _, ok := p.roles[role]; !ok {
+				return nil, fmt.Errorf("subject %s references unknown role %q", subject, role)
+			}
+		}
+	}
+
+	for _, role := range config.DefaultRoles {
+		if _, ok := p.roles[role]; !ok {
+			return nil, fmt.Errorf("default roles reference unknown role %q", role)
+		}
+	}
+
+	return p, nil
+}
+
+// resolveRole flattens the allow and deny lists of a role and of all the roles it inherits.
+func resolveRole(roles map[string]Role, name string, chain []string) (rule, error) {
+	if slices.Contains(chain, name) {
+		return rule{}, fmt.Errorf("role %q inherits itself through %v", name, append(chain, name))
+	}
+
+	role, ok := roles[name]
+	if !ok {
+		return rule{}, fmt.Errorf("role %q inherits unknown role %q", chain[len(chain)-1], name)
+	}
+
+	resolved := rule{
+		allow: slices.Clone(role.Allow),
+		deny:  slices.Clone(role.Deny),
+	}
+
+	for _, parent := range role.Inherits {
+		inherited, err := resolveRole(roles, parent, append(chain, name))
+		if err != nil {
+			return rule{}, err
+		}
+		resolved.allow = append(resolved.allow, inherited.allow...)
+		resolved.deny = append(resolved.deny, inherited.deny...)
+	}
+
+	return resolved, nil
+}
+
+// Subject returns the name the policy uses for the caller stored in ctx.
+func Subject(ctx context.Context) string {
+	identity, ok := middleware.IdentityFromContext(ctx)
+	if !ok {
+		return AnonymousSubject
+	}
+	return identity.String()
+}
+
+type adminKey struct{}
+
+// IsAdmin reports whether the caller stored in ctx has the admin role. Calls
+// that did not go through a Guard, on a server without policy, are admin.
+func IsAdmin(ctx context.Context) bool {
+	admin, ok := ctx.Value(adminKey{}).(bool)
+	return !ok || admin
+}
+
+// Roles returns the roles granted to the subject.
+func (p *Policy) Roles(subject string) []string {
+	if roles, ok := p.subjects[subject]; ok {
+		return roles
+	}
+	return p.defaultRoles
+}
+
+// Allowed reports whether the subject may use the tool.
+func (p *Policy) Allowed(subject string, tool mcp.Tool) bool {
+	for _, role := range p.Roles(subject) {
+		r := p.roles[role]
+		if matchesAny(r.allow, tool) && !matchesAny(r.deny, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedName reports whether the subject may use the tool registered through
+// Guard under name. Tools that were not registered are not allowed.
+func (p *Policy) AllowedName(subject, name string) bool {
+	p.mu.RLock()
+	tool, ok := p.tools[name]
+	p.mu.RUnlock()
+
+	return ok && p.Allowed(subject, tool)
+}
+
+// Filter removes the tools the caller may not use from a tools/list response.
+// It is meant to be installed with server.WithToolFilter.
+func (p *Policy) Filter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	subject := Subject(ctx)
+
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if p.Allowed(subject, tool) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// Guard returns a ToolServer that registers the tools on next with handlers
+// that reject callers the policy does not allow to use them.
+func (p *Policy) Guard(next registry.ToolServer) registry.ToolServer {
+	return guardedServer{policy: p, next: next}
+}
+
+type guardedServer struct {
+	policy *Policy
+	next   registry.ToolServer
+}
+
+func (g guardedServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	g.policy.mu.Lock()
+	g.policy.tools[tool.Name] = tool
+	g.policy.mu.Unlock()
+
+	g.next.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subject := Subject(ctx)
+		if !g.policy.Allowed(subject, tool) {
+			zap.L().Warn(fmt.Sprintf("[Policy] Denied call of %s for %s", tool.Name, subject))
+			return mcp.NewToolResultError(fmt.Sprintf("Caller %s is not allowed to use the tool %s", subject, tool.Name)), nil
+		}
+
+		admin := slices.Contains(g.policy.Roles(subject), AdminRole)
+		return handler(context.WithValue(ctx, adminKey{}, admin), request)
+	})
+}
+
+func matchesAny(patterns []string, tool mcp.Tool) bool {
+	for _, pattern := range patterns {
+		if matches(pattern, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(pattern string, tool mcp.Tool) bool {
+	switch pattern {
+	case SelectorReadOnly:
+		return hint(tool.Annotations.ReadOnlyHint, false)
+	case SelectorNonDestructive:
+		// Per the MCP specification a tool is destructive unless stated otherwise.
+		return hint(tool.Annotations.ReadOnlyHint, false) || !hint(tool.Annotations.DestructiveHint, true)
+	default:
+		matched, err := path.Match(pattern, tool.Name)
+		return err == nil && matched
+	}
+}
+
+func hint(value *bool, fallback bool) bool {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
@@ -0,0 +1,302 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/middleware"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func newTool(name string, readOnly, destructive bool) mcp.Tool {
+	return mcp.NewTool(name, mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		ReadOnlyHint:    mcp.ToBoolPtr(readOnly),
+		DestructiveHint: mcp.ToBoolPtr(destructive),
+	}))
+}
+
+var (
+	listMachines     = newTool("list-machines", true, false)
+	readSubnet       = newTool("read-subnet", true, false)
+	createFabric     = newTool("create-fabric", false, false)
+	deployMachine    = newTool("deploy-machine", false, false)
+	changePowerState = newTool("change-power-state", false, true)
+	powerCycle       = newTool("power-cycle", false, true)
+	deleteSubnet     = newTool("delete-subnet", false, true)
+	powerParameters  = newTool("get-power-parameters", true, false)
+	updateSubnet     = newTool("update-subnet", false, false)
+	cancelJob        = newTool("cancel-job", false, false)
+	unannotated      = mcp.NewTool("unannotated")
+)
+
+func ctxFor(method, subject string) context.Context {
+	return middleware.WithIdentity(context.Background(), middleware.Identity{Subject: subject, Method: method})
+}
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	return path
+}
+
+type recordingServer struct {
+	handlers map[string]server.ToolHandlerFunc
+}
+
+func (r *recordingServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	r.handlers[tool.Name] = handler
+}
+
+func TestPolicy_BuiltinRoles(t *testing.T) {
+	p, err := New(Config{
+		Subjects: map[string][]string{
+			"token:viewer": {"observer"},
+			"token:ops":    {"operator"},
+			"cert:root":    {"admin"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cases := []struct {
+		subject string
+		tool    mcp.Tool
+		allowed bool
+	}{
+		{"token:viewer", listMachines, true},
+		{"token:viewer", readSubnet, true},
+		{"token:viewer", createFabric, false},
+		{"token:viewer", deployMachine, false},
+		{"token:viewer", powerParameters, false},
+		{"token:ops", listMachines, true},
+		{"token:ops", createFabric, false},
+		{"token:ops", updateSubnet, false},
+		{"token:ops", powerParameters, false},
+		{"token:ops", deployMachine, true},
+		{"token:ops", changePowerState, true},
+		{"token:ops", powerCycle, true},
+		{"token:viewer", powerCycle, false},
+		{"token:ops", cancelJob, true},
+		{"token:viewer", cancelJob, false},
+		{"token:ops", deleteSubnet, false},
+		{"token:ops", unannotated, false},
+		{"cert:root", deleteSubnet, true},
+		{"cert:root", unannotated, true},
+		{"token:stranger", listMachines, false},
+		{AnonymousSubject, listMachines, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.subject+"/"+tc.tool.Name, func(t *testing.T) {
+			// Act
+			allowed := p.Allowed(tc.subject, tc.tool)
+
+			// Assert
+			if allowed != tc.allowed {
+				t.Errorf("expected allowed=%v, got %v", tc.allowed, allowed)
+			}
+		})
+	}
+}
+
+func TestPolicy_Selectors(t *testing.T) {
+	p, err := New(Config{
+		Roles: map[string]Role{
+			"reader": {Allow: []string{SelectorReadOnly}},
+			"writer": {Allow: []string{SelectorNonDestructive}},
+		},
+		Subjects: map[string][]string{"token:reader": {"reader"}, "token:writer": {"writer"}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cases := []struct {
+		subject string
+		tool    mcp.Tool
+		allowed bool
+	}{
+		{"token:reader", powerParameters, true},
+		{"token:reader", createFabric, false},
+		{"token:writer", createFabric, true},
+		{"token:writer", deleteSubnet, false},
+		{"token:writer", unannotated, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.subject+"/"+tc.tool.Name, func(t *testing.T) {
+			// Act
+			allowed := p.Allowed(tc.subject, tc.tool)
+
+			// Assert
+			if allowed != tc.allowed {
+				t.Errorf("expected allowed=%v, got %v", tc.allowed, allowed)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("loads custom roles and default roles", func(t *testing.T) {
+		// Arrange
+		path := writePolicy(t, `
+roles:
+  auditor:
+    inherits: [observer]
+    deny: ["read-*"]
+subjects:
+  jwt:alice: [auditor]
+default_roles: [observer]
+`)
+
+		// Act
+		p, err := Load(path)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !p.Allowed("jwt:alice", listMachines) {
+			t.Error("expected auditor to inherit list-machines")
+		}
+		if p.Allowed("jwt:alice", readSubnet) {
+			t.Error("expected auditor to be denied read-subnet")
+		}
+		if !p.Allowed("token:unknown", readSubnet) {
+			t.Error("expected unknown callers to get the default roles")
+		}
+	})
+
+	t.Run("rejects unknown roles", func(t *testing.T) {
+		// Arrange
+		path := writePolicy(t, "subjects:\n  token:ci: [superuser]\n")
+
+		// Act
+		_, err := Load(path)
+
+		// Assert
+		if err == nil {
+			t.Fatal("expected error for unknown role")
+		}
+	})
+
+	t.Run("rejects inheritance cycles", func(t *testing.T) {
+		// Arrange
+		path := writePolicy(t, `
+roles:
+  a:
+    inherits: [b]
+  b:
+    inherits: [a]
+`)
+
+		// Act
+		_, err := Load(path)
+
+		// Assert
+		if err == nil {
+			t.Fatal("expected error for inheritance cycle")
+		}
+	})
+
+	t.Run("rejects unknown fields", func(t *testing.T) {
+		// Arrange
+		path := writePolicy(t, "subject:\n  token:ci: [admin]\n")
+
+		// Act
+		_, err := Load(path)
+
+		// Assert
+		if err == nil {
+			t.Fatal("expected error for unknown field")
+		}
+	})
+}
+
+func TestPolicy_Filter(t *testing.T) {
+	// Arrange
+	p, err := New(Config{Subjects: map[string][]string{"token:viewer": {"observer"}}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Act
+	filtered := p.Filter(ctxFor("token", "viewer"), []mcp.Tool{listMachines, deployMachine, readSubnet, deleteSubnet})
+
+	// Assert
+	if len(filtered) != 2 || filtered[0].Name != "list-machines" || filtered[1].Name != "read-subnet" {
+		t.Errorf("unexpected filtered tools %v", filtered)
+	}
+}
+
+func TestPolicy_Guard(t *testing.T) {
+	// Arrange
+	p, err := New(Config{Subjects: map[string][]string{"token:viewer": {"observer"}}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	recorder := &recordingServer{handlers: map[string]server.ToolHandlerFunc{}}
+	called := false
+	p.Guard(recorder).AddTool(deleteSubnet, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("deleted"), nil
+	})
+
+	t.Run("rejects callers without the required role", func(t *testing.T) {
+		// Act
+		result, err := recorder.handlers["delete-subnet"](ctxFor("token", "viewer"), mcp.CallToolRequest{})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !result.IsError {
+			t.Error("expected an error result")
+		}
+		if called {
+			t.Error("expected the handler not to be called")
+		}
+	})
+}
+
+func TestPolicy_AllowedName(t *testing.T) {
+	// Arrange
+	p, err := New(Config{Subjects: map[string][]string{"token:viewer": {"observer"}, "token:ops": {"admin"}}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	recorder := &recordingServer{handlers: map[string]server.ToolHandlerFunc{}}
+	p.Guard(recorder).AddTool(deleteSubnet, nil)
+
+	cases := []struct {
+		name     string
+		subject  string
+		tool     string
+		expected bool
+	}{
+		{"allows a registered tool granted by a role", "token:ops", "delete-subnet", true},
+		{"rejects a registered tool not granted by a role", "token:viewer", "delete-subnet", false},
+		{"rejects a tool that was not registered", "token:ops", "create-fabric", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			allowed := p.AllowedName(tc.subject, tc.tool)
+
+			// Assert
+			if allowed != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// ReadOnly returns a ToolServer that only registers the tools annotated as
+// read-only on next and silently drops the others.
+func ReadOnly(next ToolServer) ToolServer {
+	return readOnlyServer{next: next}
+}
+
+type readOnlyServer struct {
+	next ToolServer
+}
+
+func (s readOnlyServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint {
+		zap.L().Debug(fmt.Sprintf("Skipping tool %s in read-only mode", tool.Name))
+		return
+	}
+
+	s.next.AddTool(tool, handler)
+}
@@ -0,0 +1,36 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type recordingServer struct {
+	names []string
+}
+
+func (r *recordingServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	r.names = append(r.names, tool.Name)
+}
+
+func TestReadOnly(t *testing.T) {
+	// Arrange
+	recorder := &recordingServer{}
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	}
+	toolServer := ReadOnly(recorder)
+
+	// Act
+	toolServer.AddTool(mcp.NewTool("list-machines", mcp.WithReadOnlyHintAnnotation(true)), handler)
+	toolServer.AddTool(mcp.NewTool("deploy-machine", mcp.WithReadOnlyHintAnnotation(false)), handler)
+	toolServer.AddTool(mcp.NewTool("unannotated"), handler)
+
+	// Assert
+	if len(recorder.names) != 1 || recorder.names[0] != "list-machines" {
+		t.Errorf("expected only list-machines to be registered, got %v", recorder.names)
+	}
+}
@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// RegionArgument is the optional tool argument selecting the MAAS region a call is sent to.
+const RegionArgument = "region"
+
+// WithRegions returns a ToolServer that adds the optional region argument to
+// every tool registered on next. Calls naming a region are sent to it through
+// maas_client.WithRegion; the others go to the default region.
+func WithRegions(next ToolServer, regions *maas_client.Regions) ToolServer {
+	return regionServer{next: next, regions: regions}
+}
+
+type regionServer struct {
+	next    ToolServer
+	regions *maas_client.Regions
+}
+
+func (s regionServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	mcp.WithString(
+		RegionArgument,
+		mcp.Enum(s.regions.Names()...),
+		mcp.Description(fmt.Sprintf("The MAAS region to send the request to. Defaults to %s.", s.regions.Default())),
+	)(&tool)
+
+	s.next.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		region := request.GetString(RegionArgument, "")
+		if region == "" {
+			return handler(ctx, request)
+		}
+
+		if !s.regions.Has(region) {
+			errMsg := fmt.Sprintf("Unknown MAAS region %q, expected one of %s", region, strings.Join(s.regions.Names(), ", "))
+			zap.L().Error(fmt.Sprintf("[Regions] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		return handler(maas_client.WithRegion(ctx, region), request)
+	})
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type capturingServer struct {
+	tool    mcp.Tool
+	handler server.ToolHandlerFunc
+}
+
+func (c *capturingServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	c.tool = tool
+	c.handler = handler
+}
+
+func TestWithRegions(t *testing.T) {
+	clients := map[string]*maas_client.MAASClient{}
+	for _, name := range []string{"lab", "prod"} {
+		client, err := maas_client.NewMAASClient(maas_client.Config{BaseURL: "http://" + name + ".example.com:5240", APIKey: "consumer:token:secret"})
+		if err != nil {
+			t.Fatalf("failed to create the client: %v", err)
+		}
+		clients[name] = client
+	}
+	regions, err := maas_client.NewRegions("lab", clients)
+	if err != nil {
+		t.Fatalf("failed to create the regions: %v", err)
+	}
+
+	capture := &capturingServer{}
+	var selected string
+	WithRegions(capture, regions).AddTool(mcp.NewTool("list-machines"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		selected, _ = maas_client.RegionFromContext(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	t.Run("adds the region argument", func(t *testing.T) {
+		// Assert
+		if _, ok := capture.tool.InputSchema.Properties[RegionArgument]; !ok {
+			t.Errorf("expected a region argument, got %v", capture.tool.InputSchema.Properties)
+		}
+	})
+
+	cases := []struct {
+		name     string
+		region   string
+		expected string
+		isError  bool
+	}{
+		{"no region", "", "", false},
+		{"known region", "prod", "prod", false},
+		{"unknown region", "staging", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			selected = ""
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{}
+			if tc.region != "" {
+				request.Params.Arguments = map[string]any{RegionArgument: tc.region}
+			}
+
+			// Act
+			result, err := capture.handler(context.Background(), request)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %v", tc.isError, result.IsError)
+			}
+			if selected != tc.expected {
+				t.Errorf("expected region %q to be selected, got %q", tc.expected, selected)
+			}
+		})
+	}
+}
@@ -1,7 +1,16 @@
 package registry
 
-import "github.com/mark3labs/mcp-go/server"
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolServer is the part of the MCP server the registries add their tools to.
+// It is satisfied by *server.MCPServer and by the wrappers that sit in front of it.
+type ToolServer interface {
+	AddTool(tool mcp.Tool, handler server.ToolHandlerFunc)
+}
 
 type Registry interface {
-	Register(mcpServer *server.MCPServer)
+	Register(mcpServer ToolServer)
 }
@@ -49,7 +49,9 @@ func NewTemplateExecutor(store *TemplateStore, templateID string, parameters str
 }
 
 // Execute renders the template with parameters and returns base64 encoded user data
-func (e *TemplateExecutor) Execute() (string, error) {
+// for the machine with the given id. The id fills the MachineId variable of the
+// injected scripts, their other variables are read from the environment.
+func (e *TemplateExecutor) Execute(machineID string) (string, error) {
 	content, err := e.store.GetContent(e.templateID)
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("Template not found: %s, err=%v", e.templateID, err))
@@ -68,7 +70,7 @@ func (e *TemplateExecutor) Execute() (string, error) {
 		return "", err
 	}
 
-	userData, err := e.injectScripts(buf.Bytes())
+	userData, err := e.injectScripts(buf.Bytes(), map[string]string{"MachineId": machineID})
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("Failed to inject scripts into user data err=%v", err))
 		return "", err
@@ -92,7 +94,9 @@ type CloudConfig struct {
 	Other      map[string]any `yaml:",inline"`
 }
 
-func (e *TemplateExecutor) injectScripts(userData []byte) ([]byte, error) {
+// injectScripts adds the embedded scripts to the user data. Their variables
+// are taken from vars, or from the environment when missing there.
+func (e *TemplateExecutor) injectScripts(userData []byte, vars map[string]string) ([]byte, error) {
 	// Read script files from the embedded filesystem
 	entries, err := e.scriptsFS.ReadDir("scripts")
 	if err != nil {
@@ -132,6 +136,9 @@ func (e *TemplateExecutor) injectScripts(userData []byte) ([]byte, error) {
 			}
 			varName := submatches[1]
 
+			if value := vars[varName]; value != "" {
+				return value
+			}
 			envName := toEnvVarName(varName)
 			if envValue := os.Getenv(envName); envValue != "" {
 				return envValue
@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestNewTemplateExecutor(t *testing.T) {
@@ -148,7 +150,7 @@ func TestTemplateExecutor_Execute(t *testing.T) {
 		executor, _ := NewTemplateExecutor(store, "execute_test", `{}`)
 
 		// Act
-		result, err := executor.Execute()
+		result, err := executor.Execute("abc123")
 
 		// Assert
 		if err != nil {
@@ -183,7 +185,7 @@ func TestTemplateExecutor_Execute(t *testing.T) {
 		executor, _ := NewTemplateExecutor(store, "param_sub_test", `{"ServerName": "my-server"}`)
 
 		// Act
-		result, err := executor.Execute()
+		result, err := executor.Execute("abc123")
 
 		// Assert
 		if err != nil {
@@ -210,7 +212,7 @@ func TestTemplateExecutor_Execute(t *testing.T) {
 		executor, _ := NewTemplateExecutor(store, "files_test", `{}`)
 
 		// Act
-		result, err := executor.Execute()
+		result, err := executor.Execute("abc123")
 
 		// Assert
 		if err != nil {
@@ -235,7 +237,7 @@ func TestTemplateExecutor_Execute(t *testing.T) {
 		executor, _ := NewTemplateExecutor(store, "packages_test", `{}`)
 
 		// Act
-		result, err := executor.Execute()
+		result, err := executor.Execute("abc123")
 
 		// Assert
 		if err != nil {
@@ -267,7 +269,7 @@ func TestTemplateExecutor_Execute(t *testing.T) {
 		executor, _ := NewTemplateExecutor(store, "commands_test", `{}`)
 
 		// Act
-		result, err := executor.Execute()
+		result, err := executor.Execute("abc123")
 
 		// Assert
 		if err != nil {
@@ -281,6 +283,44 @@ func TestTemplateExecutor_Execute(t *testing.T) {
 	})
 }
 
+func TestTemplateExecutor_ExecuteMachineID(t *testing.T) {
+	// Arrange
+	store := NewTemplateStore()
+	_ = store.Create(GenericTemplate{Id: "machine_id_test", Name: "Machine ID Test", Description: "Test machine ids"})
+	executor, _ := NewTemplateExecutor(store, "machine_id_test", `{}`)
+	t.Setenv("MACHINE_ID", "envid1")
+
+	// Act
+	first, firstErr := executor.Execute("aaa111")
+	second, secondErr := executor.Execute("bbb222")
+
+	// Assert
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("expected no error, got %v and %v", firstErr, secondErr)
+	}
+	for machineID, result := range map[string]string{"aaa111": first, "bbb222": second} {
+		decoded, _ := base64.StdEncoding.DecodeString(result)
+		var config CloudConfig
+		if err := yaml.Unmarshal(decoded, &config); err != nil {
+			t.Fatalf("failed to parse the user data: %v", err)
+		}
+
+		found := false
+		for _, file := range config.WriteFiles {
+			script, _ := base64.StdEncoding.DecodeString(file.Content)
+			if strings.Contains(string(script), machineID) {
+				found = true
+			}
+			if strings.Contains(string(script), "envid1") {
+				t.Errorf("expected script %s not to use the MACHINE_ID environment variable", file.Path)
+			}
+		}
+		if !found {
+			t.Errorf("expected the scripts to use machine id %s", machineID)
+		}
+	}
+}
+
 func TestToEnvVarName(t *testing.T) {
 	tests := []struct {
 		name     string
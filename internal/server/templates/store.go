@@ -2,13 +2,27 @@ package templates
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	descriptionFile = "description.json"
+	contentFile     = "template.yaml"
 )
 
 //go:embed template/*
@@ -18,14 +32,20 @@ var (
 	once        sync.Once
 )
 
-// TemplateStore manages both embedded meta-templates and runtime templates
+var templateIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// TemplateStore manages both embedded meta-templates and runtime templates.
+// When dir is set, runtime templates are also persisted on disk, one folder
+// per template holding description.json and template.yaml.
 type TemplateStore struct {
-	metaFS    embed.FS
-	runtime   map[string]Template
-	runtimeMu sync.RWMutex
+	metaFS      embed.FS
+	runtime     map[string]Template
+	runtimeMu   sync.RWMutex
+	dir         string
+	fingerprint string
 }
 
-// NewTemplateStore creates a new TemplateStore with the embedded meta-templates
+// NewTemplateStore creates a new in-memory TemplateStore with the embedded meta-templates
 func NewTemplateStore() *TemplateStore {
 	return &TemplateStore{
 		metaFS:  metaTemplateFS,
@@ -33,6 +53,28 @@ func NewTemplateStore() *TemplateStore {
 	}
 }
 
+// NewPersistentTemplateStore creates a TemplateStore backed by dir and loads
+// every template already present in it. The directory is created if missing.
+func NewPersistentTemplateStore(dir string) (*TemplateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create template directory %s: %w", dir, err)
+	}
+
+	store := NewTemplateStore()
+	store.dir = dir
+
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Dir returns the directory backing the store, or an empty string for in-memory stores
+func (s *TemplateStore) Dir() string {
+	return s.dir
+}
+
 // ListIDs returns all runtime template IDs
 func (s *TemplateStore) ListIDs() []string {
 	s.runtimeMu.RLock()
@@ -141,6 +183,12 @@ func (s *TemplateStore) Create(gt GenericTemplate) error {
 		return fmt.Errorf("failed to parse generated description: %w", err)
 	}
 
+	if s.dir != "" {
+		if err := s.writeTemplate(gt.Id, descContent, yamlContent); err != nil {
+			return err
+		}
+	}
+
 	s.runtime[gt.Id] = Template{
 		Description: desc,
 		Content:     yamlContent,
@@ -158,10 +206,210 @@ func (s *TemplateStore) Delete(templateID string) error {
 		return fmt.Errorf("template %s not found", templateID)
 	}
 
+	if s.dir != "" {
+		templateDir, err := s.templateDir(templateID)
+		if err != nil {
+			return err
+		}
+
+		if err := os.RemoveAll(templateDir); err != nil {
+			return fmt.Errorf("failed to remove template %s from disk: %w", templateID, err)
+		}
+
+		s.fingerprint, _ = s.computeFingerprint()
+	}
+
 	delete(s.runtime, templateID)
 	return nil
 }
 
+// Reload replaces the runtime templates with the ones found on disk. Folders
+// missing one of the template files or holding an invalid description are
+// skipped. It is a no-op for in-memory stores.
+func (s *TemplateStore) Reload() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	s.runtimeMu.Lock()
+	defer s.runtimeMu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read template directory %s: %w", s.dir, err)
+	}
+
+	loaded := make(map[string]Template, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || !templateIDRegex.MatchString(entry.Name()) {
+			continue
+		}
+
+		t, err := s.readTemplate(entry.Name())
+		if err != nil {
+			zap.L().Warn(fmt.Sprintf("Skipping template %s: %v", entry.Name(), err))
+			continue
+		}
+
+		loaded[entry.Name()] = t
+	}
+
+	fingerprint, err := s.computeFingerprint()
+	if err != nil {
+		return err
+	}
+
+	s.runtime = loaded
+	s.fingerprint = fingerprint
+
+	zap.L().Info(fmt.Sprintf("Loaded %d templates from %s", len(loaded), s.dir))
+	return nil
+}
+
+// Watch polls the template directory every interval and reloads the store
+// when files are added, removed or modified. It blocks until ctx is done, and
+// returns right away for an in-memory store or an interval that is not
+// positive.
+func (s *TemplateStore) Watch(ctx context.Context, interval time.Duration) {
+	if s.dir == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fingerprint, err := s.computeFingerprint()
+			if err != nil {
+				zap.L().Warn(fmt.Sprintf("Failed to scan template directory %s: %v", s.dir, err))
+				continue
+			}
+
+			s.runtimeMu.RLock()
+			changed := fingerprint != s.fingerprint
+			s.runtimeMu.RUnlock()
+
+			if !changed {
+				continue
+			}
+
+			if err := s.Reload(); err != nil {
+				zap.L().Error(fmt.Sprintf("Failed to reload templates from %s: %v", s.dir, err))
+			}
+		}
+	}
+}
+
+// templateDir returns the folder holding the files of a template, rejecting
+// ids that would escape the store directory.
+func (s *TemplateStore) templateDir(templateID string) (string, error) {
+	if !templateIDRegex.MatchString(templateID) {
+		return "", fmt.Errorf("invalid template id %q", templateID)
+	}
+	return filepath.Join(s.dir, templateID), nil
+}
+
+// readTemplate loads a single template folder from disk
+func (s *TemplateStore) readTemplate(templateID string) (Template, error) {
+	templateDir, err := s.templateDir(templateID)
+	if err != nil {
+		return Template{}, err
+	}
+
+	descContent, err := os.ReadFile(filepath.Join(templateDir, descriptionFile))
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to read %s: %w", descriptionFile, err)
+	}
+
+	yamlContent, err := os.ReadFile(filepath.Join(templateDir, contentFile))
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to read %s: %w", contentFile, err)
+	}
+
+	var desc Description
+	if err := json.Unmarshal(descContent, &desc); err != nil {
+		return Template{}, fmt.Errorf("failed to parse %s: %w", descriptionFile, err)
+	}
+
+	// The folder name is the source of truth for the template id.
+	desc.ID = templateID
+
+	return Template{
+		Description: desc,
+		Content:     string(yamlContent),
+	}, nil
+}
+
+// writeTemplate persists the generated files of a template. Files are written
+// to temporary names first so a concurrent reload never sees half a template.
+func (s *TemplateStore) writeTemplate(templateID, descContent, yamlContent string) error {
+	templateDir, err := s.templateDir(templateID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create folder for template %s: %w", templateID, err)
+	}
+
+	files := map[string]string{
+		descriptionFile: descContent,
+		contentFile:     yamlContent,
+	}
+
+	for name, content := range files {
+		target := filepath.Join(templateDir, name)
+		if err := os.WriteFile(target+".tmp", []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s for template %s: %w", name, templateID, err)
+		}
+		if err := os.Rename(target+".tmp", target); err != nil {
+			return fmt.Errorf("failed to write %s for template %s: %w", name, templateID, err)
+		}
+	}
+
+	s.fingerprint, _ = s.computeFingerprint()
+	return nil
+}
+
+// computeFingerprint summarises the name, size and modification time of every
+// template file so that changes on disk can be detected cheaply.
+func (s *TemplateStore) computeFingerprint() (string, error) {
+	var parts []string
+
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || (d.Name() != descriptionFile && d.Name() != contentFile) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to scan template directory %s: %w", s.dir, err)
+	}
+
+	sort.Strings(parts)
+	return strings.Join(parts, "|"), nil
+}
+
 // executeMetaTemplate executes a meta-template file against the generic template data
 func (s *TemplateStore) executeMetaTemplate(filename string, gt GenericTemplate) (string, error) {
 	content, err := s.metaFS.ReadFile("template/" + filename)
@@ -230,6 +478,24 @@ func MustTemplateStore() *TemplateStore {
 	return getTemplateStore()
 }
 
+// InitTemplateStore makes the global store persistent, backed by dir. It must
+// be called before the first call to MustTemplateStore.
+func InitTemplateStore(dir string) (*TemplateStore, error) {
+	var err error
+	initialized := false
+
+	once.Do(func() {
+		initialized = true
+		globalStore, err = NewPersistentTemplateStore(dir)
+	})
+
+	if !initialized {
+		return nil, fmt.Errorf("template store already initialized")
+	}
+
+	return globalStore, err
+}
+
 func RetrieveExecutor(templateID string, parameters string) (*TemplateExecutor, error) {
-	return NewTemplateExecutor(globalStore, templateID, parameters)
+	return NewTemplateExecutor(getTemplateStore(), templateID, parameters)
 }
@@ -1,10 +1,14 @@
 package templates
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewTemplateStore(t *testing.T) {
@@ -533,3 +537,228 @@ func TestMustTemplateStore(t *testing.T) {
 		t.Error("expected MustTemplateStore to return the same instance")
 	}
 }
+
+func writeTemplateFiles(t *testing.T, dir, id string) {
+	t.Helper()
+
+	templateDir := filepath.Join(dir, id)
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("failed to create template folder: %v", err)
+	}
+
+	description := fmt.Sprintf(`{"id": %q, "name": "On Disk", "description": "Loaded from disk", "parameters": {}}`, id)
+	if err := os.WriteFile(filepath.Join(templateDir, "description.json"), []byte(description), 0o644); err != nil {
+		t.Fatalf("failed to write description.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "template.yaml"), []byte("#cloud-config\n"), 0o644); err != nil {
+		t.Fatalf("failed to write template.yaml: %v", err)
+	}
+}
+
+func TestNewPersistentTemplateStore(t *testing.T) {
+	t.Run("loads templates already on disk", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		writeTemplateFiles(t, dir, "on_disk")
+
+		// Act
+		store, err := NewPersistentTemplateStore(dir)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !store.Exists("on_disk") {
+			t.Error("expected template from disk to be loaded")
+		}
+		if store.Dir() != dir {
+			t.Errorf("expected dir %s, got %s", dir, store.Dir())
+		}
+	})
+
+	t.Run("skips incomplete template folders", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "incomplete"), 0o755); err != nil {
+			t.Fatalf("failed to create folder: %v", err)
+		}
+
+		// Act
+		store, err := NewPersistentTemplateStore(dir)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if store.Exists("incomplete") {
+			t.Error("expected incomplete template to be skipped")
+		}
+	})
+
+	t.Run("creates missing directory", func(t *testing.T) {
+		// Arrange
+		dir := filepath.Join(t.TempDir(), "nested", "templates")
+
+		// Act
+		_, err := NewPersistentTemplateStore(dir)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected directory to be created, got %v", err)
+		}
+	})
+}
+
+func TestTemplateStore_Persistence(t *testing.T) {
+	t.Run("create writes template files to disk", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, _ := NewPersistentTemplateStore(dir)
+
+		// Act
+		err := store.Create(GenericTemplate{Id: "persisted", Name: "Persisted", Description: "Persisted template"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for _, name := range []string{"description.json", "template.yaml"} {
+			if _, err := os.Stat(filepath.Join(dir, "persisted", name)); err != nil {
+				t.Errorf("expected %s to exist, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("templates survive a restart", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, _ := NewPersistentTemplateStore(dir)
+		_ = store.Create(GenericTemplate{Id: "survivor", Name: "Survivor", Description: "Survives restarts", Packages: []string{"vim"}})
+
+		// Act
+		restarted, err := NewPersistentTemplateStore(dir)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		content, err := restarted.GetContent("survivor")
+		if err != nil {
+			t.Fatalf("expected template to survive restart, got %v", err)
+		}
+		if !strings.Contains(content, "vim") {
+			t.Error("expected persisted content to contain the package list")
+		}
+	})
+
+	t.Run("delete removes template folder", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, _ := NewPersistentTemplateStore(dir)
+		_ = store.Create(GenericTemplate{Id: "removed", Name: "Removed", Description: "Removed template"})
+
+		// Act
+		err := store.Delete("removed")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "removed")); !os.IsNotExist(err) {
+			t.Errorf("expected template folder to be removed, got %v", err)
+		}
+	})
+
+	t.Run("rejects ids escaping the directory", func(t *testing.T) {
+		// Arrange
+		store, _ := NewPersistentTemplateStore(t.TempDir())
+
+		// Act
+		err := store.Create(GenericTemplate{Id: "../escape", Name: "Escape", Description: "Escape"})
+
+		// Assert
+		if err == nil {
+			t.Fatal("expected error for invalid template id")
+		}
+	})
+}
+
+func TestTemplateStore_Reload(t *testing.T) {
+	t.Run("picks up templates added on disk", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		store, _ := NewPersistentTemplateStore(dir)
+		writeTemplateFiles(t, dir, "added_later")
+
+		// Act
+		err := store.Reload()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !store.Exists("added_later") {
+			t.Error("expected reloaded store to contain the new template")
+		}
+	})
+
+	t.Run("is a no-op for in-memory stores", func(t *testing.T) {
+		// Arrange
+		store := NewTemplateStore()
+		_ = store.Create(GenericTemplate{Id: "memory", Name: "Memory", Description: "In memory"})
+
+		// Act
+		err := store.Reload()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !store.Exists("memory") {
+			t.Error("expected in-memory template to be kept")
+		}
+	})
+}
+
+func TestTemplateStore_Watch(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	store, _ := NewPersistentTemplateStore(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx, 10*time.Millisecond)
+
+	// Act
+	writeTemplateFiles(t, dir, "watched")
+
+	// Assert
+	deadline := time.Now().Add(2 * time.Second)
+	for !store.Exists("watched") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected watcher to load the template written on disk")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTemplateStore_WatchWithoutInterval(t *testing.T) {
+	// Arrange
+	store, _ := NewPersistentTemplateStore(t.TempDir())
+	done := make(chan struct{})
+
+	// Act
+	go func() {
+		defer close(done)
+		store.Watch(context.Background(), 0)
+	}()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Watch to return right away without interval")
+	}
+}
@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// withConstraints adds the allocation constraints to a tool.
+func withConstraints(options ...mcp.ToolOption) []mcp.ToolOption {
+	return append([]mcp.ToolOption{
+		mcp.WithString(
+			"name",
+			mcp.Description("The hostname of the machine to allocate."),
+		),
+		mcp.WithString(
+			"system_id",
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine to allocate."),
+		),
+		mcp.WithString(
+			"arch",
+			mcp.Description("The architecture of the machine, like amd64 or arm64/generic."),
+		),
+		mcp.WithNumber(
+			"cpu_count",
+			mcp.Min(1),
+			mcp.Description("The minimum number of CPU cores."),
+		),
+		mcp.WithNumber(
+			"mem",
+			mcp.Min(1),
+			mcp.Description("The minimum memory in MiB."),
+		),
+		mcp.WithArray(
+			"tags",
+			mcp.WithStringItems(),
+			mcp.Description("Tags the machine must have."),
+		),
+		mcp.WithArray(
+			"not_tags",
+			mcp.WithStringItems(),
+			mcp.Description("Tags the machine must not have."),
+		),
+		mcp.WithString(
+			"zone",
+			mcp.Description("The zone of the machine."),
+		),
+		mcp.WithString(
+			"pool",
+			mcp.Description("The resource pool of the machine."),
+		),
+		mcp.WithString(
+			"storage",
+			mcp.Description("The disks of the machine as comma separated sizes in GB with optional labels and tags, like root:100(ssd),data:500."),
+		),
+		mcp.WithString(
+			"interfaces",
+			mcp.Description("The network interfaces of the machine in the MAAS label syntax, like eth0:space=storage;eth1:subnet=10.0.0.0/24."),
+		),
+	}, options...)
+}
+
+// allocateParams reads the allocation constraints of the request. Protected
+// machines are always excluded.
+func allocateParams(request mcp.CallToolRequest) (maas_api.AllocateParams, error) {
+	params := maas_api.AllocateParams{
+		Name:         request.GetString("name", ""),
+		SystemID:     request.GetString("system_id", ""),
+		Architecture: request.GetString("arch", ""),
+		CPUCount:     request.GetInt("cpu_count", 0),
+		Memory:       request.GetInt("mem", 0),
+		Tags:         request.GetStringSlice("tags", nil),
+		NotTags:      request.GetStringSlice("not_tags", nil),
+		Zone:         request.GetString("zone", ""),
+		Pool:         request.GetString("pool", ""),
+		Storage:      request.GetString("storage", ""),
+		Interfaces:   request.GetString("interfaces", ""),
+	}
+
+	if slices.Contains(params.Tags, maas_api.ProtectedTag) {
+		return params, fmt.Errorf("cannot allocate machines with the %s tag", maas_api.ProtectedTag)
+	}
+	if !slices.Contains(params.NotTags, maas_api.ProtectedTag) {
+		params.NotTags = append(params.NotTags, maas_api.ProtectedTag)
+	}
+	return params, nil
+}
+
+type AllocateMachine struct {
+	Client maas_client.Client
+}
+
+func (AllocateMachine) Create() mcp.Tool {
+	return mcp.NewTool(
+		"allocate-machine",
+		withConstraints(
+			mcp.WithToolAnnotation(CreateToolAnnotation("Allocate Machine", false, false, false, true)),
+			mcp.WithDescription("Allocate a ready machine matching the hardware constraints, so that it can be deployed with deploy-machine. Protected machines are never allocated."),
+		)...,
+	)
+}
+
+func (a AllocateMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	params, err := allocateParams(request)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[AllocateMachine] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info("[AllocateMachine] Allocating a machine...")
+	machine, err := maas_api.New(a.Client).AllocateMachine(ctx, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to allocate a machine err=%v", err)
+		zap.L().Error(fmt.Sprintf("[AllocateMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(convertToMachine(machine))
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[AllocateMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type DeployByConstraints struct {
+	Client maas_client.Client
+	Jobs   *jobs.Manager
+}
+
+func (d DeployByConstraints) Create() mcp.Tool {
+	return mcp.NewTool(
+		"deploy-by-constraints",
+		withConstraints(withDeployOptions(
+			mcp.WithString(
+				"templateId",
+				mcp.Required(),
+				mcp.Pattern("^[0-9a-z-_]*$"),
+				mcp.Description("The id of the template to use for deployment."),
+			),
+			mcp.WithString(
+				"templateParameters",
+				mcp.Required(),
+				mcp.Description("The parameters that will be used to replace the values in the template. They are represented as a valid JSON object. If the template does not require parameters enter an empty JSON dictionary {}."),
+			),
+			withAsync(d.Jobs, "Return a job right away, then allocate and deploy the machine and wait for it to be deployed in the background. Follow the job with get-job."),
+			mcp.WithNumber(
+				"timeout",
+				mcp.DefaultNumber(3600.0),
+				mcp.Description("With async, the timeout in seconds of the wait for the machine to be deployed. Default: 3600s"),
+			),
+			mcp.WithToolAnnotation(CreateToolAnnotation("Deploy By Constraints", false, false, false, true)),
+			mcp.WithDescription("Allocate a ready machine matching the hardware constraints and deploy it with a template. The machine is released when the deployment cannot start. With async, also wait for the machine to be deployed in a background job."),
+		)...)...,
+	)
+}
+
+func (d DeployByConstraints) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	templateID, err := request.RequireString("templateId")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] Required parameter templateId not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if _, err := request.RequireString("templateParameters"); err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] Required parameter templateParameters not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if !templates.MustTemplateStore().Exists(templateID) {
+		errMsg = fmt.Sprintf("Template %s does not exist", templateID)
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if runAsync(ctx, d.Jobs, request) {
+		return submitJob(ctx, d.Jobs, "deploy-by-constraints", request, "DeployByConstraints")
+	}
+
+	allocated, err := callTool(ctx, AllocateMachine{Client: d.Client}.Handle, request)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	machineID, err := allocatedMachine(allocated)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	deployed, err := d.deploy(ctx, machineID, request)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var machine maas_api.Machine
+	if err := json.Unmarshal([]byte(deployed), &machine); err != nil {
+		errMsg = fmt.Sprintf("Failed to decode the deployed machine err=%v", err)
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(convertToMachine(machine))
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[DeployByConstraints] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runJob allocates a machine, deploys it, then waits for it to be deployed.
+func (d DeployByConstraints) runJob(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error) {
+	allocated, err := toolStep(ctx, job, "allocate", AllocateMachine{Client: d.Client}.Handle, request)
+	if err != nil {
+		return "", err
+	}
+
+	machineID, err := allocatedMachine(allocated)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = job.Step(ctx, "deploy", func(ctx context.Context) (string, error) {
+		return d.deploy(ctx, machineID, request)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	wait := WaitForMachineStatus{Client: d.Client}
+	return toolStep(ctx, job, "wait", wait.Handle, waitRequest(machineID, "deployed", request.GetFloat("timeout", 3600.0)))
+}
+
+// deploy deploys the allocated machine with the template and the deploy
+// options of the request, and returns the machine. The machine is released
+// when the deployment cannot start, so that it is not left allocated.
+func (d DeployByConstraints) deploy(ctx context.Context, machineID string, request mcp.CallToolRequest) (string, error) {
+	arguments := map[string]any{
+		"machineId":          machineID,
+		"templateId":         request.GetString("templateId", ""),
+		"templateParameters": request.GetString("templateParameters", ""),
+	}
+	for _, key := range deployOptionArguments {
+		if value, ok := request.GetArguments()[key]; ok {
+			arguments[key] = value
+		}
+	}
+	deploy := toolRequest("deploy-machine", arguments)
+
+	deployed, err := callTool(ctx, DeployMachine{Client: d.Client}.Handle, deploy)
+	if err == nil {
+		return deployed, nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeployByConstraints] Releasing machine with id %s after the failed deployment...", machineID))
+	if _, releaseErr := maas_api.New(d.Client).ReleaseMachine(ctx, machineID, maas_api.ReleaseParams{Comment: "Deployment failed to start"}); releaseErr != nil {
+		return "", fmt.Errorf("%v, and releasing the machine with id %s failed err=%v", err, machineID, releaseErr)
+	}
+	return "", fmt.Errorf("%v, the machine with id %s was released", err, machineID)
+}
+
+// allocatedMachine returns the id of the machine in the output of
+// allocate-machine.
+func allocatedMachine(allocated string) (string, error) {
+	var machine Machine
+	if err := json.Unmarshal([]byte(allocated), &machine); err != nil || machine.SystemID == "" {
+		return "", fmt.Errorf("failed to decode the allocated machine: %s", allocated)
+	}
+	return machine.SystemID, nil
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+)
+
+// newAllocationFake returns a fake with ready machines of different sizes.
+func newAllocationFake(t *testing.T) *fakemaas.Server {
+	t.Helper()
+
+	fake := fakemaas.Start(t)
+	fake.TransitionReads = 0
+	fake.AddMachine(fakemaas.Machine{SystemID: "sml001", Hostname: "small", CPUCount: 2, Memory: 4096, Storage: 100000})
+	fake.AddMachine(fakemaas.Machine{SystemID: "big001", Hostname: "big", CPUCount: 32, Memory: 131072, Storage: 2000000, TagNames: []string{"gpu"}, Zone: "edge"})
+	fake.AddMachine(fakemaas.Machine{SystemID: "arm001", Hostname: "arm", Architecture: "arm64/generic", CPUCount: 8, Memory: 16384, Storage: 500000})
+	fake.AddMachine(fakemaas.Machine{SystemID: "prt001", Hostname: "protected", CPUCount: 64, Memory: 262144, TagNames: []string{"protected"}})
+	fake.AddMachine(fakemaas.Machine{SystemID: "dep001", Hostname: "deployed", Status: fakemaas.StatusDeployed, CPUCount: 64})
+	return fake
+}
+
+func TestAllocateMachine(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  string
+	}{
+		{"cpu and memory", map[string]any{"cpu_count": 16, "mem": 65536}, "big001"},
+		{"architecture", map[string]any{"arch": "arm64"}, "arm001"},
+		{"tags", map[string]any{"tags": []any{"gpu"}}, "big001"},
+		{"not tags", map[string]any{"cpu_count": 8, "not_tags": []any{"gpu"}}, "arm001"},
+		{"zone", map[string]any{"zone": "edge"}, "big001"},
+		{"storage", map[string]any{"storage": "root:200,data:200(ssd)"}, "big001"},
+		{"name", map[string]any{"name": "small"}, "sml001"},
+		{"system id", map[string]any{"system_id": "arm001"}, "arm001"},
+		{"protected machines are excluded", map[string]any{"cpu_count": 64}, ""},
+		{"deployed machines are excluded", map[string]any{"system_id": "dep001"}, ""},
+		{"protected tag is refused", map[string]any{"tags": []any{"protected"}}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := newAllocationFake(t)
+
+			// Act
+			result := fakemaas.CallTool(t, AllocateMachine{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if tc.expected == "" {
+				if !result.IsError {
+					t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+				}
+				if got, _ := fake.Machine("prt001"); got.Status != fakemaas.StatusReady {
+					t.Errorf("expected the protected machine not to be allocated, got %s", got.Status)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var machine Machine
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &machine); err != nil {
+				t.Fatalf("expected a machine, got %v", err)
+			}
+			if machine.SystemID != tc.expected || machine.StatusName != fakemaas.StatusAllocated {
+				t.Errorf("expected %s to be allocated, got %s %s", tc.expected, machine.SystemID, machine.StatusName)
+			}
+		})
+	}
+}
+
+func TestDeployByConstraints(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	store := templates.MustTemplateStore()
+	if err := store.Create(templates.GenericTemplate{Id: "deploy_by_constraints_test", Name: "Deploy By Constraints Test", Description: "Test deployment"}); err != nil {
+		t.Fatalf("failed to create the template: %v", err)
+	}
+	defer store.Delete("deploy_by_constraints_test")
+
+	deploy := map[string]any{"cpu_count": 8, "not_tags": []any{"gpu"}, "templateId": "deploy_by_constraints_test", "templateParameters": "{}"}
+
+	t.Run("allocates and deploys", func(t *testing.T) {
+		// Arrange
+		fake := newAllocationFake(t)
+
+		// Act
+		result := fakemaas.CallTool(t, DeployByConstraints{Client: fake.Client()}.Handle, deploy)
+
+		// Assert
+		if result.IsError {
+			t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+		}
+		if got, _ := fake.Machine("arm001"); got.Status != fakemaas.StatusDeployed {
+			t.Errorf("expected the arm machine to be deployed, got %s", got.Status)
+		}
+	})
+
+	t.Run("releases the machine when the deployment fails", func(t *testing.T) {
+		// Arrange
+		fake := newAllocationFake(t)
+		fake.Fail("POST", "/MAAS/api/2.0/machines/arm001/op-deploy", 500)
+
+		// Act
+		result := fakemaas.CallTool(t, DeployByConstraints{Client: fake.Client()}.Handle, deploy)
+
+		// Assert
+		if !result.IsError || !strings.Contains(fakemaas.ResultText(t, result), "was released") {
+			t.Errorf("expected an error result saying the machine was released, got %s", fakemaas.ResultText(t, result))
+		}
+		if got, _ := fake.Machine("arm001"); got.Status != fakemaas.StatusReady {
+			t.Errorf("expected the arm machine to be released, got %s", got.Status)
+		}
+	})
+
+	t.Run("refuses unknown templates before allocating", func(t *testing.T) {
+		// Arrange
+		fake := newAllocationFake(t)
+
+		// Act
+		result := fakemaas.CallTool(t, DeployByConstraints{Client: fake.Client()}.Handle, map[string]any{"templateId": "missing", "templateParameters": "{}"})
+
+		// Assert
+		if !result.IsError {
+			t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+		if _, ok := fake.LastRequest("POST"); ok {
+			t.Errorf("expected no machine to be allocated")
+		}
+	})
+
+	t.Run("runs as a job", func(t *testing.T) {
+		// Arrange
+		fake := newAllocationFake(t)
+		manager := newTestJobManager(t)
+		handlers := fakemaas.Handlers(Machines{Client: fake.Client(), Jobs: manager}, Jobs{Manager: manager})
+		arguments := map[string]any{"async": true, "timeout": 5.0}
+		for key, value := range deploy {
+			arguments[key] = value
+		}
+
+		// Act
+		result := fakemaas.CallTool(t, handlers["deploy-by-constraints"], arguments)
+		manager.Wait()
+
+		// Assert
+		var submitted jobs.Job
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &submitted); err != nil {
+			t.Fatalf("expected a job, got %v", err)
+		}
+		job, err := manager.Get(submitted.ID)
+		if err != nil {
+			t.Fatalf("expected the job to be stored, got %v", err)
+		}
+		if job.State != jobs.StateSucceeded || len(job.Steps) != 3 {
+			t.Errorf("expected the job to allocate, deploy and wait, got %+v", job)
+		}
+		if got, _ := fake.Machine("arm001"); got.Status != fakemaas.StatusDeployed {
+			t.Errorf("expected the arm machine to be deployed, got %s", got.Status)
+		}
+	})
+}
@@ -0,0 +1,353 @@
+package boot_resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// BootImage is an imported image in the output of list-boot-resources.
+type BootImage struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Architectures maps the architectures of the image to the
+	// subarchitectures, the kernels, they boot.
+	Architectures map[string][]string `json:"architectures"`
+}
+
+// BootSourceSelections is a boot source with its selections.
+type BootSourceSelections struct {
+	ID         int                            `json:"id"`
+	URL        string                         `json:"url"`
+	Selections []maas_api.BootSourceSelection `json:"selections"`
+}
+
+// ImportResult is the output of import-boot-resources.
+type ImportResult struct {
+	SelectionAdded *maas_api.BootSourceSelection `json:"selection_added,omitempty"`
+	Message        string                        `json:"message"`
+}
+
+// ImportStatus is the output of get-boot-resources-import-status.
+type ImportStatus struct {
+	Importing bool            `json:"importing"`
+	Complete  int             `json:"complete"`
+	Total     int             `json:"total"`
+	Images    []ImageProgress `json:"images"`
+}
+
+// ImageProgress is the download progress of the newest version of an image.
+type ImageProgress struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Architecture string  `json:"architecture"`
+	Version      string  `json:"version,omitempty"`
+	Complete     bool    `json:"complete"`
+	Progress     float64 `json:"progress"`
+}
+
+type BootResources struct {
+	Client maas_client.Client
+}
+
+func (b BootResources) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{
+		ListBootResources{Client: b.Client},
+		ListBootSourceSelections{Client: b.Client},
+		ImportBootResources{Client: b.Client},
+		GetImportStatus{Client: b.Client},
+	}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListBootResources struct {
+	Client maas_client.Client
+}
+
+func (ListBootResources) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-boot-resources",
+		mcp.WithString(
+			"os",
+			mcp.Description("Only list the images of this operating system, like ubuntu."),
+		),
+		mcp.WithString(
+			"arch",
+			mcp.Description("Only list the images of this architecture, like amd64."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Boot Resources", true, false, true, true)),
+		mcp.WithDescription("List the OS images imported in MAAS, like ubuntu/noble, with their architectures and the subarchitectures (kernels) they boot. Use it to check that an image exists before deploying."),
+	)
+}
+
+func (l ListBootResources) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	osystem := request.GetString("os", "")
+	arch := request.GetString("arch", "")
+
+	zap.L().Info("[ListBootResources] Retrieving the boot resources...")
+	resources, err := maas_api.New(l.Client).ListBootResources(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the boot resources err=%v", err)
+		zap.L().Error(fmt.Sprintf("[ListBootResources] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	images := []BootImage{}
+	for _, resource := range resources {
+		if (osystem != "" && resource.OSystem() != osystem) || (arch != "" && resource.Arch() != arch) {
+			continue
+		}
+
+		index := slices.IndexFunc(images, func(image BootImage) bool { return image.Name == resource.Name })
+		if index < 0 {
+			images = append(images, BootImage{Name: resource.Name, Type: resource.Type, Architectures: map[string][]string{}})
+			index = len(images) - 1
+		}
+
+		kernels := append(images[index].Architectures[resource.Arch()], resource.Kernels()...)
+		slices.Sort(kernels)
+		images[index].Architectures[resource.Arch()] = slices.Compact(kernels)
+	}
+
+	jsonData, err := json.Marshal(images)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListBootResources] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type ListBootSourceSelections struct {
+	Client maas_client.Client
+}
+
+func (ListBootSourceSelections) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-boot-source-selections",
+		mcp.WithInputSchema[struct{}](),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Boot Source Selections", true, false, true, true)),
+		mcp.WithDescription("List the boot sources MAAS imports images from, with the releases and architectures selected on each of them."),
+	)
+}
+
+func (l ListBootSourceSelections) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	api := maas_api.New(l.Client)
+
+	zap.L().Info("[ListBootSourceSelections] Retrieving the boot sources...")
+	sources, err := api.ListBootSources(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the boot sources err=%v", err)
+		zap.L().Error(fmt.Sprintf("[ListBootSourceSelections] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	result := []BootSourceSelections{}
+	for _, source := range sources {
+		selections, err := api.ListBootSourceSelections(ctx, source.ID)
+		if err != nil {
+			errMsg = fmt.Sprintf("Failed to retrieve the selections of the boot source %d err=%v", source.ID, err)
+			zap.L().Error(fmt.Sprintf("[ListBootSourceSelections] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		result = append(result, BootSourceSelections{ID: source.ID, URL: source.URL, Selections: selections})
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListBootSourceSelections] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type ImportBootResources struct {
+	Client maas_client.Client
+}
+
+func (ImportBootResources) Create() mcp.Tool {
+	return mcp.NewTool(
+		"import-boot-resources",
+		mcp.WithString(
+			"os",
+			mcp.DefaultString("ubuntu"),
+			mcp.Description("The operating system of the release to select. Default: ubuntu"),
+		),
+		mcp.WithString(
+			"release",
+			mcp.Description("A release to select before importing, like noble. Without it, the images already selected are imported again."),
+		),
+		mcp.WithArray(
+			"arches",
+			mcp.WithStringItems(),
+			mcp.Description("The architectures of the release to select. Default: [amd64]"),
+		),
+		mcp.WithNumber(
+			"source_id",
+			mcp.Description("The id of the boot source to select the release on. Default: the first boot source."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Import Boot Resources", false, false, true, true)),
+		mcp.WithDescription("Start importing the images selected on the boot sources, after selecting a release when one is given and is not selected yet. The import runs in the background, follow it with get-boot-resources-import-status."),
+	)
+}
+
+func (i ImportBootResources) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	api := maas_api.New(i.Client)
+	result := ImportResult{}
+
+	if release := request.GetString("release", ""); release != "" {
+		params := maas_api.BootSourceSelectionParams{
+			OS:      request.GetString("os", "ubuntu"),
+			Release: release,
+			Arches:  request.GetStringSlice("arches", []string{"amd64"}),
+		}
+
+		selection, err := i.selectRelease(ctx, api, request.GetInt("source_id", 0), params)
+		if err != nil {
+			zap.L().Error(fmt.Sprintf("[ImportBootResources] %v", err))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result.SelectionAdded = selection
+	}
+
+	zap.L().Info("[ImportBootResources] Starting the import of the boot resources...")
+	if err := api.ImportBootResources(ctx); err != nil {
+		errMsg = fmt.Sprintf("Failed to start the import of the boot resources err=%v", err)
+		zap.L().Error(fmt.Sprintf("[ImportBootResources] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	result.Message = "Import of the boot resources started"
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ImportBootResources] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// selectRelease selects the release on the boot source, the first one when
+// sourceID is 0. It returns nil when the release is already selected.
+func (ImportBootResources) selectRelease(ctx context.Context, api *maas_api.API, sourceID int, params maas_api.BootSourceSelectionParams) (*maas_api.BootSourceSelection, error) {
+	if sourceID == 0 {
+		sources, err := api.ListBootSources(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the boot sources err=%v", err)
+		}
+		if len(sources) == 0 {
+			return nil, fmt.Errorf("MAAS has no boot source to import %s/%s from", params.OS, params.Release)
+		}
+		sourceID = sources[0].ID
+	}
+
+	selections, err := api.ListBootSourceSelections(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the selections of the boot source %d err=%v", sourceID, err)
+	}
+	for _, selection := range selections {
+		if selection.OS == params.OS && selection.Release == params.Release {
+			return nil, nil
+		}
+	}
+
+	zap.L().Info(fmt.Sprintf("[ImportBootResources] Selecting %s/%s on the boot source %d...", params.OS, params.Release, sourceID))
+	selection, err := api.CreateBootSourceSelection(ctx, sourceID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select %s/%s on the boot source %d err=%v", params.OS, params.Release, sourceID, err)
+	}
+	return &selection, nil
+}
+
+type GetImportStatus struct {
+	Client maas_client.Client
+}
+
+func (GetImportStatus) Create() mcp.Tool {
+	return mcp.NewTool(
+		"get-boot-resources-import-status",
+		mcp.WithString(
+			"name",
+			mcp.Description("Only report the images with this name, like ubuntu/noble."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Get Boot Resources Import Status", true, false, false, true)),
+		mcp.WithDescription("Report whether MAAS is importing images, and the download progress of every image."),
+	)
+}
+
+func (g GetImportStatus) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name := request.GetString("name", "")
+	api := maas_api.New(g.Client)
+
+	importing, err := api.IsImportingBootResources(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the import status err=%v", err)
+		zap.L().Error(fmt.Sprintf("[GetImportStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	resources, err := api.ListBootResources(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the boot resources err=%v", err)
+		zap.L().Error(fmt.Sprintf("[GetImportStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	status := ImportStatus{Importing: importing, Images: []ImageProgress{}}
+	for _, resource := range resources {
+		if name != "" && resource.Name != name {
+			continue
+		}
+
+		detailed, err := api.GetBootResource(ctx, resource.ID)
+		if err != nil {
+			errMsg = fmt.Sprintf("Failed to retrieve the boot resource %d err=%v", resource.ID, err)
+			zap.L().Error(fmt.Sprintf("[GetImportStatus] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		progress := ImageProgress{ID: resource.ID, Name: resource.Name, Architecture: resource.Architecture}
+		if set, ok := detailed.LatestSet(); ok {
+			progress.Version = set.Version
+			progress.Complete = set.Complete
+			progress.Progress = set.Progress
+		}
+		if progress.Complete {
+			status.Complete++
+		}
+		status.Images = append(status.Images, progress)
+	}
+	status.Total = len(status.Images)
+
+	jsonData, err := json.Marshal(status)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[GetImportStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,142 @@
+package boot_resources
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestListBootResources(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  []BootImage
+	}{
+		{"every image", map[string]any{}, []BootImage{
+			{Name: "ubuntu/noble", Type: "Synced", Architectures: map[string][]string{"amd64": {"ga-24.04", "generic", "hwe-24.04"}, "arm64": {"generic"}}},
+			{Name: "centos/9", Type: "Uploaded", Architectures: map[string][]string{"amd64": {"generic"}}},
+		}},
+		{"by os", map[string]any{"os": "centos"}, []BootImage{
+			{Name: "centos/9", Type: "Uploaded", Architectures: map[string][]string{"amd64": {"generic"}}},
+		}},
+		{"by arch", map[string]any{"arch": "arm64"}, []BootImage{
+			{Name: "ubuntu/noble", Type: "Synced", Architectures: map[string][]string{"arm64": {"generic"}}},
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddBootResource(fakemaas.BootResource{Name: "ubuntu/noble", Architecture: "amd64/ga-24.04", Subarches: "generic,hwe-24.04"})
+			fake.AddBootResource(fakemaas.BootResource{Name: "ubuntu/noble", Architecture: "arm64/generic"})
+			fake.AddBootResource(fakemaas.BootResource{Name: "centos/9", Type: "Uploaded"})
+
+			// Act
+			result := fakemaas.CallTool(t, ListBootResources{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			var images []BootImage
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &images); err != nil {
+				t.Fatalf("expected a list of images, got %v", err)
+			}
+			if !reflect.DeepEqual(images, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, images)
+			}
+		})
+	}
+}
+
+func TestImportBootResources(t *testing.T) {
+	cases := []struct {
+		name              string
+		arguments         map[string]any
+		expectedSelection bool
+		expectedImages    []string
+	}{
+		{"import the selected images", map[string]any{}, false, []string{"ubuntu/noble"}},
+		{"select a missing release", map[string]any{"release": "jammy", "arches": []any{"amd64", "arm64"}}, true, []string{"ubuntu/noble", "ubuntu/jammy", "ubuntu/jammy"}},
+		{"release already selected", map[string]any{"release": "noble"}, false, []string{"ubuntu/noble"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.TransitionReads = 1
+			source := fake.AddBootSource(fakemaas.BootSource{})
+			fake.AddBootSourceSelection(fakemaas.BootSourceSelection{BootSourceID: source.ID, OS: "ubuntu", Release: "noble"})
+			handlers := fakemaas.Handlers(BootResources{Client: fake.Client()})
+
+			// Act
+			result := fakemaas.CallTool(t, handlers["import-boot-resources"], tc.arguments)
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var imported ImportResult
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &imported); err != nil {
+				t.Fatalf("expected an import result, got %v", err)
+			}
+			if (imported.SelectionAdded != nil) != tc.expectedSelection {
+				t.Errorf("expected a selection added=%v, got %+v", tc.expectedSelection, imported.SelectionAdded)
+			}
+			var names []string
+			for _, resource := range fake.BootResources() {
+				names = append(names, resource.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expectedImages) {
+				t.Errorf("expected images %v, got %v", tc.expectedImages, names)
+			}
+
+			var status ImportStatus
+			for _, expected := range []bool{true, false} {
+				result = fakemaas.CallTool(t, handlers["get-boot-resources-import-status"], map[string]any{})
+				if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &status); err != nil {
+					t.Fatalf("expected an import status, got %v", err)
+				}
+				if status.Importing != expected {
+					t.Errorf("expected importing=%v, got %+v", expected, status)
+				}
+			}
+			if status.Complete != len(tc.expectedImages) || status.Total != len(tc.expectedImages) {
+				t.Errorf("expected every image to be complete after the import, got %+v", status)
+			}
+		})
+	}
+
+	t.Run("without boot source", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+
+		// Act
+		result := fakemaas.CallTool(t, ImportBootResources{Client: fake.Client()}.Handle, map[string]any{"release": "noble"})
+
+		// Assert
+		if !result.IsError {
+			t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+	})
+}
+
+func TestListBootSourceSelections(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	source := fake.AddBootSource(fakemaas.BootSource{})
+	fake.AddBootSourceSelection(fakemaas.BootSourceSelection{BootSourceID: source.ID, OS: "ubuntu", Release: "noble", Arches: []string{"amd64", "arm64"}})
+
+	// Act
+	result := fakemaas.CallTool(t, ListBootSourceSelections{Client: fake.Client()}.Handle, map[string]any{})
+
+	// Assert
+	var sources []BootSourceSelections
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &sources); err != nil {
+		t.Fatalf("expected a list of boot sources, got %v", err)
+	}
+	if len(sources) != 1 || len(sources[0].Selections) != 1 || !reflect.DeepEqual(sources[0].Selections[0].Arches, []string{"amd64", "arm64"}) {
+		t.Errorf("expected the noble selection for amd64 and arm64, got %+v", sources)
+	}
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// Results of a machine in a bulk power operation.
+const (
+	PowerDone    = "done"
+	PowerRefused = "refused"
+	PowerFailed  = "failed"
+)
+
+// maxPowerConcurrency bounds the concurrency a bulk power operation or a
+// power state sweep may ask for, so that the BMCs are not all hit at once.
+const maxPowerConcurrency = 20
+
+// MachinePower is the result of a machine in a bulk power operation.
+type MachinePower struct {
+	SystemID   string `json:"system_id"`
+	Hostname   string `json:"hostname,omitempty"`
+	Result     string `json:"result"`
+	PowerState string `json:"power_state,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BulkPowerResult is the answer of bulk-power.
+type BulkPowerResult struct {
+	Action    string         `json:"action"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Machines  []MachinePower `json:"machines"`
+}
+
+type BulkPower struct {
+	Client maas_client.Client
+}
+
+func (BulkPower) Create() mcp.Tool {
+	return mcp.NewTool(
+		"bulk-power",
+		mcp.WithArray(
+			"ids",
+			mcp.WithStringItems(mcp.Pattern("^[0-9a-z]{6}$")),
+			mcp.Description("The ids of the machines. Either ids or tag is required."),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Description("Act on the machines with this tag. Protected machines are left out."),
+		),
+		mcp.WithString(
+			"action",
+			mcp.Required(),
+			mcp.Enum("on", "off", "cycle"),
+			mcp.Description("Power the machines on, off, or off then on again after delay."),
+		),
+		mcp.WithNumber(
+			"delay",
+			mcp.DefaultNumber(10),
+			mcp.Min(0),
+			mcp.Max(maxPowerCycleDelay),
+			mcp.Description("cycle only: seconds to wait between powering a machine off and on."),
+		),
+		mcp.WithNumber(
+			"concurrency",
+			mcp.DefaultNumber(5),
+			mcp.Min(1),
+			mcp.Max(maxPowerConcurrency),
+			mcp.Description("How many machines are powered at the same time."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Bulk Power", false, true, false, true)),
+		mcp.WithDescription("Power several machines on, off or cycle them, a bounded number at a time, and report for every machine whether it succeeded. Protected machines given by id are refused. Sends a progress notification every time a machine is done."),
+	)
+}
+
+func (b BulkPower) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	ids := request.GetStringSlice("ids", nil)
+	tag := request.GetString("tag", "")
+	concurrency := min(max(request.GetInt("concurrency", 5), 1), maxPowerConcurrency)
+	delay := powerCycleDelay(request)
+
+	action, err := request.RequireString("action")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[BulkPower] Required parameter action not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(b.Client)
+
+	var power func(ctx context.Context, systemID string) (maas_api.Machine, error)
+	switch action {
+	case "on":
+		power = api.PowerOn
+	case "off":
+		power = api.PowerOff
+	case "cycle":
+		power = func(ctx context.Context, systemID string) (maas_api.Machine, error) {
+			return powerCycle(ctx, api, systemID, delay)
+		}
+	default:
+		errMsg = fmt.Sprintf("Unknown power action %s, expected on, off or cycle", action)
+		zap.L().Error(fmt.Sprintf("[BulkPower] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if len(ids) == 0 && tag == "" {
+		errMsg = "Either ids or tag is required"
+		zap.L().Error(fmt.Sprintf("[BulkPower] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	machines, missing, err := selectMachines(ctx, api, ids, tag)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[BulkPower] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(machines) == 0 && len(missing) == 0 {
+		errMsg = fmt.Sprintf("No machines found with tag %s", tag)
+		zap.L().Error(fmt.Sprintf("[BulkPower] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	results := make([]MachinePower, len(machines))
+	progress := NewProgressNotifier(ctx, request)
+	var mu sync.Mutex
+
+	zap.L().Info(fmt.Sprintf("[BulkPower] Powering %s %d machines, %d at a time...", action, len(machines), concurrency))
+	forEachConcurrently(len(machines), concurrency, func(i int) {
+		machine := machines[i]
+		result := MachinePower{SystemID: machine.SystemID, Hostname: machine.Hostname}
+
+		if machine.Protected() {
+			result.Result, result.Error = PowerRefused, "machine is protected"
+		} else if powered, err := power(ctx, machine.SystemID); err != nil {
+			zap.L().Warn(fmt.Sprintf("[BulkPower] Failed to power %s machine with id %s err=%v", action, machine.SystemID, err))
+			result.Result, result.Error = PowerFailed, err.Error()
+		} else {
+			result.Result, result.PowerState = PowerDone, powered.PowerState
+		}
+		results[i] = result
+
+		mu.Lock()
+		progress.Notify(ctx, float64(len(machines)), fmt.Sprintf("Machine %s: %s", machine.SystemID, result.Result))
+		mu.Unlock()
+	})
+
+	for _, id := range missing {
+		results = append(results, MachinePower{SystemID: id, Result: PowerFailed, Error: "machine not found"})
+	}
+
+	result := BulkPowerResult{Action: action, Machines: results}
+	for _, machine := range results {
+		if machine.Result == PowerDone {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[BulkPower] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if result.Failed > 0 {
+		zap.L().Error(fmt.Sprintf("[BulkPower] %d of %d machines could not be powered %s", result.Failed, len(results), action))
+		return mcp.NewToolResultError(string(jsonData)), nil
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// PowerCheck compares the power state MAAS cached for a machine with the one
+// its BMC reports.
+type PowerCheck struct {
+	SystemID     string `json:"system_id"`
+	Hostname     string `json:"hostname,omitempty"`
+	CachedState  string `json:"cached_state"`
+	QueriedState string `json:"queried_state,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// PowerSweepResult is the answer of power-state-sweep. Only the machines
+// whose states differ, or whose BMC could not be queried, are listed.
+type PowerSweepResult struct {
+	Pool       string       `json:"pool"`
+	Checked    int          `json:"checked"`
+	Mismatched []PowerCheck `json:"mismatched"`
+	Failed     []PowerCheck `json:"failed"`
+}
+
+type PowerStateSweep struct {
+	Client maas_client.Client
+}
+
+func (PowerStateSweep) Create() mcp.Tool {
+	return mcp.NewTool(
+		"power-state-sweep",
+		mcp.WithString(
+			"pool",
+			mcp.Required(),
+			mcp.Description("The resource pool whose machines are checked."),
+		),
+		mcp.WithNumber(
+			"concurrency",
+			mcp.DefaultNumber(5),
+			mcp.Min(1),
+			mcp.Max(maxPowerConcurrency),
+			mcp.Description("How many BMCs are queried at the same time."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Power State Sweep", true, false, false, true)),
+		mcp.WithDescription("Query the BMC of every machine in a resource pool and report the machines whose power state differs from the one MAAS has cached, and the ones whose BMC could not be queried. MAAS updates its cached state with the queried one. Protected machines are left out."),
+	)
+}
+
+func (p PowerStateSweep) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	pool, err := request.RequireString("pool")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PowerStateSweep] Required parameter pool not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	concurrency := min(max(request.GetInt("concurrency", 5), 1), maxPowerConcurrency)
+
+	api := maas_api.New(p.Client)
+
+	machines, err := api.ListMachines(ctx, maas_api.MachineFilter{Pool: pool})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machines of pool %s err=%v", pool, err)
+		zap.L().Error(fmt.Sprintf("[PowerStateSweep] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	checks := make([]PowerCheck, 0, len(machines))
+	for _, machine := range machines {
+		if !machine.Protected() {
+			checks = append(checks, PowerCheck{SystemID: machine.SystemID, Hostname: machine.Hostname, CachedState: machine.PowerState})
+		}
+	}
+
+	zap.L().Info(fmt.Sprintf("[PowerStateSweep] Querying the power state of %d machines in pool %s, %d at a time...", len(checks), pool, concurrency))
+	forEachConcurrently(len(checks), concurrency, func(i int) {
+		state, err := api.QueryPowerState(ctx, checks[i].SystemID)
+		if err != nil {
+			checks[i].Error = err.Error()
+			return
+		}
+		checks[i].QueriedState = state.State
+	})
+
+	result := PowerSweepResult{Pool: pool, Checked: len(checks), Mismatched: []PowerCheck{}, Failed: []PowerCheck{}}
+	for _, check := range checks {
+		switch {
+		case check.Error != "":
+			result.Failed = append(result.Failed, check)
+		case check.QueriedState != check.CachedState:
+			result.Mismatched = append(result.Mismatched, check)
+		}
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[PowerStateSweep] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
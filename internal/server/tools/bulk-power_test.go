@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+)
+
+// concurrencyClient records how many POST requests were in flight at once.
+type concurrencyClient struct {
+	maas_client.Client
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (c *concurrencyClient) Do(ctx context.Context, requestType maas_client.RequestType, path string, body io.Reader) (string, error) {
+	if requestType != maas_client.RequestTypePost {
+		return c.Client.Do(ctx, requestType, path, body)
+	}
+
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	response, err := c.Client.Do(ctx, requestType, path, body)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return response, err
+}
+
+func TestBulkPower(t *testing.T) {
+	cases := []struct {
+		name     string
+		action   string
+		expected string
+	}{
+		{"power on", "on", "on"},
+		{"power off", "off", "off"},
+		{"power cycle", "cycle", "on"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			var ids []string
+			for range 6 {
+				ids = append(ids, fake.AddMachine(fakemaas.Machine{PowerState: "unknown", TagNames: []string{"rack1"}}).SystemID)
+			}
+			client := &concurrencyClient{Client: fake.Client()}
+
+			// Act
+			result := fakemaas.CallTool(t, BulkPower{Client: client}.Handle, map[string]any{"tag": "rack1", "action": tc.action, "delay": 0, "concurrency": 2})
+
+			// Assert
+			body := fakemaas.ResultText(t, result)
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", body)
+			}
+			var bulk BulkPowerResult
+			if err := json.Unmarshal([]byte(body), &bulk); err != nil {
+				t.Fatalf("failed to unmarshal result: %v", err)
+			}
+			if bulk.Succeeded != len(ids) || bulk.Failed != 0 {
+				t.Errorf("unexpected result %s", body)
+			}
+			for _, id := range ids {
+				if got, _ := fake.Machine(id); got.PowerState != tc.expected {
+					t.Errorf("expected machine %s to be %s, got %s", id, tc.expected, got.PowerState)
+				}
+			}
+			if client.peak > 2 {
+				t.Errorf("expected at most 2 machines powered at once, got %d", client.peak)
+			}
+		})
+	}
+
+	t.Run("reports the machines that cannot be powered", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		ready := fake.AddMachine(fakemaas.Machine{})
+		locked := fake.AddMachine(fakemaas.Machine{Locked: true})
+		protected := fake.AddMachine(fakemaas.Machine{TagNames: []string{maas_api.ProtectedTag}})
+
+		// Act
+		result := fakemaas.CallTool(t, BulkPower{Client: fake.Client()}.Handle, map[string]any{
+			"ids":    []any{ready.SystemID, locked.SystemID, protected.SystemID, "zzzzzz"},
+			"action": "on",
+		})
+
+		// Assert
+		if !result.IsError {
+			t.Fatal("expected an error result")
+		}
+		var bulk BulkPowerResult
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &bulk); err != nil {
+			t.Fatalf("failed to unmarshal result: %v", err)
+		}
+		results := map[string]string{}
+		for _, machine := range bulk.Machines {
+			results[machine.SystemID] = machine.Result
+		}
+		expected := map[string]string{
+			ready.SystemID:     PowerDone,
+			locked.SystemID:    PowerFailed,
+			protected.SystemID: PowerRefused,
+			"zzzzzz":           PowerFailed,
+		}
+		if !reflect.DeepEqual(results, expected) || bulk.Succeeded != 1 || bulk.Failed != 3 {
+			t.Errorf("expected results %v, got %+v", expected, bulk)
+		}
+	})
+}
+
+func TestPowerStateSweep(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	fake.AddMachine(fakemaas.Machine{Pool: "edge", PowerState: "on"})
+	drifted := fake.AddMachine(fakemaas.Machine{Pool: "edge", PowerState: "on", BMCPowerState: "off"})
+	unreachable := fake.AddMachine(fakemaas.Machine{Pool: "edge", PowerState: "off"})
+	fake.AddMachine(fakemaas.Machine{Pool: "edge", PowerState: "on", BMCPowerState: "off", TagNames: []string{maas_api.ProtectedTag}})
+	fake.AddMachine(fakemaas.Machine{Pool: "default", PowerState: "on", BMCPowerState: "off"})
+	fake.Fail("GET", "/MAAS/api/2.0/machines/"+unreachable.SystemID+"/op-query_power_state", 503)
+
+	// Act
+	result := fakemaas.CallTool(t, PowerStateSweep{Client: fake.Client()}.Handle, map[string]any{"pool": "edge"})
+
+	// Assert
+	body := fakemaas.ResultText(t, result)
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", body)
+	}
+	var sweep PowerSweepResult
+	if err := json.Unmarshal([]byte(body), &sweep); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if sweep.Checked != 3 {
+		t.Errorf("expected 3 machines checked, got %d", sweep.Checked)
+	}
+	expected := []PowerCheck{{SystemID: drifted.SystemID, Hostname: drifted.Hostname, CachedState: "on", QueriedState: "off"}}
+	if !reflect.DeepEqual(sweep.Mismatched, expected) {
+		t.Errorf("expected mismatches %+v, got %+v", expected, sweep.Mismatched)
+	}
+	if len(sweep.Failed) != 1 || sweep.Failed[0].SystemID != unreachable.SystemID || sweep.Failed[0].Error == "" {
+		t.Errorf("expected machine %s to fail, got %+v", unreachable.SystemID, sweep.Failed)
+	}
+	if slices.ContainsFunc(fake.Requests(), func(r maas_client.Request) bool { return r.Method != "GET" }) {
+		t.Error("expected the sweep to send only GET requests")
+	}
+}
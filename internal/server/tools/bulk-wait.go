@@ -0,0 +1,338 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// Results of a machine in a bulk wait.
+const (
+	WaitReached  = "reached"
+	WaitFailed   = "failed"
+	WaitTimedOut = "timed_out"
+	WaitRefused  = "refused"
+	// WaitPending is the result of the machines still moving when the
+	// completion condition was decided without them.
+	WaitPending = "pending"
+)
+
+// maxWaitConcurrency bounds the concurrency a bulk wait may ask for.
+const maxWaitConcurrency = 20
+
+var systemIDPattern = regexp.MustCompile("^[0-9a-z]{6}$")
+
+// MachineWait is the result of a machine in a bulk wait.
+type MachineWait struct {
+	SystemID string `json:"system_id"`
+	Hostname string `json:"hostname,omitempty"`
+	Result   string `json:"result"`
+	Status   string `json:"status,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BulkWaitResult is the answer of wait-for-machines-status.
+type BulkWaitResult struct {
+	ConditionMet bool          `json:"condition_met"`
+	Required     int           `json:"required"`
+	Reached      int           `json:"reached"`
+	Failed       int           `json:"failed"`
+	TimedOut     int           `json:"timed_out"`
+	Refused      int           `json:"refused"`
+	Pending      int           `json:"pending"`
+	Machines     []MachineWait `json:"machines"`
+}
+
+type WaitForMachinesStatus struct {
+	Client maas_client.Client
+	Jobs   *jobs.Manager
+}
+
+func (w WaitForMachinesStatus) Create() mcp.Tool {
+	return mcp.NewTool(
+		"wait-for-machines-status",
+		mcp.WithArray(
+			"ids",
+			mcp.WithStringItems(mcp.Pattern("^[0-9a-z]{6}$")),
+			mcp.Description("The ids of the machines to wait for. Either ids or tag is required."),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Description("Wait for the machines with this tag. Protected machines are left out."),
+		),
+		mcp.WithString(
+			"status",
+			mcp.Enum(statuses...),
+			mcp.DefaultString("deployed"),
+			mcp.Description("The status the machines should reach."),
+		),
+		mcp.WithString(
+			"condition",
+			mcp.Pattern("^(all|any|[0-9]+)$"),
+			mcp.DefaultString("all"),
+			mcp.Description("When the wait is complete: all machines reached the status, any of them did, or a number N of them did."),
+		),
+		mcp.WithNumber(
+			"timeout",
+			mcp.DefaultNumber(1800.0),
+			mcp.Description("Timeout in seconds until the waiting is stopped. Default: 1800s"),
+		),
+		mcp.WithNumber(
+			"concurrency",
+			mcp.DefaultNumber(5),
+			mcp.Min(1),
+			mcp.Max(maxWaitConcurrency),
+			mcp.Description("How many machines are polled at the same time."),
+		),
+		withAsync(w.Jobs, "Return a job right away and wait in the background. Follow the job with get-job."),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Wait for Machines Status", true, false, false, true)),
+		mcp.WithDescription("Wait until several machines reach a status and report for every machine whether it reached the status, failed or timed out. A machine that enters a failed status or becomes broken stops being waited for. Protected machines given by id are refused. Sends a progress notification every time a machine is settled."),
+	)
+}
+
+func (w WaitForMachinesStatus) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	ids := request.GetStringSlice("ids", nil)
+	tag := request.GetString("tag", "")
+	requiredStatus := request.GetString("status", "deployed")
+	timeout := request.GetFloat("timeout", 1800.0)
+	concurrency := min(max(request.GetInt("concurrency", 5), 1), maxWaitConcurrency)
+
+	if len(ids) == 0 && tag == "" {
+		errMsg = "Either ids or tag is required"
+		zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	for _, id := range ids {
+		if !systemIDPattern.MatchString(id) {
+			errMsg = fmt.Sprintf("Invalid machine id %q", id)
+			zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	if runAsync(ctx, w.Jobs, request) {
+		return submitJob(ctx, w.Jobs, "wait-for-machines-status", request, "WaitForMachinesStatus")
+	}
+
+	api := maas_api.New(w.Client)
+
+	machines, err := waitedMachines(ctx, api, ids, tag)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machines to wait for err=%v", err)
+		zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if len(machines) == 0 {
+		errMsg = fmt.Sprintf("No machines found with tag %s", tag)
+		zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	required, err := requiredCount(request.GetString("condition", "all"), len(machines))
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[WaitForMachinesStatus] Waiting for %d of %d machines to reach status %s...", required, len(machines), requiredStatus))
+	result := waitForMachines(ctx, api, machines, requiredStatus, required, concurrency, time.Duration(timeout*float64(time.Second)), NewProgressNotifier(ctx, request))
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if !result.ConditionMet {
+		zap.L().Error(fmt.Sprintf("[WaitForMachinesStatus] Only %d of the %d required machines reached status %s", result.Reached, required, requiredStatus))
+		return mcp.NewToolResultError(string(jsonData)), nil
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+func (w WaitForMachinesStatus) runJob(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error) {
+	return toolStep(ctx, job, "wait", w.Handle, request)
+}
+
+// waitedMachines lists the machines to wait for, given by id or by tag.
+// Protected machines given by id are refused, and the ones with the tag are
+// left out.
+func waitedMachines(ctx context.Context, api *maas_api.API, ids []string, tag string) ([]*MachineWait, error) {
+	selected, missing, err := selectMachines(ctx, api, ids, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := make([]*MachineWait, 0, len(selected)+len(missing))
+	for _, machine := range selected {
+		wait := &MachineWait{SystemID: machine.SystemID, Hostname: machine.Hostname, Result: WaitPending}
+		if machine.Protected() {
+			wait.Result, wait.Error = WaitRefused, "machine is protected"
+		}
+		machines = append(machines, wait)
+	}
+	for _, id := range missing {
+		machines = append(machines, &MachineWait{SystemID: id, Result: WaitFailed, Status: "Not found"})
+	}
+	return machines, nil
+}
+
+// requiredCount returns how many of total machines the condition requires.
+func requiredCount(condition string, total int) (int, error) {
+	switch condition {
+	case "all":
+		return total, nil
+	case "any":
+		return 1, nil
+	}
+
+	required, err := strconv.Atoi(condition)
+	if err != nil || required < 1 || required > total {
+		return 0, fmt.Errorf("invalid condition %q: expected all, any or a number between 1 and %d", condition, total)
+	}
+	return required, nil
+}
+
+// waitForMachines polls the pending machines every pollInterval, at most
+// concurrency at a time, until required of them reached the status, too many
+// failed for that to happen, or timeout.
+func waitForMachines(ctx context.Context, api *maas_api.API, machines []*MachineWait, requiredStatus string, required, concurrency int, timeout time.Duration, progress *ProgressNotifier) BulkWaitResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	result := BulkWaitResult{Required: required}
+	for !result.decided(len(machines)) {
+		select {
+		case <-ctx.Done():
+			for _, machine := range machines {
+				if machine.Result == WaitPending {
+					machine.Result = WaitTimedOut
+				}
+			}
+		case <-ticker.C:
+			settled := pollMachines(ctx, api, machines, requiredStatus, concurrency)
+			for _, machine := range settled {
+				message := fmt.Sprintf("Machine %s reached status %s", machine.SystemID, machine.Status)
+				if machine.Result == WaitFailed {
+					message = fmt.Sprintf("Machine %s failed with status %s", machine.SystemID, machine.Status)
+				}
+				progress.Notify(ctx, float64(len(machines)), message)
+			}
+		}
+
+		result.count(machines)
+		if result.TimedOut > 0 {
+			break
+		}
+	}
+
+	result.ConditionMet = result.Reached >= required
+	result.Machines = make([]MachineWait, 0, len(machines))
+	for _, machine := range machines {
+		result.Machines = append(result.Machines, *machine)
+	}
+	return result
+}
+
+// pollMachines reads the status of every pending machine and returns the
+// ones that settled. A machine that cannot be read stays pending, unless it
+// does not exist.
+func pollMachines(ctx context.Context, api *maas_api.API, machines []*MachineWait, requiredStatus string, concurrency int) []*MachineWait {
+	var pending []*MachineWait
+	for _, machine := range machines {
+		if machine.Result == WaitPending {
+			pending = append(pending, machine)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		settled []*MachineWait
+	)
+	forEachConcurrently(len(pending), concurrency, func(i int) {
+		machine := pending[i]
+
+		current, err := api.GetMachine(ctx, machine.SystemID)
+		switch {
+		case maas_api.IsNotFound(err):
+			machine.Result, machine.Status = WaitFailed, "Not found"
+		case err != nil:
+			zap.L().Warn(fmt.Sprintf("[WaitForMachinesStatus] Failed to retrieve the machine with id %s err=%v", machine.SystemID, err))
+			return
+		default:
+			machine.Hostname, machine.Status = current.Hostname, current.StatusName
+			if sameStatus(requiredStatus, current.StatusName) {
+				machine.Result = WaitReached
+			} else if isFailedStatus(current.StatusName) {
+				machine.Result = WaitFailed
+			} else {
+				return
+			}
+		}
+
+		mu.Lock()
+		settled = append(settled, machine)
+		mu.Unlock()
+	})
+
+	return settled
+}
+
+// forEachConcurrently calls fn with every index below n, at most concurrency
+// at a time, and returns once all calls returned.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+
+	for i := range n {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			fn(i)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (r *BulkWaitResult) count(machines []*MachineWait) {
+	r.Reached, r.Failed, r.TimedOut, r.Refused, r.Pending = 0, 0, 0, 0, 0
+	for _, machine := range machines {
+		switch machine.Result {
+		case WaitReached:
+			r.Reached++
+		case WaitFailed:
+			r.Failed++
+		case WaitTimedOut:
+			r.TimedOut++
+		case WaitRefused:
+			r.Refused++
+		default:
+			r.Pending++
+		}
+	}
+}
+
+// decided reports whether the condition is met or can no longer be.
+func (r *BulkWaitResult) decided(total int) bool {
+	return r.Reached >= r.Required || total-r.Failed-r.TimedOut-r.Refused < r.Required
+}
@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+)
+
+func TestWaitForMachinesStatus(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	cases := []struct {
+		name      string
+		statuses  []string
+		condition string
+		isError   bool
+		expected  []string
+	}{
+		{"all reach the status", []string{"deploying", "deploying", fakemaas.StatusDeployed}, "all", false, []string{WaitReached, WaitReached, WaitReached}},
+		{"any stops at the first", []string{fakemaas.StatusReady, fakemaas.StatusDeployed}, "any", false, []string{WaitPending, WaitReached}},
+		{"n of m tolerates failures", []string{"deploying", fakemaas.StatusFailedDeployment, fakemaas.StatusDeployed}, "2", false, []string{WaitReached, WaitFailed, WaitReached}},
+		{"all stops at the first failure", []string{fakemaas.StatusReady, fakemaas.StatusBroken}, "all", true, []string{WaitPending, WaitFailed}},
+		{"times out", []string{fakemaas.StatusReady, fakemaas.StatusDeployed}, "all", true, []string{WaitTimedOut, WaitReached}},
+		{"condition above the machine count", []string{fakemaas.StatusDeployed}, "2", true, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.TransitionReads = 2
+			ids := []any{}
+			for _, status := range tc.statuses {
+				m := fake.AddMachine(fakemaas.Machine{})
+				if status == "deploying" {
+					if _, err := fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+m.SystemID+"/op-deploy", nil); err != nil {
+						t.Fatalf("failed to start the deployment: %v", err)
+					}
+				} else {
+					fake.SetStatus(m.SystemID, status)
+				}
+				ids = append(ids, m.SystemID)
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, WaitForMachinesStatus{Client: fake.Client()}.Handle, map[string]any{"ids": ids, "condition": tc.condition, "timeout": 0.2})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if tc.expected == nil {
+				return
+			}
+			var waitResult BulkWaitResult
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &waitResult); err != nil {
+				t.Fatalf("expected a wait result, got %v", err)
+			}
+			if waitResult.ConditionMet == tc.isError {
+				t.Errorf("expected condition_met=%v", !tc.isError)
+			}
+			for i, machine := range waitResult.Machines {
+				if machine.SystemID != ids[i] || machine.Result != tc.expected[i] {
+					t.Errorf("expected machine %s to be %s, got %+v", ids[i], tc.expected[i], machine)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForMachinesStatus_Tag(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	// Arrange
+	fake := fakemaas.Start(t)
+	rack := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusDeployed, TagNames: []string{"rack1"}})
+	fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusReady, TagNames: []string{"rack1", "protected"}})
+	fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusReady})
+	tool := WaitForMachinesStatus{Client: fake.Client()}
+
+	// Act
+	result, notifications := fakemaas.CallToolWithProgress(t, tool.Create(), tool.Handle, map[string]any{"tag": "rack1", "timeout": 1.0})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	var waitResult BulkWaitResult
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &waitResult); err != nil {
+		t.Fatalf("expected a wait result, got %v", err)
+	}
+	if len(waitResult.Machines) != 1 || waitResult.Machines[0].SystemID != rack.SystemID {
+		t.Errorf("expected only the unprotected tagged machine, got %+v", waitResult.Machines)
+	}
+	if len(notifications) != 1 || notifications[0].Params.AdditionalFields["total"] != float64(1) {
+		t.Errorf("expected one progress notification, got %+v", notifications)
+	}
+}
+
+func TestWaitForMachinesStatus_MissingMachines(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+
+	// Act
+	result := fakemaas.CallTool(t, WaitForMachinesStatus{Client: fake.Client()}.Handle, map[string]any{})
+
+	// Assert
+	if !result.IsError {
+		t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+	}
+}
+
+func TestWaitForMachinesStatus_Protected(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	// Arrange
+	fake := fakemaas.Start(t)
+	deployed := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusDeployed})
+	protected := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusDeployed, TagNames: []string{"protected"}})
+
+	// Act
+	result := fakemaas.CallTool(t, WaitForMachinesStatus{Client: fake.Client()}.Handle, map[string]any{
+		"ids":     []any{deployed.SystemID, protected.SystemID},
+		"timeout": 1.0,
+	})
+
+	// Assert
+	if !result.IsError {
+		t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+	}
+	var waitResult BulkWaitResult
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &waitResult); err != nil {
+		t.Fatalf("expected a wait result, got %v", err)
+	}
+	if waitResult.Refused != 1 || waitResult.Reached != 1 {
+		t.Errorf("expected one machine reached and one refused, got %+v", waitResult)
+	}
+	for _, machine := range waitResult.Machines {
+		if machine.SystemID == protected.SystemID && (machine.Result != WaitRefused || machine.Status != "") {
+			t.Errorf("expected the protected machine to be refused without its status, got %+v", machine)
+		}
+	}
+}
+
+func TestWaitForMachinesStatus_ProtectedAcrossPolls(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	for _, condition := range []string{"any", "1"} {
+		t.Run(condition, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.TransitionReads = 5
+			deploying := fake.AddMachine(fakemaas.Machine{})
+			if _, err := fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+deploying.SystemID+"/op-deploy", nil); err != nil {
+				t.Fatalf("failed to start the deployment: %v", err)
+			}
+			protected := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusDeployed, TagNames: []string{"protected"}})
+
+			// Act
+			result := fakemaas.CallTool(t, WaitForMachinesStatus{Client: fake.Client()}.Handle, map[string]any{
+				"ids":       []any{deploying.SystemID, protected.SystemID},
+				"condition": condition,
+				"timeout":   1.0,
+			})
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var waitResult BulkWaitResult
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &waitResult); err != nil {
+				t.Fatalf("expected a wait result, got %v", err)
+			}
+			if !waitResult.ConditionMet || waitResult.Reached != 1 || waitResult.Refused != 1 {
+				t.Errorf("expected the deploying machine reached and one refused, got %+v", waitResult)
+			}
+		})
+	}
+}
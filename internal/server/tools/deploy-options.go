@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// deployOptionArguments are the arguments of withDeployOptions.
+var deployOptionArguments = []string{
+	"osystem",
+	"distro_series",
+	"hwe_kernel",
+	"install_kvm",
+	"register_vmhost",
+	"enable_hw_sync",
+	"ephemeral_deploy",
+	"install_rackd",
+}
+
+// withDeployOptions adds the OS, kernel and deployment options to a tool
+// that deploys machines.
+func withDeployOptions(options ...mcp.ToolOption) []mcp.ToolOption {
+	return append([]mcp.ToolOption{
+		mcp.WithString(
+			"osystem",
+			mcp.Description("The operating system to deploy, like ubuntu. It must be imported in MAAS. Default: the MAAS default."),
+		),
+		mcp.WithString(
+			"distro_series",
+			mcp.Description("The series to deploy, like noble. It must be imported in MAAS for the architecture of the machine. Default: the MAAS default."),
+		),
+		mcp.WithString(
+			"hwe_kernel",
+			mcp.Description("The kernel to deploy, like ga-24.04 or hwe-24.04. It must be available in the images imported for the series."),
+		),
+		mcp.WithBoolean(
+			"install_kvm",
+			mcp.Description("Install KVM on the machine and add it as a VM host."),
+		),
+		mcp.WithBoolean(
+			"register_vmhost",
+			mcp.Description("Install LXD on the machine and add it as a VM host."),
+		),
+		mcp.WithBoolean(
+			"enable_hw_sync",
+			mcp.Description("Periodically sync the hardware of the deployed machine with MAAS."),
+		),
+		mcp.WithBoolean(
+			"ephemeral_deploy",
+			mcp.Description("Deploy the machine in memory, without installing the OS on its disks."),
+		),
+		mcp.WithBoolean(
+			"install_rackd",
+			mcp.Description("Install a MAAS rack controller on the deployed machine."),
+		),
+	}, options...)
+}
+
+// deployParams reads the OS, kernel and deployment options of the request.
+// A distro_series given as osystem/series, like ubuntu/noble, is split.
+func deployParams(request mcp.CallToolRequest) maas_api.DeployParams {
+	params := maas_api.DeployParams{
+		OSystem:         request.GetString("osystem", ""),
+		DistroSeries:    request.GetString("distro_series", ""),
+		HWEKernel:       request.GetString("hwe_kernel", ""),
+		InstallKVM:      OptionalBool(request, "install_kvm"),
+		RegisterVMHost:  OptionalBool(request, "register_vmhost"),
+		EnableHWSync:    OptionalBool(request, "enable_hw_sync"),
+		EphemeralDeploy: OptionalBool(request, "ephemeral_deploy"),
+		InstallRackd:    OptionalBool(request, "install_rackd"),
+	}
+
+	if osystem, series, found := strings.Cut(params.DistroSeries, "/"); found && (params.OSystem == "" || params.OSystem == osystem) {
+		params.OSystem, params.DistroSeries = osystem, series
+	}
+	return params
+}
+
+// validateDeployParams checks the OS, series and kernel of params against
+// the images imported in MAAS for the architecture of the machine, so that
+// a typo fails before the machine is powered on.
+func validateDeployParams(ctx context.Context, api *maas_api.API, machineID string, params maas_api.DeployParams) error {
+	if params.OSystem == "" && params.DistroSeries == "" && params.HWEKernel == "" {
+		return nil
+	}
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the machine with id %s err=%v", machineID, err)
+	}
+
+	resources, err := api.ListBootResources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the boot resources err=%v", err)
+	}
+
+	arch, _, _ := strings.Cut(machine.Architecture, "/")
+	images := slices.DeleteFunc(resources, func(resource maas_api.BootResource) bool {
+		return resource.Arch() != arch
+	})
+	if len(images) == 0 {
+		return fmt.Errorf("no image is imported for the architecture %s of the machine", arch)
+	}
+
+	if params.OSystem != "" {
+		available := imageValues(images, maas_api.BootResource.OSystem)
+		images = slices.DeleteFunc(images, func(resource maas_api.BootResource) bool {
+			return resource.OSystem() != params.OSystem
+		})
+		if len(images) == 0 {
+			return fmt.Errorf("osystem %s is not imported for %s, available: %s", params.OSystem, arch, strings.Join(available, ", "))
+		}
+	}
+
+	if params.DistroSeries != "" {
+		available := imageValues(images, maas_api.BootResource.Series)
+		images = slices.DeleteFunc(images, func(resource maas_api.BootResource) bool {
+			return resource.Series() != params.DistroSeries
+		})
+		if len(images) == 0 {
+			return fmt.Errorf("distro_series %s is not imported for %s, available: %s", params.DistroSeries, arch, strings.Join(available, ", "))
+		}
+	}
+
+	if params.HWEKernel != "" {
+		var available []string
+		for _, image := range images {
+			available = append(available, image.Kernels()...)
+		}
+		slices.Sort(available)
+		available = slices.Compact(available)
+		if !slices.Contains(available, params.HWEKernel) {
+			return fmt.Errorf("hwe_kernel %s is not available for %s, available: %s", params.HWEKernel, arch, strings.Join(available, ", "))
+		}
+	}
+
+	return nil
+}
+
+// imageValues returns the sorted distinct values of the images.
+func imageValues(images []maas_api.BootResource, value func(maas_api.BootResource) string) []string {
+	var values []string
+	for _, image := range images {
+		values = append(values, value(image))
+	}
+	slices.Sort(values)
+	return slices.Compact(values)
+}
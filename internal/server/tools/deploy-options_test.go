@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+)
+
+func TestDeployMachine_Options(t *testing.T) {
+	store := templates.MustTemplateStore()
+	if err := store.Create(templates.GenericTemplate{Id: "deploy_options_test", Name: "Deploy Options Test", Description: "Test deployment"}); err != nil {
+		t.Fatalf("failed to create the template: %v", err)
+	}
+	defer store.Delete("deploy_options_test")
+
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  string
+	}{
+		{"series and kernel", map[string]any{"osystem": "ubuntu", "distro_series": "noble", "hwe_kernel": "hwe-24.04"}, ""},
+		{"series with the osystem", map[string]any{"distro_series": "ubuntu/jammy"}, ""},
+		{"kernel of the subarches", map[string]any{"distro_series": "jammy", "hwe_kernel": "hwe-22.04-edge"}, ""},
+		{"deployment flags", map[string]any{"register_vmhost": true, "ephemeral_deploy": false}, ""},
+		{"unknown osystem", map[string]any{"osystem": "centos"}, "osystem centos is not imported for amd64, available: ubuntu"},
+		{"series typo", map[string]any{"distro_series": "nobel"}, "distro_series nobel is not imported for amd64, available: jammy, noble"},
+		{"series of another architecture", map[string]any{"distro_series": "focal"}, "distro_series focal is not imported"},
+		{"kernel of another series", map[string]any{"distro_series": "jammy", "hwe_kernel": "hwe-24.04"}, "hwe_kernel hwe-24.04 is not available"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddBootResource(fakemaas.BootResource{Name: "ubuntu/noble", Architecture: "amd64/ga-24.04", Subarches: "generic,hwe-24.04"})
+			fake.AddBootResource(fakemaas.BootResource{Name: "ubuntu/jammy", Architecture: "amd64/ga-22.04", Subarches: "generic,hwe-22.04,hwe-22.04-edge"})
+			fake.AddBootResource(fakemaas.BootResource{Name: "ubuntu/focal", Architecture: "arm64/ga-20.04"})
+			m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusReady})
+			arguments := map[string]any{"machineId": m.SystemID, "templateId": "deploy_options_test", "templateParameters": "{}"}
+			for key, value := range tc.arguments {
+				arguments[key] = value
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, DeployMachine{Client: fake.Client()}.Handle, arguments)
+
+			// Assert
+			deploy, deployed := fake.LastRequest("POST")
+			if tc.expected != "" {
+				if !result.IsError || !strings.Contains(fakemaas.ResultText(t, result), tc.expected) {
+					t.Errorf("expected an error containing %q, got %s", tc.expected, fakemaas.ResultText(t, result))
+				}
+				if deployed {
+					t.Errorf("expected the machine not to be deployed, got %s %s", deploy.Method, deploy.Path)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			for key, value := range tc.arguments {
+				sent := deploy.Form.Get(key)
+				switch value {
+				case true:
+					value = "1"
+				case false:
+					value = "0"
+				case "ubuntu/jammy":
+					value = "jammy"
+				}
+				if sent != value {
+					t.Errorf("expected %s=%v to be sent, got %q", key, value, sent)
+				}
+			}
+		})
+	}
+
+	t.Run("no validation without OS options", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusReady})
+
+		// Act
+		result := fakemaas.CallTool(t, DeployMachine{Client: fake.Client()}.Handle, map[string]any{"machineId": m.SystemID, "templateId": "deploy_options_test", "templateParameters": "{}", "install_kvm": true})
+
+		// Assert
+		if result.IsError {
+			t.Errorf("expected no error result without boot resources, got %s", fakemaas.ResultText(t, result))
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"go.uber.org/zap"
+)
+
+// DNSRecord is a single DNS record. A and AAAA records carry the ID of
+// their DNS resource, the other records the ID of the resource record.
+type DNSRecord struct {
+	ID      int    `json:"id"`
+	FQDN    string `json:"fqdn"`
+	Type    string `json:"type"`
+	Data    string `json:"data"`
+	TTL     *int   `json:"ttl,omitempty"`
+	Machine string `json:"machine,omitempty"`
+}
+
+// DNSRecords flattens DNS resources into one A or AAAA record per address
+// and one record per resource record.
+func DNSRecords(resources []maas_api.DNSResource) []DNSRecord {
+	records := []DNSRecord{}
+	for _, resource := range resources {
+		for _, address := range resource.IPAddresses {
+			records = append(records, DNSRecord{
+				ID:   resource.ID,
+				FQDN: resource.FQDN,
+				Type: AddressRecordType(address.IP),
+				Data: address.IP,
+				TTL:  resource.AddressTTL,
+			})
+		}
+		for _, record := range resource.ResourceRecords {
+			records = append(records, DNSRecord{
+				ID:   record.ID,
+				FQDN: resource.FQDN,
+				Type: strings.ToUpper(record.RRType),
+				Data: record.RRData,
+				TTL:  record.TTL,
+			})
+		}
+	}
+	return records
+}
+
+// AddressRecordType returns AAAA for IPv6 addresses and A otherwise.
+func AddressRecordType(ip string) string {
+	if addr, err := netip.ParseAddr(ip); err == nil && addr.Is6() && !addr.Is4In6() {
+		return "AAAA"
+	}
+	return "A"
+}
+
+// Owns reports whether the DNS record belongs to the machine with the given
+// FQDN and addresses: it carries the name of the machine, points at one of
+// its addresses, or targets its FQDN (CNAME and SRV).
+func (r DNSRecord) Owns(fqdn string, addresses []string) bool {
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	if fqdn == "" {
+		return false
+	}
+	if strings.TrimSuffix(r.FQDN, ".") == fqdn {
+		return true
+	}
+
+	switch r.Type {
+	case "A", "AAAA":
+		return slices.Contains(addresses, r.Data)
+	case "CNAME":
+		return strings.TrimSuffix(r.Data, ".") == fqdn
+	case "SRV":
+		fields := strings.Fields(r.Data)
+		return len(fields) == 4 && strings.TrimSuffix(fields[3], ".") == fqdn
+	}
+	return false
+}
+
+// addDNSRecords sets the DNS records owned by each machine. The records are
+// read once per region; the machines of a region whose records cannot be
+// read are left without them.
+func addDNSRecords(ctx context.Context, client maas_client.Client, machines []Machine) {
+	api := maas_api.New(client)
+
+	byRegion := map[string][]DNSRecord{}
+	for i, machine := range machines {
+		records, ok := byRegion[machine.Region]
+		if !ok {
+			regionCtx := ctx
+			if machine.Region != "" {
+				regionCtx = maas_client.WithRegion(ctx, machine.Region)
+			}
+			resources, err := api.ListDNSResources(regionCtx, "")
+			if err != nil {
+				zap.L().Warn(fmt.Sprintf("Failed to retrieve the DNS records err=%v", err))
+			}
+			records = DNSRecords(resources)
+			byRegion[machine.Region] = records
+		}
+
+		for _, record := range records {
+			if record.Owns(machine.FQDN, machine.IPAddresses) {
+				machines[i].DNSRecords = append(machines[i].DNSRecords, record)
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+)
+
+// records returns the records of the fake as "fqdn type data" strings.
+func records(fake *fakemaas.Server) []string {
+	var result []string
+	for _, resource := range fake.DNSResources() {
+		fqdn := resource.Name + "." + resource.Domain
+		for _, address := range resource.IPAddresses {
+			result = append(result, fmt.Sprintf("%s %s %s", fqdn, tools.AddressRecordType(address), address))
+		}
+		for _, record := range resource.Records {
+			result = append(result, fmt.Sprintf("%s %s %s", fqdn, record.RRType, record.RRData))
+		}
+	}
+	return result
+}
+
+func startDNS(t *testing.T) *fakemaas.Server {
+	fake := fakemaas.Start(t)
+	fake.AddDomain(fakemaas.Domain{Name: "maas", Authoritative: true})
+	fake.AddDomain(fakemaas.Domain{Name: "example.com", Authoritative: true})
+	fake.AddMachine(fakemaas.Machine{
+		SystemID: "aaaaaa",
+		Hostname: "node-1",
+		Interfaces: []fakemaas.Interface{{Name: "eth0", Links: []fakemaas.Link{
+			{Mode: "static", IPAddress: "10.0.0.5"},
+			{Mode: "static", IPAddress: "fd00::5"},
+		}}},
+	})
+	fake.AddMachine(fakemaas.Machine{SystemID: "bbbbbb", Hostname: "node-2", TagNames: []string{"protected"}})
+	return fake
+}
+
+func TestDomainTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{"list domains", "list-domains", map[string]any{}, false, []string{"maas", "example.com", "lab.internal"}},
+		{"create domain", "create-domain", map[string]any{"name": "k3s.lab", "ttl": "300"}, false, []string{"maas", "example.com", "lab.internal", "k3s.lab"}},
+		{"read domain", "read-domain", map[string]any{"id": "lab"}, false, []string{"maas", "example.com", "lab.internal"}},
+		{"rename domain", "update-domain", map[string]any{"id": "lab", "name": "lab.example"}, false, []string{"maas", "example.com", "lab.example"}},
+		{"delete empty domain", "delete-domain", map[string]any{"id": "lab"}, false, []string{"maas", "example.com"}},
+		{"delete domain with records", "delete-domain", map[string]any{"id": "example"}, true, []string{"maas", "example.com", "lab.internal"}},
+		{"delete default domain", "delete-domain", map[string]any{"id": "default"}, true, []string{"maas", "example.com", "lab.internal"}},
+		{"delete unknown domain", "delete-domain", map[string]any{"id": "999"}, true, []string{"maas", "example.com", "lab.internal"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := startDNS(t)
+			lab := fake.AddDomain(fakemaas.Domain{Name: "lab.internal"})
+			fake.AddDNSResource(fakemaas.DNSResource{Name: "www", Domain: "example.com", IPAddresses: []string{"10.0.0.20"}})
+			ids := map[string]string{"lab": strconv.Itoa(lab.ID), "example": strconv.Itoa(fake.Domains()[1].ID), "default": strconv.Itoa(fake.Domains()[0].ID)}
+			if id, ok := ids[fmt.Sprint(tc.arguments["id"])]; ok {
+				tc.arguments["id"] = id
+			}
+			client := fake.Client()
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Domains{Client: client}, Domain{Client: client})[tc.tool], tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			var domains []string
+			for _, domain := range fake.Domains() {
+				domains = append(domains, domain.Name)
+			}
+			if !reflect.DeepEqual(domains, tc.expected) {
+				t.Errorf("expected domains %v, got %v", tc.expected, domains)
+			}
+		})
+	}
+}
+
+func TestCreateDNSRecord(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{"static A record", map[string]any{"name": "api", "type": "A", "ip": "10.0.0.10"}, false, []string{"www.example.com A 10.0.0.20", "api.example.com A 10.0.0.10"}},
+		{"A record of a machine", map[string]any{"name": "api", "type": "A", "machine": "aaaaaa"}, false, []string{"www.example.com A 10.0.0.20", "api.example.com A 10.0.0.5"}},
+		{"AAAA record of a machine", map[string]any{"name": "api", "type": "AAAA", "machine": "aaaaaa"}, false, []string{"www.example.com A 10.0.0.20", "api.example.com AAAA fd00::5"}},
+		{"address added to an existing name", map[string]any{"name": "www", "type": "A", "ip": "10.0.0.21"}, false, []string{"www.example.com A 10.0.0.20", "www.example.com A 10.0.0.21"}},
+		{"CNAME to a machine", map[string]any{"name": "alias", "type": "CNAME", "machine": "aaaaaa"}, false, []string{"www.example.com A 10.0.0.20", "alias.example.com CNAME node-1.maas"}},
+		{"TXT record", map[string]any{"name": "info", "type": "TXT", "data": "rack 3"}, false, []string{"www.example.com A 10.0.0.20", "info.example.com TXT rack 3"}},
+		{"SRV to a machine", map[string]any{"name": "_k3s._tcp", "type": "SRV", "machine": "aaaaaa", "port": "6443", "priority": "10"}, false, []string{"www.example.com A 10.0.0.20", "_k3s._tcp.example.com SRV 10 0 6443 node-1.maas"}},
+		{"AAAA record with an IPv4 address", map[string]any{"name": "api", "type": "AAAA", "ip": "10.0.0.10"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"both machine and ip", map[string]any{"name": "api", "type": "A", "ip": "10.0.0.10", "machine": "aaaaaa"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"A record without a target", map[string]any{"name": "api", "type": "A"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"TXT bound to a machine", map[string]any{"name": "info", "type": "TXT", "machine": "aaaaaa"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"SRV without a port", map[string]any{"name": "_k3s._tcp", "type": "SRV", "machine": "aaaaaa"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"CNAME next to an address", map[string]any{"name": "www", "type": "CNAME", "data": "node-1.maas"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"protected machine", map[string]any{"name": "api", "type": "A", "machine": "bbbbbb"}, true, []string{"www.example.com A 10.0.0.20"}},
+		{"unknown domain", map[string]any{"name": "api", "type": "A", "ip": "10.0.0.10", "domain": "unknown.com"}, true, []string{"www.example.com A 10.0.0.20"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := startDNS(t)
+			fake.AddDNSResource(fakemaas.DNSResource{Name: "www", Domain: "example.com", IPAddresses: []string{"10.0.0.20"}})
+			if _, ok := tc.arguments["domain"]; !ok {
+				tc.arguments["domain"] = "example.com"
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, CreateDNSRecord{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if got := records(fake); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected records %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestListDNSRecords(t *testing.T) {
+	// Arrange
+	fake := startDNS(t)
+	fake.AddDNSResource(fakemaas.DNSResource{Name: "api", Domain: "example.com", IPAddresses: []string{"10.0.0.5", "10.0.0.6"}})
+	fake.AddDNSResource(fakemaas.DNSResource{Name: "www", Domain: "example.com", Records: []fakemaas.DNSRecord{{RRType: "CNAME", RRData: "node-2.maas"}}})
+	fake.AddDNSResource(fakemaas.DNSResource{Name: "gw", Domain: "maas", IPAddresses: []string{"10.0.0.1"}})
+
+	// Act
+	result := fakemaas.CallTool(t, ListDNSRecords{Client: fake.Client()}.Handle, map[string]any{"domain": "example.com"})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	var listed []tools.DNSRecord
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &listed); err != nil {
+		t.Fatalf("expected a list of records, got %v", err)
+	}
+	var got []string
+	for _, record := range listed {
+		got = append(got, strings.TrimSpace(fmt.Sprintf("%s %s %s %s", record.FQDN, record.Type, record.Data, record.Machine)))
+	}
+	expected := []string{"api.example.com A 10.0.0.5 aaaaaa", "api.example.com A 10.0.0.6", "www.example.com CNAME node-2.maas"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected records %v, got %v", expected, got)
+	}
+}
+
+func TestDeleteDNSRecord(t *testing.T) {
+	cases := []struct {
+		name      string
+		resource  string
+		arguments map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{"one of several addresses", "api", map[string]any{"type": "A", "data": "10.0.0.6"}, false, []string{"api.example.com A 10.0.0.5", "www.example.com A 10.0.0.20", "www.example.com TXT web"}},
+		{"last address", "api", map[string]any{"type": "A", "data": "10.0.0.5"}, false, []string{"api.example.com A 10.0.0.6", "www.example.com A 10.0.0.20", "www.example.com TXT web"}},
+		{"last address next to other records", "www", map[string]any{"type": "A", "data": "10.0.0.20"}, true, []string{"api.example.com A 10.0.0.5", "api.example.com A 10.0.0.6", "www.example.com A 10.0.0.20", "www.example.com TXT web"}},
+		{"address without data", "api", map[string]any{"type": "A"}, true, []string{"api.example.com A 10.0.0.5", "api.example.com A 10.0.0.6", "www.example.com A 10.0.0.20", "www.example.com TXT web"}},
+		{"TXT record", "txt", map[string]any{"type": "TXT"}, false, []string{"api.example.com A 10.0.0.5", "api.example.com A 10.0.0.6", "www.example.com A 10.0.0.20"}},
+		{"unknown record", "", map[string]any{"type": "TXT", "id": "999"}, true, []string{"api.example.com A 10.0.0.5", "api.example.com A 10.0.0.6", "www.example.com A 10.0.0.20", "www.example.com TXT web"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := startDNS(t)
+			api := fake.AddDNSResource(fakemaas.DNSResource{Name: "api", Domain: "example.com", IPAddresses: []string{"10.0.0.5", "10.0.0.6"}})
+			www := fake.AddDNSResource(fakemaas.DNSResource{Name: "www", Domain: "example.com", IPAddresses: []string{"10.0.0.20"}, Records: []fakemaas.DNSRecord{{RRType: "TXT", RRData: "web"}}})
+			ids := map[string]int{"api": api.ID, "www": www.ID, "txt": www.Records[0].ID}
+			if id, ok := ids[tc.resource]; ok {
+				tc.arguments["id"] = strconv.Itoa(id)
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, DeleteDNSRecord{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if got := records(fake); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected records %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
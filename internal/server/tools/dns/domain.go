@@ -0,0 +1,179 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Domain struct {
+	Client maas_client.Client
+}
+
+func (d Domain) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteDomain{Client: d.Client}, ReadDomain{Client: d.Client}, UpdateDomain{Client: d.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type DeleteDomain struct {
+	Client maas_client.Client
+}
+
+func (DeleteDomain) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-domain",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the domain to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Domain", false, true, false, true)),
+		mcp.WithDescription("Delete a DNS domain with the given ID. The default domain and domains that still hold records cannot be deleted."),
+	)
+}
+
+func (d DeleteDomain) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	domainID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteDomain] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteDomain] Deleting domain with ID: %d", domainID))
+	if err := maas_api.New(d.Client).DeleteDomain(ctx, domainID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete domain %d err=%v", domainID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Domain %d deleted", domainID)), nil
+}
+
+type ReadDomain struct {
+	Client maas_client.Client
+}
+
+func (ReadDomain) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-domain",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the domain to retrieve."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Domain", true, false, false, true)),
+		mcp.WithDescription("Read a DNS domain with the given ID."),
+	)
+}
+
+func (r ReadDomain) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	domainID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadDomain] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadDomain] Retrieving domain with ID: %d", domainID))
+	domain, err := maas_api.New(r.Client).GetDomain(ctx, domainID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read domain %d err=%v", domainID, err)
+		zap.L().Error(fmt.Sprintf("[ReadDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(domain)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type UpdateDomain struct {
+	Client maas_client.Client
+}
+
+func (UpdateDomain) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-domain",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the domain to update."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Pattern(`^[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*$`),
+			mcp.Description("Name of the domain."),
+		),
+		mcp.WithBoolean(
+			"authoritative",
+			mcp.Description("Whether MAAS is authoritative for the domain."),
+		),
+		mcp.WithString(
+			"ttl",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The default TTL of the records of the domain, in seconds."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update Domain", false, false, false, true)),
+		mcp.WithDescription("Update a DNS domain with the given ID."),
+	)
+}
+
+func (u UpdateDomain) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	domainID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateDomain] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ttl, err := tools.OptionalInt(request, "ttl")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateDomain] Invalid parameter ttl err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.DomainParams{
+		Name:          request.GetString("name", ""),
+		Authoritative: tools.OptionalBool(request, "authoritative"),
+		TTL:           ttl,
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdateDomain] Updating domain with ID: %d", domainID))
+	domain, err := maas_api.New(u.Client).UpdateDomain(ctx, domainID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to update domain %d err=%v", domainID, err)
+		zap.L().Error(fmt.Sprintf("[UpdateDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(domain)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdateDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Domains struct {
+	Client maas_client.Client
+}
+
+func (d Domains) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListDomains{Client: d.Client}, CreateDomain{Client: d.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListDomains struct {
+	Client maas_client.Client
+}
+
+func (ListDomains) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-domains",
+		mcp.WithInputSchema[struct{}](),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Domains", true, false, false, true)),
+		mcp.WithDescription("Return all the DNS domains defined on the running instance of MAAS."),
+	)
+}
+
+func (l ListDomains) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	zap.L().Info("[ListDomains] Retrieving all domains...")
+	domains, err := maas_api.New(l.Client).ListDomains(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve all the domains: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListDomains] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(domains)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListDomains] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateDomain struct {
+	Client maas_client.Client
+}
+
+func (CreateDomain) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-domain",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*$`),
+			mcp.Description("Name of the domain, like example.com."),
+		),
+		mcp.WithBoolean(
+			"authoritative",
+			mcp.Description("Whether MAAS is authoritative for the domain. Defaults to true."),
+		),
+		mcp.WithString(
+			"ttl",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The default TTL of the records of the domain, in seconds."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Domain", false, false, false, true)),
+		mcp.WithDescription("Create a new DNS domain on the running instance of MAAS."),
+	)
+}
+
+func (c CreateDomain) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateDomain] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ttl, err := tools.OptionalInt(request, "ttl")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateDomain] Invalid parameter ttl err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.DomainParams{
+		Name:          name,
+		Authoritative: tools.OptionalBool(request, "authoritative"),
+		TTL:           ttl,
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateDomain] Creating domain %s...", name))
+	domain, err := maas_api.New(c.Client).CreateDomain(ctx, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create domain %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[CreateDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(domain)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateDomain] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
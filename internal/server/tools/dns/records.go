@@ -0,0 +1,442 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Records struct {
+	Client maas_client.Client
+}
+
+func (r Records) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListDNSRecords{Client: r.Client}, CreateDNSRecord{Client: r.Client}, DeleteDNSRecord{Client: r.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListDNSRecords struct {
+	Client maas_client.Client
+}
+
+func (ListDNSRecords) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-dns-records",
+		mcp.WithString(
+			"domain",
+			mcp.Description("Only return the records of this domain."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List DNS Records", true, false, false, true)),
+		mcp.WithDescription("Return the DNS records defined on the running instance of MAAS, each with the machine that owns it, if any. Records MAAS generates for the machines themselves are not included."),
+	)
+}
+
+func (l ListDNSRecords) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	domain := request.GetString("domain", "")
+	api := maas_api.New(l.Client)
+
+	zap.L().Info("[ListDNSRecords] Retrieving DNS records...")
+	resources, err := api.ListDNSResources(ctx, domain)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the DNS records: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListDNSRecords] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	machines, err := api.ListMachines(ctx, maas_api.MachineFilter{})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machines: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListDNSRecords] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	records := tools.DNSRecords(resources)
+	for i, record := range records {
+		for _, machine := range machines {
+			if !machine.Protected() && record.Owns(machine.FQDN, machine.IPAddresses) {
+				records[i].Machine = machine.SystemID
+				break
+			}
+		}
+	}
+
+	jsonData, err := json.Marshal(records)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListDNSRecords] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateDNSRecord struct {
+	Client maas_client.Client
+}
+
+func (CreateDNSRecord) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-dns-record",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w-]+(\.[\w-]+)*$`),
+			mcp.Description("The name of the record in the domain, like k3s-api or _http._tcp."),
+		),
+		mcp.WithString(
+			"domain",
+			mcp.Required(),
+			mcp.Description("The domain of the record."),
+		),
+		mcp.WithString(
+			"type",
+			mcp.Required(),
+			mcp.Enum("A", "AAAA", "CNAME", "TXT", "SRV"),
+			mcp.Description("The type of the record."),
+		),
+		mcp.WithString(
+			"machine",
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("Bind the record to this machine: A and AAAA records point at its addresses, CNAME and SRV records at its FQDN."),
+		),
+		mcp.WithString(
+			"ip",
+			mcp.Description("The static address of an A or AAAA record, when it is not bound to a machine."),
+		),
+		mcp.WithString(
+			"data",
+			mcp.Description("The target of a CNAME or SRV record that is not bound to a machine, or the text of a TXT record."),
+		),
+		mcp.WithString(
+			"port",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The port of an SRV record."),
+		),
+		mcp.WithString(
+			"priority",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The priority of an SRV record. Defaults to 0."),
+		),
+		mcp.WithString(
+			"weight",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The weight of an SRV record. Defaults to 0."),
+		),
+		mcp.WithString(
+			"ttl",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The TTL of the record in seconds. Defaults to the TTL of the domain."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create DNS Record", false, false, false, true)),
+		mcp.WithDescription("Create an A, AAAA, CNAME, TXT or SRV record bound to a machine or to a static address. Protected machines cannot be used."),
+	)
+}
+
+func (c CreateDNSRecord) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateDNSRecord] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	domain, err := request.RequireString("domain")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateDNSRecord] Required parameter domain not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	recordType, err := request.RequireString("type")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateDNSRecord] Required parameter type not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	recordType = strings.ToUpper(recordType)
+
+	ttl, err := tools.OptionalInt(request, "ttl")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateDNSRecord] Invalid parameter ttl err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	var machine *maas_api.Machine
+	if machineID := request.GetString("machine", ""); machineID != "" {
+		found, err := api.GetMachine(ctx, machineID)
+		if err != nil {
+			errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+			zap.L().Error(fmt.Sprintf("[CreateDNSRecord] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		if found.Protected() {
+			errMsg = fmt.Sprintf("Machine %s is protected and cannot be used", machineID)
+			zap.L().Error(fmt.Sprintf("[CreateDNSRecord] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		machine = &found
+	}
+
+	fqdn := name + "." + strings.TrimSuffix(domain, ".")
+
+	var records []tools.DNSRecord
+	switch recordType {
+	case "A", "AAAA":
+		records, err = createAddressRecord(ctx, api, fqdn, domain, recordType, machine, request.GetString("ip", ""), ttl)
+	default:
+		var data string
+		data, err = recordData(recordType, machine, request)
+		if err == nil {
+			records, err = createResourceRecord(ctx, api, fqdn, recordType, data, ttl)
+		}
+	}
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create %s record %s err=%v", recordType, fqdn, err)
+		zap.L().Error(fmt.Sprintf("[CreateDNSRecord] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if machine != nil {
+		for i := range records {
+			records[i].Machine = machine.SystemID
+		}
+	}
+
+	jsonData, err := json.Marshal(records)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateDNSRecord] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// createAddressRecord adds the static address ip, or the addresses of the
+// machine of the record type, to the DNS resource of fqdn. The resource is
+// created when the name has no records yet.
+func createAddressRecord(ctx context.Context, api *maas_api.API, fqdn, domain, recordType string, machine *maas_api.Machine, ip string, ttl *int) ([]tools.DNSRecord, error) {
+	var addresses []string
+	switch {
+	case machine != nil && ip != "":
+		return nil, fmt.Errorf("either machine or ip is required, not both")
+	case ip != "":
+		if _, err := netip.ParseAddr(ip); err != nil {
+			return nil, fmt.Errorf("invalid IP address %q", ip)
+		}
+		if tools.AddressRecordType(ip) != recordType {
+			return nil, fmt.Errorf("%s is not a valid address for a %s record", ip, recordType)
+		}
+		addresses = []string{ip}
+	case machine != nil:
+		for _, address := range machine.IPAddresses {
+			if tools.AddressRecordType(address) == recordType {
+				addresses = append(addresses, address)
+			}
+		}
+		if len(addresses) == 0 {
+			return nil, fmt.Errorf("machine %s has no address for a %s record", machine.SystemID, recordType)
+		}
+	default:
+		return nil, fmt.Errorf("either machine or ip is required")
+	}
+
+	resources, err := api.ListDNSResources(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource maas_api.DNSResource
+	index := slices.IndexFunc(resources, func(r maas_api.DNSResource) bool { return r.FQDN == fqdn })
+	if index >= 0 {
+		current := []string{}
+		for _, address := range resources[index].IPAddresses {
+			current = append(current, address.IP)
+		}
+		for _, address := range addresses {
+			if !slices.Contains(current, address) {
+				current = append(current, address)
+			}
+		}
+		resource, err = api.UpdateDNSResource(ctx, resources[index].ID, maas_api.DNSResourceParams{IPAddresses: strings.Join(current, " "), AddressTTL: ttl})
+	} else {
+		resource, err = api.CreateDNSResource(ctx, maas_api.DNSResourceParams{FQDN: fqdn, IPAddresses: strings.Join(addresses, " "), AddressTTL: ttl})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(tools.DNSRecords([]maas_api.DNSResource{resource}), func(record tools.DNSRecord) bool {
+		return !slices.Contains(addresses, record.Data)
+	}), nil
+}
+
+// recordData returns the data of a CNAME, TXT or SRV record. CNAME and SRV
+// records bound to a machine target its FQDN.
+func recordData(recordType string, machine *maas_api.Machine, request mcp.CallToolRequest) (string, error) {
+	data := request.GetString("data", "")
+	if machine != nil {
+		if recordType == "TXT" {
+			return "", fmt.Errorf("TXT records cannot be bound to a machine")
+		}
+		if data != "" {
+			return "", fmt.Errorf("either machine or data is required, not both")
+		}
+		data = machine.FQDN
+	}
+	if data == "" {
+		return "", fmt.Errorf("either machine or data is required")
+	}
+
+	if recordType != "SRV" {
+		return data, nil
+	}
+
+	port, err := tools.OptionalInt(request, "port")
+	if err != nil {
+		return "", err
+	}
+	if port == nil {
+		return "", fmt.Errorf("port is required for SRV records")
+	}
+	priority, err := tools.OptionalInt(request, "priority")
+	if err != nil {
+		return "", err
+	}
+	weight, err := tools.OptionalInt(request, "weight")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %d %d %s", valueOrZero(priority), valueOrZero(weight), *port, data), nil
+}
+
+func valueOrZero(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func createResourceRecord(ctx context.Context, api *maas_api.API, fqdn, recordType, data string, ttl *int) ([]tools.DNSRecord, error) {
+	record, err := api.CreateDNSResourceRecord(ctx, maas_api.DNSResourceRecordParams{FQDN: fqdn, RRType: recordType, RRData: data, TTL: ttl})
+	if err != nil {
+		return nil, err
+	}
+
+	return []tools.DNSRecord{{
+		ID:   record.ID,
+		FQDN: record.FQDN,
+		Type: strings.ToUpper(record.RRType),
+		Data: record.RRData,
+		TTL:  record.TTL,
+	}}, nil
+}
+
+type DeleteDNSRecord struct {
+	Client maas_client.Client
+}
+
+func (DeleteDNSRecord) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-dns-record",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the record, as returned by list-dns-records."),
+		),
+		mcp.WithString(
+			"type",
+			mcp.Required(),
+			mcp.Enum("A", "AAAA", "CNAME", "TXT", "SRV"),
+			mcp.Description("The type of the record."),
+		),
+		mcp.WithString(
+			"data",
+			mcp.Description("The address of an A or AAAA record. Required for those records, since they share the ID of their name."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete DNS Record", false, true, false, true)),
+		mcp.WithDescription("Delete a DNS record."),
+	)
+}
+
+func (d DeleteDNSRecord) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	recordID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteDNSRecord] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	recordType, err := request.RequireString("type")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteDNSRecord] Required parameter type not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	recordType = strings.ToUpper(recordType)
+
+	api := maas_api.New(d.Client)
+
+	zap.L().Info(fmt.Sprintf("[DeleteDNSRecord] Deleting %s record with ID: %d", recordType, recordID))
+	if recordType == "A" || recordType == "AAAA" {
+		err = deleteAddressRecord(ctx, api, recordID, request.GetString("data", ""))
+	} else {
+		err = api.DeleteDNSResourceRecord(ctx, recordID)
+	}
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to delete %s record %d err=%v", recordType, recordID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteDNSRecord] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%s record %d deleted", recordType, recordID)), nil
+}
+
+// deleteAddressRecord removes the address from the DNS resource with the
+// given ID, and deletes the resource once it has no records left.
+func deleteAddressRecord(ctx context.Context, api *maas_api.API, id int, address string) error {
+	if address == "" {
+		return fmt.Errorf("data is required for A and AAAA records")
+	}
+
+	resource, err := api.GetDNSResource(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var remaining []string
+	for _, current := range resource.IPAddresses {
+		if current.IP != address {
+			remaining = append(remaining, current.IP)
+		}
+	}
+	if len(remaining) == len(resource.IPAddresses) {
+		return fmt.Errorf("%s has no address %s", resource.FQDN, address)
+	}
+
+	if len(remaining) > 0 {
+		_, err = api.UpdateDNSResource(ctx, id, maas_api.DNSResourceParams{IPAddresses: strings.Join(remaining, " ")})
+		return err
+	}
+	if len(resource.ResourceRecords) > 0 {
+		return fmt.Errorf("%s has other records, delete them first", resource.FQDN)
+	}
+	return api.DeleteDNSResource(ctx, id)
+}
@@ -4,25 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Events struct{}
+type Events struct {
+	Client maas_client.Client
+}
 
-func (Events) Register(mcpServer *server.MCPServer) {
-	mcpTools := []MCPTool{GetEvents{}}
+func (e Events) Register(mcpServer registry.ToolServer) {
+	mcpTools := []MCPTool{GetEvents{Client: e.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type GetEvents struct{}
+type GetEvents struct {
+	Client maas_client.Client
+}
 
 func (GetEvents) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -48,58 +52,39 @@ func (GetEvents) Create() mcp.Tool {
 		mcp.WithString(
 			"before",
 			mcp.DefaultString(""),
+			mcp.Pattern("^[0-9]*$"),
 			mcp.Description("The id of the event to return the events before it."),
 		),
 		mcp.WithString(
 			"after",
 			mcp.DefaultString(""),
+			mcp.Pattern("^[0-9]*$"),
 			mcp.Description("The id of the event to return the events after it."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Events", true, false, false, true)),
 		mcp.WithDescription("Get all the events from the MAAS envrionment."),
 	)
 }
 
-func (GetEvents) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (g GetEvents) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	level := request.GetString("level", "")
-	limit := request.GetFloat("limit", 1000)
-	before := request.GetString("before", "")
-	after := request.GetString("after", "")
-
-	query := url.Values{}
-	query.Add("limit", fmt.Sprintf("%.0f", limit))
-
-	if level != "" {
-		query.Add("level", level)
-	}
-	if before != "" {
-		query.Add("before", before)
-	}
-	if after != "" {
-		query.Add("after", after)
+	filter := maas_api.EventFilter{
+		Level:  request.GetString("level", ""),
+		Limit:  int(request.GetFloat("limit", 1000)),
+		Before: request.GetInt("before", 0),
+		After:  request.GetInt("after", 0),
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/events/?%s", query.Encode())
-
-	client := maas_client.MustClient()
-
 	zap.L().Info("[GetEvents] Retrieving events...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	page, err := maas_api.New(g.Client).ListEvents(ctx, filter)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve events: %v", err)
 		zap.L().Error(fmt.Sprintf("[GetEvents] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	var events []map[string]any
-	if err := json.Unmarshal([]byte(resultData), &events); err != nil {
-		errMsg = fmt.Sprintf("Failed to unmarshal events: %v", err)
-		zap.L().Error(fmt.Sprintf("[GetEvents] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	response, err := json.Marshal(events)
+	response, err := json.Marshal(page.Events)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to marshal events: %v", err)
 		zap.L().Error(fmt.Sprintf("[GetEvents] %s", errMsg))
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestGetEvents(t *testing.T) {
+	fake := fakemaas.Start(t)
+	fake.TransitionReads = 0
+	m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusNew})
+	_ = fakemaas.CallTool(t, CommissionMachine{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID})
+	_ = fakemaas.CallTool(t, ChangePowerState{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "state": true})
+
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  []string
+	}{
+		{"newest first", map[string]any{}, []string{"Powering on", "Status changed", "Commissioning"}},
+		{"limit", map[string]any{"limit": 1.0}, []string{"Powering on"}},
+		{"minimum level", map[string]any{"level": "ERROR"}, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			result := fakemaas.CallTool(t, GetEvents{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var events []map[string]any
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &events); err != nil {
+				t.Fatalf("expected a list of events, got %v", err)
+			}
+			var types []string
+			for _, event := range events {
+				types = append(types, event["type"].(string))
+			}
+			if strings.Join(types, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("expected events %v, got %v", tc.expected, types)
+			}
+		})
+	}
+}
@@ -4,27 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Fabric struct{}
+type Fabric struct {
+	Client maas_client.Client
+}
 
-func (Fabric) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{DeleteFabric{}, ReadFabric{}, UpdateFabric{}}
+func (f Fabric) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteFabric{Client: f.Client}, ReadFabric{Client: f.Client}, UpdateFabric{Client: f.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type DeleteFabric struct{}
+type DeleteFabric struct {
+	Client maas_client.Client
+}
 
 func (DeleteFabric) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -40,38 +43,28 @@ func (DeleteFabric) Create() mcp.Tool {
 	)
 }
 
-func (DeleteFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DeleteFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("id")
+	fabricID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[DeleteFabric] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/", fabricID)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[DeleteFabric] Deleting fabric with ID: %s", fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeDelete, path, nil)
-	if err != nil {
-		errMsg = fmt.Sprintf("Failed to delete fabric %s err=%v", fabricID, err)
-		zap.L().Error(fmt.Sprintf("[DeleteFabric] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	jsonData, err := json.Marshal(resultData)
-	if err != nil {
-		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+	zap.L().Info(fmt.Sprintf("[DeleteFabric] Deleting fabric with ID: %d", fabricID))
+	if err := maas_api.New(d.Client).DeleteFabric(ctx, fabricID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete fabric %d err=%v", fabricID, err)
 		zap.L().Error(fmt.Sprintf("[DeleteFabric] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	return mcp.NewToolResultText(string(jsonData)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Fabric %d deleted", fabricID)), nil
 }
 
-type ReadFabric struct{}
+type ReadFabric struct {
+	Client maas_client.Client
+}
 
 func (ReadFabric) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -87,28 +80,24 @@ func (ReadFabric) Create() mcp.Tool {
 	)
 }
 
-func (ReadFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (r ReadFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("id")
+	fabricID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ReadFabric] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/", fabricID)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[ReadFabric] Retrieving fabric with ID: %s", fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[ReadFabric] Retrieving fabric with ID: %d", fabricID))
+	fabric, err := maas_api.New(r.Client).GetFabric(ctx, fabricID)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to read fabric %s err=%v", fabricID, err)
+		errMsg = fmt.Sprintf("Failed to read fabric %d err=%v", fabricID, err)
 		zap.L().Error(fmt.Sprintf("[ReadFabric] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(fabric)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ReadFabric] %s", errMsg))
@@ -118,7 +107,9 @@ func (ReadFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type UpdateFabric struct{}
+type UpdateFabric struct {
+	Client maas_client.Client
+}
 
 func (UpdateFabric) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -146,40 +137,30 @@ func (UpdateFabric) Create() mcp.Tool {
 	)
 }
 
-func (UpdateFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (u UpdateFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("id")
+	fabricID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[UpdateFabric] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-
-	if name := request.GetString("name", ""); name != "" {
-		form.Add("name", name)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if classType := request.GetString("class_type", ""); classType != "" {
-		form.Add("class_type", classType)
+	params := maas_api.FabricParams{
+		Name:        request.GetString("name", ""),
+		Description: request.GetString("description", ""),
+		ClassType:   request.GetString("class_type", ""),
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/", fabricID)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[UpdateFabric] Updating fabric with ID: %s", fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePut, path, strings.NewReader(form.Encode()))
+	zap.L().Info(fmt.Sprintf("[UpdateFabric] Updating fabric with ID: %d", fabricID))
+	fabric, err := maas_api.New(u.Client).UpdateFabric(ctx, fabricID, params)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to update fabric %s err=%v", fabricID, err)
+		errMsg = fmt.Sprintf("Failed to update fabric %d err=%v", fabricID, err)
 		zap.L().Error(fmt.Sprintf("[UpdateFabric] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(fabric)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[UpdateFabric] %s", errMsg))
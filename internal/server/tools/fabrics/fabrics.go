@@ -4,27 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Fabrics struct{}
+type Fabrics struct {
+	Client maas_client.Client
+}
 
-func (Fabrics) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{ListFabrics{}, CreateFabric{}}
+func (f Fabrics) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListFabrics{Client: f.Client}, CreateFabric{Client: f.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListFabrics struct{}
+type ListFabrics struct {
+	Client maas_client.Client
+}
 
 func (ListFabrics) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -35,21 +38,18 @@ func (ListFabrics) Create() mcp.Tool {
 	)
 }
 
-func (ListFabrics) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListFabrics) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/fabrics/"
-
-	client := maas_client.MustClient()
 
 	zap.L().Info("[ListFabrics] Retrieving all fabrics...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	fabrics, err := maas_api.New(l.Client).ListFabrics(ctx)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve all the fabrics: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListFabrics] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(fabrics)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListFabrics] %s", errMsg))
@@ -59,7 +59,9 @@ func (ListFabrics) Handle(ctx context.Context, request mcp.CallToolRequest) (*mc
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type CreateFabric struct{}
+type CreateFabric struct {
+	Client maas_client.Client
+}
 
 func (CreateFabric) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -81,33 +83,24 @@ func (CreateFabric) Create() mcp.Tool {
 	)
 }
 
-func (CreateFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c CreateFabric) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/fabrics/"
 
-	form := make(url.Values)
-
-	if name := request.GetString("name", ""); name != "" {
-		form.Add("name", name)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
+	params := maas_api.FabricParams{
+		Name:        request.GetString("name", ""),
+		Description: request.GetString("description", ""),
+		ClassType:   request.GetString("class_type", ""),
 	}
-	if classType := request.GetString("class_type", ""); classType != "" {
-		form.Add("class_type", classType)
-	}
-
-	client := maas_client.MustClient()
 
 	zap.L().Info("[CreateFabric] Creating fabric...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	fabric, err := maas_api.New(c.Client).CreateFabric(ctx, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to create fabric err=%v", err)
 		zap.L().Error(fmt.Sprintf("[CreateFabric] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(fabric)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[CreateFabric] %s", errMsg))
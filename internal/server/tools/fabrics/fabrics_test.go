@@ -0,0 +1,80 @@
+package fabrics
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestFabricTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments func(id string) map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{
+			name:      "list fabrics",
+			tool:      "list-fabrics",
+			arguments: func(id string) map[string]any { return map[string]any{} },
+			expected:  []string{"fabric-0"},
+		},
+		{
+			name:      "create fabric",
+			tool:      "create-fabric",
+			arguments: func(id string) map[string]any { return map[string]any{"name": "fabric-new", "class_type": "10g"} },
+			expected:  []string{"fabric-0", "fabric-new"},
+		},
+		{
+			name:      "read fabric",
+			tool:      "read-fabric",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{"fabric-0"},
+		},
+		{
+			name:      "read unknown fabric",
+			tool:      "read-fabric",
+			arguments: func(id string) map[string]any { return map[string]any{"id": "999"} },
+			isError:   true,
+			expected:  []string{"fabric-0"},
+		},
+		{
+			name:      "update fabric",
+			tool:      "update-fabric",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id, "name": "renamed"} },
+			expected:  []string{"renamed"},
+		},
+		{
+			name:      "delete fabric",
+			tool:      "delete-fabric",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fabric := fake.AddFabric(fakemaas.Fabric{Name: "fabric-0"})
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Fabrics{Client: fake.Client()}, Fabric{Client: fake.Client()})[tc.tool], tc.arguments(strconv.Itoa(fabric.ID)))
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			names := []string{}
+			for _, f := range fake.Fabrics() {
+				names = append(names, f.Name)
+			}
+			if !slices.Equal(names, tc.expected) {
+				t.Errorf("expected fabrics %v, got %v", tc.expected, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Interface struct {
+	Client maas_client.Client
+}
+
+func (i Interface) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteInterface{Client: i.Client}, LinkSubnet{Client: i.Client}, UnlinkSubnet{Client: i.Client}, SetDefaultGateway{Client: i.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type DeleteInterface struct {
+	Client maas_client.Client
+}
+
+func (DeleteInterface) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-interface",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"interface",
+			mcp.Required(),
+			mcp.Description("The name or ID of the interface to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Interface", false, true, false, true)),
+		mcp.WithDescription("Delete a bond, bridge or VLAN interface of a machine. Interfaces that other interfaces are built on cannot be deleted. The machine must be Ready or Allocated."),
+	)
+}
+
+func (d DeleteInterface) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteInterface] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ref, err := request.RequireString("interface")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteInterface] Required parameter interface not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	iface, err := machineInterface(ctx, api, machineID, ref)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteInterface] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(iface.Children) > 0 {
+		errMsg = fmt.Sprintf("Interface %s is the parent of %s, delete those first", iface.Name, strings.Join(iface.Children, ", "))
+		zap.L().Error(fmt.Sprintf("[DeleteInterface] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteInterface] Deleting interface %s of machine %s", iface.Name, machineID))
+	if err := api.DeleteInterface(ctx, machineID, iface.ID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete interface %s of machine %s err=%v", iface.Name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteInterface] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Interface %s of machine %s deleted", iface.Name, machineID)), nil
+}
+
+type LinkSubnet struct {
+	Client maas_client.Client
+}
+
+func (LinkSubnet) Create() mcp.Tool {
+	return mcp.NewTool(
+		"link-subnet",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"interface",
+			mcp.Required(),
+			mcp.Description("The name or ID of the interface to link."),
+		),
+		mcp.WithString(
+			"mode",
+			mcp.Required(),
+			mcp.Enum(maas_api.LinkModeAuto, maas_api.LinkModeDHCP, maas_api.LinkModeStatic, maas_api.LinkModeLinkUp),
+			mcp.Description("AUTO assigns an address from the subnet at deployment, DHCP leaves the address to a DHCP server, STATIC assigns ip_address, or a free address when omitted, and LINK_UP only brings the interface up."),
+		),
+		mcp.WithString(
+			"subnet_id",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the subnet. Required for AUTO and STATIC."),
+		),
+		mcp.WithString(
+			"ip_address",
+			mcp.Description("The address to assign in STATIC mode. It must lie in the subnet."),
+		),
+		mcp.WithBoolean(
+			"default_gateway",
+			mcp.Description("Route the machine through the gateway of the subnet. Only for AUTO and STATIC links."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Link Subnet", false, false, false, true)),
+		mcp.WithDescription("Link an interface of a machine to a subnet. The machine must be Ready or Allocated."),
+	)
+}
+
+func (l LinkSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ref, err := request.RequireString("interface")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] Required parameter interface not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mode, err := request.RequireString("mode")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] Required parameter mode not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	mode = strings.ToUpper(mode)
+
+	subnetID, err := tools.OptionalInt(request, "subnet_id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] Invalid parameter subnet_id err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.LinkSubnetParams{
+		Mode:           mode,
+		IPAddress:      request.GetString("ip_address", ""),
+		DefaultGateway: request.GetBool("default_gateway", false),
+	}
+
+	api := maas_api.New(l.Client)
+
+	if subnetID != nil {
+		subnet, err := api.GetSubnet(ctx, *subnetID)
+		if err != nil {
+			errMsg = fmt.Sprintf("Failed to retrieve subnet %d err=%v", *subnetID, err)
+			zap.L().Error(fmt.Sprintf("[LinkSubnet] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		params.Subnet = subnet.ID
+		if err := checkLink(params, subnet); err != nil {
+			zap.L().Error(fmt.Sprintf("[LinkSubnet] %v", err))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	} else if err := checkLink(params, maas_api.Subnet{}); err != nil {
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	iface, err := machineInterface(ctx, api, machineID, ref)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[LinkSubnet] Linking interface %s of machine %s in %s mode...", iface.Name, machineID, mode))
+	iface, err = api.LinkSubnet(ctx, machineID, iface.ID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to link interface %s of machine %s err=%v", ref, machineID, err)
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(iface)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[LinkSubnet] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// checkLink checks a link before it is sent to MAAS. subnet is the zero
+// Subnet when the link has none.
+func checkLink(params maas_api.LinkSubnetParams, subnet maas_api.Subnet) error {
+	static := params.Mode == maas_api.LinkModeStatic
+	if subnet.ID == 0 && (static || params.Mode == maas_api.LinkModeAuto) {
+		return fmt.Errorf("subnet_id is required in %s mode", params.Mode)
+	}
+	if params.IPAddress != "" && !static {
+		return fmt.Errorf("ip_address can only be set in %s mode", maas_api.LinkModeStatic)
+	}
+	if params.DefaultGateway && !static && params.Mode != maas_api.LinkModeAuto {
+		return fmt.Errorf("default_gateway can only be set in %s and %s modes", maas_api.LinkModeAuto, maas_api.LinkModeStatic)
+	}
+	if params.DefaultGateway && subnet.GatewayIP == "" {
+		return fmt.Errorf("subnet %s has no gateway", subnet.CIDR)
+	}
+
+	if params.IPAddress == "" {
+		return nil
+	}
+	addr, err := netip.ParseAddr(params.IPAddress)
+	if err != nil {
+		return fmt.Errorf("invalid IP address %q", params.IPAddress)
+	}
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil {
+		return fmt.Errorf("subnet %d has an invalid CIDR %q", subnet.ID, subnet.CIDR)
+	}
+	if !prefix.Contains(addr) {
+		return fmt.Errorf("%s is not in subnet %s", params.IPAddress, subnet.CIDR)
+	}
+	if params.IPAddress == subnet.GatewayIP {
+		return fmt.Errorf("%s is the gateway of subnet %s", params.IPAddress, subnet.CIDR)
+	}
+	return nil
+}
+
+type UnlinkSubnet struct {
+	Client maas_client.Client
+}
+
+func (UnlinkSubnet) Create() mcp.Tool {
+	return mcp.NewTool(
+		"unlink-subnet",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"interface",
+			mcp.Required(),
+			mcp.Description("The name or ID of the interface."),
+		),
+		mcp.WithString(
+			"link_id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the link to remove, as returned by list-interfaces."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Unlink Subnet", false, true, false, true)),
+		mcp.WithDescription("Remove a subnet link from an interface of a machine, releasing its address. The machine must be Ready or Allocated."),
+	)
+}
+
+func (u UnlinkSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ref, err := request.RequireString("interface")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] Required parameter interface not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	linkID, err := request.RequireInt("link_id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] Required parameter link_id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(u.Client)
+
+	iface, err := machineInterface(ctx, api, machineID, ref)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !hasLink(iface, linkID) {
+		errMsg = fmt.Sprintf("Interface %s has no link %d", iface.Name, linkID)
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[UnlinkSubnet] Removing link %d from interface %s of machine %s", linkID, iface.Name, machineID))
+	iface, err = api.UnlinkSubnet(ctx, machineID, iface.ID, linkID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to remove link %d from interface %s of machine %s err=%v", linkID, ref, machineID, err)
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(iface)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UnlinkSubnet] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+func hasLink(iface maas_api.Interface, linkID int) bool {
+	for _, link := range iface.Links {
+		if link.ID == linkID {
+			return true
+		}
+	}
+	return false
+}
+
+type SetDefaultGateway struct {
+	Client maas_client.Client
+}
+
+func (SetDefaultGateway) Create() mcp.Tool {
+	return mcp.NewTool(
+		"set-default-gateway",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"interface",
+			mcp.Required(),
+			mcp.Description("The name or ID of the interface to route through."),
+		),
+		mcp.WithString(
+			"link_id",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the AUTO or STATIC link whose subnet gateway to use. MAAS picks one of the links of the interface when omitted."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Set Default Gateway", false, false, true, true)),
+		mcp.WithDescription("Set the default gateway of a machine to the gateway of a subnet linked to one of its interfaces. The machine must be Ready or Allocated."),
+	)
+}
+
+func (s SetDefaultGateway) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ref, err := request.RequireString("interface")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] Required parameter interface not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	linkID, err := tools.OptionalInt(request, "link_id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] Invalid parameter link_id err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(s.Client)
+
+	iface, err := machineInterface(ctx, api, machineID, ref)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if linkID != nil && !hasLink(iface, *linkID) {
+		errMsg = fmt.Sprintf("Interface %s has no link %d", iface.Name, *linkID)
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[SetDefaultGateway] Routing machine %s through interface %s", machineID, iface.Name))
+	iface, err = api.SetDefaultGateway(ctx, machineID, iface.ID, linkID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to set the default gateway of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(iface)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[SetDefaultGateway] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,442 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// findInterface returns the interface of the machine with the given name or
+// ID.
+func findInterface(interfaces []maas_api.Interface, ref string) (maas_api.Interface, error) {
+	for _, iface := range interfaces {
+		if iface.Name == ref || strconv.Itoa(iface.ID) == ref {
+			return iface, nil
+		}
+	}
+	return maas_api.Interface{}, fmt.Errorf("the machine has no interface %s", ref)
+}
+
+// machineInterface checks that the interfaces of the machine can be changed
+// and returns the one with the given name or ID.
+func machineInterface(ctx context.Context, api *maas_api.API, systemID, ref string) (maas_api.Interface, error) {
+	if _, err := tools.ConfigurableMachine(ctx, api, systemID, "network"); err != nil {
+		return maas_api.Interface{}, err
+	}
+
+	interfaces, err := api.ListInterfaces(ctx, systemID)
+	if err != nil {
+		return maas_api.Interface{}, fmt.Errorf("failed to retrieve the interfaces of machine %s err=%v", systemID, err)
+	}
+	return findInterface(interfaces, ref)
+}
+
+type Interfaces struct {
+	Client maas_client.Client
+}
+
+func (i Interfaces) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListInterfaces{Client: i.Client}, CreateBond{Client: i.Client}, CreateBridge{Client: i.Client}, CreateVLANInterface{Client: i.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListInterfaces struct {
+	Client maas_client.Client
+}
+
+func (ListInterfaces) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-interfaces",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Interfaces", true, false, false, true)),
+		mcp.WithDescription("Return the network interfaces of a machine with their VLANs and subnet links."),
+	)
+}
+
+func (l ListInterfaces) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ListInterfaces] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(l.Client)
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[ListInterfaces] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if machine.Protected() {
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ListInterfaces] Retrieving the interfaces of machine %s...", machineID))
+	interfaces, err := api.ListInterfaces(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the interfaces of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[ListInterfaces] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(interfaces)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListInterfaces] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateBond struct {
+	Client maas_client.Client
+}
+
+func (CreateBond) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-bond",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w.-]+$`),
+			mcp.Description("Name of the bond, like bond0."),
+		),
+		mcp.WithArray(
+			"parents",
+			mcp.Required(),
+			mcp.WithStringItems(),
+			mcp.Description("The names or IDs of the interfaces to bond. Their subnet links move to the bond."),
+		),
+		mcp.WithString(
+			"bond_mode",
+			mcp.Enum("balance-rr", "active-backup", "balance-xor", "broadcast", "802.3ad", "balance-tlb", "balance-alb"),
+			mcp.Description("The bonding mode. Defaults to balance-rr."),
+		),
+		mcp.WithString(
+			"mac_address",
+			mcp.Description("The MAC address of the bond. Defaults to the MAC address of the first parent."),
+		),
+		mcp.WithString(
+			"mtu",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The MTU of the bond."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Bond", false, false, false, true)),
+		mcp.WithDescription("Bond interfaces of a machine. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreateBond) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBond] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBond] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	parents := request.GetStringSlice("parents", nil)
+	if len(parents) == 0 {
+		errMsg = "At least one parent interface is required"
+		zap.L().Error(fmt.Sprintf("[CreateBond] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	mtu, err := tools.OptionalInt(request, "mtu")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBond] Invalid parameter mtu err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	if _, err := tools.ConfigurableMachine(ctx, api, machineID, "network"); err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBond] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	interfaces, err := api.ListInterfaces(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the interfaces of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateBond] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	params := maas_api.BondParams{
+		Name:       name,
+		MACAddress: request.GetString("mac_address", ""),
+		BondMode:   request.GetString("bond_mode", ""),
+		MTU:        mtu,
+	}
+	for _, ref := range parents {
+		parent, err := findInterface(interfaces, ref)
+		if err != nil {
+			zap.L().Error(fmt.Sprintf("[CreateBond] %v", err))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		params.Parents = append(params.Parents, parent.ID)
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateBond] Creating bond %s on machine %s...", name, machineID))
+	bond, err := api.CreateBond(ctx, machineID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create bond %s on machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateBond] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(bond)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateBond] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateBridge struct {
+	Client maas_client.Client
+}
+
+func (CreateBridge) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-bridge",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w.-]+$`),
+			mcp.Description("Name of the bridge, like br0."),
+		),
+		mcp.WithString(
+			"parent",
+			mcp.Required(),
+			mcp.Description("The name or ID of the interface to bridge. Its subnet links move to the bridge."),
+		),
+		mcp.WithBoolean(
+			"bridge_stp",
+			mcp.Description("Whether to turn on the spanning tree protocol. Defaults to false."),
+		),
+		mcp.WithString(
+			"bridge_fd",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The forward delay of the bridge in seconds. Defaults to 15."),
+		),
+		mcp.WithString(
+			"mac_address",
+			mcp.Description("The MAC address of the bridge. Defaults to the MAC address of the parent."),
+		),
+		mcp.WithString(
+			"mtu",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The MTU of the bridge."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Bridge", false, false, false, true)),
+		mcp.WithDescription("Create a bridge on top of an interface of a machine, for example to attach VMs or containers. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreateBridge) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBridge] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBridge] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	parentRef, err := request.RequireString("parent")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBridge] Required parameter parent not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	bridgeFD, err := tools.OptionalInt(request, "bridge_fd")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBridge] Invalid parameter bridge_fd err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mtu, err := tools.OptionalInt(request, "mtu")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBridge] Invalid parameter mtu err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	parent, err := machineInterface(ctx, api, machineID, parentRef)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateBridge] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.BridgeParams{
+		Name:       name,
+		Parent:     parent.ID,
+		MACAddress: request.GetString("mac_address", ""),
+		BridgeSTP:  tools.OptionalBool(request, "bridge_stp"),
+		BridgeFD:   bridgeFD,
+		MTU:        mtu,
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateBridge] Creating bridge %s on machine %s...", name, machineID))
+	bridge, err := api.CreateBridge(ctx, machineID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create bridge %s on machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateBridge] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(bridge)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateBridge] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateVLANInterface struct {
+	Client maas_client.Client
+}
+
+func (CreateVLANInterface) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-vlan-interface",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"parent",
+			mcp.Required(),
+			mcp.Description("The name or ID of the interface to tag the traffic of."),
+		),
+		mcp.WithString(
+			"vid",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The VID of the VLAN. The VLAN must exist on the fabric of the parent interface."),
+		),
+		mcp.WithString(
+			"mtu",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The MTU of the VLAN interface."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create VLAN Interface", false, false, false, true)),
+		mcp.WithDescription("Create a tagged VLAN sub-interface, like eth0.100, on top of an interface of a machine. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreateVLANInterface) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	parentRef, err := request.RequireString("parent")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] Required parameter parent not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	vid, err := request.RequireInt("vid")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] Required parameter vid not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mtu, err := tools.OptionalInt(request, "mtu")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] Invalid parameter mtu err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	parent, err := machineInterface(ctx, api, machineID, parentRef)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if parent.VLAN == nil {
+		errMsg = fmt.Sprintf("Interface %s is not connected to a fabric", parent.Name)
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	vlan, err := api.GetVLAN(ctx, parent.VLAN.FabricID, vid)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve VLAN %d of fabric %s err=%v", vid, parent.VLAN.Fabric, err)
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateVLANInterface] Creating VLAN %d interface on %s of machine %s...", vid, parent.Name, machineID))
+	iface, err := api.CreateVLANInterface(ctx, machineID, maas_api.VLANInterfaceParams{VLAN: vlan.ID, Parent: parent.ID, MTU: mtu})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create VLAN %d interface on machine %s err=%v", vid, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(iface)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateVLANInterface] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,246 @@
+package interfaces
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+// startMachine starts a fake with a machine aaaaaa in the given status. eth0
+// is linked to 10.0.0.0/24 in AUTO mode, eth1 is not linked.
+func startMachine(t *testing.T, status string, tags ...string) (*fakemaas.Server, fakemaas.Subnet) {
+	fake := fakemaas.Start(t)
+	fabric := fake.AddFabric(fakemaas.Fabric{})
+	fake.AddVLAN(fakemaas.VLAN{FabricID: fabric.ID, VID: 100})
+	subnet := fake.AddSubnet(fakemaas.Subnet{CIDR: "10.0.0.0/24", GatewayIP: "10.0.0.1"})
+	fake.AddMachine(fakemaas.Machine{
+		SystemID: "aaaaaa",
+		Status:   status,
+		TagNames: tags,
+		Interfaces: []fakemaas.Interface{
+			{Name: "eth0", MACAddress: "52:54:00:00:00:01", VLANID: subnet.VLANID, Links: []fakemaas.Link{{Mode: "auto", SubnetID: subnet.ID}}},
+			{Name: "eth1", MACAddress: "52:54:00:00:00:02", VLANID: subnet.VLANID},
+		},
+	})
+	return fake, subnet
+}
+
+// interfaces returns the interfaces of machine aaaaaa as "name(parents)"
+// strings.
+func interfaces(fake *fakemaas.Server) []string {
+	machine, _ := fake.Machine("aaaaaa")
+	var result []string
+	for _, iface := range machine.Interfaces {
+		result = append(result, fmt.Sprintf("%s(%s)", iface.Name, strings.Join(iface.Parents, ",")))
+	}
+	return result
+}
+
+func TestInterfaceTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		status    string
+		tags      []string
+		arguments map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{"list interfaces", "list-interfaces", fakemaas.StatusDeployed, nil, map[string]any{}, false, []string{"eth0()", "eth1()"}},
+		{"list interfaces of a protected machine", "list-interfaces", fakemaas.StatusReady, []string{"protected"}, map[string]any{}, true, []string{"eth0()", "eth1()"}},
+		{"create bond", "create-bond", fakemaas.StatusReady, nil, map[string]any{"name": "bond0", "parents": []any{"eth0", "eth1"}, "bond_mode": "802.3ad"}, false, []string{"eth0()", "eth1()", "bond0(eth0,eth1)"}},
+		{"create bond on an allocated machine", "create-bond", fakemaas.StatusAllocated, nil, map[string]any{"name": "bond0", "parents": []any{"eth0", "eth1"}}, false, []string{"eth0()", "eth1()", "bond0(eth0,eth1)"}},
+		{"create bond on a deployed machine", "create-bond", fakemaas.StatusDeployed, nil, map[string]any{"name": "bond0", "parents": []any{"eth0", "eth1"}}, true, []string{"eth0()", "eth1()"}},
+		{"create bond on a protected machine", "create-bond", fakemaas.StatusReady, []string{"protected"}, map[string]any{"name": "bond0", "parents": []any{"eth0", "eth1"}}, true, []string{"eth0()", "eth1()"}},
+		{"create bond with an unknown parent", "create-bond", fakemaas.StatusReady, nil, map[string]any{"name": "bond0", "parents": []any{"eth0", "eth9"}}, true, []string{"eth0()", "eth1()"}},
+		{"create bridge", "create-bridge", fakemaas.StatusReady, nil, map[string]any{"name": "br0", "parent": "eth1", "bridge_stp": true}, false, []string{"eth0()", "eth1()", "br0(eth1)"}},
+		{"create bridge on a new machine", "create-bridge", fakemaas.StatusNew, nil, map[string]any{"name": "br0", "parent": "eth1"}, true, []string{"eth0()", "eth1()"}},
+		{"create VLAN interface", "create-vlan-interface", fakemaas.StatusReady, nil, map[string]any{"parent": "eth0", "vid": "100"}, false, []string{"eth0()", "eth1()", "eth0.100(eth0)"}},
+		{"create VLAN interface on an unknown VLAN", "create-vlan-interface", fakemaas.StatusReady, nil, map[string]any{"parent": "eth0", "vid": "200"}, true, []string{"eth0()", "eth1()"}},
+		{"delete interface", "delete-interface", fakemaas.StatusReady, nil, map[string]any{"interface": "eth1"}, false, []string{"eth0()"}},
+		{"delete interface of a deployed machine", "delete-interface", fakemaas.StatusDeployed, nil, map[string]any{"interface": "eth1"}, true, []string{"eth0()", "eth1()"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake, _ := startMachine(t, tc.status, tc.tags...)
+			tc.arguments["id"] = "aaaaaa"
+			client := fake.Client()
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Interfaces{Client: client}, Interface{Client: client})[tc.tool], tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if got := interfaces(fake); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected interfaces %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCreateBond_ExplainsStatus(t *testing.T) {
+	cases := []struct {
+		status   string
+		expected string
+	}{
+		{fakemaas.StatusDeployed, "Release the machine first"},
+		{fakemaas.StatusCommissioning, "Commission the machine first"},
+		{fakemaas.StatusBroken, "only accepts network changes while a machine is Ready or Allocated"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.status, func(t *testing.T) {
+			// Arrange
+			fake, _ := startMachine(t, tc.status)
+
+			// Act
+			result := fakemaas.CallTool(t, CreateBond{Client: fake.Client()}.Handle, map[string]any{"id": "aaaaaa", "name": "bond0", "parents": []any{"eth0", "eth1"}})
+
+			// Assert
+			if !result.IsError {
+				t.Fatalf("expected an error result")
+			}
+			if text := fakemaas.ResultText(t, result); !strings.Contains(text, tc.expected) {
+				t.Errorf("expected %q in %q", tc.expected, text)
+			}
+		})
+	}
+}
+
+func TestLinkSubnet(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		isError   bool
+		expected  []string
+		gateway   bool
+	}{
+		{"static address", map[string]any{"mode": "STATIC", "ip_address": "10.0.0.50"}, false, []string{"static 10.0.0.50"}, false},
+		{"static address picked by MAAS", map[string]any{"mode": "STATIC"}, false, []string{"static 10.0.0.2"}, false},
+		{"static address as default gateway", map[string]any{"mode": "STATIC", "ip_address": "10.0.0.50", "default_gateway": true}, false, []string{"static 10.0.0.50"}, true},
+		{"dhcp", map[string]any{"mode": "DHCP"}, false, []string{"dhcp "}, false},
+		{"address outside the subnet", map[string]any{"mode": "STATIC", "ip_address": "10.0.1.50"}, true, nil, false},
+		{"gateway address", map[string]any{"mode": "STATIC", "ip_address": "10.0.0.1"}, true, nil, false},
+		{"address in dhcp mode", map[string]any{"mode": "DHCP", "ip_address": "10.0.0.50"}, true, nil, false},
+		{"default gateway in dhcp mode", map[string]any{"mode": "DHCP", "default_gateway": true}, true, nil, false},
+		{"auto without a subnet", map[string]any{"mode": "AUTO", "subnet_id": nil}, true, nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake, subnet := startMachine(t, fakemaas.StatusReady)
+			tc.arguments["id"] = "aaaaaa"
+			tc.arguments["interface"] = "eth1"
+			if subnetID, ok := tc.arguments["subnet_id"]; !ok {
+				tc.arguments["subnet_id"] = strconv.Itoa(subnet.ID)
+			} else if subnetID == nil {
+				delete(tc.arguments, "subnet_id")
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, LinkSubnet{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			machine, _ := fake.Machine("aaaaaa")
+			var links []string
+			for _, link := range machine.Interfaces[1].Links {
+				links = append(links, link.Mode+" "+link.IPAddress)
+			}
+			if !reflect.DeepEqual(links, tc.expected) {
+				t.Errorf("expected links %v, got %v", tc.expected, links)
+			}
+			if gateway := machine.GatewayLinkID != 0; gateway != tc.gateway {
+				t.Errorf("expected default gateway=%v, got link %d", tc.gateway, machine.GatewayLinkID)
+			}
+		})
+	}
+}
+
+func TestUnlinkSubnet(t *testing.T) {
+	cases := []struct {
+		name     string
+		link     string
+		isError  bool
+		expected int
+	}{
+		{"existing link", "eth0", false, 0},
+		{"link of another interface", "other", true, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake, _ := startMachine(t, fakemaas.StatusReady)
+			machine, _ := fake.Machine("aaaaaa")
+			linkID := strconv.Itoa(machine.Interfaces[0].Links[0].ID)
+			if tc.link == "other" {
+				linkID = "999"
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, UnlinkSubnet{Client: fake.Client()}.Handle, map[string]any{"id": "aaaaaa", "interface": "eth0", "link_id": linkID})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			machine, _ = fake.Machine("aaaaaa")
+			if len(machine.Interfaces[0].Links) != tc.expected {
+				t.Errorf("expected %d links on eth0, got %d", tc.expected, len(machine.Interfaces[0].Links))
+			}
+		})
+	}
+}
+
+func TestSetDefaultGateway(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		isError   bool
+	}{
+		{"link picked by MAAS", map[string]any{"interface": "eth0"}, false},
+		{"given link", map[string]any{"interface": "eth0", "link_id": "eth0"}, false},
+		{"interface without links", map[string]any{"interface": "eth1"}, true},
+		{"unknown link", map[string]any{"interface": "eth0", "link_id": "999"}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake, _ := startMachine(t, fakemaas.StatusAllocated)
+			machine, _ := fake.Machine("aaaaaa")
+			expected := machine.Interfaces[0].Links[0].ID
+			if tc.arguments["link_id"] == "eth0" {
+				tc.arguments["link_id"] = strconv.Itoa(expected)
+			}
+			tc.arguments["id"] = "aaaaaa"
+			if tc.isError {
+				expected = 0
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, SetDefaultGateway{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			machine, _ = fake.Machine("aaaaaa")
+			if machine.GatewayLinkID != expected {
+				t.Errorf("expected gateway link %d, got %d", expected, machine.GatewayLinkID)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package ip_ranges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type IPRange struct {
+	Client maas_client.Client
+}
+
+func (i IPRange) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteIPRange{Client: i.Client}, ReadIPRange{Client: i.Client}, UpdateIPRange{Client: i.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type DeleteIPRange struct {
+	Client maas_client.Client
+}
+
+func (DeleteIPRange) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-ip-range",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the IP range to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete IP Range", false, true, false, true)),
+		mcp.WithDescription("Delete an IP range with the given ID."),
+	)
+}
+
+func (d DeleteIPRange) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	rangeID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteIPRange] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteIPRange] Deleting IP range with ID: %d", rangeID))
+	if err := maas_api.New(d.Client).DeleteIPRange(ctx, rangeID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete IP range %d err=%v", rangeID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("IP range %d deleted", rangeID)), nil
+}
+
+type ReadIPRange struct {
+	Client maas_client.Client
+}
+
+func (ReadIPRange) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-ip-range",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the IP range to retrieve."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read IP Range", true, false, false, true)),
+		mcp.WithDescription("Read an IP range with the given ID."),
+	)
+}
+
+func (r ReadIPRange) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	rangeID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadIPRange] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadIPRange] Retrieving IP range with ID: %d", rangeID))
+	ipRange, err := maas_api.New(r.Client).GetIPRange(ctx, rangeID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read IP range %d err=%v", rangeID, err)
+		zap.L().Error(fmt.Sprintf("[ReadIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(ipRange)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type UpdateIPRange struct {
+	Client maas_client.Client
+}
+
+func (UpdateIPRange) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-ip-range",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the IP range to update."),
+		),
+		mcp.WithString(
+			"type",
+			mcp.Enum("dynamic", "reserved"),
+			mcp.Description("A dynamic range is handed out by DHCP, a reserved range is never assigned by MAAS."),
+		),
+		mcp.WithString(
+			"start_ip",
+			mcp.Description("The first address of the range."),
+		),
+		mcp.WithString(
+			"end_ip",
+			mcp.Description("The last address of the range."),
+		),
+		mcp.WithString(
+			"comment",
+			mcp.Description("A description of what the range is used for."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update IP Range", false, false, false, true)),
+		mcp.WithDescription("Update an IP range with the given ID. The range must stay inside its subnet CIDR and must not overlap the other ranges of the subnet."),
+	)
+}
+
+func (u UpdateIPRange) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	rangeID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateIPRange] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.IPRangeParams{
+		Type:    request.GetString("type", ""),
+		StartIP: request.GetString("start_ip", ""),
+		EndIP:   request.GetString("end_ip", ""),
+		Comment: request.GetString("comment", ""),
+	}
+
+	api := maas_api.New(u.Client)
+
+	current, err := api.GetIPRange(ctx, rangeID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read IP range %d err=%v", rangeID, err)
+		zap.L().Error(fmt.Sprintf("[UpdateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if current.Subnet == nil {
+		errMsg = fmt.Sprintf("IP range %d has no subnet", rangeID)
+		zap.L().Error(fmt.Sprintf("[UpdateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if params.StartIP != "" || params.EndIP != "" {
+		startIP, endIP := current.StartIP, current.EndIP
+		if params.StartIP != "" {
+			startIP = params.StartIP
+		}
+		if params.EndIP != "" {
+			endIP = params.EndIP
+		}
+
+		ranges, err := subnetRanges(ctx, api, current.Subnet.ID)
+		if err != nil {
+			errMsg = fmt.Sprintf("Failed to retrieve the IP ranges of subnet %d err=%v", current.Subnet.ID, err)
+			zap.L().Error(fmt.Sprintf("[UpdateIPRange] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+
+		if err := checkRange(current.Subnet.CIDR, ranges, startIP, endIP, rangeID); err != nil {
+			zap.L().Error(fmt.Sprintf("[UpdateIPRange] %v", err))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdateIPRange] Updating IP range with ID: %d", rangeID))
+	ipRange, err := api.UpdateIPRange(ctx, rangeID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to update IP range %d err=%v", rangeID, err)
+		zap.L().Error(fmt.Sprintf("[UpdateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(ipRange)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,247 @@
+package ip_ranges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strconv"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type IPRanges struct {
+	Client maas_client.Client
+}
+
+func (i IPRanges) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListIPRanges{Client: i.Client}, CreateIPRange{Client: i.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+// checkRange reports an error when the range from start to end is not
+// inside cidr or overlaps one of ranges. The range with the ID skip is
+// ignored, so a range can be checked against the others when it is updated.
+func checkRange(cidr string, ranges []maas_api.SubnetRange, start, end string, skip int) error {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return fmt.Errorf("invalid subnet CIDR %q", cidr)
+	}
+	startIP, err := netip.ParseAddr(start)
+	if err != nil {
+		return fmt.Errorf("invalid start IP %q", start)
+	}
+	endIP, err := netip.ParseAddr(end)
+	if err != nil {
+		return fmt.Errorf("invalid end IP %q", end)
+	}
+
+	if !prefix.Contains(startIP) || !prefix.Contains(endIP) {
+		return fmt.Errorf("range %s-%s is not inside subnet %s", start, end, cidr)
+	}
+	if endIP.Less(startIP) {
+		return fmt.Errorf("end IP %s is before start IP %s", end, start)
+	}
+
+	for _, other := range ranges {
+		if other.ID == skip {
+			continue
+		}
+		otherStart, errStart := netip.ParseAddr(other.StartIP)
+		otherEnd, errEnd := netip.ParseAddr(other.EndIP)
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+		if !endIP.Less(otherStart) && !otherEnd.Less(startIP) {
+			return fmt.Errorf("range %s-%s overlaps the %s range %d (%s-%s)", start, end, other.Type, other.ID, other.StartIP, other.EndIP)
+		}
+	}
+	return nil
+}
+
+// subnetRanges returns the IP ranges of the subnet with the given ID.
+func subnetRanges(ctx context.Context, api *maas_api.API, subnetID int) ([]maas_api.SubnetRange, error) {
+	ranges, err := api.ListIPRanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []maas_api.SubnetRange
+	for _, r := range ranges {
+		if r.Subnet != nil && r.Subnet.ID == subnetID {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+type ListIPRanges struct {
+	Client maas_client.Client
+}
+
+func (ListIPRanges) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-ip-ranges",
+		mcp.WithString(
+			"subnet_id",
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("Only return the ranges of the subnet with this ID."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List IP Ranges", true, false, false, true)),
+		mcp.WithDescription("Return the dynamic and reserved IP ranges defined on the running instance of MAAS."),
+	)
+}
+
+func (l ListIPRanges) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	subnetID, err := tools.OptionalInt(request, "subnet_id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ListIPRanges] Invalid parameter subnet_id err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(l.Client)
+
+	zap.L().Info("[ListIPRanges] Retrieving IP ranges...")
+	var ranges []maas_api.SubnetRange
+	if subnetID != nil {
+		ranges, err = subnetRanges(ctx, api, *subnetID)
+	} else {
+		ranges, err = api.ListIPRanges(ctx)
+	}
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the IP ranges: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListIPRanges] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(ranges)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListIPRanges] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateIPRange struct {
+	Client maas_client.Client
+}
+
+func (CreateIPRange) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-ip-range",
+		mcp.WithString(
+			"subnet_id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the subnet of the range."),
+		),
+		mcp.WithString(
+			"type",
+			mcp.Required(),
+			mcp.Enum("dynamic", "reserved"),
+			mcp.Description("A dynamic range is handed out by DHCP, a reserved range is never assigned by MAAS."),
+		),
+		mcp.WithString(
+			"start_ip",
+			mcp.Required(),
+			mcp.Description("The first address of the range."),
+		),
+		mcp.WithString(
+			"end_ip",
+			mcp.Required(),
+			mcp.Description("The last address of the range."),
+		),
+		mcp.WithString(
+			"comment",
+			mcp.Description("A description of what the range is used for."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create IP Range", false, false, false, true)),
+		mcp.WithDescription("Create a dynamic or reserved IP range. The range must lie inside the subnet CIDR and must not overlap the other ranges of the subnet."),
+	)
+}
+
+func (c CreateIPRange) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	subnetID, err := request.RequireInt("subnet_id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] Required parameter subnet_id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	rangeType, err := request.RequireString("type")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] Required parameter type not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	startIP, err := request.RequireString("start_ip")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] Required parameter start_ip not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	endIP, err := request.RequireString("end_ip")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] Required parameter end_ip not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	subnet, err := api.GetSubnet(ctx, subnetID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read subnet %d err=%v", subnetID, err)
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	ranges, err := subnetRanges(ctx, api, subnetID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the IP ranges of subnet %d err=%v", subnetID, err)
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if err := checkRange(subnet.CIDR, ranges, startIP, endIP, 0); err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.IPRangeParams{
+		Type:    rangeType,
+		StartIP: startIP,
+		EndIP:   endIP,
+		Subnet:  strconv.Itoa(subnetID),
+		Comment: request.GetString("comment", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateIPRange] Creating %s range %s-%s on subnet %d...", rangeType, startIP, endIP, subnetID))
+	ipRange, err := api.CreateIPRange(ctx, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create IP range err=%v", err)
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(ipRange)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateIPRange] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
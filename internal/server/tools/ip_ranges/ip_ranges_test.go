@@ -0,0 +1,107 @@
+package ip_ranges
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestCreateIPRange(t *testing.T) {
+	cases := []struct {
+		name          string
+		start         string
+		end           string
+		expectedError bool
+		expected      []string
+	}{
+		{"free range", "10.0.0.10", "10.0.0.49", false, []string{"10.0.0.100-10.0.0.199", "10.0.0.10-10.0.0.49"}},
+		{"outside the subnet", "10.0.1.10", "10.0.1.49", true, []string{"10.0.0.100-10.0.0.199"}},
+		{"overlaps a range", "10.0.0.50", "10.0.0.100", true, []string{"10.0.0.100-10.0.0.199"}},
+		{"end before start", "10.0.0.49", "10.0.0.10", true, []string{"10.0.0.100-10.0.0.199"}},
+		{"invalid address", "10.0.0", "10.0.0.49", true, []string{"10.0.0.100-10.0.0.199"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			subnet := fake.AddSubnet(fakemaas.Subnet{
+				CIDR:     "10.0.0.0/24",
+				IPRanges: []fakemaas.IPRange{{Type: "dynamic", StartIP: "10.0.0.100", EndIP: "10.0.0.199"}},
+			})
+			arguments := map[string]any{"subnet_id": strconv.Itoa(subnet.ID), "type": "reserved", "start_ip": tc.start, "end_ip": tc.end}
+
+			// Act
+			result := fakemaas.CallTool(t, CreateIPRange{Client: fake.Client()}.Handle, arguments)
+
+			// Assert
+			if result.IsError != tc.expectedError {
+				t.Errorf("expected error=%v, got %s", tc.expectedError, fakemaas.ResultText(t, result))
+			}
+			if _, posted := fake.LastRequest(http.MethodPost); posted == tc.expectedError {
+				t.Errorf("expected a request to MAAS=%v", !tc.expectedError)
+			}
+			var ranges []string
+			for _, r := range fake.IPRanges() {
+				ranges = append(ranges, r.StartIP+"-"+r.EndIP)
+			}
+			if !reflect.DeepEqual(ranges, tc.expected) {
+				t.Errorf("expected ranges %v, got %v", tc.expected, ranges)
+			}
+		})
+	}
+}
+
+func TestIPRangeTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{"list ranges", "list-ip-ranges", map[string]any{}, false, []string{"10.0.0.100-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+		{"read range", "read-ip-range", map[string]any{}, false, []string{"10.0.0.100-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+		{"grow range", "update-ip-range", map[string]any{"start_ip": "10.0.0.50"}, false, []string{"10.0.0.50-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+		{"grow into another range", "update-ip-range", map[string]any{"end_ip": "10.0.0.210"}, true, []string{"10.0.0.100-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+		{"grow outside the subnet", "update-ip-range", map[string]any{"end_ip": "10.0.1.10"}, true, []string{"10.0.0.100-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+		{"update comment", "update-ip-range", map[string]any{"comment": "rack 3"}, false, []string{"10.0.0.100-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+		{"delete range", "delete-ip-range", map[string]any{}, false, []string{"10.0.0.200-10.0.0.219"}},
+		{"delete unknown range", "delete-ip-range", map[string]any{"id": "999"}, true, []string{"10.0.0.100-10.0.0.199", "10.0.0.200-10.0.0.219"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			subnet := fake.AddSubnet(fakemaas.Subnet{
+				CIDR: "10.0.0.0/24",
+				IPRanges: []fakemaas.IPRange{
+					{Type: "dynamic", StartIP: "10.0.0.100", EndIP: "10.0.0.199"},
+					{Type: "reserved", StartIP: "10.0.0.200", EndIP: "10.0.0.219"},
+				},
+			})
+			if _, ok := tc.arguments["id"]; !ok {
+				tc.arguments["id"] = strconv.Itoa(subnet.IPRanges[0].ID)
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(IPRanges{Client: fake.Client()}, IPRange{Client: fake.Client()})[tc.tool], tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			var ranges []string
+			for _, r := range fake.IPRanges() {
+				ranges = append(ranges, r.StartIP+"-"+r.EndIP)
+			}
+			if !reflect.DeepEqual(ranges, tc.expected) {
+				t.Errorf("expected ranges %v, got %v", tc.expected, ranges)
+			}
+		})
+	}
+}
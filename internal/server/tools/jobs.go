@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/policy"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// asyncArgument is the tool argument asking for a call to run as a job.
+const asyncArgument = "async"
+
+// redactedArguments are the job arguments that may hold secrets. They are
+// neither stored nor returned by the job tools.
+var redactedArguments = []string{"templateParameters", "document"}
+
+var jobStates = []string{
+	string(jobs.StateQueued),
+	string(jobs.StateRunning),
+	string(jobs.StateSucceeded),
+	string(jobs.StateFailed),
+	string(jobs.StateCancelled),
+}
+
+// JobSummary describes a job in the output of list-jobs.
+type JobSummary struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	State       jobs.State `json:"state"`
+	Caller      string     `json:"caller,omitempty"`
+	CurrentStep string     `json:"current_step,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Created     time.Time  `json:"created"`
+	Updated     time.Time  `json:"updated"`
+}
+
+type Jobs struct {
+	Manager *jobs.Manager
+}
+
+func (j Jobs) Register(mcpServer registry.ToolServer) {
+	mcpTools := []MCPTool{
+		GetJob{Manager: j.Manager},
+		ListJobs{Manager: j.Manager},
+		CancelJob{Manager: j.Manager},
+	}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type GetJob struct {
+	Manager *jobs.Manager
+}
+
+func (GetJob) Create() mcp.Tool {
+	return mcp.NewTool(
+		"get-job",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-f]{16}$"),
+			mcp.Description("The id of the job."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Job", true, false, true, false)),
+		mcp.WithDescription("Retrieve the state of an asynchronous job, with the history of its steps, its result and its error. Only the jobs of the caller are found, unless the caller is an admin."),
+	)
+}
+
+func (g GetJob) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	jobID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[GetJob] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	job, err := callerJob(ctx, g.Manager, jobID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the job with id %s err=%v", jobID, err)
+		zap.L().Error(fmt.Sprintf("[GetJob] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(redactJob(job))
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[GetJob] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type ListJobs struct {
+	Manager *jobs.Manager
+}
+
+func (ListJobs) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-jobs",
+		mcp.WithString(
+			"state",
+			mcp.Enum(jobStates...),
+			mcp.Description("Only list the jobs in this state."),
+		),
+		mcp.WithString(
+			"kind",
+			mcp.Description("Only list the jobs of this kind, the name of the tool that submitted them, like deploy-machine."),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.DefaultNumber(20),
+			mcp.Min(1),
+			mcp.Description("The maximum number of jobs to list, newest first."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("List Jobs", true, false, true, false)),
+		mcp.WithDescription("List the asynchronous jobs of the caller, or of every caller for admins, newest first, with their state and current step."),
+	)
+}
+
+func (l ListJobs) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	state := request.GetString("state", "")
+	kind := request.GetString("kind", "")
+	limit := max(request.GetInt("limit", 20), 1)
+
+	allJobs, err := l.Manager.List()
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to list the jobs err=%v", err)
+		zap.L().Error(fmt.Sprintf("[ListJobs] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	summaries := []JobSummary{}
+	for _, job := range allJobs {
+		if len(summaries) == limit {
+			break
+		}
+		if !ownedBy(ctx, job) || (state != "" && string(job.State) != state) || (kind != "" && job.Kind != kind) {
+			continue
+		}
+
+		summary := JobSummary{
+			ID:      job.ID,
+			Kind:    job.Kind,
+			State:   job.State,
+			Caller:  job.Caller,
+			Error:   job.Error,
+			Created: job.Created,
+			Updated: job.Updated,
+		}
+		if len(job.Steps) > 0 {
+			summary.CurrentStep = job.Steps[len(job.Steps)-1].Name
+		}
+		summaries = append(summaries, summary)
+	}
+
+	jsonData, err := json.Marshal(summaries)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListJobs] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CancelJob struct {
+	Manager *jobs.Manager
+}
+
+func (CancelJob) Create() mcp.Tool {
+	return mcp.NewTool(
+		"cancel-job",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-f]{16}$"),
+			mcp.Description("The id of the job to cancel."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Cancel Job", false, false, true, false)),
+		mcp.WithDescription("Cancel a queued or running asynchronous job of the caller, or of any caller for admins. Only the job stops: an operation already started in MAAS, like a deployment, goes on and can be stopped with abort-machine-operation."),
+	)
+}
+
+func (c CancelJob) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	jobID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CancelJob] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if _, err := callerJob(ctx, c.Manager, jobID); err != nil {
+		errMsg = fmt.Sprintf("Failed to cancel the job with id %s err=%v", jobID, err)
+		zap.L().Error(fmt.Sprintf("[CancelJob] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[CancelJob] Cancelling job %s...", jobID))
+	job, err := c.Manager.Cancel(jobID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to cancel the job with id %s err=%v", jobID, err)
+		zap.L().Error(fmt.Sprintf("[CancelJob] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(redactJob(job))
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CancelJob] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// ownedBy reports whether the caller stored in ctx may see and cancel the
+// job: admins may for every job, the other callers for the jobs they started.
+func ownedBy(ctx context.Context, job jobs.Job) bool {
+	return policy.IsAdmin(ctx) || job.Caller == policy.Subject(ctx)
+}
+
+// callerJob returns the job with the given id if the caller stored in ctx
+// owns it. The jobs of other callers are reported as missing.
+func callerJob(ctx context.Context, manager *jobs.Manager, id string) (jobs.Job, error) {
+	job, err := manager.Get(id)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	if !ownedBy(ctx, job) {
+		return jobs.Job{}, jobs.ErrNotFound
+	}
+	return job, nil
+}
+
+// redactJob returns the job without the arguments that may hold secrets.
+func redactJob(job jobs.Job) jobs.Job {
+	arguments := maps.Clone(job.Arguments)
+	for _, key := range redactedArguments {
+		if _, ok := arguments[key]; ok {
+			arguments[key] = jobs.Redacted
+		}
+	}
+	job.Arguments = arguments
+	return job
+}
+
+// runAsync reports whether the tool call runs as a job. Calls run as jobs
+// unless they pass async false, or the server has no job manager; asking for
+// async then is refused by submitJob. Calls in dry-run mode always run
+// synchronously, so that the requests they would send are returned.
+func runAsync(ctx context.Context, manager *jobs.Manager, request mcp.CallToolRequest) bool {
+	return request.GetBool(asyncArgument, manager != nil) && !maas_client.IsDryRun(ctx)
+}
+
+// submitJob runs the tool call as a job of kind and returns the job. The job
+// runs with the arguments of the call, in the MAAS region it named.
+func submitJob(ctx context.Context, manager *jobs.Manager, kind string, request mcp.CallToolRequest, caller string) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	if manager == nil {
+		errMsg = "Asynchronous jobs are not enabled on this server"
+		zap.L().Error(fmt.Sprintf("[%s] %s", caller, errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	job, err := manager.Submit(kind, policy.Subject(ctx), request.GetArguments(), redactedArguments...)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to submit the job err=%v", err)
+		zap.L().Error(fmt.Sprintf("[%s] %s", caller, errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(redactJob(job))
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[%s] %s", caller, errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// toolRunner adapts run, the job of a tool, to a jobs.Runner. run receives the
+// arguments the tool was called with, with async false, and a context in the
+// MAAS region named by the call.
+func toolRunner(run func(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error)) jobs.Runner {
+	return func(ctx context.Context, job *jobs.Run) (string, error) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = job.Job().Kind
+		request.Params.Arguments = maps.Clone(job.Arguments())
+		request.GetArguments()[asyncArgument] = false
+
+		if region := request.GetString(registry.RegionArgument, ""); region != "" {
+			ctx = maas_client.WithRegion(ctx, region)
+		}
+
+		return run(ctx, job, request)
+	}
+}
+
+// toolStep runs the tool handler as the step name of the job. The text of the
+// result is the message of the step, and an error result fails it.
+func toolStep(ctx context.Context, job *jobs.Run, name string, handler server.ToolHandlerFunc, request mcp.CallToolRequest) (string, error) {
+	return job.Step(ctx, name, func(ctx context.Context) (string, error) {
+		return callTool(ctx, handler, request)
+	})
+}
+
+// callTool calls the tool handler and returns the text of its result. An
+// error result is returned as an error.
+func callTool(ctx context.Context, handler server.ToolHandlerFunc, request mcp.CallToolRequest) (string, error) {
+	result, err := handler(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	message := strings.Join(texts, "\n")
+
+	if result.IsError {
+		return "", errors.New(message)
+	}
+	return message, nil
+}
+
+// waitRequest is the request of wait-for-machine-status waiting timeout
+// seconds for the machine to reach status.
+func waitRequest(machineID, status string, timeout float64) mcp.CallToolRequest {
+	return toolRequest("wait-for-machine-status", map[string]any{"id": machineID, "status": status, "timeout": timeout})
+}
+
+// toolRequest is a call of the tool name with arguments.
+func toolRequest(name string, arguments map[string]any) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	return request
+}
+
+// withAsync adds the async argument to a tool that can run as a job. The
+// tools of a server with a job manager run as jobs by default: they wait for
+// MAAS longer than most clients wait for a tool call.
+func withAsync(manager *jobs.Manager, description string) mcp.ToolOption {
+	return mcp.WithBoolean(
+		asyncArgument,
+		mcp.DefaultBool(manager != nil),
+		mcp.Description(description+" Pass false to wait for the result in the call instead."),
+	)
+}
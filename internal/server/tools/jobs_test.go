@@ -0,0 +1,293 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/middleware"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/policy"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func newTestJobManager(t *testing.T) *jobs.Manager {
+	t.Helper()
+
+	store, err := jobs.OpenStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("failed to open the job store: %v", err)
+	}
+	manager := jobs.NewManager(store)
+	t.Cleanup(func() {
+		manager.Wait()
+		store.Close()
+	})
+	return manager
+}
+
+func TestDeployMachine_Async(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	store := templates.MustTemplateStore()
+	if err := store.Create(templates.GenericTemplate{Id: "deploy_machine_async_test", Name: "Deploy Machine Async Test", Description: "Test deployment"}); err != nil {
+		t.Fatalf("failed to create the template: %v", err)
+	}
+	defer store.Delete("deploy_machine_async_test")
+
+	cases := []struct {
+		name          string
+		status        string
+		expectedState jobs.State
+		expectedSteps []string
+	}{
+		{"deploys and waits", fakemaas.StatusReady, jobs.StateSucceeded, []string{"deploy", "wait"}},
+		{"fails when MAAS refuses the deployment", fakemaas.StatusDeployed, jobs.StateFailed, []string{"deploy"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.TransitionReads = 3
+			m := fake.AddMachine(fakemaas.Machine{Status: tc.status})
+			manager := newTestJobManager(t)
+			handlers := fakemaas.Handlers(Machines{Client: fake.Client(), Jobs: manager}, Jobs{Manager: manager})
+
+			// Act
+			result := fakemaas.CallTool(t, handlers["deploy-machine"], map[string]any{
+				"machineId":          m.SystemID,
+				"templateId":         "deploy_machine_async_test",
+				"templateParameters": `{"secret": "s3cr3t"}`,
+				"async":              true,
+				"timeout":            5.0,
+			})
+			manager.Wait()
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var submitted jobs.Job
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &submitted); err != nil {
+				t.Fatalf("expected a job, got %v", err)
+			}
+			if submitted.Kind != "deploy-machine" || submitted.Arguments["templateParameters"] != "REDACTED" {
+				t.Errorf("expected a deploy-machine job with redacted parameters, got %+v", submitted)
+			}
+
+			if stored, _ := manager.Get(submitted.ID); stored.Arguments["templateParameters"] != jobs.Redacted {
+				t.Errorf("expected the parameters to be redacted in the job database, got %+v", stored.Arguments)
+			}
+
+			result = fakemaas.CallTool(t, handlers["get-job"], map[string]any{"id": submitted.ID})
+			var job jobs.Job
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &job); err != nil {
+				t.Fatalf("expected a job, got %v", err)
+			}
+			if job.State != tc.expectedState {
+				t.Errorf("expected the job to be %s, got %+v", tc.expectedState, job)
+			}
+			var steps []string
+			for _, step := range job.Steps {
+				steps = append(steps, step.Name)
+			}
+			if strings.Join(steps, ",") != strings.Join(tc.expectedSteps, ",") {
+				t.Errorf("expected steps %v, got %+v", tc.expectedSteps, job.Steps)
+			}
+		})
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	// Arrange
+	fake := fakemaas.Start(t)
+	manager := newTestJobManager(t)
+	handlers := fakemaas.Handlers(Machines{Client: fake.Client(), Jobs: manager}, Jobs{Manager: manager})
+	for _, status := range []string{fakemaas.StatusReady, fakemaas.StatusDeployed} {
+		m := fake.AddMachine(fakemaas.Machine{Status: status})
+		fakemaas.CallTool(t, handlers["wait-for-machine-status"], map[string]any{"id": m.SystemID, "status": "deployed", "timeout": 0.05, "async": true})
+	}
+	manager.Wait()
+
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  int
+	}{
+		{"every job", map[string]any{}, 2},
+		{"by state", map[string]any{"state": string(jobs.StateSucceeded)}, 1},
+		{"by kind", map[string]any{"kind": "deploy-machine"}, 0},
+		{"limited", map[string]any{"limit": 1}, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			result := fakemaas.CallTool(t, handlers["list-jobs"], tc.arguments)
+
+			// Assert
+			var summaries []JobSummary
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &summaries); err != nil {
+				t.Fatalf("expected a list of jobs, got %v", err)
+			}
+			if len(summaries) != tc.expected {
+				t.Errorf("expected %d jobs, got %+v", tc.expected, summaries)
+			}
+		})
+	}
+}
+
+func TestAsync_WithoutManager(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusReady})
+
+	// Act
+	result := fakemaas.CallTool(t, CommissionMachine{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "async": true})
+
+	// Assert
+	if !result.IsError {
+		t.Errorf("expected an error result without job manager, got %s", fakemaas.ResultText(t, result))
+	}
+	if got, _ := fake.Machine(m.SystemID); got.Status != fakemaas.StatusReady {
+		t.Errorf("expected the machine not to be commissioned, got status %s", got.Status)
+	}
+}
+
+func TestAsync_Default(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	cases := []struct {
+		name        string
+		withManager bool
+		arguments   map[string]any
+		expectedJob bool
+	}{
+		{"runs as a job with a job manager", true, map[string]any{}, true},
+		{"runs synchronously when async is false", true, map[string]any{"async": false}, false},
+		{"runs synchronously without job manager", false, map[string]any{}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusDeployed})
+			var manager *jobs.Manager
+			if tc.withManager {
+				manager = newTestJobManager(t)
+			}
+			handlers := fakemaas.Handlers(Machines{Client: fake.Client(), Jobs: manager})
+			tc.arguments["id"] = m.SystemID
+			tc.arguments["status"] = "deployed"
+
+			// Act
+			result := fakemaas.CallTool(t, handlers["wait-for-machine-status"], tc.arguments)
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var job jobs.Job
+			isJob := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &job) == nil && job.ID != ""
+			if isJob != tc.expectedJob {
+				t.Errorf("expected a job %v, got %s", tc.expectedJob, fakemaas.ResultText(t, result))
+			}
+		})
+	}
+}
+
+// handlerRecorder collects the handlers added to it by tool name.
+type handlerRecorder map[string]server.ToolHandlerFunc
+
+func (r handlerRecorder) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	r[tool.Name] = handler
+}
+
+func TestJobs_CallerScope(t *testing.T) {
+	// Arrange
+	manager := newTestJobManager(t)
+	release := make(chan struct{})
+	defer close(release)
+	manager.Handle("test", func(ctx context.Context, run *jobs.Run) (string, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return "done", nil
+	})
+	aliceJob, _ := manager.Submit("test", "token:alice", nil)
+	bobJob, _ := manager.Submit("test", "token:bob", nil)
+
+	toolPolicy, err := policy.New(policy.Config{
+		Roles:    map[string]policy.Role{"user": {Allow: []string{"get-job", "list-jobs", "cancel-job"}}},
+		Subjects: map[string][]string{"token:alice": {"user"}, "token:root": {policy.AdminRole}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create the policy: %v", err)
+	}
+	handlers := handlerRecorder{}
+	Jobs{Manager: manager}.Register(toolPolicy.Guard(handlers))
+
+	cases := []struct {
+		name      string
+		caller    string
+		tool      string
+		arguments map[string]any
+		isError   bool
+		listed    int
+	}{
+		{"gets its own job", "alice", "get-job", map[string]any{"id": aliceJob.ID}, false, 0},
+		{"does not get the job of another caller", "alice", "get-job", map[string]any{"id": bobJob.ID}, true, 0},
+		{"lists its own jobs", "alice", "list-jobs", map[string]any{}, false, 1},
+		{"does not cancel the job of another caller", "alice", "cancel-job", map[string]any{"id": bobJob.ID}, true, 0},
+		{"cancels its own job", "alice", "cancel-job", map[string]any{"id": aliceJob.ID}, false, 0},
+		{"admin gets any job", "root", "get-job", map[string]any{"id": bobJob.ID}, false, 0},
+		{"admin lists every job", "root", "list-jobs", map[string]any{}, false, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctx := middleware.WithIdentity(context.Background(), middleware.Identity{Method: "token", Subject: tc.caller})
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.arguments
+
+			// Act
+			result, err := handlers[tc.tool](ctx, request)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.IsError != tc.isError {
+				t.Fatalf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if tc.tool == "list-jobs" {
+				var summaries []JobSummary
+				if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &summaries); err != nil {
+					t.Fatalf("expected a list of jobs, got %v", err)
+				}
+				if len(summaries) != tc.listed {
+					t.Errorf("expected %d jobs, got %+v", tc.listed, summaries)
+				}
+			}
+		})
+	}
+
+	if job, _ := manager.Get(bobJob.ID); job.State.Done() {
+		t.Errorf("expected the job of bob to keep running, got %+v", job)
+	}
+}
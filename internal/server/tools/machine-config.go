@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+)
+
+// ConfigurableMachine returns the machine with the given system id, or an
+// error saying why its network or storage configuration, named by what,
+// cannot be changed. MAAS only accepts such changes while a machine is Ready
+// or Allocated.
+func ConfigurableMachine(ctx context.Context, api *maas_api.API, systemID, what string) (maas_api.Machine, error) {
+	machine, err := api.GetMachine(ctx, systemID)
+	if err != nil {
+		return machine, fmt.Errorf("failed to retrieve the machine with id %s err=%v", systemID, err)
+	}
+	if machine.Protected() {
+		return machine, fmt.Errorf("machine %s is protected and its %s configuration cannot be changed", systemID, what)
+	}
+
+	switch machine.StatusName {
+	case "Ready", "Allocated":
+		return machine, nil
+	case "Deploying", "Deployed", "Failed deployment":
+		return machine, fmt.Errorf("machine %s is %s: MAAS writes the %s configuration to the machine when it is deployed, so it can only be changed while the machine is Ready or Allocated. Release the machine first", systemID, machine.StatusName, what)
+	case "New", "Commissioning", "Failed commissioning":
+		return machine, fmt.Errorf("machine %s is %s: MAAS discovers the hardware of a machine while commissioning it, so its %s configuration can only be changed once it is Ready or Allocated. Commission the machine first", systemID, machine.StatusName, what)
+	default:
+		return machine, fmt.Errorf("machine %s is %s: MAAS only accepts %s changes while a machine is Ready or Allocated", systemID, machine.StatusName, what)
+	}
+}
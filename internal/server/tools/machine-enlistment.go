@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type CreateMachine struct {
+	Client maas_client.Client
+}
+
+func (CreateMachine) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-machine",
+		withPowerOptions(
+			[]mcp.PropertyOption{
+				mcp.Required(),
+				mcp.Description("How MAAS powers the machine: through its BMC with ipmi or redfish, as a libvirt VM with virsh, as an LXD VM with lxd, or by hand with manual."),
+			},
+			mcp.WithArray(
+				"mac_addresses",
+				mcp.Required(),
+				mcp.WithStringItems(),
+				mcp.Description("The MAC addresses of the network interfaces of the machine, the PXE boot interface first."),
+			),
+			mcp.WithString(
+				"architecture",
+				mcp.DefaultString("amd64/generic"),
+				mcp.Description("The architecture of the machine, like amd64/generic or arm64/generic."),
+			),
+			mcp.WithString(
+				"hostname",
+				mcp.Pattern(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`),
+				mcp.Description("The hostname of the machine. Default: a name generated by MAAS."),
+			),
+			mcp.WithString(
+				"domain",
+				mcp.Description("The DNS domain of the machine. Default: the MAAS default domain."),
+			),
+			mcp.WithString(
+				"zone",
+				mcp.Description("The availability zone of the machine. Default: default."),
+			),
+			mcp.WithString(
+				"pool",
+				mcp.Description("The resource pool of the machine. Default: default."),
+			),
+			mcp.WithString(
+				"description",
+				mcp.Description("A free-form description of the machine."),
+			),
+			mcp.WithBoolean(
+				"commission",
+				mcp.DefaultBool(true),
+				mcp.Description("Start commissioning the machine once it is enlisted, so that it becomes Ready. Otherwise it stays New until commission-machine is called."),
+			),
+			mcp.WithToolAnnotation(CreateToolAnnotation("Create Machine", false, false, false, true)),
+			mcp.WithDescription("Enlist a new machine in MAAS with its MAC addresses and BMC power settings. Returns the created machine."),
+		)...,
+	)
+}
+
+func (c CreateMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	macAddresses, err := request.RequireStringSlice("mac_addresses")
+	if err == nil && len(macAddresses) == 0 {
+		err = fmt.Errorf("at least one MAC address is required")
+	}
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateMachine] Required parameter mac_addresses not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for _, mac := range macAddresses {
+		if _, err := net.ParseMAC(mac); err != nil {
+			errMsg = fmt.Sprintf("Invalid MAC address %s", mac)
+			zap.L().Error(fmt.Sprintf("[CreateMachine] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	powerType, err := request.RequireString("power_type")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateMachine] Required parameter power_type not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	given, _ := request.GetArguments()["power_parameters"].(map[string]any)
+	params, err := powerParameters(powerType, given, true)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateMachine] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	commission := request.GetBool("commission", true)
+
+	zap.L().Info(fmt.Sprintf("[CreateMachine] Enlisting a %s machine with MAC addresses %s...", powerType, strings.Join(macAddresses, ", ")))
+	machine, err := maas_api.New(c.Client).CreateMachine(ctx, maas_api.CreateMachineParams{
+		Hostname:        request.GetString("hostname", ""),
+		Architecture:    request.GetString("architecture", "amd64/generic"),
+		MACAddresses:    macAddresses,
+		Domain:          request.GetString("domain", ""),
+		Zone:            request.GetString("zone", ""),
+		Pool:            request.GetString("pool", ""),
+		Description:     request.GetString("description", ""),
+		PowerType:       powerType,
+		PowerParameters: params,
+		Commission:      &commission,
+	})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to enlist the machine with MAC addresses %s err=%v", strings.Join(macAddresses, ", "), err)
+		zap.L().Error(fmt.Sprintf("[CreateMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(machine)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type DeleteMachine struct {
+	Client maas_client.Client
+}
+
+func (DeleteMachine) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-machine",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine to delete."),
+		),
+		mcp.WithBoolean(
+			"force",
+			mcp.DefaultBool(false),
+			mcp.Description("Also delete a machine that is Deploying or Deployed."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Delete Machine", false, true, true, true)),
+		mcp.WithDescription("Remove a machine from MAAS. The hardware is not touched, but MAAS forgets the machine, its configuration and its results; it has to be enlisted again to be used. Deploying and Deployed machines are only deleted with force."),
+	)
+}
+
+func (d DeleteMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteMachine] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if machine.Protected() {
+		zap.L().Warn(fmt.Sprintf("[DeleteMachine] Refusing to delete protected machine %s", machineID))
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
+	}
+
+	if (machine.StatusName == "Deploying" || machine.StatusName == "Deployed") && !request.GetBool("force", false) {
+		errMsg = fmt.Sprintf("Machine %s is %s. Release it first or pass force to delete it anyway", machineID, machine.StatusName)
+		zap.L().Error(fmt.Sprintf("[DeleteMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteMachine] Deleting machine %s (%s)...", machineID, machine.Hostname))
+	if err := api.DeleteMachine(ctx, machineID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Machine %s (%s) deleted", machineID, machine.Hostname)), nil
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+)
+
+func TestCreateMachine(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+
+	// Act
+	result := fakemaas.CallTool(t, CreateMachine{Client: fake.Client()}.Handle, map[string]any{
+		"hostname":         "rack1-node1",
+		"mac_addresses":    []any{"52:54:00:aa:bb:01", "52:54:00:aa:bb:02"},
+		"power_type":       "ipmi",
+		"power_parameters": map[string]any{"power_address": "10.0.0.10", "power_user": "admin", "power_pass": "hunter2"},
+	})
+
+	// Assert
+	body := fakemaas.ResultText(t, result)
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", body)
+	}
+	var machine maas_api.Machine
+	if err := json.Unmarshal([]byte(body), &machine); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+
+	created, ok := fake.Machine(machine.SystemID)
+	if !ok {
+		t.Fatalf("expected machine %s to be created", machine.SystemID)
+	}
+	if created.Hostname != "rack1-node1" || created.Architecture != "amd64/generic" || created.PowerType != "ipmi" {
+		t.Errorf("unexpected machine %+v", created)
+	}
+	if created.Status != fakemaas.StatusCommissioning && created.Status != fakemaas.StatusReady {
+		t.Errorf("expected the machine to be commissioned, got %s", created.Status)
+	}
+	if len(created.Interfaces) != 2 || created.Interfaces[0].MACAddress != "52:54:00:aa:bb:01" {
+		t.Errorf("unexpected interfaces %+v", created.Interfaces)
+	}
+	expected := map[string]string{"power_address": "10.0.0.10", "power_user": "admin", "power_pass": "hunter2"}
+	if !reflect.DeepEqual(created.PowerParameters, expected) {
+		t.Errorf("expected power parameters %v, got %v", expected, created.PowerParameters)
+	}
+}
+
+func TestCreateMachine_Errors(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		errorText string
+	}{
+		{
+			name:      "invalid MAC address",
+			arguments: map[string]any{"mac_addresses": []any{"52:54:00:aa:bb"}, "power_type": "manual"},
+			errorText: "Invalid MAC address",
+		},
+		{
+			name:      "MAC address in use",
+			arguments: map[string]any{"mac_addresses": []any{"52:54:00:00:00:01"}, "power_type": "manual"},
+			errorText: "already in use",
+		},
+		{
+			name:      "missing required power parameter",
+			arguments: map[string]any{"mac_addresses": []any{"52:54:00:aa:bb:01"}, "power_type": "virsh", "power_parameters": map[string]any{"power_address": "qemu+ssh://ubuntu@kvm/system"}},
+			errorText: "requires the power parameter power_id",
+		},
+		{
+			name:      "parameters for manual",
+			arguments: map[string]any{"mac_addresses": []any{"52:54:00:aa:bb:01"}, "power_type": "manual", "power_parameters": map[string]any{"power_address": "10.0.0.10"}},
+			errorText: "takes no power parameters",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddMachine(fakemaas.Machine{Interfaces: []fakemaas.Interface{{Name: "eth0", MACAddress: "52:54:00:00:00:01"}}})
+
+			// Act
+			result := fakemaas.CallTool(t, CreateMachine{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if body := fakemaas.ResultText(t, result); !result.IsError || !strings.Contains(body, tc.errorText) {
+				t.Errorf("expected an error containing %q, got %s", tc.errorText, body)
+			}
+		})
+	}
+}
+
+func TestDeleteMachine(t *testing.T) {
+	cases := []struct {
+		name      string
+		machine   fakemaas.Machine
+		force     bool
+		errorText string
+	}{
+		{name: "ready machine", machine: fakemaas.Machine{Status: fakemaas.StatusReady}},
+		{name: "deployed machine", machine: fakemaas.Machine{Status: fakemaas.StatusDeployed}, errorText: "Release it first"},
+		{name: "deployed machine with force", machine: fakemaas.Machine{Status: fakemaas.StatusDeployed}, force: true},
+		{name: "protected machine", machine: fakemaas.Machine{TagNames: []string{maas_api.ProtectedTag}}, force: true, errorText: "protected"},
+		{name: "locked machine", machine: fakemaas.Machine{Locked: true}, errorText: "locked"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(tc.machine)
+
+			// Act
+			result := fakemaas.CallTool(t, DeleteMachine{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "force": tc.force})
+
+			// Assert
+			body := fakemaas.ResultText(t, result)
+			_, exists := fake.Machine(m.SystemID)
+			if tc.errorText != "" {
+				if !result.IsError || !strings.Contains(body, tc.errorText) {
+					t.Errorf("expected an error containing %q, got %s", tc.errorText, body)
+				}
+				if !exists {
+					t.Error("expected the machine to be kept")
+				}
+				return
+			}
+			if result.IsError || exists {
+				t.Errorf("expected the machine to be deleted, got %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type ReleaseMachine struct {
+	Client maas_client.Client
+}
+
+func (ReleaseMachine) Create() mcp.Tool {
+	return mcp.NewTool(
+		"release-machine",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine to release."),
+		),
+		mcp.WithString(
+			"comment",
+			mcp.Description("Optional comment for the event log."),
+		),
+		mcp.WithBoolean(
+			"erase",
+			mcp.DefaultBool(false),
+			mcp.Description("Erase the disk when releasing."),
+		),
+		mcp.WithBoolean(
+			"secure_erase",
+			mcp.DefaultBool(false),
+			mcp.Description("Use the drive's secure erase feature if available. Only used when erase is true."),
+		),
+		mcp.WithBoolean(
+			"quick_erase",
+			mcp.DefaultBool(false),
+			mcp.Description("Wipe only 2MiB at the start and at the end of the drive. Only used when erase is true."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Release Machine", false, true, false, true)),
+		mcp.WithDescription("Release a machine specified by id, returning it to the ready state. All the data on the machine is lost."),
+	)
+}
+
+func (r ReleaseMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReleaseMachine] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(r.Client)
+
+	if result := ensureNotProtected(ctx, api, machineID, "ReleaseMachine"); result != nil {
+		return result, nil
+	}
+
+	params := maas_api.ReleaseParams{
+		Comment:     request.GetString("comment", ""),
+		Erase:       request.GetBool("erase", false),
+		SecureErase: request.GetBool("secure_erase", false),
+		QuickErase:  request.GetBool("quick_erase", false),
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReleaseMachine] Releasing machine with id %s...", machineID))
+	machine, err := api.ReleaseMachine(ctx, machineID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to release the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[ReleaseMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(machine)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReleaseMachine] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type AbortMachineOperation struct {
+	Client maas_client.Client
+}
+
+func (AbortMachineOperation) Create() mcp.Tool {
+	return mcp.NewTool(
+		"abort-machine-operation",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine whose current operation will be aborted."),
+		),
+		mcp.WithString(
+			"comment",
+			mcp.Description("Optional comment for the event log."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Abort Machine Operation", false, true, false, true)),
+		mcp.WithDescription("Abort the current operation (commissioning, deploying, testing or disk erasing) of the machine specified by id."),
+	)
+}
+
+func (a AbortMachineOperation) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[AbortMachineOperation] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(a.Client)
+
+	if result := ensureNotProtected(ctx, api, machineID, "AbortMachineOperation"); result != nil {
+		return result, nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[AbortMachineOperation] Aborting the current operation of machine with id %s...", machineID))
+	machine, err := api.AbortMachineOperation(ctx, machineID, request.GetString("comment", ""))
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to abort the operation of machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[AbortMachineOperation] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(machine)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[AbortMachineOperation] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type RescueMode struct {
+	Client maas_client.Client
+}
+
+func (RescueMode) Create() mcp.Tool {
+	return mcp.NewTool(
+		"rescue-mode",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine to boot into rescue mode."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Enter Rescue Mode", false, true, false, true)),
+		mcp.WithDescription("Reboot the machine specified by id into an ephemeral rescue environment."),
+	)
+}
+
+func (r RescueMode) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[RescueMode] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(r.Client)
+
+	if result := ensureNotProtected(ctx, api, machineID, "RescueMode"); result != nil {
+		return result, nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[RescueMode] Entering rescue mode on machine with id %s...", machineID))
+	machine, err := api.EnterRescueMode(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to enter rescue mode on machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[RescueMode] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(machine)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[RescueMode] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type ExitRescueMode struct {
+	Client maas_client.Client
+}
+
+func (ExitRescueMode) Create() mcp.Tool {
+	return mcp.NewTool(
+		"exit-rescue-mode",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine to take out of rescue mode."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Exit Rescue Mode", false, true, false, true)),
+		mcp.WithDescription("Reboot the machine specified by id out of rescue mode, back into its previous state."),
+	)
+}
+
+func (e ExitRescueMode) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ExitRescueMode] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(e.Client)
+
+	if result := ensureNotProtected(ctx, api, machineID, "ExitRescueMode"); result != nil {
+		return result, nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ExitRescueMode] Exiting rescue mode on machine with id %s...", machineID))
+	machine, err := api.ExitRescueMode(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to exit rescue mode on machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[ExitRescueMode] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(machine)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ExitRescueMode] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// ensureNotProtected retrieves the machine and returns an error result if it
+// cannot be read or carries the "protected" tag, nil otherwise.
+func ensureNotProtected(ctx context.Context, api *maas_api.API, machineID, caller string) *mcp.CallToolResult {
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[%s] %s", caller, errMsg))
+		return mcp.NewToolResultError(errMsg)
+	}
+
+	if machine.Protected() {
+		zap.L().Warn(fmt.Sprintf("[%s] Refusing to act on protected machine %s", caller, machineID))
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed")
+	}
+
+	return nil
+}
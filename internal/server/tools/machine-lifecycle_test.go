@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestMachineLifecycle(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		machine   fakemaas.Machine
+		expected  string
+		isError   bool
+	}{
+		{"release", "release-machine", nil, fakemaas.Machine{Status: fakemaas.StatusDeployed}, fakemaas.StatusReleasing, false},
+		{"release with erase", "release-machine", map[string]any{"erase": true}, fakemaas.Machine{Status: fakemaas.StatusDeployed}, fakemaas.StatusDiskErasing, false},
+		{"release a ready machine", "release-machine", nil, fakemaas.Machine{Status: fakemaas.StatusReady}, fakemaas.StatusReady, true},
+		{"release a protected machine", "release-machine", nil, fakemaas.Machine{Status: fakemaas.StatusDeployed, TagNames: []string{"protected"}}, fakemaas.StatusDeployed, true},
+		{"abort a deployment", "abort-machine-operation", nil, fakemaas.Machine{Status: fakemaas.StatusDeploying}, fakemaas.StatusAllocated, false},
+		{"abort without an operation", "abort-machine-operation", nil, fakemaas.Machine{Status: fakemaas.StatusReady}, fakemaas.StatusReady, true},
+		{"enter rescue mode", "rescue-mode", nil, fakemaas.Machine{Status: fakemaas.StatusDeployed}, fakemaas.StatusEnteringRescueMode, false},
+		{"exit rescue mode", "exit-rescue-mode", nil, fakemaas.Machine{Status: fakemaas.StatusRescueMode}, fakemaas.StatusExitingRescueMode, false},
+		{"exit rescue mode when not rescued", "exit-rescue-mode", nil, fakemaas.Machine{Status: fakemaas.StatusReady}, fakemaas.StatusReady, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.TransitionReads = 10
+			m := fake.AddMachine(tc.machine)
+			arguments := map[string]any{"id": m.SystemID}
+			for key, value := range tc.arguments {
+				arguments[key] = value
+			}
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Machines{Client: fake.Client()})[tc.tool], arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if got, _ := fake.Machine(m.SystemID); got.Status != tc.expected {
+				t.Errorf("expected status %s, got %s", tc.expected, got.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+)
+
+// Results of a machine in MoveMachines.
+const (
+	MoveMoved     = "moved"
+	MoveUnchanged = "unchanged"
+	MoveRefused   = "refused"
+	MoveFailed    = "failed"
+)
+
+// MachineMove is the result of moving a machine to a zone or a pool.
+type MachineMove struct {
+	SystemID string `json:"system_id"`
+	Hostname string `json:"hostname,omitempty"`
+	From     string `json:"from,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+// MoveResult is the output of the tools moving machines to a zone or a pool.
+type MoveResult struct {
+	Target   string        `json:"target"`
+	Moved    int           `json:"moved"`
+	Machines []MachineMove `json:"machines"`
+}
+
+// NewMoveResult summarizes the moves of the machines to target.
+func NewMoveResult(target string, moves []MachineMove) MoveResult {
+	result := MoveResult{Target: target, Machines: moves}
+	for _, move := range moves {
+		if move.Result == MoveMoved {
+			result.Moved++
+		}
+	}
+	return result
+}
+
+// Failed reports whether a machine was refused or failed to move.
+func (r MoveResult) Failed() bool {
+	return slices.ContainsFunc(r.Machines, func(move MachineMove) bool {
+		return move.Result == MoveRefused || move.Result == MoveFailed
+	})
+}
+
+// MoveMachines moves the machines given by id or by tag with params, which
+// sets their zone or their pool. current returns the zone or the pool a
+// machine is in, and target the one it is moved to. Protected machines given
+// by id are refused, and the ones with the tag are left out.
+func MoveMachines(ctx context.Context, api *maas_api.API, ids []string, tag string, current func(maas_api.Machine) string, target string, params maas_api.MachineParams) ([]MachineMove, error) {
+	machines, missing, err := selectMachines(ctx, api, ids, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := []MachineMove{}
+	for _, id := range missing {
+		moves = append(moves, MachineMove{SystemID: id, Result: MoveFailed, Error: "machine not found"})
+	}
+
+	for _, machine := range machines {
+		move := MachineMove{SystemID: machine.SystemID, Hostname: machine.Hostname, From: current(machine)}
+		switch {
+		case machine.Protected():
+			move.Result = MoveRefused
+			move.Error = "machine is protected"
+		case move.From == target:
+			move.Result = MoveUnchanged
+		default:
+			if _, err := api.UpdateMachine(ctx, machine.SystemID, params); err != nil {
+				move.Result = MoveFailed
+				move.Error = err.Error()
+			} else {
+				move.Result = MoveMoved
+			}
+		}
+		moves = append(moves, move)
+	}
+	return moves, nil
+}
+
+// MachineZone returns the zone of the machine.
+func MachineZone(machine maas_api.Machine) string {
+	if machine.Zone == nil {
+		return ""
+	}
+	return machine.Zone.Name
+}
+
+// MachinePool returns the resource pool of the machine.
+func MachinePool(machine maas_api.Machine) string {
+	if machine.Pool == nil {
+		return ""
+	}
+	return machine.Pool.Name
+}
+
+// selectMachines returns the machines given by id or by tag, and the ids
+// that matched no machine. Protected machines with the tag are left out,
+// while the ones given by id are returned for the caller to refuse.
+func selectMachines(ctx context.Context, api *maas_api.API, ids []string, tag string) ([]maas_api.Machine, []string, error) {
+	for _, id := range ids {
+		if !systemIDPattern.MatchString(id) {
+			return nil, nil, fmt.Errorf("invalid machine id %q", id)
+		}
+	}
+
+	var machines []maas_api.Machine
+	if len(ids) > 0 {
+		found, err := api.ListMachines(ctx, maas_api.MachineFilter{SystemIDs: ids})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to retrieve the machines err=%v", err)
+		}
+		machines = found
+	}
+	if tag != "" {
+		tagged, err := api.ListMachines(ctx, maas_api.MachineFilter{Tags: []string{tag}})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to retrieve the machines with tag %s err=%v", tag, err)
+		}
+		for _, machine := range tagged {
+			if !machine.Protected() && !slices.ContainsFunc(machines, func(m maas_api.Machine) bool { return m.SystemID == machine.SystemID }) {
+				machines = append(machines, machine)
+			}
+		}
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !slices.ContainsFunc(machines, func(m maas_api.Machine) bool { return m.SystemID == id }) && !slices.Contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+	return machines, missing, nil
+}
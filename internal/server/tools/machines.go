@@ -5,21 +5,24 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"os"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
-	"github.com/JarcauCristian/ztp-mcp/internal/server/parser"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
+// pollInterval is how often WaitForMachineStatus polls the machine.
+var pollInterval = 3 * time.Second
+
 var statuses = []string{
 	"new",
 	"commissioning",
@@ -40,17 +43,54 @@ var statuses = []string{
 	"failed_disk_erasing",
 }
 
-type Machines struct{}
+type Machines struct {
+	Client maas_client.Client
+	// Jobs runs the calls asking for async. They are refused when it is nil.
+	Jobs *jobs.Manager
+}
 
-func (Machines) Register(mcpServer *server.MCPServer) {
-	mcpTools := []MCPTool{ListMachines{}, ListMachine{}, GetMachineDetails{}, GetMachineStatus{}, GetMachineIp{}, GetMachineScriptResults{}, CommissionMachine{}, DeployMachine{}, WaitForMachineStatus{}}
+func (m Machines) Register(mcpServer registry.ToolServer) {
+	commission := CommissionMachine{Client: m.Client, Jobs: m.Jobs}
+	deploy := DeployMachine{Client: m.Client, Jobs: m.Jobs}
+	wait := WaitForMachineStatus{Client: m.Client, Jobs: m.Jobs}
+	bulkWait := WaitForMachinesStatus{Client: m.Client, Jobs: m.Jobs}
+	deployByConstraints := DeployByConstraints{Client: m.Client, Jobs: m.Jobs}
+
+	if m.Jobs != nil {
+		m.Jobs.Handle("commission-machine", toolRunner(commission.runJob))
+		m.Jobs.Handle("deploy-machine", toolRunner(deploy.runJob))
+		m.Jobs.Handle("wait-for-machine-status", toolRunner(wait.runJob))
+		m.Jobs.Handle("wait-for-machines-status", toolRunner(bulkWait.runJob))
+		m.Jobs.Handle("deploy-by-constraints", toolRunner(deployByConstraints.runJob))
+	}
+
+	mcpTools := []MCPTool{
+		ListMachines{Client: m.Client},
+		ListMachine{Client: m.Client},
+		GetMachineDetails{Client: m.Client},
+		GetMachineStatus{Client: m.Client},
+		GetMachineIp{Client: m.Client},
+		GetMachineScriptResults{Client: m.Client},
+		commission,
+		AllocateMachine{Client: m.Client},
+		deploy,
+		deployByConstraints,
+		wait,
+		bulkWait,
+		ReleaseMachine{Client: m.Client},
+		AbortMachineOperation{Client: m.Client},
+		RescueMode{Client: m.Client},
+		ExitRescueMode{Client: m.Client},
+	}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListMachines struct{}
+type ListMachines struct {
+	Client maas_client.Client
+}
 
 func (ListMachines) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -83,57 +123,49 @@ func (ListMachines) Create() mcp.Tool {
 			mcp.DefaultBool(true),
 			mcp.Description("If true will output the short version of the machine output."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("List Machines", true, false, false, true)),
 		mcp.WithDescription("List all the available machines on the current ZTP agent connected."),
 	)
 }
 
-func (ListMachines) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var path, errMsg string
+func (l ListMachines) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
 
 	status := request.GetString("status", "")
 	shortOutput := request.GetBool("short_output", true)
 
-	if status == "" || !slices.Contains(statuses, status) {
-		path = "/MAAS/api/2.0/machines/"
-	} else {
-		path = fmt.Sprintf("/MAAS/api/2.0/machines/?status=%s", status)
+	var filter maas_api.MachineFilter
+	if slices.Contains(statuses, status) {
+		filter.Status = status
 	}
 
-	client := maas_client.MustClient()
-
 	zap.L().Info("[ListMachines] Retrieving all the machines...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	machines, err := l.listMachines(ctx, filter)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve all the machines: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListMachines] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	var rawMachines []map[string]any
-	err = json.Unmarshal([]byte(resultData), &rawMachines)
-	if err != nil {
-		errMsg = fmt.Sprintf("Failed to unmarshal the result err=%v", err)
-		zap.L().Error(fmt.Sprintf("[ListMachines] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
 	// Filter out machines with the "protected" tag
-	var filteredRawMachines []map[string]any
-	for _, machine := range rawMachines {
-		if !parser.CheckForProtectedTag(machine) {
-			filteredRawMachines = append(filteredRawMachines, machine)
-		}
-	}
+	machines = slices.DeleteFunc(machines, func(machine regionalMachine) bool { return machine.Protected() })
 
 	var response []byte
 	if shortOutput {
 		var shortMachines []Machine
-		for _, raw := range filteredRawMachines {
-			shortMachines = append(shortMachines, convertToMachine(raw))
+		for _, machine := range machines {
+			shortMachine := convertToMachine(machine.Machine)
+			shortMachine.Region = machine.Region
+			shortMachines = append(shortMachines, shortMachine)
 		}
+		addDNSRecords(ctx, l.Client, shortMachines)
 		response, err = json.Marshal(shortMachines)
 	} else {
-		response, err = json.Marshal(filteredRawMachines)
+		var rawMachines []json.RawMessage
+		rawMachines, err = rawRegionalMachines(machines)
+		if err == nil {
+			response, err = json.Marshal(rawMachines)
+		}
 	}
 
 	if err != nil {
@@ -145,7 +177,109 @@ func (ListMachines) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 	return mcp.NewToolResultText(string(response)), nil
 }
 
-type ListMachine struct{}
+// regionalMachine is a machine labelled with the region it was listed from.
+// raw keeps the machine as MAAS encoded it, with the fields Machine does not
+// model, for the long output.
+type regionalMachine struct {
+	maas_api.Machine
+	Region string
+	raw    json.RawMessage
+}
+
+// rawRegionalMachines returns the machines as MAAS encoded them, with a region
+// field added to the machines labelled with one.
+func rawRegionalMachines(machines []regionalMachine) ([]json.RawMessage, error) {
+	rawMachines := make([]json.RawMessage, 0, len(machines))
+	for _, machine := range machines {
+		if machine.Region == "" {
+			rawMachines = append(rawMachines, machine.raw)
+			continue
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(machine.raw, &fields); err != nil {
+			return nil, err
+		}
+		region, err := json.Marshal(machine.Region)
+		if err != nil {
+			return nil, err
+		}
+		fields["region"] = region
+
+		labelled, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		rawMachines = append(rawMachines, labelled)
+	}
+	return rawMachines, nil
+}
+
+// decodeMachines decodes the machines MAAS returned, keeping their encoding.
+func decodeMachines(rawMachines []json.RawMessage, region string) ([]regionalMachine, error) {
+	machines := make([]regionalMachine, 0, len(rawMachines))
+	for _, raw := range rawMachines {
+		machine := regionalMachine{Region: region, raw: raw}
+		if err := json.Unmarshal(raw, &machine.Machine); err != nil {
+			return nil, err
+		}
+		machines = append(machines, machine)
+	}
+	return machines, nil
+}
+
+// listMachines retrieves the machines matching filter. When the client spans
+// several regions and the call did not select one, every region is queried
+// in parallel. Machines are labelled with their region whenever the client
+// spans regions.
+func (l ListMachines) listMachines(ctx context.Context, filter maas_api.MachineFilter) ([]regionalMachine, error) {
+	regions, ok := l.Client.(*maas_client.Regions)
+	if !ok {
+		rawMachines, err := maas_api.New(l.Client).ListMachinesRaw(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMachines(rawMachines, "")
+	}
+
+	names := regions.Names()
+	if name, ok := maas_client.RegionFromContext(ctx); ok {
+		names = []string{name}
+	}
+
+	results := make([][]json.RawMessage, len(names))
+	errs := make([]error, len(names))
+
+	api := maas_api.New(regions)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = api.ListMachinesRaw(maas_client.WithRegion(ctx, name), filter)
+		}()
+	}
+	wg.Wait()
+
+	var machines []regionalMachine
+	for i, name := range names {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("region %s: %w", name, errs[i])
+		}
+		labelled, err := decodeMachines(results[i], name)
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", name, err)
+		}
+		machines = append(machines, labelled...)
+	}
+
+	return machines, nil
+}
+
+type ListMachine struct {
+	Client maas_client.Client
+}
 
 func (ListMachine) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -161,11 +295,12 @@ func (ListMachine) Create() mcp.Tool {
 			mcp.DefaultBool(true),
 			mcp.Description("If true will output the short version of the machine output."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("List Machine", true, false, false, true)),
 		mcp.WithDescription("Return the information about a particular machine."),
 	)
 }
 
-func (ListMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	shortOutput := request.GetBool("short_output", true)
@@ -176,38 +311,32 @@ func (ListMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mc
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/", machineID)
-
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[ListMachine] Retrieving machine with id %s...", machineID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	raw, err := maas_api.New(l.Client).GetMachineRaw(ctx, machineID)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[ListMachine] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	var rawMachine map[string]any
-	err = json.Unmarshal([]byte(resultData), &rawMachine)
-	if err != nil {
-		errMsg = fmt.Sprintf("Failed to unmarshal the result: %v", err)
+	var machine maas_api.Machine
+	if err := json.Unmarshal(raw, &machine); err != nil {
+		errMsg = fmt.Sprintf("Failed to decode the machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[ListMachine] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	if parser.CheckForProtectedTag(rawMachine) {
+	if machine.Protected() {
 		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
 	}
 
-	var response []byte
-	if shortOutput {
-		machine := convertToMachine(rawMachine)
-		response, err = json.Marshal(machine)
-	} else {
-		response, err = json.Marshal(rawMachine)
+	if !shortOutput {
+		return mcp.NewToolResultText(string(raw)), nil
 	}
 
+	shortMachine := []Machine{convertToMachine(machine)}
+	addDNSRecords(ctx, l.Client, shortMachine)
+	response, err := json.Marshal(shortMachine[0])
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListMachine] %s", errMsg))
@@ -217,9 +346,12 @@ func (ListMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mc
 	return mcp.NewToolResultText(string(response)), nil
 }
 
-type WaitForMachineStatus struct{}
+type WaitForMachineStatus struct {
+	Client maas_client.Client
+	Jobs   *jobs.Manager
+}
 
-func (WaitForMachineStatus) Create() mcp.Tool {
+func (w WaitForMachineStatus) Create() mcp.Tool {
 	return mcp.NewTool(
 		"wait-for-machine-status",
 		mcp.WithString(
@@ -257,11 +389,13 @@ func (WaitForMachineStatus) Create() mcp.Tool {
 			mcp.DefaultNumber(120.0),
 			mcp.Description("Timeout until the waiting is stoped. Default: 120s"),
 		),
-		mcp.WithDescription("Retrieve the status of the machine specified by id."),
+		withAsync(w.Jobs, "Return a job right away and wait in the background. Follow the job with get-job."),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Wait for Machine Status", true, false, false, true)),
+		mcp.WithDescription("Wait until the machine specified by id reaches the status. Sends a progress notification on every status change and fails as soon as the machine enters a failed status or becomes broken."),
 	)
 }
 
-func (WaitForMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (w WaitForMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	machineID, err := request.RequireString("id")
@@ -270,18 +404,24 @@ func (WaitForMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequ
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	if runAsync(ctx, w.Jobs, request) {
+		return submitJob(ctx, w.Jobs, "wait-for-machine-status", request, "WaitForMachineStatus")
+	}
+
 	timeout := request.GetFloat("timeout", 120.0)
 	requiredStatus := request.GetString("status", "deployed")
 
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/", machineID)
-	client := maas_client.MustClient()
+	api := maas_api.New(w.Client)
+	progress := NewProgressNotifier(ctx, request)
+	start := time.Now()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
 	defer cancel()
 
-	ticker := time.NewTicker(3 * time.Second)
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
+	var lastStatus, lastEvent string
 	for {
 		select {
 		case <-ctx.Done():
@@ -290,36 +430,93 @@ func (WaitForMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequ
 			return mcp.NewToolResultError(errMsg), nil
 
 		case <-ticker.C:
-			machineRaw, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+			machine, err := api.GetMachine(ctx, machineID)
 			if err != nil {
 				errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
 				zap.L().Error(fmt.Sprintf("[WaitForMachineStatus] %s", errMsg))
 				return mcp.NewToolResultError(errMsg), nil
 			}
 
-			var machine map[string]any
-			if err := json.Unmarshal([]byte(machineRaw), &machine); err != nil {
-				errMsg = fmt.Sprintf("Failed to unmarshal the result: %v", err)
+			if machine.StatusName == "" {
+				errMsg = fmt.Sprintf("Failed to get status_name for machine %s", machineID)
 				zap.L().Error(fmt.Sprintf("[WaitForMachineStatus] %s", errMsg))
 				return mcp.NewToolResultError(errMsg), nil
 			}
 
-			statusName, ok := machine["status_name"].(string)
-			if !ok {
-				errMsg = fmt.Sprintf("Failed to get status_name for machine %s", machineID)
-				zap.L().Error(fmt.Sprintf("[WaitForMachineStatus] %s", errMsg))
-				return mcp.NewToolResultError(errMsg), nil
+			if machine.StatusName != lastStatus {
+				lastStatus = machine.StatusName
+				lastEvent = latestEvent(ctx, api, machineID)
+
+				message := fmt.Sprintf("Machine %s is %s after %s", machineID, machine.StatusName, time.Since(start).Round(time.Second))
+				if lastEvent != "" {
+					message += fmt.Sprintf(", latest event: %s", lastEvent)
+				}
+				progress.Notify(ctx, 0, message)
 			}
 
-			if statusName == requiredStatus {
+			if sameStatus(requiredStatus, machine.StatusName) {
 				zap.L().Info(fmt.Sprintf("[WaitForMachineStatus] Machine %s reached status %s", machineID, requiredStatus))
-				return mcp.NewToolResultText(fmt.Sprintf("Machine reached status: %s", statusName)), nil
+				return mcp.NewToolResultText(fmt.Sprintf("Machine reached status: %s", machine.StatusName)), nil
+			}
+
+			if isFailedStatus(machine.StatusName) {
+				errMsg = fmt.Sprintf("Machine %s entered status %s while waiting for status %s", machineID, machine.StatusName, requiredStatus)
+				if lastEvent != "" {
+					errMsg += fmt.Sprintf(", latest event: %s", lastEvent)
+				}
+				zap.L().Error(fmt.Sprintf("[WaitForMachineStatus] %s", errMsg))
+				return mcp.NewToolResultError(errMsg), nil
 			}
 		}
 	}
 }
 
-type GetMachineStatus struct{}
+func (w WaitForMachineStatus) runJob(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error) {
+	return toolStep(ctx, job, "wait", w.Handle, request)
+}
+
+// failedStatuses are the statuses a machine does not leave without an operator.
+var failedStatuses = []string{
+	"failed_commissioning",
+	"failed_deployment",
+	"failed_testing",
+	"failed_disk_erasing",
+	"failed_releasing",
+	"failed_entering_rescue_mode",
+	"failed_exiting_rescue_mode",
+	"broken",
+}
+
+// sameStatus reports whether the status name reported by MAAS, like
+// "Failed deployment", is the status given like "failed_deployment".
+func sameStatus(status, statusName string) bool {
+	return strings.EqualFold(strings.ReplaceAll(status, "_", " "), statusName)
+}
+
+func isFailedStatus(statusName string) bool {
+	return slices.ContainsFunc(failedStatuses, func(status string) bool { return sameStatus(status, statusName) })
+}
+
+// latestEvent describes the newest event of the machine, or returns an empty
+// string when it cannot be retrieved.
+func latestEvent(ctx context.Context, api *maas_api.API, machineID string) string {
+	event, ok, err := api.LatestEvent(ctx, machineID)
+	if err != nil {
+		zap.L().Warn(fmt.Sprintf("[WaitForMachineStatus] Failed to retrieve the events of machine %s err=%v", machineID, err))
+		return ""
+	}
+	if !ok {
+		return ""
+	}
+	if event.Description == "" {
+		return event.Type
+	}
+	return fmt.Sprintf("%s (%s)", event.Type, event.Description)
+}
+
+type GetMachineStatus struct {
+	Client maas_client.Client
+}
 
 func (GetMachineStatus) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -330,11 +527,12 @@ func (GetMachineStatus) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z]{6}$"),
 			mcp.Description("The id of the machine to commission."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Machine Status", true, false, false, true)),
 		mcp.WithDescription("Retrieve the status of the machine specified by id."),
 	)
 }
 
-func (GetMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (g GetMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	machineID, err := request.RequireString("id")
@@ -343,35 +541,26 @@ func (GetMachineStatus) Handle(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/", machineID)
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[GetMachineStatus] Retrieving status for machine with id %s...", machineID))
-	machineRaw, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	machine, err := maas_api.New(g.Client).GetMachine(ctx, machineID)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[GetMachineStatus] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	var machine map[string]any
-	if err := json.Unmarshal([]byte(machineRaw), &machine); err != nil {
-		errMsg = fmt.Sprintf("Failed to unmarshal the result: %v", err)
-		zap.L().Error(fmt.Sprintf("[GetMachineStatus] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	statusName, ok := machine["status_name"].(string)
-	if !ok {
+	if machine.StatusName == "" {
 		errMsg = fmt.Sprintf("Failed to get status_name for machine %s", machineID)
 		zap.L().Error(fmt.Sprintf("[GetMachineStatus] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{"status": "%s"}`, statusName)), nil
+	return mcp.NewToolResultText(fmt.Sprintf(`{"status": "%s"}`, machine.StatusName)), nil
 }
 
-type GetMachineDetails struct{}
+type GetMachineDetails struct {
+	Client maas_client.Client
+}
 
 func (GetMachineDetails) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -382,21 +571,19 @@ func (GetMachineDetails) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z]{6}$"),
 			mcp.Description("The id of the machine to retrieve the details."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Machine Details", true, false, false, true)),
 		mcp.WithDescription("Retrieve the details in XML format of the machine specified by id."),
 	)
 }
 
-func (GetMachineDetails) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (g GetMachineDetails) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	machineID, err := request.RequireString("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[GetMachineDetails] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/op-details", machineID)
-	client := maas_client.MustClient()
-
-	response, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	response, err := maas_api.New(g.Client).GetMachineDetails(ctx, machineID)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to retrieve the details of the machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[GetMachineDetails] %s", errMsg))
@@ -406,7 +593,9 @@ func (GetMachineDetails) Handle(ctx context.Context, request mcp.CallToolRequest
 	return mcp.NewToolResultText(response), nil
 }
 
-type GetMachineScriptResults struct{}
+type GetMachineScriptResults struct {
+	Client maas_client.Client
+}
 
 func (GetMachineScriptResults) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -417,11 +606,12 @@ func (GetMachineScriptResults) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z]{6}$"),
 			mcp.Description("The id of the machine to retrieve the commissioning results."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Machine Script Results", true, false, false, true)),
 		mcp.WithDescription("Retrieve the commissioning script results for a machine."),
 	)
 }
 
-func (GetMachineScriptResults) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (g GetMachineScriptResults) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 	machineID, err := request.RequireString("id")
 	if err != nil {
@@ -429,53 +619,35 @@ func (GetMachineScriptResults) Handle(ctx context.Context, request mcp.CallToolR
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/installation-results/?system_id=%s", machineID)
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[GetMachineScriptResults] Retrieving commissioning script results for machine with id %s...", machineID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	results, err := maas_api.New(g.Client).ListScriptResults(ctx, machineID)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve commissioning results for machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[GetMachineScriptResults] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	var rawScripts []map[string]any
-	if err := json.Unmarshal([]byte(resultData), &rawScripts); err != nil {
-		errMsg = fmt.Sprintf("Failed to unmarshal commissioning results: %v", err)
-		zap.L().Error(fmt.Sprintf("[GetMachineScriptResults] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
 	var scripts []CommissioningScript
-	for _, raw := range rawScripts {
-		systemID := ""
-		if node, ok := raw["node"].(map[string]any); ok {
-			if sid, ok := node["system_id"].(string); ok {
-				systemID = sid
-			}
-		}
-
-		data := ""
-		if d, ok := raw["data"].(string); ok && d != "" {
-			decoded, err := base64.StdEncoding.DecodeString(d)
+	for _, result := range results {
+		data := result.Data
+		if data != "" {
+			decoded, err := base64.StdEncoding.DecodeString(data)
 			if err != nil {
-				zap.L().Warn(fmt.Sprintf("[GetMachineScriptResults] Failed to decode base64 data for script %s: %v", raw["name"], err))
-				data = d
+				zap.L().Warn(fmt.Sprintf("[GetMachineScriptResults] Failed to decode base64 data for script %s: %v", result.Name, err))
 			} else {
 				data = string(decoded)
 			}
 		}
 
 		script := CommissioningScript{
-			ID:           parser.GetInt(raw, "id"),
-			Name:         parser.GetString(raw, "name"),
-			ScriptResult: parser.GetInt(raw, "script_result"),
-			ResultType:   parser.GetInt(raw, "result_type"),
-			SystemID:     systemID,
+			ID:           result.ID,
+			Name:         result.Name,
+			ScriptResult: result.ScriptResult,
+			ResultType:   result.ResultType,
+			SystemID:     result.Node.SystemID,
 			Data:         data,
-			Created:      parser.GetString(raw, "created"),
-			Updated:      parser.GetString(raw, "updated"),
+			Created:      result.Created,
+			Updated:      result.Updated,
 		}
 		scripts = append(scripts, script)
 	}
@@ -490,7 +662,9 @@ func (GetMachineScriptResults) Handle(ctx context.Context, request mcp.CallToolR
 	return mcp.NewToolResultText(string(response)), nil
 }
 
-type GetMachineIp struct{}
+type GetMachineIp struct {
+	Client maas_client.Client
+}
 
 func (GetMachineIp) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -501,11 +675,12 @@ func (GetMachineIp) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z]{6}$"),
 			mcp.Description("The id of the machine to commission."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Machine IP", true, false, false, true)),
 		mcp.WithDescription("Retrieve the main IP of the machine specified by id."),
 	)
 }
 
-func (GetMachineIp) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (g GetMachineIp) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 	machineID, err := request.RequireString("id")
 	if err != nil {
@@ -513,34 +688,19 @@ func (GetMachineIp) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/nodes/%s/interfaces/", machineID)
-	client := maas_client.MustClient()
-
-	interfacesRaw, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	interfaces, err := maas_api.New(g.Client).ListInterfaces(ctx, machineID)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve the interfaces of the machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[GetMachineIp] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	var interfaces []map[string]any
-	if err := json.Unmarshal([]byte(interfacesRaw), &interfaces); err != nil {
-		zap.L().Error(fmt.Sprintf("[GetMachineIp] %s", err.Error()))
-		return mcp.NewToolResultError(err.Error()), nil
-	}
-
 	ipv4Regex := regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`)
 
 	// Filter all the interfaces that are physical and without parents.
-	var filteredInterfaces []map[string]any
+	var filteredInterfaces []maas_api.Interface
 	for _, iface := range interfaces {
-		interfaceType, ok := iface["type"].(string)
-		if !ok || interfaceType != "physical" {
-			continue
-		}
-
-		parents, ok := iface["parents"].([]any)
-		if ok && len(parents) > 0 {
+		if iface.Type != "physical" || len(iface.Parents) > 0 {
 			continue
 		}
 
@@ -553,9 +713,8 @@ func (GetMachineIp) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	firstInterface := filteredInterfaces[0]
-	links, ok := firstInterface["links"].([]any)
-	if !ok || len(links) == 0 {
+	links := filteredInterfaces[0].Links
+	if len(links) == 0 {
 		errMsg = fmt.Sprintf("No links found for the interface on machine %s", machineID)
 		zap.L().Error(fmt.Sprintf("[GetMachineIp] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
@@ -563,18 +722,8 @@ func (GetMachineIp) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 
 	var ipAddress string
 	for _, link := range links {
-		linkMap, ok := link.(map[string]any)
-		if !ok {
-			continue
-		}
-
-		ip, ok := linkMap["ip_address"].(string)
-		if !ok || ip == "" {
-			continue
-		}
-
-		if ipv4Regex.MatchString(ip) {
-			ipAddress = ip
+		if ipv4Regex.MatchString(link.IPAddress) {
+			ipAddress = link.IPAddress
 			break
 		}
 	}
@@ -596,9 +745,12 @@ func (GetMachineIp) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 	return mcp.NewToolResultText(fmt.Sprintf(`{"ip_address": "%s", "machine_id": "%s"}`, output.IpAddress, output.MachineId)), nil
 }
 
-type CommissionMachine struct{}
+type CommissionMachine struct {
+	Client maas_client.Client
+	Jobs   *jobs.Manager
+}
 
-func (CommissionMachine) Create() mcp.Tool {
+func (c CommissionMachine) Create() mcp.Tool {
 	return mcp.NewTool(
 		"commission-machine",
 		mcp.WithString(
@@ -607,11 +759,18 @@ func (CommissionMachine) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z]{6}$"),
 			mcp.Description("The id of the machine to commission."),
 		),
-		mcp.WithDescription("Start the commissioning process on a particular machine."),
+		withAsync(c.Jobs, "Return a job right away, then commission the machine and wait for it to be ready in the background. Follow the job with get-job."),
+		mcp.WithNumber(
+			"timeout",
+			mcp.DefaultNumber(1800.0),
+			mcp.Description("With async, the timeout in seconds of the wait for the machine to be ready. Default: 1800s"),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Commission Machine", false, false, false, true)),
+		mcp.WithDescription("Start the commissioning process on a particular machine. With async, also wait for the machine to be ready in a background job."),
 	)
 }
 
-func (CommissionMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c CommissionMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	machineID, err := request.RequireString("id")
@@ -620,22 +779,19 @@ func (CommissionMachine) Handle(ctx context.Context, request mcp.CallToolRequest
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/op-commission", machineID)
-
-	client := maas_client.MustClient()
-
-	form := make(url.Values)
-	form.Add("enable_ssh", "1")
+	if runAsync(ctx, c.Jobs, request) {
+		return submitJob(ctx, c.Jobs, "commission-machine", request, "CommissionMachine")
+	}
 
 	zap.L().Info(fmt.Sprintf("[CommissionMachine] Commissioning machine with id %s...", machineID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	machine, err := maas_api.New(c.Client).CommissionMachine(ctx, machineID, maas_api.CommissionParams{EnableSSH: true})
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to commission the machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[CommissionMachine] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(machine)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[CommissionMachine] %s", errMsg))
@@ -645,33 +801,55 @@ func (CommissionMachine) Handle(ctx context.Context, request mcp.CallToolRequest
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type DeployMachine struct{}
+// runJob commissions the machine, then waits for it to be ready.
+func (c CommissionMachine) runJob(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error) {
+	if _, err := toolStep(ctx, job, "commission", c.Handle, request); err != nil {
+		return "", err
+	}
 
-func (DeployMachine) Create() mcp.Tool {
+	wait := WaitForMachineStatus{Client: c.Client}
+	return toolStep(ctx, job, "wait", wait.Handle, waitRequest(request.GetString("id", ""), "ready", request.GetFloat("timeout", 1800.0)))
+}
+
+type DeployMachine struct {
+	Client maas_client.Client
+	Jobs   *jobs.Manager
+}
+
+func (d DeployMachine) Create() mcp.Tool {
 	return mcp.NewTool(
 		"deploy-machine",
-		mcp.WithString(
-			"machineId",
-			mcp.Required(),
-			mcp.Pattern("^[0-9a-z]{6}$"),
-			mcp.Description("The id of the machine to deploy."),
-		),
-		mcp.WithString(
-			"templateId",
-			mcp.Required(),
-			mcp.Pattern("^[0-9a-z-_]*$"),
-			mcp.Description("The id of the templates to use for deployment."),
-		),
-		mcp.WithString(
-			"templateParameters",
-			mcp.Required(),
-			mcp.Description("The parameters that will be used to replace the values in the templates. They are represented as a valid JSON object. If the template does not require parameters enter an empty JSON dictionary {}."),
-		),
-		mcp.WithDescription("Deploys a machine with the specified id and template."),
+		withDeployOptions(
+			mcp.WithString(
+				"machineId",
+				mcp.Required(),
+				mcp.Pattern("^[0-9a-z]{6}$"),
+				mcp.Description("The id of the machine to deploy."),
+			),
+			mcp.WithString(
+				"templateId",
+				mcp.Required(),
+				mcp.Pattern("^[0-9a-z-_]*$"),
+				mcp.Description("The id of the templates to use for deployment."),
+			),
+			mcp.WithString(
+				"templateParameters",
+				mcp.Required(),
+				mcp.Description("The parameters that will be used to replace the values in the templates. They are represented as a valid JSON object. If the template does not require parameters enter an empty JSON dictionary {}."),
+			),
+			withAsync(d.Jobs, "Return a job right away, then deploy the machine and wait for it to be deployed in the background. Follow the job with get-job."),
+			mcp.WithNumber(
+				"timeout",
+				mcp.DefaultNumber(3600.0),
+				mcp.Description("With async, the timeout in seconds of the wait for the machine to be deployed. Default: 3600s"),
+			),
+			mcp.WithToolAnnotation(CreateToolAnnotation("Deploy Machine", false, false, false, true)),
+			mcp.WithDescription("Deploys a machine with the specified id and template, optionally choosing the OS, the series and the kernel among the imported images. With async, also wait for the machine to be deployed in a background job."),
+		)...,
 	)
 }
 
-func (DeployMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DeployMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	machineId, err := request.RequireString("machineId")
@@ -692,39 +870,41 @@ func (DeployMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	templateExecutor, err := templates.RetrieveExecutor(templateId, parameters)
-	if err != nil {
-		zap.L().Error(fmt.Sprintf("[DeployMachine] Failed to retrieve the template executor for parameters %s.", parameters))
+	api := maas_api.New(d.Client)
+	params := deployParams(request)
+	if err := validateDeployParams(ctx, api, machineId, params); err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployMachine] %v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	if err := os.Setenv("MACHINE_ID", machineId); err != nil {
+	if runAsync(ctx, d.Jobs, request) {
+		return submitJob(ctx, d.Jobs, "deploy-machine", request, "DeployMachine")
+	}
+
+	templateExecutor, err := templates.RetrieveExecutor(templateId, parameters)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeployMachine] Failed to retrieve the template executor for parameters %s.", parameters))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	userData, err := templateExecutor.Execute()
+	userData, err := templateExecutor.Execute(machineId)
 	if err != nil {
 		errMsg = "Failed to execute the template to retrieve the userData."
 		zap.L().Error(fmt.Sprintf("[DeployMachine] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	client := maas_client.MustClient()
-
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/op-deploy", machineId)
-
-	form := make(url.Values)
-	form.Add("user_data", userData)
+	params.UserData = userData
 
 	zap.L().Info(fmt.Sprintf("[DeployMachine] Deploying machine with id %s and template %s...", machineId, templateId))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	machine, err := api.DeployMachine(ctx, machineId, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to deploy the machine with id %s err=%v", machineId, err)
 		zap.L().Error(fmt.Sprintf("[DeployMachine] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(machine)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[DeployMachine] %s", errMsg))
@@ -734,116 +914,108 @@ func (DeployMachine) Handle(ctx context.Context, request mcp.CallToolRequest) (*
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-func convertToMachine(raw map[string]any) Machine {
+// runJob deploys the machine, then waits for it to be deployed.
+func (d DeployMachine) runJob(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error) {
+	if _, err := toolStep(ctx, job, "deploy", d.Handle, request); err != nil {
+		return "", err
+	}
+
+	wait := WaitForMachineStatus{Client: d.Client}
+	return toolStep(ctx, job, "wait", wait.Handle, waitRequest(request.GetString("machineId", ""), "deployed", request.GetFloat("timeout", 3600.0)))
+}
+
+// convertToMachine summarizes a machine for the short output.
+func convertToMachine(machine maas_api.Machine) Machine {
 	m := Machine{
-		SystemID:   parser.GetString(raw, "system_id"),
-		Hostname:   parser.GetString(raw, "hostname"),
-		FQDN:       parser.GetString(raw, "fqdn"),
-		StatusName: parser.GetString(raw, "status_name"),
-		PowerState: parser.GetString(raw, "power_state"),
-		Locked:     parser.GetBool(raw, "locked"),
+		SystemID:   machine.SystemID,
+		Hostname:   machine.Hostname,
+		FQDN:       machine.FQDN,
+		StatusName: machine.StatusName,
+		PowerState: machine.PowerState,
+		Locked:     machine.Locked,
 
-		Architecture: parser.GetString(raw, "architecture"),
-		CPUCount:     parser.GetInt(raw, "cpu_count"),
-		Memory:       parser.GetInt(raw, "memory"),
-		Storage:      parser.GetFloat(raw, "storage"),
+		Architecture: machine.Architecture,
+		CPUCount:     machine.CPUCount,
+		Memory:       machine.Memory,
+		Storage:      machine.Storage,
+		CPUModel:     machine.HardwareInfo["cpu_model"],
 
-		OSSystem:     parser.GetString(raw, "osystem"),
-		DistroSeries: parser.GetString(raw, "distro_series"),
+		OSSystem:     machine.OSystem,
+		DistroSeries: machine.DistroSeries,
 
-		IPAddresses: parser.GetStringSlice(raw, "ip_addresses"),
-		TagNames:    parser.GetStringSlice(raw, "tag_names"),
+		IPAddresses: machine.IPAddresses,
+		TagNames:    machine.TagNames,
 	}
 
-	if hwInfo, ok := raw["hardware_info"].(map[string]any); ok {
-		m.CPUModel = parser.GetString(hwInfo, "cpu_model")
+	if machine.DefaultGateways != nil {
+		m.Gateway = machine.DefaultGateways.IPv4.GatewayIP
 	}
 
-	if gateways, ok := raw["default_gateways"].(map[string]any); ok {
-		if ipv4, ok := gateways["ipv4"].(map[string]any); ok {
-			m.Gateway = parser.GetString(ipv4, "gateway_ip")
-		}
+	if machine.Zone != nil {
+		m.Zone = machine.Zone.Name
 	}
 
-	if zone, ok := raw["zone"].(map[string]any); ok {
-		m.Zone = parser.GetString(zone, "name")
+	if machine.Pool != nil {
+		m.Pool = machine.Pool.Name
 	}
 
-	if pool, ok := raw["pool"].(map[string]any); ok {
-		m.Pool = parser.GetString(pool, "name")
+	if machine.BootInterface != nil {
+		m.BootInterface = convertToInterface(*machine.BootInterface)
 	}
 
-	if bootIface, ok := raw["boot_interface"].(map[string]any); ok {
-		m.BootInterface = convertToInterface(bootIface)
+	for _, iface := range machine.Interfaces {
+		m.Interfaces = append(m.Interfaces, *convertToInterface(iface))
 	}
 
-	if ifaceSet, ok := raw["interface_set"].([]any); ok {
-		for _, iface := range ifaceSet {
-			if ifaceMap, ok := iface.(map[string]any); ok {
-				m.Interfaces = append(m.Interfaces, *convertToInterface(ifaceMap))
-			}
-		}
+	if machine.BootDisk != nil {
+		m.BootDisk = convertToBlockDevice(*machine.BootDisk)
 	}
 
-	if bootDisk, ok := raw["boot_disk"].(map[string]any); ok {
-		m.BootDisk = convertToBlockDevice(bootDisk)
-	}
-
-	if diskSet, ok := raw["blockdevice_set"].([]any); ok {
-		for _, disk := range diskSet {
-			if diskMap, ok := disk.(map[string]any); ok {
-				m.Disks = append(m.Disks, *convertToBlockDevice(diskMap))
-			}
-		}
+	for _, disk := range machine.BlockDevices {
+		m.Disks = append(m.Disks, *convertToBlockDevice(disk))
 	}
 
 	return m
 }
 
-func convertToInterface(raw map[string]any) *Interface {
-	iface := &Interface{
-		Name:       parser.GetString(raw, "name"),
-		MACAddress: parser.GetString(raw, "mac_address"),
+func convertToInterface(iface maas_api.Interface) *Interface {
+	converted := &Interface{
+		Name:       iface.Name,
+		MACAddress: iface.MACAddress,
 	}
 
-	if vlan, ok := raw["vlan"].(map[string]any); ok {
-		iface.VLAN = parser.GetInt(vlan, "vid")
+	if iface.VLAN != nil {
+		converted.VLAN = iface.VLAN.VID
 	}
 
-	if links, ok := raw["links"].([]any); ok && len(links) > 0 {
-		if link, ok := links[0].(map[string]any); ok {
-			iface.IPAddress = parser.GetString(link, "ip_address")
-			if subnet, ok := link["subnet"].(map[string]any); ok {
-				iface.CIDR = parser.GetString(subnet, "cidr")
-			}
+	if len(iface.Links) > 0 {
+		converted.IPAddress = iface.Links[0].IPAddress
+		if iface.Links[0].Subnet != nil {
+			converted.CIDR = iface.Links[0].Subnet.CIDR
 		}
 	}
 
-	return iface
+	return converted
 }
 
-func convertToBlockDevice(raw map[string]any) *BlockDevice {
+func convertToBlockDevice(device maas_api.BlockDevice) *BlockDevice {
 	disk := &BlockDevice{
-		Name:   parser.GetString(raw, "name"),
-		Size:   parser.GetInt64(raw, "size"),
-		Model:  parser.GetString(raw, "model"),
-		Serial: parser.GetString(raw, "serial"),
-	}
-
-	if partitions, ok := raw["partitions"].([]any); ok {
-		for _, part := range partitions {
-			if partMap, ok := part.(map[string]any); ok {
-				p := Partition{
-					Path: parser.GetString(partMap, "path"),
-					Size: parser.GetInt64(partMap, "size"),
-				}
-				if fs, ok := partMap["filesystem"].(map[string]any); ok {
-					p.FSType = parser.GetString(fs, "fstype")
-					p.MountPoint = parser.GetString(fs, "mount_point")
-				}
-				disk.Partitions = append(disk.Partitions, p)
-			}
+		Name:   device.Name,
+		Size:   device.Size,
+		Model:  device.Model,
+		Serial: device.Serial,
+	}
+
+	for _, partition := range device.Partitions {
+		p := Partition{
+			Path: partition.Path,
+			Size: partition.Size,
+		}
+		if partition.Filesystem != nil {
+			p.FSType = partition.Filesystem.FSType
+			p.MountPoint = partition.Filesystem.MountPoint
 		}
+		disk.Partitions = append(disk.Partitions, p)
 	}
 
 	return disk
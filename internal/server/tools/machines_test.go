@@ -0,0 +1,444 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+)
+
+func TestListMachines(t *testing.T) {
+	fake := fakemaas.Start(t)
+	fake.AddMachine(fakemaas.Machine{SystemID: "aaaaaa", Status: fakemaas.StatusReady})
+	fake.AddMachine(fakemaas.Machine{SystemID: "bbbbbb", Status: fakemaas.StatusDeployed})
+	fake.AddMachine(fakemaas.Machine{SystemID: "cccccc", Status: fakemaas.StatusReady, TagNames: []string{"protected"}})
+
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  []string
+	}{
+		{"all machines except protected ones", map[string]any{}, []string{"aaaaaa", "bbbbbb"}},
+		{"filters by status", map[string]any{"status": "deployed"}, []string{"bbbbbb"}},
+		{"ignores unknown statuses", map[string]any{"status": "flying"}, []string{"aaaaaa", "bbbbbb"}},
+		{"long output", map[string]any{"short_output": false}, []string{"aaaaaa", "bbbbbb"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			result := fakemaas.CallTool(t, ListMachines{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			var machines []map[string]any
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &machines); err != nil {
+				t.Fatalf("expected a list of machines, got %v", err)
+			}
+			var ids []string
+			for _, m := range machines {
+				ids = append(ids, m["system_id"].(string))
+			}
+			if strings.Join(ids, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("expected machines %v, got %v", tc.expected, ids)
+			}
+		})
+	}
+
+	t.Run("long output keeps the fields the machine type does not model", func(t *testing.T) {
+		// Act
+		result := fakemaas.CallTool(t, ListMachines{Client: fake.Client()}.Handle, map[string]any{"short_output": false})
+
+		// Assert
+		var machines []map[string]any
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &machines); err != nil {
+			t.Fatalf("expected a list of machines, got %v", err)
+		}
+		for _, m := range machines {
+			if m["node_type_name"] != "Machine" {
+				t.Errorf("expected node_type_name to be passed through, got %v", m)
+			}
+		}
+	})
+
+	t.Run("reports MAAS errors", func(t *testing.T) {
+		// Arrange
+		fake.Fail(http.MethodGet, "/MAAS/api/2.0/machines/", http.StatusInternalServerError)
+		defer fake.ClearFailures()
+
+		// Act
+		result := fakemaas.CallTool(t, ListMachines{Client: fake.Client()}.Handle, map[string]any{})
+
+		// Assert
+		if !result.IsError {
+			t.Error("expected an error result")
+		}
+	})
+}
+
+func TestListMachine(t *testing.T) {
+	fake := fakemaas.Start(t)
+	fake.AddMachine(fakemaas.Machine{SystemID: "aaaaaa", Hostname: "node-1"})
+	fake.AddMachine(fakemaas.Machine{SystemID: "bbbbbb", TagNames: []string{"protected"}})
+
+	cases := []struct {
+		name    string
+		id      string
+		isError bool
+	}{
+		{"existing machine", "aaaaaa", false},
+		{"protected machine", "bbbbbb", true},
+		{"unknown machine", "zzzzzz", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			result := fakemaas.CallTool(t, ListMachine{Client: fake.Client()}.Handle, map[string]any{"id": tc.id})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+		})
+	}
+
+	t.Run("long output keeps the fields the machine type does not model", func(t *testing.T) {
+		// Act
+		result := fakemaas.CallTool(t, ListMachine{Client: fake.Client()}.Handle, map[string]any{"id": "aaaaaa", "short_output": false})
+
+		// Assert
+		var machine map[string]any
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &machine); err != nil {
+			t.Fatalf("expected a machine, got %v", err)
+		}
+		if machine["node_type_name"] != "Machine" {
+			t.Errorf("expected node_type_name to be passed through, got %v", machine)
+		}
+	})
+
+	t.Run("long output refuses protected machines", func(t *testing.T) {
+		// Act
+		result := fakemaas.CallTool(t, ListMachine{Client: fake.Client()}.Handle, map[string]any{"id": "bbbbbb", "short_output": false})
+
+		// Assert
+		if !result.IsError {
+			t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+	})
+}
+
+func TestListMachine_DNSRecords(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	fake.AddDomain(fakemaas.Domain{Name: "maas"})
+	fake.AddDomain(fakemaas.Domain{Name: "example.com"})
+	fake.AddMachine(fakemaas.Machine{
+		SystemID:   "aaaaaa",
+		Hostname:   "node-1",
+		Interfaces: []fakemaas.Interface{{Name: "eth0", Links: []fakemaas.Link{{Mode: "static", IPAddress: "10.0.0.5"}}}},
+	})
+	fake.AddDNSResource(fakemaas.DNSResource{Name: "api", Domain: "example.com", IPAddresses: []string{"10.0.0.5"}})
+	fake.AddDNSResource(fakemaas.DNSResource{Name: "www", Domain: "example.com", Records: []fakemaas.DNSRecord{{RRType: "CNAME", RRData: "node-1.maas"}}})
+	fake.AddDNSResource(fakemaas.DNSResource{Name: "other", Domain: "example.com", IPAddresses: []string{"10.0.0.6"}})
+
+	// Act
+	result := fakemaas.CallTool(t, ListMachine{Client: fake.Client()}.Handle, map[string]any{"id": "aaaaaa"})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	var machine Machine
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &machine); err != nil {
+		t.Fatalf("expected a machine, got %v", err)
+	}
+	if machine.FQDN != "node-1.maas" {
+		t.Errorf("expected fqdn node-1.maas, got %q", machine.FQDN)
+	}
+	var records []string
+	for _, record := range machine.DNSRecords {
+		records = append(records, record.FQDN+" "+record.Type+" "+record.Data)
+	}
+	expected := []string{"api.example.com A 10.0.0.5", "www.example.com CNAME node-1.maas"}
+	if strings.Join(records, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected records %v, got %v", expected, records)
+	}
+}
+
+func TestWaitForMachineStatus(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	cases := []struct {
+		name    string
+		status  string
+		setup   func(t *testing.T, fake *fakemaas.Server, id string)
+		isError bool
+	}{
+		{
+			name:   "waits for the deployment to finish",
+			status: "deployed",
+			setup: func(t *testing.T, fake *fakemaas.Server, id string) {
+				fake.TransitionReads = 3
+				if _, err := fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+id+"/op-deploy", nil); err != nil {
+					t.Fatalf("failed to start the deployment: %v", err)
+				}
+			},
+		},
+		{
+			name:   "matches statuses with spaces",
+			status: "failed_deployment",
+			setup: func(t *testing.T, fake *fakemaas.Server, id string) {
+				fake.SetStatus(id, fakemaas.StatusFailedDeployment)
+			},
+		},
+		{
+			name:    "times out",
+			status:  "deployed",
+			setup:   func(t *testing.T, fake *fakemaas.Server, id string) {},
+			isError: true,
+		},
+		{
+			name:   "unknown machine",
+			status: "deployed",
+			setup: func(t *testing.T, fake *fakemaas.Server, id string) {
+				fake.Fail(http.MethodGet, "/MAAS/api/2.0/machines/"+id+"/", http.StatusNotFound)
+			},
+			isError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{})
+			tc.setup(t, fake, m.SystemID)
+
+			// Act
+			result := fakemaas.CallTool(t, WaitForMachineStatus{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "status": tc.status, "timeout": 0.2})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+		})
+	}
+}
+
+func TestWaitForMachineStatus_Failed(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{})
+	if _, err := fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+m.SystemID+"/op-deploy", nil); err != nil {
+		t.Fatalf("failed to start the deployment: %v", err)
+	}
+	fake.FailTransition(m.SystemID)
+
+	// Act
+	start := time.Now()
+	result := fakemaas.CallTool(t, WaitForMachineStatus{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "status": "deployed", "timeout": 30.0})
+
+	// Assert
+	if !result.IsError {
+		t.Fatalf("expected an error result, got %s", fakemaas.ResultText(t, result))
+	}
+	if text := fakemaas.ResultText(t, result); !strings.Contains(text, "Failed deployment") || !strings.Contains(text, "Machine moved to Failed deployment") {
+		t.Errorf("expected the failed status and its event, got %s", text)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the wait to stop early, took %s", elapsed)
+	}
+}
+
+func TestWaitForMachineStatus_Progress(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{})
+	fake.TransitionReads = 3
+	if _, err := fake.Client().Do(context.Background(), maas_client.RequestTypePost, "/MAAS/api/2.0/machines/"+m.SystemID+"/op-deploy", nil); err != nil {
+		t.Fatalf("failed to start the deployment: %v", err)
+	}
+	tool := WaitForMachineStatus{Client: fake.Client()}
+
+	// Act
+	result, notifications := fakemaas.CallToolWithProgress(t, tool.Create(), tool.Handle, map[string]any{"id": m.SystemID, "status": "deployed", "timeout": 5.0})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	expected := []string{"Deploying", "Deployed"}
+	if len(notifications) != len(expected) {
+		t.Fatalf("expected %d notifications, got %d", len(expected), len(notifications))
+	}
+	for i, notification := range notifications {
+		params := notification.Params.AdditionalFields
+		if params["progressToken"] != "progress" || params["progress"] != float64(i+1) {
+			t.Errorf("unexpected progress %v", params)
+		}
+		message, _ := params["message"].(string)
+		if !strings.Contains(message, "is "+expected[i]+" after") || !strings.Contains(message, "latest event:") {
+			t.Errorf("unexpected message %q", message)
+		}
+	}
+}
+
+func TestGetMachineStatus(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{Status: fakemaas.StatusBroken})
+
+	// Act
+	result := fakemaas.CallTool(t, GetMachineStatus{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID})
+
+	// Assert
+	if text := fakemaas.ResultText(t, result); text != `{"status": "Broken"}` {
+		t.Errorf("unexpected result %s", text)
+	}
+}
+
+func TestGetMachineIp(t *testing.T) {
+	fake := fakemaas.Start(t)
+	subnet := fake.AddSubnet(fakemaas.Subnet{CIDR: "10.0.0.0/24"})
+	withIP := fake.AddMachine(fakemaas.Machine{Interfaces: []fakemaas.Interface{
+		{Name: "eth0", Links: []fakemaas.Link{{IPAddress: "fd00::5"}, {IPAddress: "10.0.0.5", SubnetID: subnet.ID}}},
+	}})
+	withoutLinks := fake.AddMachine(fakemaas.Machine{Interfaces: []fakemaas.Interface{{Name: "eth0"}}})
+	bondOnly := fake.AddMachine(fakemaas.Machine{Interfaces: []fakemaas.Interface{{Name: "bond0", Type: "bond", Parents: []string{"eth0"}}}})
+
+	cases := []struct {
+		name     string
+		id       string
+		expected string
+		isError  bool
+	}{
+		{"first IPv4 address", withIP.SystemID, `{"ip_address": "10.0.0.5", "machine_id": "` + withIP.SystemID + `"}`, false},
+		{"interface without links", withoutLinks.SystemID, "", true},
+		{"no physical interface", bondOnly.SystemID, "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			result := fakemaas.CallTool(t, GetMachineIp{Client: fake.Client()}.Handle, map[string]any{"id": tc.id})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Fatalf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if !tc.isError && fakemaas.ResultText(t, result) != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, fakemaas.ResultText(t, result))
+			}
+		})
+	}
+}
+
+func TestGetMachineScriptResults(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{ScriptResults: []fakemaas.ScriptResult{{ID: 7, Name: "00-maas-01-lshw", Output: "<list/>"}}})
+
+	// Act
+	result := fakemaas.CallTool(t, GetMachineScriptResults{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID})
+
+	// Assert
+	var scripts []CommissioningScript
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &scripts); err != nil {
+		t.Fatalf("expected a list of scripts, got %v", err)
+	}
+	if len(scripts) != 1 || scripts[0].Data != "<list/>" || scripts[0].SystemID != m.SystemID {
+		t.Errorf("unexpected scripts %+v", scripts)
+	}
+}
+
+func TestCommissionMachine(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   string
+		expected string
+		isError  bool
+	}{
+		{"new machine", fakemaas.StatusNew, fakemaas.StatusCommissioning, false},
+		{"deployed machine", fakemaas.StatusDeployed, fakemaas.StatusDeployed, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{Status: tc.status})
+
+			// Act
+			result := fakemaas.CallTool(t, CommissionMachine{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if got, _ := fake.Machine(m.SystemID); got.Status != tc.expected {
+				t.Errorf("expected status %s, got %s", tc.expected, got.Status)
+			}
+			if request, _ := fake.LastRequest(http.MethodPost); !tc.isError && request.Form.Get("enable_ssh") != "1" {
+				t.Errorf("expected enable_ssh=1, got %v", request.Form)
+			}
+		})
+	}
+}
+
+func TestDeployMachine(t *testing.T) {
+	store := templates.MustTemplateStore()
+	if err := store.Create(templates.GenericTemplate{Id: "deploy_machine_test", Name: "Deploy Machine Test", Description: "Test deployment"}); err != nil {
+		t.Fatalf("failed to create the template: %v", err)
+	}
+	defer store.Delete("deploy_machine_test")
+
+	cases := []struct {
+		name       string
+		templateID string
+		status     string
+		isError    bool
+	}{
+		{"ready machine", "deploy_machine_test", fakemaas.StatusReady, false},
+		{"machine in use", "deploy_machine_test", fakemaas.StatusDeployed, true},
+		{"unknown template", "missing_template", fakemaas.StatusReady, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{Status: tc.status})
+
+			// Act
+			result := fakemaas.CallTool(t, DeployMachine{Client: fake.Client()}.Handle, map[string]any{
+				"machineId":          m.SystemID,
+				"templateId":         tc.templateID,
+				"templateParameters": "{}",
+			})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Fatalf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if got, _ := fake.Machine(m.SystemID); !tc.isError && (got.Status != fakemaas.StatusDeploying || got.UserData == "") {
+				t.Errorf("expected a deployment with user data, got status %s", got.Status)
+			}
+		})
+	}
+}
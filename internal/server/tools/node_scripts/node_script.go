@@ -4,26 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type NodeScript struct{}
+type NodeScript struct {
+	Client maas_client.Client
+}
 
-func (NodeScript) Register(mcpServer *server.MCPServer) {
+func (n NodeScript) Register(mcpServer registry.ToolServer) {
 	mcpTools := []tools.MCPTool{
-		DeleteNodeScript{},
-		ReadNodeScript{},
-		UpdateNodeScript{},
-		AddTagToNodeScript{},
-		DownloadNodeScript{},
-		RemoveTagFromNodeScript{},
+		DeleteNodeScript{Client: n.Client},
+		ReadNodeScript{Client: n.Client},
+		UpdateNodeScript{Client: n.Client},
+		AddTagToNodeScript{Client: n.Client},
+		DownloadNodeScript{Client: n.Client},
+		RemoveTagFromNodeScript{Client: n.Client},
 	}
 
 	for _, tool := range mcpTools {
@@ -31,7 +32,9 @@ func (NodeScript) Register(mcpServer *server.MCPServer) {
 	}
 }
 
-type DeleteNodeScript struct{}
+type DeleteNodeScript struct {
+	Client maas_client.Client
+}
 
 func (DeleteNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -46,7 +49,7 @@ func (DeleteNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (DeleteNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DeleteNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	scriptName, err := request.RequireString("name")
@@ -55,29 +58,19 @@ func (DeleteNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/scripts/%s", scriptName)
-
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[DeleteNodeScript] Deleting script with name: %s", scriptName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeDelete, path, nil)
-	if err != nil {
+	if err := maas_api.New(d.Client).DeleteScript(ctx, scriptName); err != nil {
 		errMsg = fmt.Sprintf("Failed to delete script %s err=%v", scriptName, err)
 		zap.L().Error(fmt.Sprintf("[DeleteNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
-	if err != nil {
-		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
-		zap.L().Error(fmt.Sprintf("[DeleteNodeScript] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Script %s deleted", scriptName)), nil
 }
 
-type ReadNodeScript struct{}
+type ReadNodeScript struct {
+	Client maas_client.Client
+}
 
 func (ReadNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -96,7 +89,7 @@ func (ReadNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (ReadNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (r ReadNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	scriptName, err := request.RequireString("name")
@@ -105,25 +98,17 @@ func (ReadNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/scripts/%s", scriptName)
-
-	if includeScript := request.GetString("include_script", ""); includeScript != "" {
-		queryParams := url.Values{}
-		queryParams.Add("include_script", includeScript)
-		path += "?" + queryParams.Encode()
-	}
-
-	client := maas_client.MustClient()
+	includeScript := request.GetString("include_script", "") != ""
 
 	zap.L().Info(fmt.Sprintf("[ReadNodeScript] Retrieving script with name: %s", scriptName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	script, err := maas_api.New(r.Client).GetScript(ctx, scriptName, includeScript)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to read script %s err=%v", scriptName, err)
 		zap.L().Error(fmt.Sprintf("[ReadNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(script)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ReadNodeScript] %s", errMsg))
@@ -133,7 +118,9 @@ func (ReadNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type UpdateNodeScript struct{}
+type UpdateNodeScript struct {
+	Client maas_client.Client
+}
 
 func (UpdateNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -208,7 +195,7 @@ func (UpdateNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (UpdateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (u UpdateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	scriptName, err := request.RequireString("name")
@@ -217,80 +204,44 @@ func (UpdateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-
-	if script := request.GetString("script", ""); script != "" {
-		form.Add("script", script)
-	}
-	if scriptType := request.GetString("type", ""); scriptType != "" {
-		form.Add("type", scriptType)
-	}
-	if hardwareType := request.GetString("hardware_type", ""); hardwareType != "" {
-		form.Add("hardware_type", hardwareType)
-	}
-	if title := request.GetString("title", ""); title != "" {
-		form.Add("title", title)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if tags := request.GetString("tags", ""); tags != "" {
-		form.Add("tags", tags)
-	}
-	if timeout := request.GetString("timeout", ""); timeout != "" {
-		form.Add("timeout", timeout)
-	}
-	if comment := request.GetString("comment", ""); comment != "" {
-		form.Add("comment", comment)
-	}
-	if forHardware := request.GetString("for_hardware", ""); forHardware != "" {
-		form.Add("for_hardware", forHardware)
-	}
-	if parallel := request.GetString("parallel", ""); parallel != "" {
-		form.Add("parallel", parallel)
-	}
-
-	destructive := request.GetBool("destructive", false)
-	if destructive {
-		form.Add("destructive", "1")
-	} else {
-		form.Add("destructive", "0")
-	}
-
-	mayReboot := request.GetBool("may_reboot", false)
-	if mayReboot {
-		form.Add("may_reboot", "1")
-	} else {
-		form.Add("may_reboot", "0")
+	timeout, err := tools.OptionalInt(request, "timeout")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateNodeScript] Invalid parameter timeout err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	recommission := request.GetBool("recommission", false)
-	if recommission {
-		form.Add("recommission", "1")
-	} else {
-		form.Add("recommission", "0")
+	parallel, err := tools.OptionalInt(request, "parallel")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateNodeScript] Invalid parameter parallel err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	applyConfiguredNetworking := request.GetBool("apply_configured_networking", false)
-	if applyConfiguredNetworking {
-		form.Add("apply_configured_networking", "1")
-	} else {
-		form.Add("apply_configured_networking", "0")
+	params := maas_api.ScriptParams{
+		Script:                    request.GetString("script", ""),
+		Type:                      request.GetString("type", ""),
+		HardwareType:              request.GetString("hardware_type", ""),
+		Title:                     request.GetString("title", ""),
+		Description:               request.GetString("description", ""),
+		Tags:                      request.GetString("tags", ""),
+		ForHardware:               request.GetString("for_hardware", ""),
+		Comment:                   request.GetString("comment", ""),
+		Timeout:                   timeout,
+		Parallel:                  parallel,
+		Destructive:               tools.OptionalBool(request, "destructive"),
+		MayReboot:                 tools.OptionalBool(request, "may_reboot"),
+		Recommission:              tools.OptionalBool(request, "recommission"),
+		ApplyConfiguredNetworking: tools.OptionalBool(request, "apply_configured_networking"),
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/scripts/%s", scriptName)
-
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[UpdateNodeScript] Updating script with name: %s", scriptName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePut, path, strings.NewReader(form.Encode()))
+	script, err := maas_api.New(u.Client).UpdateScript(ctx, scriptName, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to update script %s err=%v", scriptName, err)
 		zap.L().Error(fmt.Sprintf("[UpdateNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(script)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[UpdateNodeScript] %s", errMsg))
@@ -300,7 +251,9 @@ func (UpdateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type AddTagToNodeScript struct{}
+type AddTagToNodeScript struct {
+	Client maas_client.Client
+}
 
 func (AddTagToNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -319,7 +272,7 @@ func (AddTagToNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (AddTagToNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (a AddTagToNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	scriptName, err := request.RequireString("name")
@@ -328,24 +281,17 @@ func (AddTagToNodeScript) Handle(ctx context.Context, request mcp.CallToolReques
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-	if tag := request.GetString("tag", ""); tag != "" {
-		form.Add("tag", tag)
-	}
-
-	path := fmt.Sprintf("/MAAS/api/2.0/scripts/%sop-add_tag", scriptName)
-
-	client := maas_client.MustClient()
+	tag := request.GetString("tag", "")
 
 	zap.L().Info(fmt.Sprintf("[AddTagToNodeScript] Adding tag to script with name: %s", scriptName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	script, err := maas_api.New(a.Client).AddScriptTag(ctx, scriptName, tag)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to add tag to script %s err=%v", scriptName, err)
 		zap.L().Error(fmt.Sprintf("[AddTagToNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(script)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[AddTagToNodeScript] %s", errMsg))
@@ -355,7 +301,9 @@ func (AddTagToNodeScript) Handle(ctx context.Context, request mcp.CallToolReques
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type DownloadNodeScript struct{}
+type DownloadNodeScript struct {
+	Client maas_client.Client
+}
 
 func (DownloadNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -375,7 +323,7 @@ func (DownloadNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (DownloadNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DownloadNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	scriptName, err := request.RequireString("name")
@@ -384,28 +332,22 @@ func (DownloadNodeScript) Handle(ctx context.Context, request mcp.CallToolReques
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/scripts/%sop-download", scriptName)
-
-	if revision := request.GetString("revision", ""); revision != "" {
-		queryParams := url.Values{}
-		queryParams.Add("revision", revision)
-		path += "?" + queryParams.Encode()
-	}
-
-	client := maas_client.MustClient()
+	revision := request.GetInt("revision", 0)
 
 	zap.L().Info(fmt.Sprintf("[DownloadNodeScript] Downloading script with name: %s", scriptName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	content, err := maas_api.New(d.Client).DownloadScript(ctx, scriptName, revision)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to download script %s err=%v", scriptName, err)
 		zap.L().Error(fmt.Sprintf("[DownloadNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("%v", resultData)), nil
+	return mcp.NewToolResultText(content), nil
 }
 
-type RemoveTagFromNodeScript struct{}
+type RemoveTagFromNodeScript struct {
+	Client maas_client.Client
+}
 
 func (RemoveTagFromNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -424,7 +366,7 @@ func (RemoveTagFromNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (RemoveTagFromNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (r RemoveTagFromNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	scriptName, err := request.RequireString("name")
@@ -433,24 +375,17 @@ func (RemoveTagFromNodeScript) Handle(ctx context.Context, request mcp.CallToolR
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-	if tag := request.GetString("tag", ""); tag != "" {
-		form.Add("tag", tag)
-	}
-
-	path := fmt.Sprintf("/MAAS/api/2.0/scripts/%sop-remove_tag", scriptName)
-
-	client := maas_client.MustClient()
+	tag := request.GetString("tag", "")
 
 	zap.L().Info(fmt.Sprintf("[RemoveTagFromNodeScript] Removing tag from script with name: %s", scriptName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	script, err := maas_api.New(r.Client).RemoveScriptTag(ctx, scriptName, tag)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to remove tag from script %s err=%v", scriptName, err)
 		zap.L().Error(fmt.Sprintf("[RemoveTagFromNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(script)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[RemoveTagFromNodeScript] %s", errMsg))
@@ -4,27 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type NodeScripts struct{}
+type NodeScripts struct {
+	Client maas_client.Client
+}
 
-func (NodeScripts) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{ListNodeScripts{}, CreateNodeScript{}}
+func (n NodeScripts) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListNodeScripts{Client: n.Client}, CreateNodeScript{Client: n.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListNodeScripts struct{}
+type ListNodeScripts struct {
+	Client maas_client.Client
+}
 
 func (ListNodeScripts) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -52,42 +55,25 @@ func (ListNodeScripts) Create() mcp.Tool {
 	)
 }
 
-func (ListNodeScripts) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListNodeScripts) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/scripts/"
 
-	// Build query parameters
-	queryParams := url.Values{}
-
-	if scriptType := request.GetString("type", ""); scriptType != "" {
-		queryParams.Add("type", scriptType)
-	}
-	if hardwareType := request.GetString("hardware_type", ""); hardwareType != "" {
-		queryParams.Add("hardware_type", hardwareType)
-	}
-	if includeScript := request.GetString("include_script", ""); includeScript != "" {
-		queryParams.Add("include_script", includeScript)
-	}
-	if filters := request.GetString("filters", ""); filters != "" {
-		queryParams.Add("filters", filters)
+	filter := maas_api.ScriptFilter{
+		Type:          request.GetString("type", ""),
+		HardwareType:  request.GetString("hardware_type", ""),
+		Filters:       request.GetString("filters", ""),
+		IncludeScript: request.GetString("include_script", "") != "",
 	}
 
-	// Add query parameters to path if any exist
-	if len(queryParams) > 0 {
-		path += "?" + queryParams.Encode()
-	}
-
-	client := maas_client.MustClient()
-
 	zap.L().Info("[ListNodeScripts] Retrieving all node scripts...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	scripts, err := maas_api.New(l.Client).ListScripts(ctx, filter)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve all the node scripts: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListNodeScripts] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(scripts)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListNodeScripts] %s", errMsg))
@@ -97,7 +83,9 @@ func (ListNodeScripts) Handle(ctx context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type CreateNodeScript struct{}
+type CreateNodeScript struct {
+	Client maas_client.Client
+}
 
 func (CreateNodeScript) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -168,9 +156,8 @@ func (CreateNodeScript) Create() mcp.Tool {
 	)
 }
 
-func (CreateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c CreateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/scripts/"
 
 	name, err := request.RequireString("name")
 	if err != nil {
@@ -178,69 +165,44 @@ func (CreateNodeScript) Handle(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-	form.Add("name", name)
-
-	// Add optional string parameters
-	if script := request.GetString("script", ""); script != "" {
-		form.Add("script", script)
-	}
-	if scriptType := request.GetString("type", ""); scriptType != "" {
-		form.Add("type", scriptType)
-	}
-	if hardwareType := request.GetString("hardware_type", ""); hardwareType != "" {
-		form.Add("hardware_type", hardwareType)
-	}
-	if title := request.GetString("title", ""); title != "" {
-		form.Add("title", title)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if tags := request.GetString("tags", ""); tags != "" {
-		form.Add("tags", tags)
-	}
-	if timeout := request.GetString("timeout", ""); timeout != "" {
-		form.Add("timeout", timeout)
-	}
-	if comment := request.GetString("comment", ""); comment != "" {
-		form.Add("comment", comment)
-	}
-	if forHardware := request.GetString("for_hardware", ""); forHardware != "" {
-		form.Add("for_hardware", forHardware)
-	}
-	if parallel := request.GetString("parallel", ""); parallel != "" {
-		form.Add("parallel", parallel)
-	}
-	if recommission := request.GetString("recommission", ""); recommission != "" {
-		form.Add("recommission", recommission)
+	timeout, err := tools.OptionalInt(request, "timeout")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateNodeScript] Invalid parameter timeout err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	destructive := request.GetBool("destructive", false)
-	if destructive {
-		form.Add("destructive", "1")
-	} else {
-		form.Add("destructive", "0")
+	parallel, err := tools.OptionalInt(request, "parallel")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateNodeScript] Invalid parameter parallel err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	may_reboot := request.GetBool("may_reboot", false)
-	if may_reboot {
-		form.Add("may_reboot", "1")
-	} else {
-		form.Add("may_reboot", "0")
+	params := maas_api.ScriptParams{
+		Name:         name,
+		Script:       request.GetString("script", ""),
+		Type:         request.GetString("type", ""),
+		HardwareType: request.GetString("hardware_type", ""),
+		Title:        request.GetString("title", ""),
+		Description:  request.GetString("description", ""),
+		Tags:         request.GetString("tags", ""),
+		ForHardware:  request.GetString("for_hardware", ""),
+		Comment:      request.GetString("comment", ""),
+		Timeout:      timeout,
+		Parallel:     parallel,
+		Destructive:  tools.OptionalBool(request, "destructive"),
+		MayReboot:    tools.OptionalBool(request, "may_reboot"),
+		Recommission: tools.OptionalBool(request, "recommission"),
 	}
 
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[CreateNodeScript] Creating node script with name: %s", name))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	script, err := maas_api.New(c.Client).CreateScript(ctx, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to create node script err=%v", err)
 		zap.L().Error(fmt.Sprintf("[CreateNodeScript] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(script)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[CreateNodeScript] %s", errMsg))
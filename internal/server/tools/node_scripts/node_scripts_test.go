@@ -0,0 +1,67 @@
+package nodescripts
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestNodeScriptTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		isError   bool
+		tags      []string
+		exists    bool
+	}{
+		{"list scripts", "list-node-scripts", map[string]any{"type": "testing", "filters": "burn-in"}, false, []string{"stress"}, true},
+		{"create script", "create-node-script", map[string]any{"name": "fio", "script": "#!/bin/sh\nfio", "type": "testing"}, false, []string{"stress"}, true},
+		{"create duplicate script", "create-node-script", map[string]any{"name": "burn-in", "script": "#!/bin/sh"}, true, []string{"stress"}, true},
+		{"read script", "read-node-script", map[string]any{"name": "burn-in", "include_script": "1"}, false, []string{"stress"}, true},
+		{"read unknown script", "read-node-script", map[string]any{"name": "missing"}, true, []string{"stress"}, true},
+		{"update script", "update-node-script", map[string]any{"name": "burn-in", "script": "#!/bin/sh\nstress-ng --cpu 0"}, false, []string{"stress"}, true},
+		{"add tag", "add-tag-to-node-script", map[string]any{"name": "burn-in", "tag": "cpu"}, false, []string{"stress", "cpu"}, true},
+		{"remove tag", "remove-tag-from-node-script", map[string]any{"name": "burn-in", "tag": "stress"}, false, []string{}, true},
+		{"download script", "download-node-script", map[string]any{"name": "burn-in", "revision": "1"}, false, []string{"stress"}, true},
+		{"delete script", "delete-node-script", map[string]any{"name": "burn-in"}, false, nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddScript(fakemaas.Script{Name: "burn-in", Tags: []string{"stress"}, Script: "#!/bin/sh\nstress-ng"})
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(NodeScripts{Client: fake.Client()}, NodeScript{Client: fake.Client()})[tc.tool], tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			script, exists := fake.Script("burn-in")
+			if exists != tc.exists {
+				t.Fatalf("expected exists=%v, got %v", tc.exists, exists)
+			}
+			if exists && !slices.Equal(append([]string{}, script.Tags...), tc.tags) {
+				t.Errorf("expected tags %v, got %v", tc.tags, script.Tags)
+			}
+		})
+	}
+
+	t.Run("download returns the script content", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		fake.AddScript(fakemaas.Script{Name: "burn-in", Script: "#!/bin/sh\nstress-ng"})
+
+		// Act
+		result := fakemaas.CallTool(t, DownloadNodeScript{Client: fake.Client()}.Handle, map[string]any{"name": "burn-in"})
+
+		// Assert
+		if content := fakemaas.ResultText(t, result); content != "#!/bin/sh\nstress-ng" {
+			t.Errorf("unexpected content %s", content)
+		}
+	})
+}
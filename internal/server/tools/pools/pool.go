@@ -0,0 +1,190 @@
+package pools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Pool struct {
+	Client maas_client.Client
+}
+
+func (p Pool) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeletePool{Client: p.Client}, ReadPool{Client: p.Client}, UpdatePool{Client: p.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type DeletePool struct {
+	Client maas_client.Client
+}
+
+func (DeletePool) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-pool",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the resource pool to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Pool", false, true, false, true)),
+		mcp.WithDescription("Delete a resource pool. Its machines are moved to the default pool, so pools holding protected machines cannot be deleted."),
+	)
+}
+
+func (d DeletePool) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	poolID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeletePool] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	pool, err := api.GetResourcePool(ctx, poolID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read resource pool %d err=%v", poolID, err)
+		zap.L().Error(fmt.Sprintf("[DeletePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	machines, err := api.ListMachines(ctx, maas_api.MachineFilter{Pool: pool.Name})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machines of resource pool %s err=%v", pool.Name, err)
+		zap.L().Error(fmt.Sprintf("[DeletePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	for _, machine := range machines {
+		if machine.Protected() {
+			errMsg = fmt.Sprintf("Resource pool %s holds protected machines and cannot be deleted", pool.Name)
+			zap.L().Error(fmt.Sprintf("[DeletePool] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeletePool] Deleting resource pool %d", poolID))
+	if err := api.DeleteResourcePool(ctx, poolID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete resource pool %d err=%v", poolID, err)
+		zap.L().Error(fmt.Sprintf("[DeletePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Resource pool %s deleted", pool.Name)), nil
+}
+
+type ReadPool struct {
+	Client maas_client.Client
+}
+
+func (ReadPool) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-pool",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the resource pool to retrieve."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Pool", true, false, false, true)),
+		mcp.WithDescription("Read a resource pool with the given ID."),
+	)
+}
+
+func (r ReadPool) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	poolID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadPool] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadPool] Retrieving resource pool %d", poolID))
+	pool, err := maas_api.New(r.Client).GetResourcePool(ctx, poolID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read resource pool %d err=%v", poolID, err)
+		zap.L().Error(fmt.Sprintf("[ReadPool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(pool)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadPool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type UpdatePool struct {
+	Client maas_client.Client
+}
+
+func (UpdatePool) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-pool",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the resource pool to update."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Pattern(`^[\w-]+$`),
+			mcp.Description("The new name of the resource pool."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("Description of the resource pool."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update Pool", false, false, false, true)),
+		mcp.WithDescription("Rename a resource pool or change its description."),
+	)
+}
+
+func (u UpdatePool) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	poolID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdatePool] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.ZoneParams{
+		Name:        request.GetString("name", ""),
+		Description: request.GetString("description", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdatePool] Updating resource pool %d", poolID))
+	pool, err := maas_api.New(u.Client).UpdateResourcePool(ctx, poolID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to update resource pool %d err=%v", poolID, err)
+		zap.L().Error(fmt.Sprintf("[UpdatePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(pool)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdatePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,194 @@
+package pools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Pools struct {
+	Client maas_client.Client
+}
+
+func (p Pools) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListPools{Client: p.Client}, CreatePool{Client: p.Client}, AssignMachinesToPool{Client: p.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListPools struct {
+	Client maas_client.Client
+}
+
+func (ListPools) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-pools",
+		mcp.WithInputSchema[struct{}](),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Pools", true, false, false, true)),
+		mcp.WithDescription("Return all the resource pools defined on the running instance of MAAS."),
+	)
+}
+
+func (l ListPools) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	zap.L().Info("[ListPools] Retrieving all resource pools...")
+	pools, err := maas_api.New(l.Client).ListResourcePools(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve all the resource pools: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListPools] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(pools)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListPools] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreatePool struct {
+	Client maas_client.Client
+}
+
+func (CreatePool) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-pool",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w-]+$`),
+			mcp.Description("Name of the resource pool."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("Description of the resource pool."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Pool", false, false, false, true)),
+		mcp.WithDescription("Create a new resource pool on the running instance of MAAS."),
+	)
+}
+
+func (c CreatePool) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreatePool] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.ZoneParams{
+		Name:        name,
+		Description: request.GetString("description", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreatePool] Creating resource pool %s...", name))
+	pool, err := maas_api.New(c.Client).CreateResourcePool(ctx, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create resource pool %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[CreatePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(pool)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreatePool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type AssignMachinesToPool struct {
+	Client maas_client.Client
+}
+
+func (AssignMachinesToPool) Create() mcp.Tool {
+	return mcp.NewTool(
+		"assign-machines-to-pool",
+		mcp.WithString(
+			"pool",
+			mcp.Required(),
+			mcp.Description("The name of the resource pool to move the machines to."),
+		),
+		mcp.WithArray(
+			"ids",
+			mcp.WithStringItems(mcp.Pattern("^[0-9a-z]{6}$")),
+			mcp.Description("The ids of the machines to move. Either ids or tag is required."),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Description("Move the machines with this tag, protected machines excluded. Either ids or tag is required."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Assign Machines to Pool", false, false, true, true)),
+		mcp.WithDescription("Move several machines to a resource pool and report for every machine whether it was moved. Protected machines are never moved."),
+	)
+}
+
+func (a AssignMachinesToPool) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	poolName, err := request.RequireString("pool")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] Required parameter pool not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ids := request.GetStringSlice("ids", nil)
+	tag := request.GetString("tag", "")
+	if len(ids) == 0 && tag == "" {
+		errMsg = "Either ids or tag is required"
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	api := maas_api.New(a.Client)
+
+	pools, err := api.ListResourcePools(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the resource pools err=%v", err)
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if !slices.ContainsFunc(pools, func(pool maas_api.ResourcePool) bool { return pool.Name == poolName }) {
+		errMsg = fmt.Sprintf("Resource pool %s does not exist", poolName)
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[AssignMachinesToPool] Moving machines to resource pool %s...", poolName))
+	moves, err := tools.MoveMachines(ctx, api, ids, tag, tools.MachinePool, poolName, maas_api.MachineParams{Pool: poolName})
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result := tools.NewMoveResult(poolName, moves)
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if result.Failed() {
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToPool] Some machines were not moved to resource pool %s", poolName))
+		return mcp.NewToolResultError(string(jsonData)), nil
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,140 @@
+package pools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+)
+
+func TestAssignMachinesToPool(t *testing.T) {
+	cases := []struct {
+		name          string
+		arguments     map[string]any
+		expectedError bool
+		expected      map[string]string
+	}{
+		{
+			"by ids and tag",
+			map[string]any{"pool": "batch", "ids": []any{"abc001"}, "tag": "web"},
+			false,
+			map[string]string{"abc001": tools.MoveMoved, "abc002": tools.MoveMoved},
+		},
+		{
+			"protected machine by id",
+			map[string]any{"pool": "batch", "ids": []any{"abc003"}},
+			true,
+			map[string]string{"abc003": tools.MoveRefused},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddResourcePool(fakemaas.ResourcePool{Name: "default"})
+			fake.AddResourcePool(fakemaas.ResourcePool{Name: "batch"})
+			fake.AddMachine(fakemaas.Machine{SystemID: "abc001"})
+			fake.AddMachine(fakemaas.Machine{SystemID: "abc002", TagNames: []string{"web"}})
+			fake.AddMachine(fakemaas.Machine{SystemID: "abc003", TagNames: []string{"web", "protected"}})
+
+			// Act
+			result := fakemaas.CallTool(t, AssignMachinesToPool{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.expectedError {
+				t.Errorf("expected error=%v, got %s", tc.expectedError, fakemaas.ResultText(t, result))
+			}
+			var moved tools.MoveResult
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &moved); err != nil {
+				t.Fatalf("expected a move result, got %v", err)
+			}
+			results := map[string]string{}
+			for _, move := range moved.Machines {
+				results[move.SystemID] = move.Result
+			}
+			if !reflect.DeepEqual(results, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, results)
+			}
+			for id, expected := range tc.expected {
+				if stored, _ := fake.Machine(id); (stored.Pool == "batch") != (expected == tools.MoveMoved) {
+					t.Errorf("expected machine %s moved=%v, got pool %s", id, expected == tools.MoveMoved, stored.Pool)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown pool", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		fake.AddResourcePool(fakemaas.ResourcePool{Name: "default"})
+		fake.AddMachine(fakemaas.Machine{SystemID: "abc001"})
+
+		// Act
+		result := fakemaas.CallTool(t, AssignMachinesToPool{Client: fake.Client()}.Handle, map[string]any{"pool": "batch", "ids": []any{"abc001"}})
+
+		// Assert
+		if !result.IsError {
+			t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+	})
+}
+
+func TestDeletePool(t *testing.T) {
+	cases := []struct {
+		name          string
+		protected     bool
+		expectedError bool
+		expectedPool  string
+	}{
+		{"moves machines to default", false, false, "default"},
+		{"holds protected machines", true, true, "batch"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddResourcePool(fakemaas.ResourcePool{Name: "default"})
+			pool := fake.AddResourcePool(fakemaas.ResourcePool{Name: "batch"})
+			machine := fakemaas.Machine{SystemID: "abc001", Pool: "batch"}
+			if tc.protected {
+				machine.TagNames = []string{"protected"}
+			}
+			fake.AddMachine(machine)
+
+			// Act
+			result := fakemaas.CallTool(t, DeletePool{Client: fake.Client()}.Handle, map[string]any{"id": strconv.Itoa(pool.ID)})
+
+			// Assert
+			if result.IsError != tc.expectedError {
+				t.Errorf("expected error=%v, got %s", tc.expectedError, fakemaas.ResultText(t, result))
+			}
+			if stored, _ := fake.Machine("abc001"); stored.Pool != tc.expectedPool {
+				t.Errorf("expected the machine in pool %s, got %s", tc.expectedPool, stored.Pool)
+			}
+		})
+	}
+}
+
+func TestUpdatePool(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	fake.AddResourcePool(fakemaas.ResourcePool{Name: "default"})
+	pool := fake.AddResourcePool(fakemaas.ResourcePool{Name: "batch"})
+	fake.AddMachine(fakemaas.Machine{SystemID: "abc001", Pool: "batch"})
+
+	// Act
+	result := fakemaas.CallTool(t, UpdatePool{Client: fake.Client()}.Handle, map[string]any{"id": strconv.Itoa(pool.ID), "name": "compute"})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	if stored, _ := fake.Machine("abc001"); stored.Pool != "compute" {
+		t.Errorf("expected the machine to follow the renamed pool, got %s", stored.Pool)
+	}
+}
@@ -0,0 +1,267 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// powerType lists the power parameters of a MAAS power type.
+type powerType struct {
+	required []string
+	optional []string
+}
+
+// powerTypes are the power types machines can be enlisted with.
+var powerTypes = map[string]powerType{
+	"ipmi": {
+		required: []string{"power_address"},
+		optional: []string{"power_user", "power_pass", "power_driver", "power_boot_type", "mac_address", "k_g", "cipher_suite_id", "privilege_level"},
+	},
+	"redfish": {
+		required: []string{"power_address"},
+		optional: []string{"power_user", "power_pass", "node_id"},
+	},
+	"virsh": {
+		required: []string{"power_address", "power_id"},
+		optional: []string{"power_pass"},
+	},
+	"lxd": {
+		required: []string{"power_address", "instance_name"},
+		optional: []string{"project", "password", "certificate", "key"},
+	},
+	"manual": {},
+}
+
+// powerTypeNames are the keys of powerTypes, sorted for the tool schemas.
+var powerTypeNames = slices.Sorted(maps.Keys(powerTypes))
+
+// powerParameters checks the power parameters given to a tool against the
+// power type and returns them as the strings MAAS takes. With requireAll
+// the required parameters of the power type must all be given.
+func powerParameters(name string, given map[string]any, requireAll bool) (map[string]string, error) {
+	powerType, ok := powerTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown power type %s, expected one of %s", name, strings.Join(powerTypeNames, ", "))
+	}
+
+	known := slices.Concat(powerType.required, powerType.optional)
+	params := make(map[string]string, len(given))
+	for key, value := range given {
+		if !slices.Contains(known, key) {
+			if len(known) == 0 {
+				return nil, fmt.Errorf("power type %s takes no power parameters, got %s", name, key)
+			}
+			return nil, fmt.Errorf("power type %s has no power parameter %s, expected %s", name, key, strings.Join(known, ", "))
+		}
+
+		switch value := value.(type) {
+		case string:
+			params[key] = value
+		case float64, bool:
+			params[key] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("power parameter %s must be a string", key)
+		}
+	}
+
+	if requireAll {
+		for _, key := range powerType.required {
+			if params[key] == "" {
+				return nil, fmt.Errorf("power type %s requires the power parameter %s", name, key)
+			}
+		}
+	}
+	return params, nil
+}
+
+// withPowerOptions adds the power type and power parameters to a tool that
+// configures the BMC of a machine.
+func withPowerOptions(powerTypeOptions []mcp.PropertyOption, options ...mcp.ToolOption) []mcp.ToolOption {
+	return append([]mcp.ToolOption{
+		mcp.WithString(
+			"power_type",
+			append([]mcp.PropertyOption{
+				mcp.Enum(powerTypeNames...),
+			}, powerTypeOptions...)...,
+		),
+		mcp.WithObject(
+			"power_parameters",
+			mcp.Description("The power parameters of the power type. ipmi and redfish take power_address, power_user and power_pass; ipmi also power_driver (LAN or LAN_2_0), power_boot_type, mac_address, k_g, cipher_suite_id and privilege_level, redfish node_id. virsh takes power_address, like qemu+ssh://user@host/system, power_id, the name of the VM, and power_pass. lxd takes power_address, instance_name, project, password, certificate and key. manual takes none."),
+		),
+	}, options...)
+}
+
+type GetPowerParameters struct {
+	Client maas_client.Client
+}
+
+// PowerParameters is the result of get-power-parameters, with the secrets
+// redacted.
+type PowerParameters struct {
+	SystemID        string         `json:"system_id"`
+	Hostname        string         `json:"hostname"`
+	PowerType       string         `json:"power_type"`
+	PowerParameters map[string]any `json:"power_parameters"`
+}
+
+func (GetPowerParameters) Create() mcp.Tool {
+	return mcp.NewTool(
+		"get-power-parameters",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Get Power Parameters", true, false, true, true)),
+		mcp.WithDescription("Returns the power type and BMC power parameters of a machine. Passwords, keys, tokens and other secrets are redacted."),
+	)
+}
+
+func (g GetPowerParameters) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[GetPowerParameters] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(g.Client)
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[GetPowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if machine.Protected() {
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[GetPowerParameters] Retrieving the power parameters of machine %s...", machineID))
+	params, err := api.GetPowerParameters(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the power parameters of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[GetPowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(PowerParameters{
+		SystemID:        machine.SystemID,
+		Hostname:        machine.Hostname,
+		PowerType:       machine.PowerType,
+		PowerParameters: maas_api.RedactPowerParameters(params),
+	})
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[GetPowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type UpdatePowerParameters struct {
+	Client maas_client.Client
+}
+
+func (UpdatePowerParameters) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-power-parameters",
+		withPowerOptions(
+			[]mcp.PropertyOption{
+				mcp.Description("The new power type of the machine. Changing it replaces all power parameters, so the required ones of the new type must be given. Default: the current power type."),
+			},
+			mcp.WithString(
+				"id",
+				mcp.Required(),
+				mcp.Pattern("^[0-9a-z]{6}$"),
+				mcp.Description("The id of the machine."),
+			),
+			mcp.WithBoolean(
+				"skip_check",
+				mcp.DefaultBool(false),
+				mcp.Description("Store the power parameters without MAAS checking them against the power type."),
+			),
+			mcp.WithToolAnnotation(CreateToolAnnotation("Update Power Parameters", false, true, true, true)),
+			mcp.WithDescription("Change the power type or BMC power parameters of a machine, like a new IPMI address or password. Parameters that are not given keep their value. Returns the power parameters with passwords, keys, tokens and other secrets redacted."),
+		)...,
+	)
+}
+
+func (u UpdatePowerParameters) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(u.Client)
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if machine.Protected() {
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
+	}
+
+	given, _ := request.GetArguments()["power_parameters"].(map[string]any)
+	powerType := request.GetString("power_type", machine.PowerType)
+	if powerType == machine.PowerType && len(given) == 0 {
+		errMsg = "Nothing to update: give a new power_type or power_parameters"
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	skipCheck := request.GetBool("skip_check", false)
+	params, err := powerParameters(powerType, given, powerType != machine.PowerType && !skipCheck)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdatePowerParameters] Updating the %s power parameters of machine %s...", powerType, machineID))
+	if _, err := api.UpdateMachine(ctx, machineID, maas_api.MachineParams{PowerType: powerType, PowerParameters: params, SkipPowerCheck: skipCheck}); err != nil {
+		errMsg = fmt.Sprintf("Failed to update the power parameters of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	updated, err := api.GetPowerParameters(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Updated the power parameters of machine %s but failed to read them back err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(PowerParameters{
+		SystemID:        machine.SystemID,
+		Hostname:        machine.Hostname,
+		PowerType:       powerType,
+		PowerParameters: maas_api.RedactPowerParameters(updated),
+	})
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdatePowerParameters] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -4,24 +4,40 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Power struct{}
+type Power struct {
+	Client maas_client.Client
+}
 
-func (Power) Register(mcpServer *server.MCPServer) {
-	mcpTools := []MCPTool{PowerState{}, ChangePowerState{}}
+func (p Power) Register(mcpServer registry.ToolServer) {
+	mcpTools := []MCPTool{
+		PowerState{Client: p.Client},
+		ChangePowerState{Client: p.Client},
+		PowerCycle{Client: p.Client},
+		BulkPower{Client: p.Client},
+		PowerStateSweep{Client: p.Client},
+		GetPowerParameters{Client: p.Client},
+		UpdatePowerParameters{Client: p.Client},
+		CreateMachine{Client: p.Client},
+		DeleteMachine{Client: p.Client},
+	}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type PowerState struct{}
+type PowerState struct {
+	Client maas_client.Client
+}
 
 func (PowerState) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -32,11 +48,12 @@ func (PowerState) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z]{6}$"),
 			mcp.Description("The id of the machine to retrieve information for."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Power State", true, false, false, true)),
 		mcp.WithDescription("Returns the power state of a particular machine."),
 	)
 }
 
-func (PowerState) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (p PowerState) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	machineID, err := request.RequireString("id")
@@ -45,19 +62,15 @@ func (PowerState) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/machines/%s/op-query_power_state", machineID)
-
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[PowerState] Retrieving power state for machine with id %s...", machineID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	powerState, err := maas_api.New(p.Client).QueryPowerState(ctx, machineID)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve power state for machine with id %s err=%v", machineID, err)
 		zap.L().Error(fmt.Sprintf("[PowerState] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(powerState)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[PowerState] %s", errMsg))
@@ -67,7 +80,9 @@ func (PowerState) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type ChangePowerState struct{}
+type ChangePowerState struct {
+	Client maas_client.Client
+}
 
 func (ChangePowerState) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -83,11 +98,12 @@ func (ChangePowerState) Create() mcp.Tool {
 			mcp.Required(),
 			mcp.Description("If true power on the machine else power off."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Change Power State", false, true, false, true)),
 		mcp.WithDescription("Change the power state of a machine specified by id."),
 	)
 }
 
-func (ChangePowerState) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c ChangePowerState) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	machineID, err := request.RequireString("id")
@@ -102,31 +118,25 @@ func (ChangePowerState) Handle(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	var path string
-
-	if state {
-		path = fmt.Sprintf("/MAAS/api/2.0/machines/%s/op-power_on", machineID)
-	} else {
-		path = fmt.Sprintf("/MAAS/api/2.0/machines/%s/op-power_off", machineID)
-	}
-
-	client := maas_client.MustClient()
+	api := maas_api.New(c.Client)
 
 	powerName := "on"
+	changePower := api.PowerOn
 
 	if !state {
 		powerName = "off"
+		changePower = api.PowerOff
 	}
 
 	zap.L().Info(fmt.Sprintf("[ChangePowerState] Power machine with id %s %s...", machineID, powerName))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	machine, err := changePower(ctx, machineID)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to power %s machine with id %s err=%v", powerName, machineID, err)
 		zap.L().Error(fmt.Sprintf("[ChangePowerState] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(machine)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ChangePowerState] %s", errMsg))
@@ -135,3 +145,95 @@ func (ChangePowerState) Handle(ctx context.Context, request mcp.CallToolRequest)
 
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
+
+// maxPowerCycleDelay bounds the time a power cycle may keep a machine off.
+const maxPowerCycleDelay = 600
+
+type PowerCycle struct {
+	Client maas_client.Client
+}
+
+func (PowerCycle) Create() mcp.Tool {
+	return mcp.NewTool(
+		"power-cycle",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine to power cycle."),
+		),
+		mcp.WithNumber(
+			"delay",
+			mcp.DefaultNumber(10),
+			mcp.Min(0),
+			mcp.Max(maxPowerCycleDelay),
+			mcp.Description("Seconds to wait between powering the machine off and on, so that the BMC settles."),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Power Cycle", false, true, false, true)),
+		mcp.WithDescription("Power a machine off, wait, then power it back on. Returns the machine after it was powered on."),
+	)
+}
+
+func (p PowerCycle) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PowerCycle] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	delay := powerCycleDelay(request)
+
+	api := maas_api.New(p.Client)
+
+	if result := ensureNotProtected(ctx, api, machineID, "PowerCycle"); result != nil {
+		return result, nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[PowerCycle] Power cycling machine with id %s with a delay of %s...", machineID, delay))
+	machine, err := powerCycle(ctx, api, machineID, delay)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to power cycle machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[PowerCycle] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(machine)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[PowerCycle] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// powerCycleDelay returns the delay argument of a power cycle, in bounds.
+func powerCycleDelay(request mcp.CallToolRequest) time.Duration {
+	seconds := min(max(request.GetFloat("delay", 10), 0), maxPowerCycleDelay)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// powerCycle powers the machine off, waits delay, then powers it back on.
+// The machine stays off when ctx is done before it is powered on.
+func powerCycle(ctx context.Context, api *maas_api.API, systemID string, delay time.Duration) (maas_api.Machine, error) {
+	if _, err := api.PowerOff(ctx, systemID); err != nil {
+		return maas_api.Machine{}, fmt.Errorf("failed to power off: %w", err)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return maas_api.Machine{}, fmt.Errorf("interrupted while the machine was off, it was not powered back on: %w", ctx.Err())
+	case <-timer.C:
+	}
+
+	machine, err := api.PowerOn(ctx, systemID)
+	if err != nil {
+		return machine, fmt.Errorf("powered off but failed to power back on: %w", err)
+	}
+	return machine, nil
+}
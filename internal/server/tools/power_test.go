@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestPowerState(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{PowerState: "on"})
+
+	// Act
+	result := fakemaas.CallTool(t, PowerState{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID})
+
+	// Assert
+	body := fakemaas.ResultText(t, result)
+	var state map[string]string
+	if err := json.Unmarshal([]byte(body), &state); err != nil || state["state"] != "on" {
+		t.Errorf("unexpected result %s", body)
+	}
+}
+
+func TestChangePowerState(t *testing.T) {
+	cases := []struct {
+		name     string
+		state    bool
+		expected string
+	}{
+		{"power on", true, "on"},
+		{"power off", false, "off"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{PowerState: "unknown"})
+
+			// Act
+			result := fakemaas.CallTool(t, ChangePowerState{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "state": tc.state})
+
+			// Assert
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+			}
+			if got, _ := fake.Machine(m.SystemID); got.PowerState != tc.expected {
+				t.Errorf("expected power state %s, got %s", tc.expected, got.PowerState)
+			}
+		})
+	}
+
+	t.Run("locked machine", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		m := fake.AddMachine(fakemaas.Machine{Locked: true})
+
+		// Act
+		result := fakemaas.CallTool(t, ChangePowerState{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "state": true})
+
+		// Assert
+		if !result.IsError {
+			t.Error("expected an error result")
+		}
+	})
+}
+
+func TestGetPowerParameters(t *testing.T) {
+	cases := []struct {
+		name      string
+		powerType string
+		params    map[string]string
+		expected  map[string]any
+	}{
+		{
+			"ipmi",
+			"ipmi",
+			map[string]string{"power_address": "10.0.0.10", "power_user": "admin", "power_pass": "hunter2", "k_g": ""},
+			map[string]any{"power_address": "10.0.0.10", "power_user": "admin", "power_pass": "********", "k_g": ""},
+		},
+		{
+			"proxmox",
+			"proxmox",
+			map[string]string{"power_address": "pve.lab", "power_user": "root@pam", "power_token_secret": "hunter2", "power_vm_name": "vm-1"},
+			map[string]any{"power_address": "pve.lab", "power_user": "root@pam", "power_token_secret": "********", "power_vm_name": "vm-1"},
+		},
+		{
+			"webhook",
+			"webhook",
+			map[string]string{"power_on_uri": "https://bmc.lab/on", "power_token": "hunter2", "power_verify_ssl": "y"},
+			map[string]any{"power_on_uri": "https://bmc.lab/on", "power_token": "********", "power_verify_ssl": "y"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{PowerType: tc.powerType, PowerParameters: tc.params})
+
+			// Act
+			result := fakemaas.CallTool(t, GetPowerParameters{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID})
+
+			// Assert
+			body := fakemaas.ResultText(t, result)
+			if strings.Contains(body, "hunter2") {
+				t.Fatalf("expected the secret to be redacted, got %s", body)
+			}
+			var params PowerParameters
+			if err := json.Unmarshal([]byte(body), &params); err != nil {
+				t.Fatalf("failed to unmarshal result: %v", err)
+			}
+			if params.PowerType != tc.powerType || !reflect.DeepEqual(params.PowerParameters, tc.expected) {
+				t.Errorf("unexpected power parameters %+v", params)
+			}
+		})
+	}
+}
+
+func TestUpdatePowerParameters(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  map[string]string
+		errorText string
+	}{
+		{
+			name:      "merges the parameters",
+			arguments: map[string]any{"power_parameters": map[string]any{"power_pass": "s3cret"}},
+			expected:  map[string]string{"power_address": "10.0.0.10", "power_user": "admin", "power_pass": "s3cret"},
+		},
+		{
+			name:      "replaces the parameters of a new power type",
+			arguments: map[string]any{"power_type": "redfish", "power_parameters": map[string]any{"power_address": "10.0.0.20", "node_id": "1"}},
+			expected:  map[string]string{"power_address": "10.0.0.20", "node_id": "1"},
+		},
+		{
+			name:      "new power type without its required parameters",
+			arguments: map[string]any{"power_type": "virsh", "power_parameters": map[string]any{"power_address": "qemu+ssh://ubuntu@kvm/system"}},
+			errorText: "requires the power parameter power_id",
+		},
+		{
+			name:      "unknown power parameter",
+			arguments: map[string]any{"power_parameters": map[string]any{"instance_name": "vm1"}},
+			errorText: "has no power parameter instance_name",
+		},
+		{
+			name:      "nothing to update",
+			arguments: map[string]any{},
+			errorText: "Nothing to update",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			m := fake.AddMachine(fakemaas.Machine{PowerType: "ipmi", PowerParameters: map[string]string{"power_address": "10.0.0.10", "power_user": "admin", "power_pass": "hunter2"}})
+			tc.arguments["id"] = m.SystemID
+
+			// Act
+			result := fakemaas.CallTool(t, UpdatePowerParameters{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			body := fakemaas.ResultText(t, result)
+			if tc.errorText != "" {
+				if !result.IsError || !strings.Contains(body, tc.errorText) {
+					t.Errorf("expected an error containing %q, got %s", tc.errorText, body)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("expected no error result, got %s", body)
+			}
+			if strings.Contains(body, "hunter2") || strings.Contains(body, "s3cret") {
+				t.Errorf("expected the password to be redacted, got %s", body)
+			}
+			if got, _ := fake.Machine(m.SystemID); !reflect.DeepEqual(got.PowerParameters, tc.expected) {
+				t.Errorf("expected power parameters %v, got %v", tc.expected, got.PowerParameters)
+			}
+		})
+	}
+}
+
+func TestPowerCycle(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	m := fake.AddMachine(fakemaas.Machine{PowerState: "on"})
+
+	// Act
+	result := fakemaas.CallTool(t, PowerCycle{Client: fake.Client()}.Handle, map[string]any{"id": m.SystemID, "delay": 0})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	var operations []string
+	for _, request := range fake.Requests() {
+		if request.Method == "POST" {
+			operations = append(operations, request.Path[strings.LastIndex(request.Path, "/")+1:])
+		}
+	}
+	if !reflect.DeepEqual(operations, []string{"op-power_off", "op-power_on"}) {
+		t.Errorf("expected the machine to be powered off then on, got %v", operations)
+	}
+	if got, _ := fake.Machine(m.SystemID); got.PowerState != "on" {
+		t.Errorf("expected power state on, got %s", got.PowerState)
+	}
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// ProgressNotifier sends MCP progress notifications for a tool call. It does
+// nothing when the caller did not ask for progress with a progress token.
+type ProgressNotifier struct {
+	server   *server.MCPServer
+	token    mcp.ProgressToken
+	progress float64
+}
+
+// NewProgressNotifier returns a notifier for the tool call of request.
+func NewProgressNotifier(ctx context.Context, request mcp.CallToolRequest) *ProgressNotifier {
+	notifier := &ProgressNotifier{server: server.ServerFromContext(ctx)}
+	if request.Params.Meta != nil {
+		notifier.token = request.Params.Meta.ProgressToken
+	}
+	return notifier
+}
+
+// Notify sends message as the next step of the progress. total is the number
+// of steps expected, 0 when unknown.
+func (p *ProgressNotifier) Notify(ctx context.Context, total float64, message string) {
+	if p.server == nil || p.token == nil {
+		return
+	}
+
+	p.progress++
+	params := map[string]any{
+		"progressToken": p.token,
+		"progress":      p.progress,
+		"message":       message,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+
+	if err := p.server.SendNotificationToClient(ctx, "notifications/progress", params); err != nil {
+		zap.L().Warn(fmt.Sprintf("[Progress] Failed to send a progress notification err=%v", err))
+	}
+}
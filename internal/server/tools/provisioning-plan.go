@@ -0,0 +1,473 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+	"gopkg.in/yaml.v3"
+)
+
+// Statuses a machine group of a provisioning document can ask for.
+const (
+	DesiredDeployed = "deployed"
+	DesiredReady    = "ready"
+)
+
+// Actions of the steps of a provisioning plan, in the order they are applied
+// to a machine.
+const (
+	ActionCreateTag  = "create-tag"
+	ActionCommission = "commission"
+	ActionAddTag     = "add-tag"
+	ActionLinkSubnet = "link-subnet"
+	ActionDeploy     = "deploy"
+	ActionPowerOn    = "power-on"
+	ActionPowerOff   = "power-off"
+)
+
+// ProvisioningDocument is the YAML description of the desired state of a
+// cluster.
+type ProvisioningDocument struct {
+	Name   string              `yaml:"name"`
+	Groups []ProvisioningGroup `yaml:"groups"`
+}
+
+// ProvisioningGroup is a set of machines that share a role in the cluster.
+type ProvisioningGroup struct {
+	Name  string         `yaml:"name"`
+	Count int            `yaml:"count"`
+	Match MachineMatcher `yaml:"match"`
+	// Tags are added to every machine of the group.
+	Tags []string `yaml:"tags"`
+	// Status is DesiredDeployed, the default, or DesiredReady.
+	Status     string         `yaml:"status"`
+	Template   string         `yaml:"template"`
+	Parameters map[string]any `yaml:"parameters"`
+	// Power is the power state, on or off, of the machines of a group left
+	// ready. Deployed machines are always on.
+	Power string `yaml:"power"`
+	// Subnets are the subnets an interface of every machine of the group is
+	// linked to.
+	Subnets []SubnetLink `yaml:"subnets"`
+}
+
+// linkModes are the modes of a SubnetLink.
+var linkModes = []string{"auto", "dhcp", "static", "link_up"}
+
+// SubnetLink links an interface of the machines of a group to a subnet.
+type SubnetLink struct {
+	CIDR string `yaml:"cidr"`
+	// Mode is auto, the default, dhcp, static or link_up. Static links get a
+	// free address of the subnet.
+	Mode string `yaml:"mode"`
+	// Interface is the name of the interface to link. Default: the boot
+	// interface.
+	Interface string `yaml:"interface"`
+}
+
+// UnmarshalYAML also accepts a link given by its CIDR alone, like
+// 10.0.0.0/24.
+func (l *SubnetLink) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&l.CIDR)
+	}
+
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; !slices.Contains([]string{"cidr", "mode", "interface"}, key.Value) {
+				return fmt.Errorf("line %d: field %s not found in subnet link", key.Line, key.Value)
+			}
+		}
+	}
+
+	type plain SubnetLink
+	return node.Decode((*plain)(l))
+}
+
+// MachineMatcher selects the machines that can be part of a group. Empty
+// fields do not filter.
+type MachineMatcher struct {
+	Tags         []string `yaml:"tags"`
+	Zone         string   `yaml:"zone"`
+	Pool         string   `yaml:"pool"`
+	Hostnames    []string `yaml:"hostnames"`
+	Architecture string   `yaml:"architecture"`
+	MinCPUCount  int      `yaml:"min_cpu_count"`
+	MinMemory    int      `yaml:"min_memory"`  // in MiB
+	MinStorage   float64  `yaml:"min_storage"` // in MB
+}
+
+// ParseProvisioningDocument decodes and validates a provisioning document.
+func ParseProvisioningDocument(content string) (ProvisioningDocument, error) {
+	var document ProvisioningDocument
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&document); err != nil && !errors.Is(err, io.EOF) {
+		return ProvisioningDocument{}, fmt.Errorf("failed to parse the provisioning document: %w", err)
+	}
+
+	if len(document.Groups) == 0 {
+		return ProvisioningDocument{}, errors.New("the provisioning document has no groups")
+	}
+
+	names := map[string]bool{}
+	for i := range document.Groups {
+		group := &document.Groups[i]
+		if group.Name == "" {
+			return ProvisioningDocument{}, fmt.Errorf("group %d has no name", i+1)
+		}
+		if names[group.Name] {
+			return ProvisioningDocument{}, fmt.Errorf("group %s is defined twice", group.Name)
+		}
+		names[group.Name] = true
+
+		if group.Count == 0 {
+			group.Count = 1
+		}
+		if group.Count < 0 {
+			return ProvisioningDocument{}, fmt.Errorf("group %s has a negative count", group.Name)
+		}
+
+		group.Status = cmp.Or(group.Status, DesiredDeployed)
+		switch group.Status {
+		case DesiredDeployed:
+			if group.Template == "" {
+				return ProvisioningDocument{}, fmt.Errorf("group %s is deployed but has no template", group.Name)
+			}
+			if !templates.MustTemplateStore().Exists(group.Template) {
+				return ProvisioningDocument{}, fmt.Errorf("group %s uses template %s, which does not exist", group.Name, group.Template)
+			}
+			if group.Power != "" {
+				return ProvisioningDocument{}, fmt.Errorf("group %s is deployed and cannot set a power state", group.Name)
+			}
+		case DesiredReady:
+			if group.Template != "" {
+				return ProvisioningDocument{}, fmt.Errorf("group %s is not deployed and cannot use a template", group.Name)
+			}
+		default:
+			return ProvisioningDocument{}, fmt.Errorf("group %s has unknown status %q, expected %s or %s", group.Name, group.Status, DesiredDeployed, DesiredReady)
+		}
+
+		if group.Power != "" && group.Power != "on" && group.Power != "off" {
+			return ProvisioningDocument{}, fmt.Errorf("group %s has unknown power state %q, expected on or off", group.Name, group.Power)
+		}
+
+		linked := map[string]bool{}
+		for j := range group.Subnets {
+			link := &group.Subnets[j]
+			_, subnet, err := net.ParseCIDR(link.CIDR)
+			if err != nil {
+				return ProvisioningDocument{}, fmt.Errorf("group %s has invalid subnet %q, expected a CIDR like 10.0.0.0/24", group.Name, link.CIDR)
+			}
+			link.CIDR = subnet.String()
+			if linked[link.CIDR] {
+				return ProvisioningDocument{}, fmt.Errorf("group %s links subnet %s twice", group.Name, link.CIDR)
+			}
+			linked[link.CIDR] = true
+
+			link.Mode = cmp.Or(strings.ToLower(link.Mode), "auto")
+			if !slices.Contains(linkModes, link.Mode) {
+				return ProvisioningDocument{}, fmt.Errorf("group %s has unknown link mode %q for subnet %s, expected %s", group.Name, link.Mode, link.CIDR, strings.Join(linkModes, ", "))
+			}
+		}
+
+		for _, tag := range group.Tags {
+			if tag == maas_api.ProtectedTag {
+				return ProvisioningDocument{}, fmt.Errorf("group %s cannot add the %s tag", group.Name, maas_api.ProtectedTag)
+			}
+		}
+	}
+
+	return document, nil
+}
+
+// ProvisioningPlan is the list of steps that bring MAAS to the state of a
+// provisioning document.
+type ProvisioningPlan struct {
+	Name     string      `json:"name,omitempty"`
+	Groups   []PlanGroup `json:"groups"`
+	Steps    []PlanStep  `json:"steps"`
+	Warnings []string    `json:"warnings,omitempty"`
+	InSync   bool        `json:"in_sync"`
+}
+
+// PlanGroup lists the machines selected for a group.
+type PlanGroup struct {
+	Name     string        `json:"name"`
+	Wanted   int           `json:"wanted"`
+	Machines []PlanMachine `json:"machines"`
+}
+
+// PlanMachine is a machine selected for a group, in its current state.
+type PlanMachine struct {
+	SystemID   string `json:"system_id"`
+	Hostname   string `json:"hostname"`
+	StatusName string `json:"status_name"`
+	PowerState string `json:"power_state"`
+}
+
+// PlanStep is a change to apply to MAAS.
+type PlanStep struct {
+	ID       int    `json:"id"`
+	Group    string `json:"group,omitempty"`
+	SystemID string `json:"system_id,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	Action   string `json:"action"`
+	// Target is the tag of the tag actions, the CIDR of link-subnet and the
+	// template of deploy.
+	Target string `json:"target,omitempty"`
+	// Interface is the interface of link-subnet, the boot interface when
+	// empty.
+	Interface string `json:"interface,omitempty"`
+}
+
+// selection is a machine selected for a group.
+type selection struct {
+	group   *ProvisioningGroup
+	machine maas_api.Machine
+}
+
+// candidateRanks order the statuses of the machines that can join a group,
+// from the closest to the desired status. Machines in other statuses, like
+// broken, commissioning or deployed for a group left ready, are never
+// selected. Deployed and deploying machines only join a deployed group when
+// they already carry all its tags: matching the zone, pool or constraints of
+// the group does not tell that they were deployed for it.
+var candidateRanks = map[string]map[string]int{
+	DesiredDeployed: {
+		"deployed":             0,
+		"deploying":            0,
+		"ready":                1,
+		"allocated":            1,
+		"new":                  2,
+		"failed_commissioning": 2,
+	},
+	DesiredReady: {
+		"ready":                0,
+		"new":                  1,
+		"failed_commissioning": 1,
+	},
+}
+
+// planProvisioning compares the document with the current state of MAAS and
+// returns the steps that reconcile them.
+func planProvisioning(ctx context.Context, api *maas_api.API, document ProvisioningDocument) (ProvisioningPlan, error) {
+	plan := ProvisioningPlan{Name: document.Name, Groups: []PlanGroup{}, Steps: []PlanStep{}}
+
+	existingTags, err := api.ListTags(ctx)
+	if err != nil {
+		return ProvisioningPlan{}, fmt.Errorf("failed to list the tags: %w", err)
+	}
+	tagExists := map[string]bool{}
+	for _, tag := range existingTags {
+		tagExists[tag.Name] = true
+	}
+
+	subnets, err := api.ListSubnets(ctx)
+	if err != nil {
+		return ProvisioningPlan{}, fmt.Errorf("failed to list the subnets: %w", err)
+	}
+	subnetExists := map[string]bool{}
+	for _, subnet := range subnets {
+		subnetExists[subnet.CIDR] = true
+	}
+
+	var selections []selection
+	selected := map[string]bool{}
+	for i := range document.Groups {
+		group := &document.Groups[i]
+
+		for _, link := range group.Subnets {
+			if !subnetExists[link.CIDR] {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("group %s: subnet %s does not exist in MAAS", group.Name, link.CIDR))
+			}
+		}
+
+		machines, err := api.ListMachines(ctx, maas_api.MachineFilter{
+			Tags:      group.Match.Tags,
+			Zone:      group.Match.Zone,
+			Pool:      group.Match.Pool,
+			Hostnames: group.Match.Hostnames,
+		})
+		if err != nil {
+			return ProvisioningPlan{}, fmt.Errorf("failed to list the machines of group %s: %w", group.Name, err)
+		}
+
+		ranks := candidateRanks[group.Status]
+		candidates := slices.DeleteFunc(machines, func(machine maas_api.Machine) bool {
+			_, usable := ranks[statusKey(machine.StatusName)]
+			return !usable || machine.Protected() || selected[machine.SystemID] || !group.Match.matches(machine) ||
+				(inUse(machine) && (len(group.Tags) == 0 || missingTags(machine, group.Tags) > 0))
+		})
+		slices.SortStableFunc(candidates, func(a, b maas_api.Machine) int {
+			return cmp.Or(
+				cmp.Compare(missingTags(a, group.Tags), missingTags(b, group.Tags)),
+				cmp.Compare(ranks[statusKey(a.StatusName)], ranks[statusKey(b.StatusName)]),
+				strings.Compare(a.Hostname, b.Hostname),
+			)
+		})
+
+		if len(candidates) < group.Count {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("group %s: %d machines wanted but only %d match", group.Name, group.Count, len(candidates)))
+		}
+
+		planGroup := PlanGroup{Name: group.Name, Wanted: group.Count, Machines: []PlanMachine{}}
+		for _, machine := range candidates[:min(group.Count, len(candidates))] {
+			selected[machine.SystemID] = true
+			planGroup.Machines = append(planGroup.Machines, PlanMachine{
+				SystemID:   machine.SystemID,
+				Hostname:   machine.Hostname,
+				StatusName: machine.StatusName,
+				PowerState: machine.PowerState,
+			})
+			selections = append(selections, selection{group: group, machine: machine})
+		}
+		plan.Groups = append(plan.Groups, planGroup)
+	}
+
+	for _, chosen := range selections {
+		for _, tag := range chosen.group.Tags {
+			if !tagExists[tag] {
+				tagExists[tag] = true
+				plan.Steps = append(plan.Steps, PlanStep{Action: ActionCreateTag, Target: tag})
+			}
+		}
+	}
+
+	for _, chosen := range selections {
+		group, machine := chosen.group, chosen.machine
+		step := func(action, target, iface string) {
+			plan.Steps = append(plan.Steps, PlanStep{
+				Group:     group.Name,
+				SystemID:  machine.SystemID,
+				Hostname:  machine.Hostname,
+				Action:    action,
+				Target:    target,
+				Interface: iface,
+			})
+		}
+
+		status := statusKey(machine.StatusName)
+		commissioned := status == "new" || status == "failed_commissioning"
+		if commissioned {
+			step(ActionCommission, "", "")
+		}
+		for _, tag := range group.Tags {
+			if !machine.HasTag(tag) {
+				step(ActionAddTag, tag, "")
+			}
+		}
+		// MAAS only changes the interfaces of machines that are not deployed,
+		// so the links come before the deployment.
+		for _, link := range group.Subnets {
+			if !subnetExists[link.CIDR] {
+				continue
+			}
+			iface, found := linkInterface(machine, link.Interface)
+			switch {
+			case found && linkedTo(iface, link.CIDR):
+			case inUse(machine):
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("group %s: machine %s is %s and cannot be linked to subnet %s", group.Name, machine.Hostname, machine.StatusName, link.CIDR))
+			case !found && !commissioned:
+				// Commissioning discovers the interfaces, so they are only
+				// looked up again when the step runs.
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("group %s: machine %s has no interface %s to link to subnet %s", group.Name, machine.Hostname, cmp.Or(link.Interface, "to boot from"), link.CIDR))
+			default:
+				step(ActionLinkSubnet, link.CIDR, link.Interface)
+			}
+		}
+		if group.Status == DesiredDeployed && !inUse(machine) {
+			step(ActionDeploy, group.Template, "")
+		}
+		// Commissioning leaves the machine off.
+		powerState := machine.PowerState
+		if status != "ready" {
+			powerState = "off"
+		}
+		if group.Power != "" && group.Power != powerState {
+			if group.Power == "on" {
+				step(ActionPowerOn, "", "")
+			} else {
+				step(ActionPowerOff, "", "")
+			}
+		}
+	}
+
+	for i := range plan.Steps {
+		plan.Steps[i].ID = i + 1
+	}
+	plan.InSync = len(plan.Steps) == 0 && len(plan.Warnings) == 0
+
+	return plan, nil
+}
+
+// matches reports whether the machine meets the hardware constraints. The
+// tags, zone, pool and hostnames are filtered by MAAS.
+func (m MachineMatcher) matches(machine maas_api.Machine) bool {
+	if m.Architecture != "" && machine.Architecture != m.Architecture && !strings.HasPrefix(machine.Architecture, m.Architecture+"/") {
+		return false
+	}
+	return machine.CPUCount >= m.MinCPUCount && machine.Memory >= m.MinMemory && machine.Storage >= m.MinStorage
+}
+
+// statusKey turns a status name like "Failed commissioning" into the status
+// used by the machine filters, like "failed_commissioning".
+func statusKey(statusName string) string {
+	return strings.ReplaceAll(strings.ToLower(statusName), " ", "_")
+}
+
+// inUse reports whether the machine is deployed or being deployed.
+func inUse(machine maas_api.Machine) bool {
+	status := statusKey(machine.StatusName)
+	return status == "deployed" || status == "deploying"
+}
+
+// linkInterface returns the interface of the machine with the given name, or
+// its boot interface when name is empty.
+func linkInterface(machine maas_api.Machine, name string) (maas_api.Interface, bool) {
+	if name == "" {
+		if machine.BootInterface == nil {
+			return maas_api.Interface{}, false
+		}
+		name = machine.BootInterface.Name
+	}
+
+	for _, iface := range machine.Interfaces {
+		if iface.Name == name {
+			return iface, true
+		}
+	}
+	if machine.BootInterface != nil && machine.BootInterface.Name == name {
+		return *machine.BootInterface, true
+	}
+	return maas_api.Interface{}, false
+}
+
+// linkedTo reports whether the interface is linked to the subnet.
+func linkedTo(iface maas_api.Interface, cidr string) bool {
+	for _, link := range iface.Links {
+		if link.Subnet != nil && link.Subnet.CIDR == cidr {
+			return true
+		}
+	}
+	return false
+}
+
+func missingTags(machine maas_api.Machine, tags []string) int {
+	missing := 0
+	for _, tag := range tags {
+		if !machine.HasTag(tag) {
+			missing++
+		}
+	}
+	return missing
+}
@@ -0,0 +1,368 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/jobs"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// Results of the steps of an applied provisioning plan.
+const (
+	StepDone    = "done"
+	StepFailed  = "failed"
+	StepSkipped = "skipped"
+)
+
+const documentDescription = "The YAML provisioning document describing the desired state of the cluster: a name and a list of groups, each with a name, a count of machines, match (tags, zone, pool, hostnames, architecture, min_cpu_count, min_memory in MiB, min_storage in MB), the tags to add, the status (deployed or ready), the template and its parameters for deployed groups, the power state (on or off) for ready groups, and the subnets the machines are linked to, each a CIDR or a map with cidr, mode (auto, dhcp, static or link_up) and interface (default: the boot interface)."
+
+// ApplyReport is the result of applying a provisioning plan.
+type ApplyReport struct {
+	Name      string       `json:"name,omitempty"`
+	Steps     []StepReport `json:"steps"`
+	Warnings  []string     `json:"warnings,omitempty"`
+	Succeeded bool         `json:"succeeded"`
+}
+
+// StepReport is the outcome of a step of a provisioning plan.
+type StepReport struct {
+	PlanStep
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Provisioning struct {
+	Client maas_client.Client
+	// Jobs runs the calls asking for async. They are refused when it is nil.
+	Jobs *jobs.Manager
+}
+
+func (p Provisioning) Register(mcpServer registry.ToolServer) {
+	apply := ApplyProvisioning{Client: p.Client, Jobs: p.Jobs}
+
+	if p.Jobs != nil {
+		p.Jobs.Handle("apply-provisioning", toolRunner(apply.runJob))
+	}
+
+	mcpTools := []MCPTool{PlanProvisioning{Client: p.Client}, apply}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type PlanProvisioning struct {
+	Client maas_client.Client
+}
+
+func (PlanProvisioning) Create() mcp.Tool {
+	return mcp.NewTool(
+		"plan-provisioning",
+		mcp.WithString(
+			"document",
+			mcp.Required(),
+			mcp.Description(documentDescription),
+		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Plan Provisioning", true, false, true, true)),
+		mcp.WithDescription("Compare a provisioning document with the current state of MAAS and return the machines selected for every group and the steps (create-tag, commission, add-tag, link-subnet, deploy, power-on, power-off) that apply-provisioning would run. Protected machines are never selected. Nothing is changed."),
+	)
+}
+
+func (p PlanProvisioning) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	content, err := request.RequireString("document")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PlanProvisioning] Required parameter document not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	document, err := ParseProvisioningDocument(content)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PlanProvisioning] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[PlanProvisioning] Planning provisioning document %s...", document.Name))
+	plan, err := planProvisioning(ctx, maas_api.New(p.Client), document)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to plan the provisioning err=%v", err)
+		zap.L().Error(fmt.Sprintf("[PlanProvisioning] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(plan)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[PlanProvisioning] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type ApplyProvisioning struct {
+	Client maas_client.Client
+	Jobs   *jobs.Manager
+}
+
+func (a ApplyProvisioning) Create() mcp.Tool {
+	return mcp.NewTool(
+		"apply-provisioning",
+		mcp.WithString(
+			"document",
+			mcp.Required(),
+			mcp.Description(documentDescription),
+		),
+		mcp.WithBoolean(
+			"wait",
+			mcp.DefaultBool(false),
+			mcp.Description("Wait for every deployment to complete instead of only starting it."),
+		),
+		mcp.WithNumber(
+			"commission_timeout",
+			mcp.DefaultNumber(1800.0),
+			mcp.Description("The timeout in seconds of the wait for a commissioned machine to be ready. Default: 1800s"),
+		),
+		mcp.WithNumber(
+			"deploy_timeout",
+			mcp.DefaultNumber(3600.0),
+			mcp.Description("With wait, the timeout in seconds of the wait for a machine to be deployed. Default: 3600s"),
+		),
+		withAsync(a.Jobs, "Return a job right away and apply the plan in the background, one job step per plan step. Follow the job with get-job."),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Apply Provisioning", false, true, true, true)),
+		mcp.WithDescription("Plan a provisioning document against the current state of MAAS, like plan-provisioning, and run the steps in order with a report for every step. A machine whose step fails is skipped for the rest of the plan, the other machines go on. Applying the same document again only runs what is still missing."),
+	)
+}
+
+func (a ApplyProvisioning) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	content, err := request.RequireString("document")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ApplyProvisioning] Required parameter document not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	document, err := ParseProvisioningDocument(content)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ApplyProvisioning] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if runAsync(ctx, a.Jobs, request) {
+		return submitJob(ctx, a.Jobs, "apply-provisioning", request, "ApplyProvisioning")
+	}
+
+	report, err := a.apply(ctx, document, request, func(ctx context.Context, name string, fn func(ctx context.Context) (string, error)) (string, error) {
+		return fn(ctx)
+	})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to plan the provisioning err=%v", err)
+		zap.L().Error(fmt.Sprintf("[ApplyProvisioning] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(report)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ApplyProvisioning] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if !report.Succeeded {
+		return mcp.NewToolResultError(string(jsonData)), nil
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runJob applies the plan with one job step per plan step. The plan is
+// computed again when the job is resumed, so it only holds what is left.
+func (a ApplyProvisioning) runJob(ctx context.Context, job *jobs.Run, request mcp.CallToolRequest) (string, error) {
+	document, err := ParseProvisioningDocument(request.GetString("document", ""))
+	if err != nil {
+		return "", err
+	}
+
+	report, err := a.apply(ctx, document, request, job.Step)
+	if err != nil {
+		return "", fmt.Errorf("failed to plan the provisioning: %w", err)
+	}
+
+	jsonData, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	if !report.Succeeded {
+		return "", fmt.Errorf("some steps of the provisioning failed: %s", jsonData)
+	}
+	return string(jsonData), nil
+}
+
+// stepRunner runs fn as the step name of an apply.
+type stepRunner func(ctx context.Context, name string, fn func(ctx context.Context) (string, error)) (string, error)
+
+// apply plans the document and runs every step of the plan with run. It
+// fails only when the plan cannot be computed; failed steps are reported.
+func (a ApplyProvisioning) apply(ctx context.Context, document ProvisioningDocument, request mcp.CallToolRequest, run stepRunner) (ApplyReport, error) {
+	api := maas_api.New(a.Client)
+
+	zap.L().Info(fmt.Sprintf("[ApplyProvisioning] Planning provisioning document %s...", document.Name))
+	plan, err := planProvisioning(ctx, api, document)
+	if err != nil {
+		return ApplyReport{}, err
+	}
+
+	groups := map[string]ProvisioningGroup{}
+	for _, group := range document.Groups {
+		groups[group.Name] = group
+	}
+
+	report := ApplyReport{Name: plan.Name, Steps: []StepReport{}, Warnings: plan.Warnings, Succeeded: true}
+	// failed holds the machines and the tags whose steps failed.
+	failed := map[string]bool{}
+
+	for _, step := range plan.Steps {
+		stepReport := StepReport{PlanStep: step}
+
+		if failed[step.SystemID] || (step.Action == ActionAddTag && failed["tag:"+step.Target]) {
+			stepReport.Result = StepSkipped
+			report.Steps = append(report.Steps, stepReport)
+			continue
+		}
+
+		zap.L().Info(fmt.Sprintf("[ApplyProvisioning] Step %d: %s %s %s", step.ID, step.Action, step.Hostname, step.Target))
+		message, err := run(ctx, stepName(step), func(ctx context.Context) (string, error) {
+			return a.applyStep(ctx, api, plan.Name, step, groups[step.Group], request)
+		})
+		if err != nil {
+			zap.L().Error(fmt.Sprintf("[ApplyProvisioning] Step %d failed err=%v", step.ID, err))
+			stepReport.Result = StepFailed
+			stepReport.Error = err.Error()
+			report.Succeeded = false
+			if step.SystemID != "" {
+				failed[step.SystemID] = true
+			} else {
+				failed["tag:"+step.Target] = true
+			}
+		} else {
+			stepReport.Result = StepDone
+			stepReport.Message = message
+		}
+		report.Steps = append(report.Steps, stepReport)
+	}
+
+	return report, nil
+}
+
+// applyStep runs a step of the plan and returns a short message describing it.
+func (a ApplyProvisioning) applyStep(ctx context.Context, api *maas_api.API, planName string, step PlanStep, group ProvisioningGroup, request mcp.CallToolRequest) (string, error) {
+	switch step.Action {
+	case ActionCreateTag:
+		comment := fmt.Sprintf("Created by the provisioning of %s", planName)
+		if _, err := api.CreateTag(ctx, maas_api.TagParams{Name: step.Target, Comment: comment}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Tag %s created", step.Target), nil
+
+	case ActionCommission:
+		commission := CommissionMachine{Client: a.Client}
+		if _, err := callTool(ctx, commission.Handle, toolRequest("commission-machine", map[string]any{"id": step.SystemID})); err != nil {
+			return "", err
+		}
+		wait := WaitForMachineStatus{Client: a.Client}
+		if _, err := callTool(ctx, wait.Handle, waitRequest(step.SystemID, "ready", request.GetFloat("commission_timeout", 1800.0))); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Machine %s commissioned", step.Hostname), nil
+
+	case ActionAddTag:
+		if _, err := api.UpdateTagNodes(ctx, step.Target, []string{step.SystemID}, nil); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Tag %s added to machine %s", step.Target, step.Hostname), nil
+
+	case ActionLinkSubnet:
+		link := group.Subnets[slices.IndexFunc(group.Subnets, func(link SubnetLink) bool { return link.CIDR == step.Target })]
+		subnets, err := api.ListSubnets(ctx)
+		if err != nil {
+			return "", err
+		}
+		index := slices.IndexFunc(subnets, func(subnet maas_api.Subnet) bool { return subnet.CIDR == link.CIDR })
+		if index < 0 {
+			return "", fmt.Errorf("subnet %s does not exist in MAAS", link.CIDR)
+		}
+		machine, err := api.GetMachine(ctx, step.SystemID)
+		if err != nil {
+			return "", err
+		}
+		iface, found := linkInterface(machine, link.Interface)
+		if !found {
+			return "", fmt.Errorf("machine %s has no interface %s", step.Hostname, cmp.Or(link.Interface, "to boot from"))
+		}
+		if _, err := api.LinkSubnet(ctx, step.SystemID, iface.ID, maas_api.LinkSubnetParams{Mode: strings.ToUpper(link.Mode), Subnet: subnets[index].ID}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Interface %s of machine %s linked to subnet %s in %s mode", iface.Name, step.Hostname, link.CIDR, link.Mode), nil
+
+	case ActionDeploy:
+		parameters, err := json.Marshal(group.Parameters)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the parameters of template %s: %w", group.Template, err)
+		}
+		if group.Parameters == nil {
+			parameters = []byte("{}")
+		}
+
+		deploy := DeployMachine{Client: a.Client}
+		if _, err := callTool(ctx, deploy.Handle, toolRequest("deploy-machine", map[string]any{
+			"machineId":          step.SystemID,
+			"templateId":         group.Template,
+			"templateParameters": string(parameters),
+		})); err != nil {
+			return "", err
+		}
+		if !request.GetBool("wait", false) {
+			return fmt.Sprintf("Deployment of machine %s started with template %s", step.Hostname, group.Template), nil
+		}
+
+		wait := WaitForMachineStatus{Client: a.Client}
+		if _, err := callTool(ctx, wait.Handle, waitRequest(step.SystemID, "deployed", request.GetFloat("deploy_timeout", 3600.0))); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Machine %s deployed with template %s", step.Hostname, group.Template), nil
+
+	case ActionPowerOn, ActionPowerOff:
+		power := ChangePowerState{Client: a.Client}
+		if _, err := callTool(ctx, power.Handle, toolRequest("change-power-state", map[string]any{"id": step.SystemID, "state": step.Action == ActionPowerOn})); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Machine %s powered %s", step.Hostname, group.Power), nil
+	}
+
+	return "", fmt.Errorf("unknown action %s", step.Action)
+}
+
+// stepName names a plan step in a job. It does not depend on the position of
+// the step, so a resumed job does not run again the steps it completed.
+func stepName(step PlanStep) string {
+	name := step.Action
+	if step.Target != "" {
+		name += " " + step.Target
+	}
+	if step.SystemID != "" {
+		name += " " + step.SystemID
+	}
+	return name
+}
@@ -0,0 +1,315 @@
+package tools
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
+)
+
+const provisioningTemplate = "provisioning_test"
+
+const provisioningDocument = `
+name: lab
+groups:
+  - name: servers
+    count: 1
+    match:
+      tags: [rack-a]
+      min_cpu_count: 8
+    tags: [k3s-server]
+    template: provisioning_test
+    parameters:
+      token: s3cr3t
+  - name: spares
+    count: 2
+    status: ready
+    match:
+      zone: spares
+    power: "on"
+    subnets: [10.0.0.0/24]
+`
+
+func createProvisioningTemplate(t *testing.T) {
+	t.Helper()
+
+	store := templates.MustTemplateStore()
+	if err := store.Create(templates.GenericTemplate{Id: provisioningTemplate, Name: "Provisioning Test", Description: "Test provisioning"}); err != nil {
+		t.Fatalf("failed to create the template: %v", err)
+	}
+	t.Cleanup(func() { store.Delete(provisioningTemplate) })
+}
+
+func TestParseProvisioningDocument(t *testing.T) {
+	createProvisioningTemplate(t)
+
+	cases := []struct {
+		name     string
+		document string
+		expected string
+	}{
+		{"valid", provisioningDocument, ""},
+		{"no groups", "name: empty", "has no groups"},
+		{"unknown field", "groups: [{name: a, template: provisioning_test, colour: red}]", "field colour not found"},
+		{"duplicate group", "groups: [{name: a, status: ready}, {name: a, status: ready}]", "defined twice"},
+		{"deployed without template", "groups: [{name: a}]", "has no template"},
+		{"unknown template", "groups: [{name: a, template: missing}]", "does not exist"},
+		{"deployed with power", "groups: [{name: a, template: provisioning_test, power: off}]", "cannot set a power state"},
+		{"unknown status", "groups: [{name: a, status: retired}]", "unknown status"},
+		{"protected tag", "groups: [{name: a, status: ready, tags: [protected]}]", "cannot add the protected tag"},
+		{"invalid subnet", "groups: [{name: a, status: ready, subnets: [10.0.0.0]}]", "invalid subnet"},
+		{"duplicate subnet", "groups: [{name: a, status: ready, subnets: [10.0.0.0/24, {cidr: 10.0.0.1/24}]}]", "links subnet 10.0.0.0/24 twice"},
+		{"unknown link mode", "groups: [{name: a, status: ready, subnets: [{cidr: 10.0.0.0/24, mode: bridge}]}]", "unknown link mode"},
+		{"unknown link field", "groups: [{name: a, status: ready, subnets: [{cidr: 10.0.0.0/24, vlan: 5}]}]", "field vlan not found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			document, err := ParseProvisioningDocument(tc.document)
+
+			// Assert
+			if tc.expected == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if document.Groups[0].Status != DesiredDeployed || document.Groups[1].Count != 2 {
+					t.Errorf("expected the defaults to be filled in, got %+v", document.Groups)
+				}
+				if link := document.Groups[1].Subnets; len(link) != 1 || link[0] != (SubnetLink{CIDR: "10.0.0.0/24", Mode: "auto"}) {
+					t.Errorf("expected an auto link to the boot interface, got %+v", link)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected an error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// newProvisioningFake returns a fake with a subnet and machines covering the
+// statuses the planner handles.
+func newProvisioningFake(t *testing.T) *fakemaas.Server {
+	t.Helper()
+
+	fake := fakemaas.Start(t)
+	fake.TransitionReads = 0
+	subnet := fake.AddSubnet(fakemaas.Subnet{CIDR: "10.0.0.0/24"})
+	linked := []fakemaas.Interface{{Name: "eth0", Links: []fakemaas.Link{{Mode: "auto", IPAddress: "10.0.0.10", SubnetID: subnet.ID}}}}
+
+	fake.AddMachine(fakemaas.Machine{SystemID: "srv001", Hostname: "server-small", CPUCount: 4, TagNames: []string{"rack-a"}})
+	fake.AddMachine(fakemaas.Machine{SystemID: "srv002", Hostname: "server-big", CPUCount: 16, TagNames: []string{"rack-a"}})
+	fake.AddMachine(fakemaas.Machine{SystemID: "spr001", Hostname: "spare-new", Status: fakemaas.StatusNew, Zone: "spares", Interfaces: []fakemaas.Interface{{Name: "eth0"}}})
+	fake.AddMachine(fakemaas.Machine{SystemID: "spr002", Hostname: "spare-ready", Zone: "spares", Interfaces: linked})
+	fake.AddMachine(fakemaas.Machine{SystemID: "brk001", Hostname: "broken", Status: fakemaas.StatusBroken})
+	fake.AddMachine(fakemaas.Machine{SystemID: "prt001", Hostname: "protected", TagNames: []string{maas_api.ProtectedTag}})
+	return fake
+}
+
+func TestPlanProvisioning(t *testing.T) {
+	// Arrange
+	createProvisioningTemplate(t)
+	fake := newProvisioningFake(t)
+
+	// Act
+	result := fakemaas.CallTool(t, PlanProvisioning{Client: fake.Client()}.Handle, map[string]any{"document": provisioningDocument})
+
+	// Assert
+	if result.IsError {
+		t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+	}
+	var plan ProvisioningPlan
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &plan); err != nil {
+		t.Fatalf("expected a plan, got %v", err)
+	}
+
+	var steps []string
+	for _, step := range plan.Steps {
+		steps = append(steps, stepName(step))
+	}
+	expected := []string{
+		"create-tag k3s-server",
+		"add-tag k3s-server srv002",
+		"deploy provisioning_test srv002",
+		"power-on spr002",
+		"commission spr001",
+		"link-subnet 10.0.0.0/24 spr001",
+		"power-on spr001",
+	}
+	if !slices.Equal(steps, expected) {
+		t.Errorf("expected steps %v, got %v", expected, steps)
+	}
+	if plan.InSync || len(plan.Warnings) != 0 {
+		t.Errorf("expected a plan out of sync without warnings, got %+v", plan)
+	}
+	if len(plan.Groups) != 2 || len(plan.Groups[1].Machines) != 2 {
+		t.Errorf("expected two spares, got %+v", plan.Groups)
+	}
+	for _, request := range fake.Requests() {
+		if request.Method != "GET" {
+			t.Errorf("expected planning not to change MAAS, got %s %s", request.Method, request.Path)
+		}
+	}
+
+	t.Run("warns about missing machines and subnets", func(t *testing.T) {
+		// Act
+		result := fakemaas.CallTool(t, PlanProvisioning{Client: fake.Client()}.Handle, map[string]any{
+			"document": "groups: [{name: spares, count: 5, status: ready, match: {zone: spares}, subnets: [10.9.0.0/24]}]",
+		})
+
+		// Assert
+		var plan ProvisioningPlan
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &plan); err != nil {
+			t.Fatalf("expected a plan, got %v", err)
+		}
+		if len(plan.Warnings) != 2 || plan.InSync {
+			t.Errorf("expected warnings for the subnet and the machine count, got %v", plan.Warnings)
+		}
+	})
+
+	t.Run("warns about machines it cannot link", func(t *testing.T) {
+		// Arrange
+		fake.AddMachine(fakemaas.Machine{SystemID: "dep001", Hostname: "deployed", Status: fakemaas.StatusDeployed, Zone: "lab", TagNames: []string{"web"}, Interfaces: []fakemaas.Interface{{Name: "eth0"}}})
+		fake.AddMachine(fakemaas.Machine{SystemID: "rdy001", Hostname: "ready", Zone: "office", Interfaces: []fakemaas.Interface{{Name: "eth0"}}})
+		fake.AddTag(fakemaas.Tag{Name: "web"})
+
+		// Act
+		result := fakemaas.CallTool(t, PlanProvisioning{Client: fake.Client()}.Handle, map[string]any{
+			"document": `groups: [
+  {name: web, match: {zone: lab}, tags: [web], template: provisioning_test, subnets: [10.0.0.0/24]},
+  {name: office, status: ready, match: {zone: office}, subnets: [{cidr: 10.0.0.0/24, interface: eth1}]}]`,
+		})
+
+		// Assert
+		var plan ProvisioningPlan
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &plan); err != nil {
+			t.Fatalf("expected a plan, got %v", err)
+		}
+		if len(plan.Steps) != 0 || len(plan.Warnings) != 2 {
+			t.Fatalf("expected no steps and two warnings, got %+v", plan)
+		}
+		if !strings.Contains(plan.Warnings[0], "is Deployed and cannot be linked") || !strings.Contains(plan.Warnings[1], "has no interface eth1") {
+			t.Errorf("unexpected warnings %v", plan.Warnings)
+		}
+	})
+}
+
+func TestPlanProvisioning_DeployedMachines(t *testing.T) {
+	createProvisioningTemplate(t)
+	document := "groups: [{name: servers, match: {zone: lab}, tags: [k3s-server], template: provisioning_test}]"
+
+	cases := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{"adopts a deployed machine with the group tags", []string{"k3s-server"}, nil},
+		{"ignores a deployed machine without the group tags", nil, []string{"add-tag k3s-server rdy001", "deploy provisioning_test rdy001"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddMachine(fakemaas.Machine{SystemID: "dep001", Hostname: "a-deployed", Status: fakemaas.StatusDeployed, Zone: "lab", TagNames: tc.tags})
+			fake.AddMachine(fakemaas.Machine{SystemID: "rdy001", Hostname: "b-ready", Zone: "lab"})
+			fake.AddTag(fakemaas.Tag{Name: "k3s-server"})
+
+			// Act
+			result := fakemaas.CallTool(t, PlanProvisioning{Client: fake.Client()}.Handle, map[string]any{"document": document})
+
+			// Assert
+			var plan ProvisioningPlan
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &plan); err != nil {
+				t.Fatalf("expected a plan, got %v", err)
+			}
+			var steps []string
+			for _, step := range plan.Steps {
+				steps = append(steps, stepName(step))
+			}
+			if !slices.Equal(steps, tc.expected) {
+				t.Errorf("expected steps %v, got %v", tc.expected, steps)
+			}
+		})
+	}
+}
+
+func TestApplyProvisioning(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	createProvisioningTemplate(t)
+
+	t.Run("reconciles MAAS with the document", func(t *testing.T) {
+		// Arrange
+		fake := newProvisioningFake(t)
+
+		// Act
+		result := fakemaas.CallTool(t, ApplyProvisioning{Client: fake.Client()}.Handle, map[string]any{"document": provisioningDocument, "wait": true})
+
+		// Assert
+		if result.IsError {
+			t.Fatalf("expected no error result, got %s", fakemaas.ResultText(t, result))
+		}
+		var report ApplyReport
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &report); err != nil {
+			t.Fatalf("expected a report, got %v", err)
+		}
+		if !report.Succeeded || len(report.Steps) != 7 {
+			t.Errorf("expected the seven steps to be done, got %+v", report)
+		}
+		server, _ := fake.Machine("srv002")
+		if server.Status != fakemaas.StatusDeployed || !slices.Contains(server.TagNames, "k3s-server") || server.UserData == "" {
+			t.Errorf("expected the server to be tagged and deployed with user data, got %s %v", server.Status, server.TagNames)
+		}
+		for _, id := range []string{"spr001", "spr002"} {
+			if spare, _ := fake.Machine(id); spare.Status != fakemaas.StatusReady || spare.PowerState != "on" || len(spare.Interfaces[0].Links) != 1 {
+				t.Errorf("expected spare %s to be ready, on and linked to the subnet, got %s %s %+v", id, spare.Status, spare.PowerState, spare.Interfaces)
+			}
+		}
+
+		result = fakemaas.CallTool(t, PlanProvisioning{Client: fake.Client()}.Handle, map[string]any{"document": provisioningDocument})
+		var plan ProvisioningPlan
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &plan); err != nil {
+			t.Fatalf("expected a plan, got %v", err)
+		}
+		if !plan.InSync {
+			t.Errorf("expected MAAS to be in sync after applying, got %+v", plan.Steps)
+		}
+	})
+
+	t.Run("skips the steps of a failed machine", func(t *testing.T) {
+		// Arrange
+		fake := newProvisioningFake(t)
+		fake.Fail("POST", "/MAAS/api/2.0/tags/k3s-server/op-update_nodes", 500)
+
+		// Act
+		result := fakemaas.CallTool(t, ApplyProvisioning{Client: fake.Client()}.Handle, map[string]any{"document": provisioningDocument})
+
+		// Assert
+		if !result.IsError {
+			t.Fatalf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+		var report ApplyReport
+		if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &report); err != nil {
+			t.Fatalf("expected a report, got %v", err)
+		}
+		var results []string
+		for _, step := range report.Steps {
+			results = append(results, step.Result)
+		}
+		expected := []string{StepDone, StepFailed, StepSkipped, StepDone, StepDone, StepDone, StepDone}
+		if !slices.Equal(results, expected) {
+			t.Errorf("expected results %v, got %v", expected, results)
+		}
+		if server, _ := fake.Machine("srv002"); server.Status != fakemaas.StatusReady {
+			t.Errorf("expected the server not to be deployed, got %s", server.Status)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Regions struct {
+	Regions *maas_client.Regions
+}
+
+func (r Regions) Register(mcpServer registry.ToolServer) {
+	mcpTools := []MCPTool{ListRegions{Regions: r.Regions}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListRegions struct {
+	Regions *maas_client.Regions
+}
+
+func (ListRegions) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-regions",
+		mcp.WithToolAnnotation(CreateToolAnnotation("List Regions", true, false, true, false)),
+		mcp.WithDescription("List the MAAS regions the other tools can target with their region argument, marking the default one."),
+	)
+}
+
+func (l ListRegions) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	zap.L().Info("[ListRegions] Listing the MAAS regions...")
+
+	jsonData, err := json.Marshal(l.Regions.List())
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to marshal the regions: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListRegions] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
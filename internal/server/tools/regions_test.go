@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func startRegions(t *testing.T) (*maas_client.Regions, map[string]*fakemaas.Server) {
+	fakes := map[string]*fakemaas.Server{"lab": fakemaas.Start(t), "prod": fakemaas.Start(t)}
+	fakes["lab"].AddMachine(fakemaas.Machine{SystemID: "aaaaaa"})
+	fakes["prod"].AddMachine(fakemaas.Machine{SystemID: "bbbbbb"})
+	fakes["prod"].AddMachine(fakemaas.Machine{SystemID: "cccccc", TagNames: []string{"protected"}})
+
+	clients := map[string]*maas_client.MAASClient{}
+	for name, fake := range fakes {
+		clients[name] = fake.Client()
+	}
+	regions, err := maas_client.NewRegions("lab", clients)
+	if err != nil {
+		t.Fatalf("failed to create the regions: %v", err)
+	}
+	return regions, fakes
+}
+
+func TestListRegions(t *testing.T) {
+	// Arrange
+	regions, _ := startRegions(t)
+
+	// Act
+	result := fakemaas.CallTool(t, ListRegions{Regions: regions}.Handle, map[string]any{})
+
+	// Assert
+	var listed []maas_client.Region
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &listed); err != nil {
+		t.Fatalf("expected a list of regions, got %v", err)
+	}
+	if len(listed) != 2 || listed[0].Name != "lab" || !listed[0].Default || listed[1].Name != "prod" {
+		t.Errorf("unexpected regions %+v", listed)
+	}
+}
+
+func TestListMachines_Regions(t *testing.T) {
+	regions, fakes := startRegions(t)
+
+	cases := []struct {
+		name     string
+		ctx      context.Context
+		expected []string
+	}{
+		{"fans out across all regions", context.Background(), []string{"aaaaaa@lab", "bbbbbb@prod"}},
+		{"selected region", maas_client.WithRegion(context.Background(), "prod"), []string{"bbbbbb@prod"}},
+	}
+
+	for _, tc := range cases {
+		for _, shortOutput := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s short_output=%v", tc.name, shortOutput), func(t *testing.T) {
+				// Arrange
+				request := mcp.CallToolRequest{}
+				request.Params.Arguments = map[string]any{"short_output": shortOutput}
+
+				// Act
+				result, err := ListMachines{Client: regions}.Handle(tc.ctx, request)
+
+				// Assert
+				if err != nil || result.IsError {
+					t.Fatalf("expected no error, got %v %s", err, fakemaas.ResultText(t, result))
+				}
+				var machines []map[string]any
+				if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &machines); err != nil {
+					t.Fatalf("expected a list of machines, got %v", err)
+				}
+				var labels []string
+				for _, m := range machines {
+					labels = append(labels, m["system_id"].(string)+"@"+m["region"].(string))
+				}
+				if strings.Join(labels, ",") != strings.Join(tc.expected, ",") {
+					t.Errorf("expected machines %v, got %v", tc.expected, labels)
+				}
+			})
+		}
+	}
+
+	t.Run("reports the failing region", func(t *testing.T) {
+		// Arrange
+		fakes["prod"].Fail(http.MethodGet, "/MAAS/api/2.0/machines/", http.StatusServiceUnavailable)
+		defer fakes["prod"].ClearFailures()
+
+		// Act
+		result := fakemaas.CallTool(t, ListMachines{Client: regions}.Handle, map[string]any{})
+
+		// Assert
+		if text := fakemaas.ResultText(t, result); !result.IsError || !strings.Contains(text, "region prod") {
+			t.Errorf("expected an error naming the region, got %s", text)
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package spaces
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Space struct {
+	Client maas_client.Client
+}
+
+func (s Space) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteSpace{Client: s.Client}, ReadSpace{Client: s.Client}, UpdateSpace{Client: s.Client}, ReadSpaceTopology{Client: s.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type DeleteSpace struct {
+	Client maas_client.Client
+}
+
+func (DeleteSpace) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-space",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the space to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Space", false, true, false, true)),
+		mcp.WithDescription("Delete a space with the given ID. Its VLANs and subnets are moved to the undefined space."),
+	)
+}
+
+func (d DeleteSpace) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	spaceID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteSpace] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteSpace] Deleting space with ID: %d", spaceID))
+	if err := maas_api.New(d.Client).DeleteSpace(ctx, spaceID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete space %d err=%v", spaceID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Space %d deleted", spaceID)), nil
+}
+
+type ReadSpace struct {
+	Client maas_client.Client
+}
+
+func (ReadSpace) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-space",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the space to retrieve."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Space", true, false, false, true)),
+		mcp.WithDescription("Read a space with the given ID."),
+	)
+}
+
+func (r ReadSpace) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	spaceID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadSpace] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadSpace] Retrieving space with ID: %d", spaceID))
+	space, err := maas_api.New(r.Client).GetSpace(ctx, spaceID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read space %d err=%v", spaceID, err)
+		zap.L().Error(fmt.Sprintf("[ReadSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(space)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type UpdateSpace struct {
+	Client maas_client.Client
+}
+
+func (UpdateSpace) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-space",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the space to update."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Pattern(`^[\w-]+$`),
+			mcp.Description("Name of the space."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("Description of the space."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update Space", false, false, false, true)),
+		mcp.WithDescription("Update a space with the given ID. Its VLANs and subnets follow a renamed space."),
+	)
+}
+
+func (u UpdateSpace) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	spaceID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateSpace] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.SpaceParams{
+		Name:        request.GetString("name", ""),
+		Description: request.GetString("description", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdateSpace] Updating space with ID: %d", spaceID))
+	space, err := maas_api.New(u.Client).UpdateSpace(ctx, spaceID, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to update space %d err=%v", spaceID, err)
+		zap.L().Error(fmt.Sprintf("[UpdateSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(space)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdateSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// Topology is the layer 3 view of a space: the fabrics it spans and every
+// VLAN in it with its subnets.
+type Topology struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Fabrics     []string       `json:"fabrics"`
+	VLANs       []TopologyVLAN `json:"vlans"`
+}
+
+// TopologyVLAN is a VLAN of a space with the subnets of the space on it.
+type TopologyVLAN struct {
+	ID       int              `json:"id"`
+	VID      int              `json:"vid"`
+	Name     string           `json:"name"`
+	Fabric   string           `json:"fabric"`
+	FabricID int              `json:"fabric_id"`
+	MTU      int              `json:"mtu"`
+	DHCPOn   bool             `json:"dhcp_on"`
+	Subnets  []TopologySubnet `json:"subnets"`
+}
+
+// TopologySubnet is a subnet of a space.
+type TopologySubnet struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	CIDR      string `json:"cidr"`
+	GatewayIP string `json:"gateway_ip,omitempty"`
+	Managed   bool   `json:"managed"`
+}
+
+// NewTopology groups the subnets of a space by VLAN. A subnet placed in the
+// space on a VLAN of another space brings its VLAN into the view.
+func NewTopology(space maas_api.Space) Topology {
+	topology := Topology{ID: space.ID, Name: space.Name, Description: space.Description, Fabrics: []string{}, VLANs: []TopologyVLAN{}}
+
+	addVLAN := func(vlan maas_api.VLAN) int {
+		if index := slices.IndexFunc(topology.VLANs, func(v TopologyVLAN) bool { return v.ID == vlan.ID }); index >= 0 {
+			return index
+		}
+		topology.VLANs = append(topology.VLANs, TopologyVLAN{
+			ID:       vlan.ID,
+			VID:      vlan.VID,
+			Name:     vlan.Name,
+			Fabric:   vlan.Fabric,
+			FabricID: vlan.FabricID,
+			MTU:      vlan.MTU,
+			DHCPOn:   vlan.DHCPOn,
+			Subnets:  []TopologySubnet{},
+		})
+		return len(topology.VLANs) - 1
+	}
+
+	for _, vlan := range space.VLANs {
+		addVLAN(vlan)
+	}
+	for _, subnet := range space.Subnets {
+		if subnet.VLAN == nil {
+			continue
+		}
+		index := addVLAN(*subnet.VLAN)
+		topology.VLANs[index].Subnets = append(topology.VLANs[index].Subnets, TopologySubnet{
+			ID:        subnet.ID,
+			Name:      subnet.Name,
+			CIDR:      subnet.CIDR,
+			GatewayIP: subnet.GatewayIP,
+			Managed:   subnet.Managed,
+		})
+	}
+
+	slices.SortFunc(topology.VLANs, func(a, b TopologyVLAN) int {
+		return cmp.Or(cmp.Compare(a.Fabric, b.Fabric), cmp.Compare(a.VID, b.VID))
+	})
+	for _, vlan := range topology.VLANs {
+		if !slices.Contains(topology.Fabrics, vlan.Fabric) {
+			topology.Fabrics = append(topology.Fabrics, vlan.Fabric)
+		}
+	}
+	return topology
+}
+
+type ReadSpaceTopology struct {
+	Client maas_client.Client
+}
+
+func (ReadSpaceTopology) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-space-topology",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9]+$"),
+			mcp.Description("The ID of the space."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Space Topology", true, false, false, true)),
+		mcp.WithDescription("List every VLAN and subnet in a space, grouped by fabric and VLAN. Machines on subnets of the same space have layer 3 connectivity across fabrics."),
+	)
+}
+
+func (r ReadSpaceTopology) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	spaceID, err := request.RequireInt("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadSpaceTopology] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadSpaceTopology] Retrieving space with ID: %d", spaceID))
+	space, err := maas_api.New(r.Client).GetSpace(ctx, spaceID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read space %d err=%v", spaceID, err)
+		zap.L().Error(fmt.Sprintf("[ReadSpaceTopology] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(NewTopology(space))
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadSpaceTopology] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
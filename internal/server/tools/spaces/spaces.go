@@ -0,0 +1,114 @@
+package spaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Spaces struct {
+	Client maas_client.Client
+}
+
+func (s Spaces) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListSpaces{Client: s.Client}, CreateSpace{Client: s.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListSpaces struct {
+	Client maas_client.Client
+}
+
+func (ListSpaces) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-spaces",
+		mcp.WithInputSchema[struct{}](),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Spaces", true, false, false, true)),
+		mcp.WithDescription("Return all the spaces defined on the running instance of MAAS, with the VLANs and subnets placed in each of them."),
+	)
+}
+
+func (l ListSpaces) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	zap.L().Info("[ListSpaces] Retrieving all spaces...")
+	spaces, err := maas_api.New(l.Client).ListSpaces(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve all the spaces: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListSpaces] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(spaces)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListSpaces] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateSpace struct {
+	Client maas_client.Client
+}
+
+func (CreateSpace) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-space",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w-]+$`),
+			mcp.Description("Name of the space."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("Description of the space."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Space", false, false, false, true)),
+		mcp.WithDescription("Create a new space on the running instance of MAAS. VLANs and subnets are placed in it with update-vlan and update-subnet."),
+	)
+}
+
+func (c CreateSpace) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateSpace] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.SpaceParams{
+		Name:        name,
+		Description: request.GetString("description", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateSpace] Creating space %s...", name))
+	space, err := maas_api.New(c.Client).CreateSpace(ctx, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create space %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[CreateSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(space)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateSpace] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
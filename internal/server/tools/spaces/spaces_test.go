@@ -0,0 +1,151 @@
+package spaces
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestSpaceTools(t *testing.T) {
+	cases := []struct {
+		name          string
+		tool          string
+		arguments     func(id string) map[string]any
+		isError       bool
+		expected      []string
+		expectedSpace string
+	}{
+		{
+			name:          "list spaces",
+			tool:          "list-spaces",
+			arguments:     func(id string) map[string]any { return map[string]any{} },
+			expected:      []string{"storage"},
+			expectedSpace: "storage",
+		},
+		{
+			name: "create space",
+			tool: "create-space",
+			arguments: func(id string) map[string]any {
+				return map[string]any{"name": "public", "description": "Internet facing"}
+			},
+			expected:      []string{"storage", "public"},
+			expectedSpace: "storage",
+		},
+		{
+			name:          "create duplicate space",
+			tool:          "create-space",
+			arguments:     func(id string) map[string]any { return map[string]any{"name": "storage"} },
+			isError:       true,
+			expected:      []string{"storage"},
+			expectedSpace: "storage",
+		},
+		{
+			name:          "read space",
+			tool:          "read-space",
+			arguments:     func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:      []string{"storage"},
+			expectedSpace: "storage",
+		},
+		{
+			name:          "read unknown space",
+			tool:          "read-space",
+			arguments:     func(id string) map[string]any { return map[string]any{"id": "999"} },
+			isError:       true,
+			expected:      []string{"storage"},
+			expectedSpace: "storage",
+		},
+		{
+			name:          "rename space",
+			tool:          "update-space",
+			arguments:     func(id string) map[string]any { return map[string]any{"id": id, "name": "ceph"} },
+			expected:      []string{"ceph"},
+			expectedSpace: "ceph",
+		},
+		{
+			name:          "delete space",
+			tool:          "delete-space",
+			arguments:     func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:      []string{},
+			expectedSpace: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			space := fake.AddSpace(fakemaas.Space{Name: "storage"})
+			fabric := fake.AddFabric(fakemaas.Fabric{})
+			vlan := fake.AddVLAN(fakemaas.VLAN{FabricID: fabric.ID, VID: 20, Space: "storage"})
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Spaces{Client: fake.Client()}, Space{Client: fake.Client()})[tc.tool], tc.arguments(strconv.Itoa(space.ID)))
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			names := []string{}
+			for _, s := range fake.Spaces() {
+				names = append(names, s.Name)
+			}
+			if !slices.Equal(names, tc.expected) {
+				t.Errorf("expected spaces %v, got %v", tc.expected, names)
+			}
+			for _, v := range fake.VLANs() {
+				if v.ID == vlan.ID && v.Space != tc.expectedSpace {
+					t.Errorf("expected the VLAN in space %q, got %q", tc.expectedSpace, v.Space)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSpaceTopology(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	space := fake.AddSpace(fakemaas.Space{Name: "storage"})
+	fake.AddSpace(fakemaas.Space{Name: "public"})
+	east := fake.AddFabric(fakemaas.Fabric{Name: "east"})
+	west := fake.AddFabric(fakemaas.Fabric{Name: "west"})
+	westVLAN := fake.AddVLAN(fakemaas.VLAN{FabricID: west.ID, VID: 30, Space: "storage"})
+	eastVLAN := fake.AddVLAN(fakemaas.VLAN{FabricID: east.ID, VID: 20, Space: "storage"})
+	publicVLAN := fake.AddVLAN(fakemaas.VLAN{FabricID: east.ID, VID: 40, Space: "public"})
+	fake.AddSubnet(fakemaas.Subnet{CIDR: "10.20.0.0/24", VLANID: eastVLAN.ID, GatewayIP: "10.20.0.1"})
+	fake.AddSubnet(fakemaas.Subnet{CIDR: "10.30.0.0/24", VLANID: westVLAN.ID})
+	fake.AddSubnet(fakemaas.Subnet{CIDR: "10.40.0.0/24", VLANID: publicVLAN.ID})
+	fake.AddSubnet(fakemaas.Subnet{CIDR: "10.41.0.0/24", VLANID: publicVLAN.ID, Space: "storage"})
+
+	// Act
+	result := fakemaas.CallTool(t, ReadSpaceTopology{Client: fake.Client()}.Handle, map[string]any{"id": strconv.Itoa(space.ID)})
+
+	// Assert
+	var topology Topology
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &topology); err != nil {
+		t.Fatalf("expected a space topology, got %v", err)
+	}
+	if !slices.Equal(topology.Fabrics, []string{"east", "west"}) {
+		t.Errorf("expected fabrics [east west], got %v", topology.Fabrics)
+	}
+	subnets := map[int][]string{}
+	for _, vlan := range topology.VLANs {
+		for _, subnet := range vlan.Subnets {
+			subnets[vlan.VID] = append(subnets[vlan.VID], subnet.CIDR)
+		}
+	}
+	expected := map[int][]string{20: {"10.20.0.0/24"}, 30: {"10.30.0.0/24"}, 40: {"10.41.0.0/24"}}
+	if !reflect.DeepEqual(subnets, expected) {
+		t.Errorf("expected subnets by VID %v, got %v", expected, subnets)
+	}
+	var vids []int
+	for _, vlan := range topology.VLANs {
+		vids = append(vids, vlan.VID)
+	}
+	if !slices.Equal(vids, []int{20, 40, 30}) {
+		t.Errorf("expected the VLANs ordered by fabric and VID, got %v", vids)
+	}
+}
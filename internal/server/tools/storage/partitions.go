@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Partitions struct {
+	Client maas_client.Client
+}
+
+func (p Partitions) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{CreatePartition{Client: p.Client}, DeletePartition{Client: p.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type CreatePartition struct {
+	Client maas_client.Client
+}
+
+func (CreatePartition) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-partition",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"device",
+			mcp.Required(),
+			mcp.Description("The name of the block device to partition, like sdb or md0."),
+		),
+		mcp.WithString(
+			"size",
+			mcp.Description("Size of the partition, like 20G. Default: the rest of the device."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Partition", false, false, false, true)),
+		mcp.WithDescription("Create a partition on a block device of a machine and return the resulting storage tree. Format it with format-storage. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreatePartition) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreatePartition] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("device")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreatePartition] Required parameter device not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	size, err := optionalSize(request, "size")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreatePartition] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreatePartition] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	device, partition, err := findStorage(devices, name)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreatePartition] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	switch {
+	case partition != nil:
+		errMsg = fmt.Sprintf("%s is a partition, partitions can only be created on block devices", name)
+	case device.AvailableSize <= 0:
+		errMsg = fmt.Sprintf("Block device %s has no space left for a partition: %s", name, device.UsedFor)
+	case size != nil && *size > device.AvailableSize:
+		errMsg = fmt.Sprintf("Block device %s has only %s left for a partition", name, formatSize(device.AvailableSize))
+	}
+	if errMsg != "" {
+		zap.L().Error(fmt.Sprintf("[CreatePartition] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreatePartition] Creating a partition on %s of machine %s...", name, machineID))
+	if _, err := api.CreatePartition(ctx, machineID, device.ID, size); err != nil {
+		errMsg = fmt.Sprintf("Failed to create a partition on %s of machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreatePartition] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "CreatePartition")
+}
+
+type DeletePartition struct {
+	Client maas_client.Client
+}
+
+func (DeletePartition) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-partition",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"partition",
+			mcp.Required(),
+			mcp.Description("The name of the partition to delete, like sda-part3."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Partition", false, true, false, true)),
+		mcp.WithDescription("Delete a partition of a machine with its filesystem and return the resulting storage tree. Partitions of a RAID set, volume group or bcache cannot be deleted. The machine must be Ready or Allocated."),
+	)
+}
+
+func (d DeletePartition) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeletePartition] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("partition")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeletePartition] Required parameter partition not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeletePartition] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	device, partition, err := findStorage(devices, name)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeletePartition] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if partition == nil {
+		errMsg = fmt.Sprintf("%s is a block device, not a partition", name)
+		zap.L().Error(fmt.Sprintf("[DeletePartition] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeletePartition] Deleting partition %s of machine %s...", name, machineID))
+	if err := api.DeletePartition(ctx, machineID, device.ID, partition.ID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete partition %s of machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeletePartition] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "DeletePartition")
+}
+
+// storageMembers resolves the names of block devices and partitions to the
+// IDs MAAS takes for the members of RAID sets and volume groups. They must
+// be unused: without a filesystem, partitions or another owner.
+func storageMembers(devices []maas_api.BlockDevice, names []string) ([]int, []int, error) {
+	var blockDevices, partitions []int
+	for _, name := range names {
+		device, partition, err := findStorage(devices, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		usedFor := device.UsedFor
+		if partition != nil {
+			usedFor = partition.UsedFor
+		}
+		if usedFor != "" && usedFor != "Unused" {
+			return nil, nil, fmt.Errorf("%s is already in use: %s", name, usedFor)
+		}
+
+		if partition != nil {
+			partitions = append(partitions, partition.ID)
+		} else {
+			blockDevices = append(blockDevices, device.ID)
+		}
+	}
+	return blockDevices, partitions, nil
+}
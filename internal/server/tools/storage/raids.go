@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// minimumRAIDMembers is the smallest number of active members MAAS accepts
+// for each RAID level.
+var minimumRAIDMembers = map[string]int{
+	"raid-0":  2,
+	"raid-1":  2,
+	"raid-5":  3,
+	"raid-6":  4,
+	"raid-10": 3,
+}
+
+type RAIDs struct {
+	Client maas_client.Client
+}
+
+func (r RAIDs) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{CreateRAID{Client: r.Client}, DeleteRAID{Client: r.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type CreateRAID struct {
+	Client maas_client.Client
+}
+
+func (CreateRAID) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-raid",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Pattern(`^[\w.-]+$`),
+			mcp.Description("Name of the RAID set. Default: the next free mdN."),
+		),
+		mcp.WithString(
+			"level",
+			mcp.Required(),
+			mcp.Enum("raid-0", "raid-1", "raid-5", "raid-6", "raid-10"),
+			mcp.Description("The RAID level. It needs at least 2 members for raid-0 and raid-1, 3 for raid-5 and raid-10 and 4 for raid-6."),
+		),
+		mcp.WithArray(
+			"devices",
+			mcp.Required(),
+			mcp.WithStringItems(),
+			mcp.Description("The names of the unused block devices and partitions to use as active members, like sdb or sdc-part1."),
+		),
+		mcp.WithArray(
+			"spares",
+			mcp.WithStringItems(),
+			mcp.Description("The names of the unused block devices and partitions to use as spares. Not for raid-0."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create RAID", false, false, false, true)),
+		mcp.WithDescription("Create a software RAID set on a machine and return the resulting storage tree. Format the RAID device with format-storage, partition it or add it to a volume group. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreateRAID) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateRAID] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	level, err := request.RequireString("level")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateRAID] Required parameter level not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	members := request.GetStringSlice("devices", nil)
+	spares := request.GetStringSlice("spares", nil)
+	minimum, ok := minimumRAIDMembers[level]
+	switch {
+	case !ok:
+		errMsg = fmt.Sprintf("Unknown RAID level %s", level)
+	case len(members) < minimum:
+		errMsg = fmt.Sprintf("A %s set needs at least %d active members, got %d", level, minimum, len(members))
+	case level == "raid-0" && len(spares) > 0:
+		errMsg = "A raid-0 set cannot have spares"
+	}
+	if errMsg != "" {
+		zap.L().Error(fmt.Sprintf("[CreateRAID] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateRAID] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.RAIDParams{Name: request.GetString("name", ""), Level: level}
+	if params.BlockDevices, params.Partitions, err = storageMembers(devices, members); err == nil {
+		params.SpareDevices, params.SparePartitions, err = storageMembers(devices, spares)
+	}
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateRAID] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateRAID] Creating a %s set on machine %s...", level, machineID))
+	if _, err := api.CreateRAID(ctx, machineID, params); err != nil {
+		errMsg = fmt.Sprintf("Failed to create a %s set on machine %s err=%v", level, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateRAID] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "CreateRAID")
+}
+
+type DeleteRAID struct {
+	Client maas_client.Client
+}
+
+func (DeleteRAID) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-raid",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the RAID set to delete, like md0."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete RAID", false, true, false, true)),
+		mcp.WithDescription("Delete a RAID set of a machine and return the resulting storage tree. Its members become unused. The machine must be Ready or Allocated."),
+	)
+}
+
+func (d DeleteRAID) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteRAID] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteRAID] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	if _, err := tools.ConfigurableMachine(ctx, api, machineID, "storage"); err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteRAID] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	raids, err := api.ListRAIDs(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the RAID sets of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteRAID] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	var raid *maas_api.RAID
+	for i := range raids {
+		if raids[i].Name == name {
+			raid = &raids[i]
+		}
+	}
+	if raid == nil {
+		errMsg = fmt.Sprintf("Machine %s has no RAID set %s", machineID, name)
+		zap.L().Error(fmt.Sprintf("[DeleteRAID] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteRAID] Deleting RAID set %s of machine %s...", name, machineID))
+	if err := api.DeleteRAID(ctx, machineID, raid.ID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete RAID set %s of machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteRAID] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "DeleteRAID")
+}
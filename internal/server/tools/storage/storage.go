@@ -0,0 +1,657 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// machineDevices checks that the storage of the machine can be changed and
+// returns its block devices.
+func machineDevices(ctx context.Context, api *maas_api.API, systemID string) ([]maas_api.BlockDevice, error) {
+	if _, err := tools.ConfigurableMachine(ctx, api, systemID, "storage"); err != nil {
+		return nil, err
+	}
+
+	devices, err := api.ListBlockDevices(ctx, systemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the block devices of machine %s err=%v", systemID, err)
+	}
+	return devices, nil
+}
+
+// findStorage returns the block device with the given name, or the device
+// holding the partition with the given name along with the partition.
+func findStorage(devices []maas_api.BlockDevice, name string) (maas_api.BlockDevice, *maas_api.Partition, error) {
+	for _, device := range devices {
+		if device.Name == name {
+			return device, nil, nil
+		}
+		for _, partition := range device.Partitions {
+			if partitionName(partition) == name {
+				return device, &partition, nil
+			}
+		}
+	}
+	return maas_api.BlockDevice{}, nil, fmt.Errorf("the machine has no block device or partition %s", name)
+}
+
+// physicalDisks returns the physical block devices of a machine.
+func physicalDisks(devices []maas_api.BlockDevice) []maas_api.BlockDevice {
+	var disks []maas_api.BlockDevice
+	for _, device := range devices {
+		if device.Type == "physical" {
+			disks = append(disks, device)
+		}
+	}
+	return disks
+}
+
+// findDisk returns the ID of the physical disk with the given name.
+func findDisk(disks []maas_api.BlockDevice, name string) (int, error) {
+	for _, disk := range disks {
+		if disk.Name == name {
+			return disk.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("the machine has no physical disk %s", name)
+}
+
+// optionalSize returns the size argument key in bytes, or nil when the call
+// left it out.
+func optionalSize(request mcp.CallToolRequest, key string) (*int64, error) {
+	value := request.GetString(key, "")
+	if value == "" {
+		return nil, nil
+	}
+
+	size, err := parseSize(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameter %s: %v", key, err)
+	}
+	return &size, nil
+}
+
+// storageResult reads the storage of the machine back after a change and
+// returns it as the result of the tool named by tool.
+func storageResult(ctx context.Context, api *maas_api.API, systemID, tool string) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machine, err := api.GetMachine(ctx, systemID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", systemID, err)
+		zap.L().Error(fmt.Sprintf("[%s] %s", tool, errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	tree, err := readTree(ctx, api, machine)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[%s] %v", tool, err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	jsonData, err := json.Marshal(tree)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[%s] %s", tool, errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// withLayoutOptions adds the storage layout options to a tool that applies or
+// previews a layout.
+func withLayoutOptions(options ...mcp.ToolOption) []mcp.ToolOption {
+	return append([]mcp.ToolOption{
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"layout",
+			mcp.Required(),
+			mcp.Enum(maas_api.StorageLayoutFlat, maas_api.StorageLayoutLVM, maas_api.StorageLayoutBcache, maas_api.StorageLayoutBlank, maas_api.StorageLayoutCustom),
+			mcp.Description("flat puts / on a partition, lvm on a logical volume and bcache on a bcache device cached by an SSD; each adds an EFI partition. blank removes all storage configuration and custom applies the layout from the commissioning output of the machine."),
+		),
+		mcp.WithString(
+			"root_device",
+			mcp.Description("The name of the disk to put the layout on, like sdb. Default: the boot disk."),
+		),
+		mcp.WithString(
+			"boot_size",
+			mcp.Description("Size of a separate /boot partition, like 1G. Default: no /boot partition."),
+		),
+		mcp.WithString(
+			"root_size",
+			mcp.Description("Size of the root partition, like 100G. Default: the rest of the disk."),
+		),
+		mcp.WithString(
+			"vg_name",
+			mcp.Description("lvm only: the name of the volume group. Default: vgroot."),
+		),
+		mcp.WithString(
+			"lv_name",
+			mcp.Description("lvm only: the name of the root logical volume. Default: lvroot."),
+		),
+		mcp.WithString(
+			"lv_size",
+			mcp.Description("lvm only: size of the root logical volume, like 50G. Default: the whole volume group."),
+		),
+		mcp.WithString(
+			"cache_device",
+			mcp.Description("bcache only: the name of the cache disk. Default: the smallest disk tagged ssd. Without one the flat layout is used."),
+		),
+		mcp.WithString(
+			"cache_mode",
+			mcp.Enum("writethrough", "writeback", "writearound"),
+			mcp.Description("bcache only: the cache mode. Default: writethrough."),
+		),
+		mcp.WithString(
+			"cache_size",
+			mcp.Description("bcache only: size of a cache partition, like 50G. Default: the whole cache disk."),
+		),
+	}, options...)
+}
+
+// layoutParams reads the storage layout options of the request. Disks are
+// resolved by name among the physical disks of the machine.
+func layoutParams(request mcp.CallToolRequest, disks []maas_api.BlockDevice) (maas_api.StorageLayoutParams, error) {
+	params := maas_api.StorageLayoutParams{
+		Layout:    request.GetString("layout", ""),
+		VGName:    request.GetString("vg_name", ""),
+		LVName:    request.GetString("lv_name", ""),
+		CacheMode: request.GetString("cache_mode", ""),
+	}
+
+	sizes := []struct {
+		key  string
+		size **int64
+	}{{"boot_size", &params.BootSize}, {"root_size", &params.RootSize}, {"lv_size", &params.LVSize}, {"cache_size", &params.CacheSize}}
+	for _, option := range sizes {
+		size, err := optionalSize(request, option.key)
+		if err != nil {
+			return params, err
+		}
+		*option.size = size
+	}
+
+	devices := []struct {
+		key    string
+		device **int
+	}{{"root_device", &params.RootDevice}, {"cache_device", &params.CacheDevice}}
+	for _, option := range devices {
+		name := request.GetString(option.key, "")
+		if name == "" {
+			continue
+		}
+		id, err := findDisk(disks, name)
+		if err != nil {
+			return params, err
+		}
+		*option.device = &id
+	}
+	return params, nil
+}
+
+type Storage struct {
+	Client maas_client.Client
+}
+
+func (s Storage) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ReadStorage{Client: s.Client}, PreviewStorageLayout{Client: s.Client}, SetStorageLayout{Client: s.Client}, SetBootDisk{Client: s.Client}, FormatStorage{Client: s.Client}, MountStorage{Client: s.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ReadStorage struct {
+	Client maas_client.Client
+}
+
+func (ReadStorage) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-storage",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Storage", true, false, false, true)),
+		mcp.WithDescription("Return the storage of a machine as a tree: the disks with their partitions, filesystems and mount points, and the RAID sets, volume groups and bcaches built on them. The rendered field shows the tree like lsblk."),
+	)
+}
+
+func (r ReadStorage) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadStorage] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(r.Client)
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[ReadStorage] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if machine.Protected() {
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadStorage] Reading the storage of machine %s...", machineID))
+	tree, err := readTree(ctx, api, machine)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadStorage] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	jsonData, err := json.Marshal(tree)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadStorage] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type PreviewStorageLayout struct {
+	Client maas_client.Client
+}
+
+func (PreviewStorageLayout) Create() mcp.Tool {
+	return mcp.NewTool(
+		"preview-storage-layout",
+		withLayoutOptions(
+			mcp.WithToolAnnotation(tools.CreateToolAnnotation("Preview Storage Layout", true, false, true, true)),
+			mcp.WithDescription("Show the storage tree a layout would give a machine, without changing anything. Apply it with set-storage-layout. Sizes follow the layout rules of MAAS and may differ slightly from what MAAS allocates."),
+		)...,
+	)
+}
+
+func (p PreviewStorageLayout) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PreviewStorageLayout] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(p.Client)
+
+	machine, err := api.GetMachine(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machine with id %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[PreviewStorageLayout] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if machine.Protected() {
+		return mcp.NewToolResultError("Machine is protected and cannot be accessed"), nil
+	}
+
+	devices, err := api.ListBlockDevices(ctx, machineID)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the block devices of machine %s err=%v", machineID, err)
+		zap.L().Error(fmt.Sprintf("[PreviewStorageLayout] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	disks := physicalDisks(devices)
+
+	params, err := layoutParams(request, disks)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PreviewStorageLayout] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	bootDiskID := 0
+	if machine.BootDisk != nil {
+		bootDiskID = machine.BootDisk.ID
+	}
+	tree, err := previewLayout(machineID, bootDiskID, disks, params)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[PreviewStorageLayout] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	jsonData, err := json.Marshal(tree)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[PreviewStorageLayout] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type SetStorageLayout struct {
+	Client maas_client.Client
+}
+
+func (SetStorageLayout) Create() mcp.Tool {
+	return mcp.NewTool(
+		"set-storage-layout",
+		withLayoutOptions(
+			mcp.WithToolAnnotation(tools.CreateToolAnnotation("Set Storage Layout", false, true, true, true)),
+			mcp.WithDescription("Replace the whole storage configuration of a machine with a layout and return the resulting storage tree. Check the result with preview-storage-layout first. The machine must be Ready or Allocated."),
+		)...,
+	)
+}
+
+func (s SetStorageLayout) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetStorageLayout] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(s.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetStorageLayout] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params, err := layoutParams(request, physicalDisks(devices))
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetStorageLayout] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[SetStorageLayout] Applying the %s layout to machine %s...", params.Layout, machineID))
+	if _, err := api.SetStorageLayout(ctx, machineID, params); err != nil {
+		errMsg = fmt.Sprintf("Failed to apply the %s layout to machine %s err=%v", params.Layout, machineID, err)
+		zap.L().Error(fmt.Sprintf("[SetStorageLayout] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "SetStorageLayout")
+}
+
+type SetBootDisk struct {
+	Client maas_client.Client
+}
+
+func (SetBootDisk) Create() mcp.Tool {
+	return mcp.NewTool(
+		"set-boot-disk",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"device",
+			mcp.Required(),
+			mcp.Description("The name of the physical disk to boot from, like sdb."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Set Boot Disk", false, false, true, true)),
+		mcp.WithDescription("Make a physical disk the boot disk of a machine. Storage layouts applied afterwards are built on it. The machine must be Ready or Allocated."),
+	)
+}
+
+func (s SetBootDisk) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetBootDisk] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("device")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetBootDisk] Required parameter device not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(s.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetBootDisk] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	id, err := findDisk(physicalDisks(devices), name)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[SetBootDisk] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[SetBootDisk] Setting the boot disk of machine %s to %s...", machineID, name))
+	if err := api.SetBootDisk(ctx, machineID, id); err != nil {
+		errMsg = fmt.Sprintf("Failed to set the boot disk of machine %s to %s err=%v", machineID, name, err)
+		zap.L().Error(fmt.Sprintf("[SetBootDisk] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "SetBootDisk")
+}
+
+type FormatStorage struct {
+	Client maas_client.Client
+}
+
+func (FormatStorage) Create() mcp.Tool {
+	return mcp.NewTool(
+		"format-storage",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"device",
+			mcp.Required(),
+			mcp.Description("The name of the block device or partition to format, like sdb, sda-part2, md0 or vgdata-lvdata."),
+		),
+		mcp.WithString(
+			"fstype",
+			mcp.Required(),
+			mcp.Enum("ext4", "xfs", "btrfs", "ext2", "fat32", "vfat", "swap"),
+			mcp.Description("The filesystem to create."),
+		),
+		mcp.WithString(
+			"label",
+			mcp.Description("The label of the filesystem."),
+		),
+		mcp.WithString(
+			"mount_point",
+			mcp.Description("Mount the filesystem at this absolute path, like /srv. Ignored for swap, which is always enabled."),
+		),
+		mcp.WithString(
+			"mount_options",
+			mcp.Description("The mount options, like noatime."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Format Storage", false, true, true, true)),
+		mcp.WithDescription("Create a filesystem on a block device or partition of a machine and optionally mount it. Devices with partitions or that belong to a RAID set, volume group or bcache cannot be formatted. The machine must be Ready or Allocated."),
+	)
+}
+
+func (f FormatStorage) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[FormatStorage] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("device")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[FormatStorage] Required parameter device not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	fsType, err := request.RequireString("fstype")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[FormatStorage] Required parameter fstype not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	format := maas_api.FormatParams{FSType: fsType, Label: request.GetString("label", "")}
+	mount := maas_api.MountParams{MountPoint: request.GetString("mount_point", ""), MountOptions: request.GetString("mount_options", "")}
+	if fsType == "swap" {
+		mount.MountPoint = "none"
+	}
+
+	api := maas_api.New(f.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[FormatStorage] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	device, partition, err := findStorage(devices, name)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[FormatStorage] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if partition == nil && len(device.Partitions) > 0 {
+		errMsg = fmt.Sprintf("Block device %s has partitions, format one of them or delete them first", name)
+		zap.L().Error(fmt.Sprintf("[FormatStorage] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[FormatStorage] Formatting %s of machine %s as %s...", name, machineID, fsType))
+	if partition != nil {
+		_, err = api.FormatPartition(ctx, machineID, device.ID, partition.ID, format)
+	} else {
+		_, err = api.FormatBlockDevice(ctx, machineID, device.ID, format)
+	}
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to format %s of machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[FormatStorage] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if mount.MountPoint != "" {
+		if err := mountStorage(ctx, api, machineID, device, partition, mount); err != nil {
+			errMsg = fmt.Sprintf("Formatted %s of machine %s but failed to mount it at %s err=%v", name, machineID, mount.MountPoint, err)
+			zap.L().Error(fmt.Sprintf("[FormatStorage] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	return storageResult(ctx, api, machineID, "FormatStorage")
+}
+
+type MountStorage struct {
+	Client maas_client.Client
+}
+
+func (MountStorage) Create() mcp.Tool {
+	return mcp.NewTool(
+		"mount-storage",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"device",
+			mcp.Required(),
+			mcp.Description("The name of the formatted block device or partition to mount, like sdb or sda-part2."),
+		),
+		mcp.WithString(
+			"mount_point",
+			mcp.Required(),
+			mcp.Description("The absolute path to mount the filesystem at, like /srv."),
+		),
+		mcp.WithString(
+			"mount_options",
+			mcp.Description("The mount options, like noatime."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Mount Storage", false, false, true, true)),
+		mcp.WithDescription("Mount the filesystem of a block device or partition of a machine. Format it first with format-storage. The machine must be Ready or Allocated."),
+	)
+}
+
+func (m MountStorage) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[MountStorage] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("device")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[MountStorage] Required parameter device not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mountPoint, err := request.RequireString("mount_point")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[MountStorage] Required parameter mount_point not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(m.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[MountStorage] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	device, partition, err := findStorage(devices, name)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[MountStorage] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	filesystem := device.Filesystem
+	if partition != nil {
+		filesystem = partition.Filesystem
+	}
+	if filesystem == nil {
+		errMsg = fmt.Sprintf("%s has no filesystem, format it first with format-storage", name)
+		zap.L().Error(fmt.Sprintf("[MountStorage] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[MountStorage] Mounting %s of machine %s at %s...", name, machineID, mountPoint))
+	if err := mountStorage(ctx, api, machineID, device, partition, maas_api.MountParams{MountPoint: mountPoint, MountOptions: request.GetString("mount_options", "")}); err != nil {
+		errMsg = fmt.Sprintf("Failed to mount %s of machine %s at %s err=%v", name, machineID, mountPoint, err)
+		zap.L().Error(fmt.Sprintf("[MountStorage] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "MountStorage")
+}
+
+// mountStorage mounts the filesystem of the partition, or of the device when
+// partition is nil.
+func mountStorage(ctx context.Context, api *maas_api.API, systemID string, device maas_api.BlockDevice, partition *maas_api.Partition, params maas_api.MountParams) error {
+	if partition != nil {
+		_, err := api.MountPartition(ctx, systemID, device.ID, partition.ID, params)
+		return err
+	}
+	_, err := api.MountBlockDevice(ctx, systemID, device.ID, params)
+	return err
+}
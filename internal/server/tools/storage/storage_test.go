@@ -0,0 +1,448 @@
+package storage
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+const gigabyte = 1000 * 1000 * 1000
+
+// startMachine starts a fake with a machine aaaaaa in the given status. It
+// has a 100 GB boot disk sda, two 200 GB disks sdb and sdc and a 50 GB SSD
+// sdd, all blank.
+func startMachine(t *testing.T, status string, tags ...string) *fakemaas.Server {
+	fake := fakemaas.Start(t)
+	fake.AddMachine(fakemaas.Machine{
+		SystemID: "aaaaaa",
+		Status:   status,
+		TagNames: tags,
+		BlockDevices: []fakemaas.BlockDevice{
+			{Name: "sda", Size: 100 * gigabyte, Model: "QEMU HARDDISK"},
+			{Name: "sdb", Size: 200 * gigabyte},
+			{Name: "sdc", Size: 200 * gigabyte},
+			{Name: "sdd", Size: 50 * gigabyte, Tags: []string{"ssd"}},
+		},
+	})
+	return fake
+}
+
+// callTree calls a storage tool on machine aaaaaa and decodes the tree it
+// returns.
+func callTree(t *testing.T, fake *fakemaas.Server, tool string, arguments map[string]any) (Tree, bool, string) {
+	t.Helper()
+
+	client := fake.Client()
+	arguments["id"] = "aaaaaa"
+	result := fakemaas.CallTool(t, fakemaas.Handlers(Storage{Client: client}, Partitions{Client: client}, RAIDs{Client: client}, VolumeGroups{Client: client})[tool], arguments)
+	text := fakemaas.ResultText(t, result)
+	if result.IsError {
+		return Tree{}, true, text
+	}
+
+	var tree Tree
+	if err := json.Unmarshal([]byte(text), &tree); err != nil {
+		t.Fatalf("expected a storage tree, got %s", text)
+	}
+	return tree, false, text
+}
+
+// outline flattens a tree to one "name type fstype mount_point" line per
+// node, indented by depth.
+func outline(nodes []Node, indent string) []string {
+	var lines []string
+	for _, node := range nodes {
+		lines = append(lines, strings.TrimRight(strings.Join([]string{indent + node.Name, node.Type, node.FSType, node.MountPoint}, " "), " "))
+		lines = append(lines, outline(node.Children, indent+"  ")...)
+	}
+	return lines
+}
+
+func TestPreviewStorageLayout_MatchesAppliedLayout(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		layout    string
+		root      []string
+	}{
+		{"flat", map[string]any{"layout": "flat"}, "flat", []string{"sda disk", "  sda-part1 part fat32 /boot/efi", "  sda-part2 part ext4 /"}},
+		{"flat with boot and root sizes", map[string]any{"layout": "flat", "boot_size": "1G", "root_size": "50G"}, "flat", []string{"sda disk", "  sda-part1 part fat32 /boot/efi", "  sda-part2 part ext4 /boot", "  sda-part3 part ext4 /"}},
+		{"flat on another disk", map[string]any{"layout": "flat", "root_device": "sdb"}, "flat", []string{"sda disk"}},
+		{"lvm", map[string]any{"layout": "lvm", "vg_name": "vg0", "lv_size": "40G"}, "lvm", []string{"sda disk", "  sda-part1 part fat32 /boot/efi", "  sda-part2 part", "    vg0 vg", "      vg0-lvroot lvm ext4 /"}},
+		{"bcache on the ssd", map[string]any{"layout": "bcache"}, "bcache", []string{"sda disk", "  sda-part1 part fat32 /boot/efi", "  sda-part2 part", "    bcache0 bcache ext4 /"}},
+		{"bcache with a cache partition", map[string]any{"layout": "bcache", "cache_device": "sdb", "cache_size": "20G", "cache_mode": "writeback"}, "bcache", []string{"sda disk", "  sda-part1 part fat32 /boot/efi", "  sda-part2 part", "    bcache0 bcache ext4 /"}},
+		{"blank", map[string]any{"layout": "blank"}, "blank", []string{"sda disk"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := startMachine(t, fakemaas.StatusReady)
+			arguments := map[string]any{}
+			for key, value := range tc.arguments {
+				arguments[key] = value
+			}
+
+			// Act
+			preview, isError, text := callTree(t, fake, "preview-storage-layout", tc.arguments)
+			if isError {
+				t.Fatalf("expected a preview, got %s", text)
+			}
+			applied, isError, text := callTree(t, fake, "set-storage-layout", arguments)
+			if isError {
+				t.Fatalf("expected the layout to be applied, got %s", text)
+			}
+
+			// Assert
+			if !reflect.DeepEqual(preview.Devices, applied.Devices) {
+				t.Errorf("expected the preview to match the applied layout\npreview:\n%s\napplied:\n%s", preview.Rendered, applied.Rendered)
+			}
+			if preview.Rendered != applied.Rendered {
+				t.Errorf("expected the rendered trees to match\npreview:\n%s\napplied:\n%s", preview.Rendered, applied.Rendered)
+			}
+			if preview.Layout != tc.layout {
+				t.Errorf("expected layout %s, got %s", tc.layout, preview.Layout)
+			}
+			if got := outline(preview.Devices[:1], ""); !reflect.DeepEqual(got, tc.root) {
+				t.Errorf("expected sda to be %v, got %v", tc.root, got)
+			}
+		})
+	}
+}
+
+func TestPreviewStorageLayout_DoesNotChangeStorage(t *testing.T) {
+	// Arrange
+	fake := startMachine(t, fakemaas.StatusDeployed)
+
+	// Act
+	preview, isError, text := callTree(t, fake, "preview-storage-layout", map[string]any{"layout": "lvm"})
+
+	// Assert
+	if isError {
+		t.Fatalf("expected a preview, got %s", text)
+	}
+	if !strings.Contains(preview.Rendered, "vgroot-lvroot") {
+		t.Errorf("expected the logical volume in the rendered tree, got\n%s", preview.Rendered)
+	}
+	machine, _ := fake.Machine("aaaaaa")
+	if len(machine.BlockDevices[0].Partitions) != 0 || len(machine.VolumeGroups) != 0 {
+		t.Errorf("expected the storage to be unchanged, got %+v", machine.BlockDevices[0])
+	}
+}
+
+func TestSetStorageLayout_Errors(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    string
+		tags      []string
+		arguments map[string]any
+		expected  string
+	}{
+		{"deployed machine", fakemaas.StatusDeployed, nil, map[string]any{"layout": "flat"}, "Release the machine first"},
+		{"commissioning machine", fakemaas.StatusCommissioning, nil, map[string]any{"layout": "flat"}, "Commission the machine first"},
+		{"protected machine", fakemaas.StatusReady, []string{"protected"}, map[string]any{"layout": "flat"}, "protected"},
+		{"unknown root device", fakemaas.StatusReady, nil, map[string]any{"layout": "flat", "root_device": "sdz"}, "no physical disk sdz"},
+		{"malformed size", fakemaas.StatusReady, nil, map[string]any{"layout": "flat", "root_size": "big"}, "invalid parameter root_size"},
+		{"root size larger than the disk", fakemaas.StatusReady, nil, map[string]any{"layout": "flat", "root_size": "200G"}, "Size is too large"},
+		{"custom layout without configuration", fakemaas.StatusReady, nil, map[string]any{"layout": "custom"}, "No custom storage layout"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := startMachine(t, tc.status, tc.tags...)
+
+			// Act
+			_, isError, text := callTree(t, fake, "set-storage-layout", tc.arguments)
+
+			// Assert
+			if !isError {
+				t.Fatalf("expected an error result, got %s", text)
+			}
+			if !strings.Contains(text, tc.expected) {
+				t.Errorf("expected %q in %q", tc.expected, text)
+			}
+			machine, _ := fake.Machine("aaaaaa")
+			if len(machine.BlockDevices[0].Partitions) != 0 {
+				t.Errorf("expected the storage to be unchanged, got %+v", machine.BlockDevices[0])
+			}
+		})
+	}
+}
+
+func TestStorageTools(t *testing.T) {
+	type call struct {
+		tool      string
+		arguments map[string]any
+	}
+
+	cases := []struct {
+		name     string
+		status   string
+		setup    []call
+		call     call
+		isError  bool
+		expected []string
+	}{
+		{
+			"create partition",
+			fakemaas.StatusReady,
+			nil,
+			call{"create-partition", map[string]any{"device": "sdb", "size": "20G"}},
+			false,
+			[]string{"sdb disk", "  sdb-part1 part"},
+		},
+		{
+			"create partition larger than the disk",
+			fakemaas.StatusReady,
+			nil,
+			call{"create-partition", map[string]any{"device": "sdb", "size": "300G"}},
+			true,
+			[]string{"sdb disk"},
+		},
+		{
+			"format and mount partition",
+			fakemaas.StatusAllocated,
+			[]call{{"create-partition", map[string]any{"device": "sdb"}}},
+			call{"format-storage", map[string]any{"device": "sdb-part1", "fstype": "xfs", "mount_point": "/srv"}},
+			false,
+			[]string{"sdb disk", "  sdb-part1 part xfs /srv"},
+		},
+		{
+			"format disk with partitions",
+			fakemaas.StatusReady,
+			[]call{{"create-partition", map[string]any{"device": "sdb"}}},
+			call{"format-storage", map[string]any{"device": "sdb", "fstype": "ext4"}},
+			true,
+			[]string{"sdb disk", "  sdb-part1 part"},
+		},
+		{
+			"format swap",
+			fakemaas.StatusReady,
+			nil,
+			call{"format-storage", map[string]any{"device": "sdb", "fstype": "swap"}},
+			false,
+			[]string{"sdb disk swap none"},
+		},
+		{
+			"mount unformatted disk",
+			fakemaas.StatusReady,
+			nil,
+			call{"mount-storage", map[string]any{"device": "sdb", "mount_point": "/srv"}},
+			true,
+			[]string{"sdb disk"},
+		},
+		{
+			"mount formatted disk",
+			fakemaas.StatusReady,
+			[]call{{"format-storage", map[string]any{"device": "sdb", "fstype": "ext4"}}},
+			call{"mount-storage", map[string]any{"device": "sdb", "mount_point": "/data", "mount_options": "noatime"}},
+			false,
+			[]string{"sdb disk ext4 /data"},
+		},
+		{
+			"delete partition",
+			fakemaas.StatusReady,
+			[]call{{"create-partition", map[string]any{"device": "sdb", "size": "20G"}}, {"create-partition", map[string]any{"device": "sdb"}}},
+			call{"delete-partition", map[string]any{"partition": "sdb-part2"}},
+			false,
+			[]string{"sdb disk", "  sdb-part1 part"},
+		},
+		{
+			"create RAID",
+			fakemaas.StatusReady,
+			nil,
+			call{"create-raid", map[string]any{"level": "raid-1", "devices": []any{"sdb", "sdc"}}},
+			false,
+			[]string{"sdb disk", "  md0 raid-1"},
+		},
+		{
+			"create RAID with too few members",
+			fakemaas.StatusReady,
+			nil,
+			call{"create-raid", map[string]any{"level": "raid-5", "devices": []any{"sdb", "sdc"}}},
+			true,
+			[]string{"sdb disk"},
+		},
+		{
+			"create RAID on a formatted disk",
+			fakemaas.StatusReady,
+			[]call{{"format-storage", map[string]any{"device": "sdc", "fstype": "ext4"}}},
+			call{"create-raid", map[string]any{"level": "raid-1", "devices": []any{"sdb", "sdc"}}},
+			true,
+			[]string{"sdb disk"},
+		},
+		{
+			"create RAID on a deployed machine",
+			fakemaas.StatusDeployed,
+			nil,
+			call{"create-raid", map[string]any{"level": "raid-1", "devices": []any{"sdb", "sdc"}}},
+			true,
+			[]string{"sdb disk"},
+		},
+		{
+			"delete RAID",
+			fakemaas.StatusReady,
+			[]call{{"create-raid", map[string]any{"name": "md7", "level": "raid-0", "devices": []any{"sdb", "sdc"}}}},
+			call{"delete-raid", map[string]any{"name": "md7"}},
+			false,
+			[]string{"sdb disk"},
+		},
+		{
+			"volume group and logical volume on a RAID set",
+			fakemaas.StatusReady,
+			[]call{
+				{"create-raid", map[string]any{"level": "raid-1", "devices": []any{"sdb", "sdc"}}},
+				{"create-volume-group", map[string]any{"name": "vgdata", "devices": []any{"md0"}}},
+				{"create-logical-volume", map[string]any{"volume_group": "vgdata", "name": "lvdata", "size": "100G"}},
+			},
+			call{"format-storage", map[string]any{"device": "vgdata-lvdata", "fstype": "ext4", "mount_point": "/data"}},
+			false,
+			[]string{"sdb disk", "  md0 raid-1", "    vgdata vg", "      vgdata-lvdata lvm ext4 /data"},
+		},
+		{
+			"logical volume larger than the volume group",
+			fakemaas.StatusReady,
+			[]call{{"create-volume-group", map[string]any{"name": "vgdata", "devices": []any{"sdb"}}}},
+			call{"create-logical-volume", map[string]any{"volume_group": "vgdata", "name": "lvdata", "size": "300G"}},
+			true,
+			[]string{"sdb disk", "  vgdata vg"},
+		},
+		{
+			"delete logical volume",
+			fakemaas.StatusReady,
+			[]call{
+				{"create-volume-group", map[string]any{"name": "vgdata", "devices": []any{"sdb"}}},
+				{"create-logical-volume", map[string]any{"volume_group": "vgdata", "name": "lvdata"}},
+			},
+			call{"delete-logical-volume", map[string]any{"volume_group": "vgdata", "name": "lvdata"}},
+			false,
+			[]string{"sdb disk", "  vgdata vg"},
+		},
+		{
+			"delete volume group",
+			fakemaas.StatusReady,
+			[]call{
+				{"create-volume-group", map[string]any{"name": "vgdata", "devices": []any{"sdb"}}},
+				{"create-logical-volume", map[string]any{"volume_group": "vgdata", "name": "lvdata"}},
+			},
+			call{"delete-volume-group", map[string]any{"name": "vgdata"}},
+			false,
+			[]string{"sdb disk"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := startMachine(t, fakemaas.StatusReady)
+			for _, setup := range tc.setup {
+				if _, isError, text := callTree(t, fake, setup.tool, setup.arguments); isError {
+					t.Fatalf("expected %s to succeed, got %s", setup.tool, text)
+				}
+			}
+			fake.SetStatus("aaaaaa", tc.status)
+
+			// Act
+			_, isError, text := callTree(t, fake, tc.call.tool, tc.call.arguments)
+
+			// Assert
+			if isError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, text)
+			}
+			tree, _, _ := callTree(t, fake, "read-storage", map[string]any{})
+			if got := outline(tree.Devices[1:2], ""); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected sdb to be %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetBootDisk(t *testing.T) {
+	// Arrange
+	fake := startMachine(t, fakemaas.StatusAllocated)
+
+	// Act
+	tree, isError, text := callTree(t, fake, "set-boot-disk", map[string]any{"device": "sdc"})
+
+	// Assert
+	if isError {
+		t.Fatalf("expected the boot disk to be set, got %s", text)
+	}
+	for _, disk := range tree.Devices {
+		if disk.Boot != (disk.Name == "sdc") {
+			t.Errorf("expected only sdc to be the boot disk, got %s boot=%v", disk.Name, disk.Boot)
+		}
+	}
+	applied, _, _ := callTree(t, fake, "set-storage-layout", map[string]any{"layout": "flat"})
+	if got := outline(applied.Devices[2:3], ""); len(got) != 3 {
+		t.Errorf("expected the layout on sdc, got\n%s", applied.Rendered)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected int64
+		isError  bool
+	}{
+		{"1073741824", 1073741824, false},
+		{"512M", 512 * 1000 * 1000, false},
+		{"20G", 20 * gigabyte, false},
+		{"20GB", 20 * gigabyte, false},
+		{"1.5t", 1500 * gigabyte, false},
+		{"", 0, true},
+		{"G", 0, true},
+		{"-1G", 0, true},
+		{"big", 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			// Act
+			size, err := parseSize(tc.value)
+
+			// Assert
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error=%v, got %v", tc.isError, err)
+			}
+			if size != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, size)
+			}
+		})
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	// Arrange
+	nodes := []Node{
+		{Name: "sda", Type: "disk", Size: 100 * gigabyte, Boot: true, Children: []Node{
+			{Name: "sda-part1", Type: "part", Size: efiPartitionSize, FSType: "fat32", MountPoint: "/boot/efi", Boot: true},
+			{Name: "sda-part2", Type: "part", Size: 99 * gigabyte, Children: []Node{
+				{Name: "vgroot", Type: "vg", Size: 99 * gigabyte, Children: []Node{
+					{Name: "vgroot-lvroot", Type: "lvm", Size: 99 * gigabyte, FSType: "ext4", MountPoint: "/"},
+				}},
+			}},
+		}},
+	}
+	expected := strings.Join([]string{
+		"NAME                 SIZE      TYPE         FSTYPE  MOUNTPOINT",
+		"sda                  100.0 GB  disk (boot)",
+		"├─sda-part1          536.9 MB  part (boot)  fat32   /boot/efi",
+		"└─sda-part2          99.0 GB   part",
+		"  └─vgroot           99.0 GB   vg",
+		"    └─vgroot-lvroot  99.0 GB   lvm          ext4    /",
+		"",
+	}, "\n")
+
+	// Act
+	rendered := renderTree(nodes)
+
+	// Assert
+	if rendered != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rendered)
+	}
+}
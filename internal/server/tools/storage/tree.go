@@ -0,0 +1,375 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+)
+
+// efiPartitionSize is the size of the EFI system partition MAAS creates on
+// the boot disk for every layout but blank.
+const efiPartitionSize int64 = 512 << 20
+
+// Tree is the storage of a machine as lsblk shows it: the physical disks with
+// their partitions, and the RAID sets, volume groups and bcaches built on
+// them nested under their members.
+type Tree struct {
+	SystemID string `json:"system_id"`
+	Layout   string `json:"layout,omitempty"`
+	Devices  []Node `json:"devices"`
+	Rendered string `json:"rendered"`
+}
+
+// Node is a disk, partition or virtual device of the tree. Type is disk,
+// part, vg, lvm, bcache or the level of a RAID set, like raid-1.
+type Node struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`
+	FSType     string `json:"fstype,omitempty"`
+	MountPoint string `json:"mount_point,omitempty"`
+	Boot       bool   `json:"boot,omitempty"`
+	Children   []Node `json:"children,omitempty"`
+}
+
+// memberKey identifies a block device or a partition, whose IDs MAAS keeps
+// apart.
+type memberKey struct {
+	partition bool
+	id        int
+}
+
+type treeBuilder struct {
+	bootDiskID int
+	devices    map[int]maas_api.BlockDevice
+	holders    map[memberKey][]func() Node
+}
+
+// readTree reads the storage of a machine from MAAS.
+func readTree(ctx context.Context, api *maas_api.API, machine maas_api.Machine) (Tree, error) {
+	devices, err := api.ListBlockDevices(ctx, machine.SystemID)
+	if err != nil {
+		return Tree{}, fmt.Errorf("failed to retrieve the block devices of machine %s err=%v", machine.SystemID, err)
+	}
+	raids, err := api.ListRAIDs(ctx, machine.SystemID)
+	if err != nil {
+		return Tree{}, fmt.Errorf("failed to retrieve the RAID sets of machine %s err=%v", machine.SystemID, err)
+	}
+	groups, err := api.ListVolumeGroups(ctx, machine.SystemID)
+	if err != nil {
+		return Tree{}, fmt.Errorf("failed to retrieve the volume groups of machine %s err=%v", machine.SystemID, err)
+	}
+	bcaches, err := api.ListBcaches(ctx, machine.SystemID)
+	if err != nil {
+		return Tree{}, fmt.Errorf("failed to retrieve the bcaches of machine %s err=%v", machine.SystemID, err)
+	}
+
+	bootDiskID := 0
+	if machine.BootDisk != nil {
+		bootDiskID = machine.BootDisk.ID
+	}
+	return buildTree(machine.SystemID, bootDiskID, devices, raids, groups, bcaches), nil
+}
+
+// buildTree nests the virtual devices of a machine under the block devices
+// and partitions they are built on.
+func buildTree(systemID string, bootDiskID int, devices []maas_api.BlockDevice, raids []maas_api.RAID, groups []maas_api.VolumeGroup, bcaches []maas_api.Bcache) Tree {
+	b := treeBuilder{
+		bootDiskID: bootDiskID,
+		devices:    map[int]maas_api.BlockDevice{},
+		holders:    map[memberKey][]func() Node{},
+	}
+	for _, device := range devices {
+		b.devices[device.ID] = device
+	}
+
+	for _, raid := range raids {
+		if raid.VirtualDevice == nil {
+			continue
+		}
+		holder := func() Node { return b.deviceNode(raid.VirtualDevice.ID, raid.Level) }
+		for _, member := range slices.Concat(raid.Devices, raid.SpareDevices) {
+			b.addHolder(member, holder)
+		}
+	}
+	for _, group := range groups {
+		holder := func() Node {
+			node := Node{Name: group.Name, Type: "vg", Size: group.Size}
+			for _, volume := range group.LogicalVolumes {
+				node.Children = append(node.Children, b.deviceNode(volume.ID, "lvm"))
+			}
+			return node
+		}
+		for _, member := range group.Devices {
+			b.addHolder(member, holder)
+		}
+	}
+	for _, bcache := range bcaches {
+		if bcache.VirtualDevice == nil {
+			continue
+		}
+		holder := func() Node { return b.deviceNode(bcache.VirtualDevice.ID, "bcache") }
+		if bcache.BackingDevice != nil {
+			b.addHolder(*bcache.BackingDevice, holder)
+		}
+		if bcache.CacheSet != nil && bcache.CacheSet.CacheDevice != nil {
+			b.addHolder(*bcache.CacheSet.CacheDevice, holder)
+		}
+	}
+
+	tree := Tree{SystemID: systemID, Devices: []Node{}}
+	for _, device := range devices {
+		if device.Type == "physical" {
+			tree.Devices = append(tree.Devices, b.deviceNode(device.ID, "disk"))
+		}
+	}
+	tree.Rendered = renderTree(tree.Devices)
+	return tree
+}
+
+func (b *treeBuilder) addHolder(member maas_api.BlockDevice, holder func() Node) {
+	key := memberKey{partition: member.Type == "partition", id: member.ID}
+	b.holders[key] = append(b.holders[key], holder)
+}
+
+func (b *treeBuilder) held(key memberKey) []Node {
+	var nodes []Node
+	for _, holder := range b.holders[key] {
+		nodes = append(nodes, holder())
+	}
+	return nodes
+}
+
+func (b *treeBuilder) deviceNode(id int, nodeType string) Node {
+	device := b.devices[id]
+	node := Node{Name: device.Name, Type: nodeType, Size: device.Size, Boot: nodeType == "disk" && id == b.bootDiskID}
+	setFilesystem(&node, device.Filesystem)
+
+	for _, partition := range device.Partitions {
+		child := Node{Name: partitionName(partition), Type: "part", Size: partition.Size, Boot: partition.Bootable}
+		setFilesystem(&child, partition.Filesystem)
+		child.Children = b.held(memberKey{partition: true, id: partition.ID})
+		node.Children = append(node.Children, child)
+	}
+	node.Children = append(node.Children, b.held(memberKey{id: id})...)
+	return node
+}
+
+func setFilesystem(node *Node, filesystem *maas_api.Filesystem) {
+	if filesystem != nil {
+		node.FSType = filesystem.FSType
+		node.MountPoint = filesystem.MountPoint
+	}
+}
+
+// partitionName returns the name of a partition, like sda-part2, from its
+// path under /dev/disk/by-dname.
+func partitionName(partition maas_api.Partition) string {
+	return path.Base(partition.Path)
+}
+
+// previewLayout returns the tree MAAS builds when it applies a storage layout
+// to the physical disks of a machine. It follows MAAS: the layout goes on the
+// root device, or else the boot disk, behind a 512 MiB EFI partition and an
+// optional /boot partition, and every other disk is left blank.
+func previewLayout(systemID string, bootDiskID int, disks []maas_api.BlockDevice, params maas_api.StorageLayoutParams) (Tree, error) {
+	layout := params.Layout
+	if layout == "" {
+		layout = maas_api.StorageLayoutFlat
+	}
+	if layout == maas_api.StorageLayoutCustom {
+		return Tree{}, fmt.Errorf("the custom layout is read from the commissioning output of the machine and cannot be previewed")
+	}
+
+	tree := Tree{SystemID: systemID, Layout: layout, Devices: []Node{}}
+	root := -1
+	for i, disk := range disks {
+		tree.Devices = append(tree.Devices, Node{Name: disk.Name, Type: "disk", Size: disk.Size, Boot: disk.ID == bootDiskID})
+		if params.RootDevice != nil && disk.ID == *params.RootDevice || params.RootDevice == nil && disk.ID == bootDiskID {
+			root = i
+		}
+	}
+	if root < 0 && params.RootDevice == nil && len(disks) > 0 {
+		root = 0
+	}
+	if root < 0 {
+		return Tree{}, fmt.Errorf("machine %s has no disk to apply the layout to", systemID)
+	}
+	if layout == maas_api.StorageLayoutBlank {
+		tree.Rendered = renderTree(tree.Devices)
+		return tree, nil
+	}
+
+	disk := &tree.Devices[root]
+	bootSize := valueOr(params.BootSize, 0)
+	available := disk.Size - efiPartitionSize - bootSize
+	if available <= 0 {
+		return Tree{}, fmt.Errorf("disk %s of %s has no room for the root partition", disk.Name, formatSize(disk.Size))
+	}
+	rootSize := valueOr(params.RootSize, available)
+	if rootSize > available {
+		return Tree{}, fmt.Errorf("root_size is larger than the %s left on disk %s", formatSize(available), disk.Name)
+	}
+
+	disk.Children = append(disk.Children, Node{Name: disk.Name + "-part1", Type: "part", Size: efiPartitionSize, FSType: "fat32", MountPoint: "/boot/efi", Boot: true})
+	if bootSize > 0 {
+		disk.Children = append(disk.Children, Node{Name: disk.Name + "-part2", Type: "part", Size: bootSize, FSType: "ext4", MountPoint: "/boot"})
+	}
+	rootPartition := Node{Name: fmt.Sprintf("%s-part%d", disk.Name, len(disk.Children)+1), Type: "part", Size: rootSize}
+
+	var cache int
+	if layout == maas_api.StorageLayoutBcache {
+		cache = cacheDisk(disks, root, params.CacheDevice)
+		if cache < 0 && params.CacheDevice != nil {
+			return Tree{}, fmt.Errorf("the cache device must be a disk of the machine other than the root disk %s", disk.Name)
+		} else if cache < 0 {
+			tree.Layout, layout = maas_api.StorageLayoutFlat, maas_api.StorageLayoutFlat
+		} else if params.CacheSize != nil && *params.CacheSize > disks[cache].Size {
+			return Tree{}, fmt.Errorf("cache_size is larger than cache disk %s of %s", disks[cache].Name, formatSize(disks[cache].Size))
+		}
+	}
+
+	switch layout {
+	case maas_api.StorageLayoutFlat:
+		rootPartition.FSType, rootPartition.MountPoint = "ext4", "/"
+	case maas_api.StorageLayoutLVM:
+		lvSize := valueOr(params.LVSize, rootSize)
+		if lvSize > rootSize {
+			return Tree{}, fmt.Errorf("lv_size is larger than the %s of the volume group", formatSize(rootSize))
+		}
+		vgName, lvName := stringOr(params.VGName, "vgroot"), stringOr(params.LVName, "lvroot")
+		rootPartition.Children = []Node{{
+			Name:     vgName,
+			Type:     "vg",
+			Size:     rootSize,
+			Children: []Node{{Name: vgName + "-" + lvName, Type: "lvm", Size: lvSize, FSType: "ext4", MountPoint: "/"}},
+		}}
+	case maas_api.StorageLayoutBcache:
+		bcache := Node{Name: "bcache0", Type: "bcache", Size: rootSize, FSType: "ext4", MountPoint: "/"}
+		rootPartition.Children = []Node{bcache}
+		if params.CacheSize != nil && *params.CacheSize > 0 {
+			tree.Devices[cache].Children = []Node{{Name: disks[cache].Name + "-part1", Type: "part", Size: *params.CacheSize, Children: []Node{bcache}}}
+		} else {
+			tree.Devices[cache].Children = []Node{bcache}
+		}
+	default:
+		return Tree{}, fmt.Errorf("unknown storage layout %s", layout)
+	}
+	disk.Children = append(disk.Children, rootPartition)
+
+	tree.Rendered = renderTree(tree.Devices)
+	return tree, nil
+}
+
+// cacheDisk returns the index of the cache disk of the bcache layout: the
+// given cache device, or else the smallest disk tagged ssd other than the
+// root disk. It returns -1 when there is none.
+func cacheDisk(disks []maas_api.BlockDevice, root int, cacheDevice *int) int {
+	cache := -1
+	for i, disk := range disks {
+		switch {
+		case i == root:
+			continue
+		case cacheDevice != nil:
+			if disk.ID == *cacheDevice {
+				return i
+			}
+		case slices.Contains(disk.Tags, "ssd") && (cache < 0 || disk.Size < disks[cache].Size):
+			cache = i
+		}
+	}
+	return cache
+}
+
+// renderTree renders the nodes as an lsblk style table.
+func renderTree(nodes []Node) string {
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tTYPE\tFSTYPE\tMOUNTPOINT")
+
+	row := func(name string, node Node) {
+		nodeType := node.Type
+		if node.Boot {
+			nodeType += " (boot)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, formatSize(node.Size), nodeType, node.FSType, node.MountPoint)
+	}
+	var walk func(nodes []Node, prefix string)
+	walk = func(nodes []Node, prefix string) {
+		for i, node := range nodes {
+			branch, next := "├─", "│ "
+			if i == len(nodes)-1 {
+				branch, next = "└─", "  "
+			}
+			row(prefix+branch+node.Name, node)
+			walk(node.Children, prefix+next)
+		}
+	}
+	for _, node := range nodes {
+		row(node.Name, node)
+		walk(node.Children, "")
+	}
+
+	w.Flush()
+
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSize formats a size in bytes with SI units, as MAAS shows sizes.
+func formatSize(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB", "PB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// parseSize parses a size like 512M, 20G, 1.5T or a plain number of bytes.
+// The suffixes K, M, G and T are SI units, as MAAS reads them.
+func parseSize(value string) (int64, error) {
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B")
+	multiplier := 1.0
+	if number != "" {
+		if exponent := strings.Index("KMGT", number[len(number)-1:]); exponent >= 0 {
+			multiplier = math.Pow(1000, float64(exponent+1))
+			number = strings.TrimSpace(number[:len(number)-1])
+		}
+	}
+
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid size %q: use a number of bytes or a number with a K, M, G or T suffix", value)
+	}
+	return int64(size * multiplier), nil
+}
+
+func valueOr(value *int64, fallback int64) int64 {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func stringOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// volumeGroup checks that the storage of the machine can be changed and
+// returns its volume group with the given name.
+func volumeGroup(ctx context.Context, api *maas_api.API, systemID, name string) (maas_api.VolumeGroup, error) {
+	if _, err := tools.ConfigurableMachine(ctx, api, systemID, "storage"); err != nil {
+		return maas_api.VolumeGroup{}, err
+	}
+
+	groups, err := api.ListVolumeGroups(ctx, systemID)
+	if err != nil {
+		return maas_api.VolumeGroup{}, fmt.Errorf("failed to retrieve the volume groups of machine %s err=%v", systemID, err)
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return group, nil
+		}
+	}
+	return maas_api.VolumeGroup{}, fmt.Errorf("machine %s has no volume group %s", systemID, name)
+}
+
+type VolumeGroups struct {
+	Client maas_client.Client
+}
+
+func (v VolumeGroups) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{CreateVolumeGroup{Client: v.Client}, DeleteVolumeGroup{Client: v.Client}, CreateLogicalVolume{Client: v.Client}, DeleteLogicalVolume{Client: v.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type CreateVolumeGroup struct {
+	Client maas_client.Client
+}
+
+func (CreateVolumeGroup) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-volume-group",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w.+-]+$`),
+			mcp.Description("Name of the volume group, like vgdata."),
+		),
+		mcp.WithArray(
+			"devices",
+			mcp.Required(),
+			mcp.WithStringItems(),
+			mcp.Description("The names of the unused block devices, partitions and RAID devices to use as physical volumes, like sdb, sdc-part1 or md0."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Volume Group", false, false, false, true)),
+		mcp.WithDescription("Create an LVM volume group on a machine and return the resulting storage tree. Add logical volumes with create-logical-volume. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreateVolumeGroup) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVolumeGroup] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVolumeGroup] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	members := request.GetStringSlice("devices", nil)
+	if len(members) == 0 {
+		errMsg = "At least one physical volume is required"
+		zap.L().Error(fmt.Sprintf("[CreateVolumeGroup] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	devices, err := machineDevices(ctx, api, machineID)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVolumeGroup] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.VolumeGroupParams{Name: name}
+	if params.BlockDevices, params.Partitions, err = storageMembers(devices, members); err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVolumeGroup] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateVolumeGroup] Creating volume group %s on machine %s...", name, machineID))
+	if _, err := api.CreateVolumeGroup(ctx, machineID, params); err != nil {
+		errMsg = fmt.Sprintf("Failed to create volume group %s on machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateVolumeGroup] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "CreateVolumeGroup")
+}
+
+type DeleteVolumeGroup struct {
+	Client maas_client.Client
+}
+
+func (DeleteVolumeGroup) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-volume-group",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the volume group to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Volume Group", false, true, false, true)),
+		mcp.WithDescription("Delete an LVM volume group of a machine with all its logical volumes and return the resulting storage tree. Its physical volumes become unused. The machine must be Ready or Allocated."),
+	)
+}
+
+func (d DeleteVolumeGroup) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteVolumeGroup] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteVolumeGroup] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	group, err := volumeGroup(ctx, api, machineID, name)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteVolumeGroup] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteVolumeGroup] Deleting volume group %s of machine %s...", name, machineID))
+	if err := api.DeleteVolumeGroup(ctx, machineID, group.ID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete volume group %s of machine %s err=%v", name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteVolumeGroup] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "DeleteVolumeGroup")
+}
+
+type CreateLogicalVolume struct {
+	Client maas_client.Client
+}
+
+func (CreateLogicalVolume) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-logical-volume",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"volume_group",
+			mcp.Required(),
+			mcp.Description("The name of the volume group."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w.+-]+$`),
+			mcp.Description("Name of the logical volume, like lvdata. Its block device is named after the volume group and the volume, like vgdata-lvdata."),
+		),
+		mcp.WithString(
+			"size",
+			mcp.Description("Size of the logical volume, like 50G. Default: the rest of the volume group."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Logical Volume", false, false, false, true)),
+		mcp.WithDescription("Create a logical volume in an LVM volume group of a machine and return the resulting storage tree. Format it with format-storage. The machine must be Ready or Allocated."),
+	)
+}
+
+func (c CreateLogicalVolume) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	groupName, err := request.RequireString("volume_group")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] Required parameter volume_group not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	size, err := optionalSize(request, "size")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(c.Client)
+
+	group, err := volumeGroup(ctx, api, machineID, groupName)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if group.AvailableSize <= 0 || size != nil && *size > group.AvailableSize {
+		errMsg = fmt.Sprintf("Volume group %s has only %s left", groupName, formatSize(group.AvailableSize))
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateLogicalVolume] Creating logical volume %s in %s of machine %s...", name, groupName, machineID))
+	if _, err := api.CreateLogicalVolume(ctx, machineID, group.ID, name, size); err != nil {
+		errMsg = fmt.Sprintf("Failed to create logical volume %s in %s of machine %s err=%v", name, groupName, machineID, err)
+		zap.L().Error(fmt.Sprintf("[CreateLogicalVolume] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "CreateLogicalVolume")
+}
+
+type DeleteLogicalVolume struct {
+	Client maas_client.Client
+}
+
+func (DeleteLogicalVolume) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-logical-volume",
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Pattern("^[0-9a-z]{6}$"),
+			mcp.Description("The id of the machine."),
+		),
+		mcp.WithString(
+			"volume_group",
+			mcp.Required(),
+			mcp.Description("The name of the volume group."),
+		),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the logical volume, like lvdata or vgdata-lvdata."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Logical Volume", false, true, false, true)),
+		mcp.WithDescription("Delete a logical volume of an LVM volume group of a machine with its filesystem and return the resulting storage tree. The machine must be Ready or Allocated."),
+	)
+}
+
+func (d DeleteLogicalVolume) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	machineID, err := request.RequireString("id")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteLogicalVolume] Required parameter id not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	groupName, err := request.RequireString("volume_group")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteLogicalVolume] Required parameter volume_group not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteLogicalVolume] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	group, err := volumeGroup(ctx, api, machineID, groupName)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteLogicalVolume] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var volume *maas_api.BlockDevice
+	for i, candidate := range group.LogicalVolumes {
+		if candidate.Name == name || candidate.Name == groupName+"-"+name {
+			volume = &group.LogicalVolumes[i]
+		}
+	}
+	if volume == nil {
+		errMsg = fmt.Sprintf("Volume group %s has no logical volume %s", groupName, name)
+		zap.L().Error(fmt.Sprintf("[DeleteLogicalVolume] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteLogicalVolume] Deleting logical volume %s of machine %s...", volume.Name, machineID))
+	if err := api.DeleteLogicalVolume(ctx, machineID, group.ID, volume.ID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete logical volume %s of machine %s err=%v", volume.Name, machineID, err)
+		zap.L().Error(fmt.Sprintf("[DeleteLogicalVolume] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return storageResult(ctx, api, machineID, "DeleteLogicalVolume")
+}
@@ -4,27 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Subnet struct{}
+type Subnet struct {
+	Client maas_client.Client
+}
 
-func (Subnet) Register(mcpServer *server.MCPServer) {
+func (s Subnet) Register(mcpServer registry.ToolServer) {
 	mcpTools := []tools.MCPTool{
-		ReadSubnet{},
-		UpdateSubnet{},
-		DeleteSubnet{},
-		SubnetIPAddresses{},
-		SubnetReservedIPRanges{},
-		SubnetStatistics{},
-		SubnetUnreservedIPRanges{},
+		ReadSubnet{Client: s.Client},
+		UpdateSubnet{Client: s.Client},
+		DeleteSubnet{Client: s.Client},
+		SubnetIPAddresses{Client: s.Client},
+		SubnetReservedIPRanges{Client: s.Client},
+		SubnetStatistics{Client: s.Client},
+		SubnetUnreservedIPRanges{Client: s.Client},
 	}
 
 	for _, tool := range mcpTools {
@@ -32,7 +33,9 @@ func (Subnet) Register(mcpServer *server.MCPServer) {
 	}
 }
 
-type ReadSubnet struct{}
+type ReadSubnet struct {
+	Client maas_client.Client
+}
 
 func (ReadSubnet) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -48,28 +51,24 @@ func (ReadSubnet) Create() mcp.Tool {
 	)
 }
 
-func (ReadSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (r ReadSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ReadSubnet] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/subnets/" + subnetID + "/"
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[ReadSubnet] Retrieving subnet with ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[ReadSubnet] Retrieving subnet with ID: %d", subnetID))
+	subnet, err := maas_api.New(r.Client).GetSubnet(ctx, subnetID)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to read subnet %s err=%v", subnetID, err)
+		errMsg = fmt.Sprintf("Failed to read subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[ReadSubnet] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(subnet)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ReadSubnet] %s", errMsg))
@@ -79,7 +78,9 @@ func (ReadSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type UpdateSubnet struct{}
+type UpdateSubnet struct {
+	Client maas_client.Client
+}
 
 func (UpdateSubnet) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -149,83 +150,46 @@ func (UpdateSubnet) Create() mcp.Tool {
 	)
 }
 
-func (UpdateSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (u UpdateSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[UpdateSubnet] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-
-	// Optional parameters - only add if provided
-	if cidr := request.GetString("cidr", ""); cidr != "" {
-		form.Add("cidr", cidr)
-	}
-	if name := request.GetString("name", ""); name != "" {
-		form.Add("name", name)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if vlan := request.GetString("vlan", ""); vlan != "" {
-		form.Add("vlan", vlan)
-	}
-	if fabric := request.GetString("fabric", ""); fabric != "" {
-		form.Add("fabric", fabric)
-	}
-	if vid := request.GetString("vid", ""); vid != "" {
-		form.Add("vid", vid)
-	}
-	if gatewayIP := request.GetString("gateway_ip", ""); gatewayIP != "" {
-		form.Add("gateway_ip", gatewayIP)
-	}
-	if dnsServers := request.GetString("dns_servers", ""); dnsServers != "" {
-		form.Add("dns_servers", dnsServers)
-	}
-	if disabledBootArchs := request.GetString("disabled_boot_architectures", ""); disabledBootArchs != "" {
-		form.Add("disabled_boot_architectures", disabledBootArchs)
-	}
-	if rdnsMode := request.GetString("rdns_mode", ""); rdnsMode != "" {
-		form.Add("rdns_mode", rdnsMode)
-	}
-
-	managed := request.GetBool("managed", false)
-	if managed {
-		form.Add("managed", "1")
-	} else {
-		form.Add("managed", "0")
-	}
-
-	allowDNS := request.GetBool("allow_dns", false)
-	if allowDNS {
-		form.Add("allow_dns", "1")
-	} else {
-		form.Add("allow_dns", "0")
-	}
-
-	allowProxy := request.GetBool("allow_proxy", false)
-	if allowProxy {
-		form.Add("allow_proxy", "1")
-	} else {
-		form.Add("allow_proxy", "0")
+	rdnsMode, err := tools.OptionalInt(request, "rdns_mode")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateSubnet] Invalid parameter rdns_mode err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/subnets/" + subnetID + "/"
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[UpdateSubnet] Updating subnet with ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePut, path, strings.NewReader(form.Encode()))
+	params := maas_api.SubnetParams{
+		CIDR:                      request.GetString("cidr", ""),
+		Name:                      request.GetString("name", ""),
+		Description:               request.GetString("description", ""),
+		VLAN:                      request.GetString("vlan", ""),
+		Fabric:                    request.GetString("fabric", ""),
+		VID:                       request.GetString("vid", ""),
+		GatewayIP:                 request.GetString("gateway_ip", ""),
+		DNSServers:                request.GetString("dns_servers", ""),
+		DisabledBootArchitectures: request.GetString("disabled_boot_architectures", ""),
+		RDNSMode:                  rdnsMode,
+		Managed:                   tools.OptionalBool(request, "managed"),
+		AllowDNS:                  tools.OptionalBool(request, "allow_dns"),
+		AllowProxy:                tools.OptionalBool(request, "allow_proxy"),
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdateSubnet] Updating subnet with ID: %d", subnetID))
+	subnet, err := maas_api.New(u.Client).UpdateSubnet(ctx, subnetID, params)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to update subnet %s err=%v", subnetID, err)
+		errMsg = fmt.Sprintf("Failed to update subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[UpdateSubnet] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(subnet)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[UpdateSubnet] %s", errMsg))
@@ -235,7 +199,9 @@ func (UpdateSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type DeleteSubnet struct{}
+type DeleteSubnet struct {
+	Client maas_client.Client
+}
 
 func (DeleteSubnet) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -251,38 +217,28 @@ func (DeleteSubnet) Create() mcp.Tool {
 	)
 }
 
-func (DeleteSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DeleteSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[DeleteSubnet] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/subnets/" + subnetID + "/"
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[DeleteSubnet] Deleting subnet with ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeDelete, path, nil)
-	if err != nil {
-		errMsg = fmt.Sprintf("Failed to delete subnet %s err=%v", subnetID, err)
+	zap.L().Info(fmt.Sprintf("[DeleteSubnet] Deleting subnet with ID: %d", subnetID))
+	if err := maas_api.New(d.Client).DeleteSubnet(ctx, subnetID); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[DeleteSubnet] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
-	if err != nil {
-		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
-		zap.L().Error(fmt.Sprintf("[DeleteSubnet] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Subnet %d deleted", subnetID)), nil
 }
 
-type SubnetIPAddresses struct{}
+type SubnetIPAddresses struct {
+	Client maas_client.Client
+}
 
 func (SubnetIPAddresses) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -308,10 +264,10 @@ func (SubnetIPAddresses) Create() mcp.Tool {
 	)
 }
 
-func (SubnetIPAddresses) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s SubnetIPAddresses) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[SubnetIPAddresses] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
@@ -320,22 +276,15 @@ func (SubnetIPAddresses) Handle(ctx context.Context, request mcp.CallToolRequest
 	withUsername := request.GetBool("with_username", true)
 	withSummary := request.GetBool("with_summary", true)
 
-	path := fmt.Sprintf("/MAAS/api/2.0/subnets/%s/op-ip_addresses?with_username=%d&with_summary=%d",
-		subnetID,
-		boolToInt(withUsername),
-		boolToInt(withSummary))
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[SubnetIPAddresses] Retrieving IP addresses for subnet ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[SubnetIPAddresses] Retrieving IP addresses for subnet ID: %d", subnetID))
+	addresses, err := maas_api.New(s.Client).GetSubnetIPAddresses(ctx, subnetID, withUsername, withSummary)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to get IP addresses for subnet %s err=%v", subnetID, err)
+		errMsg = fmt.Sprintf("Failed to get IP addresses for subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[SubnetIPAddresses] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(addresses)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[SubnetIPAddresses] %s", errMsg))
@@ -345,7 +294,9 @@ func (SubnetIPAddresses) Handle(ctx context.Context, request mcp.CallToolRequest
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type SubnetReservedIPRanges struct{}
+type SubnetReservedIPRanges struct {
+	Client maas_client.Client
+}
 
 func (SubnetReservedIPRanges) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -361,28 +312,24 @@ func (SubnetReservedIPRanges) Create() mcp.Tool {
 	)
 }
 
-func (SubnetReservedIPRanges) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s SubnetReservedIPRanges) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[SubnetReservedIPRanges] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/subnets/" + subnetID + "/op-reserved_ip_ranges"
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[SubnetReservedIPRanges] Retrieving reserved IP ranges for subnet ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[SubnetReservedIPRanges] Retrieving reserved IP ranges for subnet ID: %d", subnetID))
+	ranges, err := maas_api.New(s.Client).GetSubnetReservedIPRanges(ctx, subnetID)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to get reserved IP ranges for subnet %s err=%v", subnetID, err)
+		errMsg = fmt.Sprintf("Failed to get reserved IP ranges for subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[SubnetReservedIPRanges] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(ranges)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[SubnetReservedIPRanges] %s", errMsg))
@@ -392,7 +339,9 @@ func (SubnetReservedIPRanges) Handle(ctx context.Context, request mcp.CallToolRe
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type SubnetStatistics struct{}
+type SubnetStatistics struct {
+	Client maas_client.Client
+}
 
 func (SubnetStatistics) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -418,10 +367,10 @@ func (SubnetStatistics) Create() mcp.Tool {
 	)
 }
 
-func (SubnetStatistics) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s SubnetStatistics) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[SubnetStatistics] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
@@ -430,22 +379,15 @@ func (SubnetStatistics) Handle(ctx context.Context, request mcp.CallToolRequest)
 	includeRanges := request.GetBool("include_ranges", false)
 	includeSuggestions := request.GetBool("include_suggestions", false)
 
-	path := fmt.Sprintf("/MAAS/api/2.0/subnets/%s/op-statistics?include_ranges=%d&include_suggestions=%d",
-		subnetID,
-		boolToInt(includeRanges),
-		boolToInt(includeSuggestions))
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[SubnetStatistics] Retrieving statistics for subnet ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[SubnetStatistics] Retrieving statistics for subnet ID: %d", subnetID))
+	statistics, err := maas_api.New(s.Client).GetSubnetStatistics(ctx, subnetID, includeRanges, includeSuggestions)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to get statistics for subnet %s err=%v", subnetID, err)
+		errMsg = fmt.Sprintf("Failed to get statistics for subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[SubnetStatistics] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(statistics)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[SubnetStatistics] %s", errMsg))
@@ -455,7 +397,9 @@ func (SubnetStatistics) Handle(ctx context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type SubnetUnreservedIPRanges struct{}
+type SubnetUnreservedIPRanges struct {
+	Client maas_client.Client
+}
 
 func (SubnetUnreservedIPRanges) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -471,28 +415,24 @@ func (SubnetUnreservedIPRanges) Create() mcp.Tool {
 	)
 }
 
-func (SubnetUnreservedIPRanges) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s SubnetUnreservedIPRanges) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	subnetID, err := request.RequireString("id")
+	subnetID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[SubnetUnreservedIPRanges] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/subnets/" + subnetID + "/op-unreserved_ip_ranges"
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[SubnetUnreservedIPRanges] Retrieving unreserved IP ranges for subnet ID: %s", subnetID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[SubnetUnreservedIPRanges] Retrieving unreserved IP ranges for subnet ID: %d", subnetID))
+	ranges, err := maas_api.New(s.Client).GetSubnetUnreservedIPRanges(ctx, subnetID)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to get unreserved IP ranges for subnet %s err=%v", subnetID, err)
+		errMsg = fmt.Sprintf("Failed to get unreserved IP ranges for subnet %d err=%v", subnetID, err)
 		zap.L().Error(fmt.Sprintf("[SubnetUnreservedIPRanges] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(ranges)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[SubnetUnreservedIPRanges] %s", errMsg))
@@ -501,10 +441,3 @@ func (SubnetUnreservedIPRanges) Handle(ctx context.Context, request mcp.CallTool
 
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
-
-func boolToInt(b bool) int {
-	if b {
-		return 1
-	}
-	return 0
-}
@@ -4,27 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Subnets struct{}
+type Subnets struct {
+	Client maas_client.Client
+}
 
-func (Subnets) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{ListSubnets{}, CreateSubnet{}}
+func (s Subnets) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListSubnets{Client: s.Client}, CreateSubnet{Client: s.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListSubnets struct{}
+type ListSubnets struct {
+	Client maas_client.Client
+}
 
 func (ListSubnets) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -35,21 +38,18 @@ func (ListSubnets) Create() mcp.Tool {
 	)
 }
 
-func (ListSubnets) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListSubnets) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/subnets/"
-
-	client := maas_client.MustClient()
 
 	zap.L().Info("[ListSubnets] Retrieving all subnets...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	subnets, err := maas_api.New(l.Client).ListSubnets(ctx)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve all the subnets: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListSubnets] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(subnets)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListSubnets] %s", errMsg))
@@ -59,12 +59,14 @@ func (ListSubnets) Handle(ctx context.Context, request mcp.CallToolRequest) (*mc
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type CreateSubnet struct{}
+type CreateSubnet struct {
+	Client maas_client.Client
+}
 
 func (CreateSubnet) Create() mcp.Tool {
 	return mcp.NewTool(
 		"create-subnet",
-		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Subnet", false, true, false, true)),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Subnet", false, false, false, true)),
 		mcp.WithString(
 			"cidr",
 			mcp.Required(),
@@ -111,9 +113,8 @@ func (CreateSubnet) Create() mcp.Tool {
 	)
 }
 
-func (CreateSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c CreateSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/subnets/"
 
 	cidr, err := request.RequireString("cidr")
 	if err != nil {
@@ -121,52 +122,29 @@ func (CreateSubnet) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-	form.Add("cidr", cidr)
-
-	if name := request.GetString("name", ""); name != "" {
-		form.Add("name", name)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if vlan := request.GetString("vlan", ""); vlan != "" {
-		form.Add("vlan", vlan)
-	}
-	if fabric := request.GetString("fabric", ""); fabric != "" {
-		form.Add("fabric", fabric)
-	}
-	if vid := request.GetString("vid", ""); vid != "" {
-		form.Add("vid", vid)
-	}
-	if space := request.GetString("space", ""); space != "" {
-		form.Add("space", space)
-	}
-	if gatewayIP := request.GetString("gateway_ip", ""); gatewayIP != "" {
-		form.Add("gateway_ip", gatewayIP)
-	}
-	if dnsServers := request.GetString("dns_servers", ""); dnsServers != "" {
-		form.Add("dns_servers", dnsServers)
-	}
-
 	managed := request.GetBool("managed", true)
-	if managed {
-		form.Add("managed", "1")
-	} else {
-		form.Add("managed", "0")
+	params := maas_api.SubnetParams{
+		CIDR:        cidr,
+		Name:        request.GetString("name", ""),
+		Description: request.GetString("description", ""),
+		VLAN:        request.GetString("vlan", ""),
+		Fabric:      request.GetString("fabric", ""),
+		VID:         request.GetString("vid", ""),
+		Space:       request.GetString("space", ""),
+		GatewayIP:   request.GetString("gateway_ip", ""),
+		DNSServers:  request.GetString("dns_servers", ""),
+		Managed:     &managed,
 	}
 
-	client := maas_client.MustClient()
-
 	zap.L().Info(fmt.Sprintf("[CreateSubnet] Creating subnet with CIDR: %s", cidr))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	subnet, err := maas_api.New(c.Client).CreateSubnet(ctx, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to create subnet err=%v", err)
 		zap.L().Error(fmt.Sprintf("[CreateSubnet] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(subnet)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[CreateSubnet] %s", errMsg))
@@ -0,0 +1,128 @@
+package subnets
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestSubnetTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments func(id string) map[string]any
+		isError   bool
+		expected  []string
+	}{
+		{
+			name:      "list subnets",
+			tool:      "list-subnets",
+			arguments: func(id string) map[string]any { return map[string]any{} },
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "create subnet",
+			tool:      "create-subnet",
+			arguments: func(id string) map[string]any { return map[string]any{"cidr": "10.1.0.0/24", "gateway_ip": "10.1.0.1"} },
+			expected:  []string{"10.0.0.0/24", "10.1.0.0/24"},
+		},
+		{
+			name:      "create subnet with invalid cidr",
+			tool:      "create-subnet",
+			arguments: func(id string) map[string]any { return map[string]any{"cidr": "10.1.0.1/24"} },
+			isError:   true,
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "read subnet",
+			tool:      "read-subnet",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "update subnet gateway outside the cidr",
+			tool:      "update-subnet",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id, "gateway_ip": "192.168.0.1"} },
+			isError:   true,
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "delete subnet",
+			tool:      "delete-subnet",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{},
+		},
+		{
+			name:      "ip addresses",
+			tool:      "subnet-ip-addresses",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "reserved ranges",
+			tool:      "subnet-reserved-ip-ranges",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "unreserved ranges",
+			tool:      "subnet-unreserved-ip-ranges",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id} },
+			expected:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "statistics",
+			tool:      "subnet-statistics",
+			arguments: func(id string) map[string]any { return map[string]any{"id": id, "include_ranges": true} },
+			expected:  []string{"10.0.0.0/24"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			subnet := fake.AddSubnet(fakemaas.Subnet{CIDR: "10.0.0.0/24", GatewayIP: "10.0.0.1"})
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Subnets{Client: fake.Client()}, Subnet{Client: fake.Client()})[tc.tool], tc.arguments(strconv.Itoa(subnet.ID)))
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			cidrs := []string{}
+			for _, s := range fake.Subnets() {
+				cidrs = append(cidrs, s.CIDR)
+			}
+			if !slices.Equal(cidrs, tc.expected) {
+				t.Errorf("expected subnets %v, got %v", tc.expected, cidrs)
+			}
+		})
+	}
+}
+
+func TestSubnetUnreservedIPRanges(t *testing.T) {
+	// Arrange
+	fake := fakemaas.Start(t)
+	subnet := fake.AddSubnet(fakemaas.Subnet{
+		CIDR:      "10.0.0.0/24",
+		GatewayIP: "10.0.0.1",
+		IPRanges:  []fakemaas.IPRange{{Type: "dynamic", StartIP: "10.0.0.100", EndIP: "10.0.0.254"}},
+	})
+
+	// Act
+	result := fakemaas.CallTool(t, SubnetUnreservedIPRanges{Client: fake.Client()}.Handle, map[string]any{"id": strconv.Itoa(subnet.ID)})
+
+	// Assert
+	var ranges []map[string]any
+	if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &ranges); err != nil {
+		t.Fatalf("expected a list of ranges, got %v", err)
+	}
+	if len(ranges) != 1 || ranges[0]["start"] != "10.0.0.2" || ranges[0]["end"] != "10.0.0.99" {
+		t.Errorf("unexpected ranges %v", ranges)
+	}
+}
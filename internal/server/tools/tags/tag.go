@@ -4,27 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
+	"slices"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Tag struct{}
+type Tag struct {
+	Client maas_client.Client
+}
 
-func (Tag) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{DeleteTag{}, ReadTag{}, UpdateTag{}, ListByTag{}}
+func (t Tag) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteTag{Client: t.Client}, ReadTag{Client: t.Client}, UpdateTag{Client: t.Client}, ListByTag{Client: t.Client}, UpdateTagNodes{Client: t.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type DeleteTag struct{}
+type DeleteTag struct {
+	Client maas_client.Client
+}
 
 func (DeleteTag) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -39,7 +43,7 @@ func (DeleteTag) Create() mcp.Tool {
 	)
 }
 
-func (DeleteTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DeleteTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	name, err := request.RequireString("name")
@@ -48,28 +52,18 @@ func (DeleteTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/tags/" + name + "/"
-
-	client := maas_client.MustClient()
-
-	resultData, err := client.Do(ctx, maas_client.RequestTypeDelete, path, nil)
-	if err != nil {
+	if err := maas_api.New(d.Client).DeleteTag(ctx, name); err != nil {
 		errMsg = fmt.Sprintf("Failed to delete tag %s err=%v", name, err)
 		zap.L().Error(fmt.Sprintf("[DeleteTag] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
-	if err != nil {
-		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
-		zap.L().Error(fmt.Sprintf("[DeleteTag] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Tag %s deleted", name)), nil
 }
 
-type ReadTag struct{}
+type ReadTag struct {
+	Client maas_client.Client
+}
 
 func (ReadTag) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -84,7 +78,7 @@ func (ReadTag) Create() mcp.Tool {
 	)
 }
 
-func (ReadTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (r ReadTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	name, err := request.RequireString("name")
@@ -93,18 +87,14 @@ func (ReadTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := "/MAAS/api/2.0/tags/" + name + "/"
-
-	client := maas_client.MustClient()
-
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	tag, err := maas_api.New(r.Client).GetTag(ctx, name)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to read tag %s err=%v", name, err)
 		zap.L().Error(fmt.Sprintf("[ReadTag] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(tag)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ReadTag] %s", errMsg))
@@ -114,7 +104,9 @@ func (ReadTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type UpdateTag struct{}
+type UpdateTag struct {
+	Client maas_client.Client
+}
 
 func (UpdateTag) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -136,58 +128,46 @@ func (UpdateTag) Create() mcp.Tool {
 			"definition",
 			mcp.Description("An XPATH query that is evaluated against the hardware_details stored for all nodes (i.e. the output of `lshw -xml`)."),
 		),
-		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Tag", false, true, false, true)),
-		mcp.WithDescription("Return information about a specified tag by name."),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update Tag", false, false, false, true)),
+		mcp.WithDescription("Update the name, comment or definition of a specified tag by name."),
 	)
 }
 
-func (UpdateTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (u UpdateTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	name, err := request.RequireString("name")
 	if err != nil {
-		zap.L().Error(fmt.Sprintf("[ReadTag] Required parameter name not present err=%v", err))
+		zap.L().Error(fmt.Sprintf("[UpdateTag] Required parameter name not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-	newName := request.GetString("new_name", "")
-	if newName != "" {
-		form.Add("name", newName)
+	params := maas_api.TagParams{
+		Name:       request.GetString("new_name", ""),
+		Comment:    request.GetString("comment", ""),
+		Definition: request.GetString("definition", ""),
 	}
 
-	comment := request.GetString("comment", "")
-	if newName != "" {
-		form.Add("comment", comment)
-	}
-
-	definition := request.GetString("definition", "")
-	if newName != "" {
-		form.Add("definition", definition)
-	}
-
-	path := "/MAAS/api/2.0/tags/" + name + "/"
-
-	client := maas_client.MustClient()
-
-	resultData, err := client.Do(ctx, maas_client.RequestTypePut, path, strings.NewReader(form.Encode()))
+	tag, err := maas_api.New(u.Client).UpdateTag(ctx, name, params)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to read tag %s err=%v", name, err)
-		zap.L().Error(fmt.Sprintf("[ReadTag] %s", errMsg))
+		errMsg = fmt.Sprintf("Failed to update tag %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[UpdateTag] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(tag)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
-		zap.L().Error(fmt.Sprintf("[ReadTag] %s", errMsg))
+		zap.L().Error(fmt.Sprintf("[UpdateTag] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type ListByTag struct{}
+type ListByTag struct {
+	Client maas_client.Client
+}
 
 func (ListByTag) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -214,7 +194,7 @@ func (ListByTag) Create() mcp.Tool {
 	)
 }
 
-func (ListByTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListByTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
 	name, err := request.RequireString("name")
@@ -222,7 +202,6 @@ func (ListByTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		zap.L().Error(fmt.Sprintf("[ListByTag] Required parameter name not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	path := "/MAAS/api/2.0/tags/" + name + "/"
 
 	nodeType, err := request.RequireString("type")
 	if err != nil {
@@ -230,29 +209,14 @@ func (ListByTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	switch nodeType {
-	case "nodes":
-		path += "op-nodes"
-	case "devices":
-		path += "op-devices"
-	case "machines":
-		path += "op-machines"
-	case "rack_controllers":
-		path += "op-rack_controllers"
-	case "region_controllers":
-		path += "op-region_controllers"
-	}
-
-	client := maas_client.MustClient()
-
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	nodes, err := maas_api.New(l.Client).ListTaggedNodes(ctx, name, nodeType)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to get elements of type %s for tag %s err=%v", nodeType, name, err)
 		zap.L().Error(fmt.Sprintf("[ListByTag] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(nodes)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListByTag] %s", errMsg))
@@ -261,3 +225,86 @@ func (ListByTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
+
+type UpdateTagNodes struct {
+	Client maas_client.Client
+}
+
+func (UpdateTagNodes) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-tag-nodes",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the tag to add or remove."),
+		),
+		mcp.WithArray(
+			"add",
+			mcp.WithStringItems(mcp.Pattern("^[0-9a-z]{6}$")),
+			mcp.Description("The ids of the machines to add the tag to."),
+		),
+		mcp.WithArray(
+			"remove",
+			mcp.WithStringItems(mcp.Pattern("^[0-9a-z]{6}$")),
+			mcp.Description("The ids of the machines to remove the tag from."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update Tag Nodes", false, false, true, true)),
+		mcp.WithDescription("Add a tag to machines or remove it from them. Protected machines and the protected tag itself are refused."),
+	)
+}
+
+func (u UpdateTagNodes) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateTagNodes] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	add := request.GetStringSlice("add", nil)
+	remove := request.GetStringSlice("remove", nil)
+
+	if len(add) == 0 && len(remove) == 0 {
+		errMsg = "Either add or remove is required"
+		zap.L().Error(fmt.Sprintf("[UpdateTagNodes] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if name == maas_api.ProtectedTag {
+		zap.L().Warn("[UpdateTagNodes] Refusing to change the machines of the protected tag")
+		return mcp.NewToolResultError("The protected tag cannot be changed"), nil
+	}
+
+	api := maas_api.New(u.Client)
+
+	machines, err := api.ListMachines(ctx, maas_api.MachineFilter{SystemIDs: slices.Concat(add, remove)})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machines err=%v", err)
+		zap.L().Error(fmt.Sprintf("[UpdateTagNodes] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	for _, machine := range machines {
+		if machine.Protected() {
+			zap.L().Warn(fmt.Sprintf("[UpdateTagNodes] Refusing to act on protected machine %s", machine.SystemID))
+			return mcp.NewToolResultError(fmt.Sprintf("Machine %s is protected and cannot be accessed", machine.SystemID)), nil
+		}
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdateTagNodes] Updating the machines of tag %s...", name))
+	update, err := api.UpdateTagNodes(ctx, name, add, remove)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to update the machines of tag %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[UpdateTagNodes] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(update)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdateTagNodes] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -4,27 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Tags struct{}
+type Tags struct {
+	Client maas_client.Client
+}
 
-func (Tags) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{ListTags{}, CreateTag{}}
+func (t Tags) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListTags{Client: t.Client}, CreateTag{Client: t.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListTags struct{}
+type ListTags struct {
+	Client maas_client.Client
+}
 
 func (ListTags) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -35,20 +38,17 @@ func (ListTags) Create() mcp.Tool {
 	)
 }
 
-func (ListTags) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListTags) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/tags/"
 
-	client := maas_client.MustClient()
-
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	tags, err := maas_api.New(l.Client).ListTags(ctx)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve all the tags: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListTags] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(tags)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListTags] %s", errMsg))
@@ -58,12 +58,14 @@ func (ListTags) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type CreateTag struct{}
+type CreateTag struct {
+	Client maas_client.Client
+}
 
 func (CreateTag) Create() mcp.Tool {
 	return mcp.NewTool(
 		"create-tag",
-		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Tag", true, false, false, true)),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Tag", false, false, false, true)),
 		mcp.WithString(
 			"name",
 			mcp.Required(),
@@ -86,9 +88,8 @@ func (CreateTag) Create() mcp.Tool {
 	)
 }
 
-func (CreateTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c CreateTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
-	path := "/MAAS/api/2.0/tags/"
 
 	name, err := request.RequireString("name")
 	if err != nil {
@@ -102,25 +103,21 @@ func (CreateTag) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	definition := request.GetString("definition", "")
-	kernelOpts := request.GetString("kernel_opts", "")
-
-	form := make(url.Values)
-	form.Add("name", name)
-	form.Add("comment", comment)
-	form.Add("definition", definition)
-	form.Add("kernel_opts", kernelOpts)
-
-	client := maas_client.MustClient()
+	params := maas_api.TagParams{
+		Name:       name,
+		Comment:    comment,
+		Definition: request.GetString("definition", ""),
+		KernelOpts: request.GetString("kernel_opts", ""),
+	}
 
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	tag, err := maas_api.New(c.Client).CreateTag(ctx, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to create tag err=%v", err)
 		zap.L().Error(fmt.Sprintf("[CreateTag] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(tag)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[CreateTag] %s", errMsg))
@@ -0,0 +1,90 @@
+package tags
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+)
+
+func TestTagTools(t *testing.T) {
+	cases := []struct {
+		name        string
+		tool        string
+		arguments   map[string]any
+		isError     bool
+		tags        []string
+		machineTags []string
+	}{
+		{"list tags", "read-tags", map[string]any{}, false, []string{"gpu"}, []string{"gpu"}},
+		{"create tag", "create-tag", map[string]any{"name": "nvme", "comment": "Fast disks"}, false, []string{"gpu", "nvme"}, []string{"gpu"}},
+		{"create invalid tag", "create-tag", map[string]any{"name": "has space", "comment": "Invalid"}, true, []string{"gpu"}, []string{"gpu"}},
+		{"read tag", "read-tag", map[string]any{"name": "gpu"}, false, []string{"gpu"}, []string{"gpu"}},
+		{"read unknown tag", "read-tag", map[string]any{"name": "fpga"}, true, []string{"gpu"}, []string{"gpu"}},
+		{"rename tag", "update-tag", map[string]any{"name": "gpu", "new_name": "cuda"}, false, []string{"cuda"}, []string{"cuda"}},
+		{"list machines by tag", "list-by-tag", map[string]any{"name": "gpu", "type": "machines"}, false, []string{"gpu"}, []string{"gpu"}},
+		{"tag machines with an unknown tag", "update-tag-nodes", map[string]any{"name": "nvme", "add": []any{"abc123"}}, true, []string{"gpu"}, []string{"gpu"}},
+		{"untag machines", "update-tag-nodes", map[string]any{"name": "gpu", "remove": []any{"abc123"}}, false, []string{"gpu"}, []string{}},
+		{"delete tag", "delete-tag", map[string]any{"name": "gpu"}, false, []string{}, []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddTag(fakemaas.Tag{Name: "gpu", Comment: "Has a GPU"})
+			m := fake.AddMachine(fakemaas.Machine{SystemID: "abc123", TagNames: []string{"gpu"}})
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Tags{Client: fake.Client()}, Tag{Client: fake.Client()})[tc.tool], tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			tags := []string{}
+			for _, tag := range fake.Tags() {
+				tags = append(tags, tag.Name)
+			}
+			if !slices.Equal(tags, tc.tags) {
+				t.Errorf("expected tags %v, got %v", tc.tags, tags)
+			}
+			if got, _ := fake.Machine(m.SystemID); !slices.Equal(append([]string{}, got.TagNames...), tc.machineTags) {
+				t.Errorf("expected machine tags %v, got %v", tc.machineTags, got.TagNames)
+			}
+		})
+	}
+
+	t.Run("refuses protected machines", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		fake.AddTag(fakemaas.Tag{Name: "gpu"})
+		m := fake.AddMachine(fakemaas.Machine{TagNames: []string{maas_api.ProtectedTag}})
+
+		// Act
+		result := fakemaas.CallTool(t, UpdateTagNodes{Client: fake.Client()}.Handle, map[string]any{"name": "gpu", "add": []any{m.SystemID}})
+
+		// Assert
+		if !result.IsError {
+			t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+		if got, _ := fake.Machine(m.SystemID); slices.Contains(got.TagNames, "gpu") {
+			t.Errorf("expected the protected machine not to be tagged, got %v", got.TagNames)
+		}
+	})
+
+	t.Run("update only sends the given fields", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		fake.AddTag(fakemaas.Tag{Name: "gpu", Comment: "Has a GPU"})
+
+		// Act
+		fakemaas.CallTool(t, UpdateTag{Client: fake.Client()}.Handle, map[string]any{"name": "gpu", "comment": "Has a CUDA GPU"})
+
+		// Assert
+		if tags := fake.Tags(); tags[0].Comment != "Has a CUDA GPU" {
+			t.Errorf("expected the comment to be updated, got %q", tags[0].Comment)
+		}
+	})
+}
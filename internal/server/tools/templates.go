@@ -5,15 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/templates"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
 type Templates struct{}
 
-func (Templates) Register(mcpServer *server.MCPServer) {
+func (Templates) Register(mcpServer registry.ToolServer) {
 	mcpTools := []MCPTool{RetrieveTemplates{}, RetrieveTemplateContents{}, RetrieveTemplateById{}, CreateTemplate{}, DeleteTemplate{}}
 
 	for _, tool := range mcpTools {
@@ -31,6 +31,7 @@ func (RetrieveTemplates) Create() mcp.Tool {
 			mcp.DefaultBool(false),
 			mcp.Description("If true return only the ids of the templates."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Retrieve Templates", true, false, false, false)),
 		mcp.WithDescription("Returns all deployment Cloud-Init templates that are available on the system."),
 	)
 }
@@ -77,6 +78,7 @@ func (RetrieveTemplateById) Create() mcp.Tool {
 			mcp.Pattern("^[a-z0-9_-]*$"),
 			mcp.Description("The id of the template to retrieve."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Retrieve Template", true, false, false, false)),
 		mcp.WithDescription("Return the information about a particular template specified by ID."),
 	)
 }
@@ -121,6 +123,7 @@ func (RetrieveTemplateContents) Create() mcp.Tool {
 			mcp.Pattern("^[a-z0-9_-]*$"),
 			mcp.Description("The id of the template to retrieve the contents for."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Retrieve Template Content", true, false, false, false)),
 		mcp.WithDescription("Return contents of a particular template specified by ID."),
 	)
 }
@@ -153,6 +156,7 @@ func (CreateTemplate) Create() mcp.Tool {
 	return mcp.NewTool(
 		"create-template",
 		mcp.WithInputSchema[templates.GenericTemplate](),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Create Template", false, false, false, false)),
 		mcp.WithDescription("Create and add a new template based on the html template files required: description.json and template.yaml."),
 	)
 }
@@ -197,6 +201,7 @@ func (DeleteTemplate) Create() mcp.Tool {
 			mcp.Pattern("^[0-9a-z_-]+$"),
 			mcp.Description("The id of the template to be deleted."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Delete Template", false, true, false, false)),
 		mcp.WithDescription("Delete the templated specified by the id."),
 	)
 }
@@ -20,3 +20,27 @@ func CreateToolAnnotation(title string, readOnly, destructive, idempotency, open
 		OpenWorldHint:   mcp.ToBoolPtr(openWorld),
 	}
 }
+
+// OptionalInt returns the integer argument key, or nil when the call left it
+// out or gave it as an empty string.
+func OptionalInt(request mcp.CallToolRequest, key string) (*int, error) {
+	if value, ok := request.GetArguments()[key]; !ok || value == "" {
+		return nil, nil
+	}
+
+	value, err := request.RequireInt(key)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// OptionalBool returns the boolean argument key, or nil when the call left it out.
+func OptionalBool(request mcp.CallToolRequest, key string) *bool {
+	if _, ok := request.GetArguments()[key]; !ok {
+		return nil
+	}
+
+	value := request.GetBool(key, false)
+	return &value
+}
@@ -26,6 +26,7 @@ type Machine struct {
 	Gateway       string      `json:"gateway,omitempty"`
 	BootInterface *Interface  `json:"boot_interface,omitempty"`
 	Interfaces    []Interface `json:"interfaces,omitempty"`
+	DNSRecords    []DNSRecord `json:"dns_records,omitempty"`
 
 	// Storage
 	BootDisk *BlockDevice  `json:"boot_disk,omitempty"`
@@ -35,6 +36,7 @@ type Machine struct {
 	Zone     string   `json:"zone"`
 	Pool     string   `json:"pool"`
 	TagNames []string `json:"tags,omitempty"`
+	Region   string   `json:"region,omitempty"`
 }
 
 type Interface struct {
@@ -60,6 +62,16 @@ type Partition struct {
 	MountPoint string `json:"mount_point,omitempty"`
 }
 
+type VirtualMachine struct {
+	Hostname         string  `json:"hostname"`
+	VirtualMachineID int     `json:"virtualmachine_id"`
+	SystemID         string  `json:"system_id"`
+	Status           string  `json:"status"`
+	CPUCount         int     `json:"cpu_count"`
+	Memory           int     `json:"memory"`  // in MB
+	Storage          float64 `json:"storage"` // in MB
+}
+
 type CommissioningScript struct {
 	ID           int    `json:"id"`
 	Name         string `json:"name"`
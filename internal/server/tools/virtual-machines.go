@@ -4,52 +4,54 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
 const NUMBER_PATTERN = "^[0-9]+$"
 
-type VMHosts struct{}
+type VMHosts struct {
+	Client maas_client.Client
+}
 
-func (VMHosts) Register(mcpServer *server.MCPServer) {
-	mcpTools := []MCPTool{ListVMHosts{}, ListVMHost{}, ListVirtualMachines{}, ComposeVM{}}
+func (v VMHosts) Register(mcpServer registry.ToolServer) {
+	mcpTools := []MCPTool{ListVMHosts{Client: v.Client}, ListVMHost{Client: v.Client}, ListVirtualMachines{Client: v.Client}, ComposeVM{Client: v.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListVMHosts struct{}
+type ListVMHosts struct {
+	Client maas_client.Client
+}
 
 func (ListVMHosts) Create() mcp.Tool {
 	return mcp.NewTool(
 		"list-vm-hosts",
+		mcp.WithToolAnnotation(CreateToolAnnotation("List VM Hosts", true, false, false, true)),
 		mcp.WithDescription("Returns the available VM hosts from the ZTP agent conected."),
 	)
 }
 
-func (ListVMHosts) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListVMHosts) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	path := "/MAAS/api/2.0/vm-hosts/"
-
-	client := maas_client.MustClient()
-
 	zap.L().Info("[ListVMHosts] Retrieving all VM hosts...")
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	hosts, err := maas_api.New(l.Client).ListVMHosts(ctx)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to retrieve the VM hosts: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListVMHosts] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(hosts)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListVMHosts] %s", errMsg))
@@ -59,7 +61,9 @@ func (ListVMHosts) Handle(ctx context.Context, request mcp.CallToolRequest) (*mc
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type ListVMHost struct{}
+type ListVMHost struct {
+	Client maas_client.Client
+}
 
 func (ListVMHost) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -70,32 +74,29 @@ func (ListVMHost) Create() mcp.Tool {
 			mcp.Description("The ID of the VM host to query information for."),
 			mcp.Pattern(NUMBER_PATTERN),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("List VM Host", true, false, false, true)),
 		mcp.WithDescription("Returns information about a particular VM host specified by id on the ZTP agent conected."),
 	)
 }
 
-func (ListVMHost) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListVMHost) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	vmID, err := request.RequireString("id")
+	vmID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ListVMHost] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/vm-hosts/%s/", vmID)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[ListVMHost] Retrieving VM host with ID %s...", vmID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[ListVMHost] Retrieving VM host with ID %d...", vmID))
+	host, err := maas_api.New(l.Client).GetVMHost(ctx, vmID)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to retreive VM host with ID %s, err=%v", vmID, err)
+		errMsg = fmt.Sprintf("Failed to retreive VM host with ID %d, err=%v", vmID, err)
 		zap.L().Error(fmt.Sprintf("[ListVMHost] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(host)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListVMHost] %s", errMsg))
@@ -105,7 +106,9 @@ func (ListVMHost) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type ComposeVM struct{}
+type ComposeVM struct {
+	Client maas_client.Client
+}
 
 func (ComposeVM) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -140,26 +143,27 @@ func (ComposeVM) Create() mcp.Tool {
 			mcp.Description("The name of the created VM (Give something random if not provided)."),
 			mcp.Pattern("^[a-zA-Z0-9.-]+$"),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("Compose VM", false, false, false, true)),
 		mcp.WithDescription("Compose a VM on a particular VM host specified by ID."),
 	)
 }
 
-func (ComposeVM) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c ComposeVM) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	vmHostID, err := request.RequireString("id")
+	vmHostID, err := request.RequireInt("id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ComposeVM] Required parameter id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	cores, err := request.RequireString("cores")
+	cores, err := request.RequireInt("cores")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ComposeVM] Required parameter cores not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	memory, err := request.RequireString("memory")
+	memory, err := request.RequireInt("memory")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ComposeVM] Required parameter memory not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
@@ -177,25 +181,22 @@ func (ComposeVM) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-	form.Set("cores", cores)
-	form.Set("memory", memory)
-	form.Set("storage", storage)
-	form.Set("hostname", hostname)
-
-	path := fmt.Sprintf("/MAAS/api/2.0/vm-hosts/%s/op-compose", vmHostID)
-
-	client := maas_client.MustClient()
+	params := maas_api.ComposeParams{
+		Cores:    cores,
+		Memory:   memory,
+		Storage:  storage,
+		Hostname: hostname,
+	}
 
-	zap.L().Info(fmt.Sprintf("[ComposeVM] Composing VM on host %s with the following configuration:\nCores: %s\nMemory: %s\nStorage: %s\nHostname: %s", vmHostID, cores, memory, storage, hostname))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	zap.L().Info(fmt.Sprintf("[ComposeVM] Composing VM on host %d with the following configuration:\nCores: %d\nMemory: %d\nStorage: %s\nHostname: %s", vmHostID, cores, memory, storage, hostname))
+	machine, err := maas_api.New(c.Client).ComposeVM(ctx, vmHostID, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to compose VM err=%v", err)
 		zap.L().Error(fmt.Sprintf("[ComposeVM] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(machine)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ComposeVM] %s", errMsg))
@@ -205,7 +206,9 @@ func (ComposeVM) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type ListVirtualMachines struct{}
+type ListVirtualMachines struct {
+	Client maas_client.Client
+}
 
 func (ListVirtualMachines) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -220,13 +223,12 @@ func (ListVirtualMachines) Create() mcp.Tool {
 			mcp.DefaultBool(true),
 			mcp.Description("If true return all virtual machines and ignore vm-host-id."),
 		),
+		mcp.WithToolAnnotation(CreateToolAnnotation("List Virtual Machines", true, false, false, true)),
 		mcp.WithDescription("Retrieve all the virtual machines from a specified VM host or all of them."),
 	)
 }
 
 func (l ListVirtualMachines) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var path string
-	var machines []map[string]any
 	vmHostId := request.GetString("vm-host-id", "")
 	all := request.GetBool("all", true)
 
@@ -234,19 +236,11 @@ func (l ListVirtualMachines) Handle(ctx context.Context, request mcp.CallToolReq
 		return mcp.NewToolResultError("vm-host-id is required when all=false"), nil
 	}
 
-	client := maas_client.MustClient()
-	path = "/MAAS/api/2.0/machines/"
-
-	machinesRaw, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	machines, err := maas_api.New(l.Client).ListMachines(ctx, maas_api.MachineFilter{})
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to retrieve machines from the MAAS host; err=%s", err.Error())), nil
 	}
 
-	err = json.Unmarshal([]byte(machinesRaw), &machines)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to unmarshal machines result; err=%s", err.Error())), nil
-	}
-
 	virtualMachines := l.parseMachineList(machines)
 	var output any
 	if all {
@@ -255,7 +249,7 @@ func (l ListVirtualMachines) Handle(ctx context.Context, request mcp.CallToolReq
 		if vms, ok := virtualMachines[vmHostId]; ok {
 			output = vms
 		} else {
-			output = []map[string]any{}
+			output = []VirtualMachine{}
 		}
 	}
 
@@ -267,81 +261,25 @@ func (l ListVirtualMachines) Handle(ctx context.Context, request mcp.CallToolReq
 	return mcp.NewToolResultText(string(outputBytes)), nil
 }
 
-func (ListVirtualMachines) parseMachineList(machines []map[string]any) map[string][]map[string]any {
-	output := make(map[string][]map[string]any)
+// parseMachineList groups the machines composed on a VM host by the ID of the host.
+func (ListVirtualMachines) parseMachineList(machines []maas_api.Machine) map[string][]VirtualMachine {
+	output := make(map[string][]VirtualMachine)
 	for _, machine := range machines {
-		vmData, podId, ok := extractVMData(machine)
-		if !ok {
+		if machine.VirtualMachineID == nil || machine.Pod == nil {
 			continue
 		}
 
-		output[podId] = append(output[podId], vmData)
+		podId := strconv.Itoa(machine.Pod.ID)
+		output[podId] = append(output[podId], VirtualMachine{
+			Hostname:         machine.Hostname,
+			VirtualMachineID: *machine.VirtualMachineID,
+			SystemID:         machine.SystemID,
+			Status:           strings.ToLower(machine.StatusName),
+			CPUCount:         machine.CPUCount,
+			Memory:           machine.Memory,
+			Storage:          machine.Storage,
+		})
 	}
 
 	return output
 }
-
-func extractVMData(machine map[string]any) (vmData map[string]any, podId string, ok bool) {
-	vmId, ok := machine["virtualmachine_id"]
-	if !ok {
-		return nil, "", false
-	}
-
-	systemId, ok := machine["system_id"]
-	if !ok {
-		return nil, "", false
-	}
-
-	status_name, ok := machine["status_name"]
-	if !ok {
-		return nil, "", false
-	}
-
-	memory, ok := machine["memory"]
-	if !ok {
-		return nil, "", false
-	}
-
-	storage, ok := machine["storage"]
-	if !ok {
-		return nil, "", false
-	}
-	cpuCount, ok := machine["cpu_count"]
-	if !ok {
-		return nil, "", false
-	}
-
-	hostname, ok := machine["hostname"]
-	if !ok {
-		return nil, "", false
-	}
-
-	podRaw, ok := machine["pod"]
-	if !ok {
-		return nil, "", false
-	}
-
-	pod, ok := podRaw.(map[string]any)
-	if !ok {
-		return nil, "", false
-	}
-
-	podIdRaw, ok := pod["id"]
-	if !ok {
-		return nil, "", false
-	}
-
-	podId = fmt.Sprintf("%v", podIdRaw)
-
-	vmData = map[string]any{
-		"hostname":          hostname,
-		"virtualmachine_id": vmId,
-		"system_id":         systemId,
-		"status":            strings.ToLower(status_name.(string)),
-		"cpu_count":         cpuCount,
-		"memory":            memory,
-		"storage":           storage,
-	}
-
-	return vmData, podId, true
-}
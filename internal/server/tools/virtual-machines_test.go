@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestComposeVM(t *testing.T) {
+	cases := []struct {
+		name    string
+		cores   string
+		memory  string
+		isError bool
+	}{
+		{"fits on the host", "2", "4096", false},
+		{"not enough cores", "16", "4096", true},
+		{"not enough memory", "2", "65536", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			host := fake.AddVMHost(fakemaas.VMHost{Cores: 8, Memory: 16384, Storage: 500 * 1000 * 1000 * 1000})
+
+			// Act
+			result := fakemaas.CallTool(t, ComposeVM{Client: fake.Client()}.Handle, map[string]any{
+				"id":       strconv.Itoa(host.ID),
+				"cores":    tc.cores,
+				"memory":   tc.memory,
+				"storage":  "20",
+				"hostname": "vm-1",
+			})
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+		})
+	}
+}
+
+func TestListVirtualMachines(t *testing.T) {
+	fake := fakemaas.Start(t)
+	host := fake.AddVMHost(fakemaas.VMHost{Cores: 8, Memory: 16384, Storage: 500 * 1000 * 1000 * 1000})
+	other := fake.AddVMHost(fakemaas.VMHost{Cores: 8, Memory: 16384, Storage: 500 * 1000 * 1000 * 1000})
+	fake.AddMachine(fakemaas.Machine{Hostname: "bare-metal"})
+	_ = fakemaas.CallTool(t, ComposeVM{Client: fake.Client()}.Handle, map[string]any{"id": strconv.Itoa(host.ID), "cores": "2", "memory": "4096", "storage": "20", "hostname": "vm-1"})
+
+	cases := []struct {
+		name      string
+		arguments map[string]any
+		expected  string
+		isError   bool
+	}{
+		{"all hosts", map[string]any{}, `{"` + strconv.Itoa(host.ID) + `":["vm-1"]}`, false},
+		{"single host", map[string]any{"all": false, "vm-host-id": strconv.Itoa(host.ID)}, `["vm-1"]`, false},
+		{"host without VMs", map[string]any{"all": false, "vm-host-id": strconv.Itoa(other.ID)}, `[]`, false},
+		{"missing host id", map[string]any{"all": false}, "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			result := fakemaas.CallTool(t, ListVirtualMachines{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Fatalf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			if tc.isError {
+				return
+			}
+			if hostnames := vmHostnames(t, fakemaas.ResultText(t, result)); hostnames != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, hostnames)
+			}
+		})
+	}
+}
+
+// vmHostnames reduces a list-virtual-machines result to the VM hostnames.
+func vmHostnames(t *testing.T, text string) string {
+	t.Helper()
+
+	hostnames := func(vms []map[string]any) []string {
+		names := []string{}
+		for _, vm := range vms {
+			names = append(names, vm["hostname"].(string))
+		}
+		return names
+	}
+
+	var reduced any
+	var byHost map[string][]map[string]any
+	if err := json.Unmarshal([]byte(text), &byHost); err == nil {
+		names := map[string][]string{}
+		for id, vms := range byHost {
+			names[id] = hostnames(vms)
+		}
+		reduced = names
+	} else {
+		var vms []map[string]any
+		if err := json.Unmarshal([]byte(text), &vms); err != nil {
+			t.Fatalf("unexpected result %s", text)
+		}
+		reduced = hostnames(vms)
+	}
+
+	encoded, _ := json.Marshal(reduced)
+	return string(encoded)
+}
@@ -4,27 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
-type Vlan struct{}
+type Vlan struct {
+	Client maas_client.Client
+}
 
-func (Vlan) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{DeleteVlan{}, ReadVlan{}, UpdateVlan{}}
+func (v Vlan) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteVlan{Client: v.Client}, ReadVlan{Client: v.Client}, UpdateVlan{Client: v.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type DeleteVlan struct{}
+type DeleteVlan struct {
+	Client maas_client.Client
+}
 
 func (DeleteVlan) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -46,44 +49,34 @@ func (DeleteVlan) Create() mcp.Tool {
 	)
 }
 
-func (DeleteVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (d DeleteVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("fabric_id")
+	fabricID, err := request.RequireInt("fabric_id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[DeleteVlan] Required parameter fabric_id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	vid, err := request.RequireString("vid")
+	vid, err := request.RequireInt("vid")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[DeleteVlan] Required parameter vid not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/vlans/%s/", fabricID, vid)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[DeleteVlan] Deleting VLAN %s on fabric %s", vid, fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeDelete, path, nil)
-	if err != nil {
-		errMsg = fmt.Sprintf("Failed to delete VLAN %s on fabric %s err=%v", vid, fabricID, err)
+	zap.L().Info(fmt.Sprintf("[DeleteVlan] Deleting VLAN %d on fabric %d", vid, fabricID))
+	if err := maas_api.New(d.Client).DeleteVLAN(ctx, fabricID, vid); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete VLAN %d on fabric %d err=%v", vid, fabricID, err)
 		zap.L().Error(fmt.Sprintf("[DeleteVlan] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
-	if err != nil {
-		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
-		zap.L().Error(fmt.Sprintf("[DeleteVlan] %s", errMsg))
-		return mcp.NewToolResultError(errMsg), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonData)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("VLAN %d on fabric %d deleted", vid, fabricID)), nil
 }
 
-type ReadVlan struct{}
+type ReadVlan struct {
+	Client maas_client.Client
+}
 
 func (ReadVlan) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -105,34 +98,30 @@ func (ReadVlan) Create() mcp.Tool {
 	)
 }
 
-func (ReadVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (r ReadVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("fabric_id")
+	fabricID, err := request.RequireInt("fabric_id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ReadVlan] Required parameter fabric_id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	vid, err := request.RequireString("vid")
+	vid, err := request.RequireInt("vid")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ReadVlan] Required parameter vid not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/vlans/%s/", fabricID, vid)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[ReadVlan] Retrieving VLAN %s on fabric %s", vid, fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[ReadVlan] Retrieving VLAN %d on fabric %d", vid, fabricID))
+	vlan, err := maas_api.New(r.Client).GetVLAN(ctx, fabricID, vid)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to read VLAN %s on fabric %s err=%v", vid, fabricID, err)
+		errMsg = fmt.Sprintf("Failed to read VLAN %d on fabric %d err=%v", vid, fabricID, err)
 		zap.L().Error(fmt.Sprintf("[ReadVlan] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(vlan)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ReadVlan] %s", errMsg))
@@ -142,7 +131,9 @@ func (ReadVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type UpdateVlan struct{}
+type UpdateVlan struct {
+	Client maas_client.Client
+}
 
 func (UpdateVlan) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -198,65 +189,53 @@ func (UpdateVlan) Create() mcp.Tool {
 	)
 }
 
-func (UpdateVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (u UpdateVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("fabric_id")
+	fabricID, err := request.RequireInt("fabric_id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[UpdateVlan] Required parameter fabric_id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	vid, err := request.RequireString("vid")
+	vid, err := request.RequireInt("vid")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[UpdateVlan] Required parameter vid not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	form := make(url.Values)
-
-	if name := request.GetString("name", ""); name != "" {
-		form.Add("name", name)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if mtu := request.GetString("mtu", ""); mtu != "" {
-		form.Add("mtu", mtu)
-	}
-	if space := request.GetString("space", ""); space != "" {
-		form.Add("space", space)
-	}
-	if primaryRack := request.GetString("primary_rack", ""); primaryRack != "" {
-		form.Add("primary_rack", primaryRack)
-	}
-	if secondaryRack := request.GetString("secondary_rack", ""); secondaryRack != "" {
-		form.Add("secondary_rack", secondaryRack)
-	}
-	if relayVlan := request.GetString("relay_vlan", ""); relayVlan != "" {
-		form.Add("relay_vlan", relayVlan)
+	mtu, err := tools.OptionalInt(request, "mtu")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateVlan] Invalid parameter mtu err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	dhcpOn := request.GetBool("dhcp_on", false)
-	if dhcpOn {
-		form.Add("dhcp_on", "1")
-	} else {
-		form.Add("dhcp_on", "0")
+	relayVLAN, err := tools.OptionalInt(request, "relay_vlan")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateVlan] Invalid parameter relay_vlan err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/vlans/%s/", fabricID, vid)
-
-	client := maas_client.MustClient()
+	params := maas_api.VLANParams{
+		Name:          request.GetString("name", ""),
+		Description:   request.GetString("description", ""),
+		MTU:           mtu,
+		Space:         request.GetString("space", ""),
+		DHCPOn:        tools.OptionalBool(request, "dhcp_on"),
+		PrimaryRack:   request.GetString("primary_rack", ""),
+		SecondaryRack: request.GetString("secondary_rack", ""),
+		RelayVLAN:     relayVLAN,
+	}
 
-	zap.L().Info(fmt.Sprintf("[UpdateVlan] Updating VLAN %s on fabric %s", vid, fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePut, path, strings.NewReader(form.Encode()))
+	zap.L().Info(fmt.Sprintf("[UpdateVlan] Updating VLAN %d on fabric %d", vid, fabricID))
+	vlan, err := maas_api.New(u.Client).UpdateVLAN(ctx, fabricID, vid, params)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to update VLAN %s on fabric %s err=%v", vid, fabricID, err)
+		errMsg = fmt.Sprintf("Failed to update VLAN %d on fabric %d err=%v", vid, fabricID, err)
 		zap.L().Error(fmt.Sprintf("[UpdateVlan] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(vlan)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[UpdateVlan] %s", errMsg))
@@ -4,29 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
 	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
 
 const NUMBER_PATTERN = "^[0-9]+$"
 
-type Vlans struct{}
+type Vlans struct {
+	Client maas_client.Client
+}
 
-func (Vlans) Register(mcpServer *server.MCPServer) {
-	mcpTools := []tools.MCPTool{ListVlans{}, CreateVlan{}}
+func (v Vlans) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListVlans{Client: v.Client}, CreateVlan{Client: v.Client}}
 
 	for _, tool := range mcpTools {
 		mcpServer.AddTool(tool.Create(), tool.Handle)
 	}
 }
 
-type ListVlans struct{}
+type ListVlans struct {
+	Client maas_client.Client
+}
 
 func (ListVlans) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -42,28 +45,24 @@ func (ListVlans) Create() mcp.Tool {
 	)
 }
 
-func (ListVlans) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (l ListVlans) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("fabric_id")
+	fabricID, err := request.RequireInt("fabric_id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[ListVlans] Required parameter fabric_id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/vlans/", fabricID)
-
-	client := maas_client.MustClient()
-
-	zap.L().Info(fmt.Sprintf("[ListVlans] Retrieving all VLANs for fabric ID: %s", fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypeGet, path, nil)
+	zap.L().Info(fmt.Sprintf("[ListVlans] Retrieving all VLANs for fabric ID: %d", fabricID))
+	vlans, err := maas_api.New(l.Client).ListVLANs(ctx, fabricID)
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to retrieve all the VLANs for fabric %s: %v", fabricID, err)
+		errMsg = fmt.Sprintf("Failed to retrieve all the VLANs for fabric %d: %v", fabricID, err)
 		zap.L().Error(fmt.Sprintf("[ListVlans] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(vlans)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[ListVlans] %s", errMsg))
@@ -73,7 +72,9 @@ func (ListVlans) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-type CreateVlan struct{}
+type CreateVlan struct {
+	Client maas_client.Client
+}
 
 func (CreateVlan) Create() mcp.Tool {
 	return mcp.NewTool(
@@ -112,50 +113,43 @@ func (CreateVlan) Create() mcp.Tool {
 	)
 }
 
-func (CreateVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (c CreateVlan) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var errMsg string
 
-	fabricID, err := request.RequireString("fabric_id")
+	fabricID, err := request.RequireInt("fabric_id")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[CreateVlan] Required parameter fabric_id not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	vid, err := request.RequireString("vid")
+	vid, err := request.RequireInt("vid")
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("[CreateVlan] Required parameter vid not present err=%v", err))
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	path := fmt.Sprintf("/MAAS/api/2.0/fabrics/%s/vlans/", fabricID)
-
-	form := make(url.Values)
-	form.Add("vid", vid)
-
-	if name := request.GetString("name", ""); name != "" {
-		form.Add("name", name)
-	}
-	if description := request.GetString("description", ""); description != "" {
-		form.Add("description", description)
-	}
-	if mtu := request.GetString("mtu", ""); mtu != "" {
-		form.Add("mtu", mtu)
-	}
-	if space := request.GetString("space", ""); space != "" {
-		form.Add("space", space)
+	mtu, err := tools.OptionalInt(request, "mtu")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateVlan] Invalid parameter mtu err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	client := maas_client.MustClient()
+	params := maas_api.VLANParams{
+		Name:        request.GetString("name", ""),
+		Description: request.GetString("description", ""),
+		MTU:         mtu,
+		Space:       request.GetString("space", ""),
+	}
 
-	zap.L().Info(fmt.Sprintf("[CreateVlan] Creating VLAN with VID %s on fabric %s", vid, fabricID))
-	resultData, err := client.Do(ctx, maas_client.RequestTypePost, path, strings.NewReader(form.Encode()))
+	zap.L().Info(fmt.Sprintf("[CreateVlan] Creating VLAN with VID %d on fabric %d", vid, fabricID))
+	vlan, err := maas_api.New(c.Client).CreateVLAN(ctx, fabricID, vid, params)
 	if err != nil {
 		errMsg = fmt.Sprintf("Failed to create VLAN err=%v", err)
 		zap.L().Error(fmt.Sprintf("[CreateVlan] %s", errMsg))
 		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	jsonData, err := json.Marshal(resultData)
+	jsonData, err := json.Marshal(vlan)
 	if err != nil {
 		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
 		zap.L().Error(fmt.Sprintf("[CreateVlan] %s", errMsg))
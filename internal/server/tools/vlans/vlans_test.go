@@ -0,0 +1,54 @@
+package vlans
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+)
+
+func TestVlanTools(t *testing.T) {
+	cases := []struct {
+		name      string
+		tool      string
+		arguments map[string]any
+		isError   bool
+		expected  []int
+	}{
+		{"list vlans", "list-vlans", map[string]any{}, false, []int{0, 10}},
+		{"create vlan", "create-vlan", map[string]any{"vid": "20", "name": "storage"}, false, []int{0, 10, 20}},
+		{"create duplicate vlan", "create-vlan", map[string]any{"vid": "10"}, true, []int{0, 10}},
+		{"read vlan", "read-vlan", map[string]any{"vid": "10"}, false, []int{0, 10}},
+		{"read unknown vlan", "read-vlan", map[string]any{"vid": "30"}, true, []int{0, 10}},
+		{"update vlan", "update-vlan", map[string]any{"vid": "10", "mtu": "9000"}, false, []int{0, 10}},
+		{"enable dhcp without a rack", "update-vlan", map[string]any{"vid": "10", "dhcp_on": true}, true, []int{0, 10}},
+		{"delete vlan", "delete-vlan", map[string]any{"vid": "10"}, false, []int{0}},
+		{"delete untagged vlan", "delete-vlan", map[string]any{"vid": "0"}, true, []int{0, 10}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fabric := fake.AddFabric(fakemaas.Fabric{})
+			fake.AddVLAN(fakemaas.VLAN{FabricID: fabric.ID, VID: 10})
+			tc.arguments["fabric_id"] = strconv.Itoa(fabric.ID)
+
+			// Act
+			result := fakemaas.CallTool(t, fakemaas.Handlers(Vlans{Client: fake.Client()}, Vlan{Client: fake.Client()})[tc.tool], tc.arguments)
+
+			// Assert
+			if result.IsError != tc.isError {
+				t.Errorf("expected IsError=%v, got %s", tc.isError, fakemaas.ResultText(t, result))
+			}
+			vids := []int{}
+			for _, v := range fake.VLANs() {
+				vids = append(vids, v.VID)
+			}
+			if !slices.Equal(vids, tc.expected) {
+				t.Errorf("expected vids %v, got %v", tc.expected, vids)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package zones
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Zone struct {
+	Client maas_client.Client
+}
+
+func (z Zone) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{DeleteZone{Client: z.Client}, ReadZone{Client: z.Client}, UpdateZone{Client: z.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type DeleteZone struct {
+	Client maas_client.Client
+}
+
+func (DeleteZone) Create() mcp.Tool {
+	return mcp.NewTool(
+		"delete-zone",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the zone to delete."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Delete Zone", false, true, false, true)),
+		mcp.WithDescription("Delete an availability zone. Its machines are moved to the default zone, so zones holding protected machines cannot be deleted."),
+	)
+}
+
+func (d DeleteZone) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[DeleteZone] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	api := maas_api.New(d.Client)
+
+	machines, err := api.ListMachines(ctx, maas_api.MachineFilter{Zone: name})
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve the machines of zone %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[DeleteZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	for _, machine := range machines {
+		if machine.Protected() {
+			errMsg = fmt.Sprintf("Zone %s holds protected machines and cannot be deleted", name)
+			zap.L().Error(fmt.Sprintf("[DeleteZone] %s", errMsg))
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	zap.L().Info(fmt.Sprintf("[DeleteZone] Deleting zone %s", name))
+	if err := api.DeleteZone(ctx, name); err != nil {
+		errMsg = fmt.Sprintf("Failed to delete zone %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[DeleteZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Zone %s deleted", name)), nil
+}
+
+type ReadZone struct {
+	Client maas_client.Client
+}
+
+func (ReadZone) Create() mcp.Tool {
+	return mcp.NewTool(
+		"read-zone",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the zone to retrieve."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Read Zone", true, false, false, true)),
+		mcp.WithDescription("Read an availability zone with the given name."),
+	)
+}
+
+func (r ReadZone) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[ReadZone] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[ReadZone] Retrieving zone %s", name))
+	zone, err := maas_api.New(r.Client).GetZone(ctx, name)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to read zone %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[ReadZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(zone)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ReadZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type UpdateZone struct {
+	Client maas_client.Client
+}
+
+func (UpdateZone) Create() mcp.Tool {
+	return mcp.NewTool(
+		"update-zone",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the zone to update."),
+		),
+		mcp.WithString(
+			"new_name",
+			mcp.Pattern(`^[\w-]+$`),
+			mcp.Description("The new name of the zone."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("Description of the zone."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Update Zone", false, false, false, true)),
+		mcp.WithDescription("Rename an availability zone or change its description."),
+	)
+}
+
+func (u UpdateZone) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[UpdateZone] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.ZoneParams{
+		Name:        request.GetString("new_name", ""),
+		Description: request.GetString("description", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[UpdateZone] Updating zone %s", name))
+	zone, err := maas_api.New(u.Client).UpdateZone(ctx, name, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to update zone %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[UpdateZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(zone)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[UpdateZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
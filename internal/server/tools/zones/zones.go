@@ -0,0 +1,187 @@
+package zones
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_api"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/maas_client"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/registry"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+type Zones struct {
+	Client maas_client.Client
+}
+
+func (z Zones) Register(mcpServer registry.ToolServer) {
+	mcpTools := []tools.MCPTool{ListZones{Client: z.Client}, CreateZone{Client: z.Client}, AssignMachinesToZone{Client: z.Client}}
+
+	for _, tool := range mcpTools {
+		mcpServer.AddTool(tool.Create(), tool.Handle)
+	}
+}
+
+type ListZones struct {
+	Client maas_client.Client
+}
+
+func (ListZones) Create() mcp.Tool {
+	return mcp.NewTool(
+		"list-zones",
+		mcp.WithInputSchema[struct{}](),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("List Zones", true, false, false, true)),
+		mcp.WithDescription("Return all the availability zones defined on the running instance of MAAS."),
+	)
+}
+
+func (l ListZones) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	zap.L().Info("[ListZones] Retrieving all zones...")
+	zones, err := maas_api.New(l.Client).ListZones(ctx)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve all the zones: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListZones] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(zones)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[ListZones] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type CreateZone struct {
+	Client maas_client.Client
+}
+
+func (CreateZone) Create() mcp.Tool {
+	return mcp.NewTool(
+		"create-zone",
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Pattern(`^[\w-]+$`),
+			mcp.Description("Name of the zone."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("Description of the zone."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Create Zone", false, false, false, true)),
+		mcp.WithDescription("Create a new availability zone on the running instance of MAAS."),
+	)
+}
+
+func (c CreateZone) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[CreateZone] Required parameter name not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := maas_api.ZoneParams{
+		Name:        name,
+		Description: request.GetString("description", ""),
+	}
+
+	zap.L().Info(fmt.Sprintf("[CreateZone] Creating zone %s...", name))
+	zone, err := maas_api.New(c.Client).CreateZone(ctx, params)
+	if err != nil {
+		errMsg = fmt.Sprintf("Failed to create zone %s err=%v", name, err)
+		zap.L().Error(fmt.Sprintf("[CreateZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	jsonData, err := json.Marshal(zone)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[CreateZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+type AssignMachinesToZone struct {
+	Client maas_client.Client
+}
+
+func (AssignMachinesToZone) Create() mcp.Tool {
+	return mcp.NewTool(
+		"assign-machines-to-zone",
+		mcp.WithString(
+			"zone",
+			mcp.Required(),
+			mcp.Description("The name of the zone to move the machines to."),
+		),
+		mcp.WithArray(
+			"ids",
+			mcp.WithStringItems(mcp.Pattern("^[0-9a-z]{6}$")),
+			mcp.Description("The ids of the machines to move. Either ids or tag is required."),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Description("Move the machines with this tag, protected machines excluded. Either ids or tag is required."),
+		),
+		mcp.WithToolAnnotation(tools.CreateToolAnnotation("Assign Machines to Zone", false, false, true, true)),
+		mcp.WithDescription("Move several machines to an availability zone and report for every machine whether it was moved. Protected machines are never moved."),
+	)
+}
+
+func (a AssignMachinesToZone) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var errMsg string
+
+	zoneName, err := request.RequireString("zone")
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToZone] Required parameter zone not present err=%v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ids := request.GetStringSlice("ids", nil)
+	tag := request.GetString("tag", "")
+	if len(ids) == 0 && tag == "" {
+		errMsg = "Either ids or tag is required"
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	api := maas_api.New(a.Client)
+
+	if _, err := api.GetZone(ctx, zoneName); err != nil {
+		errMsg = fmt.Sprintf("Failed to retrieve zone %s err=%v", zoneName, err)
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	zap.L().Info(fmt.Sprintf("[AssignMachinesToZone] Moving machines to zone %s...", zoneName))
+	moves, err := tools.MoveMachines(ctx, api, ids, tag, tools.MachineZone, zoneName, maas_api.MachineParams{Zone: zoneName})
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToZone] %v", err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result := tools.NewMoveResult(zoneName, moves)
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		errMsg = fmt.Sprintf("failed to marshal result: %v", err)
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToZone] %s", errMsg))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	if result.Failed() {
+		zap.L().Error(fmt.Sprintf("[AssignMachinesToZone] Some machines were not moved to zone %s", zoneName))
+		return mcp.NewToolResultError(string(jsonData)), nil
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,167 @@
+package zones
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/JarcauCristian/ztp-mcp/internal/server/fakemaas"
+	"github.com/JarcauCristian/ztp-mcp/internal/server/tools"
+)
+
+func TestCreateZone(t *testing.T) {
+	cases := []struct {
+		name          string
+		arguments     map[string]any
+		expectedError bool
+		expectedZones []string
+	}{
+		{"new zone", map[string]any{"name": "rack-b", "description": "Second rack"}, false, []string{"default", "rack-a", "rack-b"}},
+		{"existing zone", map[string]any{"name": "rack-a"}, true, []string{"default", "rack-a"}},
+		{"missing name", map[string]any{}, true, []string{"default", "rack-a"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddZone(fakemaas.Zone{Name: "default"})
+			fake.AddZone(fakemaas.Zone{Name: "rack-a"})
+
+			// Act
+			result := fakemaas.CallTool(t, CreateZone{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.expectedError {
+				t.Errorf("expected error=%v, got %s", tc.expectedError, fakemaas.ResultText(t, result))
+			}
+			var names []string
+			for _, zone := range fake.Zones() {
+				names = append(names, zone.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expectedZones) {
+				t.Errorf("expected zones %v, got %v", tc.expectedZones, names)
+			}
+		})
+	}
+}
+
+func TestAssignMachinesToZone(t *testing.T) {
+	cases := []struct {
+		name          string
+		arguments     map[string]any
+		expectedError bool
+		expected      map[string]string
+	}{
+		{
+			"by ids",
+			map[string]any{"zone": "rack-a", "ids": []any{"abc001", "abc002"}},
+			false,
+			map[string]string{"abc001": tools.MoveMoved, "abc002": tools.MoveUnchanged},
+		},
+		{
+			"by tag skips protected machines",
+			map[string]any{"zone": "rack-a", "tag": "web"},
+			false,
+			map[string]string{"abc001": tools.MoveMoved, "abc002": tools.MoveUnchanged},
+		},
+		{
+			"protected machine by id",
+			map[string]any{"zone": "rack-a", "ids": []any{"abc001", "abc003"}},
+			true,
+			map[string]string{"abc001": tools.MoveMoved, "abc003": tools.MoveRefused},
+		},
+		{
+			"unknown machine",
+			map[string]any{"zone": "rack-a", "ids": []any{"zzz999"}},
+			true,
+			map[string]string{"zzz999": tools.MoveFailed},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddZone(fakemaas.Zone{Name: "default"})
+			fake.AddZone(fakemaas.Zone{Name: "rack-a"})
+			fake.AddMachine(fakemaas.Machine{SystemID: "abc001", TagNames: []string{"web"}})
+			fake.AddMachine(fakemaas.Machine{SystemID: "abc002", Zone: "rack-a", TagNames: []string{"web"}})
+			fake.AddMachine(fakemaas.Machine{SystemID: "abc003", TagNames: []string{"web", "protected"}})
+
+			// Act
+			result := fakemaas.CallTool(t, AssignMachinesToZone{Client: fake.Client()}.Handle, tc.arguments)
+
+			// Assert
+			if result.IsError != tc.expectedError {
+				t.Errorf("expected error=%v, got %s", tc.expectedError, fakemaas.ResultText(t, result))
+			}
+			var moved tools.MoveResult
+			if err := json.Unmarshal([]byte(fakemaas.ResultText(t, result)), &moved); err != nil {
+				t.Fatalf("expected a move result, got %v", err)
+			}
+			results := map[string]string{}
+			for _, move := range moved.Machines {
+				results[move.SystemID] = move.Result
+			}
+			if !reflect.DeepEqual(results, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, results)
+			}
+			if protected, _ := fake.Machine("abc003"); protected.Zone != "default" {
+				t.Errorf("expected the protected machine to stay in default, got %s", protected.Zone)
+			}
+		})
+	}
+
+	t.Run("unknown zone", func(t *testing.T) {
+		// Arrange
+		fake := fakemaas.Start(t)
+		fake.AddZone(fakemaas.Zone{Name: "default"})
+		fake.AddMachine(fakemaas.Machine{SystemID: "abc001"})
+
+		// Act
+		result := fakemaas.CallTool(t, AssignMachinesToZone{Client: fake.Client()}.Handle, map[string]any{"zone": "rack-z", "ids": []any{"abc001"}})
+
+		// Assert
+		if !result.IsError {
+			t.Errorf("expected an error result, got %s", fakemaas.ResultText(t, result))
+		}
+	})
+}
+
+func TestDeleteZone(t *testing.T) {
+	cases := []struct {
+		name          string
+		protected     bool
+		expectedError bool
+		expectedZone  string
+	}{
+		{"moves machines to default", false, false, "default"},
+		{"holds protected machines", true, true, "rack-a"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			fake := fakemaas.Start(t)
+			fake.AddZone(fakemaas.Zone{Name: "default"})
+			fake.AddZone(fakemaas.Zone{Name: "rack-a"})
+			machine := fakemaas.Machine{SystemID: "abc001", Zone: "rack-a"}
+			if tc.protected {
+				machine.TagNames = []string{"protected"}
+			}
+			fake.AddMachine(machine)
+
+			// Act
+			result := fakemaas.CallTool(t, DeleteZone{Client: fake.Client()}.Handle, map[string]any{"name": "rack-a"})
+
+			// Assert
+			if result.IsError != tc.expectedError {
+				t.Errorf("expected error=%v, got %s", tc.expectedError, fakemaas.ResultText(t, result))
+			}
+			if stored, _ := fake.Machine("abc001"); stored.Zone != tc.expectedZone {
+				t.Errorf("expected the machine in zone %s, got %s", tc.expectedZone, stored.Zone)
+			}
+		})
+	}
+}